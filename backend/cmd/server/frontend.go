@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/config"
+	"r-panel/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loadFrontend returns the built frontend and where it came from: the
+// configured frontend_dir, a web/dist directory found by findFrontendDir, or
+// the copy embedded in the binary
+func loadFrontend(cfg *config.Config) (fs.FS, string, error) {
+	if dir := cfg.Server.FrontendDir; dir != "" {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid server.frontend_dir: %w", err)
+		}
+		if _, err := os.Stat(filepath.Join(absDir, "index.html")); err != nil {
+			return nil, "", fmt.Errorf("server.frontend_dir %s has no index.html: %w", absDir, err)
+		}
+		return os.DirFS(absDir), absDir, nil
+	}
+
+	if dir := findFrontendDir(); dir != "" {
+		return os.DirFS(dir), dir, nil
+	}
+	if embedded, ok := web.Embedded(); ok {
+		return embedded, "embedded in the binary", nil
+	}
+	return nil, "", fmt.Errorf("no web/dist found in ./web/dist, /usr/local/r-panel/web/dist or next to the binary; set server.frontend_dir or build with -tags embed_frontend")
+}
+
+// serveFrontend serves the assets of frontend and its index.html for every
+// other route that is not part of the API (SPA routing)
+func serveFrontend(r *gin.Engine, frontend fs.FS) {
+	if assets, err := fs.Sub(frontend, "assets"); err == nil {
+		r.StaticFS("/assets", http.FS(assets))
+	}
+	r.GET("/favicon.ico", func(c *gin.Context) {
+		c.FileFromFS("favicon.ico", http.FS(frontend))
+	})
+
+	// index.html is read per request so a rebuilt frontend shows up without a restart
+	serveIndex := func(c *gin.Context) {
+		index, err := fs.ReadFile(frontend, "index.html")
+		if err != nil {
+			apierror.Respond(c, 500, apierror.CodeInternal, apierror.Message("Frontend index.html is missing"))
+			return
+		}
+		c.Data(200, "text/html; charset=utf-8", index)
+	}
+
+	// Serve index.html for root and all non-API routes (SPA routing)
+	r.GET("/", serveIndex)
+
+	// Fallback to index.html for SPA routing (Vue Router)
+	r.NoRoute(func(c *gin.Context) {
+		path := c.Request.URL.Path
+
+		// Check if it's an API route
+		if len(path) >= 4 && path[:4] == "/api" {
+			apierror.Respond(c, 404, apierror.CodeNotFound, apierror.Message("API endpoint not found"))
+			return
+		}
+
+		serveIndex(c)
+	})
+}
@@ -3,23 +3,31 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"r-panel/internal/api/routes"
 	"r-panel/internal/config"
 	"r-panel/internal/models"
 	"r-panel/internal/services"
+	"r-panel/internal/startup"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/acme/autocert"
 )
 
+// shutdownTimeout is how long requests in flight get to finish on shutdown
+const shutdownTimeout = 30 * time.Second
+
 // findConfigFile searches for config.yaml in multiple locations
 func findConfigFile() string {
 	// List of possible config file locations (in order of priority)
@@ -107,6 +115,9 @@ func findFrontendDir() string {
 }
 
 func main() {
+	checkConfig := flag.Bool("check-config", false, "validate the configuration and exit")
+	flag.Parse()
+
 	// Find config file - try multiple locations
 	configPath := findConfigFile()
 	if configPath == "" {
@@ -128,15 +139,122 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Check paths, TLS, JWT and database settings up front; production refuses
+	// to start with a broken configuration
+	validationErr := config.Validate(cfg)
+	if *checkConfig {
+		if validationErr != nil {
+			fmt.Fprintln(os.Stderr, validationErr)
+			os.Exit(1)
+		}
+		fmt.Println("Configuration OK")
+		return
+	}
+	if validationErr != nil {
+		if cfg.Environment == "production" {
+			log.Fatalf("Refusing to start: %v", validationErr)
+		}
+		var problems *config.ValidationError
+		if errors.As(validationErr, &problems) {
+			for _, problem := range problems.Problems {
+				log.Printf("Warning: config: %s", problem)
+			}
+		}
+	}
+
 	// Initialize database
 	if err := models.InitDB(cfg); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	// Create default user if database is empty
+	// Load the customized nginx site and PHP-FPM pool templates
+	if err := services.LoadConfigTemplates(cfg.Paths.ConfigTemplatesDir()); err != nil {
+		log.Fatalf("Failed to load config templates: %v", err)
+	}
+
+	// Background workers and the server stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background work once the dependencies it needs are healthy
 	authService := services.NewAuthService(cfg)
-	if err := authService.CreateDefaultUser(); err != nil {
-		log.Printf("Warning: Failed to create default user: %v", err)
+	orchestrator := startup.New()
+	orchestrator.AddDependency("database", startup.DatabaseProbe())
+	orchestrator.AddDependency("nginx", startup.NginxProbe(services.NewSystemService()))
+	if cfg.Database.Type == "mysql" {
+		orchestrator.AddDependency("mysql", startup.MySQLProbe(cfg.Database.MySQL.DSN()))
+	}
+	orchestrator.AddWorker(startup.Worker{
+		Name:     "default user",
+		Requires: []string{"database"},
+		Start: func(ctx context.Context) {
+			// Create default user if database is empty
+			if err := authService.CreateDefaultUser(); err != nil {
+				log.Printf("Warning: Failed to create default user: %v", err)
+			}
+		},
+	})
+	orchestrator.AddWorker(startup.Worker{
+		Name:     "notification checks",
+		Requires: []string{"database"},
+		Start: func(ctx context.Context) {
+			// Config.Load has already validated the interval
+			interval, _ := cfg.Notifications.CheckIntervalDuration()
+			monitor := services.NewNotificationMonitor(
+				services.NewNotificationService(cfg),
+				services.NewMailService(services.NewMaildirStorage(cfg.Paths.MailStorage)),
+				cfg.Notifications,
+			)
+			go monitor.Run(ctx, interval)
+		},
+	})
+	orchestrator.AddWorker(startup.Worker{
+		Name:     "certificate expiry scan",
+		// Uploaded site certificates are nginx's, scanning is moot until it runs
+		Requires: []string{"database", "nginx"},
+		Start: func(ctx context.Context) {
+			scanner := services.NewCertExpiryScanner(
+				services.NewNotificationService(cfg),
+				services.NewWebhookService(cfg),
+				cfg,
+			)
+			go scanner.Run(ctx)
+		},
+	})
+	orchestrator.AddWorker(startup.Worker{
+		Name:     "service watcher",
+		Requires: []string{"database"},
+		Start: func(ctx context.Context) {
+			// Config.Load has already validated the interval
+			interval, _ := cfg.Webhooks.ServiceCheckIntervalDuration()
+			watcher := services.NewServiceWatcher(
+				services.NewSystemService(),
+				services.NewWebhookService(cfg),
+				services.MonitoredServices,
+			)
+			go watcher.Run(ctx, interval)
+		},
+	})
+	orchestrator.AddWorker(startup.Worker{
+		Name:     "session cleanup",
+		Requires: []string{"database"},
+		Start: func(ctx context.Context) {
+			// Config.Load has already validated the interval
+			interval, _ := cfg.Security.SessionCleanupIntervalDuration()
+			go authService.RunSessionCleanup(ctx, interval)
+		},
+	})
+	if cfg.Audit.RetentionDays > 0 {
+		orchestrator.AddWorker(startup.Worker{
+			Name:     "audit retention",
+			Requires: []string{"database"},
+			Start: func(ctx context.Context) {
+				go services.NewAuditService().RunRetention(ctx, cfg.Audit.RetentionDays)
+			},
+		})
+	}
+	if err := orchestrator.Start(ctx); err != nil {
+		log.Fatalf("Failed to start background workers: %v", err)
 	}
 
 	// Set Gin mode
@@ -150,51 +268,40 @@ func main() {
 	// Setup routes
 	routes.SetupRoutes(r, cfg)
 
-	// Find frontend directory - try multiple locations
-	frontendDir := findFrontendDir()
-	if frontendDir == "" {
-		log.Fatalf("Failed to find frontend directory (web/dist). Searched in:")
-		log.Fatalf("  - ./web/dist (current directory)")
-		log.Fatalf("  - /usr/local/r-panel/web/dist (installed location)")
+	// Frontend from frontend_dir, web/dist or the binary itself
+	frontend, frontendSource, err := loadFrontend(cfg)
+	if err != nil {
+		log.Fatalf("Failed to find frontend: %v", err)
 	}
-
-	r.Static("/assets", filepath.Join(frontendDir, "assets"))
-	r.StaticFile("/favicon.ico", filepath.Join(frontendDir, "favicon.ico"))
-
-	// Serve index.html for root and all non-API routes (SPA routing)
-	r.GET("/", func(c *gin.Context) {
-		c.File(filepath.Join(frontendDir, "index.html"))
-	})
-
-	// Fallback to index.html for SPA routing (Vue Router)
-	r.NoRoute(func(c *gin.Context) {
-		path := c.Request.URL.Path
-
-		// Check if it's an API route
-		if len(path) >= 4 && path[:4] == "/api" {
-			c.JSON(404, gin.H{"error": "API endpoint not found"})
-			return
-		}
-
-		// Serve index.html for SPA fallback
-		c.File(filepath.Join(frontendDir, "index.html"))
-	})
+	log.Printf("Serving frontend from %s", frontendSource)
+	serveFrontend(r, frontend)
 
 	// Create HTTP server
+	// No WriteTimeout here: routes.SetupRoutes sets write deadlines with middleware.WriteTimeout,
+	// on every route by default, so exports, imports and backups can lift theirs
 	srv := &http.Server{
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Handler:     r,
+		ReadTimeout: 15 * time.Second,
+		IdleTimeout: 60 * time.Second,
 	}
 
+	// Let requests in flight finish on shutdown; ListenAndServe returns at once
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		log.Printf("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Warning: shutdown: %v", err)
+		}
+	}()
+
 	// Configure TLS if enabled
 	if cfg.Server.TLS.Enabled && cfg.Server.TLS.Domain != "" {
 		// Setup cache directory
-		cacheDir := cfg.Server.TLS.CacheDir
-		if cacheDir == "" {
-			cacheDir = "./data/certs"
-		}
+		cacheDir := cfg.Server.TLS.CertCacheDir()
 
 		// Convert to absolute path
 		absCacheDir, err := filepath.Abs(cacheDir)
@@ -266,6 +373,7 @@ func main() {
 		if err := srv.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
+		<-shutdownDone
 	} else {
 		// No TLS - listen on configured host/port (typically localhost for reverse proxy)
 		addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -274,5 +382,6 @@ func main() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
+		<-shutdownDone
 	}
 }
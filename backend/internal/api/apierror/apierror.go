@@ -0,0 +1,118 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable error codes returned in API error responses
+const (
+	CodeBadRequest             = "BAD_REQUEST"
+	CodeValidationFailed       = "VALIDATION_FAILED"
+	CodeInvalidID              = "INVALID_ID"
+	CodeUnauthorized           = "UNAUTHORIZED"
+	CodeInvalidCredentials     = "INVALID_CREDENTIALS"
+	CodePasswordChangeRequired = "PASSWORD_CHANGE_REQUIRED"
+	CodeAccountDisabled        = "ACCOUNT_DISABLED"
+	CodeForbidden              = "FORBIDDEN"
+	CodeNotFound               = "NOT_FOUND"
+	CodeUserNotFound           = "USER_NOT_FOUND"
+	CodeUserExists             = "USER_EXISTS"
+	CodeClientNotFound         = "CLIENT_NOT_FOUND"
+	CodeClientExists           = "CLIENT_EXISTS"
+	CodeCustomerNoExists       = "CUSTOMER_NO_EXISTS"
+	CodeClientNotInTrash       = "CLIENT_NOT_IN_TRASH"
+	CodeClientModified         = "CLIENT_MODIFIED"
+	CodeSiteNotFound           = "SITE_NOT_FOUND"
+	CodeSiteExists             = "SITE_EXISTS"
+	CodePoolNotFound           = "POOL_NOT_FOUND"
+	CodeBackupNotFound         = "BACKUP_NOT_FOUND"
+	CodeBackupExists           = "BACKUP_EXISTS"
+	CodeBackupRunning          = "BACKUP_RUNNING"
+	CodeBackupNotRunning       = "BACKUP_NOT_RUNNING"
+	CodeInsufficientSpace      = "INSUFFICIENT_SPACE"
+	CodePayloadTooLarge        = "PAYLOAD_TOO_LARGE"
+	CodeDatabaseExists         = "DATABASE_EXISTS"
+	CodeDatabaseNotFound       = "DATABASE_NOT_FOUND"
+	CodeLimitExceeded          = "LIMIT_EXCEEDED"
+	CodeSMTPNotConfigured      = "SMTP_NOT_CONFIGURED"
+	CodeEmailFailed            = "EMAIL_SEND_FAILED"
+	CodeWebhookNotFound        = "WEBHOOK_NOT_FOUND"
+	CodeSnippetNotFound        = "SNIPPET_NOT_FOUND"
+	CodeSnippetExists          = "SNIPPET_EXISTS"
+	CodeTemplateNotFound       = "TEMPLATE_NOT_FOUND"
+	CodeTemplateExists         = "TEMPLATE_EXISTS"
+	CodeTemplateInUse          = "TEMPLATE_IN_USE"
+	CodeSetupCompleted         = "SETUP_COMPLETED"
+	CodeServiceUnavailable     = "SERVICE_UNAVAILABLE"
+	CodeMaintenance            = "MAINTENANCE"
+	CodeTimeout                = "REQUEST_TIMEOUT"
+	CodeInternal               = "INTERNAL_ERROR"
+)
+
+// APIError is the response body returned for every failed API request
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+	// Fields maps invalid request fields to what is wrong with them
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// FieldErrors is implemented by errors that know which request fields were
+// invalid; Respond adds them to the response
+type FieldErrors interface {
+	FieldErrors() map[string]string
+}
+
+func (e *APIError) Error() string {
+	if e.Details != "" {
+		return e.Message + ": " + e.Details
+	}
+	return e.Message
+}
+
+// Message returns an APIError carrying only a human-readable message
+func Message(message string) *APIError {
+	return &APIError{Message: message}
+}
+
+// Wrap returns an APIError with message as the summary and err as the details
+func Wrap(message string, err error) *APIError {
+	apiErr := &APIError{Message: message}
+	if err != nil {
+		apiErr.Details = err.Error()
+	}
+	return apiErr
+}
+
+// Respond writes an APIError response for err with the given status and code and aborts the request.
+// A server error after the request deadline passed is reported as 504, it was
+// most likely caused by the deadline killing the work.
+func Respond(c *gin.Context, status int, code string, err error) {
+	if status >= 500 && errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		status, code = http.StatusGatewayTimeout, CodeTimeout
+	}
+	apiErr := &APIError{Code: code}
+
+	var wrapped *APIError
+	switch {
+	case errors.As(err, &wrapped):
+		apiErr.Message = wrapped.Message
+		apiErr.Details = wrapped.Details
+		apiErr.Fields = wrapped.Fields
+	case err != nil:
+		apiErr.Message = err.Error()
+	default:
+		apiErr.Message = http.StatusText(status)
+	}
+	var fieldErrs FieldErrors
+	if errors.As(err, &fieldErrs) {
+		apiErr.Fields = fieldErrs.FieldErrors()
+	}
+
+	c.AbortWithStatusJSON(status, apiErr)
+}
@@ -0,0 +1,140 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report fields by their JSON name, the one the client sent
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+// jsonFieldName returns the name a struct field has in JSON
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// Invalid returns the APIError for a request body that failed to bind, with
+// the problem of each invalid field when binding got that far
+func Invalid(err error) *APIError {
+	apiErr := &APIError{Message: "Invalid request"}
+	fields := ValidationFields(err)
+	if len(fields) == 0 {
+		if err != nil {
+			apiErr.Details = err.Error()
+		}
+		return apiErr
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	problems := make([]string, len(names))
+	for i, name := range names {
+		problems[i] = name + ": " + fields[name]
+	}
+	apiErr.Details = strings.Join(problems, "; ")
+	apiErr.Fields = fields
+	return apiErr
+}
+
+// ValidationFields maps the fields err complains about to a readable message.
+// It understands validator errors and JSON values of the wrong type, and
+// returns nil for anything else.
+func ValidationFields(err error) map[string]string {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make(map[string]string, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields[fieldPath(fieldErr.Namespace())] = fieldMessage(fieldErr)
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return map[string]string{typeErr.Field: "must be " + jsonTypeName(typeErr.Type)}
+	}
+	return nil
+}
+
+// fieldPath drops the request struct from a validator namespace, leaving
+// "limits.limit_web" for a nested field
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// fieldMessage describes a failed validation tag
+func fieldMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min", "max":
+		bound := "at least"
+		if fieldErr.Tag() == "max" {
+			bound = "at most"
+		}
+		switch fieldErr.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("must be %s %s characters long", bound, fieldErr.Param())
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("must have %s %s items", bound, fieldErr.Param())
+		}
+		return fmt.Sprintf("must be %s %s", bound, fieldErr.Param())
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fieldErr.Param()), ", ")
+	}
+	if fieldErr.Param() != "" {
+		return fmt.Sprintf("failed the %s=%s check", fieldErr.Tag(), fieldErr.Param())
+	}
+	return fmt.Sprintf("failed the %s check", fieldErr.Tag())
+}
+
+// jsonTypeName names the JSON type a Go type is decoded from
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "a different type"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	}
+	return "a different type"
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+func NewAPIKeyHandler() *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: services.NewAPIKeyService(),
+	}
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=255"`
+	Scopes    []string   `json:"scopes"` // read, write, admin; read only when empty
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// GetAPIKeys returns the keys of the current user, or of every user for admins
+// asking with ?all=true
+// @Summary     Returns the keys of the current user, or of every user for admins asking with ?all=true
+// @ID          apiKeyGetAPIKeys
+// @Tags        apikeys
+// @Produce     json
+// @Param       all query string false "all"
+// @Success     200 {object} map[string]interface{} "OK, with api_keys"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/apikeys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	userID := user.ID
+	if c.Query("all") == "true" && user.Role == models.RoleAdmin {
+		userID = 0
+	}
+
+	keys, err := h.apiKeyService.GetAPIKeys(userID)
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get API keys", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"api_keys": keys})
+}
+
+// CreateAPIKey creates a key for the current user. The key is only returned here.
+// @Summary     Creates a key for the current user
+// @Description Creates a key for the current user. The key is only returned here.
+// @ID          apiKeyCreateAPIKey
+// @Tags        apikeys
+// @Accept      json
+// @Produce     json
+// @Param       request body CreateAPIKeyRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created, with api_key, key, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/apikeys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	// A leaked key must not be able to mint more keys
+	if _, ok := c.Get("api_key"); ok {
+		respondError(c, 403, apierror.CodeForbidden, apierror.Message("API keys can only be created from a login session"))
+		return
+	}
+	// Nor may an impersonation token leave behind a key that outlives it
+	if _, ok := c.Get("impersonated_by"); ok {
+		respondError(c, 403, apierror.CodeForbidden, apierror.Message("API keys cannot be created while impersonating a user"))
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	apiKey, key, err := h.apiKeyService.CreateAPIKey(user, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIKeyScope) || errors.Is(err, services.ErrInvalidAPIKey) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		} else {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to create API key", err))
+		}
+		return
+	}
+
+	logAudit(c, user.ID, "create", "apikey", strconv.FormatUint(uint64(apiKey.ID), 10), fmt.Sprintf("name=%s scopes=%v", apiKey.Name, apiKey.Scopes))
+	c.JSON(201, gin.H{
+		"api_key": apiKey,
+		"key":     key,
+		"message": "Store the key now, it is not shown again",
+	})
+}
+
+// RevokeAPIKey deletes a key of the current user, or any key for admins
+// @Summary     Deletes a key of the current user, or any key for admins
+// @ID          apiKeyRevokeAPIKey
+// @Tags        apikeys
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/apikeys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	// A leaked key must not be able to revoke the keys its owner relies on
+	if _, ok := c.Get("api_key"); ok {
+		respondError(c, 403, apierror.CodeForbidden, apierror.Message("API keys can only be revoked from a login session"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid API key ID"))
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	if err := h.apiKeyService.RevokeAPIKey(uint(id), user); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			respondError(c, 404, apierror.CodeNotFound, err)
+		} else {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to revoke API key", err))
+		}
+		return
+	}
+
+	logAudit(c, user.ID, "delete", "apikey", strconv.FormatUint(id, 10), "")
+	c.JSON(200, gin.H{"message": "API key revoked"})
+}
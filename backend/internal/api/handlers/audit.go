@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"time"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{
+		auditService: services.NewAuditService(),
+	}
+}
+
+// parseAuditCutoff accepts a date (2006-01-02, midnight UTC) or an RFC 3339 timestamp
+func parseAuditCutoff(value string) (time.Time, bool) {
+	if cutoff, err := time.Parse("2006-01-02", value); err == nil {
+		return cutoff, true
+	}
+	if cutoff, err := time.Parse(time.RFC3339, value); err == nil {
+		return cutoff, true
+	}
+	return time.Time{}, false
+}
+
+// PruneAuditLogs deletes audit log entries created before ?before=<date>
+// @Summary     Deletes audit log entries created before ?before=<date>
+// @ID          auditPruneAuditLogs
+// @Tags        audit
+// @Produce     json
+// @Param       before query string false "before"
+// @Success     200 {object} map[string]interface{} "OK, with before, deleted, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/audit [delete]
+func (h *AuditHandler) PruneAuditLogs(c *gin.Context) {
+	before := c.Query("before")
+	if before == "" {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Message("before is required, e.g. ?before=2024-01-31"))
+		return
+	}
+	cutoff, ok := parseAuditCutoff(before)
+	if !ok {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Message("Invalid before date. Use YYYY-MM-DD or RFC 3339"))
+		return
+	}
+
+	deleted, err := h.auditService.PruneBefore(cutoff)
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to prune audit logs", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Audit logs pruned", "deleted": deleted, "before": cutoff})
+}
+
+// logAudit logs an audit entry, attributed to the impersonating admin if there is one
+func logAudit(c *gin.Context, userID uint, action, resource, resourceID, details string) {
+	auditLog := &models.AuditLog{
+		UserID:     userID,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Details:    details,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.GetHeader("User-Agent"),
+	}
+	if adminID, ok := c.Get("impersonated_by"); ok {
+		id := adminID.(uint)
+		auditLog.ImpersonatedBy = &id
+	}
+	models.DB.Create(auditLog)
+}
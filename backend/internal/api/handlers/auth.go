@@ -1,24 +1,32 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"r-panel/internal/api/apierror"
 	"r-panel/internal/config"
 	"r-panel/internal/models"
 	"r-panel/internal/services"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
-	cfg         *config.Config
+	authService   *services.AuthService
+	jwtService    *services.JWTService
+	clientService *services.ClientService
+	cfg           *config.Config
 }
 
-func NewAuthHandler(authService *services.AuthService, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, jwtService *services.JWTService, cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		cfg:         cfg,
+		authService:   authService,
+		jwtService:    jwtService,
+		clientService: services.NewClientService(cfg),
+		cfg:           cfg,
 	}
 }
 
@@ -27,41 +35,123 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type ChangeInitialPasswordRequest struct {
+	Username        string `json:"username" binding:"required"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
 type LoginResponse struct {
 	Token string       `json:"token"`
 	User  *models.User `json:"user"`
 }
 
+type ImpersonationResponse struct {
+	Token          string       `json:"token"`
+	User           *models.User `json:"user"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	ImpersonatedBy uint         `json:"impersonated_by"`
+}
+
 // Login handles user login
+// @Summary     Handles user login
+// @ID          authLogin
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body LoginRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code INVALID_CREDENTIALS"
+// @Failure     403 {object} apierror.APIError "Forbidden, code ACCOUNT_DISABLED or PASSWORD_CHANGE_REQUIRED"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Router      /api/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
 	// Authenticate user
 	user, err := h.authService.Authenticate(req.Username, req.Password)
+	if errors.Is(err, services.ErrAccountDisabled) {
+		respondError(c, 403, apierror.CodeAccountDisabled, apierror.Message("Account is disabled"))
+		return
+	}
+	if err != nil {
+		respondError(c, 401, apierror.CodeInvalidCredentials, apierror.Message("Invalid credentials"))
+		return
+	}
+
+	// No token until a default or reset password has been replaced
+	if user.MustChangePassword {
+		respondError(c, 403, apierror.CodePasswordChangeRequired,
+			apierror.Message("Password must be changed before logging in"))
+		return
+	}
+
+	h.issueToken(c, user)
+}
+
+// ChangeInitialPassword replaces a password that must be changed and logs the user in
+// @Summary     Replaces a password that must be changed and logs the user in
+// @ID          authChangeInitialPassword
+// @Tags        auth
+// @Accept      json
+// @Produce     json
+// @Param       request body ChangeInitialPasswordRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code INVALID_CREDENTIALS"
+// @Failure     403 {object} apierror.APIError "Forbidden, code ACCOUNT_DISABLED or FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Router      /api/auth/change-password [post]
+func (h *AuthHandler) ChangeInitialPassword(c *gin.Context) {
+	var req ChangeInitialPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	user, err := h.authService.ChangeInitialPassword(req.Username, req.CurrentPassword, req.NewPassword)
 	if err != nil {
-		c.JSON(401, gin.H{"error": "Invalid credentials"})
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			respondError(c, 401, apierror.CodeInvalidCredentials, apierror.Message("Invalid credentials"))
+		case errors.Is(err, services.ErrAccountDisabled):
+			respondError(c, 403, apierror.CodeAccountDisabled, apierror.Message("Account is disabled"))
+		case errors.Is(err, services.ErrPasswordChangeNotRequired):
+			respondError(c, 403, apierror.CodeForbidden, err)
+		case errors.Is(err, services.ErrWeakPassword):
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to change password", err))
+		}
 		return
 	}
 
+	logAudit(c, user.ID, "change_password", "user", strconv.FormatUint(uint64(user.ID), 10), "")
+	h.issueToken(c, user)
+}
+
+// issueToken creates a session for user and responds with its token
+func (h *AuthHandler) issueToken(c *gin.Context, user *models.User) {
 	// Generate JWT token
-	token, expiresAt, err := h.generateToken(user)
+	token, expiresAt, err := h.jwtService.GenerateToken(user)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to generate token"})
+		respondError(c, 500, apierror.CodeInternal, apierror.Message("Failed to generate token"))
 		return
 	}
 
 	// Create session
 	if err := h.authService.CreateSession(user.ID, token, expiresAt); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create session"})
+		respondError(c, 500, apierror.CodeInternal, apierror.Message("Failed to create session"))
 		return
 	}
 
 	// Log audit
-	h.logAudit(user.ID, "login", "", "", c.ClientIP(), c.GetHeader("User-Agent"))
+	logAudit(c, user.ID, "login", "", "", "")
 
 	c.JSON(200, LoginResponse{
 		Token: token,
@@ -70,31 +160,50 @@ func (h *AuthHandler) Login(c *gin.Context) {
 }
 
 // Logout handles user logout
+// @Summary     Handles user logout
+// @ID          authLogout
+// @Tags        auth
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
 	session, exists := c.Get("session")
 	if !exists {
-		c.JSON(401, gin.H{"error": "Not authenticated"})
+		respondError(c, 401, apierror.CodeUnauthorized, apierror.Message("Not authenticated"))
 		return
 	}
 
 	sess := session.(*models.Session)
 	if err := h.authService.DeleteSession(sess.Token); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to logout"})
+		respondError(c, 500, apierror.CodeInternal, apierror.Message("Failed to logout"))
 		return
 	}
 
 	user, _ := c.Get("user")
 	u := user.(*models.User)
-	h.logAudit(u.ID, "logout", "", "", c.ClientIP(), c.GetHeader("User-Agent"))
+	logAudit(c, u.ID, "logout", "", "", "")
 
 	c.JSON(200, gin.H{"message": "Logged out successfully"})
 }
 
 // GetMe returns current user information
+// @Summary     Returns current user information
+// @ID          authGetMe
+// @Tags        auth
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/auth/me [get]
 func (h *AuthHandler) GetMe(c *gin.Context) {
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(401, gin.H{"error": "Not authenticated"})
+		respondError(c, 401, apierror.CodeUnauthorized, apierror.Message("Not authenticated"))
 		return
 	}
 
@@ -104,49 +213,105 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 	c.JSON(200, u)
 }
 
-// generateToken generates a JWT token for the user
-func (h *AuthHandler) generateToken(user *models.User) (string, time.Time, error) {
-	// Parse expires_in duration
-	expiresIn, err := time.ParseDuration(h.cfg.JWT.ExpiresIn)
+// ImpersonateClient issues a short-lived token acting as the client's user
+// @Summary     Issues a short-lived token acting as the client's user
+// @ID          authImpersonateClient
+// @Tags        clients
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     201 {object} map[string]interface{} "Created"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/impersonate [post]
+func (h *AuthHandler) ImpersonateClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		expiresIn = 24 * time.Hour // Default to 24 hours
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid client ID"))
+		return
 	}
 
-	expiresAt := time.Now().Add(expiresIn)
-
-	// Get JWT secret
-	secret := h.cfg.JWT.Secret
-	if secret == "" {
-		secret = "r-panel-default-secret-change-in-production"
+	client, err := h.clientService.GetClient(uint(id))
+	if err != nil {
+		respondError(c, 404, errorCode(err, apierror.CodeClientNotFound), err)
+		return
 	}
 
-	claims := jwt.MapClaims{
-		"user_id":  user.ID,
-		"username": user.Username,
-		"role":     user.Role,
-		"exp":      expiresAt.Unix(),
-		"iat":      time.Now().Unix(),
-		"iss":      h.cfg.JWT.Issuer,
+	// Impersonation sessions hang off the admin's session, API keys have none
+	current, ok := c.Get("session")
+	if !ok {
+		respondError(c, 403, apierror.CodeForbidden, apierror.Message("Impersonation requires a login session"))
+		return
+	}
+	adminSession := current.(*models.Session)
+	admin := c.MustGet("user").(*models.User)
+	target := &client.User
+	if err := h.authService.CheckImpersonation(adminSession, target); err != nil {
+		respondError(c, 403, apierror.CodeForbidden, err)
+		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secret))
+	// Never outlive the admin session the impersonation was started from
+	ttl := services.ImpersonationTTL
+	if remaining := time.Until(adminSession.ExpiresAt); remaining < ttl {
+		ttl = remaining
+	}
+	token, expiresAt, err := h.jwtService.GenerateImpersonationToken(target, admin, ttl)
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Message("Failed to generate token"))
+		return
+	}
+	session, err := h.authService.CreateImpersonationSession(adminSession, target, token, expiresAt)
 	if err != nil {
-		return "", time.Time{}, err
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to create session", err))
+		return
 	}
 
-	return tokenString, expiresAt, nil
+	logAudit(c, admin.ID, "impersonate", "client", strconv.FormatUint(id, 10),
+		fmt.Sprintf("user_id=%d username=%s", target.ID, target.Username))
+
+	c.JSON(201, ImpersonationResponse{
+		Token:          token,
+		User:           target,
+		ExpiresAt:      session.ExpiresAt,
+		ImpersonatedBy: admin.ID,
+	})
 }
 
-// logAudit logs an audit entry
-func (h *AuthHandler) logAudit(userID uint, action, resource, resourceID, ipAddress, userAgent string) {
-	auditLog := &models.AuditLog{
-		UserID:     userID,
-		Action:     action,
-		Resource:   resource,
-		ResourceID: resourceID,
-		IPAddress:  ipAddress,
-		UserAgent:  userAgent,
-	}
-	models.DB.Create(auditLog)
+// StopImpersonation ends the current impersonation session. The admin's own
+// token is not returned, the client switches back to the one it kept.
+// @Summary     Ends the current impersonation session
+// @ID          authStopImpersonation
+// @Tags        auth
+// @Produce     json
+// @Success     204 "No Content"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/auth/stop-impersonation [post]
+func (h *AuthHandler) StopImpersonation(c *gin.Context) {
+	current, ok := c.Get("session")
+	if !ok {
+		respondError(c, 400, apierror.CodeBadRequest, services.ErrNotImpersonating)
+		return
+	}
+	session := current.(*models.Session)
+
+	if err := h.authService.StopImpersonation(session); err != nil {
+		if errors.Is(err, services.ErrNotImpersonating) {
+			respondError(c, 400, apierror.CodeBadRequest, err)
+		} else {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to stop impersonation", err))
+		}
+		return
+	}
+
+	logAudit(c, *session.ImpersonatedBy, "stop_impersonation", "user", strconv.FormatUint(uint64(session.UserID), 10), "")
+	c.Status(204)
 }
@@ -1,19 +1,48 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"r-panel/internal/api/apierror"
 	"r-panel/internal/config"
+	"r-panel/internal/models"
 	"r-panel/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type BackupHandler struct {
-	backupService *services.BackupService
+	backupService       *services.BackupService
+	notificationService *services.NotificationService
+	webhookService      *services.WebhookService
+	maxUploadSize       int64
+	compression         int
+	downloadLimit       int64 // bytes per second, 0 for none
 }
 
-func NewBackupHandler(cfg *config.Config) *BackupHandler {
+// NewBackupHandler dumps databases on the primary server of mysqlHandler, which
+// is nil when MySQL is not configured
+func NewBackupHandler(cfg *config.Config, mysqlHandler *MySQLHandler) *BackupHandler {
+	// Load has already rejected an invalid level
+	compression, _ := cfg.Backup.Compression()
+	backupService := services.NewBackupService(cfg.Paths.Backups, cfg.Backup.FreeSpaceMargin())
+	if mysqlHandler != nil {
+		backupService.SetMySQLServers(mysqlHandler.servers)
+	}
 	return &BackupHandler{
-		backupService: services.NewBackupService(cfg.Paths.Backups),
+		backupService:       backupService,
+		notificationService: services.NewNotificationService(cfg),
+		webhookService:      services.NewWebhookService(cfg),
+		maxUploadSize:       cfg.Backup.MaxUploadBytes(),
+		compression:         compression,
+		downloadLimit:       cfg.Backup.DownloadLimit(),
 	}
 }
 
@@ -21,6 +50,10 @@ type CreateBackupRequest struct {
 	Type       string `json:"type" binding:"required"` // file or database
 	Source     string `json:"source" binding:"required"`
 	BackupName string `json:"backup_name"`
+	// CompressionLevel overrides backup.compression_level: 0 to 9 or none
+	CompressionLevel string `json:"compression_level"`
+	// Wait responds once the backup has finished instead of right after it started
+	Wait bool `json:"wait"`
 }
 
 type RestoreBackupRequest struct {
@@ -28,52 +61,260 @@ type RestoreBackupRequest struct {
 	TargetPath string `json:"target_path"`
 }
 
-// GetBackups returns list of backup files
+// GetBackups returns the backup files, newest first, filtered by ?type=, ordered by ?sort= and ?order= and paged
+// when ?page= or ?limit= is given
+// @Summary     Returns the backup files, newest first, filtered by ?type=, ordered by ?sort= and ?order= and paged when ?page= or ?limit= is given
+// @ID          backupGetBackups
+// @Tags        backups
+// @Produce     json
+// @Param       limit query string false "limit"
+// @Param       order query string false "order"
+// @Param       page query string false "page"
+// @Param       sort query string false "sort"
+// @Param       type query string false "type"
+// @Success     200 {object} map[string]interface{} "OK, with backups, limit, page, total, total_pages"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/backups [get]
 func (h *BackupHandler) GetBackups(c *gin.Context) {
-	backups, err := h.backupService.ListBackups()
+	opts := services.BackupListOptions{
+		Sort:  c.Query("sort"),
+		Order: c.Query("order"),
+		Type:  c.Query("type"),
+	}
+	paginated := c.Query("page") != "" || c.Query("limit") != ""
+	if paginated {
+		opts.Page, opts.Limit = 1, 15
+		if page, err := strconv.Atoi(c.Query("page")); err == nil && page > 0 {
+			opts.Page = page
+		}
+		if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+			opts.Limit = limit
+		}
+	}
+
+	result, err := h.backupService.ListBackupsFiltered(opts)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to list backups", "details": err.Error()})
+		if errors.Is(err, services.ErrInvalidBackupListOptions) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		} else {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to list backups", err))
+		}
 		return
 	}
 
-	c.JSON(200, gin.H{"backups": backups})
+	if !paginated {
+		c.JSON(200, gin.H{"backups": result.Data})
+		return
+	}
+	c.JSON(200, gin.H{
+		"backups":     result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"limit":       result.Limit,
+		"total_pages": result.TotalPages,
+	})
 }
 
 // CreateBackup creates a new backup
+// @Summary     Creates a new backup
+// @ID          backupCreateBackup
+// @Tags        backups
+// @Accept      json
+// @Produce     json
+// @Param       request body CreateBackupRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created, with compression, job, message, path"
+// @Success     202 {object} map[string]interface{} "Accepted, with job, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     409 {object} apierror.APIError "Conflict, code BACKUP_RUNNING"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Failure     507 {object} apierror.APIError "Insufficient Storage, code INSUFFICIENT_SPACE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/backups [post]
 func (h *BackupHandler) CreateBackup(c *gin.Context) {
 	var req CreateBackupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	level := h.compression
+	if req.CompressionLevel != "" {
+		var err error
+		if level, err = config.ParseCompressionLevel(req.CompressionLevel); err != nil {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+			return
+		}
+	}
+
+	job, err := h.backupService.StartBackup(services.BackupRequest{
+		Type:   req.Type,
+		Source: req.Source,
+		Name:   req.BackupName,
+		Level:  level,
+	}, h.backupFinished)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidBackupType):
+			respondError(c, 400, apierror.CodeBadRequest, apierror.Message("Invalid backup type. Use 'file' or 'database'"))
+		case errors.Is(err, services.ErrInvalidBackupName) || errors.Is(err, services.ErrUnsafeDatabaseName):
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		case errors.Is(err, services.ErrMySQLNotConfigured):
+			respondError(c, 503, apierror.CodeServiceUnavailable, err)
+		case errors.Is(err, services.ErrBackupRunning):
+			respondError(c, 409, apierror.CodeBackupRunning, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to create backup", err))
+		}
 		return
 	}
 
-	var backupPath string
-	var err error
+	if !req.Wait {
+		c.JSON(202, gin.H{"message": "Backup started", "job": job})
+		return
+	}
 
-	switch req.Type {
-	case "file":
-		backupPath, err = h.backupService.CreateFileBackup(req.Source, req.BackupName)
-	case "database":
-		backupPath, err = h.backupService.CreateDatabaseBackup(req.Source, req.BackupName)
-	default:
-		c.JSON(400, gin.H{"error": "Invalid backup type. Use 'file' or 'database'"})
+	job, err = h.backupService.WaitBackup(job.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrInsufficientSpace) {
+			respondError(c, 507, apierror.CodeInsufficientSpace, err)
+		} else {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to create backup", err))
+		}
 		return
 	}
 
+	c.JSON(201, gin.H{
+		"message":     "Backup created successfully",
+		"path":        job.Path,
+		"compression": job.Compression,
+		"job":         job,
+	})
+}
+
+// backupFinished notifies about a backup started by CreateBackup once it is done
+func (h *BackupHandler) backupFinished(job services.BackupJobStatus, err error) {
+	// Whoever canceled it already knows
+	if errors.Is(err, services.ErrBackupCanceled) {
+		return
+	}
+	if err != nil {
+		h.notificationService.BackupFailed(job.Type+" "+job.Source, err)
+		return
+	}
+	h.webhookService.Dispatch(services.WebhookBackupCompleted, services.BackupEvent{
+		Type:   job.Type,
+		Source: job.Source,
+		Path:   job.Path,
+	})
+}
+
+// GetRunningBackups returns the backups that are in progress
+// @Summary     Returns the backups that are in progress
+// @ID          backupGetRunningBackups
+// @Tags        backups
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with backups"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/backups/running [get]
+func (h *BackupHandler) GetRunningBackups(c *gin.Context) {
+	c.JSON(200, gin.H{"backups": h.backupService.RunningBackups()})
+}
+
+// GetBackupJob returns a running or recently finished backup job
+// @Summary     Returns a running or recently finished backup job
+// @ID          backupGetBackupJob
+// @Tags        backups
+// @Produce     json
+// @Param       job path string true "job"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/backups/running/{job} [get]
+func (h *BackupHandler) GetBackupJob(c *gin.Context) {
+	job, err := h.backupService.GetBackupJob(c.Param("job"))
+	if err != nil {
+		if errors.Is(err, services.ErrBackupJobNotFound) {
+			respondError(c, 404, apierror.CodeNotFound, err)
+		} else {
+			respondError(c, 500, apierror.CodeInternal, err)
+		}
+		return
+	}
+
+	c.JSON(200, job)
+}
+
+// CancelBackup stops a running backup job and removes its partial file
+// @Summary     Stops a running backup job and removes its partial file
+// @ID          backupCancelBackup
+// @Tags        backups
+// @Produce     json
+// @Param       job path string true "job"
+// @Success     200 {object} map[string]interface{} "OK, with job, message"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code BACKUP_NOT_RUNNING"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/backups/running/{job} [delete]
+func (h *BackupHandler) CancelBackup(c *gin.Context) {
+	job, err := h.backupService.CancelBackup(c.Param("job"))
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create backup", "details": err.Error()})
+		switch {
+		case errors.Is(err, services.ErrBackupJobNotFound):
+			respondError(c, 404, apierror.CodeNotFound, err)
+		case errors.Is(err, services.ErrBackupNotRunning):
+			respondError(c, 409, apierror.CodeBackupNotRunning, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to cancel backup", err))
+		}
 		return
 	}
 
-	c.JSON(201, gin.H{"message": "Backup created successfully", "path": backupPath})
+	user := c.MustGet("user").(*models.User)
+	logAudit(c, user.ID, "cancel", "backup", job.ID, job.Type+" "+job.Source)
+
+	c.JSON(200, gin.H{"message": "Backup canceled", "job": job})
 }
 
 // DeleteBackup deletes a backup
+// @Summary     Deletes a backup
+// @ID          backupDeleteBackup
+// @Tags        backups
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code BACKUP_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/backups/{id} [delete]
 func (h *BackupHandler) DeleteBackup(c *gin.Context) {
 	backupName := c.Param("id")
 
 	if err := h.backupService.DeleteBackup(backupName); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondBackupLookupError(c, err)
 		return
 	}
 
@@ -81,52 +322,196 @@ func (h *BackupHandler) DeleteBackup(c *gin.Context) {
 }
 
 // RestoreBackup restores a backup
+// @Summary     Restores a backup
+// @ID          backupRestoreBackup
+// @Tags        backups
+// @Accept      json
+// @Produce     json
+// @Param       request body RestoreBackupRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code BACKUP_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/backups/restore [post]
 func (h *BackupHandler) RestoreBackup(c *gin.Context) {
 	var req RestoreBackupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
-	backups, err := h.backupService.ListBackups()
+	backup, err := h.backupService.FindBackup(req.BackupName)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to list backups"})
-		return
-	}
-
-	var backupPath string
-	for _, backup := range backups {
-		if backup.Name == req.BackupName {
-			backupPath = backup.Path
-			break
-		}
-	}
-
-	if backupPath == "" {
-		c.JSON(404, gin.H{"error": "Backup not found"})
+		respondBackupLookupError(c, err)
 		return
 	}
+	backupPath := backup.Path
 
 	// Determine backup type
 	backupType := "file"
-	if backupPath[len(backupPath)-7:] == ".sql.gz" || backupPath[len(backupPath)-4:] == ".sql" {
+	if strings.HasSuffix(backupPath, ".sql.gz") || strings.HasSuffix(backupPath, ".sql") {
 		backupType = "database"
 	}
 
 	if backupType == "file" {
 		if req.TargetPath == "" {
-			c.JSON(400, gin.H{"error": "target_path is required for file backups"})
+			respondError(c, 400, apierror.CodeBadRequest, apierror.Message("target_path is required for file backups"))
 			return
 		}
 
 		if err := h.backupService.RestoreFileBackup(backupPath, req.TargetPath); err != nil {
-			c.JSON(500, gin.H{"error": "Failed to restore backup", "details": err.Error()})
+			if errors.Is(err, services.ErrUnsafeBackupEntry) {
+				respondError(c, 400, apierror.CodeBadRequest, apierror.Wrap("Refusing to restore backup", err))
+			} else {
+				respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to restore backup", err))
+			}
 			return
 		}
 	} else {
-		c.JSON(400, gin.H{"error": "Database restore not implemented yet"})
+		respondError(c, 400, apierror.CodeBadRequest, apierror.Message("Database restore not implemented yet"))
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Backup restored successfully"})
 }
+
+// DownloadBackup streams a backup file as an attachment, resumable with Range
+// requests and capped at backup.download_limit_kbps
+// @Summary     Streams a backup file as an attachment, resumable with Range requests and capped at backup.download_limit_kbps
+// @ID          backupDownloadBackup
+// @Tags        backups
+// @Produce     octet-stream
+// @Param       id path int true "id"
+// @Param       limit_kbps query string false "limit_kbps"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code BACKUP_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/backups/{id}/download [get]
+func (h *BackupHandler) DownloadBackup(c *gin.Context) {
+	backup, err := h.backupService.FindBackup(c.Param("id"))
+	if err != nil {
+		respondBackupLookupError(c, err)
+		return
+	}
+
+	file, err := os.Open(backup.Path)
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to open backup", err))
+		return
+	}
+	defer file.Close()
+
+	// ?limit_kbps= may lower backup.download_limit_kbps, never raise it
+	limit := h.downloadLimit
+	if kbps, err := strconv.ParseInt(c.Query("limit_kbps"), 10, 64); err == nil && kbps > 0 {
+		if requested := kbps << 10; limit == 0 || requested < limit {
+			limit = requested
+		}
+	}
+
+	// ServeContent answers Range and If-Range requests, so broken downloads resume
+	c.Header("Content-Type", backupContentType(backup.Name))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": backup.Name}))
+	http.ServeContent(c.Writer, c.Request, backup.Name, backup.CreatedAt, services.NewThrottledReadSeeker(file, limit))
+}
+
+// UploadBackup stores the multipart "file" field in the backups directory
+// @Summary     Stores the multipart "file" field in the backups directory
+// @ID          backupUploadBackup
+// @Tags        backups
+// @Produce     json
+// @Success     201 {object} map[string]interface{} "Created, with backup, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     409 {object} apierror.APIError "Conflict, code BACKUP_EXISTS"
+// @Failure     413 {object} apierror.APIError "Request Entity Too Large, code PAYLOAD_TOO_LARGE"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/backups/upload [post]
+func (h *BackupHandler) UploadBackup(c *gin.Context) {
+	// Allow some room for the multipart framing around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadSize+1<<20)
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		respondError(c, 400, apierror.CodeBadRequest, apierror.Wrap("Expected a multipart/form-data upload", err))
+		return
+	}
+
+	// Stream the file part straight to disk instead of buffering the whole form
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			respondUploadError(c, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		backup, err := h.backupService.SaveBackup(part.FileName(), part, h.maxUploadSize)
+		part.Close()
+		if err != nil {
+			respondUploadError(c, err)
+			return
+		}
+
+		c.JSON(201, gin.H{"message": "Backup uploaded successfully", "backup": backup})
+		return
+	}
+
+	respondError(c, 400, apierror.CodeValidationFailed, apierror.Message("Missing file field"))
+}
+
+// respondBackupLookupError maps a failed lookup of a backup by name to its API error
+func respondBackupLookupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBackupNotFound):
+		respondError(c, 404, apierror.CodeBackupNotFound, apierror.Message("Backup not found"))
+	case errors.Is(err, services.ErrInvalidBackupName):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to find backup", err))
+	}
+}
+
+// respondUploadError maps a failed upload to its API error
+func respondUploadError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, services.ErrBackupTooLarge), errors.As(err, &maxBytesErr):
+		respondError(c, 413, apierror.CodePayloadTooLarge, services.ErrBackupTooLarge)
+	case errors.Is(err, services.ErrInvalidBackupName), errors.Is(err, services.ErrUnsupportedBackupType):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	case errors.Is(err, services.ErrBackupExists):
+		respondError(c, 409, apierror.CodeBackupExists, err)
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to upload backup", err))
+	}
+}
+
+// backupContentType returns the media type for a backup file name
+func backupContentType(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".gz"), filepath.Ext(name) == ".tgz":
+		return "application/gzip"
+	case filepath.Ext(name) == ".sql":
+		return "application/sql"
+	default:
+		return "application/octet-stream"
+	}
+}
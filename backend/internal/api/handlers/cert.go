@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/config"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCertExpiryDays bounds the ?days= window of the expiry report
+const maxCertExpiryDays = 3650
+
+type CertHandler struct {
+	scanner *services.CertExpiryScanner
+}
+
+func NewCertHandler(cfg *config.Config) *CertHandler {
+	return &CertHandler{
+		scanner: services.NewCertExpiryScanner(
+			services.NewNotificationService(cfg),
+			services.NewWebhookService(cfg),
+			cfg,
+		),
+	}
+}
+
+// GetExpiring returns the certificates that expire within ?days=, by default
+// the notifications.cert_expiry_days warning window
+// @Summary     Returns the certificates that expire within ?days=, by default the notifications.cert_expiry_days warning window
+// @ID          certGetExpiring
+// @Tags        certs
+// @Produce     json
+// @Param       days query string false "days"
+// @Success     200 {object} map[string]interface{} "OK, with certificates, days"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/certs/expiring [get]
+func (h *CertHandler) GetExpiring(c *gin.Context) {
+	days := h.scanner.WarningDays()
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > maxCertExpiryDays {
+			respondError(c, 400, apierror.CodeValidationFailed, apierror.Message("days must be a whole number from 0 to "+strconv.Itoa(maxCertExpiryDays)))
+			return
+		}
+		days = parsed
+	}
+
+	certificates, err := h.scanner.Expiring(days)
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to scan certificates", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"days": days, "certificates": certificates})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"r-panel/internal/api/apierror"
 	"r-panel/internal/config"
 	"r-panel/internal/models"
 	"r-panel/internal/services"
@@ -11,15 +13,37 @@ import (
 )
 
 type ClientHandler struct {
-	clientService *services.ClientService
+	clientService  *services.ClientService
+	nginxService   *services.NginxService
+	siteService    *services.ClientSiteService
+	mailService    *services.MailService
+	backupService  *services.BackupService
+	webhookService *services.WebhookService
 }
 
-func NewClientHandler(cfg *config.Config) *ClientHandler {
+// NewClientHandler backs up and restores client databases on the servers of
+// mysqlHandler, which is nil when MySQL is not configured
+func NewClientHandler(cfg *config.Config, mysqlHandler *MySQLHandler) *ClientHandler {
+	clientService := services.NewClientService(cfg)
+	if mysqlHandler != nil {
+		clientService.SetMySQLServers(mysqlHandler.servers)
+	}
+	nginxService := services.NewNginxService(cfg)
 	return &ClientHandler{
-		clientService: services.NewClientService(cfg),
+		clientService: clientService,
+		nginxService: nginxService,
+		siteService: services.NewClientSiteService(cfg, nginxService, clientService),
+		mailService: services.NewMailService(services.NewMaildirStorage(cfg.Paths.MailStorage)),
+		backupService: services.NewBackupService(cfg.Paths.Backups, cfg.Backup.FreeSpaceMargin()),
+		webhookService: services.NewWebhookService(cfg),
 	}
 }
 
+type RestoreClientBackupRequest struct {
+	BackupName string `json:"backup_name" binding:"required"`
+	OnConflict string `json:"on_conflict"` // fail (default) or rename
+}
+
 type CreateClientRequest struct {
 	// User fields
 	Username string `json:"username" binding:"required"`
@@ -85,6 +109,9 @@ type CreateClientRequest struct {
 	LimitWebdavUser       int      `json:"limit_webdav_user"`
 	LimitBackup           bool     `json:"limit_backup"`
 	LimitDirectiveSnippets bool    `json:"limit_directive_snippets"`
+	LimitWebRate          int      `json:"limit_web_rate"`
+	LimitWebRateAfter     int      `json:"limit_web_rate_after"`
+	LimitWebConnections   int      `json:"limit_web_connections"`
 	MailServers           []string `json:"mail_servers"`
 	LimitMaildomain       int      `json:"limit_maildomain"`
 	LimitMailbox          int      `json:"limit_mailbox"`
@@ -163,6 +190,7 @@ type UpdateClientRequest struct {
 	ParentClientID     *uint     `json:"parent_client_id"`
 	Reseller           *bool     `json:"reseller"`
 	Limits             *UpdateClientLimitsRequest `json:"limits"`
+	UpdatedAt          *time.Time `json:"updated_at"` // updated_at of the client as loaded, rejects the update if it changed since
 }
 
 type UpdateClientLimitsRequest struct {
@@ -189,6 +217,9 @@ type UpdateClientLimitsRequest struct {
 	LimitWebdavUser       *int      `json:"limit_webdav_user"`
 	LimitBackup           *bool     `json:"limit_backup"`
 	LimitDirectiveSnippets *bool    `json:"limit_directive_snippets"`
+	LimitWebRate          *int      `json:"limit_web_rate"`
+	LimitWebRateAfter     *int      `json:"limit_web_rate_after"`
+	LimitWebConnections   *int      `json:"limit_web_connections"`
 	MailServers           *[]string `json:"mail_servers"`
 	LimitMaildomain       *int      `json:"limit_maildomain"`
 	LimitMailbox          *int      `json:"limit_mailbox"`
@@ -233,7 +264,26 @@ type UpdateClientLimitsRequest struct {
 }
 
 // GetClients returns all clients with pagination support
+// @Summary     Returns all clients with pagination support
+// @ID          clientGetClients
+// @Tags        clients
+// @Produce     json
+// @Param       limit query string false "limit"
+// @Param       page query string false "page"
+// @Success     200 {object} map[string]interface{} "OK, with clients, limit, page, total, total_pages"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients [get]
 func (h *ClientHandler) GetClients(c *gin.Context) {
+	// Clients logged into the panel only see themselves
+	if user, restricted := clientPortalUser(c); restricted {
+		h.getOwnClients(c, user)
+		return
+	}
+
 	// Check if pagination is requested
 	pageParam := c.DefaultQuery("page", "")
 	limitParam := c.DefaultQuery("limit", "")
@@ -242,7 +292,7 @@ func (h *ClientHandler) GetClients(c *gin.Context) {
 		// No pagination - return all clients (backward compatibility)
 		clients, err := h.clientService.GetClients()
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to get clients", "details": err.Error()})
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get clients", err))
 			return
 		}
 		c.JSON(200, gin.H{"clients": clients})
@@ -268,7 +318,7 @@ func (h *ClientHandler) GetClients(c *gin.Context) {
 	// Get paginated clients
 	result, err := h.clientService.GetClientsPaginated(page, limit)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get clients", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get clients", err))
 		return
 	}
 
@@ -281,21 +331,61 @@ func (h *ClientHandler) GetClients(c *gin.Context) {
 	})
 }
 
-// GetClient returns a specific client
-func (h *ClientHandler) GetClient(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+// getOwnClients answers GetClients for a user-role caller with the client bound
+// to it, in the same shape as the full listing
+func (h *ClientHandler) getOwnClients(c *gin.Context, user *models.User) {
+	client, err := h.ownClient(user)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid client ID"})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get clients", err))
 		return
 	}
+	clients := []models.Client{}
+	if client != nil {
+		clients = append(clients, *client)
+	}
 
-	client, err := h.clientService.GetClient(uint(id))
-	if err != nil {
-		if err == services.ErrClientNotFound {
-			c.JSON(404, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to get client", "details": err.Error()})
-		}
+	if c.Query("page") == "" && c.Query("limit") == "" {
+		c.JSON(200, gin.H{"clients": clients})
+		return
+	}
+	page, limit := 1, 15
+	if parsed, err := strconv.Atoi(c.Query("page")); err == nil && parsed > 0 {
+		page = parsed
+	}
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 {
+		limit = parsed
+	}
+	total := len(clients)
+	if page > 1 {
+		clients = []models.Client{}
+	}
+	c.JSON(200, gin.H{
+		"clients":     clients,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": 1,
+	})
+}
+
+// GetClient returns a specific client
+// @Summary     Returns a specific client
+// @ID          clientGetClient
+// @Tags        clients
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id} [get]
+func (h *ClientHandler) GetClient(c *gin.Context) {
+	client, ok := h.loadClient(c)
+	if !ok {
 		return
 	}
 
@@ -303,10 +393,25 @@ func (h *ClientHandler) GetClient(c *gin.Context) {
 }
 
 // CreateClient creates a new client
+// @Summary     Creates a new client
+// @ID          clientCreateClient
+// @Tags        clients
+// @Accept      json
+// @Produce     json
+// @Param       request body CreateClientRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients [post]
 func (h *ClientHandler) CreateClient(c *gin.Context) {
 	var req CreateClientRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
@@ -380,6 +485,9 @@ func (h *ClientHandler) CreateClient(c *gin.Context) {
 		LimitWebdavUser:      req.LimitWebdavUser,
 		LimitBackup:          req.LimitBackup,
 		LimitDirectiveSnippets: req.LimitDirectiveSnippets,
+		LimitWebRate:         req.LimitWebRate,
+		LimitWebRateAfter:    req.LimitWebRateAfter,
+		LimitWebConnections:  req.LimitWebConnections,
 		MailServers:          models.StringArray(req.MailServers),
 		LimitMaildomain:      req.LimitMaildomain,
 		LimitMailbox:         req.LimitMailbox,
@@ -426,27 +534,52 @@ func (h *ClientHandler) CreateClient(c *gin.Context) {
 	client, err := h.clientService.CreateClient(data)
 	if err != nil {
 		if err == services.ErrUserExists || err == services.ErrClientExists || err == services.ErrCustomerNoExists {
-			c.JSON(400, gin.H{"error": err.Error()})
+			respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
+		} else if errors.Is(err, services.ErrInvalidBandwidthLimit) || errors.Is(err, services.ErrInvalidClientData) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		} else if errors.Is(err, services.ErrClientTemplateNotFound) || errors.Is(err, services.ErrInvalidClientTemplate) {
+			respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
+		} else if errors.Is(err, services.ErrJailkitNotInstalled) {
+			respondError(c, 503, apierror.CodeServiceUnavailable, err)
 		} else {
-			c.JSON(500, gin.H{"error": "Failed to create client", "details": err.Error()})
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to create client", err))
 		}
 		return
 	}
 
+	h.webhookService.Dispatch(services.WebhookClientCreated, services.NewClientEvent(client))
+
 	c.JSON(201, client)
 }
 
 // UpdateClient updates a client
+// @Summary     Updates a client
+// @ID          clientUpdateClient
+// @Tags        clients
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       request body UpdateClientRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     409 {object} apierror.APIError "Conflict, code CLIENT_MODIFIED"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id} [put]
 func (h *ClientHandler) UpdateClient(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid client ID"})
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid client ID"))
 		return
 	}
 
 	var req UpdateClientRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
@@ -484,6 +617,7 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 		TemplateMaster:     req.TemplateMaster,
 		ParentClientID:     req.ParentClientID,
 		Reseller:           req.Reseller,
+		UpdatedAt:          req.UpdatedAt,
 	}
 
 	if req.TemplateAdditional != nil {
@@ -544,6 +678,9 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 		limitsData.LimitWebdavUser = req.Limits.LimitWebdavUser
 		limitsData.LimitBackup = req.Limits.LimitBackup
 		limitsData.LimitDirectiveSnippets = req.Limits.LimitDirectiveSnippets
+		limitsData.LimitWebRate = req.Limits.LimitWebRate
+		limitsData.LimitWebRateAfter = req.Limits.LimitWebRateAfter
+		limitsData.LimitWebConnections = req.Limits.LimitWebConnections
 		limitsData.LimitMaildomain = req.Limits.LimitMaildomain
 		limitsData.LimitMailbox = req.Limits.LimitMailbox
 		limitsData.LimitMailalias = req.Limits.LimitMailalias
@@ -588,9 +725,17 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 	client, err := h.clientService.UpdateClient(uint(id), data)
 	if err != nil {
 		if err == services.ErrClientNotFound || err == services.ErrClientExists || err == services.ErrCustomerNoExists {
-			c.JSON(400, gin.H{"error": err.Error()})
+			respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
+		} else if errors.Is(err, services.ErrInvalidBandwidthLimit) || errors.Is(err, services.ErrInvalidClientData) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		} else if errors.Is(err, services.ErrClientTemplateNotFound) || errors.Is(err, services.ErrInvalidClientTemplate) {
+			respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
+		} else if errors.Is(err, services.ErrClientModified) {
+			respondError(c, 409, apierror.CodeClientModified, err)
+		} else if errors.Is(err, services.ErrJailkitNotInstalled) {
+			respondError(c, 503, apierror.CodeServiceUnavailable, err)
 		} else {
-			c.JSON(500, gin.H{"error": "Failed to update client", "details": err.Error()})
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to update client", err))
 		}
 		return
 	}
@@ -599,16 +744,33 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 }
 
 // UpdateClientLimits updates only the limits for a client
+// @Summary     Updates only the limits for a client
+// @ID          clientUpdateClientLimits
+// @Tags        clients
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       request body UpdateClientLimitsRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/limits [put]
 func (h *ClientHandler) UpdateClientLimits(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid client ID"})
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid client ID"))
 		return
 	}
 
 	var req UpdateClientLimitsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
@@ -665,6 +827,9 @@ func (h *ClientHandler) UpdateClientLimits(c *gin.Context) {
 	limitsData.LimitWebdavUser = req.LimitWebdavUser
 	limitsData.LimitBackup = req.LimitBackup
 	limitsData.LimitDirectiveSnippets = req.LimitDirectiveSnippets
+	limitsData.LimitWebRate = req.LimitWebRate
+	limitsData.LimitWebRateAfter = req.LimitWebRateAfter
+	limitsData.LimitWebConnections = req.LimitWebConnections
 	limitsData.LimitMaildomain = req.LimitMaildomain
 	limitsData.LimitMailbox = req.LimitMailbox
 	limitsData.LimitMailalias = req.LimitMailalias
@@ -705,9 +870,13 @@ func (h *ClientHandler) UpdateClientLimits(c *gin.Context) {
 
 	if err := h.clientService.UpdateClientLimits(uint(id), limitsData); err != nil {
 		if err == services.ErrClientNotFound {
-			c.JSON(404, gin.H{"error": err.Error()})
+			respondError(c, 404, apierror.CodeClientNotFound, err)
+		} else if errors.Is(err, services.ErrInvalidBandwidthLimit) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		} else if errors.Is(err, services.ErrJailkitNotInstalled) {
+			respondError(c, 503, apierror.CodeServiceUnavailable, err)
 		} else {
-			c.JSON(500, gin.H{"error": "Failed to update client limits", "details": err.Error()})
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to update client limits", err))
 		}
 		return
 	}
@@ -715,30 +884,314 @@ func (h *ClientHandler) UpdateClientLimits(c *gin.Context) {
 	// Return updated client with limits
 	client, err := h.clientService.GetClient(uint(id))
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get updated client", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get updated client", err))
 		return
 	}
 
 	c.JSON(200, client)
 }
 
-// DeleteClient deletes a client
+// DeleteClient moves a client to the trash
+// @Summary     Moves a client to the trash
+// @ID          clientDeleteClient
+// @Tags        clients
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       backup query string false "backup"
+// @Param       dry_run query string false "dry_run"
+// @Success     200 {object} map[string]interface{} "OK, with backup_path, dry_run, message, plan"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id} [delete]
 func (h *ClientHandler) DeleteClient(c *gin.Context) {
+	h.deleteClient(c, false)
+}
+
+// PurgeClient permanently deletes a client, including its Linux user
+// @Summary     Permanently deletes a client, including its Linux user
+// @ID          clientPurgeClient
+// @Tags        clients
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       backup query string false "backup"
+// @Param       dry_run query string false "dry_run"
+// @Success     200 {object} map[string]interface{} "OK, with backup_path, dry_run, message, plan"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/purge [delete]
+func (h *ClientHandler) PurgeClient(c *gin.Context) {
+	h.deleteClient(c, true)
+}
+
+func (h *ClientHandler) deleteClient(c *gin.Context, purge bool) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid client ID"})
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid client ID"))
+		return
+	}
+
+	// ?backup=true|false overrides backup.before_client_delete for a purge
+	opts := services.ClientDeletionOptions{Purge: purge}
+	if backupParam := c.Query("backup"); backupParam != "" {
+		backup, err := strconv.ParseBool(backupParam)
+		if err != nil {
+			respondError(c, 400, apierror.CodeBadRequest, apierror.Message("Invalid backup parameter"))
+			return
+		}
+		opts.Backup = &backup
+	}
+
+	// Dry run: report what would be removed without deleting anything
+	if dryRun, _ := strconv.ParseBool(c.Query("dry_run")); dryRun {
+		plan, err := h.clientService.PlanClientDeletion(uint(id), opts)
+		if err != nil {
+			if err == services.ErrClientNotFound {
+				respondError(c, 404, apierror.CodeClientNotFound, err)
+			} else {
+				respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to plan client deletion", err))
+			}
+			return
+		}
+
+		c.JSON(200, gin.H{"dry_run": true, "plan": plan})
+		return
+	}
+
+	if !purge {
+		// Load the client first, it can't be looked up once it is in the trash
+		client, _ := h.clientService.GetClient(uint(id))
+
+		if err := h.clientService.DeleteClient(uint(id)); err != nil {
+			if err == services.ErrClientNotFound {
+				respondError(c, 404, apierror.CodeClientNotFound, err)
+			} else {
+				respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to delete client", err))
+			}
+			return
+		}
+
+		if client != nil {
+			h.webhookService.Dispatch(services.WebhookClientDeleted, services.NewClientEvent(client))
+		}
+
+		c.JSON(200, gin.H{"message": "Client moved to trash"})
 		return
 	}
 
-	if err := h.clientService.DeleteClient(uint(id)); err != nil {
+	plan, err := h.clientService.PurgeClient(uint(id), opts.Backup)
+	if err != nil {
 		if err == services.ErrClientNotFound {
-			c.JSON(404, gin.H{"error": err.Error()})
+			respondError(c, 404, apierror.CodeClientNotFound, err)
 		} else {
-			c.JSON(500, gin.H{"error": "Failed to delete client", "details": err.Error()})
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to purge client", err))
+		}
+		return
+	}
+
+	h.webhookService.Dispatch(services.WebhookClientDeleted, services.ClientEvent{
+		ClientID:   plan.ClientID,
+		CustomerNo: plan.CustomerNo,
+		Purged:     true,
+	})
+
+	c.JSON(200, gin.H{
+		"message":     "Client purged successfully",
+		"backup_path": plan.BackupPath,
+	})
+}
+
+// GetTrashedClients returns all soft-deleted clients
+// @Summary     Returns all soft-deleted clients
+// @ID          clientGetTrashedClients
+// @Tags        clients
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with clients"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/trash [get]
+func (h *ClientHandler) GetTrashedClients(c *gin.Context) {
+	clients, err := h.clientService.GetTrashedClients()
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get trashed clients", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"clients": clients})
+}
+
+// RestoreClient brings a soft-deleted client back
+// @Summary     Brings a soft-deleted client back
+// @ID          clientRestoreClient
+// @Tags        clients
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with client"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code CLIENT_NOT_IN_TRASH"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/restore [post]
+func (h *ClientHandler) RestoreClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid client ID"))
+		return
+	}
+
+	client, err := h.clientService.RestoreClient(uint(id))
+	if err != nil {
+		switch err {
+		case services.ErrClientNotFound:
+			respondError(c, 404, apierror.CodeClientNotFound, err)
+		case services.ErrClientNotInTrash:
+			respondError(c, 409, apierror.CodeClientNotInTrash, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to restore client", err))
 		}
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Client deleted successfully"})
+	c.JSON(200, gin.H{"client": client})
 }
 
+// RestoreClientBackup recreates a client from a client backup in the backups directory
+// @Summary     Recreates a client from a client backup in the backups directory
+// @ID          clientRestoreClientBackup
+// @Tags        clients
+// @Accept      json
+// @Produce     json
+// @Param       request body RestoreClientBackupRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created, with client, databases, renamed"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code BACKUP_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code DATABASE_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/restore [post]
+func (h *ClientHandler) RestoreClientBackup(c *gin.Context) {
+	var req RestoreClientBackupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	backup, err := h.backupService.FindBackup(req.BackupName)
+	if err != nil {
+		respondBackupLookupError(c, err)
+		return
+	}
+
+	result, err := h.clientService.RestoreClientBackup(backup.Path, services.ClientRestoreOptions{OnConflict: req.OnConflict})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserExists), errors.Is(err, services.ErrClientExists),
+			errors.Is(err, services.ErrCustomerNoExists), errors.Is(err, services.ErrDatabaseExists):
+			respondError(c, 409, errorCode(err, apierror.CodeDatabaseExists), err)
+		case errors.Is(err, services.ErrInvalidClientBackup), errors.Is(err, services.ErrUnsafeBackupEntry):
+			respondError(c, 400, apierror.CodeBadRequest, err)
+		case errors.Is(err, services.ErrInvalidConflictStrategy):
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to restore client backup", err))
+		}
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"client":    result.Client,
+		"renamed":   result.Renamed,
+		"databases": result.Databases,
+	})
+}
+
+// GetClientSites returns the Nginx sites owned by a client's linux user
+// @Summary     Returns the Nginx sites owned by a client's linux user
+// @ID          clientGetClientSites
+// @Tags        clients
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with client_id, linux_username, sites"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/sites [get]
+func (h *ClientHandler) GetClientSites(c *gin.Context) {
+	client, ok := h.loadClient(c)
+	if !ok {
+		return
+	}
+
+	sites := []services.NginxSite{}
+	if client.LinuxUsername != "" {
+		var err error
+		sites, err = h.nginxService.GetSitesByUser(client.LinuxUsername)
+		if err != nil {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get sites", err))
+			return
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"sites":          sites,
+		"client_id":      client.ID,
+		"linux_username": client.LinuxUsername,
+	})
+}
+
+// GetClientMailUsage reports per-mailbox disk usage against the client's mail quota
+// @Summary     Reports per-mailbox disk usage against the client's mail quota
+// @ID          clientGetClientMailUsage
+// @Tags        clients
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with client_id, linux_username, usage"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/mail/usage [get]
+func (h *ClientHandler) GetClientMailUsage(c *gin.Context) {
+	client, ok := h.loadClient(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.mailService.GetUsage(client.LinuxUsername, client.ClientLimits.LimitMailquota)
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get mail usage", err))
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"usage":          report,
+		"client_id":      client.ID,
+		"linux_username": client.LinuxUsername,
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientPortalUser returns the caller if it has the user role. Such callers are
+// clients logged into the panel and may only see the client bound to them.
+func clientPortalUser(c *gin.Context) (*models.User, bool) {
+	user, ok := c.Get("user")
+	if !ok {
+		return nil, false
+	}
+	u := user.(*models.User)
+	return u, u.Role == models.RoleUser
+}
+
+// ownClient returns the client bound to a user-role caller, or nil when the
+// caller has none
+func (h *ClientHandler) ownClient(user *models.User) (*models.Client, error) {
+	client, err := h.clientService.GetClientByUserID(user.ID)
+	if errors.Is(err, services.ErrClientNotFound) {
+		return nil, nil
+	}
+	return client, err
+}
+
+// loadClient returns the client named by the :id parameter, or responds with an
+// error. User-role callers get 403 for every client but their own, whether it
+// exists or not.
+func (h *ClientHandler) loadClient(c *gin.Context) (*models.Client, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid client ID"))
+		return nil, false
+	}
+
+	if user, restricted := clientPortalUser(c); restricted {
+		own, err := h.ownClient(user)
+		if err != nil {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get client", err))
+			return nil, false
+		}
+		if own == nil || own.ID != uint(id) {
+			respondError(c, 403, apierror.CodeForbidden, apierror.Message("You may only access your own client"))
+			return nil, false
+		}
+	}
+
+	client, err := h.clientService.GetClient(uint(id))
+	if err != nil {
+		if err == services.ErrClientNotFound {
+			respondError(c, 404, apierror.CodeClientNotFound, err)
+		} else {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get client", err))
+		}
+		return nil, false
+	}
+	return client, true
+}
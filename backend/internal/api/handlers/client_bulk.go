@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BulkClientActionRequest struct {
+	Action string `json:"action" binding:"required"`
+	IDs    []uint `json:"ids" binding:"required,min=1"`
+}
+
+// BulkClientAction locks, unlocks, cancels or deletes several clients at once.
+// Every client is handled on its own and reported in results, so a missing id
+// does not stop the rest.
+// @Summary     Locks, unlocks, cancels or deletes several clients at once
+// @Description Locks, unlocks, cancels or deletes several clients at once. Every client is handled on its own and
+// @Description reported in results, so a missing id does not stop the rest.
+// @ID          clientBulkClientAction
+// @Tags        clients
+// @Accept      json
+// @Produce     json
+// @Param       request body BulkClientActionRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with failed, results, succeeded"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/bulk [post]
+func (h *ClientHandler) BulkClientAction(c *gin.Context) {
+	var req BulkClientActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	// Load clients first, they can't be looked up once they are in the trash
+	deleted := map[uint]*models.Client{}
+	if req.Action == services.BulkActionDelete {
+		for _, id := range req.IDs {
+			if client, err := h.clientService.GetClient(id); err == nil {
+				deleted[id] = client
+			}
+		}
+	}
+
+	results, err := h.clientService.BulkAction(req.Action, req.IDs)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBulkAction) {
+			respondError(c, 400, apierror.CodeBadRequest, err)
+		} else {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to apply bulk action", err))
+		}
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	failed := 0
+	for _, result := range results {
+		if !result.Success {
+			failed++
+			continue
+		}
+		logAudit(c, user.ID, req.Action, "client", strconv.FormatUint(uint64(result.ClientID), 10), "bulk")
+		if client, ok := deleted[result.ClientID]; ok {
+			h.webhookService.Dispatch(services.WebhookClientDeleted, services.NewClientEvent(client))
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"results":   results,
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SetLinuxPasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// SetLinuxPassword sets the password of a client's Linux user for SSH and SFTP
+// @Summary     Sets the password of a client's Linux user for SSH and SFTP
+// @ID          clientSetLinuxPassword
+// @Tags        clients
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       request body SetLinuxPasswordRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with linux_username, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code LIMIT_EXCEEDED or FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code BAD_REQUEST"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/linux-password [post]
+func (h *ClientHandler) SetLinuxPassword(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid client ID"))
+		return
+	}
+
+	var req SetLinuxPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	client, err := h.clientService.SetLinuxPassword(c.Request.Context(), uint(id), req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrClientNotFound):
+			respondError(c, 404, apierror.CodeClientNotFound, err)
+		case errors.Is(err, services.ErrWeakPassword):
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		case errors.Is(err, services.ErrNoLinuxUser):
+			respondError(c, 409, apierror.CodeBadRequest, err)
+		case errors.Is(err, services.ErrNoShellAccess), errors.Is(err, services.ErrShellJailOnly):
+			respondError(c, 403, apierror.CodeLimitExceeded, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to set Linux password", err))
+		}
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	logAudit(c, user.ID, "set_linux_password", "client", strconv.FormatUint(id, 10), "linux_user="+client.LinuxUsername)
+
+	c.JSON(200, gin.H{"message": "Linux password updated", "linux_username": client.LinuxUsername})
+}
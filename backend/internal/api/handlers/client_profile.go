@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateOwnClientRequest holds the client fields a client may change itself.
+// Limits, billing, reseller and account state stay with the admins.
+type UpdateOwnClientRequest struct {
+	ContactFirstname *string `json:"contact_firstname"`
+	ContactName      *string `json:"contact_name"`
+	Telephone        *string `json:"telephone"`
+	Mobile           *string `json:"mobile"`
+	Fax              *string `json:"fax"`
+	Street           *string `json:"street"`
+	ZIP              *string `json:"zip"`
+	City             *string `json:"city"`
+	State            *string `json:"state"`
+	Country          *string `json:"country"` // ISO 3166-1 alpha-2 code
+	Notes            *string `json:"notes"`
+
+	// UpdatedAt, when set, rejects the update if the client changed since
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// GetOwnClient returns the client bound to the caller
+// @Summary     Returns the client bound to the caller
+// @ID          clientGetOwnClient
+// @Tags        me
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/me/client [get]
+func (h *ClientHandler) GetOwnClient(c *gin.Context) {
+	client, ok := h.loadOwnClient(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(200, client)
+}
+
+// UpdateOwnClient updates the contact details of the client bound to the caller
+// @Summary     Updates the contact details of the client bound to the caller
+// @ID          clientUpdateOwnClient
+// @Tags        me
+// @Accept      json
+// @Produce     json
+// @Param       request body UpdateOwnClientRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code CLIENT_MODIFIED"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/me/client [patch]
+func (h *ClientHandler) UpdateOwnClient(c *gin.Context) {
+	var req UpdateOwnClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+	if req.ContactName != nil && strings.TrimSpace(*req.ContactName) == "" {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Message("contact_name cannot be empty"))
+		return
+	}
+
+	own, ok := h.loadOwnClient(c)
+	if !ok {
+		return
+	}
+
+	client, err := h.clientService.UpdateClient(own.ID, &services.UpdateClientData{
+		ContactFirstname: req.ContactFirstname,
+		ContactName:      req.ContactName,
+		Telephone:        req.Telephone,
+		Mobile:           req.Mobile,
+		Fax:              req.Fax,
+		Street:           req.Street,
+		ZIP:              req.ZIP,
+		City:             req.City,
+		State:            req.State,
+		Country:          req.Country,
+		Notes:            req.Notes,
+		UpdatedAt:        req.UpdatedAt,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrClientModified) {
+			respondError(c, 409, apierror.CodeClientModified, err)
+		} else if errors.Is(err, services.ErrInvalidClientData) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		} else {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to update client", err))
+		}
+		return
+	}
+
+	c.JSON(200, client)
+}
+
+// loadOwnClient returns the client bound to the caller, or responds with 404
+// when there is none
+func (h *ClientHandler) loadOwnClient(c *gin.Context) (*models.Client, bool) {
+	user := c.MustGet("user").(*models.User)
+	client, err := h.ownClient(user)
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get client", err))
+		return nil, false
+	}
+	if client == nil {
+		respondError(c, 404, apierror.CodeClientNotFound, apierror.Message("No client is bound to this user"))
+		return nil, false
+	}
+	return client, true
+}
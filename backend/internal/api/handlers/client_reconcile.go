@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"strings"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReconcileLinuxUsers reports clients whose Linux user is gone and Linux
+// users under the home base that belong to no client
+// @Summary     Reports clients whose Linux user is gone and Linux users under the home base that belong to no client
+// @ID          clientReconcileLinuxUsers
+// @Tags        clients
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/reconcile [get]
+func (h *ClientHandler) ReconcileLinuxUsers(c *gin.Context) {
+	result, err := h.clientService.ReconcileLinuxUsers()
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to reconcile Linux users", err))
+		return
+	}
+
+	c.JSON(200, result)
+}
+
+// RecreateMissingLinuxUsers creates the Linux users of clients that lost
+// theirs and reports what is still out of sync
+// @Summary     Creates the Linux users of clients that lost theirs and reports what is still out of sync
+// @ID          clientRecreateMissingLinuxUsers
+// @Tags        clients
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/reconcile [post]
+func (h *ClientHandler) RecreateMissingLinuxUsers(c *gin.Context) {
+	result, err := h.clientService.RecreateMissingLinuxUsers()
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to recreate Linux users", err))
+		return
+	}
+
+	if len(result.Recreated) > 0 {
+		user := c.MustGet("user").(*models.User)
+		logAudit(c, user.ID, "recreate_linux_users", "client", "", strings.Join(result.Recreated, ", "))
+	}
+
+	c.JSON(200, result)
+}
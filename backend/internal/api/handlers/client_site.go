@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateClientSiteRequest struct {
+	Domain     string `json:"domain" binding:"required"`
+	Wildcard   bool   `json:"wildcard"`    // Also serve every subdomain, needs limit_wildcard
+	ForceHTTPS bool   `json:"force_https"` // Serve over HTTPS and redirect HTTP to it, needs limit_ssl
+}
+
+// CreateClientSite creates and enables a site for a client within its plan,
+// with the document root under the client's home
+// @Summary     Creates and enables a site for a client within its plan, with the document root under the client's home
+// @ID          clientCreateClientSite
+// @Tags        clients
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       request body CreateClientSiteRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created, with document_root, message, site"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN or LIMIT_EXCEEDED"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code BAD_REQUEST or SITE_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/sites [post]
+func (h *ClientHandler) CreateClientSite(c *gin.Context) {
+	client, ok := h.loadClient(c)
+	if !ok {
+		return
+	}
+
+	var req CreateClientSiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	created, err := h.siteService.CreateSite(client, services.ClientSiteOptions{
+		Domain:     req.Domain,
+		Wildcard:   req.Wildcard,
+		ForceHTTPS: req.ForceHTTPS,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrClientLocked):
+			respondError(c, 403, apierror.CodeForbidden, err)
+		case errors.Is(err, services.ErrWebDomainLimitReached), errors.Is(err, services.ErrWildcardNotAllowed),
+			errors.Is(err, services.ErrSSLNotAllowed):
+			respondError(c, 403, apierror.CodeLimitExceeded, err)
+		case errors.Is(err, services.ErrInvalidDomain), errors.Is(err, services.ErrNoCertificate),
+			errors.Is(err, services.ErrInvalidBandwidthLimit):
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		case errors.Is(err, services.ErrSiteExists):
+			respondError(c, 409, apierror.CodeSiteExists, err)
+		case errors.Is(err, services.ErrNoLinuxUser), errors.Is(err, services.ErrUnsafeDocumentRoot):
+			respondError(c, 409, apierror.CodeBadRequest, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to create site", err))
+		}
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	logAudit(c, user.ID, "create_site", "client", strconv.FormatUint(uint64(client.ID), 10), created.Site.Domain)
+
+	c.JSON(201, gin.H{
+		"message":       "Site created and enabled, Nginx serves it after the next reload",
+		"site":          created.Site,
+		"document_root": created.DocumentRoot,
+	})
+}
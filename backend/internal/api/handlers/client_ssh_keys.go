@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AddSSHKeyRequest struct {
+	Key string `json:"key" binding:"required"`
+}
+
+// respondSSHKeyError maps the errors of the SSH key methods of ClientService
+func respondSSHKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSSHKey):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	case errors.Is(err, services.ErrNoShellAccess), errors.Is(err, services.ErrShellJailOnly):
+		respondError(c, 403, apierror.CodeLimitExceeded, err)
+	case errors.Is(err, services.ErrSSHKeyNotFound):
+		respondError(c, 404, apierror.CodeNotFound, err)
+	case errors.Is(err, services.ErrSSHKeyExists), errors.Is(err, services.ErrNoLinuxUser):
+		respondError(c, 409, apierror.CodeBadRequest, err)
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to update SSH keys", err))
+	}
+}
+
+// GetSSHKeys lists the keys authorized for a client's Linux user
+// @Summary     Lists the keys authorized for a client's Linux user
+// @ID          clientGetSSHKeys
+// @Tags        clients
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with keys, linux_username"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN or LIMIT_EXCEEDED"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND or NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code BAD_REQUEST"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/ssh-keys [get]
+func (h *ClientHandler) GetSSHKeys(c *gin.Context) {
+	client, ok := h.loadClient(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.clientService.GetSSHKeys(client)
+	if err != nil {
+		respondSSHKeyError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"keys": keys, "linux_username": client.LinuxUsername})
+}
+
+// AddSSHKey authorizes a public key for a client's Linux user
+// @Summary     Authorizes a public key for a client's Linux user
+// @ID          clientAddSSHKey
+// @Tags        clients
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       request body AddSSHKeyRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN or LIMIT_EXCEEDED"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND or NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code BAD_REQUEST"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/ssh-keys [post]
+func (h *ClientHandler) AddSSHKey(c *gin.Context) {
+	client, ok := h.loadClient(c)
+	if !ok {
+		return
+	}
+
+	var req AddSSHKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	key, err := h.clientService.AddSSHKey(client, req.Key)
+	if err != nil {
+		respondSSHKeyError(c, err)
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	logAudit(c, user.ID, "add_ssh_key", "client", strconv.FormatUint(uint64(client.ID), 10), key.Fingerprint)
+
+	c.JSON(201, key)
+}
+
+// DeleteSSHKey removes the key named by ?fingerprint= from a client's Linux user
+// @Summary     Removes the key named by ?fingerprint= from a client's Linux user
+// @ID          clientDeleteSSHKey
+// @Tags        clients
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       fingerprint query string false "fingerprint"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN or LIMIT_EXCEEDED"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND or NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code BAD_REQUEST"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/ssh-keys [delete]
+func (h *ClientHandler) DeleteSSHKey(c *gin.Context) {
+	client, ok := h.loadClient(c)
+	if !ok {
+		return
+	}
+
+	fingerprint := c.Query("fingerprint")
+	if fingerprint == "" {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Message("fingerprint is required"))
+		return
+	}
+
+	if err := h.clientService.DeleteSSHKey(client, fingerprint); err != nil {
+		respondSSHKeyError(c, err)
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	logAudit(c, user.ID, "delete_ssh_key", "client", strconv.FormatUint(uint64(client.ID), 10), fingerprint)
+
+	c.JSON(200, gin.H{"message": "SSH key removed"})
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClientTemplateRequest struct {
+	Name        string                `json:"name" binding:"required"`
+	Description string                `json:"description"`
+	Limits      models.TemplateLimits `json:"limits"`
+	// ApplyToClients applies an updated template to the limits of the clients using it
+	ApplyToClients bool `json:"apply_to_clients"`
+}
+
+func (r *ClientTemplateRequest) data() *services.ClientTemplateData {
+	return &services.ClientTemplateData{
+		Name:        r.Name,
+		Description: r.Description,
+		Limits:      r.Limits,
+	}
+}
+
+// respondTemplateError maps client template service errors to their HTTP status
+func respondTemplateError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrClientTemplateNotFound):
+		respondError(c, 404, apierror.CodeTemplateNotFound, err)
+	case errors.Is(err, services.ErrClientTemplateExists), errors.Is(err, services.ErrClientTemplateInUse):
+		respondError(c, 409, errorCode(err, apierror.CodeBadRequest), err)
+	case errors.Is(err, services.ErrInvalidClientTemplate), errors.Is(err, services.ErrInvalidBandwidthLimit):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap(message, err))
+	}
+}
+
+func parseTemplateID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid template ID"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetTemplates returns all client templates
+// @Summary     Returns all client templates
+// @ID          clientGetTemplates
+// @Tags        templates
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with templates"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates [get]
+func (h *ClientHandler) GetTemplates(c *gin.Context) {
+	templates, err := h.clientService.GetTemplates()
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get templates", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"templates": templates})
+}
+
+// GetTemplate returns a specific client template
+// @Summary     Returns a specific client template
+// @ID          clientGetTemplate
+// @Tags        templates
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code TEMPLATE_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code BAD_REQUEST"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates/{id} [get]
+func (h *ClientHandler) GetTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.clientService.GetTemplate(id)
+	if err != nil {
+		respondTemplateError(c, err, "Failed to get template")
+		return
+	}
+
+	c.JSON(200, template)
+}
+
+// CreateTemplate creates a client template
+// @Summary     Creates a client template
+// @ID          clientCreateTemplate
+// @Tags        templates
+// @Accept      json
+// @Produce     json
+// @Param       request body ClientTemplateRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code TEMPLATE_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code BAD_REQUEST"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates [post]
+func (h *ClientHandler) CreateTemplate(c *gin.Context) {
+	var req ClientTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	template, err := h.clientService.CreateTemplate(req.data())
+	if err != nil {
+		respondTemplateError(c, err, "Failed to create template")
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	logAudit(c, user.ID, "create", "client_template", strconv.FormatUint(uint64(template.ID), 10), template.Name)
+
+	c.JSON(201, template)
+}
+
+// UpdateTemplate updates a client template and, when asked, applies it to the
+// clients using it
+// @Summary     Updates a client template and, when asked, applies it to the clients using it
+// @ID          clientUpdateTemplate
+// @Tags        templates
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       request body ClientTemplateRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with applied_clients, template"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code TEMPLATE_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code BAD_REQUEST"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates/{id} [put]
+func (h *ClientHandler) UpdateTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	var req ClientTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	template, applied, err := h.clientService.UpdateTemplate(id, req.data(), req.ApplyToClients)
+	if err != nil {
+		respondTemplateError(c, err, "Failed to update template")
+		return
+	}
+
+	details := template.Name
+	if req.ApplyToClients {
+		details += ", applied to " + strconv.Itoa(len(applied)) + " clients"
+	}
+	user := c.MustGet("user").(*models.User)
+	logAudit(c, user.ID, "update", "client_template", strconv.FormatUint(uint64(id), 10), details)
+
+	c.JSON(200, gin.H{"template": template, "applied_clients": applied})
+}
+
+// DeleteTemplate deletes a client template no client uses
+// @Summary     Deletes a client template no client uses
+// @ID          clientDeleteTemplate
+// @Tags        templates
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code TEMPLATE_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code BAD_REQUEST"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates/{id} [delete]
+func (h *ClientHandler) DeleteTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.clientService.DeleteTemplate(id); err != nil {
+		respondTemplateError(c, err, "Failed to delete template")
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	logAudit(c, user.ID, "delete", "client_template", strconv.FormatUint(uint64(id), 10), "")
+
+	c.JSON(200, gin.H{"message": "Template deleted successfully"})
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConfigTemplateHandler struct {
+	configTemplateService *services.ConfigTemplateService
+}
+
+func NewConfigTemplateHandler() *ConfigTemplateHandler {
+	return &ConfigTemplateHandler{
+		configTemplateService: services.NewConfigTemplateService(),
+	}
+}
+
+type UpdateConfigTemplateRequest struct {
+	Source string `json:"source" binding:"required"`
+}
+
+// respondConfigTemplateError maps config template service errors to their HTTP status
+func respondConfigTemplateError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrConfigTemplateNotFound):
+		respondError(c, 404, apierror.CodeTemplateNotFound, err)
+	case errors.Is(err, services.ErrInvalidConfigTemplate):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap(message, err))
+	}
+}
+
+// GetNginxTemplate returns the template new nginx sites are generated from
+// @Summary     Returns the template new nginx sites are generated from
+// @ID          configTemplateGetNginxTemplate
+// @Tags        templates
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code TEMPLATE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates/nginx [get]
+func (h *ConfigTemplateHandler) GetNginxTemplate(c *gin.Context) {
+	h.getTemplate(c, services.ConfigTemplateNginx)
+}
+
+// UpdateNginxTemplate replaces the template new nginx sites are generated from
+// @Summary     Replaces the template new nginx sites are generated from
+// @ID          configTemplateUpdateNginxTemplate
+// @Tags        templates
+// @Accept      json
+// @Produce     json
+// @Param       request body UpdateConfigTemplateRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code TEMPLATE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates/nginx [put]
+func (h *ConfigTemplateHandler) UpdateNginxTemplate(c *gin.Context) {
+	h.updateTemplate(c, services.ConfigTemplateNginx)
+}
+
+// ResetNginxTemplate goes back to the built-in nginx site template
+// @Summary     Goes back to the built-in nginx site template
+// @ID          configTemplateResetNginxTemplate
+// @Tags        templates
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code TEMPLATE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates/nginx [delete]
+func (h *ConfigTemplateHandler) ResetNginxTemplate(c *gin.Context) {
+	h.resetTemplate(c, services.ConfigTemplateNginx)
+}
+
+// GetPHPFPMTemplate returns the template new PHP-FPM pools are generated from
+// @Summary     Returns the template new PHP-FPM pools are generated from
+// @ID          configTemplateGetPHPFPMTemplate
+// @Tags        templates
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code TEMPLATE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates/phpfpm [get]
+func (h *ConfigTemplateHandler) GetPHPFPMTemplate(c *gin.Context) {
+	h.getTemplate(c, services.ConfigTemplatePHPFPM)
+}
+
+// UpdatePHPFPMTemplate replaces the template new PHP-FPM pools are generated from
+// @Summary     Replaces the template new PHP-FPM pools are generated from
+// @ID          configTemplateUpdatePHPFPMTemplate
+// @Tags        templates
+// @Accept      json
+// @Produce     json
+// @Param       request body UpdateConfigTemplateRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code TEMPLATE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates/phpfpm [put]
+func (h *ConfigTemplateHandler) UpdatePHPFPMTemplate(c *gin.Context) {
+	h.updateTemplate(c, services.ConfigTemplatePHPFPM)
+}
+
+// ResetPHPFPMTemplate goes back to the built-in PHP-FPM pool template
+// @Summary     Goes back to the built-in PHP-FPM pool template
+// @ID          configTemplateResetPHPFPMTemplate
+// @Tags        templates
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code TEMPLATE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/templates/phpfpm [delete]
+func (h *ConfigTemplateHandler) ResetPHPFPMTemplate(c *gin.Context) {
+	h.resetTemplate(c, services.ConfigTemplatePHPFPM)
+}
+
+func (h *ConfigTemplateHandler) getTemplate(c *gin.Context, name string) {
+	template, err := h.configTemplateService.GetTemplate(name)
+	if err != nil {
+		respondConfigTemplateError(c, err, "Failed to get template")
+		return
+	}
+
+	c.JSON(200, template)
+}
+
+func (h *ConfigTemplateHandler) updateTemplate(c *gin.Context, name string) {
+	var req UpdateConfigTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	template, err := h.configTemplateService.UpdateTemplate(name, req.Source)
+	if err != nil {
+		respondConfigTemplateError(c, err, "Failed to update template")
+		return
+	}
+
+	c.JSON(200, template)
+}
+
+func (h *ConfigTemplateHandler) resetTemplate(c *gin.Context, name string) {
+	template, err := h.configTemplateService.ResetTemplate(name)
+	if err != nil {
+		respondConfigTemplateError(c, err, "Failed to reset template")
+		return
+	}
+
+	c.JSON(200, template)
+}
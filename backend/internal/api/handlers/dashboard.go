@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/config"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DashboardHandler struct {
+	dashboardService *services.DashboardService
+	clientService    *services.ClientService
+	metrics          *services.Metrics
+}
+
+// NewDashboardHandler counts databases on the servers of mysqlHandler, which is
+// nil when MySQL is not configured, and exports the dashboard on metrics
+func NewDashboardHandler(cfg *config.Config, mysqlHandler *MySQLHandler, metrics *services.Metrics) *DashboardHandler {
+	var servers *services.MySQLServers
+	if mysqlHandler != nil {
+		servers = mysqlHandler.servers
+	}
+	dashboardService := services.NewDashboardService(
+		services.NewSystemService(),
+		services.NewNginxService(cfg),
+		services.NewBackupService(cfg.Paths.Backups, cfg.Backup.FreeSpaceMargin()),
+		servers,
+	)
+	metrics.MustRegister(services.NewDashboardCollector(dashboardService))
+
+	return &DashboardHandler{
+		dashboardService: dashboardService,
+		clientService:    services.NewClientService(cfg),
+		metrics:          metrics,
+	}
+}
+
+// GetDashboard returns the counts, system stats, service statuses and latest
+// backups the home screen shows. User-role callers get the counts of their own
+// client and no backups.
+// @Summary     Returns the counts, system stats, service statuses and latest backups the home screen shows
+// @Description Returns the counts, system stats, service statuses and latest backups the home screen shows. User-
+// @Description role callers get the counts of their own client and no backups.
+// @ID          dashboardGetDashboard
+// @Tags        dashboard
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/dashboard [get]
+func (h *DashboardHandler) GetDashboard(c *gin.Context) {
+	var dashboard *services.Dashboard
+	var err error
+	if user, restricted := clientPortalUser(c); restricted {
+		client, clientErr := h.clientService.GetClientByUserID(user.ID)
+		if clientErr != nil && !errors.Is(clientErr, services.ErrClientNotFound) {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get dashboard", clientErr))
+			return
+		}
+		dashboard, err = h.dashboardService.GetClientDashboard(user, client)
+	} else {
+		dashboard, err = h.dashboardService.GetDashboard()
+	}
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get dashboard", err))
+		return
+	}
+
+	c.JSON(200, dashboard)
+}
+
+// GetMetrics serves the panel's metrics in the Prometheus text format
+func (h *DashboardHandler) GetMetrics(c *gin.Context) {
+	h.metrics.Handler().ServeHTTP(c.Writer, c.Request)
+}
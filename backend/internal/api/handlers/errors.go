@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError writes a standard APIError response and aborts the request
+func respondError(c *gin.Context, status int, code string, err error) {
+	apierror.Respond(c, status, code, err)
+}
+
+// errorCode maps known service errors to their API error code, falling back to fallback
+func errorCode(err error, fallback string) string {
+	switch {
+	case errors.Is(err, services.ErrClientNotFound):
+		return apierror.CodeClientNotFound
+	case errors.Is(err, services.ErrClientExists):
+		return apierror.CodeClientExists
+	case errors.Is(err, services.ErrCustomerNoExists):
+		return apierror.CodeCustomerNoExists
+	case errors.Is(err, services.ErrClientNotInTrash):
+		return apierror.CodeClientNotInTrash
+	case errors.Is(err, services.ErrUserNotFound):
+		return apierror.CodeUserNotFound
+	case errors.Is(err, services.ErrUserExists):
+		return apierror.CodeUserExists
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return apierror.CodeInvalidCredentials
+	case errors.Is(err, services.ErrAccountDisabled):
+		return apierror.CodeAccountDisabled
+	case errors.Is(err, services.ErrWebhookNotFound):
+		return apierror.CodeWebhookNotFound
+	case errors.Is(err, services.ErrNginxSnippetNotFound):
+		return apierror.CodeSnippetNotFound
+	case errors.Is(err, services.ErrNginxSnippetExists):
+		return apierror.CodeSnippetExists
+	case errors.Is(err, services.ErrClientTemplateNotFound):
+		return apierror.CodeTemplateNotFound
+	case errors.Is(err, services.ErrClientTemplateExists):
+		return apierror.CodeTemplateExists
+	case errors.Is(err, services.ErrClientTemplateInUse):
+		return apierror.CodeTemplateInUse
+	case errors.Is(err, services.ErrSetupCompleted):
+		return apierror.CodeSetupCompleted
+	case errors.Is(err, services.ErrInvalidDomain),
+		errors.Is(err, services.ErrInvalidPHPVersion),
+		errors.Is(err, services.ErrInvalidPoolName),
+		errors.Is(err, services.ErrInvalidWebhookURL),
+		errors.Is(err, services.ErrInvalidWebhookEvent),
+		errors.Is(err, services.ErrInvalidSetupData),
+		errors.Is(err, services.ErrWeakPassword),
+		errors.Is(err, services.ErrInvalidRole),
+		errors.Is(err, services.ErrInvalidClientData),
+		errors.Is(err, services.ErrInvalidNginxSnippet),
+		errors.Is(err, services.ErrInvalidClientTemplate),
+		errors.Is(err, services.ErrInvalidMySQLUser),
+		errors.Is(err, services.ErrAnyHostNotAllowed),
+		errors.Is(err, services.ErrInvalidConflictStrategy),
+		errors.Is(err, services.ErrServerNameMismatch):
+		return apierror.CodeValidationFailed
+	default:
+		return fallback
+	}
+}
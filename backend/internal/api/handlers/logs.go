@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"r-panel/internal/api/apierror"
 	"r-panel/internal/config"
 	"r-panel/internal/services"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,11 +17,24 @@ type LogsHandler struct {
 
 func NewLogsHandler(cfg *config.Config) *LogsHandler {
 	return &LogsHandler{
-		logsService: services.NewLogsService(cfg.Paths.NginxLogs),
+		logsService: services.NewLogsService(cfg.Paths.NginxLogs, cfg.Paths.Logs),
 	}
 }
 
 // GetSystemLogs returns system logs
+// @Summary     Returns system logs
+// @ID          logsGetSystemLogs
+// @Tags        logs
+// @Produce     json
+// @Param       lines query string false "lines"
+// @Param       unit query string false "unit"
+// @Success     200 {object} map[string]interface{} "OK, with logs"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/logs/system [get]
 func (h *LogsHandler) GetSystemLogs(c *gin.Context) {
 	unit := c.Query("unit")
 	lines := 100
@@ -28,9 +44,9 @@ func (h *LogsHandler) GetSystemLogs(c *gin.Context) {
 		}
 	}
 
-	logs, err := h.logsService.GetSystemLogs(unit, lines)
+	logs, err := h.logsService.GetSystemLogs(c.Request.Context(), unit, lines)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to read system logs", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to read system logs", err))
 		return
 	}
 
@@ -38,10 +54,24 @@ func (h *LogsHandler) GetSystemLogs(c *gin.Context) {
 }
 
 // GetNginxLogs returns Nginx logs
+// @Summary     Returns Nginx logs
+// @ID          logsGetNginxLogs
+// @Tags        logs
+// @Produce     json
+// @Param       type path string true "type"
+// @Param       lines query string false "lines"
+// @Success     200 {object} map[string]interface{} "OK, with logs, type"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/logs/nginx/{type} [get]
 func (h *LogsHandler) GetNginxLogs(c *gin.Context) {
 	logType := c.Param("type") // access or error
 	if logType != "access" && logType != "error" {
-		c.JSON(400, gin.H{"error": "Invalid log type. Use 'access' or 'error'"})
+		respondError(c, 400, apierror.CodeBadRequest, apierror.Message("Invalid log type. Use 'access' or 'error'"))
 		return
 	}
 
@@ -52,9 +82,9 @@ func (h *LogsHandler) GetNginxLogs(c *gin.Context) {
 		}
 	}
 
-	logs, err := h.logsService.GetNginxLogs(logType, lines)
+	logs, err := h.logsService.GetNginxLogs(c.Request.Context(), logType, lines)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to read Nginx logs", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to read Nginx logs", err))
 		return
 	}
 
@@ -62,6 +92,20 @@ func (h *LogsHandler) GetNginxLogs(c *gin.Context) {
 }
 
 // GetPHPFPMLogs returns PHP-FPM logs
+// @Summary     Returns PHP-FPM logs
+// @ID          logsGetPHPFPMLogs
+// @Tags        logs
+// @Produce     json
+// @Param       lines query string false "lines"
+// @Param       version query string false "version"
+// @Success     200 {object} map[string]interface{} "OK, with logs, version"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/logs/phpfpm [get]
 func (h *LogsHandler) GetPHPFPMLogs(c *gin.Context) {
 	phpVersion := c.Query("version")
 	if phpVersion == "" {
@@ -75,16 +119,56 @@ func (h *LogsHandler) GetPHPFPMLogs(c *gin.Context) {
 		}
 	}
 
-	logs, err := h.logsService.GetPHPFPMLogs(phpVersion, lines)
+	logs, err := h.logsService.GetPHPFPMLogs(c.Request.Context(), phpVersion, lines)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to read PHP-FPM logs", "details": err.Error()})
+		if errors.Is(err, services.ErrInvalidPHPVersion) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+			return
+		}
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to read PHP-FPM logs", err))
 		return
 	}
 
 	c.JSON(200, gin.H{"logs": logs, "version": phpVersion})
 }
 
+// GetLogFiles lists the log files that can be tailed
+// @Summary     Lists the log files that can be tailed
+// @ID          logsGetLogFiles
+// @Tags        logs
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with files"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/logs/files [get]
+func (h *LogsHandler) GetLogFiles(c *gin.Context) {
+	files, err := h.logsService.ListLogFiles()
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to list log files", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"files": files})
+}
+
 // TailLogs tails a log file
+// @Summary     Tails a log file
+// @ID          logsTailLogs
+// @Tags        logs
+// @Produce     json
+// @Param       source path string true "source"
+// @Param       file query string false "file"
+// @Param       lines query string false "lines"
+// @Success     200 {object} map[string]interface{} "OK, with logs, source"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/logs/tail/{source} [get]
 func (h *LogsHandler) TailLogs(c *gin.Context) {
 	source := c.Param("source")
 	logFile := c.Query("file")
@@ -96,11 +180,54 @@ func (h *LogsHandler) TailLogs(c *gin.Context) {
 		}
 	}
 
-	logs, err := h.logsService.TailLogs(source, logFile, lines)
+	logs, err := h.logsService.TailLogs(c.Request.Context(), source, logFile, lines)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to read logs", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to read logs", err))
 		return
 	}
 
 	c.JSON(200, gin.H{"logs": logs, "source": source})
 }
+
+// GetCombinedLogs interleaves the last lines of several sources in chronological order
+// @Summary     Interleaves the last lines of several sources in chronological order
+// @ID          logsGetCombinedLogs
+// @Tags        logs
+// @Produce     json
+// @Param       lines query string false "lines"
+// @Param       sources query string false "sources"
+// @Success     200 {object} map[string]interface{} "OK, with errors, lines, sources"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/logs/combined [get]
+func (h *LogsHandler) GetCombinedLogs(c *gin.Context) {
+	var sources []string
+	for _, source := range strings.Split(c.Query("sources"), ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+
+	lines := 100
+	if linesStr := c.Query("lines"); linesStr != "" {
+		if parsedLines, err := strconv.Atoi(linesStr); err == nil && parsedLines > 0 && parsedLines <= services.MaxCombinedLines {
+			lines = parsedLines
+		}
+	}
+
+	combined, err := h.logsService.CombinedTail(c.Request.Context(), sources, lines)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLogSource) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+			return
+		}
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to read logs", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"lines": combined.Lines, "errors": combined.Errors, "sources": sources})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"r-panel/internal/api/apierror"
 	"r-panel/internal/services"
 	"strconv"
 
@@ -18,30 +19,102 @@ func NewMonitoringHandler() *MonitoringHandler {
 }
 
 // GetStats returns current system statistics
+// @Summary     Returns current system statistics
+// @ID          monitoringGetStats
+// @Tags        monitoring
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/monitoring/stats [get]
 func (h *MonitoringHandler) GetStats(c *gin.Context) {
-	stats, err := h.systemService.GetStats()
+	stats, err := h.systemService.GetStats(c.Request.Context())
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get system stats", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get system stats", err))
 		return
 	}
 
 	c.JSON(200, stats)
 }
 
+// GetDiskIO returns the throughput of each block device. Loop and ram devices
+// are only included with ?all=true.
+// @Summary     Returns the throughput of each block device
+// @Description Returns the throughput of each block device. Loop and ram devices are only included with ?all=true.
+// @ID          monitoringGetDiskIO
+// @Tags        monitoring
+// @Produce     json
+// @Param       all query string false "all"
+// @Success     200 {object} map[string]interface{} "OK, with disk_io"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/monitoring/diskio [get]
+func (h *MonitoringHandler) GetDiskIO(c *gin.Context) {
+	stats, err := h.systemService.GetDiskIOStats(c.Query("all") == "true")
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get disk I/O stats", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"disk_io": stats})
+}
+
+// GetTime returns the server timezone, time and clock sync status
+// @Summary     Returns the server timezone, time and clock sync status
+// @ID          monitoringGetTime
+// @Tags        monitoring
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/monitoring/time [get]
+func (h *MonitoringHandler) GetTime(c *gin.Context) {
+	c.JSON(200, h.systemService.GetTimeStatus(c.Request.Context()))
+}
+
 // GetServices returns status of common services
+// @Summary     Returns status of common services
+// @ID          monitoringGetServices
+// @Tags        monitoring
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with services"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/monitoring/services [get]
 func (h *MonitoringHandler) GetServices(c *gin.Context) {
-	serviceNames := []string{"nginx", "php8.1-fpm", "php8.2-fpm", "mysql", "mariadb"}
-
-	services, err := h.systemService.GetServicesStatus(serviceNames)
+	statuses, err := h.systemService.GetServicesStatus(c.Request.Context(), services.MonitoredServices)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get service status", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get service status", err))
 		return
 	}
 
-	c.JSON(200, gin.H{"services": services})
+	c.JSON(200, gin.H{"services": statuses})
 }
 
 // GetProcesses returns top processes
+// @Summary     Returns top processes
+// @ID          monitoringGetProcesses
+// @Tags        monitoring
+// @Produce     json
+// @Param       limit query string false "limit"
+// @Success     200 {object} map[string]interface{} "OK, with processes"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/monitoring/processes [get]
 func (h *MonitoringHandler) GetProcesses(c *gin.Context) {
 	limit := 10
 	if limitStr := c.Query("limit"); limitStr != "" {
@@ -51,9 +124,9 @@ func (h *MonitoringHandler) GetProcesses(c *gin.Context) {
 		}
 	}
 
-	processes, err := h.systemService.GetTopProcesses(limit)
+	processes, err := h.systemService.GetTopProcesses(c.Request.Context(), limit)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get processes", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get processes", err))
 		return
 	}
 
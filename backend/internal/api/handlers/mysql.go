@@ -1,45 +1,80 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"path/filepath"
+	"r-panel/internal/api/apierror"
 	"r-panel/internal/config"
+	"r-panel/internal/models"
 	"r-panel/internal/services"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type MySQLHandler struct {
-	mysqlService *services.MySQLService
-	cfg          *config.Config
+	servers               *services.MySQLServers
+	clientDatabaseService *services.ClientDatabaseService
+	cfg                   *config.Config
 }
 
 func NewMySQLHandler(cfg *config.Config) (*MySQLHandler, error) {
-	dsn := ""
-	if cfg.Database.Type == "mysql" {
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/?charset=%s&parseTime=True",
-			cfg.Database.MySQL.Username,
-			cfg.Database.MySQL.Password,
-			cfg.Database.MySQL.Host,
-			cfg.Database.MySQL.Port,
-			cfg.Database.MySQL.Charset,
-		)
-	} else {
+	if cfg.Database.Type != "mysql" {
 		return nil, fmt.Errorf("MySQL service requires MySQL database type")
 	}
 
-	mysqlService, err := services.NewMySQLService(dsn)
+	servers, err := services.NewMySQLServers(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	return &MySQLHandler{
-		mysqlService: mysqlService,
-		cfg:          cfg,
+		servers:               servers,
+		clientDatabaseService: services.NewClientDatabaseService(servers),
+		cfg:                   cfg,
 	}, nil
 }
 
+// EnsureConnection selects the MySQL server named by the server query parameter,
+// the primary by default, and rejects requests with 503 while it is unreachable
+func (h *MySQLHandler) EnsureConnection(c *gin.Context) {
+	mysqlService, err := h.servers.Get(c.Query("server"))
+	if err != nil {
+		respondError(c, 404, apierror.CodeNotFound, err)
+		return
+	}
+	if err := mysqlService.EnsureConnected(); err != nil {
+		respondError(c, 503, apierror.CodeServiceUnavailable, err)
+		return
+	}
+	c.Set("mysql_service", mysqlService)
+	c.Next()
+}
+
+// mysqlService returns the server selected by EnsureConnection
+func (h *MySQLHandler) mysqlService(c *gin.Context) *services.MySQLService {
+	return c.MustGet("mysql_service").(*services.MySQLService)
+}
+
+// GetServers lists the MySQL servers that can be selected with ?server=, each
+// with its own status
+// @Summary     Lists the MySQL servers that can be selected with ?server=, each with its own status
+// @ID          mySQLGetServers
+// @Tags        mysql
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with servers"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/servers [get]
+func (h *MySQLHandler) GetServers(c *gin.Context) {
+	c.JSON(200, gin.H{"servers": h.servers.Statuses()})
+}
+
 type CreateDatabaseRequest struct {
 	Name string `json:"name" binding:"required"`
 }
@@ -47,7 +82,9 @@ type CreateDatabaseRequest struct {
 type CreateMySQLUserRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
-	Host     string `json:"host"`
+	Host     string `json:"host"` // default localhost
+	// AllowAnyHost confirms a host such as % that accepts connections from anywhere, admins only
+	AllowAnyHost bool `json:"allow_any_host"`
 }
 
 type GrantPrivilegesRequest struct {
@@ -55,32 +92,101 @@ type GrantPrivilegesRequest struct {
 	Privileges string `json:"privileges" binding:"required"`
 }
 
+type CreateClientDatabaseRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Server string `json:"server"` // defaults to the ?server= query parameter, then the client's first db_servers entry
+}
+
 type QueryRequest struct {
 	Query    string `json:"query" binding:"required"`
 	ReadOnly bool   `json:"read_only"`
+	Limit    int    `json:"limit" binding:"min=0"`  // rows per page, capped at services.MaxQueryRows
+	Offset   int    `json:"offset" binding:"min=0"` // rows to skip
+}
+
+// mysqlListOptions reads ?search=, ?page= and ?limit= and reports whether a
+// page was asked for
+func mysqlListOptions(c *gin.Context) (services.MySQLListOptions, bool) {
+	opts := services.MySQLListOptions{Search: c.Query("search")}
+	paginated := c.Query("page") != "" || c.Query("limit") != ""
+	if paginated {
+		opts.Page, opts.Limit = 1, 15
+		if page, err := strconv.Atoi(c.Query("page")); err == nil && page > 0 {
+			opts.Page = page
+		}
+		if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+			opts.Limit = limit
+		}
+	}
+	return opts, paginated
 }
 
-// GetDatabases returns all databases
+// GetDatabases returns the databases whose name contains ?search=, paged when
+// ?page= or ?limit= is given
+// @Summary     Returns the databases whose name contains ?search=, paged when ?page= or ?limit= is given
+// @ID          mySQLGetDatabases
+// @Tags        mysql
+// @Produce     json
+// @Param       limit query string false "limit"
+// @Param       page query string false "page"
+// @Param       search query string false "search"
+// @Param       server query string false "server"
+// @Success     200 {object} map[string]interface{} "OK, with databases, limit, page, total, total_pages"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/databases [get]
 func (h *MySQLHandler) GetDatabases(c *gin.Context) {
-	databases, err := h.mysqlService.GetDatabases()
+	opts, paginated := mysqlListOptions(c)
+	result, err := h.mysqlService(c).GetDatabases(opts)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get databases", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get databases", err))
 		return
 	}
 
-	c.JSON(200, gin.H{"databases": databases})
+	if !paginated {
+		c.JSON(200, gin.H{"databases": result.Data, "total": result.Total})
+		return
+	}
+	c.JSON(200, gin.H{
+		"databases":   result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"limit":       result.Limit,
+		"total_pages": result.TotalPages,
+	})
 }
 
 // CreateDatabase creates a new database
+// @Summary     Creates a new database
+// @ID          mySQLCreateDatabase
+// @Tags        mysql
+// @Accept      json
+// @Produce     json
+// @Param       server query string false "server"
+// @Param       request body CreateDatabaseRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/databases [post]
 func (h *MySQLHandler) CreateDatabase(c *gin.Context) {
 	var req CreateDatabaseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
-	if err := h.mysqlService.CreateDatabase(req.Name); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	if err := h.mysqlService(c).CreateDatabase(req.Name); err != nil {
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
@@ -88,55 +194,214 @@ func (h *MySQLHandler) CreateDatabase(c *gin.Context) {
 }
 
 // DeleteDatabase deletes a database
+// @Summary     Deletes a database
+// @ID          mySQLDeleteDatabase
+// @Tags        mysql
+// @Produce     json
+// @Param       name path string true "name"
+// @Param       server query string false "server"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/databases/{name} [delete]
 func (h *MySQLHandler) DeleteDatabase(c *gin.Context) {
 	name := c.Param("name")
 
-	if err := h.mysqlService.DeleteDatabase(name); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	if err := h.mysqlService(c).DeleteDatabase(name); err != nil {
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Database deleted successfully"})
 }
 
-// GetUsers returns all MySQL users
+// OptimizeDatabase runs OPTIMIZE TABLE on every table of a database
+// @Summary     Runs OPTIMIZE TABLE on every table of a database
+// @ID          mySQLOptimizeDatabase
+// @Tags        mysql
+// @Produce     json
+// @Param       name path string true "name"
+// @Param       server query string false "server"
+// @Success     200 {object} map[string]interface{} "OK, with database, operation, tables"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code DATABASE_NOT_FOUND or NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/databases/{name}/optimize [post]
+func (h *MySQLHandler) OptimizeDatabase(c *gin.Context) {
+	h.maintainDatabase(c, "optimize", (*services.MySQLService).OptimizeDatabase)
+}
+
+// RepairDatabase runs REPAIR TABLE on every table of a database
+// @Summary     Runs REPAIR TABLE on every table of a database
+// @ID          mySQLRepairDatabase
+// @Tags        mysql
+// @Produce     json
+// @Param       name path string true "name"
+// @Param       server query string false "server"
+// @Success     200 {object} map[string]interface{} "OK, with database, operation, tables"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code DATABASE_NOT_FOUND or NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/databases/{name}/repair [post]
+func (h *MySQLHandler) RepairDatabase(c *gin.Context) {
+	h.maintainDatabase(c, "repair", (*services.MySQLService).RepairDatabase)
+}
+
+func (h *MySQLHandler) maintainDatabase(c *gin.Context, operation string, run func(*services.MySQLService, string) ([]services.TableMaintenanceResult, error)) {
+	name := c.Param("name")
+
+	tables, err := run(h.mysqlService(c), name)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDatabaseNotFound):
+			respondError(c, 404, apierror.CodeDatabaseNotFound, err)
+		case errors.Is(err, services.ErrSystemDatabase):
+			respondError(c, 403, apierror.CodeForbidden, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to "+operation+" database", err))
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{"database": name, "operation": operation, "tables": tables})
+}
+
+// GetUsers returns the MySQL accounts whose user name contains ?search=, paged
+// when ?page= or ?limit= is given
+// @Summary     Returns the MySQL accounts whose user name contains ?search=, paged when ?page= or ?limit= is given
+// @ID          mySQLGetUsers
+// @Tags        mysql
+// @Produce     json
+// @Param       limit query string false "limit"
+// @Param       page query string false "page"
+// @Param       search query string false "search"
+// @Param       server query string false "server"
+// @Success     200 {object} map[string]interface{} "OK, with limit, page, total, total_pages, users"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/users [get]
 func (h *MySQLHandler) GetUsers(c *gin.Context) {
-	users, err := h.mysqlService.GetUsers()
+	opts, paginated := mysqlListOptions(c)
+	result, err := h.mysqlService(c).GetUsers(opts)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get users", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get users", err))
 		return
 	}
 
-	c.JSON(200, gin.H{"users": users})
+	if !paginated {
+		c.JSON(200, gin.H{"users": result.Data, "total": result.Total})
+		return
+	}
+	c.JSON(200, gin.H{
+		"users":       result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"limit":       result.Limit,
+		"total_pages": result.TotalPages,
+	})
 }
 
 // CreateUser creates a new MySQL user
+// @Summary     Creates a new MySQL user
+// @ID          mySQLCreateUser
+// @Tags        mysql
+// @Accept      json
+// @Produce     json
+// @Param       server query string false "server"
+// @Param       request body CreateMySQLUserRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/users [post]
 func (h *MySQLHandler) CreateUser(c *gin.Context) {
 	var req CreateMySQLUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
 	if req.Host == "" {
 		req.Host = "localhost"
 	}
+	if err := services.ValidateMySQLAccount(req.Username, req.Host); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+		return
+	}
+
+	// Remote access from anywhere has to be asked for, and only by an admin
+	user := c.MustGet("user").(*models.User)
+	anyHost := services.AnyMySQLHost(req.Host)
+	if anyHost {
+		if !req.AllowAnyHost {
+			respondError(c, 400, apierror.CodeValidationFailed, services.ErrAnyHostNotAllowed)
+			return
+		}
+		if user.Role != models.RoleAdmin {
+			respondError(c, 403, apierror.CodeForbidden, apierror.Message("Only admins may create users that connect from any host"))
+			return
+		}
+	}
 
-	if err := h.mysqlService.CreateUser(req.Username, req.Password, req.Host); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	if err := h.mysqlService(c).CreateUser(req.Username, req.Password, req.Host); err != nil {
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
+	details := "host=" + req.Host
+	if anyHost {
+		details += " (any host, confirmed)"
+	}
+	logAudit(c, user.ID, "create_mysql_user", "mysql_user", req.Username+"@"+req.Host, details)
+
 	c.JSON(201, gin.H{"message": "User created successfully"})
 }
 
 // DeleteUser deletes a MySQL user
+// @Summary     Deletes a MySQL user
+// @ID          mySQLDeleteUser
+// @Tags        mysql
+// @Produce     json
+// @Param       user path string true "user"
+// @Param       host query string false "host"
+// @Param       server query string false "server"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/users/{user} [delete]
 func (h *MySQLHandler) DeleteUser(c *gin.Context) {
 	username := c.Param("user")
 	host := c.DefaultQuery("host", "localhost")
 
-	if err := h.mysqlService.DeleteUser(username, host); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	if err := h.mysqlService(c).DeleteUser(username, host); err != nil {
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
@@ -144,29 +409,175 @@ func (h *MySQLHandler) DeleteUser(c *gin.Context) {
 }
 
 // GrantPrivileges grants privileges to a user
+// @Summary     Grants privileges to a user
+// @ID          mySQLGrantPrivileges
+// @Tags        mysql
+// @Accept      json
+// @Produce     json
+// @Param       user path string true "user"
+// @Param       host query string false "host"
+// @Param       server query string false "server"
+// @Param       request body GrantPrivilegesRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/users/{user}/privileges [post]
 func (h *MySQLHandler) GrantPrivileges(c *gin.Context) {
 	username := c.Param("user")
 	host := c.DefaultQuery("host", "localhost")
 
 	var req GrantPrivilegesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
-	if err := h.mysqlService.GrantPrivileges(username, host, req.Database, req.Privileges); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	if err := h.mysqlService(c).GrantPrivileges(username, host, req.Database, req.Privileges); err != nil {
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Privileges granted successfully"})
 }
 
+// GetProcessList lists the connections to the MySQL server
+// @Summary     Lists the connections to the MySQL server
+// @ID          mySQLGetProcessList
+// @Tags        mysql
+// @Produce     json
+// @Param       server query string false "server"
+// @Success     200 {object} map[string]interface{} "OK, with processes"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/processlist [get]
+func (h *MySQLHandler) GetProcessList(c *gin.Context) {
+	processes, err := h.mysqlService(c).GetProcessList(c.Request.Context())
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get process list", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"processes": processes})
+}
+
+// KillProcess ends a MySQL connection, such as one stuck on a lock
+// @Summary     Ends a MySQL connection, such as one stuck on a lock
+// @ID          mySQLKillProcess
+// @Tags        mysql
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       server query string false "server"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/processlist/{id} [delete]
+func (h *MySQLHandler) KillProcess(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid process ID"))
+		return
+	}
+
+	if err := h.mysqlService(c).KillProcess(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, services.ErrProcessNotFound):
+			respondError(c, 404, apierror.CodeNotFound, err)
+		case errors.Is(err, services.ErrOwnConnection), errors.Is(err, services.ErrSystemProcess):
+			respondError(c, 403, apierror.CodeForbidden, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to kill process", err))
+		}
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	logAudit(c, user.ID, "kill_mysql_process", "mysql_process", strconv.FormatUint(id, 10), "")
+
+	c.JSON(200, gin.H{"message": "Process killed"})
+}
+
+// GetSlowLog returns the last entries of the MySQL slow query log, ?lines=
+// sets how many
+// @Summary     Returns the last entries of the MySQL slow query log, ?lines= sets how many
+// @ID          mySQLGetSlowLog
+// @Tags        mysql
+// @Produce     json
+// @Param       lines query string false "lines"
+// @Param       server query string false "server"
+// @Success     200 {object} map[string]interface{} "OK, with enabled, entries, file, message"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/slowlog [get]
+func (h *MySQLHandler) GetSlowLog(c *gin.Context) {
+	entries := services.DefaultSlowLogEntries
+	if linesStr := c.Query("lines"); linesStr != "" {
+		if parsedLines, err := strconv.Atoi(linesStr); err == nil && parsedLines > 0 && parsedLines <= services.MaxSlowLogEntries {
+			entries = parsedLines
+		}
+	}
+
+	slowLog, err := h.mysqlService(c).GetSlowLog(c.Request.Context(), entries)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSlowLogDisabled):
+			c.JSON(200, gin.H{
+				"enabled": false,
+				"entries": []services.SlowQuery{},
+				"message": "The slow query log is disabled. Enable it with SET GLOBAL slow_query_log = 'ON', or slow_query_log = 1 in the server config to keep it across restarts; long_query_time sets how slow a query must be to be logged.",
+			})
+		case errors.Is(err, services.ErrSlowLogNotFound):
+			respondError(c, 404, apierror.CodeNotFound, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to read slow query log", err))
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{"enabled": true, "file": slowLog.File, "entries": slowLog.Entries})
+}
+
 // ExecuteQuery executes a SQL query
+// @Summary     Executes a SQL query
+// @ID          mySQLExecuteQuery
+// @Tags        mysql
+// @Accept      json
+// @Produce     json
+// @Param       server query string false "server"
+// @Param       request body QueryRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/query [post]
 func (h *MySQLHandler) ExecuteQuery(c *gin.Context) {
 	var req QueryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
@@ -174,50 +585,244 @@ func (h *MySQLHandler) ExecuteQuery(c *gin.Context) {
 		req.ReadOnly = true
 	}
 
-	results, err := h.mysqlService.ExecuteQuery(req.Query, req.ReadOnly)
+	result, err := h.mysqlService(c).ExecuteQuery(c.Request.Context(), req.Query, services.QueryOptions{
+		ReadOnly: req.ReadOnly,
+		Limit:    req.Limit,
+		Offset:   req.Offset,
+	})
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
-	c.JSON(200, gin.H{"results": results})
+	c.JSON(200, result)
 }
 
 // ExportDatabase exports a database
+// @Summary     Exports a database
+// @ID          mySQLExportDatabase
+// @Tags        mysql
+// @Produce     json
+// @Param       database path string true "database"
+// @Param       server query string false "server"
+// @Success     200 {object} map[string]interface{} "OK, with file, message"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/export/{database} [post]
 func (h *MySQLHandler) ExportDatabase(c *gin.Context) {
 	database := c.Param("database")
 
 	outputPath := filepath.Join(h.cfg.Paths.Backups, fmt.Sprintf("%s_%d.sql", database, time.Now().Unix()))
 
-	if err := h.mysqlService.ExportDatabase(database, outputPath); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to export database", "details": err.Error()})
+	if err := h.mysqlService(c).ExportDatabase(c.Request.Context(), database, outputPath); err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to export database", err))
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Database exported successfully", "file": outputPath})
 }
 
-// ImportDatabase imports a database
+// DownloadDatabaseExport streams a gzipped dump of a database as an attachment.
+// Unlike ExportDatabase nothing is left on disk.
+// @Summary     Streams a gzipped dump of a database as an attachment
+// @Description Streams a gzipped dump of a database as an attachment. Unlike ExportDatabase nothing is left on
+// @Description disk.
+// @ID          mySQLDownloadDatabaseExport
+// @Tags        mysql
+// @Produce     octet-stream
+// @Param       database path string true "database"
+// @Param       download query string false "download"
+// @Param       server query string false "server"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code DATABASE_NOT_FOUND or NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/databases/{database}/export [get]
+func (h *MySQLHandler) DownloadDatabaseExport(c *gin.Context) {
+	database := c.Param("database")
+
+	if download, _ := strconv.ParseBool(c.Query("download")); !download {
+		respondError(c, 400, apierror.CodeBadRequest, apierror.Message("Add ?download=true to stream the dump, or use POST /api/mysql/export/:database to export to a file"))
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.sql.gz", database, time.Now().Format("20060102_150405"))
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+
+	err := h.mysqlService(c).StreamDatabaseExport(c.Request.Context(), database, c.Writer)
+	if err == nil {
+		return
+	}
+
+	if c.Writer.Written() {
+		// The status is already sent; the archive is left truncated so the client can tell
+		log.Printf("Database export of %s failed mid-stream: %v", database, err)
+		c.Abort()
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Disposition")
+	header.Del("Cache-Control")
+	switch {
+	case errors.Is(err, services.ErrDatabaseNotFound):
+		respondError(c, 404, apierror.CodeDatabaseNotFound, err)
+	case errors.Is(err, services.ErrUnsafeDatabaseName):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to export database", err))
+	}
+}
+
+// ImportDatabase imports an uploaded dump into the database in the path, or
+// into the target_database form field when given. With as_copy=true the dump
+// goes into a new <database>_restore_<timestamp> database instead, so a backup
+// can be checked without overwriting the original.
+// @Summary     Imports an uploaded dump into the database in the path, or into the target_database form field when given
+// @Description Imports an uploaded dump into the database in the path, or into the target_database form field when
+// @Description given. With as_copy=true the dump goes into a new <database>_restore_<timestamp> database instead,
+// @Description so a backup can be checked without overwriting the original.
+// @ID          mySQLImportDatabase
+// @Tags        mysql
+// @Accept      mpfd
+// @Produce     json
+// @Param       database path string true "database"
+// @Param       server query string false "server"
+// @Param       file formData file true "file"
+// @Param       as_copy formData string false "as_copy"
+// @Param       target_database formData string false "target_database"
+// @Success     200 {object} map[string]interface{} "OK, with database, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/mysql/import/{database} [post]
 func (h *MySQLHandler) ImportDatabase(c *gin.Context) {
 	database := c.Param("database")
+	if target := c.PostForm("target_database"); target != "" {
+		database = target
+	}
+	asCopy := c.PostForm("as_copy") == "true"
 
 	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(400, gin.H{"error": "File is required"})
+		respondError(c, 400, apierror.CodeBadRequest, apierror.Message("File is required"))
 		return
 	}
 
 	// Save uploaded file
 	dst := filepath.Join(h.cfg.Paths.Backups, file.Filename)
 	if err := c.SaveUploadedFile(file, dst); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to save file"})
+		respondError(c, 500, apierror.CodeInternal, apierror.Message("Failed to save file"))
+		return
+	}
+
+	imported := database
+	if asCopy {
+		imported, err = h.mysqlService(c).ImportDatabaseCopy(c.Request.Context(), database, dst)
+	} else {
+		err = h.mysqlService(c).ImportDatabase(c.Request.Context(), database, dst)
+	}
+	if err != nil {
+		var importErr *services.SQLImportError
+		switch {
+		case errors.As(err, &importErr):
+			respondError(c, 400, apierror.CodeBadRequest, apierror.Wrap("Failed to import database", err))
+		case errors.Is(err, services.ErrUnsafeDatabaseName):
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to import database", err))
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":  "Database imported successfully",
+		"database": imported,
+	})
+}
+
+// CreateClientDatabase creates a database and dedicated user for a client within its limits
+// @Summary     Creates a database and dedicated user for a client within its limits
+// @ID          mySQLCreateClientDatabase
+// @Tags        clients
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       server query string false "server"
+// @Param       request body CreateClientDatabaseRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created, with credentials, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code LIMIT_EXCEEDED or FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code CLIENT_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code DATABASE_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Failure     503 {object} apierror.APIError "Service Unavailable, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/clients/{id}/databases [post]
+func (h *MySQLHandler) CreateClientDatabase(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid client ID"))
+		return
+	}
+
+	var req CreateClientDatabaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
-	if err := h.mysqlService.ImportDatabase(database, dst); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to import database", "details": err.Error()})
+	server := req.Server
+	if server == "" {
+		server = c.Query("server")
+	}
+
+	credentials, err := h.clientDatabaseService.CreateClientDatabase(uint(id), req.Name, server)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownMySQLServer) || errors.Is(err, services.ErrDatabaseServerNotAllowed) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+			return
+		}
+		if errors.Is(err, services.ErrMySQLUnavailable) {
+			respondError(c, 503, apierror.CodeServiceUnavailable, err)
+			return
+		}
+		switch err {
+		case services.ErrClientNotFound:
+			respondError(c, 404, apierror.CodeClientNotFound, err)
+		case services.ErrDatabaseLimitReached, services.ErrDatabaseUserLimitReached:
+			respondError(c, 403, apierror.CodeLimitExceeded, err)
+		case services.ErrInvalidDatabaseName:
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		case services.ErrDatabaseExists:
+			respondError(c, 409, apierror.CodeDatabaseExists, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to create client database", err))
+		}
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Database imported successfully"})
+	c.JSON(201, gin.H{
+		"message":     "Database created successfully",
+		"credentials": credentials,
+	})
 }
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"r-panel/internal/api/apierror"
 	"r-panel/internal/config"
 	"r-panel/internal/services"
 	"strconv"
@@ -9,33 +12,66 @@ import (
 )
 
 type NginxHandler struct {
-	nginxService *services.NginxService
+	nginxService   *services.NginxService
+	snippetService *services.NginxSnippetService
+	authService    *services.NginxAuthService
+	certDir        string // uploaded site certificates
+	autocertDir    string // certificates obtained by the panel, empty when TLS is off
+	systemService  *services.SystemService
 }
 
 func NewNginxHandler(cfg *config.Config) *NginxHandler {
-	return &NginxHandler{
-		nginxService: services.NewNginxService(
-			cfg.Paths.NginxSitesAvailable,
-			cfg.Paths.NginxSitesEnabled,
-			cfg.Paths.NginxLogs,
-		),
+	nginxService := services.NewNginxService(cfg)
+	handler := &NginxHandler{
+		nginxService:   nginxService,
+		snippetService: services.NewNginxSnippetService(nginxService),
+		authService:    services.NewNginxAuthService(nginxService, cfg.Paths.NginxAuth),
+		certDir:        cfg.Paths.SSLCertificates,
+		systemService:  services.NewSystemService(),
 	}
+	if cfg.Server.TLS.Enabled {
+		handler.autocertDir = cfg.Server.TLS.CertCacheDir()
+	}
+	return handler
 }
 
 type CreateSiteRequest struct {
-	Domain string `json:"domain" binding:"required"`
-	Config string `json:"config" binding:"required"`
+	Domain     string `json:"domain" binding:"required"`
+	Config     string `json:"config" binding:"required"`
+	ForceHTTPS bool   `json:"force_https"` // Serve over HTTPS and redirect HTTP to it
+	// Reject the config when no server_name matches the domain instead of warning
+	StrictServerName bool `json:"strict_server_name"`
+}
+
+type ForceHTTPSRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
 }
 
 type UpdateSiteRequest struct {
-	Config string `json:"config" binding:"required"`
+	Config           string `json:"config" binding:"required"`
+	StrictServerName bool   `json:"strict_server_name"` // see CreateSiteRequest
+}
+
+type CloneSiteRequest struct {
+	NewDomain string `json:"new_domain" binding:"required"`
 }
 
 // GetSites returns all Nginx sites
+// @Summary     Returns all Nginx sites
+// @ID          nginxGetSites
+// @Tags        nginx
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with sites"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites [get]
 func (h *NginxHandler) GetSites(c *gin.Context) {
 	sites, err := h.nginxService.GetSites()
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get sites", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get sites", err))
 		return
 	}
 
@@ -43,12 +79,29 @@ func (h *NginxHandler) GetSites(c *gin.Context) {
 }
 
 // GetSite returns a specific site
+// @Summary     Returns a specific site
+// @ID          nginxGetSite
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain} [get]
 func (h *NginxHandler) GetSite(c *gin.Context) {
 	domain := c.Param("domain")
 
 	site, err := h.nginxService.GetSite(domain)
 	if err != nil {
-		c.JSON(404, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrInvalidDomain) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		} else {
+			respondError(c, 404, apierror.CodeSiteNotFound, err)
+		}
 		return
 	}
 
@@ -56,57 +109,356 @@ func (h *NginxHandler) GetSite(c *gin.Context) {
 }
 
 // CreateSite creates a new site
+// @Summary     Creates a new site
+// @ID          nginxCreateSite
+// @Tags        nginx
+// @Accept      json
+// @Produce     json
+// @Param       request body CreateSiteRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites [post]
 func (h *NginxHandler) CreateSite(c *gin.Context) {
 	var req CreateSiteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
-	if err := h.nginxService.CreateSite(req.Domain, req.Config); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	saved, ok := checkServerNames(c, req.Domain, req.Config, req.StrictServerName)
+	if !ok {
 		return
 	}
 
-	c.JSON(201, gin.H{"message": "Site created successfully"})
+	config := req.Config
+	if req.ForceHTTPS {
+		cert, err := h.httpsCertificate(req.Domain, req.Config)
+		if err != nil {
+			respondHTTPSError(c, err)
+			return
+		}
+		if config, err = services.ForceHTTPSConfig(req.Config, req.Domain, cert); err != nil {
+			respondHTTPSError(c, err)
+			return
+		}
+	}
+
+	if err := h.nginxService.CreateSite(req.Domain, config); err != nil {
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
+		return
+	}
+
+	saved["message"] = "Site created successfully"
+	c.JSON(201, saved)
+}
+
+// checkServerNames checks that a server_name of config serves domain. The
+// response fields it returns list the server names, and warn about a
+// mismatch unless strict, which rejects the config instead.
+func checkServerNames(c *gin.Context, domain, config string, strict bool) (gin.H, bool) {
+	names, err := services.CheckSiteServerNames(domain, config)
+	if err != nil && strict {
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+		return nil, false
+	}
+
+	fields := gin.H{"server_names": names}
+	if err != nil {
+		fields["warning"] = err.Error()
+	}
+	return fields, true
 }
 
 // UpdateSite updates a site
+// @Summary     Updates a site
+// @ID          nginxUpdateSite
+// @Tags        nginx
+// @Accept      json
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       request body UpdateSiteRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain} [put]
 func (h *NginxHandler) UpdateSite(c *gin.Context) {
 	domain := c.Param("domain")
 
 	var req UpdateSiteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	saved, ok := checkServerNames(c, domain, req.Config, req.StrictServerName)
+	if !ok {
 		return
 	}
 
 	if err := h.nginxService.UpdateSite(domain, req.Config); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
+		return
+	}
+
+	saved["message"] = "Site updated successfully"
+	c.JSON(200, saved)
+}
+
+// GetUpstreamHealth probes the backends a site proxies to. ?check=tcp only
+// connects, the default http check sends a request.
+// @Summary     Probes the backends a site proxies to
+// @Description Probes the backends a site proxies to. ?check=tcp only connects, the default http check sends a
+// @Description request.
+// @ID          nginxGetUpstreamHealth
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       check query string false "check"
+// @Success     200 {object} map[string]interface{} "OK, with backends, domain"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/upstream-health [get]
+func (h *NginxHandler) GetUpstreamHealth(c *gin.Context) {
+	domain := c.Param("domain")
+
+	backends, err := h.nginxService.GetUpstreamHealth(domain, c.Query("check"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidDomain), errors.Is(err, services.ErrInvalidProbeMode):
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		case errors.Is(err, services.ErrSiteNotFound):
+			respondError(c, 404, apierror.CodeSiteNotFound, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to check upstreams", err))
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{"domain": domain, "backends": backends})
+}
+
+// GetSiteVersions lists the saved previous configs of a site
+// @Summary     Lists the saved previous configs of a site
+// @ID          nginxGetSiteVersions
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Success     200 {object} map[string]interface{} "OK, with versions"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND or SITE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/versions [get]
+func (h *NginxHandler) GetSiteVersions(c *gin.Context) {
+	versions, err := h.nginxService.GetSiteVersions(c.Param("domain"))
+	if err != nil {
+		respondSiteVersionError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"versions": versions})
+}
+
+// RestoreSiteVersion puts a saved previous config of a site back
+// @Summary     Puts a saved previous config of a site back
+// @ID          nginxRestoreSiteVersion
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       version path string true "version"
+// @Success     200 {object} map[string]interface{} "OK, with config, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND or SITE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/restore/{version} [post]
+func (h *NginxHandler) RestoreSiteVersion(c *gin.Context) {
+	config, err := h.nginxService.RestoreSiteVersion(c.Param("domain"), c.Param("version"))
+	if err != nil {
+		respondSiteVersionError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Site config restored, reload Nginx to apply it", "config": config})
+}
+
+// respondSiteVersionError maps config version errors to their HTTP status
+func respondSiteVersionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSiteNotFound):
+		respondError(c, 404, apierror.CodeSiteNotFound, err)
+	case errors.Is(err, services.ErrConfigVersionNotFound):
+		respondError(c, 404, apierror.CodeNotFound, err)
+	case errors.Is(err, services.ErrInvalidDomain):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to access site versions", err))
+	}
+}
+
+// PreviewSiteUpdate diffs a proposed config against the site's current one and
+// tests it with nginx -t, without writing the site
+// @Summary     Diffs a proposed config against the site's current one and tests it with nginx -t, without writing the site
+// @ID          nginxPreviewSiteUpdate
+// @Tags        nginx
+// @Accept      json
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       request body UpdateSiteRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/diff [post]
+func (h *NginxHandler) PreviewSiteUpdate(c *gin.Context) {
+	domain := c.Param("domain")
+
+	var req UpdateSiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	preview, err := h.nginxService.PreviewSiteUpdate(c.Request.Context(), domain, req.Config)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSiteNotFound):
+			respondError(c, 404, apierror.CodeSiteNotFound, err)
+		case errors.Is(err, services.ErrInvalidDomain):
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to preview site config", err))
+		}
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Site updated successfully"})
+	c.JSON(200, preview)
 }
 
 // DeleteSite deletes a site
+// @Summary     Deletes a site
+// @ID          nginxDeleteSite
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain} [delete]
 func (h *NginxHandler) DeleteSite(c *gin.Context) {
 	domain := c.Param("domain")
 
 	if err := h.nginxService.DeleteSite(domain); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
+		return
+	}
+	if err := h.authService.RemoveSite(domain); err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Site deleted but its password file was not", err))
+		return
+	}
+	if err := h.snippetService.ForgetSite(domain); err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Site deleted but its snippet attachments were not", err))
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Site deleted successfully"})
 }
 
+// CloneSite copies a site's config to a new, disabled site for another domain
+// @Summary     Copies a site's config to a new, disabled site for another domain
+// @ID          nginxCloneSite
+// @Tags        nginx
+// @Accept      json
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       request body CloneSiteRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created, with config, domain, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code SITE_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/clone [post]
+func (h *NginxHandler) CloneSite(c *gin.Context) {
+	domain := c.Param("domain")
+
+	var req CloneSiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	config, err := h.nginxService.CloneSite(domain, req.NewDomain)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSiteNotFound):
+			respondError(c, 404, apierror.CodeSiteNotFound, err)
+		case errors.Is(err, services.ErrSiteExists):
+			respondError(c, 409, apierror.CodeSiteExists, err)
+		case errors.Is(err, services.ErrInvalidDomain):
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to clone site", err))
+		}
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"message": "Site cloned, review its config before enabling it",
+		"domain":  req.NewDomain,
+		"config":  config,
+	})
+}
+
 // EnableSite enables a site
+// @Summary     Enables a site
+// @ID          nginxEnableSite
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/enable [post]
 func (h *NginxHandler) EnableSite(c *gin.Context) {
 	domain := c.Param("domain")
 
 	if err := h.nginxService.EnableSite(domain); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
@@ -114,42 +466,238 @@ func (h *NginxHandler) EnableSite(c *gin.Context) {
 }
 
 // DisableSite disables a site
+// @Summary     Disables a site
+// @ID          nginxDisableSite
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/disable [post]
 func (h *NginxHandler) DisableSite(c *gin.Context) {
 	domain := c.Param("domain")
 
 	if err := h.nginxService.DisableSite(domain); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Site disabled successfully"})
 }
 
+// ForceHTTPS switches a site to HTTPS with an HTTP redirect, or back to plain HTTP,
+// and reloads Nginx once the new config passes its test
+// @Summary     Switches a site to HTTPS with an HTTP redirect, or back to plain HTTP, and reloads Nginx once the new config passes its test
+// @ID          nginxForceHTTPS
+// @Tags        nginx
+// @Accept      json
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       request body ForceHTTPSRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with force_https, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/force-https [post]
+func (h *NginxHandler) ForceHTTPS(c *gin.Context) {
+	domain := c.Param("domain")
+
+	var req ForceHTTPSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	site, err := h.nginxService.GetSite(domain)
+	if err != nil {
+		respondHTTPSError(c, err)
+		return
+	}
+
+	var cert *services.SiteCertificate
+	if *req.Enabled {
+		if cert, err = h.httpsCertificate(domain, site.Config); err != nil {
+			respondHTTPSError(c, err)
+			return
+		}
+	}
+
+	if err := h.nginxService.SetForceHTTPS(c.Request.Context(), domain, cert); err != nil {
+		respondHTTPSError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Site updated and Nginx reloaded", "force_https": *req.Enabled})
+}
+
+// httpsCertificate checks that the site's owner may use SSL and finds its certificate
+func (h *NginxHandler) httpsCertificate(domain, config string) (*services.SiteCertificate, error) {
+	owner, err := h.nginxService.SiteOwner(config)
+	if err != nil {
+		return nil, err
+	}
+	if owner != nil && !owner.ClientLimits.LimitSSL {
+		return nil, services.ErrSSLNotAllowed
+	}
+	return h.nginxService.FindCertificate(domain, h.certDir, h.autocertDir)
+}
+
+// respondHTTPSError maps force-https errors to their HTTP status
+func respondHTTPSError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSiteNotFound):
+		respondError(c, 404, apierror.CodeSiteNotFound, err)
+	case errors.Is(err, services.ErrSSLNotAllowed):
+		respondError(c, 403, apierror.CodeForbidden, err)
+	case errors.Is(err, services.ErrInvalidDomain), errors.Is(err, services.ErrNoCertificate),
+		errors.Is(err, services.ErrNoPlainHTTPServer):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	case errors.Is(err, services.ErrNginxConfigInvalid):
+		respondError(c, 400, apierror.CodeBadRequest, apierror.Wrap("Configuration test failed, the site config was restored", err))
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to update site", err))
+	}
+}
+
+// GetStatus returns whether Nginx is running and whether enabled sites changed
+// since it was last reloaded
+// @Summary     Returns whether Nginx is running and whether enabled sites changed since it was last reloaded
+// @ID          nginxGetStatus
+// @Tags        nginx
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with changed_since, reload_pending, status"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/status [get]
+func (h *NginxHandler) GetStatus(c *gin.Context) {
+	state, err := h.nginxService.ReloadState()
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get Nginx status", err))
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status":         serviceStatus(c.Request.Context(), h.systemService, "nginx"),
+		"reload_pending": state.ReloadPending,
+		"changed_since":  state.ChangedSince,
+	})
+}
+
 // TestConfig tests Nginx configuration
+// @Summary     Tests Nginx configuration
+// @ID          nginxTestConfig
+// @Tags        nginx
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/test [post]
 func (h *NginxHandler) TestConfig(c *gin.Context) {
-	if err := h.nginxService.TestConfig(); err != nil {
-		c.JSON(400, gin.H{"error": "Configuration test failed", "details": err.Error()})
+	if err := h.nginxService.TestConfig(c.Request.Context()); err != nil {
+		respondError(c, 400, apierror.CodeBadRequest, apierror.Wrap("Configuration test failed", err))
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Configuration is valid"})
 }
 
-// Reload reloads Nginx
+// Reload tests the configuration and reloads Nginx; ?force=true skips the test
+// @Summary     Tests the configuration and reloads Nginx; ?force=true skips the test
+// @ID          nginxReload
+// @Tags        nginx
+// @Produce     json
+// @Param       force query string false "force"
+// @Success     200 {object} map[string]interface{} "OK, with message, status"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/reload [post]
 func (h *NginxHandler) Reload(c *gin.Context) {
-	if err := h.nginxService.Reload(); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to reload Nginx", "details": err.Error()})
+	action, forced := h.nginxService.Reload, h.nginxService.ForceReload
+	h.runServiceAction(c, "reloaded", action, forced)
+}
+
+// Restart restarts Nginx, which also starts it when it is stopped.
+// Like Reload it tests the configuration first unless ?force=true.
+// @Summary     Restarts Nginx, which also starts it when it is stopped
+// @Description Restarts Nginx, which also starts it when it is stopped. Like Reload it tests the configuration
+// @Description first unless ?force=true.
+// @ID          nginxRestart
+// @Tags        nginx
+// @Produce     json
+// @Param       force query string false "force"
+// @Success     200 {object} map[string]interface{} "OK, with message, status"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/restart [post]
+func (h *NginxHandler) Restart(c *gin.Context) {
+	action, forced := h.nginxService.Restart, h.nginxService.ForceRestart
+	h.runServiceAction(c, "restarted", action, forced)
+}
+
+// runServiceAction reloads or restarts Nginx and reports the state the service
+// is in afterwards, so a failed action shows whether Nginx is still serving
+func (h *NginxHandler) runServiceAction(c *gin.Context, done string, action, forced func(context.Context) error) {
+	if force, _ := strconv.ParseBool(c.Query("force")); force {
+		action = forced
+	}
+
+	ctx := c.Request.Context()
+	err := action(ctx)
+	status := serviceStatus(ctx, h.systemService, "nginx")
+	if err != nil {
+		if errors.Is(err, services.ErrNginxConfigInvalid) {
+			respondError(c, 400, apierror.CodeBadRequest, apierror.Wrap("Configuration test failed, Nginx was not "+done, err))
+		} else {
+			message := withServiceStatus("Nginx could not be "+done, status)
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap(message, err))
+		}
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Nginx reloaded successfully"})
+	c.JSON(200, gin.H{"message": "Nginx " + done + " successfully", "status": status})
 }
 
 // GetLogs returns Nginx logs
+// @Summary     Returns Nginx logs
+// @ID          nginxGetLogs
+// @Tags        nginx
+// @Produce     json
+// @Param       type path string true "type"
+// @Param       lines query string false "lines"
+// @Success     200 {object} map[string]interface{} "OK, with logs, type"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/logs/{type} [get]
 func (h *NginxHandler) GetLogs(c *gin.Context) {
 	logType := c.Param("type") // access or error
 	if logType != "access" && logType != "error" {
-		c.JSON(400, gin.H{"error": "Invalid log type. Use 'access' or 'error'"})
+		respondError(c, 400, apierror.CodeBadRequest, apierror.Message("Invalid log type. Use 'access' or 'error'"))
 		return
 	}
 
@@ -160,9 +708,9 @@ func (h *NginxHandler) GetLogs(c *gin.Context) {
 		}
 	}
 
-	logs, err := h.nginxService.GetLogs(logType, lines)
+	logs, err := h.nginxService.GetLogs(c.Request.Context(), logType, lines)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to read logs", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to read logs", err))
 		return
 	}
 
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SiteAuthUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type SiteAuthToggleRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// respondSiteAuthError maps basic auth errors to their HTTP status
+func respondSiteAuthError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrSiteNotFound):
+		respondError(c, 404, apierror.CodeSiteNotFound, err)
+	case errors.Is(err, services.ErrAuthUserNotFound):
+		respondError(c, 404, apierror.CodeNotFound, err)
+	case errors.Is(err, services.ErrInvalidAuthUser), errors.Is(err, services.ErrNoAuthUsers),
+		errors.Is(err, services.ErrNoServerBlock), errors.Is(err, services.ErrInvalidDomain):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	case errors.Is(err, services.ErrNginxConfigInvalid):
+		respondError(c, 400, apierror.CodeBadRequest, apierror.Wrap("Configuration test failed, the site config was restored", err))
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap(message, err))
+	}
+}
+
+// GetSiteAuth returns whether a site is password protected and its users
+// @Summary     Returns whether a site is password protected and its users
+// @ID          nginxGetSiteAuth
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND or SITE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/auth [get]
+func (h *NginxHandler) GetSiteAuth(c *gin.Context) {
+	auth, err := h.authService.GetSiteAuth(c.Param("domain"))
+	if err != nil {
+		respondSiteAuthError(c, err, "Failed to get basic auth")
+		return
+	}
+
+	c.JSON(200, auth)
+}
+
+// SetSiteAuthUser adds a basic auth user to a site or changes its password
+// @Summary     Adds a basic auth user to a site or changes its password
+// @ID          nginxSetSiteAuthUser
+// @Tags        nginx
+// @Accept      json
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       request body SiteAuthUserRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND or SITE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/auth [post]
+func (h *NginxHandler) SetSiteAuthUser(c *gin.Context) {
+	var req SiteAuthUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	if err := h.authService.SetUser(c.Param("domain"), req.Username, req.Password); err != nil {
+		respondSiteAuthError(c, err, "Failed to save basic auth user")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Basic auth user saved successfully"})
+}
+
+// DeleteSiteAuthUser removes a basic auth user from a site
+// @Summary     Removes a basic auth user from a site
+// @ID          nginxDeleteSiteAuthUser
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       username path string true "username"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND or SITE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/auth/{username} [delete]
+func (h *NginxHandler) DeleteSiteAuthUser(c *gin.Context) {
+	if err := h.authService.RemoveUser(c.Param("domain"), c.Param("username")); err != nil {
+		respondSiteAuthError(c, err, "Failed to remove basic auth user")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Basic auth user removed successfully"})
+}
+
+// ToggleSiteAuth turns password protection of a site on or off and reloads Nginx
+// @Summary     Turns password protection of a site on or off and reloads Nginx
+// @ID          nginxToggleSiteAuth
+// @Tags        nginx
+// @Accept      json
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       request body SiteAuthToggleRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with enabled, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND or SITE_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/auth/toggle [post]
+func (h *NginxHandler) ToggleSiteAuth(c *gin.Context) {
+	var req SiteAuthToggleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	if err := h.authService.SetEnabled(c.Request.Context(), c.Param("domain"), *req.Enabled); err != nil {
+		respondSiteAuthError(c, err, "Failed to update basic auth")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Site updated and Nginx reloaded", "enabled": *req.Enabled})
+}
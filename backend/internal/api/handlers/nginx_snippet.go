@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NginxSnippetRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Content     string `json:"content" binding:"required"`
+}
+
+func (r *NginxSnippetRequest) data() *services.NginxSnippetData {
+	return &services.NginxSnippetData{
+		Name:        r.Name,
+		Description: r.Description,
+		Content:     r.Content,
+	}
+}
+
+// respondSnippetError maps snippet service errors to their HTTP status
+func respondSnippetError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNginxSnippetNotFound):
+		respondError(c, 404, apierror.CodeSnippetNotFound, err)
+	case errors.Is(err, services.ErrSiteNotFound):
+		respondError(c, 404, apierror.CodeSiteNotFound, err)
+	case errors.Is(err, services.ErrNginxSnippetExists):
+		respondError(c, 409, apierror.CodeSnippetExists, err)
+	case errors.Is(err, services.ErrSnippetsNotAllowed):
+		respondError(c, 403, apierror.CodeForbidden, err)
+	case errors.Is(err, services.ErrInvalidNginxSnippet), errors.Is(err, services.ErrInvalidDomain):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	case errors.Is(err, services.ErrNginxConfigInvalid):
+		respondError(c, 400, apierror.CodeBadRequest, apierror.Wrap("Configuration test failed, site configs were restored", err))
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap(message, err))
+	}
+}
+
+func parseSnippetID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid snippet ID"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetSnippets returns all snippets
+// @Summary     Returns all snippets
+// @ID          nginxGetSnippets
+// @Tags        nginx
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with snippets"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/snippets [get]
+func (h *NginxHandler) GetSnippets(c *gin.Context) {
+	snippets, err := h.snippetService.GetSnippets()
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get snippets", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"snippets": snippets})
+}
+
+// GetSnippet returns a specific snippet
+// @Summary     Returns a specific snippet
+// @ID          nginxGetSnippet
+// @Tags        nginx
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND or SNIPPET_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code SNIPPET_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/snippets/{id} [get]
+func (h *NginxHandler) GetSnippet(c *gin.Context) {
+	id, ok := parseSnippetID(c)
+	if !ok {
+		return
+	}
+
+	snippet, err := h.snippetService.GetSnippet(id)
+	if err != nil {
+		respondSnippetError(c, err, "Failed to get snippet")
+		return
+	}
+
+	c.JSON(200, snippet)
+}
+
+// CreateSnippet creates a snippet
+// @Summary     Creates a snippet
+// @ID          nginxCreateSnippet
+// @Tags        nginx
+// @Accept      json
+// @Produce     json
+// @Param       request body NginxSnippetRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND or SNIPPET_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code SNIPPET_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/snippets [post]
+func (h *NginxHandler) CreateSnippet(c *gin.Context) {
+	var req NginxSnippetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	snippet, err := h.snippetService.CreateSnippet(req.data())
+	if err != nil {
+		respondSnippetError(c, err, "Failed to create snippet")
+		return
+	}
+
+	c.JSON(201, snippet)
+}
+
+// UpdateSnippet updates a snippet and regenerates the sites using it
+// @Summary     Updates a snippet and regenerates the sites using it
+// @ID          nginxUpdateSnippet
+// @Tags        nginx
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       request body NginxSnippetRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND or SNIPPET_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code SNIPPET_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/snippets/{id} [put]
+func (h *NginxHandler) UpdateSnippet(c *gin.Context) {
+	id, ok := parseSnippetID(c)
+	if !ok {
+		return
+	}
+
+	var req NginxSnippetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	snippet, err := h.snippetService.UpdateSnippet(c.Request.Context(), id, req.data())
+	if err != nil {
+		respondSnippetError(c, err, "Failed to update snippet")
+		return
+	}
+
+	c.JSON(200, snippet)
+}
+
+// DeleteSnippet detaches a snippet from every site and deletes it
+// @Summary     Detaches a snippet from every site and deletes it
+// @ID          nginxDeleteSnippet
+// @Tags        nginx
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND or SNIPPET_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code SNIPPET_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/snippets/{id} [delete]
+func (h *NginxHandler) DeleteSnippet(c *gin.Context) {
+	id, ok := parseSnippetID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.snippetService.DeleteSnippet(c.Request.Context(), id); err != nil {
+		respondSnippetError(c, err, "Failed to delete snippet")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Snippet deleted successfully"})
+}
+
+// GetSiteSnippets returns the snippets attached to a site
+// @Summary     Returns the snippets attached to a site
+// @ID          nginxGetSiteSnippets
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Success     200 {object} map[string]interface{} "OK, with snippets"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND or SNIPPET_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code SNIPPET_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/snippets [get]
+func (h *NginxHandler) GetSiteSnippets(c *gin.Context) {
+	snippets, err := h.snippetService.GetSiteSnippets(c.Param("domain"))
+	if err != nil {
+		respondSnippetError(c, err, "Failed to get site snippets")
+		return
+	}
+
+	c.JSON(200, gin.H{"snippets": snippets})
+}
+
+// AttachSnippet adds a snippet to a site config
+// @Summary     Adds a snippet to a site config
+// @ID          nginxAttachSnippet
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND or SNIPPET_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code SNIPPET_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/snippets/{id} [post]
+func (h *NginxHandler) AttachSnippet(c *gin.Context) {
+	id, ok := parseSnippetID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.snippetService.AttachSnippet(c.Request.Context(), c.Param("domain"), id); err != nil {
+		respondSnippetError(c, err, "Failed to attach snippet")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Snippet attached successfully"})
+}
+
+// DetachSnippet removes a snippet from a site config
+// @Summary     Removes a snippet from a site config
+// @ID          nginxDetachSnippet
+// @Tags        nginx
+// @Produce     json
+// @Param       domain path string true "domain"
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code SITE_NOT_FOUND or SNIPPET_NOT_FOUND"
+// @Failure     409 {object} apierror.APIError "Conflict, code SNIPPET_EXISTS"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/nginx/sites/{domain}/snippets/{id} [delete]
+func (h *NginxHandler) DetachSnippet(c *gin.Context) {
+	id, ok := parseSnippetID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.snippetService.DetachSnippet(c.Request.Context(), c.Param("domain"), id); err != nil {
+		respondSnippetError(c, err, "Failed to detach snippet")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Snippet detached successfully"})
+}
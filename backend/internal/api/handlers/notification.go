@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/config"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(cfg *config.Config) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: services.NewNotificationService(cfg),
+	}
+}
+
+type TestNotificationRequest struct {
+	To string `json:"to"` // Defaults to the configured recipients
+}
+
+// TestNotification sends a test notification, with the same retries as real ones
+// @Summary     Sends a test notification, with the same retries as real ones
+// @ID          notificationTestNotification
+// @Tags        notifications
+// @Accept      json
+// @Produce     json
+// @Param       request body TestNotificationRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with message, to"
+// @Failure     400 {object} apierror.APIError "Bad Request, code SMTP_NOT_CONFIGURED or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     502 {object} apierror.APIError "Bad Gateway, code EMAIL_SEND_FAILED"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/notifications/test [post]
+func (h *NotificationHandler) TestNotification(c *gin.Context) {
+	var req TestNotificationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+			return
+		}
+	}
+
+	recipients := h.notificationService.Recipients()
+	if req.To != "" {
+		recipients = []string{req.To}
+	}
+	if len(recipients) == 0 {
+		respondError(c, 400, apierror.CodeValidationFailed, services.ErrNoNotificationRecipients)
+		return
+	}
+
+	subject := "[R-Panel] Test notification"
+	body := fmt.Sprintf("This is a test notification sent from R-Panel at %s.\n\nIf you received it, admin notifications are working.", time.Now().Format(time.RFC1123))
+
+	for _, to := range recipients {
+		if err := h.notificationService.SendEmail(to, subject, body); err != nil {
+			if errors.Is(err, services.ErrSMTPNotConfigured) {
+				respondError(c, 400, apierror.CodeSMTPNotConfigured, err)
+			} else {
+				respondError(c, 502, apierror.CodeEmailFailed, apierror.Wrap("Failed to send test notification to "+to, err))
+			}
+			return
+		}
+	}
+
+	c.JSON(200, gin.H{"message": "Test notification sent successfully", "to": recipients})
+}
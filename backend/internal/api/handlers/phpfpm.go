@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"r-panel/internal/api/apierror"
 	"r-panel/internal/config"
 	"r-panel/internal/services"
 
@@ -9,11 +12,14 @@ import (
 
 type PHPFPMHandler struct {
 	phpfpmService *services.PHPFPMService
+	systemService *services.SystemService
 }
 
 func NewPHPFPMHandler(cfg *config.Config) *PHPFPMHandler {
+	cacheTTL, _ := cfg.PHPFPM.CacheTTLDuration() // validated when the config was loaded
 	return &PHPFPMHandler{
-		phpfpmService: services.NewPHPFPMService(cfg.Paths.PHPFPM),
+		phpfpmService: services.NewPHPFPMService(cfg.Paths.PHPFPM, cacheTTL),
+		systemService: services.NewSystemService(),
 	}
 }
 
@@ -28,10 +34,21 @@ type UpdatePoolRequest struct {
 }
 
 // GetVersions returns installed PHP versions
+// @Summary     Returns installed PHP versions
+// @ID          phpfpmGetVersions
+// @Tags        phpfpm
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with versions"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/versions [get]
 func (h *PHPFPMHandler) GetVersions(c *gin.Context) {
 	versions, err := h.phpfpmService.GetPHPVersions()
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get PHP versions", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get PHP versions", err))
 		return
 	}
 
@@ -39,10 +56,21 @@ func (h *PHPFPMHandler) GetVersions(c *gin.Context) {
 }
 
 // GetPools returns all pools
+// @Summary     Returns all pools
+// @ID          phpfpmGetPools
+// @Tags        phpfpm
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with pools"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/pools [get]
 func (h *PHPFPMHandler) GetPools(c *gin.Context) {
 	pools, err := h.phpfpmService.GetPools()
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get pools", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get pools", err))
 		return
 	}
 
@@ -50,13 +78,31 @@ func (h *PHPFPMHandler) GetPools(c *gin.Context) {
 }
 
 // GetPool returns a specific pool
+// @Summary     Returns a specific pool
+// @ID          phpfpmGetPool
+// @Tags        phpfpm
+// @Produce     json
+// @Param       version path string true "version"
+// @Param       name path string true "name"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code POOL_NOT_FOUND"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/pools/{version}/{name} [get]
 func (h *PHPFPMHandler) GetPool(c *gin.Context) {
 	phpVersion := c.Param("version")
 	poolName := c.Param("name")
 
 	pool, err := h.phpfpmService.GetPool(phpVersion, poolName)
 	if err != nil {
-		c.JSON(404, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrInvalidPHPVersion) || errors.Is(err, services.ErrInvalidPoolName) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		} else {
+			respondError(c, 404, apierror.CodePoolNotFound, err)
+		}
 		return
 	}
 
@@ -64,15 +110,28 @@ func (h *PHPFPMHandler) GetPool(c *gin.Context) {
 }
 
 // CreatePool creates a new pool
+// @Summary     Creates a new pool
+// @ID          phpfpmCreatePool
+// @Tags        phpfpm
+// @Accept      json
+// @Produce     json
+// @Param       request body CreatePoolRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/pools [post]
 func (h *PHPFPMHandler) CreatePool(c *gin.Context) {
 	var req CreatePoolRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
 	if err := h.phpfpmService.CreatePool(req.PHPVersion, req.PoolName, req.Config); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
@@ -80,45 +139,227 @@ func (h *PHPFPMHandler) CreatePool(c *gin.Context) {
 }
 
 // UpdatePool updates a pool
+// @Summary     Updates a pool
+// @ID          phpfpmUpdatePool
+// @Tags        phpfpm
+// @Accept      json
+// @Produce     json
+// @Param       version path string true "version"
+// @Param       name path string true "name"
+// @Param       request body UpdatePoolRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/pools/{version}/{name} [put]
 func (h *PHPFPMHandler) UpdatePool(c *gin.Context) {
 	phpVersion := c.Param("version")
 	poolName := c.Param("name")
 
 	var req UpdatePoolRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
 	if err := h.phpfpmService.UpdatePool(phpVersion, poolName, req.Config); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Pool updated successfully"})
 }
 
+// GetPoolVersions lists the saved previous configs of a pool
+// @Summary     Lists the saved previous configs of a pool
+// @ID          phpfpmGetPoolVersions
+// @Tags        phpfpm
+// @Produce     json
+// @Param       version path string true "version"
+// @Param       name path string true "name"
+// @Success     200 {object} map[string]interface{} "OK, with versions"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND or POOL_NOT_FOUND"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/pools/{version}/{name}/versions [get]
+func (h *PHPFPMHandler) GetPoolVersions(c *gin.Context) {
+	versions, err := h.phpfpmService.GetPoolVersions(c.Param("version"), c.Param("name"))
+	if err != nil {
+		respondPoolVersionError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"versions": versions})
+}
+
+// RestorePoolVersion puts a saved previous config of a pool back
+// @Summary     Puts a saved previous config of a pool back
+// @ID          phpfpmRestorePoolVersion
+// @Tags        phpfpm
+// @Produce     json
+// @Param       version path string true "version"
+// @Param       name path string true "name"
+// @Param       revision path string true "revision"
+// @Success     200 {object} map[string]interface{} "OK, with config, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code NOT_FOUND or POOL_NOT_FOUND"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/pools/{version}/{name}/restore/{revision} [post]
+func (h *PHPFPMHandler) RestorePoolVersion(c *gin.Context) {
+	config, err := h.phpfpmService.RestorePoolVersion(c.Param("version"), c.Param("name"), c.Param("revision"))
+	if err != nil {
+		respondPoolVersionError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Pool config restored, reload PHP-FPM to apply it", "config": config})
+}
+
+// respondPoolVersionError maps config version errors to their HTTP status
+func respondPoolVersionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPHPVersion), errors.Is(err, services.ErrInvalidPoolName):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	case errors.Is(err, services.ErrConfigVersionNotFound):
+		respondError(c, 404, apierror.CodeNotFound, err)
+	default:
+		respondError(c, 404, apierror.CodePoolNotFound, err)
+	}
+}
+
 // DeletePool deletes a pool
+// @Summary     Deletes a pool
+// @ID          phpfpmDeletePool
+// @Tags        phpfpm
+// @Produce     json
+// @Param       version path string true "version"
+// @Param       name path string true "name"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/pools/{version}/{name} [delete]
 func (h *PHPFPMHandler) DeletePool(c *gin.Context) {
 	phpVersion := c.Param("version")
 	poolName := c.Param("name")
 
 	if err := h.phpfpmService.DeletePool(phpVersion, poolName); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Pool deleted successfully"})
 }
 
+// PHPFPMVersionStatus is the state of the PHP-FPM service of one PHP version
+type PHPFPMVersionStatus struct {
+	Version string                  `json:"version"`
+	Status  *services.ServiceStatus `json:"status"`
+	services.ReloadState
+}
+
+// GetStatus returns whether the PHP-FPM service of each installed version is
+// running and whether its pools changed since it was last reloaded
+// @Summary     Returns whether the PHP-FPM service of each installed version is running and whether its pools changed since it was last reloaded
+// @ID          phpfpmGetStatus
+// @Tags        phpfpm
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with reload_pending, versions"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/status [get]
+func (h *PHPFPMHandler) GetStatus(c *gin.Context) {
+	versions, err := h.phpfpmService.GetPHPVersions()
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get PHP versions", err))
+		return
+	}
+
+	statuses := []PHPFPMVersionStatus{}
+	reloadPending := false
+	for _, version := range versions {
+		state, err := h.phpfpmService.ReloadState(version)
+		if err != nil {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get PHP-FPM status", err))
+			return
+		}
+		reloadPending = reloadPending || state.ReloadPending
+		statuses = append(statuses, PHPFPMVersionStatus{
+			Version:     version,
+			Status:      serviceStatus(c.Request.Context(), h.systemService, services.PHPFPMServiceName(version)),
+			ReloadState: state,
+		})
+	}
+
+	c.JSON(200, gin.H{"versions": statuses, "reload_pending": reloadPending})
+}
+
 // ReloadPHPFPM reloads PHP-FPM service
+// @Summary     Reloads PHP-FPM service
+// @ID          phpfpmReloadPHPFPM
+// @Tags        phpfpm
+// @Produce     json
+// @Param       version path string true "version"
+// @Success     200 {object} map[string]interface{} "OK, with message, status"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/reload/{version} [post]
 func (h *PHPFPMHandler) ReloadPHPFPM(c *gin.Context) {
+	h.runServiceAction(c, "reloaded", h.phpfpmService.ReloadPHPFPM)
+}
+
+// RestartPHPFPM restarts PHP-FPM service, which also starts it when it is stopped
+// @Summary     Restarts PHP-FPM service, which also starts it when it is stopped
+// @ID          phpfpmRestartPHPFPM
+// @Tags        phpfpm
+// @Produce     json
+// @Param       version path string true "version"
+// @Success     200 {object} map[string]interface{} "OK, with message, status"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/phpfpm/restart/{version} [post]
+func (h *PHPFPMHandler) RestartPHPFPM(c *gin.Context) {
+	h.runServiceAction(c, "restarted", h.phpfpmService.RestartPHPFPM)
+}
+
+// runServiceAction reloads or restarts the PHP-FPM service of a version and
+// reports the state the service is in afterwards
+func (h *PHPFPMHandler) runServiceAction(c *gin.Context, done string, action func(context.Context, string) error) {
 	phpVersion := c.Param("version")
 
-	if err := h.phpfpmService.ReloadPHPFPM(phpVersion); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to reload PHP-FPM", "details": err.Error()})
+	ctx := c.Request.Context()
+	if err := action(ctx, phpVersion); err != nil {
+		if errors.Is(err, services.ErrInvalidPHPVersion) {
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+			return
+		}
+		status := serviceStatus(ctx, h.systemService, services.PHPFPMServiceName(phpVersion))
+		message := withServiceStatus("PHP-FPM could not be "+done, status)
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap(message, err))
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "PHP-FPM reloaded successfully"})
+	status := serviceStatus(ctx, h.systemService, services.PHPFPMServiceName(phpVersion))
+	c.JSON(200, gin.H{"message": "PHP-FPM " + done + " successfully", "status": status})
 }
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"r-panel/internal/services"
+)
+
+// serviceStatus returns the state of a service, such as after a reload or
+// restart, nil when systemctl could not tell
+func serviceStatus(ctx context.Context, system *services.SystemService, name string) *services.ServiceStatus {
+	status, err := system.GetServiceStatus(ctx, name)
+	if err != nil {
+		fmt.Printf("Warning: failed to get status of %s: %v\n", name, err)
+		return nil
+	}
+	return status
+}
+
+// withServiceStatus appends the state a service was left in to message
+func withServiceStatus(message string, status *services.ServiceStatus) string {
+	if status == nil {
+		return message
+	}
+	return fmt.Sprintf("%s, %s is %s", message, status.Name, status.Status)
+}
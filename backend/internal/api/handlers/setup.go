@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SetupHandler struct {
+	setupService *services.SetupService
+	authService  *services.AuthService
+	jwtService   *services.JWTService
+}
+
+func NewSetupHandler(authService *services.AuthService, jwtService *services.JWTService) *SetupHandler {
+	return &SetupHandler{
+		setupService: services.NewSetupServiceWithAuth(authService),
+		authService:  authService,
+		jwtService:   jwtService,
+	}
+}
+
+type SetupRequest struct {
+	Username   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	PanelName  string `json:"panel_name"`
+	AdminEmail string `json:"admin_email"`
+	Timezone   string `json:"timezone"`
+}
+
+// GetStatus reports whether the first-run setup still has to be completed
+// @Summary     Reports whether the first-run setup still has to be completed
+// @ID          setupGetStatus
+// @Tags        setup
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Router      /api/setup/status [get]
+func (h *SetupHandler) GetStatus(c *gin.Context) {
+	status, err := h.setupService.Status()
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get setup status", err))
+		return
+	}
+
+	c.JSON(200, status)
+}
+
+// CompleteSetup creates the first admin and logs them in. Only allowed while no user exists.
+// @Summary     Creates the first admin and logs them in
+// @Description Creates the first admin and logs them in. Only allowed while no user exists.
+// @ID          setupCompleteSetup
+// @Tags        setup
+// @Accept      json
+// @Produce     json
+// @Param       request body SetupRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     409 {object} apierror.APIError "Conflict, code SETUP_COMPLETED"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Router      /api/setup [post]
+func (h *SetupHandler) CompleteSetup(c *gin.Context) {
+	var req SetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	user, err := h.setupService.Complete(&services.SetupData{
+		Username:   req.Username,
+		Password:   req.Password,
+		PanelName:  req.PanelName,
+		AdminEmail: req.AdminEmail,
+		Timezone:   req.Timezone,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSetupCompleted):
+			respondError(c, 409, apierror.CodeSetupCompleted, err)
+		case errors.Is(err, services.ErrInvalidSetupData):
+			respondError(c, 400, apierror.CodeValidationFailed, err)
+		default:
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to complete setup", err))
+		}
+		return
+	}
+
+	models.DB.Create(&models.AuditLog{
+		UserID:    user.ID,
+		Action:    "setup",
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	})
+
+	token, expiresAt, err := h.jwtService.GenerateToken(user)
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Message("Failed to generate token"))
+		return
+	}
+	if err := h.authService.CreateSession(user.ID, token, expiresAt); err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Message("Failed to create session"))
+		return
+	}
+
+	c.JSON(201, LoginResponse{
+		Token: token,
+		User:  user,
+	})
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/config"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SystemHandler struct {
+	smtpService        *services.SMTPService
+	jwtService         *services.JWTService
+	maintenanceService *services.MaintenanceService
+}
+
+func NewSystemHandler(cfg *config.Config, jwtService *services.JWTService) *SystemHandler {
+	return &SystemHandler{
+		smtpService:        services.NewSMTPService(cfg.SMTP),
+		jwtService:         jwtService,
+		maintenanceService: services.NewMaintenanceService(),
+	}
+}
+
+type TestEmailRequest struct {
+	To string `json:"to" binding:"required"`
+}
+
+// TestEmail sends a test message through the configured SMTP server
+// @Summary     Sends a test message through the configured SMTP server
+// @ID          systemTestEmail
+// @Tags        system
+// @Accept      json
+// @Produce     json
+// @Param       request body TestEmailRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with message, to"
+// @Failure     400 {object} apierror.APIError "Bad Request, code SMTP_NOT_CONFIGURED or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     502 {object} apierror.APIError "Bad Gateway, code EMAIL_SEND_FAILED"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/system/test-email [post]
+func (h *SystemHandler) TestEmail(c *gin.Context) {
+	var req TestEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	subject := "R-Panel test email"
+	body := fmt.Sprintf("This is a test email sent from R-Panel at %s.\n\nIf you received it, your SMTP settings are working.", time.Now().Format(time.RFC1123))
+
+	if err := h.smtpService.SendEmail(req.To, subject, body); err != nil {
+		if errors.Is(err, services.ErrSMTPNotConfigured) {
+			respondError(c, 400, apierror.CodeSMTPNotConfigured, err)
+		} else {
+			respondError(c, 502, apierror.CodeEmailFailed, apierror.Wrap("Failed to send test email", err))
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Test email sent successfully", "to": req.To})
+}
+
+// RotateJWTSecret switches token signing to a fresh secret. Tokens signed with
+// the previous secret stay valid until they expire.
+// @Summary     Switches token signing to a fresh secret
+// @Description Switches token signing to a fresh secret. Tokens signed with the previous secret stay valid until
+// @Description they expire.
+// @ID          systemRotateJWTSecret
+// @Tags        system
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with kid, message, previous_valid_for"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/system/rotate-jwt-secret [post]
+func (h *SystemHandler) RotateJWTSecret(c *gin.Context) {
+	kid, err := h.jwtService.RotateSecret()
+	if errors.Is(err, services.ErrJWTRotationUnsupported) {
+		respondError(c, 400, apierror.CodeBadRequest, err)
+		return
+	}
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to rotate JWT secret", err))
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":            "JWT secret rotated successfully",
+		"kid":                kid,
+		"previous_valid_for": h.jwtService.TokenTTL().String(),
+	})
+}
+
+type MaintenanceRequest struct {
+	Enabled    *bool  `json:"enabled" binding:"required"`
+	Message    string `json:"message" binding:"max=500"`
+	RetryAfter int    `json:"retry_after" binding:"min=0"` // seconds, default 300
+}
+
+// SetMaintenance turns maintenance mode on or off. While it is on, only admins
+// may change anything.
+// @Summary     Turns maintenance mode on or off
+// @Description Turns maintenance mode on or off. While it is on, only admins may change anything.
+// @ID          systemSetMaintenance
+// @Tags        system
+// @Accept      json
+// @Produce     json
+// @Param       request body MaintenanceRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with maintenance, message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/system/maintenance [post]
+func (h *SystemHandler) SetMaintenance(c *gin.Context) {
+	var req MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	state, err := h.maintenanceService.Set(*req.Enabled, req.Message, time.Duration(req.RetryAfter)*time.Second)
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to change maintenance mode", err))
+		return
+	}
+
+	message := "Maintenance mode disabled"
+	if state.Enabled {
+		message = "Maintenance mode enabled"
+	}
+	c.JSON(200, gin.H{"message": message, "maintenance": state})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/config"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ToolsHandler struct {
+	toolsService *services.ToolsService
+}
+
+func NewToolsHandler(cfg *config.Config) *ToolsHandler {
+	return &ToolsHandler{
+		toolsService: services.NewToolsService(cfg.Tools),
+	}
+}
+
+// LookupDNS returns the records of ?type= (A by default) for ?name=
+// @Summary     Returns the records of ?type= (A by default) for ?name=
+// @ID          toolsLookupDNS
+// @Tags        tools
+// @Produce     json
+// @Param       name query string false "name"
+// @Param       type query string false "type"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     502 {object} apierror.APIError "Bad Gateway, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/tools/dns [get]
+func (h *ToolsHandler) LookupDNS(c *gin.Context) {
+	result, err := h.toolsService.LookupDNS(c.Query("name"), c.Query("type"))
+	if err != nil {
+		respondLookupError(c, err)
+		return
+	}
+
+	c.JSON(200, result)
+}
+
+// LookupPTR returns the names ?ip= resolves back to
+// @Summary     Returns the names ?ip= resolves back to
+// @ID          toolsLookupPTR
+// @Tags        tools
+// @Produce     json
+// @Param       ip query string false "ip"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     502 {object} apierror.APIError "Bad Gateway, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/tools/ptr [get]
+func (h *ToolsHandler) LookupPTR(c *gin.Context) {
+	result, err := h.toolsService.LookupPTR(c.Query("ip"))
+	if err != nil {
+		respondLookupError(c, err)
+		return
+	}
+
+	c.JSON(200, result)
+}
+
+// CheckPort tries a TCP connection to ?host= and ?port=, waiting at most
+// ?timeout= seconds (default 3, at most 10)
+// @Summary     Tries a TCP connection to ?host= and ?port=, waiting at most ?timeout= seconds (default 3, at most 10)
+// @ID          toolsCheckPort
+// @Tags        tools
+// @Produce     json
+// @Param       host query string false "host"
+// @Param       port query string false "port"
+// @Param       timeout query string false "timeout"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     502 {object} apierror.APIError "Bad Gateway, code SERVICE_UNAVAILABLE"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/tools/portcheck [get]
+func (h *ToolsHandler) CheckPort(c *gin.Context) {
+	port, err := strconv.Atoi(c.Query("port"))
+	if err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, services.ErrInvalidPort)
+		return
+	}
+	var timeout time.Duration
+	if value := c.Query("timeout"); value != "" {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil || seconds <= 0 {
+			respondError(c, 400, apierror.CodeValidationFailed, apierror.Message("timeout must be a positive number of seconds"))
+			return
+		}
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	result, err := h.toolsService.CheckPort(c.Query("host"), port, timeout)
+	if err != nil {
+		if errors.Is(err, services.ErrHostNotAllowed) {
+			respondError(c, 403, apierror.CodeForbidden, err)
+			return
+		}
+		respondLookupError(c, err)
+		return
+	}
+
+	c.JSON(200, result)
+}
+
+func respondLookupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidDomain), errors.Is(err, services.ErrInvalidDNSType), errors.Is(err, services.ErrInvalidIP),
+		errors.Is(err, services.ErrInvalidPort):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	default:
+		respondError(c, 502, apierror.CodeServiceUnavailable, err)
+	}
+}
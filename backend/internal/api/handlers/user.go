@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"r-panel/internal/api/apierror"
 	"r-panel/internal/config"
 	"r-panel/internal/models"
 	"r-panel/internal/services"
@@ -35,10 +36,32 @@ type UpdatePasswordRequest struct {
 }
 
 // GetUsers returns all users
+// @Summary     Returns all users
+// @ID          userGetUsers
+// @Tags        users
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with users"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
+	// Clients logged into the panel only see themselves
+	if user, restricted := clientPortalUser(c); restricted {
+		self, err := h.userService.GetUser(user.ID)
+		if err != nil {
+			respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get users", err))
+			return
+		}
+		c.JSON(200, gin.H{"users": []models.User{*self}})
+		return
+	}
+
 	users, err := h.userService.GetUsers()
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get users", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get users", err))
 		return
 	}
 
@@ -46,16 +69,32 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 }
 
 // GetUser returns a specific user
+// @Summary     Returns a specific user
+// @ID          userGetUser
+// @Tags        users
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code USER_NOT_FOUND"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/users/{id} [get]
 func (h *UserHandler) GetUser(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid user ID"))
+		return
+	}
+	if !mayAccessUser(c, uint(id)) {
 		return
 	}
 
 	user, err := h.userService.GetUser(uint(id))
 	if err != nil {
-		c.JSON(404, gin.H{"error": err.Error()})
+		respondError(c, 404, errorCode(err, apierror.CodeUserNotFound), err)
 		return
 	}
 
@@ -63,16 +102,29 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 }
 
 // CreateUser creates a new user
+// @Summary     Creates a new user
+// @ID          userCreateUser
+// @Tags        users
+// @Accept      json
+// @Produce     json
+// @Param       request body CreateUserRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var req CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
 	user, err := h.userService.CreateUser(req.Username, req.Password, req.Role)
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
@@ -80,22 +132,36 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 }
 
 // UpdateUser updates a user
+// @Summary     Updates a user
+// @ID          userUpdateUser
+// @Tags        users
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       request body UpdateUserRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid user ID"))
 		return
 	}
 
 	var req UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
 	user, err := h.userService.UpdateUser(uint(id), req.Username, req.Role)
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
@@ -103,21 +169,43 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 }
 
 // UpdatePassword updates user password
+// @Summary     Updates user password
+// @ID          userUpdatePassword
+// @Tags        users
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       request body UpdatePasswordRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/users/{id}/password [post]
 func (h *UserHandler) UpdatePassword(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid user ID"))
+		return
+	}
+	if !mayAccessUser(c, uint(id)) {
+		return
+	}
+	// An impersonating admin acts for the user only until the token expires
+	if _, ok := c.Get("impersonated_by"); ok {
+		respondError(c, 403, apierror.CodeForbidden, apierror.Message("Passwords cannot be changed while impersonating a user"))
 		return
 	}
 
 	var req UpdatePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request", "details": err.Error()})
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
 		return
 	}
 
 	if err := h.userService.UpdatePassword(uint(id), req.Password); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
@@ -125,33 +213,66 @@ func (h *UserHandler) UpdatePassword(c *gin.Context) {
 }
 
 // DeleteUser deletes a user
+// @Summary     Deletes a user
+// @ID          userDeleteUser
+// @Tags        users
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code BAD_REQUEST or INVALID_ID"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid user ID"))
 		return
 	}
 
 	if err := h.userService.DeleteUser(uint(id)); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		respondError(c, 400, errorCode(err, apierror.CodeBadRequest), err)
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "User deleted successfully"})
 }
 
+// mayAccessUser limits user-role callers to their own account, responding with
+// 403 for any other
+func mayAccessUser(c *gin.Context, id uint) bool {
+	if user, restricted := clientPortalUser(c); restricted && user.ID != id {
+		respondError(c, 403, apierror.CodeForbidden, apierror.Message("You may only access your own user"))
+		return false
+	}
+	return true
+}
+
 // GetSessions returns active sessions for current user
+// @Summary     Returns active sessions for current user
+// @ID          userGetSessions
+// @Tags        users
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with sessions"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/users/sessions [get]
 func (h *UserHandler) GetSessions(c *gin.Context) {
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(401, gin.H{"error": "Not authenticated"})
+		respondError(c, 401, apierror.CodeUnauthorized, apierror.Message("Not authenticated"))
 		return
 	}
 
 	u := user.(*models.User)
 	sessions, err := h.userService.GetSessions(u.ID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get sessions", "details": err.Error()})
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get sessions", err))
 		return
 	}
 
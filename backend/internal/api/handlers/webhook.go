@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/config"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(cfg *config.Config) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: services.NewWebhookService(cfg),
+	}
+}
+
+type WebhookRequest struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret"` // Generated on create when empty, unchanged on update when empty
+	Events []string `json:"events" binding:"required"`
+	Active *bool    `json:"active"`
+}
+
+func (r *WebhookRequest) data() *services.WebhookData {
+	return &services.WebhookData{
+		Name:   r.Name,
+		URL:    r.URL,
+		Secret: r.Secret,
+		Events: r.Events,
+		Active: r.Active,
+	}
+}
+
+// respondWebhookError maps webhook service errors to 404, 400 or 500
+func respondWebhookError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		respondError(c, 404, apierror.CodeWebhookNotFound, err)
+	case errors.Is(err, services.ErrInvalidWebhookURL), errors.Is(err, services.ErrInvalidWebhookEvent):
+		respondError(c, 400, apierror.CodeValidationFailed, err)
+	default:
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap(message, err))
+	}
+}
+
+func parseWebhookID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, 400, apierror.CodeInvalidID, apierror.Message("Invalid webhook ID"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetWebhooks returns all webhooks and the events they can subscribe to
+// @Summary     Returns all webhooks and the events they can subscribe to
+// @ID          webhookGetWebhooks
+// @Tags        webhooks
+// @Produce     json
+// @Success     200 {object} map[string]interface{} "OK, with events, webhooks"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookService.GetWebhooks()
+	if err != nil {
+		respondError(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to get webhooks", err))
+		return
+	}
+
+	c.JSON(200, gin.H{"webhooks": webhooks, "events": services.WebhookEvents})
+}
+
+// GetWebhook returns a specific webhook
+// @Summary     Returns a specific webhook
+// @ID          webhookGetWebhook
+// @Tags        webhooks
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code WEBHOOK_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(id)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to get webhook")
+		return
+	}
+
+	c.JSON(200, webhook)
+}
+
+// CreateWebhook creates a webhook. The signing secret is only returned here.
+// @Summary     Creates a webhook
+// @Description Creates a webhook. The signing secret is only returned here.
+// @ID          webhookCreateWebhook
+// @Tags        webhooks
+// @Accept      json
+// @Produce     json
+// @Param       request body WebhookRequest true "Request body"
+// @Success     201 {object} map[string]interface{} "Created, with secret, webhook"
+// @Failure     400 {object} apierror.APIError "Bad Request, code VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code WEBHOOK_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(req.data())
+	if err != nil {
+		respondWebhookError(c, err, "Failed to create webhook")
+		return
+	}
+
+	c.JSON(201, gin.H{"webhook": webhook, "secret": webhook.Secret})
+}
+
+// UpdateWebhook updates a webhook
+// @Summary     Updates a webhook
+// @ID          webhookUpdateWebhook
+// @Tags        webhooks
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "id"
+// @Param       request body WebhookRequest true "Request body"
+// @Success     200 {object} map[string]interface{} "OK"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code WEBHOOK_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, apierror.CodeValidationFailed, apierror.Invalid(err))
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(id, req.data())
+	if err != nil {
+		respondWebhookError(c, err, "Failed to update webhook")
+		return
+	}
+
+	c.JSON(200, webhook)
+}
+
+// DeleteWebhook deletes a webhook
+// @Summary     Deletes a webhook
+// @ID          webhookDeleteWebhook
+// @Tags        webhooks
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with message"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code WEBHOOK_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(id); err != nil {
+		respondWebhookError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeadLetters returns the deliveries to a webhook that failed after every retry
+// @Summary     Returns the deliveries to a webhook that failed after every retry
+// @ID          webhookGetDeadLetters
+// @Tags        webhooks
+// @Produce     json
+// @Param       id path int true "id"
+// @Success     200 {object} map[string]interface{} "OK, with dead_letters"
+// @Failure     400 {object} apierror.APIError "Bad Request, code INVALID_ID or VALIDATION_FAILED"
+// @Failure     401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure     403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Failure     404 {object} apierror.APIError "Not Found, code WEBHOOK_NOT_FOUND"
+// @Failure     500 {object} apierror.APIError "Internal Server Error, code INTERNAL_ERROR"
+// @Security    BearerAuth
+// @Security    APIKey
+// @Router      /api/webhooks/{id}/dead-letters [get]
+func (h *WebhookHandler) GetDeadLetters(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	letters, err := h.webhookService.GetDeadLetters(id)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to get dead letters")
+		return
+	}
+
+	c.JSON(200, gin.H{"dead_letters": letters})
+}
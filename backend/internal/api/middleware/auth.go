@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"r-panel/internal/api/apierror"
 	"r-panel/internal/models"
 	"r-panel/internal/services"
 	"strings"
@@ -8,30 +9,58 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
+// APIKeyHeader carries an API key, as an alternative to "Authorization: Bearer rpk_..."
+const APIKeyHeader = "X-API-Key"
+
+// AuthMiddleware accepts requests with a bearer token that has a valid signature,
+// issuer and expiry and belongs to a live session of the user it names, or
+// with an API key, which acts as its owner within the key's scopes
+func AuthMiddleware(authService *services.AuthService, jwtService *services.JWTService, apiKeyService *services.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := apiKeyFromRequest(c); key != "" {
+			authenticateAPIKey(c, apiKeyService, key)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(401, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Authorization header required"))
 			return
 		}
 
 		// Extract token from "Bearer <token>"
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(401, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+			apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Invalid authorization header format"))
 			return
 		}
 
 		token := parts[1]
 
-		// Verify token and get session
+		// Verify the token itself, then that it still has a session
+		claims, err := jwtService.VerifyToken(token)
+		if err != nil {
+			apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Invalid or expired token"))
+			return
+		}
+
 		session, err := authService.GetSession(token)
 		if err != nil {
-			c.JSON(401, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+			apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Session has ended, log in again"))
+			return
+		}
+		if !services.ClaimsMatchSession(claims, session) {
+			apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Token does not match its session"))
+			return
+		}
+
+		// Sessions of disabled users or users that must change their password grant nothing
+		if session.User.Disabled {
+			apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Account is disabled"))
+			return
+		}
+		if session.User.MustChangePassword {
+			apierror.Respond(c, 403, apierror.CodePasswordChangeRequired, apierror.Message("Password must be changed before using the panel"))
 			return
 		}
 
@@ -39,17 +68,60 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 		c.Set("user", &session.User)
 		c.Set("user_id", session.UserID)
 		c.Set("session", session)
+		if session.ImpersonatedBy != nil {
+			c.Set("impersonated_by", *session.ImpersonatedBy)
+		}
 
 		c.Next()
 	}
 }
 
+// apiKeyFromRequest returns the API key the request carries, if any
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && services.IsAPIKey(token) {
+		return token
+	}
+	return ""
+}
+
+// authenticateAPIKey sets the owner of key as the user of the request
+func authenticateAPIKey(c *gin.Context, apiKeyService *services.APIKeyService, key string) {
+	apiKey, err := apiKeyService.Authenticate(key)
+	if err != nil {
+		apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Invalid or expired API key"))
+		return
+	}
+	if apiKey.User.Disabled {
+		apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Account is disabled"))
+		return
+	}
+	if apiKey.User.MustChangePassword {
+		apierror.Respond(c, 403, apierror.CodePasswordChangeRequired, apierror.Message("Password must be changed before using the panel"))
+		return
+	}
+
+	c.Set("user", &apiKey.User)
+	c.Set("user_id", apiKey.UserID)
+	c.Set("api_key", apiKey)
+	c.Next()
+}
+
+// apiKeyAllows reports whether the API key of the request, if it was made
+// with one, was granted permission. Requests with a session have no key limits.
+func apiKeyAllows(c *gin.Context, permission Permission) bool {
+	apiKey, ok := c.Get("api_key")
+	return !ok || services.HasScope(apiKey.(*models.APIKey), string(permission))
+}
+
+// RequireRole aborts requests from users whose role is not one of roles
 func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, exists := c.Get("user")
 		if !exists {
-			c.JSON(401, gin.H{"error": "Unauthorized"})
-			c.Abort()
+			apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Unauthorized"))
 			return
 		}
 
@@ -63,8 +135,12 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 		}
 
 		if !hasRole {
-			c.JSON(403, gin.H{"error": "Forbidden: insufficient permissions"})
-			c.Abort()
+			apierror.Respond(c, 403, apierror.CodeForbidden, apierror.Message("Forbidden: insufficient permissions"))
+			return
+		}
+		// Role restricted routes are admin routes, keys need the admin scope for them
+		if !apiKeyAllows(c, PermissionAdmin) {
+			apierror.Respond(c, 403, apierror.CodeForbidden, apierror.Message("Forbidden: API key lacks the admin scope"))
 			return
 		}
 
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	require.NoError(t, models.InitDB(&config.Config{
+		Database: config.DatabaseConfig{
+			Type:   "sqlite",
+			SQLite: config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		},
+	}))
+	t.Cleanup(func() {
+		if sqlDB, err := models.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	admin := &models.User{Username: "admin", PasswordHash: "x", Role: models.RoleAdmin}
+	require.NoError(t, models.DB.Create(admin).Error)
+	apiKeys := services.NewAPIKeyService()
+	_, readKey, err := apiKeys.CreateAPIKey(admin, "monitoring", []string{services.APIKeyScopeRead}, nil)
+	require.NoError(t, err)
+	_, writeKey, err := apiKeys.CreateAPIKey(admin, "deploy", []string{services.APIKeyScopeRead, services.APIKeyScopeWrite}, nil)
+	require.NoError(t, err)
+
+	// Only the API key path is exercised, it never reaches the JWT checks
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AuthMiddleware(nil, nil, apiKeys), RequireMethodPermission())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/clients", ok)
+	r.DELETE("/api/clients/:id", ok)
+	r.POST("/api/users", RequireRole(models.RoleAdmin), ok)
+
+	tests := []struct {
+		name, method, path, header, value string
+		want                              int
+	}{
+		{"read key reads", http.MethodGet, "/api/clients", APIKeyHeader, readKey, http.StatusOK},
+		{"bearer API key", http.MethodGet, "/api/clients", "Authorization", "Bearer " + readKey, http.StatusOK},
+		{"read key cannot write", http.MethodDelete, "/api/clients/1", APIKeyHeader, readKey, http.StatusForbidden},
+		{"write key writes", http.MethodDelete, "/api/clients/1", APIKeyHeader, writeKey, http.StatusOK},
+		{"admin role without admin scope", http.MethodPost, "/api/users", APIKeyHeader, writeKey, http.StatusForbidden},
+		{"unknown key", http.MethodGet, "/api/clients", APIKeyHeader, services.APIKeyPrefix + "nope", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -3,23 +3,34 @@ package middleware
 import (
 	"log"
 	"net/http"
+	"runtime/debug"
+
+	"r-panel/internal/api/apierror"
 
 	"github.com/gin-gonic/gin"
 )
 
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Recover from panics and report them in the standard error format. The
+		// panic value and stack stay in the server log, they can hold internals.
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Panic: %v\n%s", r, debug.Stack())
+				apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal,
+					apierror.Message("Internal server error"))
+			}
+		}()
+
 		c.Next()
 
 		// Check if there are any errors
-		if len(c.Errors) > 0 {
+		if len(c.Errors) > 0 && !c.Writer.Written() {
 			err := c.Errors.Last()
 			log.Printf("Error: %v", err)
 
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Internal server error",
-				"message": err.Error(),
-			})
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal,
+				apierror.Wrap("Internal server error", err.Err))
 		}
 	}
 }
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maintenance rejects mutating requests from everyone but admins with 503 while
+// maintenance mode is on. It runs after AuthMiddleware; reads always pass.
+func Maintenance(maintenanceService *services.MaintenanceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if MethodPermission(c.Request.Method) == PermissionRead {
+			c.Next()
+			return
+		}
+		if user, ok := c.Get("user"); ok && user.(*models.User).Role == models.RoleAdmin {
+			c.Next()
+			return
+		}
+
+		state, err := maintenanceService.Get()
+		if err != nil || !state.Enabled {
+			// A settings table that cannot be read must not lock everyone out
+			c.Next()
+			return
+		}
+
+		message := "The panel is in maintenance mode, changes are disabled"
+		if state.Message != "" {
+			message += ": " + state.Message
+		}
+		c.Header("Retry-After", strconv.Itoa(state.RetryAfter))
+		apierror.Respond(c, 503, apierror.CodeMaintenance, apierror.Message(message))
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceBlocksNonAdminChanges(t *testing.T) {
+	require.NoError(t, models.InitDB(&config.Config{
+		Database: config.DatabaseConfig{
+			Type:   "sqlite",
+			SQLite: config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		},
+	}))
+	t.Cleanup(func() {
+		if sqlDB, err := models.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	maintenance := services.NewMaintenanceService()
+	router := func(role string) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("user", &models.User{Username: "tester", Role: role})
+		})
+		r.Use(Maintenance(maintenance))
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		r.GET("/api/clients", ok)
+		r.POST("/api/clients", ok)
+		return r
+	}
+	serve := func(role, method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router(role).ServeHTTP(w, httptest.NewRequest(method, "/api/clients", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve(models.RoleUser, http.MethodPost).Code)
+
+	_, err := maintenance.Set(true, "upgrading to 2.0", 2*time.Minute)
+	require.NoError(t, err)
+
+	w := serve(models.RoleUser, http.MethodPost)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "120", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "upgrading to 2.0")
+	assert.Equal(t, http.StatusOK, serve(models.RoleUser, http.MethodGet).Code)
+	assert.Equal(t, http.StatusOK, serve(models.RoleAdmin, http.MethodPost).Code)
+
+	_, err = maintenance.Set(false, "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, serve(models.RoleUser, http.MethodPost).Code)
+}
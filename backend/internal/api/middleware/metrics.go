@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net"
+	"time"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics counts every request and how long it took. Requests are labeled by
+// route pattern; ones that matched no route share the "unmatched" label.
+func Metrics(metrics *services.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsAccess lets scrapers from allowed networks through and requires an
+// API key with the read scope from everyone else. Only the address of the
+// connection counts, forwarding headers are set by the client and can lie.
+func MetricsAccess(allowed []*net.IPNet, apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ip := net.ParseIP(c.RemoteIP()); ip != nil {
+			for _, network := range allowed {
+				if network.Contains(ip) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		key := apiKeyFromRequest(c)
+		if key == "" {
+			apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("API key required"))
+			return
+		}
+		apiKey, err := apiKeyService.Authenticate(key)
+		if err != nil {
+			apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Invalid or expired API key"))
+			return
+		}
+		if !services.HasScope(apiKey, services.APIKeyScopeRead) {
+			apierror.Respond(c, 403, apierror.CodeForbidden, apierror.Message("Forbidden: API key lacks the read scope"))
+			return
+		}
+		c.Next()
+	}
+}
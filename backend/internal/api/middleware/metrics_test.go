@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsAccess(t *testing.T) {
+	require.NoError(t, models.InitDB(&config.Config{
+		Database: config.DatabaseConfig{
+			Type:   "sqlite",
+			SQLite: config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		},
+	}))
+	t.Cleanup(func() {
+		if sqlDB, err := models.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	admin := &models.User{Username: "admin", PasswordHash: "x", Role: models.RoleAdmin}
+	require.NoError(t, models.DB.Create(admin).Error)
+	apiKeys := services.NewAPIKeyService()
+	_, readKey, err := apiKeys.CreateAPIKey(admin, "prometheus", []string{services.APIKeyScopeRead}, nil)
+	require.NoError(t, err)
+	_, writeKey, err := apiKeys.CreateAPIKey(admin, "deploy", []string{services.APIKeyScopeWrite}, nil)
+	require.NoError(t, err)
+
+	_, allowed, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	metrics := services.NewMetrics()
+	r := gin.New()
+	r.Use(Metrics(metrics))
+	r.GET("/metrics", MetricsAccess([]*net.IPNet{allowed}, apiKeys), gin.WrapH(metrics.Handler()))
+
+	tests := []struct {
+		name, remote, forwarded, key string
+		want                         int
+	}{
+		{"allowed network", "10.1.2.3:5000", "", "", http.StatusOK},
+		{"read key", "192.0.2.1:5000", "", readKey, http.StatusOK},
+		{"no key", "192.0.2.1:5000", "", "", http.StatusUnauthorized},
+		{"forwarded from an allowed network", "192.0.2.1:5000", "10.1.2.3", "", http.StatusUnauthorized},
+		{"unknown key", "192.0.2.1:5000", "", services.APIKeyPrefix + "nope", http.StatusUnauthorized},
+		{"key without read scope", "192.0.2.1:5000", "", writeKey, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	// Earlier requests were counted by route and status
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.RemoteAddr = "10.1.2.3:5000"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	for _, line := range []string{
+		`rpanel_http_requests_total{method="GET",route="/metrics",status="200"} 2`,
+		`rpanel_http_requests_total{method="GET",route="/metrics",status="401"} 3`,
+		`rpanel_http_requests_total{method="GET",route="/metrics",status="403"} 1`,
+	} {
+		assert.Contains(t, w.Body.String(), line)
+	}
+}
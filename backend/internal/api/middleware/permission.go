@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission is an action a role may be allowed to perform
+type Permission string
+
+const (
+	PermissionRead  Permission = "read"  // view resources
+	PermissionWrite Permission = "write" // create, change or delete resources
+	PermissionAdmin Permission = "admin" // manage users, clients and the panel itself
+)
+
+// rolePermissions maps each role to the permissions it grants
+var rolePermissions = map[string][]Permission{
+	models.RoleAdmin:    {PermissionRead, PermissionWrite, PermissionAdmin},
+	models.RoleUser:     {PermissionRead, PermissionWrite},
+	models.RoleReadonly: {PermissionRead},
+}
+
+// HasPermission reports whether role grants permission
+func HasPermission(role string, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesWithPermission returns the roles granting permission, in a stable order
+func RolesWithPermission(permission Permission) []string {
+	var roles []string
+	for _, role := range []string{models.RoleAdmin, models.RoleUser, models.RoleReadonly} {
+		if HasPermission(role, permission) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// MethodPermission returns the permission needed for a request method: reading
+// for safe methods, writing for everything else
+func MethodPermission(method string) Permission {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return PermissionRead
+	default:
+		return PermissionWrite
+	}
+}
+
+// selfServiceRoutes are mutating routes every role may use on its own account,
+// the :id parameter naming the user. API keys are managed from their own group,
+// outside RequireMethodPermission.
+var selfServiceRoutes = map[string]bool{
+	"POST /api/users/:id/password": true,
+}
+
+// SelfServiceRoute reports whether the route "METHOD /path" is open to every
+// role for the user's own account
+func SelfServiceRoute(key string) bool {
+	return selfServiceRoutes[key]
+}
+
+// RequireMethodPermission requires the read permission for safe requests and the
+// write permission for mutating ones, so readonly users can look but not change.
+// Readonly users may still change their own account through selfServiceRoutes.
+func RequireMethodPermission() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("user")
+		if !exists {
+			apierror.Respond(c, 401, apierror.CodeUnauthorized, apierror.Message("Unauthorized"))
+			return
+		}
+		user := value.(*models.User)
+		permission := MethodPermission(c.Request.Method)
+
+		if !HasPermission(user.Role, permission) && !ownAccount(c, user) {
+			apierror.Respond(c, 403, apierror.CodeForbidden, apierror.Message("Forbidden: insufficient permissions"))
+			return
+		}
+		if !apiKeyAllows(c, permission) {
+			apierror.Respond(c, 403, apierror.CodeForbidden, apierror.Message("Forbidden: API key lacks the "+string(permission)+" scope"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// ownAccount reports whether the request is a self-service route for user's own account
+func ownAccount(c *gin.Context, user *models.User) bool {
+	return selfServiceRoutes[c.Request.Method+" "+c.FullPath()] &&
+		c.Param("id") == strconv.FormatUint(uint64(user.ID), 10)
+}
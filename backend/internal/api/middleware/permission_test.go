@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// permissionTestRouter serves a read and a mutating route as a user with role
+func permissionTestRouter(role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: 7, Username: "tester", Role: role})
+	})
+	r.Use(RequireMethodPermission())
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/clients", ok)
+	r.DELETE("/api/clients/:id", ok)
+	r.POST("/api/users", RequireRole(models.RoleAdmin), ok)
+	r.POST("/api/users/:id/password", ok)
+	return r
+}
+
+func TestRequireMethodPermission(t *testing.T) {
+	tests := []struct {
+		role                                                string
+		read, mutate, adminOnly, ownPassword, otherPassword int
+	}{
+		{models.RoleAdmin, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK},
+		{models.RoleUser, http.StatusOK, http.StatusOK, http.StatusForbidden, http.StatusOK, http.StatusOK},
+		{models.RoleReadonly, http.StatusOK, http.StatusForbidden, http.StatusForbidden, http.StatusOK, http.StatusForbidden},
+		{"unknown", http.StatusForbidden, http.StatusForbidden, http.StatusForbidden, http.StatusOK, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			r := permissionTestRouter(tt.role)
+			for _, request := range []struct {
+				method, path string
+				want         int
+			}{
+				{http.MethodGet, "/api/clients", tt.read},
+				{http.MethodDelete, "/api/clients/1", tt.mutate},
+				{http.MethodPost, "/api/users", tt.adminOnly},
+				{http.MethodPost, "/api/users/7/password", tt.ownPassword},
+				{http.MethodPost, "/api/users/8/password", tt.otherPassword},
+			} {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(request.method, request.path, nil))
+				assert.Equal(t, request.want, w.Code, "%s %s", request.method, request.path)
+			}
+		})
+	}
+}
+
+func TestRequireMethodPermissionWithoutUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/clients", RequireMethodPermission(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/clients", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"r-panel/internal/api/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestContextKey keeps the request context from before any RequestTimeout,
+// so a later RequestTimeout replaces the deadline instead of adding to it
+const requestContextKey = "request_context"
+
+// WriteTimeout sets the deadline for writing the response; 0 removes it.
+// The deadline set last wins, so a route can override its group's timeout.
+func WriteTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setWriteDeadline(c, timeout)
+		c.Next()
+	}
+}
+
+func setWriteDeadline(c *gin.Context, timeout time.Duration) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	// Writers without deadline support (e.g. httptest.ResponseRecorder) are left alone
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(deadline)
+}
+
+// RequestTimeout gives the handlers timeout to finish; 0 removes the deadline.
+// Commands started with the request context are killed when it passes, and
+// a request that has not responded by then gets 504. Like WriteTimeout, the
+// timeout set last wins.
+func RequestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parent, ok := c.Get(requestContextKey)
+		if !ok {
+			parent = c.Request.Context()
+			c.Set(requestContextKey, parent)
+		}
+
+		ctx := parent.(context.Context)
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if !c.Writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			apierror.Respond(c, http.StatusGatewayTimeout, apierror.CodeTimeout, apierror.Message("Request timed out"))
+		}
+	}
+}
+
+// Timeouts applies WriteTimeout and RequestTimeout in one handler, so a route
+// can override both of its group's timeouts at once
+func Timeouts(write, request time.Duration) gin.HandlerFunc {
+	requestTimeout := RequestTimeout(request)
+	return func(c *gin.Context) {
+		setWriteDeadline(c, write)
+		requestTimeout(c)
+	}
+}
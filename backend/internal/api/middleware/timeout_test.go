@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"r-panel/internal/api/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamChunks writes n chunks, flushing each one and pausing between them
+func streamChunks(n int, pause time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Status(200)
+		for i := 0; i < n; i++ {
+			if _, err := c.Writer.WriteString("chunk\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+			time.Sleep(pause)
+		}
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Scaled down: the API group gets a short timeout like the 15s CRUD default,
+	// the streaming route overrides it with no timeout
+	const short = 100 * time.Millisecond
+	const chunks = 8
+	const pause = 50 * time.Millisecond
+
+	r := gin.New()
+	api := r.Group("/api")
+	api.Use(WriteTimeout(short))
+	api.GET("/crud-stream", streamChunks(chunks, pause))
+	api.GET("/long-stream", WriteTimeout(0), streamChunks(chunks, pause))
+
+	// Same server setup as main: no blanket WriteTimeout
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 0
+	srv.Start()
+	defer srv.Close()
+
+	t.Run("long running stream outlives the group timeout", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/api/long-stream")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, chunks, strings.Count(string(body), "chunk"))
+	})
+
+	t.Run("regular route is cut at its timeout", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/api/crud-stream")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.True(t, err != nil || strings.Count(string(body), "chunk") < chunks,
+			"expected the stream to be cut after %s", short)
+	})
+}
+
+func TestRequestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const timeout = 100 * time.Millisecond
+	r := gin.New()
+	api := r.Group("/api")
+	api.Use(Timeouts(0, timeout))
+
+	// A stuck command is killed at the deadline and its error becomes a 504
+	api.GET("/command", func(c *gin.Context) {
+		if err := exec.CommandContext(c.Request.Context(), "sleep", "5").Run(); err != nil {
+			apierror.Respond(c, 500, apierror.CodeInternal, apierror.Wrap("Failed to run command", err))
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	// A handler that gives up without responding gets a 504 from the middleware
+	api.GET("/silent", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+	api.GET("/long", Timeouts(0, 0), func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"deadline": hasDeadline})
+	})
+	api.GET("/fast", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("stuck command", func(t *testing.T) {
+		if _, err := exec.LookPath("sleep"); err != nil {
+			t.Skip("sleep is not available")
+		}
+		start := time.Now()
+		w := do("/api/command")
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Contains(t, w.Body.String(), apierror.CodeTimeout)
+	})
+
+	t.Run("handler without response", func(t *testing.T) {
+		w := do("/api/silent")
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Contains(t, w.Body.String(), apierror.CodeTimeout)
+	})
+
+	t.Run("route override removes the deadline", func(t *testing.T) {
+		w := do("/api/long")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"deadline": false}`, w.Body.String())
+	})
+
+	t.Run("fast handler is untouched", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("/api/fast").Code)
+	})
+}
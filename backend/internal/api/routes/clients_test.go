@@ -112,7 +112,7 @@ func cleanupTestDB(t *testing.T, cfg *config.Config, records *testRecords) {
 
 		// Delete test Clients
 		if len(records.ClientIDs) > 0 {
-			models.DB.Where("id IN ?", records.ClientIDs).Delete(&models.Client{})
+			models.DB.Unscoped().Where("id IN ?", records.ClientIDs).Delete(&models.Client{})
 		}
 
 		// Delete test Sessions
@@ -396,6 +396,37 @@ func TestClientsRoutes(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response struct {
+			Code   string            `json:"code"`
+			Fields map[string]string `json:"fields"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "VALIDATION_FAILED", response.Code)
+		assert.Equal(t, map[string]string{
+			"password":     "is required",
+			"contact_name": "is required",
+			"email":        "is required",
+		}, response.Fields)
+	})
+
+	t.Run("POST /api/clients - Bad Request (wrong field type)", func(t *testing.T) {
+		router := setupTestRouter(cfg)
+		token := createTestToken(t, cfg, authService, adminUser, records)
+
+		req, _ := http.NewRequest("POST", "/api/clients", bytes.NewBufferString(`{"username": 42}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response struct {
+			Fields map[string]string `json:"fields"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, map[string]string{"username": "must be a string"}, response.Fields)
 	})
 
 	t.Run("PUT /api/clients/:id - Success (admin)", func(t *testing.T) {
@@ -541,6 +572,65 @@ func TestClientsRoutes(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("DELETE /api/clients/:id - Trash, restore and purge", func(t *testing.T) {
+		router := setupTestRouter(cfg)
+		token := createTestToken(t, cfg, authService, adminUser, records)
+
+		clientService := services.NewClientService(cfg)
+		client, err := clientService.CreateClient(&services.CreateClientData{
+			Username:    "trashclient",
+			Password:    "testpass123",
+			ContactName: "Trash Client",
+			Email:       "trash@example.com",
+		})
+		require.NoError(t, err)
+		trackTestClient(client, records)
+		clientPath := "/api/clients/" + strconv.FormatUint(uint64(client.ID), 10)
+
+		do := func(method, path string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(method, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		// Soft delete hides the client but keeps it in the trash
+		assert.Equal(t, http.StatusOK, do("DELETE", clientPath).Code)
+		clients, err := clientService.GetClients()
+		require.NoError(t, err)
+		for _, c := range clients {
+			assert.NotEqual(t, client.ID, c.ID)
+		}
+
+		w := do("GET", "/api/clients/trash")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var trash struct {
+			Clients []models.Client `json:"clients"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trash))
+		trashedIDs := []uint{}
+		for _, c := range trash.Clients {
+			trashedIDs = append(trashedIDs, c.ID)
+		}
+		assert.Contains(t, trashedIDs, client.ID)
+
+		// Restore brings it back
+		assert.Equal(t, http.StatusOK, do("POST", clientPath+"/restore").Code)
+		_, err = clientService.GetClient(client.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, do("POST", clientPath+"/restore").Code)
+
+		// Purge removes the client and its user for good
+		assert.Equal(t, http.StatusOK, do("DELETE", clientPath+"/purge").Code)
+		var count int64
+		models.DB.Unscoped().Model(&models.Client{}).Where("id = ?", client.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+		models.DB.Model(&models.User{}).Where("id = ?", client.UserID).Count(&count)
+		assert.Equal(t, int64(0), count)
+		assert.Equal(t, http.StatusNotFound, do("POST", clientPath+"/restore").Code)
+	})
+
 	t.Run("DELETE /api/clients/:id - Forbidden (regular user)", func(t *testing.T) {
 		router := setupTestRouter(cfg)
 		token := createTestToken(t, cfg, authService, regularUser, records)
@@ -564,4 +654,189 @@ func TestClientsRoutes(t *testing.T) {
 
 		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
+
+	t.Run("GET /api/clients/:id - Client users only reach their own client", func(t *testing.T) {
+		router := setupTestRouter(cfg)
+		adminToken := createTestToken(t, cfg, authService, adminUser, records)
+
+		clientService := services.NewClientService(cfg)
+		own, err := clientService.CreateClient(&services.CreateClientData{
+			Username:    "portalclient",
+			Password:    "testpass123",
+			ContactName: "Portal Client",
+			Email:       "portal@example.com",
+		})
+		require.NoError(t, err)
+		trackTestClient(own, records)
+		other, err := clientService.CreateClient(&services.CreateClientData{
+			Username:    "otherclient",
+			Password:    "testpass123",
+			ContactName: "Other Client",
+			Email:       "other@example.com",
+		})
+		require.NoError(t, err)
+		trackTestClient(other, records)
+		token := createTestToken(t, cfg, authService, &own.User, records)
+
+		get := func(token, path string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+		ownPath := "/api/clients/" + strconv.FormatUint(uint64(own.ID), 10)
+		otherPath := "/api/clients/" + strconv.FormatUint(uint64(other.ID), 10)
+
+		assert.Equal(t, http.StatusOK, get(token, ownPath).Code)
+		for _, path := range []string{otherPath, otherPath + "/sites", otherPath + "/mail/usage", "/api/clients/99999"} {
+			assert.Equal(t, http.StatusForbidden, get(token, path).Code, path)
+		}
+		assert.Equal(t, http.StatusOK, get(adminToken, otherPath).Code)
+
+		// The listing holds only their own client
+		w := get(token, "/api/clients")
+		require.Equal(t, http.StatusOK, w.Code)
+		var list struct {
+			Clients []models.Client `json:"clients"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Clients, 1)
+		assert.Equal(t, own.ID, list.Clients[0].ID)
+
+		// And the same goes for users
+		assert.Equal(t, http.StatusOK, get(token, "/api/users/"+strconv.FormatUint(uint64(own.UserID), 10)).Code)
+		assert.Equal(t, http.StatusForbidden, get(token, "/api/users/"+strconv.FormatUint(uint64(adminUser.ID), 10)).Code)
+
+		// Server-wide resources are not theirs at all
+		for _, path := range []string{"/api/nginx/sites", "/api/phpfpm/pools", "/api/backups", "/api/logs/system"} {
+			assert.Equal(t, http.StatusForbidden, get(token, path).Code, path)
+		}
+	})
+
+	t.Run("GET and PATCH /api/me/client - Clients edit their own contact details", func(t *testing.T) {
+		router := setupTestRouter(cfg)
+		clientService := services.NewClientService(cfg)
+		own, err := clientService.CreateClient(&services.CreateClientData{
+			Username:    "selfservice",
+			Password:    "testpass123",
+			ContactName: "Self Service",
+			Email:       "self@example.com",
+		})
+		require.NoError(t, err)
+		trackTestClient(own, records)
+		token := createTestToken(t, cfg, authService, &own.User, records)
+
+		do := func(token, method string, body interface{}) *httptest.ResponseRecorder {
+			jsonData, _ := json.Marshal(body)
+			req, _ := http.NewRequest(method, "/api/me/client", bytes.NewBuffer(jsonData))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := do(token, "GET", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response models.Client
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, own.ID, response.ID)
+
+		// Fields outside the safe subset are ignored
+		w = do(token, "PATCH", map[string]interface{}{
+			"contact_name": "Renamed",
+			"city":         "Bandung",
+			"locked":       true,
+			"reseller":     true,
+			"customer_no":  "X-1",
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		stored, err := clientService.GetClient(own.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Renamed", stored.ContactName)
+		assert.Equal(t, "Bandung", stored.City)
+		assert.False(t, stored.Locked)
+		assert.False(t, stored.Reseller)
+		assert.Equal(t, own.CustomerNo, stored.CustomerNo)
+
+		assert.Equal(t, http.StatusBadRequest, do(token, "PATCH", map[string]interface{}{"contact_name": " "}).Code)
+
+		// Users without a client have no profile
+		assert.Equal(t, http.StatusNotFound, do(createTestToken(t, cfg, authService, adminUser, records), "GET", nil).Code)
+	})
+
+	t.Run("POST /api/clients - Invalid fields are reported by name", func(t *testing.T) {
+		router := setupTestRouter(cfg)
+		token := createTestToken(t, cfg, authService, adminUser, records)
+
+		jsonData, _ := json.Marshal(map[string]interface{}{
+			"username":     "badfields",
+			"password":     "newpass123",
+			"contact_name": "Bad Fields",
+			"email":        "not-an-email",
+			"country":      "ZZ",
+		})
+		req, _ := http.NewRequest("POST", "/api/clients", bytes.NewBuffer(jsonData))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response struct {
+			Code   string            `json:"code"`
+			Fields map[string]string `json:"fields"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "VALIDATION_FAILED", response.Code)
+		assert.Contains(t, response.Fields, "email")
+		assert.Contains(t, response.Fields, "country")
+	})
+
+	t.Run("POST /api/clients/bulk - Admin only, reports each client", func(t *testing.T) {
+		router := setupTestRouter(cfg)
+		do := func(token string, body map[string]interface{}) *httptest.ResponseRecorder {
+			jsonData, _ := json.Marshal(body)
+			req, _ := http.NewRequest("POST", "/api/clients/bulk", bytes.NewBuffer(jsonData))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		body := map[string]interface{}{"action": "lock", "ids": []uint{999999}}
+		assert.Equal(t, http.StatusForbidden, do(createTestToken(t, cfg, authService, regularUser, records), body).Code)
+
+		token := createTestToken(t, cfg, authService, adminUser, records)
+		assert.Equal(t, http.StatusBadRequest, do(token, map[string]interface{}{"action": "suspend", "ids": []uint{1}}).Code)
+
+		w := do(token, body)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Results   []services.BulkActionResult `json:"results"`
+			Succeeded int                         `json:"succeeded"`
+			Failed    int                         `json:"failed"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 0, response.Succeeded)
+		assert.Equal(t, 1, response.Failed)
+		require.Len(t, response.Results, 1)
+		assert.False(t, response.Results[0].Success)
+	})
+
+	t.Run("DELETE /api/backups/running/:job - Admin only", func(t *testing.T) {
+		router := setupTestRouter(cfg)
+		do := func(token string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("DELETE", "/api/backups/running/missing", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		assert.Equal(t, http.StatusForbidden, do(createTestToken(t, cfg, authService, regularUser, records)).Code)
+		assert.Equal(t, http.StatusNotFound, do(createTestToken(t, cfg, authService, adminUser, records)).Code)
+	})
 }
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getHealth returns a handler reporting that the API is up and whether the
+// panel is in maintenance mode
+// @Summary      Reports that the API is up and whether the panel is in maintenance mode
+// @ID           getHealth
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "OK, with maintenance, message, status"
+// @Router       /api/health [get]
+func getHealth(maintenanceService *services.MaintenanceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// An unreadable state is reported as off, like the middleware treats it
+		maintenance, _ := maintenanceService.Get()
+		c.JSON(200, gin.H{
+			"status":      "ok",
+			"message":     "R-Panel API is running",
+			"maintenance": maintenance,
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package routes
+
+import (
+	"sort"
+	"strings"
+
+	apimiddleware "r-panel/internal/api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteEntry describes a registered route and the access rules applied to it
+type RouteEntry struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware"`
+	Roles      []string `json:"roles"`
+}
+
+// publicRoutes lists routes that are served without authentication
+var publicRoutes = map[string]bool{
+	"GET /api/health":                true,
+	"GET /api/openapi.json":          true,
+	"POST /api/auth/login":           true,
+	"POST /api/auth/change-password": true,
+	"GET /api/setup/status":          true,
+	"POST /api/setup":                true,
+}
+
+// accountRoutes lists authenticated routes open to every role
+var accountRoutes = map[string]bool{
+	"POST /api/auth/logout":             true,
+	"GET /api/auth/me":                  true,
+	"POST /api/auth/stop-impersonation": true,
+	"GET /api/apikeys":                  true,
+	"POST /api/apikeys":                 true,
+	"DELETE /api/apikeys/:id":           true,
+}
+
+// groupRoles annotates route groups whose every route is restricted to specific
+// roles, keyed by the group's path.
+// Keep this in sync with the RequireRole middleware used by groups in SetupRoutes.
+var groupRoles = map[string][]string{
+	"/api/phpfpm":  {"admin"},
+	"/api/nginx":   {"admin"},
+	"/api/mysql":   {"admin"},
+	"/api/backups": {"admin"},
+	"/api/logs":    {"admin"},
+}
+
+// routeRoles annotates routes restricted to specific roles.
+// Keep this in sync with the RequireRole middleware applied in SetupRoutes,
+// TestRouteRolesMatchMiddleware fails for routes it gets wrong.
+var routeRoles = map[string][]string{
+	"GET /api/routes":                      {"admin"},
+	"POST /api/users":                      {"admin"},
+	"PUT /api/users/:id":                   {"admin"},
+	"DELETE /api/users/:id":                {"admin"},
+	"POST /api/clients":                    {"admin"},
+	"PUT /api/clients/:id":                 {"admin"},
+	"PUT /api/clients/:id/limits":          {"admin"},
+	"DELETE /api/clients/:id":              {"admin"},
+	"GET /api/clients/trash":               {"admin"},
+	"GET /api/clients/reconcile":           {"admin"},
+	"POST /api/clients/reconcile":          {"admin"},
+	"POST /api/clients/bulk":               {"admin"},
+	"POST /api/clients/restore":            {"admin"},
+	"POST /api/clients/:id/restore":        {"admin"},
+	"DELETE /api/clients/:id/purge":        {"admin"},
+	"POST /api/clients/:id/impersonate":    {"admin"},
+	"POST /api/clients/:id/linux-password": {"admin"},
+	"POST /api/clients/:id/databases":      {"admin"},
+	"POST /api/templates":                  {"admin"},
+	"PUT /api/templates/:id":               {"admin"},
+	"DELETE /api/templates/:id":            {"admin"},
+	"GET /api/templates/nginx":             {"admin"},
+	"PUT /api/templates/nginx":             {"admin"},
+	"DELETE /api/templates/nginx":          {"admin"},
+	"GET /api/templates/phpfpm":            {"admin"},
+	"PUT /api/templates/phpfpm":            {"admin"},
+	"DELETE /api/templates/phpfpm":         {"admin"},
+	"POST /api/system/maintenance":         {"admin"},
+	"POST /api/system/rotate-jwt-secret":   {"admin"},
+	"POST /api/system/test-email":          {"admin"},
+	"GET /api/tools/portcheck":             {"admin"},
+	"POST /api/notifications/test":         {"admin"},
+	"GET /api/certs/expiring":              {"admin"},
+	"DELETE /api/audit":                    {"admin"},
+	"GET /api/webhooks":                    {"admin"},
+	"GET /api/webhooks/:id":                {"admin"},
+	"POST /api/webhooks":                   {"admin"},
+	"PUT /api/webhooks/:id":                {"admin"},
+	"DELETE /api/webhooks/:id":             {"admin"},
+	"GET /api/webhooks/:id/dead-letters":   {"admin"},
+
+	// Restricted itself as well as by its group
+	"GET /api/nginx/sites/:domain/upstream-health": {"admin"},
+}
+
+// BuildRouteTable returns the API routes registered on r annotated with their middleware and roles
+func BuildRouteTable(r *gin.Engine) []RouteEntry {
+	var entries []RouteEntry
+
+	for _, route := range r.Routes() {
+		if !strings.HasPrefix(route.Path, "/api") {
+			continue
+		}
+
+		key := route.Method + " " + route.Path
+		middleware := []string{"cors", "error_handler"}
+		roles := []string{}
+
+		if !publicRoutes[key] {
+			middleware = append(middleware, "auth")
+		}
+		if strings.HasPrefix(route.Path, "/api/mysql/") && key != "GET /api/mysql/servers" {
+			middleware = append(middleware, "mysql_connection")
+		}
+		if !publicRoutes[key] && !accountRoutes[key] {
+			middleware = append(middleware, "require_permission")
+		}
+		if required, ok := requiredRoles(key, route.Path); ok {
+			middleware = append(middleware, "require_role")
+			roles = append(roles, required...)
+		} else if apimiddleware.SelfServiceRoute(key) {
+			// Every role, on its own account
+			roles = append(roles, apimiddleware.RolesWithPermission(apimiddleware.PermissionRead)...)
+		} else if !publicRoutes[key] && !accountRoutes[key] {
+			roles = append(roles, apimiddleware.RolesWithPermission(apimiddleware.MethodPermission(route.Method))...)
+		}
+
+		entries = append(entries, RouteEntry{
+			Method:     route.Method,
+			Path:       route.Path,
+			Handler:    route.Handler,
+			Middleware: middleware,
+			Roles:      roles,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Path == entries[j].Path {
+			return entries[i].Method < entries[j].Method
+		}
+		return entries[i].Path < entries[j].Path
+	})
+
+	return entries
+}
+
+// requiredRoles returns the roles a route is restricted to by RequireRole, on
+// the route itself or on its group
+func requiredRoles(key, path string) ([]string, bool) {
+	if roles, ok := routeRoles[key]; ok {
+		return roles, true
+	}
+	for group, roles := range groupRoles {
+		if path == group || strings.HasPrefix(path, group+"/") {
+			return roles, true
+		}
+	}
+	return nil, false
+}
+
+// getRoutes returns a handler listing the live route table of r
+// @Summary      Lists the API routes with their middleware and the roles allowed to call them
+// @ID           getRoutes
+// @Tags         routes
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "OK, with routes, total"
+// @Failure      401 {object} apierror.APIError "Unauthorized, code UNAUTHORIZED"
+// @Failure      403 {object} apierror.APIError "Forbidden, code FORBIDDEN"
+// @Security     BearerAuth
+// @Security     APIKey
+// @Router       /api/routes [get]
+func getRoutes(r *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		routes := BuildRouteTable(r)
+		c.JSON(200, gin.H{"routes": routes, "total": len(routes)})
+	}
+}
@@ -0,0 +1,145 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"r-panel/internal/api/apierror"
+	"r-panel/internal/api/middleware"
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRouteTable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	SetupRoutes(r, &config.Config{})
+
+	table := BuildRouteTable(r)
+
+	find := func(method, path string) *RouteEntry {
+		for i := range table {
+			if table[i].Method == method && table[i].Path == path {
+				return &table[i]
+			}
+		}
+		return nil
+	}
+
+	t.Run("POST /api/clients requires admin", func(t *testing.T) {
+		entry := find("POST", "/api/clients")
+		require.NotNil(t, entry)
+		assert.Equal(t, []string{"admin"}, entry.Roles)
+		assert.Contains(t, entry.Middleware, "auth")
+		assert.Contains(t, entry.Middleware, "require_role")
+	})
+
+	t.Run("GET /api/routes requires admin", func(t *testing.T) {
+		entry := find("GET", "/api/routes")
+		require.NotNil(t, entry)
+		assert.Equal(t, []string{"admin"}, entry.Roles)
+	})
+
+	t.Run("readonly users may read but not mutate", func(t *testing.T) {
+		entry := find("GET", "/api/clients")
+		require.NotNil(t, entry)
+		assert.Equal(t, []string{"admin", "user", "readonly"}, entry.Roles)
+		assert.Contains(t, entry.Middleware, "require_permission")
+
+		entry = find("POST", "/api/clients/:id/sites")
+		require.NotNil(t, entry)
+		assert.Equal(t, []string{"admin", "user"}, entry.Roles)
+	})
+
+	t.Run("server-wide groups require admin", func(t *testing.T) {
+		for _, path := range []string{"/api/nginx/sites", "/api/phpfpm/pools", "/api/backups", "/api/logs/system"} {
+			entry := find("GET", path)
+			require.NotNil(t, entry, path)
+			assert.Equal(t, []string{"admin"}, entry.Roles, path)
+			assert.Contains(t, entry.Middleware, "require_role", path)
+		}
+	})
+
+	t.Run("GET /api/auth/me is open to every role", func(t *testing.T) {
+		entry := find("GET", "/api/auth/me")
+		require.NotNil(t, entry)
+		assert.Contains(t, entry.Middleware, "auth")
+		assert.Empty(t, entry.Roles)
+	})
+
+	t.Run("GET /api/health is public", func(t *testing.T) {
+		entry := find("GET", "/api/health")
+		require.NotNil(t, entry)
+		assert.NotContains(t, entry.Middleware, "auth")
+		assert.Empty(t, entry.Roles)
+	})
+}
+
+// TestRouteRolesMatchMiddleware calls every route as each non-admin role and
+// checks that the role and permission middleware turn the role away exactly
+// when the route table leaves it out, so the annotations in introspection.go
+// cannot drift from SetupRoutes
+func TestRouteRolesMatchMiddleware(t *testing.T) {
+	if findTestConfigFile() == "" {
+		t.Skip("config.yaml file not found. Skipping tests.")
+	}
+	t.Setenv("SKIP_LINUX_USER", "true")
+
+	cfg := setupTestDB(t)
+	records := &testRecords{}
+	defer cleanupTestDB(t, cfg, records)
+
+	authService := services.NewAuthService(cfg)
+	r := setupTestRouter(cfg)
+
+	suffix := time.Now().UnixNano()
+	users := map[string]*models.User{}
+	tokens := map[string]string{}
+	for _, role := range []string{models.RoleUser, models.RoleReadonly} {
+		users[role] = createTestUser(t, authService, fmt.Sprintf("roles_%s_%d", role, suffix), "testpass123", role, records)
+		tokens[role] = createTestToken(t, cfg, authService, users[role], records)
+	}
+
+	param := regexp.MustCompile(`[:*]\w+`)
+	for _, route := range BuildRouteTable(r) {
+		// Public and account routes are open to every role, and calling
+		// logout would end the session the rest of the test uses
+		if len(route.Roles) == 0 {
+			continue
+		}
+		key := route.Method + " " + route.Path
+
+		for role, token := range tokens {
+			// Self-service routes are open to every role on its own account
+			path := route.Path
+			if middleware.SelfServiceRoute(key) {
+				path = strings.Replace(path, ":id", strconv.FormatUint(uint64(users[role].ID), 10), 1)
+			}
+			path = param.ReplaceAllString(path, "999999")
+
+			req := httptest.NewRequest(route.Method, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var body apierror.APIError
+			json.Unmarshal(w.Body.Bytes(), &body)
+			turnedAway := w.Code == http.StatusForbidden && body.Message == "Forbidden: insufficient permissions"
+			assert.Equal(t, !slices.Contains(route.Roles, role), turnedAway,
+				"%s as %s answered %d %s, the route table says %v", key, role, w.Code, body.Message, route.Roles)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package routes
+
+//go:generate go run github.com/swaggo/swag/cmd/swag@v1.16.6 init --dir .,../handlers --generalInfo openapi.go --output ../docs --outputTypes go,json --parseDependency --parseInternal
+
+import (
+	"r-panel/internal/api/docs"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// The OpenAPI document is generated by swag from the general info below and
+// the annotations on each handler. Run go generate ./internal/api/routes after
+// changing them.
+//
+// @title                       R-Panel API
+// @version                     1.0
+// @description                 Every operation needs a JWT or API key, except those without a security requirement. GET /api/routes lists the roles allowed to call each operation.
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer " followed by a login token or an API key (rpk_...)
+// @securityDefinitions.apikey  APIKey
+// @in                          header
+// @name                        X-API-Key
+
+// getOpenAPISpec serves the generated OpenAPI document
+// @Summary      Returns the OpenAPI document of the API
+// @ID           getOpenAPISpec
+// @Tags         openapi.json
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "OK"
+// @Router       /api/openapi.json [get]
+func getOpenAPISpec(c *gin.Context) {
+	c.Data(200, "application/json; charset=utf-8", []byte(docs.SwaggerInfo.ReadDoc()))
+}
+
+// getDocs serves Swagger UI for the OpenAPI document. Its assets are embedded
+// in the binary instead of loaded from a CDN.
+var getDocs = ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/api/openapi.json"))
@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"r-panel/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// swaggerDoc is the part of the generated document the tests look at
+type swaggerDoc struct {
+	Paths map[string]map[string]struct {
+		OperationID string                `json:"operationId"`
+		Security    []map[string][]string `json:"security"`
+	} `json:"paths"`
+}
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// Configure MySQL so its routes are registered too, the connect runs in the background
+	cfg := &config.Config{}
+	cfg.Database.Type = "mysql"
+	cfg.Database.MySQL.Host = "127.0.0.1"
+	cfg.Database.MySQL.Port = 1
+	SetupRoutes(r, cfg)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code, "the spec is served without auth")
+	var spec swaggerDoc
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+
+	param := regexp.MustCompile(`[:*](\w+)`)
+	operationIDs := map[string]bool{}
+	for _, route := range BuildRouteTable(r) {
+		if strings.HasPrefix(route.Path, "/api/docs/") {
+			continue
+		}
+		path := param.ReplaceAllString(route.Path, "{$1}")
+		operation, ok := spec.Paths[path][strings.ToLower(route.Method)]
+		if !assert.True(t, ok, "%s %s has no swag annotations, add them and run go generate ./internal/api/routes", route.Method, path) {
+			continue
+		}
+
+		key := route.Method + " " + route.Path
+		if publicRoutes[key] {
+			assert.Empty(t, operation.Security, "%s is public", key)
+		} else {
+			assert.NotEmpty(t, operation.Security, "%s needs auth", key)
+		}
+		assert.False(t, operationIDs[operation.OperationID], "%s repeats operation ID %s", key, operation.OperationID)
+		operationIDs[operation.OperationID] = true
+	}
+}
+
+func TestDocsRequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	SetupRoutes(r, &config.Config{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs/index.html", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Swagger UI and its assets are served from the binary, not a CDN
+	docs := gin.New()
+	docs.GET("/api/docs/*any", getDocs)
+	for _, asset := range []string{"index.html", "swagger-ui-bundle.js", "swagger-ui.css"} {
+		w = httptest.NewRecorder()
+		docs.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs/"+asset, nil))
+		assert.Equal(t, http.StatusOK, w.Code, asset)
+		assert.NotContains(t, w.Body.String(), "https://unpkg.com", asset)
+	}
+	w = httptest.NewRecorder()
+	docs.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs/swagger-initializer.js", nil))
+	assert.Contains(t, w.Body.String(), `"/api/openapi.json"`)
+}
@@ -12,59 +12,123 @@ import (
 func SetupRoutes(r *gin.Engine, cfg *config.Config) {
   // Initialize services
   authService := services.NewAuthService(cfg)
+  jwtService := services.NewJWTService(cfg)
+  maintenanceService := services.NewMaintenanceService()
+  apiKeyService := services.NewAPIKeyService()
+  metrics := services.NewMetrics()
 
   // Initialize handlers
-  authHandler := handlers.NewAuthHandler(authService, cfg)
+  authHandler := handlers.NewAuthHandler(authService, jwtService, cfg)
+  setupHandler := handlers.NewSetupHandler(authService, jwtService)
   monitoringHandler := handlers.NewMonitoringHandler()
   phpfpmHandler := handlers.NewPHPFPMHandler(cfg)
   nginxHandler := handlers.NewNginxHandler(cfg)
-  backupHandler := handlers.NewBackupHandler(cfg)
   userHandler := handlers.NewUserHandler(cfg)
-  clientHandler := handlers.NewClientHandler(cfg)
   logsHandler := handlers.NewLogsHandler(cfg)
+  systemHandler := handlers.NewSystemHandler(cfg, jwtService)
+  notificationHandler := handlers.NewNotificationHandler(cfg)
+  certHandler := handlers.NewCertHandler(cfg)
+  webhookHandler := handlers.NewWebhookHandler(cfg)
+  auditHandler := handlers.NewAuditHandler()
+  toolsHandler := handlers.NewToolsHandler(cfg)
+  apiKeyHandler := handlers.NewAPIKeyHandler()
+  configTemplateHandler := handlers.NewConfigTemplateHandler()
 
   // Initialize MySQL handler (may fail if MySQL not configured)
   mysqlHandler, _ := handlers.NewMySQLHandler(cfg)
+  clientHandler := handlers.NewClientHandler(cfg, mysqlHandler)
+  backupHandler := handlers.NewBackupHandler(cfg, mysqlHandler)
+  dashboardHandler := handlers.NewDashboardHandler(cfg, mysqlHandler, metrics)
 
   // Middleware
+  r.Use(middleware.Metrics(metrics))
   r.Use(middleware.CORSMiddleware())
   r.Use(middleware.ErrorHandler())
 
+  // Response write and handler timeouts: short for regular API calls, long (or
+  // none) for exports, imports and backups. Config.Load has already validated them.
+  // The regular write deadline is set on the engine so it also covers /metrics,
+  // the frontend and unknown routes, which the server itself leaves unbounded.
+  writeTimeout, longWriteTimeout, _ := cfg.Server.WriteTimeouts()
+  requestTimeout, longRequestTimeout, _ := cfg.Server.RequestTimeouts()
+  longRunning := middleware.Timeouts(longWriteTimeout, longRequestTimeout)
+  r.Use(middleware.WriteTimeout(writeTimeout))
+
+  // Prometheus metrics for allowed scrapers and API keys. Config.Load has
+  // already validated the allowlist.
+  metricsNetworks, _ := cfg.Metrics.AllowedNetworks()
+  r.GET("/metrics", middleware.MetricsAccess(metricsNetworks, apiKeyService), dashboardHandler.GetMetrics)
+
   // Public routes
   api := r.Group("/api")
+  api.Use(middleware.RequestTimeout(requestTimeout))
   {
-    api.GET("/health", func(c *gin.Context) {
-      c.JSON(200, gin.H{
-        "status":  "ok",
-        "message": "R-Panel API is running",
-      })
-    })
+    api.GET("/health", getHealth(maintenanceService))
 
     // Auth routes (public)
     auth := api.Group("/auth")
     {
       auth.POST("/login", authHandler.Login)
+      auth.POST("/change-password", authHandler.ChangeInitialPassword)
+    }
+
+    // OpenAPI document, the only API docs served without auth
+    api.GET("/openapi.json", getOpenAPISpec)
+
+    // First-run setup (public until the first user exists)
+    setup := api.Group("/setup")
+    {
+      setup.GET("/status", setupHandler.GetStatus)
+      setup.POST("", setupHandler.CompleteSetup)
     }
   }
 
-  // Protected routes
+  // Session routes, available to every role
+  account := api.Group("/auth")
+  account.Use(middleware.AuthMiddleware(authService, jwtService, apiKeyService))
+  {
+    account.POST("/logout", authHandler.Logout)
+    account.GET("/me", authHandler.GetMe)
+    account.POST("/stop-impersonation", authHandler.StopImpersonation)
+  }
+
+  // API keys, every role may manage its own
+  apiKeys := api.Group("/apikeys")
+  apiKeys.Use(middleware.AuthMiddleware(authService, jwtService, apiKeyService))
+  {
+    apiKeys.GET("", apiKeyHandler.GetAPIKeys)
+    apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+    apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+  }
+
+  // Protected routes: readonly users may read but not change anything
   protected := api.Group("")
-  protected.Use(middleware.AuthMiddleware(authService))
+  protected.Use(middleware.AuthMiddleware(authService, jwtService, apiKeyService), middleware.RequireMethodPermission(), middleware.Maintenance(maintenanceService))
   {
-    // Auth routes (protected)
-    protected.POST("/auth/logout", authHandler.Logout)
-    protected.GET("/auth/me", authHandler.GetMe)
+    // Route introspection (admin only)
+    protected.GET("/routes", middleware.RequireRole("admin"), getRoutes(r))
+
+    // Swagger UI for the OpenAPI document
+    protected.GET("/docs/*any", getDocs)
+
+    // Dashboard summary, scoped to the caller's client for the user role
+    protected.GET("/dashboard", dashboardHandler.GetDashboard)
 
     // Monitoring routes
     monitoring := protected.Group("/monitoring")
     {
       monitoring.GET("/stats", monitoringHandler.GetStats)
+      monitoring.GET("/time", monitoringHandler.GetTime)
+      monitoring.GET("/diskio", monitoringHandler.GetDiskIO)
       monitoring.GET("/services", monitoringHandler.GetServices)
       monitoring.GET("/processes", monitoringHandler.GetProcesses)
     }
 
-    // PHP-FPM routes
+    // PHP-FPM, nginx, MySQL and backup routes act on the whole server rather
+    // than one client, so they are for admins only. Clients reach their own
+    // sites through /clients/:id/sites.
     phpfpm := protected.Group("/phpfpm")
+    phpfpm.Use(middleware.RequireRole("admin"))
     {
       phpfpm.GET("/versions", phpfpmHandler.GetVersions)
       phpfpm.GET("/pools", phpfpmHandler.GetPools)
@@ -72,48 +136,95 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config) {
       phpfpm.POST("/pools", phpfpmHandler.CreatePool)
       phpfpm.PUT("/pools/:version/:name", phpfpmHandler.UpdatePool)
       phpfpm.DELETE("/pools/:version/:name", phpfpmHandler.DeletePool)
+      phpfpm.GET("/pools/:version/:name/versions", phpfpmHandler.GetPoolVersions)
+      phpfpm.POST("/pools/:version/:name/restore/:revision", phpfpmHandler.RestorePoolVersion)
       phpfpm.POST("/reload/:version", phpfpmHandler.ReloadPHPFPM)
+      phpfpm.POST("/restart/:version", phpfpmHandler.RestartPHPFPM)
+      phpfpm.GET("/status", phpfpmHandler.GetStatus)
     }
 
     // Nginx routes
     nginx := protected.Group("/nginx")
+    nginx.Use(middleware.RequireRole("admin"))
     {
       nginx.GET("/sites", nginxHandler.GetSites)
       nginx.GET("/sites/:domain", nginxHandler.GetSite)
       nginx.POST("/sites", nginxHandler.CreateSite)
       nginx.PUT("/sites/:domain", nginxHandler.UpdateSite)
+      nginx.POST("/sites/:domain/diff", nginxHandler.PreviewSiteUpdate)
+      nginx.GET("/sites/:domain/versions", nginxHandler.GetSiteVersions)
+      // Dials whatever the site config proxies to, keep it admin only even if the group opens up
+      nginx.GET("/sites/:domain/upstream-health", middleware.RequireRole("admin"), nginxHandler.GetUpstreamHealth)
+      nginx.POST("/sites/:domain/restore/:version", nginxHandler.RestoreSiteVersion)
       nginx.DELETE("/sites/:domain", nginxHandler.DeleteSite)
+      nginx.POST("/sites/:domain/clone", nginxHandler.CloneSite)
       nginx.POST("/sites/:domain/enable", nginxHandler.EnableSite)
       nginx.POST("/sites/:domain/disable", nginxHandler.DisableSite)
+      nginx.POST("/sites/:domain/force-https", nginxHandler.ForceHTTPS)
+      nginx.GET("/sites/:domain/auth", nginxHandler.GetSiteAuth)
+      nginx.POST("/sites/:domain/auth", nginxHandler.SetSiteAuthUser)
+      nginx.DELETE("/sites/:domain/auth/:username", nginxHandler.DeleteSiteAuthUser)
+      nginx.POST("/sites/:domain/auth/toggle", nginxHandler.ToggleSiteAuth)
       nginx.POST("/test", nginxHandler.TestConfig)
       nginx.POST("/reload", nginxHandler.Reload)
+      nginx.POST("/restart", nginxHandler.Restart)
+      nginx.GET("/status", nginxHandler.GetStatus)
       nginx.GET("/logs/:type", nginxHandler.GetLogs)
+      nginx.GET("/sites/:domain/snippets", nginxHandler.GetSiteSnippets)
+      nginx.POST("/sites/:domain/snippets/:id", nginxHandler.AttachSnippet)
+      nginx.DELETE("/sites/:domain/snippets/:id", nginxHandler.DetachSnippet)
+
+      // Snippets hold raw directives
+      snippets := nginx.Group("/snippets")
+      {
+        snippets.GET("", nginxHandler.GetSnippets)
+        snippets.GET("/:id", nginxHandler.GetSnippet)
+        snippets.POST("", nginxHandler.CreateSnippet)
+        snippets.PUT("/:id", nginxHandler.UpdateSnippet)
+        snippets.DELETE("/:id", nginxHandler.DeleteSnippet)
+      }
     }
 
     // MySQL routes (if configured)
     if mysqlHandler != nil {
+      // Reports every server itself, so it does not need the selected one up
+      protected.GET("/mysql/servers", middleware.RequireRole("admin"), mysqlHandler.GetServers)
+
       mysql := protected.Group("/mysql")
+      mysql.Use(middleware.RequireRole("admin"), mysqlHandler.EnsureConnection)
       {
         mysql.GET("/databases", mysqlHandler.GetDatabases)
         mysql.POST("/databases", mysqlHandler.CreateDatabase)
         mysql.DELETE("/databases/:name", mysqlHandler.DeleteDatabase)
+        mysql.POST("/databases/:name/optimize", longRunning, mysqlHandler.OptimizeDatabase)
+        mysql.POST("/databases/:name/repair", longRunning, mysqlHandler.RepairDatabase)
         mysql.GET("/users", mysqlHandler.GetUsers)
         mysql.POST("/users", mysqlHandler.CreateUser)
         mysql.DELETE("/users/:user", mysqlHandler.DeleteUser)
         mysql.POST("/users/:user/privileges", mysqlHandler.GrantPrivileges)
         mysql.POST("/query", mysqlHandler.ExecuteQuery)
-        mysql.POST("/export/:database", mysqlHandler.ExportDatabase)
-        mysql.POST("/import/:database", mysqlHandler.ImportDatabase)
+        mysql.GET("/processlist", mysqlHandler.GetProcessList)
+        mysql.DELETE("/processlist/:id", mysqlHandler.KillProcess)
+        mysql.GET("/slowlog", mysqlHandler.GetSlowLog)
+        mysql.POST("/export/:database", longRunning, mysqlHandler.ExportDatabase)
+        mysql.GET("/databases/:database/export", longRunning, mysqlHandler.DownloadDatabaseExport)
+        mysql.POST("/import/:database", longRunning, mysqlHandler.ImportDatabase)
       }
     }
 
     // Backup routes
     backups := protected.Group("/backups")
+    backups.Use(middleware.RequireRole("admin"))
     {
       backups.GET("", backupHandler.GetBackups)
-      backups.POST("", backupHandler.CreateBackup)
+      backups.POST("", longRunning, backupHandler.CreateBackup)
+      backups.GET("/running", backupHandler.GetRunningBackups)
+      backups.GET("/running/:job", backupHandler.GetBackupJob)
+      backups.DELETE("/running/:job", backupHandler.CancelBackup)
       backups.DELETE("/:id", backupHandler.DeleteBackup)
-      backups.POST("/restore", backupHandler.RestoreBackup)
+      backups.GET("/:id/download", longRunning, backupHandler.DownloadBackup)
+      backups.POST("/upload", longRunning, backupHandler.UploadBackup)
+      backups.POST("/restore", longRunning, backupHandler.RestoreBackup)
     }
 
     // User management routes
@@ -128,24 +239,113 @@ func SetupRoutes(r *gin.Engine, cfg *config.Config) {
       users.GET("/sessions", userHandler.GetSessions)
     }
 
+    // Self-service profile of the client bound to the caller
+    me := protected.Group("/me")
+    {
+      me.GET("/client", clientHandler.GetOwnClient)
+      me.PATCH("/client", clientHandler.UpdateOwnClient)
+    }
+
     // Client management routes
     clients := protected.Group("/clients")
     {
       clients.GET("", clientHandler.GetClients)
+      clients.GET("/trash", middleware.RequireRole("admin"), clientHandler.GetTrashedClients)
+      clients.GET("/reconcile", middleware.RequireRole("admin"), clientHandler.ReconcileLinuxUsers)
+      clients.POST("/reconcile", middleware.RequireRole("admin"), clientHandler.RecreateMissingLinuxUsers)
+      clients.POST("/bulk", middleware.RequireRole("admin"), clientHandler.BulkClientAction)
       clients.GET("/:id", clientHandler.GetClient)
+      clients.GET("/:id/sites", clientHandler.GetClientSites)
+      clients.POST("/:id/sites", clientHandler.CreateClientSite)
+      clients.GET("/:id/mail/usage", clientHandler.GetClientMailUsage)
+      clients.GET("/:id/ssh-keys", clientHandler.GetSSHKeys)
+      clients.POST("/:id/ssh-keys", clientHandler.AddSSHKey)
+      clients.DELETE("/:id/ssh-keys", clientHandler.DeleteSSHKey)
       clients.POST("", middleware.RequireRole("admin"), clientHandler.CreateClient)
       clients.PUT("/:id", middleware.RequireRole("admin"), clientHandler.UpdateClient)
       clients.PUT("/:id/limits", middleware.RequireRole("admin"), clientHandler.UpdateClientLimits)
       clients.DELETE("/:id", middleware.RequireRole("admin"), clientHandler.DeleteClient)
+      clients.POST("/restore", middleware.RequireRole("admin"), longRunning, clientHandler.RestoreClientBackup)
+      clients.POST("/:id/restore", middleware.RequireRole("admin"), clientHandler.RestoreClient)
+      clients.DELETE("/:id/purge", middleware.RequireRole("admin"), longRunning, clientHandler.PurgeClient)
+      clients.POST("/:id/impersonate", middleware.RequireRole("admin"), authHandler.ImpersonateClient)
+      clients.POST("/:id/linux-password", middleware.RequireRole("admin"), clientHandler.SetLinuxPassword)
+      if mysqlHandler != nil {
+        clients.POST("/:id/databases", middleware.RequireRole("admin"), mysqlHandler.CreateClientDatabase)
+      }
+    }
+
+    // Client templates, named plans of limits applied to new clients
+    templates := protected.Group("/templates")
+    {
+      templates.GET("", clientHandler.GetTemplates)
+      templates.GET("/:id", clientHandler.GetTemplate)
+      templates.POST("", middleware.RequireRole("admin"), clientHandler.CreateTemplate)
+      templates.PUT("/:id", middleware.RequireRole("admin"), clientHandler.UpdateTemplate)
+      templates.DELETE("/:id", middleware.RequireRole("admin"), clientHandler.DeleteTemplate)
+
+      // Sources new nginx sites and PHP-FPM pools are generated from
+      templates.GET("/nginx", middleware.RequireRole("admin"), configTemplateHandler.GetNginxTemplate)
+      templates.PUT("/nginx", middleware.RequireRole("admin"), configTemplateHandler.UpdateNginxTemplate)
+      templates.DELETE("/nginx", middleware.RequireRole("admin"), configTemplateHandler.ResetNginxTemplate)
+      templates.GET("/phpfpm", middleware.RequireRole("admin"), configTemplateHandler.GetPHPFPMTemplate)
+      templates.PUT("/phpfpm", middleware.RequireRole("admin"), configTemplateHandler.UpdatePHPFPMTemplate)
+      templates.DELETE("/phpfpm", middleware.RequireRole("admin"), configTemplateHandler.ResetPHPFPMTemplate)
+    }
+
+    // Diagnostic tools
+    tools := protected.Group("/tools")
+    {
+      tools.GET("/dns", toolsHandler.LookupDNS)
+      tools.GET("/ptr", toolsHandler.LookupPTR)
+      // Dials arbitrary hosts, so admins only and limited by tools.portcheck_allowed_hosts
+      tools.GET("/portcheck", middleware.RequireRole("admin"), toolsHandler.CheckPort)
+    }
+
+    // System routes (admin only)
+    system := protected.Group("/system")
+    system.Use(middleware.RequireRole("admin"))
+    {
+      system.POST("/test-email", systemHandler.TestEmail)
+      system.POST("/rotate-jwt-secret", systemHandler.RotateJWTSecret)
+      system.POST("/maintenance", systemHandler.SetMaintenance)
+    }
+
+    // Notification routes (admin only)
+    notifications := protected.Group("/notifications")
+    notifications.Use(middleware.RequireRole("admin"))
+    {
+      notifications.POST("/test", notificationHandler.TestNotification)
+    }
+
+    // Certificate expiry report (admin only)
+    protected.GET("/certs/expiring", middleware.RequireRole("admin"), certHandler.GetExpiring)
+
+    // Audit log routes (admin only)
+    protected.DELETE("/audit", middleware.RequireRole("admin"), longRunning, auditHandler.PruneAuditLogs)
+
+    // Webhook routes (admin only)
+    webhooks := protected.Group("/webhooks")
+    webhooks.Use(middleware.RequireRole("admin"))
+    {
+      webhooks.GET("", webhookHandler.GetWebhooks)
+      webhooks.GET("/:id", webhookHandler.GetWebhook)
+      webhooks.POST("", webhookHandler.CreateWebhook)
+      webhooks.PUT("/:id", webhookHandler.UpdateWebhook)
+      webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+      webhooks.GET("/:id/dead-letters", webhookHandler.GetDeadLetters)
     }
 
-    // Logs routes
+    // Logs routes, server-wide like the nginx and PHP-FPM routes
     logs := protected.Group("/logs")
+    logs.Use(middleware.RequireRole("admin"))
     {
       logs.GET("/system", logsHandler.GetSystemLogs)
       logs.GET("/nginx/:type", logsHandler.GetNginxLogs)
       logs.GET("/phpfpm", logsHandler.GetPHPFPMLogs)
       logs.GET("/tail/:source", logsHandler.TailLogs)
+      logs.GET("/combined", logsHandler.GetCombinedLogs)
+      logs.GET("/files", logsHandler.GetLogFiles)
     }
   }
 }
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"r-panel/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTimeoutCoversNonAPIRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const chunks = 8
+
+	r := gin.New()
+	cfg := &config.Config{}
+	cfg.Server.WriteTimeout = "100ms"
+	SetupRoutes(r, cfg)
+
+	// Registered after SetupRoutes, like the frontend in main
+	stream := func(c *gin.Context) {
+		c.Status(200)
+		for i := 0; i < chunks; i++ {
+			if _, err := c.Writer.WriteString("chunk\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	r.GET("/", stream)
+	r.NoRoute(stream)
+
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 0
+	srv.Start()
+	defer srv.Close()
+
+	for _, path := range []string{"/", "/clients/1"} {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err, path)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.True(t, err != nil || strings.Count(string(body), "chunk") < chunks,
+			"expected %s to be cut after the write timeout", path)
+	}
+}
@@ -1,21 +1,41 @@
 package config
 
 import (
+	"compress/gzip"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
 	Environment string           `yaml:"environment"` // local, production
+	DataDir     string           `yaml:"data_dir"`    // root of the panel's own files, see ApplyDataDir
 	Server      ServerConfig     `yaml:"server"`
 	Database    DatabaseConfig   `yaml:"database"`
 	JWT         JWTConfig        `yaml:"jwt"`
 	Security    SecurityConfig   `yaml:"security"`
 	Paths       PathsConfig      `yaml:"paths"`
 	DefaultUser DefaultUserConfig `yaml:"default_user"`
+	SMTP        SMTPConfig       `yaml:"smtp"`
+	Backup      BackupConfig     `yaml:"backup"`
+	Clients     ClientsConfig    `yaml:"clients"`
+
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Webhooks      WebhooksConfig      `yaml:"webhooks"`
+	Audit         AuditConfig         `yaml:"audit"`
+	Tools         ToolsConfig         `yaml:"tools"`
+	Metrics       MetricsConfig       `yaml:"metrics"`
+	PHPFPM        PHPFPMConfig        `yaml:"php_fpm"`
 }
 
 type ServerConfig struct {
@@ -23,6 +43,94 @@ type ServerConfig struct {
     Port int       `yaml:"port"`
     Mode string    `yaml:"mode"`
     TLS  TLSConfig `yaml:"tls,omitempty"`
+
+    WriteTimeout     string `yaml:"write_timeout"`      // Regular API responses, default 15s
+    LongWriteTimeout string `yaml:"long_write_timeout"` // Exports, imports and backups, default 0 (none)
+
+    // How long a handler may run before its commands are killed and the
+    // request fails with 504
+    RequestTimeout     string `yaml:"request_timeout"`      // Regular API calls, default 10s
+    LongRequestTimeout string `yaml:"long_request_timeout"` // Exports, imports and backups, default 0 (none)
+
+    // FrontendDir serves the built frontend from this directory instead of
+    // searching the usual web/dist locations
+    FrontendDir string `yaml:"frontend_dir"`
+}
+
+// Default response write timeouts, see ServerConfig.WriteTimeouts
+const (
+	DefaultWriteTimeout     = 15 * time.Second
+	DefaultLongWriteTimeout = 0
+)
+
+// WriteTimeouts returns the response write timeouts for regular and long-running API routes
+func (s ServerConfig) WriteTimeouts() (regular, long time.Duration, err error) {
+	regular, long = DefaultWriteTimeout, DefaultLongWriteTimeout
+
+	if s.WriteTimeout != "" {
+		if regular, err = time.ParseDuration(s.WriteTimeout); err != nil {
+			return 0, 0, fmt.Errorf("invalid server.write_timeout: %w", err)
+		}
+	}
+	if s.LongWriteTimeout != "" {
+		if long, err = time.ParseDuration(s.LongWriteTimeout); err != nil {
+			return 0, 0, fmt.Errorf("invalid server.long_write_timeout: %w", err)
+		}
+	}
+
+	return regular, long, nil
+}
+
+// Default request timeouts, see ServerConfig.RequestTimeouts
+const (
+	DefaultRequestTimeout     = 10 * time.Second
+	DefaultLongRequestTimeout = 0
+)
+
+// RequestTimeouts returns how long handlers of regular and long-running API
+// routes may run. Each must be shorter than its write timeout, or the 504
+// could not be written anymore. Timeouts left unset that would not fit are
+// derived from the write timeout instead.
+func (s ServerConfig) RequestTimeouts() (regular, long time.Duration, err error) {
+	regular, long = DefaultRequestTimeout, DefaultLongRequestTimeout
+
+	writeRegular, writeLong, err := s.WriteTimeouts()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if s.RequestTimeout != "" {
+		if regular, err = time.ParseDuration(s.RequestTimeout); err != nil {
+			return 0, 0, fmt.Errorf("invalid server.request_timeout: %w", err)
+		}
+	} else if writeRegular > 0 && regular >= writeRegular {
+		regular = requestTimeoutFor(writeRegular)
+	}
+	if s.LongRequestTimeout != "" {
+		if long, err = time.ParseDuration(s.LongRequestTimeout); err != nil {
+			return 0, 0, fmt.Errorf("invalid server.long_request_timeout: %w", err)
+		}
+	} else if writeLong > 0 {
+		long = requestTimeoutFor(writeLong)
+	}
+	if regular < 0 || long < 0 {
+		return 0, 0, fmt.Errorf("invalid server request timeouts: must not be negative")
+	}
+
+	if writeRegular > 0 && (regular == 0 || regular >= writeRegular) {
+		return 0, 0, fmt.Errorf("invalid server.request_timeout: must be shorter than server.write_timeout (%s)", writeRegular)
+	}
+	if writeLong > 0 && (long == 0 || long >= writeLong) {
+		return 0, 0, fmt.Errorf("invalid server.long_request_timeout: must be shorter than server.long_write_timeout (%s)", writeLong)
+	}
+
+	return regular, long, nil
+}
+
+// requestTimeoutFor derives a request timeout from a write timeout, leaving a
+// third of it to write the response, like the 10s and 15s defaults
+func requestTimeoutFor(write time.Duration) time.Duration {
+	return write * 2 / 3
 }
 
 type TLSConfig struct {
@@ -32,10 +140,67 @@ type TLSConfig struct {
     CacheDir string `yaml:"cache_dir"` // Cache directory untuk certificates
 }
 
+// CertCacheDir returns the autocert cache directory, ./data/certs by default
+func (t TLSConfig) CertCacheDir() string {
+	if t.CacheDir == "" {
+		return "./data/certs"
+	}
+	return t.CacheDir
+}
+
+// Locations under data_dir, see ApplyDataDir
+const (
+	DataDirSQLite          = "rpanel.db"
+	DataDirBackups         = "backups"
+	DataDirCerts           = "certs"
+	DataDirConfigTemplates = "templates"
+)
+
+// ApplyDataDir fills the paths left unset with their place under data_dir:
+// the SQLite database, backups, the TLS certificate cache and the config
+// templates. Paths set explicitly keep winning, so existing configs behave as
+// before.
+func (c *Config) ApplyDataDir() {
+	if c.DataDir == "" {
+		return
+	}
+	if c.Database.SQLite.Path == "" {
+		c.Database.SQLite.Path = filepath.Join(c.DataDir, DataDirSQLite)
+	}
+	if c.Paths.Backups == "" {
+		c.Paths.Backups = filepath.Join(c.DataDir, DataDirBackups)
+	}
+	if c.Server.TLS.CacheDir == "" {
+		c.Server.TLS.CacheDir = filepath.Join(c.DataDir, DataDirCerts)
+	}
+	if c.Paths.ConfigTemplates == "" {
+		c.Paths.ConfigTemplates = filepath.Join(c.DataDir, DataDirConfigTemplates)
+	}
+}
+
 type DatabaseConfig struct {
 	Type   string         `yaml:"type"`
 	SQLite SQLiteConfig   `yaml:"sqlite"`
 	MySQL  MySQLConfig    `yaml:"mysql"`
+
+	// Additional MySQL servers managed from the panel, picked with ?server=<name>
+	// on the mysql endpoints. database.mysql is the primary server.
+	MySQLServers []MySQLServerConfig `yaml:"mysql_servers"`
+}
+
+// PrimaryMySQLServer is the name of the database.mysql server
+const PrimaryMySQLServer = "primary"
+
+// mysqlServerNamePattern matches names of additional MySQL servers
+var mysqlServerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type MySQLServerConfig struct {
+	Name        string `yaml:"name"`
+	MySQLConfig `yaml:",inline"`
+
+	// Host client database users are granted access from, default localhost.
+	// Use the web server address (or %) when the database server is remote.
+	ClientHost string `yaml:"client_host"`
 }
 
 type SQLiteConfig struct {
@@ -51,15 +216,141 @@ type MySQLConfig struct {
 	Charset  string `yaml:"charset"`
 }
 
+// DSN returns the driver connection string for the server, without selecting a database
+func (c MySQLConfig) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/?charset=%s&parseTime=True",
+		c.Username,
+		c.Password,
+		c.Host,
+		c.Port,
+		c.Charset,
+	)
+}
+
 type JWTConfig struct {
 	Secret    string `yaml:"secret"`
 	ExpiresIn string `yaml:"expires_in"`
 	Issuer    string `yaml:"issuer"`
+
+	// RS256 signs with PrivateKeyFile instead of the shared secret. Tokens
+	// signed by the keys in PreviousPublicKeyFiles keep verifying, so the key
+	// can be changed without logging everyone out.
+	Algorithm              string   `yaml:"algorithm"` // HS256 (default) or RS256
+	PrivateKeyFile         string   `yaml:"private_key_file"`
+	PreviousPublicKeyFiles []string `yaml:"previous_public_key_files"`
+}
+
+// Token signing algorithms, see JWTConfig.SigningAlgorithm
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+)
+
+// minRSAKeyBits is the smallest RSA key accepted for signing tokens
+const minRSAKeyBits = 2048
+
+// SigningAlgorithm returns the algorithm tokens are signed and verified with
+func (j JWTConfig) SigningAlgorithm() (string, error) {
+	switch strings.ToUpper(j.Algorithm) {
+	case "", JWTAlgorithmHS256:
+		return JWTAlgorithmHS256, nil
+	case JWTAlgorithmRS256:
+		if j.PrivateKeyFile == "" {
+			return "", fmt.Errorf("jwt.private_key_file is required with RS256")
+		}
+		return JWTAlgorithmRS256, nil
+	default:
+		return "", fmt.Errorf("unsupported jwt.algorithm: %s (use HS256 or RS256)", j.Algorithm)
+	}
+}
+
+// RSAKeys reads the RS256 signing key and the previous public keys that are
+// still accepted
+func (j JWTConfig) RSAKeys() (*rsa.PrivateKey, []*rsa.PublicKey, error) {
+	block, err := readPEM(j.PrivateKeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("jwt.private_key_file: %w", err)
+	}
+	var private *rsa.PrivateKey
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		private = key
+	} else if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+			private = rsaKey
+		}
+	}
+	if private == nil {
+		return nil, nil, fmt.Errorf("jwt.private_key_file: %s does not hold an RSA private key", j.PrivateKeyFile)
+	}
+	if private.N.BitLen() < minRSAKeyBits {
+		return nil, nil, fmt.Errorf("jwt.private_key_file: RSA key must have at least %d bits", minRSAKeyBits)
+	}
+
+	var previous []*rsa.PublicKey
+	for _, path := range j.PreviousPublicKeyFiles {
+		block, err := readPEM(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("jwt.previous_public_key_files: %w", err)
+		}
+		var public *rsa.PublicKey
+		if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+			public, _ = key.(*rsa.PublicKey)
+		} else if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+			public = key
+		}
+		if public == nil {
+			return nil, nil, fmt.Errorf("jwt.previous_public_key_files: %s does not hold an RSA public key", path)
+		}
+		previous = append(previous, public)
+	}
+	return private, previous, nil
 }
 
+// readPEM returns the first PEM block of the file at path
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", path)
+	}
+	return block, nil
+}
+
+// Accepted security.bcrypt_cost range. Lower costs are cheap to brute force; each
+// step up doubles the time a login takes, about a second at the maximum.
+const (
+	MinBcryptCost     = 10
+	MaxBcryptCost     = 15
+	DefaultBcryptCost = 10
+)
+
 type SecurityConfig struct {
-	BcryptCost int              `yaml:"bcrypt_cost"`
-	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	BcryptCost    int             `yaml:"bcrypt_cost"` // 10-15, default 10; raising it rehashes passwords on their next login
+	HashAlgorithm string          `yaml:"hash_algorithm"` // bcrypt (default), argon2id
+	RateLimit     RateLimitConfig `yaml:"rate_limit"`
+	// How often expired sessions are deleted, default 1h
+	SessionCleanupInterval string `yaml:"session_cleanup_interval"`
+}
+
+// DefaultSessionCleanupInterval applies when security.session_cleanup_interval is unset
+const DefaultSessionCleanupInterval = time.Hour
+
+// SessionCleanupIntervalDuration returns how often expired sessions are deleted
+func (s SecurityConfig) SessionCleanupIntervalDuration() (time.Duration, error) {
+	if s.SessionCleanupInterval == "" {
+		return DefaultSessionCleanupInterval, nil
+	}
+	interval, err := time.ParseDuration(s.SessionCleanupInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid security.session_cleanup_interval: %w", err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("invalid security.session_cleanup_interval: must be positive")
+	}
+	return interval, nil
 }
 
 type RateLimitConfig struct {
@@ -72,7 +363,372 @@ type PathsConfig struct {
 	NginxSitesAvailable string `yaml:"nginx_sites_available"`
 	NginxSitesEnabled   string `yaml:"nginx_sites_enabled"`
 	NginxLogs           string `yaml:"nginx_logs"`
+	Logs                string `yaml:"logs"` // further logs listed under /api/logs/files, e.g. /var/log
 	Backups             string `yaml:"backups"`
+	MailStorage         string `yaml:"mail_storage"` // maildir root, laid out as <root>/<linux_user>/<domain>/<mailbox>
+	SSLCertificates     string `yaml:"ssl_certificates"` // uploaded site certificates, as <domain>.crt and <domain>.key
+	NginxAuth           string `yaml:"nginx_auth"` // basic auth password files, default htpasswd next to sites-available
+	HomeBase            string `yaml:"home_base"` // client home directories, default /home; a reseller's clients live in <home_base>/resellers/<reseller>/
+	ConfigTemplates     string `yaml:"config_templates"` // customized nginx site and PHP-FPM pool templates, see ConfigTemplatesDir
+}
+
+// ConfigTemplatesDir returns where customized config templates are stored,
+// ./data/templates by default
+func (p PathsConfig) ConfigTemplatesDir() string {
+	if p.ConfigTemplates == "" {
+		return "./data/templates"
+	}
+	return p.ConfigTemplates
+}
+
+// DefaultHomeBase is where client home directories go unless paths.home_base says otherwise
+const DefaultHomeBase = "/home"
+
+// HomeRoots are the directories paths.home_base must be or lie under
+var HomeRoots = []string{"/home", "/srv", "/var/www"}
+
+// HomeBaseDir returns the cleaned base directory of client home directories.
+// It must be absolute and inside one of HomeRoots.
+func (p PathsConfig) HomeBaseDir() (string, error) {
+	if p.HomeBase == "" {
+		return DefaultHomeBase, nil
+	}
+	if !filepath.IsAbs(p.HomeBase) {
+		return "", fmt.Errorf("invalid paths.home_base %q: must be an absolute path", p.HomeBase)
+	}
+	base := filepath.Clean(p.HomeBase)
+	for _, root := range HomeRoots {
+		if base == root || strings.HasPrefix(base, root+"/") {
+			return base, nil
+		}
+	}
+	return "", fmt.Errorf("invalid paths.home_base %q: must be inside one of %s", p.HomeBase, strings.Join(HomeRoots, ", "))
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"` // Sender address, e.g. "R-Panel <panel@example.com>"
+}
+
+// NotificationsConfig controls which events are emailed to the panel admins
+type NotificationsConfig struct {
+	Recipients          []string                 `yaml:"recipients"`            // Admin addresses that receive notifications
+	Events              NotificationEventsConfig `yaml:"events"`                // Each event type is opt-in
+	QuotaWarningPercent int                      `yaml:"quota_warning_percent"` // Warn when a mailbox reaches this share of its quota, default 90
+	CertExpiryDays      int                      `yaml:"cert_expiry_days"`      // Warn this many days before a certificate expires, default 14
+	CertDirs            []string                 `yaml:"cert_dirs"`             // More certificates to watch besides the TLS cache and paths.ssl_certificates
+	CheckInterval       string                   `yaml:"check_interval"`        // How often quotas are checked, default 1h, certificates are scanned daily
+	RetryAttempts       int                      `yaml:"retry_attempts"`        // Delivery attempts on transient SMTP errors, default 3
+}
+
+type NotificationEventsConfig struct {
+	BackupFailed bool `yaml:"backup_failed"`
+	QuotaWarning bool `yaml:"quota_warning"`
+	CertExpiry   bool `yaml:"cert_expiry"`
+}
+
+// Notification defaults, see NotificationsConfig
+const (
+	DefaultQuotaWarningPercent       = 90
+	DefaultCertExpiryDays            = 14
+	DefaultNotificationCheckInterval = time.Hour
+	DefaultNotificationRetryAttempts = 3
+)
+
+// CheckIntervalDuration returns how often quotas are checked
+func (n NotificationsConfig) CheckIntervalDuration() (time.Duration, error) {
+	if n.CheckInterval == "" {
+		return DefaultNotificationCheckInterval, nil
+	}
+	interval, err := time.ParseDuration(n.CheckInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid notifications.check_interval: %w", err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("invalid notifications.check_interval: must be positive")
+	}
+	return interval, nil
+}
+
+// WebhooksConfig controls outbound webhook delivery
+type WebhooksConfig struct {
+	MaxAttempts          int    `yaml:"max_attempts"`           // Delivery attempts before a dead letter is recorded, default 5
+	ServiceCheckInterval string `yaml:"service_check_interval"` // How often services are checked for service.down, default 1m
+}
+
+// Webhook defaults, see WebhooksConfig
+const (
+	DefaultWebhookMaxAttempts          = 5
+	DefaultWebhookServiceCheckInterval = time.Minute
+)
+
+// ServiceCheckIntervalDuration returns how often services are checked for outages
+func (w WebhooksConfig) ServiceCheckIntervalDuration() (time.Duration, error) {
+	if w.ServiceCheckInterval == "" {
+		return DefaultWebhookServiceCheckInterval, nil
+	}
+	interval, err := time.ParseDuration(w.ServiceCheckInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhooks.service_check_interval: %w", err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("invalid webhooks.service_check_interval: must be positive")
+	}
+	return interval, nil
+}
+
+type AuditConfig struct {
+	RetentionDays int `yaml:"retention_days"` // Audit log entries older than this are pruned daily, 0 keeps them forever
+}
+
+type ToolsConfig struct {
+	// Hosts the port check may dial: host names, IP addresses or CIDR ranges.
+	// Empty allows any host.
+	PortCheckAllowedHosts []string `yaml:"portcheck_allowed_hosts"`
+}
+
+// PortCheckAllowlist splits PortCheckAllowedHosts into host names and the IP
+// ranges given as addresses or CIDRs
+func (t ToolsConfig) PortCheckAllowlist() (hosts []string, networks []*net.IPNet, err error) {
+	for _, entry := range t.PortCheckAllowedHosts {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid tools.portcheck_allowed_hosts entry %q: %w", entry, err)
+			}
+			networks = append(networks, network)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		case entry != "":
+			hosts = append(hosts, strings.TrimSuffix(entry, "."))
+		}
+	}
+	return hosts, networks, nil
+}
+
+type PHPFPMConfig struct {
+	// How long the PHP version and pool scans are reused, default 10s. "0"
+	// scans the disk on every request. Pool changes made through the panel
+	// show up at once either way.
+	CacheTTL string `yaml:"cache_ttl"`
+}
+
+// DefaultPHPFPMCacheTTL applies when php_fpm.cache_ttl is unset
+const DefaultPHPFPMCacheTTL = 10 * time.Second
+
+// CacheTTLDuration returns how long PHP version and pool scans are cached
+func (p PHPFPMConfig) CacheTTLDuration() (time.Duration, error) {
+	if p.CacheTTL == "" {
+		return DefaultPHPFPMCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(p.CacheTTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid php_fpm.cache_ttl: %w", err)
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("invalid php_fpm.cache_ttl: must not be negative")
+	}
+	return ttl, nil
+}
+
+type MetricsConfig struct {
+	// Addresses or CIDR ranges that may scrape /metrics without an API key.
+	// Everyone else needs an API key with the read scope.
+	AllowedIPs []string `yaml:"allowed_ips"`
+}
+
+// AllowedNetworks parses AllowedIPs, a single address becomes a /32 or /128
+func (m MetricsConfig) AllowedNetworks() ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range m.AllowedIPs {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid metrics.allowed_ips entry %q: %w", entry, err)
+			}
+			networks = append(networks, network)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid metrics.allowed_ips entry %q: not an IP address or CIDR", entry)
+		}
+		bits := 8 * len(ip.To16())
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return networks, nil
+}
+
+type BackupConfig struct {
+	BeforeClientDelete bool `yaml:"before_client_delete"` // Back up a client before it is purged
+	MaxUploadMB        int  `yaml:"max_upload_mb"`        // Largest backup accepted by upload, default 2048
+	CompressionLevel   string `yaml:"compression_level"`  // gzip level 0-9, or none for plain tar and sql files
+	DownloadLimitKBps  int  `yaml:"download_limit_kbps"`  // Bandwidth cap per backup download, default 0 (none)
+	FreeSpaceMarginMB  int  `yaml:"free_space_margin_mb"` // Space a backup must leave free on the backups file system, default 1024
+}
+
+// Backup compression levels besides the gzip levels 0 to 9
+const (
+	CompressionDefault = gzip.DefaultCompression
+	CompressionNone    = -2 // no gzip at all, backups are plain tar and sql files
+)
+
+// ParseCompressionLevel parses a backup compression level: a gzip level from 0
+// to 9, "none", or empty for the gzip default
+func ParseCompressionLevel(value string) (int, error) {
+	switch value {
+	case "", "default":
+		return CompressionDefault, nil
+	case "none":
+		return CompressionNone, nil
+	}
+	level, err := strconv.Atoi(value)
+	if err != nil || level < gzip.NoCompression || level > gzip.BestCompression {
+		return 0, fmt.Errorf("invalid compression level %q: use 0 to 9 or none", value)
+	}
+	return level, nil
+}
+
+// Compression returns the compression level backups are written with
+func (b BackupConfig) Compression() (int, error) {
+	level, err := ParseCompressionLevel(b.CompressionLevel)
+	if err != nil {
+		return 0, fmt.Errorf("backup.compression_level: %w", err)
+	}
+	return level, nil
+}
+
+// defaultMaxUploadMB applies when backup.max_upload_mb is unset
+const defaultMaxUploadMB = 2048
+
+// MaxUploadBytes returns the largest backup upload in bytes
+func (b BackupConfig) MaxUploadBytes() int64 {
+	if b.MaxUploadMB <= 0 {
+		return defaultMaxUploadMB << 20
+	}
+	return int64(b.MaxUploadMB) << 20
+}
+
+// DownloadLimit returns the bandwidth cap of a backup download in bytes per
+// second, 0 for none
+func (b BackupConfig) DownloadLimit() int64 {
+	if b.DownloadLimitKBps <= 0 {
+		return 0
+	}
+	return int64(b.DownloadLimitKBps) << 10
+}
+
+// defaultFreeSpaceMarginMB applies when backup.free_space_margin_mb is unset
+const defaultFreeSpaceMarginMB = 1024
+
+// FreeSpaceMargin returns the bytes a backup must leave free on the backups
+// file system. A negative margin only requires the backup itself to fit.
+func (b BackupConfig) FreeSpaceMargin() int64 {
+	switch {
+	case b.FreeSpaceMarginMB < 0:
+		return 0
+	case b.FreeSpaceMarginMB == 0:
+		return defaultFreeSpaceMarginMB << 20
+	}
+	return int64(b.FreeSpaceMarginMB) << 20
+}
+
+type ClientsConfig struct {
+	LinuxUsername LinuxUsernameConfig `yaml:"linux_username"`
+	CustomerNo    CustomerNoConfig    `yaml:"customer_no"`
+}
+
+// CustomerNoConfig is how customer numbers are written, e.g. C42 or KD-00042
+type CustomerNoConfig struct {
+	Prefix string `yaml:"prefix"` // Put in front of the number, default C
+	Digits int    `yaml:"digits"` // Pad the number with zeros to this many digits, default 0
+}
+
+const (
+	defaultCustomerNoPrefix = "C"
+	maxCustomerNoDigits     = 20
+)
+
+// customerNoPrefixPattern keeps customer numbers usable in database and file names
+var customerNoPrefixPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]{0,9}$`)
+
+// WithDefaults returns the format with unset fields filled in
+func (c CustomerNoConfig) WithDefaults() (CustomerNoConfig, error) {
+	if c.Prefix == "" {
+		c.Prefix = defaultCustomerNoPrefix
+	}
+	if !customerNoPrefixPattern.MatchString(c.Prefix) {
+		return c, fmt.Errorf("invalid clients.customer_no.prefix %q: start with a letter, then use up to 9 letters and digits", c.Prefix)
+	}
+	if c.Digits < 0 || c.Digits > maxCustomerNoDigits {
+		return c, fmt.Errorf("invalid clients.customer_no.digits: %d (use 0 to %d)", c.Digits, maxCustomerNoDigits)
+	}
+	return c, nil
+}
+
+// Format writes customer number n. The format must have its defaults applied.
+func (c CustomerNoConfig) Format(n uint64) string {
+	return fmt.Sprintf("%s%0*d", c.Prefix, c.Digits, n)
+}
+
+// LinuxUsernameConfig is how a client's Linux username is derived from their
+// panel username
+type LinuxUsernameConfig struct {
+	Prefix       string `yaml:"prefix"`        // Put in front of names that don't start with a letter, default u
+	AlwaysPrefix bool   `yaml:"always_prefix"` // Put the prefix in front of every name
+	MinLength    int    `yaml:"min_length"`    // Shorter names are padded with digits, default 3
+	MaxLength    int    `yaml:"max_length"`    // Longer names are cut, default and at most 32
+}
+
+// Linux username policy defaults, useradd accepts at most 32 characters
+const (
+	defaultLinuxUsernamePrefix    = "u"
+	defaultLinuxUsernameMinLength = 3
+	MaxLinuxUsernameLength        = 32
+)
+
+// linuxUsernamePrefixPattern keeps prefixed names valid Linux usernames
+var linuxUsernamePrefixPattern = regexp.MustCompile(`^[a-z_][a-z0-9_-]*$`)
+
+// WithDefaults returns the policy with unset fields filled in
+func (l LinuxUsernameConfig) WithDefaults() (LinuxUsernameConfig, error) {
+	if l.Prefix == "" {
+		l.Prefix = defaultLinuxUsernamePrefix
+	}
+	if l.MinLength == 0 {
+		l.MinLength = defaultLinuxUsernameMinLength
+	}
+	if l.MaxLength == 0 {
+		l.MaxLength = MaxLinuxUsernameLength
+	}
+
+	if !linuxUsernamePrefixPattern.MatchString(l.Prefix) {
+		return l, fmt.Errorf("invalid clients.linux_username.prefix %q: start with a lowercase letter or _, then use lowercase letters, digits, - and _", l.Prefix)
+	}
+	if l.MaxLength < 1 || l.MaxLength > MaxLinuxUsernameLength {
+		return l, fmt.Errorf("invalid clients.linux_username.max_length: %d (use 1 to %d)", l.MaxLength, MaxLinuxUsernameLength)
+	}
+	if l.MinLength < 1 || l.MinLength > l.MaxLength {
+		return l, fmt.Errorf("invalid clients.linux_username.min_length: %d (use 1 to max_length)", l.MinLength)
+	}
+	if len(l.Prefix) >= l.MaxLength {
+		return l, fmt.Errorf("invalid clients.linux_username.prefix %q: must be shorter than max_length", l.Prefix)
+	}
+	return l, nil
 }
 
 type DefaultUserConfig struct {
@@ -83,6 +739,10 @@ type DefaultUserConfig struct {
 
 var Global *Config
 
+// DefaultJWTSecret signs tokens when jwt.secret is not configured. It is public,
+// so Validate rejects it in production.
+const DefaultJWTSecret = "r-panel-default-secret-change-in-production"
+
 // Load reads the configuration file and environment variables
 func Load(configPath string) (*Config, error) {
 	// Read config file
@@ -125,6 +785,22 @@ func Load(configPath string) (*Config, error) {
 		cfg.Database.MySQL.Database = mysqlDB
 	}
 
+	if smtpPass := os.Getenv("RPANEL_SMTP_PASSWORD"); smtpPass != "" {
+		cfg.SMTP.Password = smtpPass
+	}
+
+	if dataDir := os.Getenv("RPANEL_DATA_DIR"); dataDir != "" {
+		cfg.DataDir = dataDir
+	}
+
+	// Ensure the data directory exists and provides the unset paths
+	if cfg.DataDir != "" {
+		if err := os.MkdirAll(cfg.DataDir, 0750); err != nil {
+			return nil, fmt.Errorf("failed to create data_dir: %w", err)
+		}
+		cfg.ApplyDataDir()
+	}
+
 	// Ensure data directory exists for SQLite
 	if cfg.Database.Type == "sqlite" {
 		dataDir := filepath.Dir(cfg.Database.SQLite.Path)
@@ -143,6 +819,99 @@ func Load(configPath string) (*Config, error) {
 		}
 	}
 
+	// Validate additional MySQL servers
+	serverNames := map[string]bool{PrimaryMySQLServer: true}
+	for i, server := range cfg.Database.MySQLServers {
+		if !mysqlServerNamePattern.MatchString(server.Name) {
+			return nil, fmt.Errorf("invalid database.mysql_servers[%d].name %q: use lowercase letters, digits, - and _", i, server.Name)
+		}
+		if serverNames[server.Name] {
+			return nil, fmt.Errorf("duplicate MySQL server name %q", server.Name)
+		}
+		serverNames[server.Name] = true
+		if server.Host == "" || server.Username == "" {
+			return nil, fmt.Errorf("MySQL server %q needs a host and username", server.Name)
+		}
+	}
+
+	// Validate bcrypt cost
+	if cfg.Security.BcryptCost == 0 {
+		cfg.Security.BcryptCost = DefaultBcryptCost
+	}
+	if cfg.Security.BcryptCost < MinBcryptCost || cfg.Security.BcryptCost > MaxBcryptCost {
+		return nil, fmt.Errorf("invalid security.bcrypt_cost: %d (use %d to %d)", cfg.Security.BcryptCost, MinBcryptCost, MaxBcryptCost)
+	}
+
+	// Validate password hash algorithm
+	switch cfg.Security.HashAlgorithm {
+	case "", "bcrypt", "argon2id":
+	default:
+		return nil, fmt.Errorf("unsupported security.hash_algorithm: %s (use bcrypt or argon2id)", cfg.Security.HashAlgorithm)
+	}
+
+	// Validate response write and request timeouts
+	if _, _, err := cfg.Server.WriteTimeouts(); err != nil {
+		return nil, err
+	}
+	if _, _, err := cfg.Server.RequestTimeouts(); err != nil {
+		return nil, err
+	}
+
+	// Validate audit retention
+	if cfg.Audit.RetentionDays < 0 {
+		return nil, fmt.Errorf("invalid audit.retention_days: must not be negative")
+	}
+
+	// Validate the token signing algorithm and, for RS256, its keys
+	algorithm, err := cfg.JWT.SigningAlgorithm()
+	if err != nil {
+		return nil, err
+	}
+	if algorithm == JWTAlgorithmRS256 {
+		if _, _, err := cfg.JWT.RSAKeys(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate the port check allowlist
+	if _, _, err := cfg.Tools.PortCheckAllowlist(); err != nil {
+		return nil, err
+	}
+
+	// Validate the metrics allowlist
+	if _, err := cfg.Metrics.AllowedNetworks(); err != nil {
+		return nil, err
+	}
+
+	// Validate notification, webhook and session cleanup intervals
+	if _, err := cfg.Notifications.CheckIntervalDuration(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.Webhooks.ServiceCheckIntervalDuration(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.Security.SessionCleanupIntervalDuration(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.PHPFPM.CacheTTLDuration(); err != nil {
+		return nil, err
+	}
+
+	// Validate backup compression level
+	if _, err := cfg.Backup.Compression(); err != nil {
+		return nil, err
+	}
+
+	if _, err := cfg.Clients.LinuxUsername.WithDefaults(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.Clients.CustomerNo.WithDefaults(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.Paths.HomeBaseDir(); err != nil {
+		return nil, err
+	}
+
 	// Ensure backups directory exists
 	if err := os.MkdirAll(cfg.Paths.Backups, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create backups directory: %w", err)
@@ -150,11 +919,7 @@ func Load(configPath string) (*Config, error) {
 
 	// Ensure TLS cache directory exists if TLS is enabled
 	if cfg.Server.TLS.Enabled {
-		cacheDir := cfg.Server.TLS.CacheDir
-		if cacheDir == "" {
-			cacheDir = "./data/certs"
-		}
-		if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		if err := os.MkdirAll(cfg.Server.TLS.CertCacheDir(), 0755); err != nil {
 			return nil, fmt.Errorf("failed to create TLS cache directory: %w", err)
 		}
 	}
@@ -0,0 +1,305 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestConfig writes a minimal config file with extra appended and returns its path
+func writeTestConfig(t *testing.T, extra string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := fmt.Sprintf(`database:
+  type: sqlite
+  sqlite:
+    path: %s
+paths:
+  backups: %s
+%s`, filepath.Join(dir, "data", "r-panel.db"), filepath.Join(dir, "backups"), extra)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadBcryptCost(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, ""))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBcryptCost, cfg.Security.BcryptCost, "unset cost gets the default")
+
+	cfg, err = Load(writeTestConfig(t, "security:\n  bcrypt_cost: 12\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 12, cfg.Security.BcryptCost)
+
+	for _, cost := range []int{4, 9, 16, 31} {
+		_, err := Load(writeTestConfig(t, fmt.Sprintf("security:\n  bcrypt_cost: %d\n", cost)))
+		assert.ErrorContains(t, err, "invalid security.bcrypt_cost", "cost %d", cost)
+	}
+}
+
+func TestLoadMySQLServers(t *testing.T) {
+	load := func(servers string) (*Config, error) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.yaml")
+		content := fmt.Sprintf("paths:\n  backups: %s\ndatabase:\n  type: sqlite\n  sqlite:\n    path: %s\n  mysql_servers:\n%s",
+			filepath.Join(dir, "backups"), filepath.Join(dir, "r-panel.db"), servers)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return Load(path)
+	}
+
+	cfg, err := load("    - name: db2\n      host: 10.0.0.2\n      port: 3307\n      username: panel\n      client_host: 10.0.0.%\n")
+	require.NoError(t, err)
+	require.Len(t, cfg.Database.MySQLServers, 1)
+	server := cfg.Database.MySQLServers[0]
+	assert.Equal(t, "db2", server.Name)
+	assert.Equal(t, "10.0.0.2", server.Host)
+	assert.Equal(t, 3307, server.Port)
+	assert.Equal(t, "10.0.0.%", server.ClientHost)
+
+	_, err = load("    - name: primary\n      host: 10.0.0.2\n      username: panel\n")
+	assert.ErrorContains(t, err, `duplicate MySQL server name "primary"`)
+	_, err = load("    - name: DB 2\n      host: 10.0.0.2\n      username: panel\n")
+	assert.ErrorContains(t, err, "invalid database.mysql_servers[0].name")
+	_, err = load("    - name: db2\n      username: panel\n")
+	assert.ErrorContains(t, err, `MySQL server "db2" needs a host and username`)
+}
+
+func TestLoadBackupCompressionLevel(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, ""))
+	require.NoError(t, err)
+	level, err := cfg.Backup.Compression()
+	require.NoError(t, err)
+	assert.Equal(t, CompressionDefault, level, "unset level keeps the gzip default")
+
+	for value, want := range map[string]int{"1": 1, "9": 9, "0": 0, `"none"`: CompressionNone} {
+		cfg, err := Load(writeTestConfig(t, "backup:\n  compression_level: "+value+"\n"))
+		require.NoError(t, err, value)
+		level, err := cfg.Backup.Compression()
+		require.NoError(t, err)
+		assert.Equal(t, want, level, value)
+	}
+
+	for _, value := range []string{"10", "-1", "fast"} {
+		_, err := Load(writeTestConfig(t, "backup:\n  compression_level: "+value+"\n"))
+		assert.ErrorContains(t, err, "backup.compression_level", value)
+	}
+}
+
+func TestBackupFreeSpaceMargin(t *testing.T) {
+	assert.Equal(t, int64(1024<<20), BackupConfig{}.FreeSpaceMargin(), "unset keeps the default")
+	assert.Equal(t, int64(200<<20), BackupConfig{FreeSpaceMarginMB: 200}.FreeSpaceMargin())
+	assert.Zero(t, BackupConfig{FreeSpaceMarginMB: -1}.FreeSpaceMargin())
+}
+
+func TestLoadLinuxUsernamePolicy(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, "clients:\n  linux_username:\n    prefix: web_\n    min_length: 5\n"))
+	require.NoError(t, err)
+	policy, err := cfg.Clients.LinuxUsername.WithDefaults()
+	require.NoError(t, err)
+	assert.Equal(t, LinuxUsernameConfig{Prefix: "web_", MinLength: 5, MaxLength: 32}, policy)
+
+	for setting, message := range map[string]string{
+		"prefix: 1x":     "clients.linux_username.prefix",
+		"max_length: 33": "clients.linux_username.max_length",
+		"min_length: 40": "clients.linux_username.min_length",
+	} {
+		_, err := Load(writeTestConfig(t, "clients:\n  linux_username:\n    "+setting+"\n"))
+		assert.ErrorContains(t, err, message, setting)
+	}
+}
+
+func TestLoadCustomerNoFormat(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, ""))
+	require.NoError(t, err)
+	format, err := cfg.Clients.CustomerNo.WithDefaults()
+	require.NoError(t, err)
+	assert.Equal(t, "C42", format.Format(42))
+
+	cfg, err = Load(writeTestConfig(t, "clients:\n  customer_no:\n    prefix: KD\n    digits: 5\n"))
+	require.NoError(t, err)
+	format, err = cfg.Clients.CustomerNo.WithDefaults()
+	require.NoError(t, err)
+	assert.Equal(t, "KD00042", format.Format(42))
+	assert.Equal(t, "KD1234567", format.Format(1234567))
+
+	for setting, message := range map[string]string{
+		"prefix: 1C": "clients.customer_no.prefix",
+		"prefix: C-": "clients.customer_no.prefix",
+		"digits: -1": "clients.customer_no.digits",
+		"digits: 21": "clients.customer_no.digits",
+	} {
+		_, err := Load(writeTestConfig(t, "clients:\n  customer_no:\n    "+setting+"\n"))
+		assert.ErrorContains(t, err, message, setting)
+	}
+}
+
+func TestHomeBaseDir(t *testing.T) {
+	for homeBase, want := range map[string]string{
+		"":                    "/home",
+		"/var/www":            "/var/www",
+		"/srv/clients/":       "/srv/clients",
+		"/home/../home/hosts": "/home/hosts",
+	} {
+		base, err := PathsConfig{HomeBase: homeBase}.HomeBaseDir()
+		require.NoError(t, err, homeBase)
+		assert.Equal(t, want, base)
+	}
+
+	for _, homeBase := range []string{"home", "/etc", "/", "/home/../etc", "/homes"} {
+		_, err := PathsConfig{HomeBase: homeBase}.HomeBaseDir()
+		assert.ErrorContains(t, err, "paths.home_base", homeBase)
+	}
+}
+
+func TestLoadPortCheckAllowlist(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, "tools:\n  portcheck_allowed_hosts: [\"Panel.Example.com.\", \"127.0.0.1\", \"10.0.0.0/8\", \"2001:db8::1\"]\n"))
+	require.NoError(t, err)
+	hosts, networks, err := cfg.Tools.PortCheckAllowlist()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"panel.example.com"}, hosts)
+	require.Len(t, networks, 3)
+	assert.Equal(t, "127.0.0.1/32", networks[0].String())
+	assert.Equal(t, "10.0.0.0/8", networks[1].String())
+	assert.Equal(t, "2001:db8::1/128", networks[2].String())
+
+	_, err = Load(writeTestConfig(t, "tools:\n  portcheck_allowed_hosts: [\"10.0.0.0/33\"]\n"))
+	assert.Error(t, err)
+}
+
+func TestLoadRequestTimeouts(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, ""))
+	require.NoError(t, err)
+	regular, long, err := cfg.Server.RequestTimeouts()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRequestTimeout, regular)
+	assert.Equal(t, time.Duration(0), long)
+
+	cfg, err = Load(writeTestConfig(t, "server:\n  write_timeout: \"1m\"\n  request_timeout: \"45s\"\n"))
+	require.NoError(t, err)
+	regular, _, err = cfg.Server.RequestTimeouts()
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, regular)
+
+	// The 504 must be written before the write deadline
+	_, err = Load(writeTestConfig(t, "server:\n  write_timeout: \"15s\"\n  request_timeout: \"15s\"\n"))
+	assert.Error(t, err)
+
+	// Unset request timeouts that would not fit are derived from the write timeouts
+	cfg, err = Load(writeTestConfig(t, "server:\n  long_write_timeout: \"15m\"\n"))
+	require.NoError(t, err)
+	regular, long, err = cfg.Server.RequestTimeouts()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRequestTimeout, regular)
+	assert.Equal(t, 10*time.Minute, long)
+
+	cfg, err = Load(writeTestConfig(t, "server:\n  write_timeout: \"6s\"\n"))
+	require.NoError(t, err)
+	regular, _, err = cfg.Server.RequestTimeouts()
+	require.NoError(t, err)
+	assert.Equal(t, 4*time.Second, regular)
+}
+
+func TestLoadMetricsAllowlist(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, "metrics:\n  allowed_ips: [\"127.0.0.1\", \"10.0.0.0/8\"]\n"))
+	require.NoError(t, err)
+	networks, err := cfg.Metrics.AllowedNetworks()
+	require.NoError(t, err)
+	require.Len(t, networks, 2)
+	assert.Equal(t, "127.0.0.1/32", networks[0].String())
+	assert.Equal(t, "10.0.0.0/8", networks[1].String())
+
+	_, err = Load(writeTestConfig(t, "metrics:\n  allowed_ips: [\"monitoring.example.com\"]\n"))
+	assert.Error(t, err)
+}
+
+func TestLoadPHPFPMCacheTTL(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, ""))
+	require.NoError(t, err)
+	ttl, err := cfg.PHPFPM.CacheTTLDuration()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPHPFPMCacheTTL, ttl)
+
+	cfg, err = Load(writeTestConfig(t, "php_fpm:\n  cache_ttl: \"0\"\n"))
+	require.NoError(t, err)
+	ttl, err = cfg.PHPFPM.CacheTTLDuration()
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), ttl, "0 turns the cache off")
+
+	for _, value := range []string{"-1s", "soon"} {
+		_, err = Load(writeTestConfig(t, fmt.Sprintf("php_fpm:\n  cache_ttl: %q\n", value)))
+		assert.ErrorContains(t, err, "invalid php_fpm.cache_ttl", value)
+	}
+}
+
+func TestLoadJWTAlgorithm(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, ""))
+	require.NoError(t, err)
+	algorithm, err := cfg.JWT.SigningAlgorithm()
+	require.NoError(t, err)
+	assert.Equal(t, JWTAlgorithmHS256, algorithm)
+
+	_, err = Load(writeTestConfig(t, "jwt:\n  algorithm: ES256\n"))
+	assert.ErrorContains(t, err, "unsupported jwt.algorithm")
+	_, err = Load(writeTestConfig(t, "jwt:\n  algorithm: RS256\n"))
+	assert.ErrorContains(t, err, "private_key_file is required")
+
+	dir := t.TempDir()
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, "jwt.key")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(small)}), 0600))
+	_, err = Load(writeTestConfig(t, fmt.Sprintf("jwt:\n  algorithm: RS256\n  private_key_file: %s\n", keyPath)))
+	assert.ErrorContains(t, err, "at least 2048 bits")
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	cfg, err = Load(writeTestConfig(t, fmt.Sprintf("jwt:\n  algorithm: rs256\n  private_key_file: %s\n  previous_public_key_files: [%s]\n", keyPath, filepath.Join(dir, "missing.pub"))))
+	assert.ErrorContains(t, err, "previous_public_key_files")
+
+	cfg, err = Load(writeTestConfig(t, fmt.Sprintf("jwt:\n  algorithm: rs256\n  private_key_file: %s\n", keyPath)))
+	require.NoError(t, err)
+	private, previous, err := cfg.JWT.RSAKeys()
+	require.NoError(t, err)
+	assert.True(t, key.Equal(private))
+	assert.Empty(t, previous)
+}
+
+func TestLoadDataDir(t *testing.T) {
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	path := filepath.Join(dir, "config.yaml")
+	content := fmt.Sprintf("data_dir: %s\ndatabase:\n  type: sqlite\nserver:\n  tls:\n    enabled: true\n", dataDir)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dataDir, "rpanel.db"), cfg.Database.SQLite.Path)
+	assert.Equal(t, filepath.Join(dataDir, "backups"), cfg.Paths.Backups)
+	assert.Equal(t, filepath.Join(dataDir, "certs"), cfg.Server.TLS.CertCacheDir())
+	for _, created := range []string{dataDir, cfg.Paths.Backups, cfg.Server.TLS.CacheDir} {
+		assert.DirExists(t, created)
+	}
+
+	// Paths set explicitly win over data_dir
+	cfg, err = Load(writeTestConfig(t, fmt.Sprintf("data_dir: %s\n", dataDir)))
+	require.NoError(t, err)
+	assert.NotEqual(t, filepath.Join(dataDir, "rpanel.db"), cfg.Database.SQLite.Path)
+	assert.NotEqual(t, filepath.Join(dataDir, "backups"), cfg.Paths.Backups)
+
+	moved := filepath.Join(dir, "moved")
+	t.Setenv("RPANEL_DATA_DIR", moved)
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, moved, cfg.DataDir, "RPANEL_DATA_DIR overrides data_dir")
+	assert.Equal(t, filepath.Join(moved, "rpanel.db"), cfg.Database.SQLite.Path)
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// minProductionJWTSecretLen is the shortest jwt.secret accepted in production
+const minProductionJWTSecretLen = 32
+
+// ValidationError lists every problem Validate found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration:\n  - %s", strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate checks cfg for problems that would otherwise only surface the first
+// time a setting is used: missing or inaccessible paths, incomplete TLS and
+// database settings and an insecure JWT secret in production. It returns a
+// *ValidationError listing all of them, or nil.
+func Validate(cfg *Config) error {
+	v := &validator{}
+
+	switch cfg.Environment {
+	case "", "local", "production":
+	default:
+		v.problem("environment: unknown value %q (use local or production)", cfg.Environment)
+	}
+
+	v.validateServer(cfg)
+	v.validateJWT(cfg)
+	v.validateDatabase(cfg)
+	v.validatePaths(cfg)
+
+	if cfg.SMTP.Host != "" {
+		if _, err := mail.ParseAddress(cfg.SMTP.From); err != nil {
+			v.problem("smtp.from: %q is not a valid address", cfg.SMTP.From)
+		}
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) problem(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) validateServer(cfg *Config) {
+	if cfg.Server.Port <= 0 || cfg.Server.Port > 65535 {
+		v.problem("server.port: %d is not a valid port", cfg.Server.Port)
+	}
+	switch cfg.Server.Mode {
+	case "", "debug", "release", "test":
+	default:
+		v.problem("server.mode: unknown value %q (use debug or release)", cfg.Server.Mode)
+	}
+
+	tls := cfg.Server.TLS
+	if !tls.Enabled {
+		return
+	}
+	if tls.Domain == "" {
+		v.problem("server.tls.domain: required when TLS is enabled")
+	}
+	if tls.Email == "" {
+		v.problem("server.tls.email: required when TLS is enabled, Let's Encrypt sends expiry notices there")
+	} else if _, err := mail.ParseAddress(tls.Email); err != nil {
+		v.problem("server.tls.email: %q is not a valid address", tls.Email)
+	}
+	v.writableDir("server.tls.cache_dir", tls.CertCacheDir())
+}
+
+func (v *validator) validateJWT(cfg *Config) {
+	if cfg.JWT.ExpiresIn != "" {
+		if d, err := time.ParseDuration(cfg.JWT.ExpiresIn); err != nil || d <= 0 {
+			v.problem("jwt.expires_in: %q is not a positive duration", cfg.JWT.ExpiresIn)
+		}
+	}
+
+	// The secret is not used with RS256, Load has checked the key files
+	if algorithm, _ := cfg.JWT.SigningAlgorithm(); cfg.Environment != "production" || algorithm == JWTAlgorithmRS256 {
+		return
+	}
+	switch secret := cfg.JWT.Secret; {
+	case secret == "":
+		v.problem("jwt.secret: required in production (or set RPANEL_JWT_SECRET)")
+	case secret == DefaultJWTSecret:
+		v.problem("jwt.secret: the default secret is public, generate a new one for production")
+	case len(secret) < minProductionJWTSecretLen:
+		v.problem("jwt.secret: must be at least %d characters in production", minProductionJWTSecretLen)
+	}
+}
+
+func (v *validator) validateDatabase(cfg *Config) {
+	switch cfg.Database.Type {
+	case "sqlite":
+		if cfg.Database.SQLite.Path == "" {
+			v.problem("database.sqlite.path: required for the sqlite database")
+		} else {
+			v.writableDir("database.sqlite.path", filepath.Dir(cfg.Database.SQLite.Path))
+		}
+	case "mysql":
+		mysql := cfg.Database.MySQL
+		if mysql.Host == "" {
+			v.problem("database.mysql.host: required for the mysql database")
+		}
+		if mysql.Port <= 0 || mysql.Port > 65535 {
+			v.problem("database.mysql.port: %d is not a valid port", mysql.Port)
+		}
+		if mysql.Username == "" {
+			v.problem("database.mysql.username: required for the mysql database")
+		}
+		if mysql.Database == "" {
+			v.problem("database.mysql.database: required for the mysql database")
+		}
+	case "":
+		v.problem("database.type: required (sqlite or mysql)")
+	default:
+		v.problem("database.type: unknown value %q (use sqlite or mysql)", cfg.Database.Type)
+	}
+}
+
+func (v *validator) validatePaths(cfg *Config) {
+	paths := cfg.Paths
+
+	if paths.Backups == "" {
+		v.problem("paths.backups: required")
+	} else {
+		v.writableDir("paths.backups", paths.Backups)
+	}
+
+	if paths.NginxSitesAvailable == "" {
+		v.problem("paths.nginx_sites_available: required")
+	} else {
+		v.writableDir("paths.nginx_sites_available", paths.NginxSitesAvailable)
+	}
+	if paths.NginxSitesEnabled == "" {
+		v.problem("paths.nginx_sites_enabled: required")
+	} else {
+		v.writableDir("paths.nginx_sites_enabled", paths.NginxSitesEnabled)
+	}
+	if paths.NginxLogs != "" {
+		v.readableDir("paths.nginx_logs", paths.NginxLogs)
+	}
+	if paths.Logs != "" {
+		v.readableDir("paths.logs", paths.Logs)
+	}
+	if paths.MailStorage != "" {
+		v.readableDir("paths.mail_storage", paths.MailStorage)
+	}
+	if paths.SSLCertificates != "" {
+		v.readableDir("paths.ssl_certificates", paths.SSLCertificates)
+	}
+
+	// php_fpm_pools may be a pattern covering every installed PHP version
+	if paths.PHPFPM != "" {
+		matches, err := filepath.Glob(paths.PHPFPM)
+		switch {
+		case err != nil:
+			v.problem("paths.php_fpm_pools: invalid pattern %q", paths.PHPFPM)
+		case len(matches) == 0:
+			v.problem("paths.php_fpm_pools: %s does not exist", paths.PHPFPM)
+		default:
+			for _, match := range matches {
+				v.readableDir("paths.php_fpm_pools", match)
+			}
+		}
+	}
+}
+
+// readableDir records a problem unless path is a directory that can be listed
+func (v *validator) readableDir(key, path string) bool {
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		v.problem("%s: %s does not exist", key, path)
+		return false
+	case err != nil:
+		v.problem("%s: %v", key, err)
+		return false
+	case !info.IsDir():
+		v.problem("%s: %s is not a directory", key, path)
+		return false
+	}
+
+	dir, err := os.Open(path)
+	if err == nil {
+		_, err = dir.Readdirnames(1)
+		dir.Close()
+	}
+	if err != nil && err != io.EOF {
+		v.problem("%s: %s is not readable: %v", key, path, err)
+		return false
+	}
+	return true
+}
+
+// writableDir records a problem unless path is a directory files can be created in
+func (v *validator) writableDir(key, path string) {
+	if !v.readableDir(key, path) {
+		return
+	}
+	probe, err := os.CreateTemp(path, ".rpanel-check-*")
+	if err != nil {
+		v.problem("%s: %s is not writable", key, path)
+		return
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validTestConfig(t *testing.T) *Config {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range []string{"backups", "sites-available", "sites-enabled", "logs", "php/8.2/fpm/pool.d", "data"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+
+	cfg := &Config{Environment: "production"}
+	cfg.Server.Port = 8080
+	cfg.Server.Mode = "release"
+	cfg.JWT.Secret = "a-long-random-secret-for-production-use"
+	cfg.JWT.ExpiresIn = "24h"
+	cfg.Database.Type = "sqlite"
+	cfg.Database.SQLite.Path = filepath.Join(root, "data", "r-panel.db")
+	cfg.Paths.Backups = filepath.Join(root, "backups")
+	cfg.Paths.NginxSitesAvailable = filepath.Join(root, "sites-available")
+	cfg.Paths.NginxSitesEnabled = filepath.Join(root, "sites-enabled")
+	cfg.Paths.NginxLogs = filepath.Join(root, "logs")
+	cfg.Paths.PHPFPM = filepath.Join(root, "php", "*", "fpm", "pool.d")
+	return cfg
+}
+
+func problems(t *testing.T, err error) []string {
+	t.Helper()
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "expected a *ValidationError, got %v", err)
+	return validationErr.Problems
+}
+
+func TestValidateAcceptsCompleteConfig(t *testing.T) {
+	assert.NoError(t, Validate(validTestConfig(t)))
+}
+
+func TestValidateCollectsEveryProblem(t *testing.T) {
+	cfg := validTestConfig(t)
+	cfg.JWT.Secret = DefaultJWTSecret
+	cfg.Server.TLS.Enabled = true
+	cfg.Server.TLS.Domain = "panel.example.com"
+	cfg.Server.TLS.CacheDir = filepath.Join(t.TempDir(), "certs")
+	cfg.Paths.Backups = filepath.Join(t.TempDir(), "missing")
+	cfg.Paths.NginxSitesEnabled = ""
+	cfg.Paths.PHPFPM = filepath.Join(t.TempDir(), "*", "pool.d")
+
+	assert.Equal(t, []string{
+		"server.tls.email: required when TLS is enabled, Let's Encrypt sends expiry notices there",
+		"server.tls.cache_dir: " + cfg.Server.TLS.CacheDir + " does not exist",
+		"jwt.secret: the default secret is public, generate a new one for production",
+		"paths.backups: " + cfg.Paths.Backups + " does not exist",
+		"paths.nginx_sites_enabled: required",
+		"paths.php_fpm_pools: " + cfg.Paths.PHPFPM + " does not exist",
+	}, problems(t, Validate(cfg)))
+}
+
+func TestValidateJWTSecretOnlyInProduction(t *testing.T) {
+	cfg := validTestConfig(t)
+	cfg.JWT.Secret = DefaultJWTSecret
+	cfg.Environment = "local"
+	assert.NoError(t, Validate(cfg))
+
+	cfg.Environment = "production"
+	cfg.JWT.Secret = "short"
+	assert.Equal(t, []string{"jwt.secret: must be at least 32 characters in production"}, problems(t, Validate(cfg)))
+
+	// RS256 signs with a key file, the secret is not used
+	cfg.JWT.Algorithm = JWTAlgorithmRS256
+	cfg.JWT.PrivateKeyFile = "/etc/r-panel/jwt.key"
+	assert.NoError(t, Validate(cfg))
+}
+
+func TestValidateDatabaseSettings(t *testing.T) {
+	cfg := validTestConfig(t)
+	cfg.Database.Type = "mysql"
+	cfg.Database.MySQL.Port = 3306
+	assert.Equal(t, []string{
+		"database.mysql.host: required for the mysql database",
+		"database.mysql.username: required for the mysql database",
+		"database.mysql.database: required for the mysql database",
+	}, problems(t, Validate(cfg)))
+
+	cfg.Database.Type = "postgres"
+	assert.Equal(t, []string{`database.type: unknown value "postgres" (use sqlite or mysql)`}, problems(t, Validate(cfg)))
+}
+
+func TestValidateRejectsFileWhereDirectoryExpected(t *testing.T) {
+	cfg := validTestConfig(t)
+	file := filepath.Join(t.TempDir(), "backups")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	cfg.Paths.Backups = file
+
+	assert.Equal(t, []string{"paths.backups: " + file + " is not a directory"}, problems(t, Validate(cfg)))
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// APIKey lets scripts act as its owner without a login session, limited to its
+// scopes. Only a hash of the key is stored, the key itself is shown once.
+type APIKey struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	UserID     uint        `json:"user_id" gorm:"not null;index"`
+	Name       string      `json:"name" gorm:"type:varchar(255)"`
+	Prefix     string      `json:"prefix" gorm:"type:varchar(20);not null"`        // start of the key, to tell keys apart
+	KeyHash    string      `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"` // hex SHA-256 of the key
+	Scopes     StringArray `json:"scopes" gorm:"type:json"`                        // read, write, admin
+	ExpiresAt  *time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time  `json:"last_used_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	User       User        `json:"-" gorm:"foreignKey:UserID"`
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Client represents a client (user with role "user" and additional information)
@@ -65,8 +67,9 @@ type Client struct {
 	// Relations
 	ClientLimits ClientLimits `json:"client_limits,omitempty" gorm:"foreignKey:ClientID"`
 
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
 // ClientLimits represents resource limits for a client
@@ -99,6 +102,9 @@ type ClientLimits struct {
 	LimitWebdavUser     int         `json:"limit_webdav_user" gorm:"default:0"`
 	LimitBackup         bool        `json:"limit_backup" gorm:"default:false"`
 	LimitDirectiveSnippets bool     `json:"limit_directive_snippets" gorm:"default:false"`
+	LimitWebRate        int         `json:"limit_web_rate" gorm:"default:0"`        // KB/s per connection, 0 = unlimited
+	LimitWebRateAfter   int         `json:"limit_web_rate_after" gorm:"default:0"`  // KB sent at full speed before limit_web_rate applies
+	LimitWebConnections int         `json:"limit_web_connections" gorm:"default:0"` // concurrent connections per visitor IP, 0 = unlimited
 
 	// Email Limits
 	MailServers            StringArray `json:"mail_servers" gorm:"type:json"`
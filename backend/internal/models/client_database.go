@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ClientDatabase records a MySQL database and its dedicated user provisioned for a client
+type ClientDatabase struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ClientID  uint      `json:"client_id" gorm:"not null;index"`
+	Name      string    `json:"name" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Username  string    `json:"username" gorm:"type:varchar(32);not null"`
+	Host      string    `json:"host" gorm:"type:varchar(255);not null"`
+	Server    string    `json:"server" gorm:"type:varchar(64);not null;default:'primary'"` // MySQL server name, see config.PrimaryMySQLServer
+	CreatedAt time.Time `json:"created_at"`
+}
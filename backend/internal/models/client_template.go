@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// ClientTemplate is a named plan of client limits. A client's master template
+// and then its additional templates are applied in order, each setting only
+// the limits it lists.
+type ClientTemplate struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Description string         `json:"description" gorm:"type:varchar(255)"`
+	Limits      TemplateLimits `json:"limits" gorm:"type:json"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// TemplateLimits maps ClientLimits fields, by their JSON name, to the value a
+// template gives them
+type TemplateLimits map[string]json.RawMessage
+
+// Value implements the driver.Valuer interface
+func (l TemplateLimits) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return "{}", nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements the sql.Scanner interface
+func (l *TemplateLimits) Scan(value interface{}) error {
+	if value == nil {
+		*l = TemplateLimits{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+
+	return json.Unmarshal(bytes, l)
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Counter is a named sequence, such as the one customer numbers are drawn from
+type Counter struct {
+	Name      string    `json:"name" gorm:"primaryKey;type:varchar(50)"`
+	Value     uint64    `json:"value" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
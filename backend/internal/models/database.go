@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"r-panel/internal/config"
+	"strings"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/sqlite"
@@ -55,7 +56,13 @@ func InitDB(cfg *config.Config) error {
 		)
 		dialector = mysql.Open(dsn)
 	case "sqlite":
-		dialector = sqlite.Open(cfg.Database.SQLite.Path)
+		dsn := cfg.Database.SQLite.Path
+		if !strings.Contains(dsn, "?") {
+			// Wait for the write lock instead of failing with "database is locked",
+			// and take it when a transaction begins so concurrent ones queue up
+			dsn += "?_busy_timeout=5000&_txlock=immediate"
+		}
+		dialector = sqlite.Open(dsn)
 	default:
 		return fmt.Errorf("unsupported database type: %s", cfg.Database.Type)
 	}
@@ -73,7 +80,7 @@ func InitDB(cfg *config.Config) error {
 	}
 
 	// Auto migrate models
-	if err := DB.AutoMigrate(&User{}, &Session{}, &AuditLog{}, &Client{}, &ClientLimits{}); err != nil {
+	if err := DB.AutoMigrate(&User{}, &Session{}, &AuditLog{}, &Client{}, &ClientLimits{}, &ClientDatabase{}, &JWTKey{}, &Webhook{}, &WebhookDeadLetter{}, &Setting{}, &NginxSnippet{}, &NginxSiteSnippet{}, &APIKey{}, &ClientTemplate{}, &Counter{}); err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
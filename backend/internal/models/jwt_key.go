@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// JWTKey is a signing secret for panel tokens. The active key signs new tokens;
+// retired keys keep verifying tokens issued before a rotation until they expire.
+type JWTKey struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	KID       string     `json:"kid" gorm:"column:kid;type:varchar(64);uniqueIndex;not null"`
+	Secret    string     `json:"-" gorm:"type:varchar(255);not null"`
+	Active    bool       `json:"active" gorm:"default:false;index"`
+	RetiredAt *time.Time `json:"retired_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// NginxSnippet is a reusable fragment of server-block directives that can be attached to sites
+type NginxSnippet struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Description string    `json:"description" gorm:"type:varchar(255)"`
+	Content     string    `json:"content" gorm:"type:text;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// NginxSiteSnippet attaches a snippet to the site config named after Domain
+type NginxSiteSnippet struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	Domain    string       `json:"domain" gorm:"type:varchar(253);not null;uniqueIndex:idx_site_snippet"`
+	SnippetID uint         `json:"snippet_id" gorm:"not null;uniqueIndex:idx_site_snippet;index"`
+	Snippet   NginxSnippet `json:"snippet,omitempty" gorm:"foreignKey:SnippetID"`
+	CreatedAt time.Time    `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Setting is a panel-wide key/value setting, such as those chosen in the setup wizard
+type Setting struct {
+	Key       string    `json:"key" gorm:"primaryKey;type:varchar(100)"`
+	Value     string    `json:"value" gorm:"type:text"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -4,13 +4,37 @@ import (
 	"time"
 )
 
+// User roles. Admins manage everything, users manage hosting resources and
+// readonly users can look at everything they are allowed to see but change nothing.
+const (
+	RoleAdmin    = "admin"
+	RoleUser     = "user"
+	RoleReadonly = "readonly"
+)
+
+// ValidRole reports whether role is one of the known user roles
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleUser, RoleReadonly:
+		return true
+	}
+	return false
+}
+
 type User struct {
-	ID           uint      `json:"id" gorm:"primaryKey"`
-	Username     string    `json:"username" gorm:"type:varchar(255);uniqueIndex;not null"`
-	PasswordHash string    `json:"-" gorm:"type:varchar(255);not null"`
-	Role         string    `json:"role" gorm:"type:varchar(50);default:'user'"` // admin, user, readonly
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	Username     string `json:"username" gorm:"type:varchar(255);uniqueIndex;not null"`
+	PasswordHash string `json:"-" gorm:"type:varchar(255);not null"`
+	Role         string `json:"role" gorm:"type:varchar(50);default:'user'"` // admin, user, readonly
+
+	// MustChangePassword blocks login until the user replaces the password, set for the config default user
+	MustChangePassword bool `json:"must_change_password" gorm:"not null;default:false"`
+
+	// Disabled refuses login and every token of the user, set while the user's client is in the trash
+	Disabled bool `json:"disabled" gorm:"not null;default:false"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Session struct {
@@ -20,17 +44,22 @@ type Session struct {
 	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
 	CreatedAt time.Time `json:"created_at"`
 	User      User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+
+	// Set on impersonation sessions: the admin acting as the user and the admin session to return to
+	ImpersonatedBy        *uint `json:"impersonated_by,omitempty" gorm:"index"`
+	ImpersonatorSessionID *uint `json:"-" gorm:"index"`
 }
 
 type AuditLog struct {
-	ID         uint      `json:"id" gorm:"primaryKey"`
-	UserID     uint      `json:"user_id" gorm:"index"`
-	Action     string    `json:"action" gorm:"type:varchar(50);not null"` // login, logout, create, update, delete
-	Resource   string    `json:"resource" gorm:"type:varchar(100)"`       // phpfpm, nginx, mysql, etc.
-	ResourceID string    `json:"resource_id" gorm:"type:varchar(255)"`
-	Details    string    `json:"details" gorm:"type:text"` // JSON or text details
-	IPAddress  string    `json:"ip_address" gorm:"type:varchar(45)"`
-	UserAgent  string    `json:"user_agent" gorm:"type:varchar(500)"`
-	CreatedAt  time.Time `json:"created_at" gorm:"index"`
-	User       User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserID         uint      `json:"user_id" gorm:"index"`
+	Action         string    `json:"action" gorm:"type:varchar(50);not null"` // login, logout, create, update, delete
+	Resource       string    `json:"resource" gorm:"type:varchar(100)"`       // phpfpm, nginx, mysql, etc.
+	ResourceID     string    `json:"resource_id" gorm:"type:varchar(255)"`
+	Details        string    `json:"details" gorm:"type:text"` // JSON or text details
+	IPAddress      string    `json:"ip_address" gorm:"type:varchar(45)"`
+	UserAgent      string    `json:"user_agent" gorm:"type:varchar(500)"`
+	ImpersonatedBy *uint     `json:"impersonated_by,omitempty" gorm:"index"` // admin acting as the user, if any
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+	User           User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Webhook is an outbound endpoint that receives a signed JSON payload when a subscribed panel event fires
+type Webhook struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	Name      string      `json:"name" gorm:"type:varchar(255)"`
+	URL       string      `json:"url" gorm:"type:varchar(2048);not null"`
+	Secret    string      `json:"-" gorm:"type:varchar(255);not null"` // HMAC-SHA256 key for the signature header
+	Events    StringArray `json:"events" gorm:"type:json"`             // e.g. ["client.created", "backup.completed"]
+	Active    bool        `json:"active" gorm:"not null"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// WebhookDeadLetter records a delivery that still failed after every retry
+type WebhookDeadLetter struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WebhookID  uint      `json:"webhook_id" gorm:"not null;index"`
+	DeliveryID string    `json:"delivery_id" gorm:"type:varchar(64);not null"`
+	Event      string    `json:"event" gorm:"type:varchar(100);not null"`
+	Payload    string    `json:"payload" gorm:"type:text"`
+	Attempts   int       `json:"attempts"`
+	LastError  string    `json:"last_error" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"r-panel/internal/models"
+)
+
+var (
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+	ErrInvalidAPIKey      = errors.New("invalid or expired API key")
+	ErrInvalidAPIKeyScope = errors.New("invalid API key scope: use read, write or admin")
+)
+
+// APIKeyPrefix starts every API key, so a key is recognized in a bearer token
+const APIKeyPrefix = "rpk_"
+
+// API key scopes, matching the permissions of the roles
+const (
+	APIKeyScopeRead  = "read"
+	APIKeyScopeWrite = "write"
+	APIKeyScopeAdmin = "admin"
+)
+
+// apiKeyLastUsedInterval limits how often using a key writes its last_used_at
+const apiKeyLastUsedInterval = time.Minute
+
+type APIKeyService struct{}
+
+func NewAPIKeyService() *APIKeyService {
+	return &APIKeyService{}
+}
+
+// IsAPIKey reports whether token looks like an API key rather than a JWT
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey creates a key for user with scopes, read only when none are
+// given. It returns the key itself, which cannot be retrieved later.
+func (s *APIKeyService) CreateAPIKey(user *models.User, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	if len(scopes) == 0 {
+		scopes = []string{APIKeyScopeRead}
+	}
+	seen := map[string]bool{}
+	var unique []string
+	for _, scope := range scopes {
+		switch scope {
+		case APIKeyScopeRead, APIKeyScopeWrite, APIKeyScopeAdmin:
+		default:
+			return nil, "", fmt.Errorf("%w: %q", ErrInvalidAPIKeyScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKey)
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := APIKeyPrefix + hex.EncodeToString(raw)
+
+	apiKey := &models.APIKey{
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    key[:len(APIKeyPrefix)+8],
+		KeyHash:   hashAPIKey(key),
+		Scopes:    unique,
+		ExpiresAt: expiresAt,
+	}
+	if err := models.DB.Create(apiKey).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to create API key: %w", err)
+	}
+	return apiKey, key, nil
+}
+
+// GetAPIKeys returns the keys of userID, or of every user when userID is 0
+func (s *APIKeyService) GetAPIKeys(userID uint) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	query := models.DB.Order("created_at DESC")
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey deletes key id. Users may revoke their own keys, admins any key.
+func (s *APIKeyService) RevokeAPIKey(id uint, user *models.User) error {
+	query := models.DB.Where("id = ?", id)
+	if user.Role != models.RoleAdmin {
+		query = query.Where("user_id = ?", user.ID)
+	}
+	result := query.Delete(&models.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate returns the unexpired key matching key, with its owner loaded
+func (s *APIKeyService) Authenticate(key string) (*models.APIKey, error) {
+	if !IsAPIKey(key) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var apiKey models.APIKey
+	if err := models.DB.Where("key_hash = ?", hashAPIKey(key)).Preload("User").Limit(1).Find(&apiKey).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if apiKey.ID == 0 || apiKey.User.ID == 0 || (apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyLastUsedInterval {
+		models.DB.Model(&models.APIKey{}).Where("id = ?", apiKey.ID).Update("last_used_at", now)
+		apiKey.LastUsedAt = &now
+	}
+	return &apiKey, nil
+}
+
+// HasScope reports whether apiKey was granted scope
+func HasScope(apiKey *models.APIKey, scope string) bool {
+	for _, granted := range apiKey.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
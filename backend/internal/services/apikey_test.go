@@ -0,0 +1,78 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestUser(t *testing.T, username, role string) *models.User {
+	t.Helper()
+	user := &models.User{Username: username, PasswordHash: "x", Role: role}
+	require.NoError(t, models.DB.Create(user).Error)
+	return user
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	setupTestDB(t)
+	service := NewAPIKeyService()
+	owner := createTestUser(t, "owner", models.RoleUser)
+
+	apiKey, key, err := service.CreateAPIKey(owner, " deploy ", nil, nil)
+	require.NoError(t, err)
+	assert.True(t, IsAPIKey(key))
+	assert.True(t, strings.HasPrefix(key, apiKey.Prefix))
+	assert.Equal(t, "deploy", apiKey.Name)
+	assert.Equal(t, models.StringArray{APIKeyScopeRead}, apiKey.Scopes)
+	assert.NotContains(t, apiKey.KeyHash, key)
+
+	found, err := service.Authenticate(key)
+	require.NoError(t, err)
+	assert.Equal(t, apiKey.ID, found.ID)
+	assert.Equal(t, owner.ID, found.User.ID)
+	require.NotNil(t, found.LastUsedAt)
+
+	_, err = service.Authenticate(key + "0")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	// Only the owner or an admin may revoke it
+	other := createTestUser(t, "other", models.RoleUser)
+	assert.ErrorIs(t, service.RevokeAPIKey(apiKey.ID, other), ErrAPIKeyNotFound)
+	keys, err := service.GetAPIKeys(other.ID)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	admin := createTestUser(t, "admin", models.RoleAdmin)
+	require.NoError(t, service.RevokeAPIKey(apiKey.ID, admin))
+	_, err = service.Authenticate(key)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func TestAPIKeyScopesAndExpiry(t *testing.T) {
+	setupTestDB(t)
+	service := NewAPIKeyService()
+	owner := createTestUser(t, "owner", models.RoleUser)
+
+	_, _, err := service.CreateAPIKey(owner, "bad", []string{"root"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidAPIKeyScope)
+
+	past := time.Now().Add(-time.Minute)
+	_, _, err = service.CreateAPIKey(owner, "old", nil, &past)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	apiKey, key, err := service.CreateAPIKey(owner, "ci", []string{"write", "read", "write"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.StringArray{APIKeyScopeWrite, APIKeyScopeRead}, apiKey.Scopes)
+	assert.True(t, HasScope(apiKey, APIKeyScopeWrite))
+	assert.False(t, HasScope(apiKey, APIKeyScopeAdmin))
+
+	// A key stops working once it expires
+	require.NoError(t, models.DB.Model(apiKey).Update("expires_at", past).Error)
+	_, err = service.Authenticate(key)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"r-panel/internal/models"
+)
+
+// defaultAuditPruneBatchSize bounds how many rows a single DELETE removes, so
+// pruning a large backlog never holds a long lock on audit_logs
+const defaultAuditPruneBatchSize = 1000
+
+// auditPruneInterval is how often the retention pruner runs
+const auditPruneInterval = 24 * time.Hour
+
+type AuditService struct {
+	batchSize int
+}
+
+func NewAuditService() *AuditService {
+	return &AuditService{batchSize: defaultAuditPruneBatchSize}
+}
+
+// PruneBefore deletes audit log entries created before cutoff in batches and
+// returns how many were removed
+func (s *AuditService) PruneBefore(cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		var ids []uint
+		if err := models.DB.Model(&models.AuditLog{}).
+			Where("created_at < ?", cutoff).
+			Order("id").
+			Limit(s.batchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		result := models.DB.Where("id IN ?", ids).Delete(&models.AuditLog{})
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+
+		if len(ids) < s.batchSize {
+			return total, nil
+		}
+	}
+}
+
+// RunRetention prunes entries older than retentionDays now and then once a day until ctx is cancelled
+func (s *AuditService) RunRetention(ctx context.Context, retentionDays int) {
+	ticker := time.NewTicker(auditPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		cutoff := time.Now().AddDate(0, 0, -retentionDays)
+		if deleted, err := s.PruneBefore(cutoff); err != nil {
+			log.Printf("Audit retention: pruning failed after removing %d entries: %v", deleted, err)
+		} else if deleted > 0 {
+			log.Printf("Audit retention: removed %d entries older than %d days", deleted, retentionDays)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
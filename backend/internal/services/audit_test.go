@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditServicePruneBefore(t *testing.T) {
+	setupTestDB(t)
+	cutoff := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 7; i++ {
+		require.NoError(t, models.DB.Create(&models.AuditLog{Action: "login", CreatedAt: cutoff.AddDate(0, 0, -i-1)}).Error)
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, models.DB.Create(&models.AuditLog{Action: "logout", CreatedAt: cutoff.Add(time.Duration(i) * time.Hour)}).Error)
+	}
+
+	// Batches smaller than the backlog still remove everything before the cutoff
+	service := NewAuditService()
+	service.batchSize = 3
+
+	deleted, err := service.PruneBefore(cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), deleted)
+
+	var remaining []models.AuditLog
+	require.NoError(t, models.DB.Find(&remaining).Error)
+	require.Len(t, remaining, 3)
+	for _, entry := range remaining {
+		assert.False(t, entry.CreatedAt.Before(cutoff))
+	}
+
+	deleted, err = service.PruneBefore(cutoff)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
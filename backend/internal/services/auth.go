@@ -1,12 +1,14 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
 	"r-panel/internal/config"
 	"r-panel/internal/models"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -14,33 +16,73 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserNotFound       = errors.New("user not found")
 	ErrUserExists         = errors.New("user already exists")
+	ErrWeakPassword       = errors.New("password does not meet the requirements")
+	ErrAccountDisabled    = errors.New("account is disabled")
+
+	ErrPasswordChangeNotRequired = errors.New("password change is not required, change it from the account settings")
+
+	ErrImpersonationNotAllowed = errors.New("impersonation not allowed")
+	ErrNotImpersonating        = errors.New("session is not impersonating a user")
 )
 
+// ImpersonationTTL is the longest an admin can act as another user with one token
+const ImpersonationTTL = 30 * time.Minute
+
 type AuthService struct {
-	cfg *config.Config
+	cfg     *config.Config
+	hasher  PasswordHasher
+	hashers []PasswordHasher
 }
 
 func NewAuthService(cfg *config.Config) *AuthService {
-	return &AuthService{cfg: cfg}
+	hasher, err := NewPasswordHasher(cfg.Security.HashAlgorithm, cfg.Security.BcryptCost)
+	if err != nil {
+		log.Printf("Warning: %v, falling back to bcrypt", err)
+		hasher, _ = NewPasswordHasher(HashAlgorithmBcrypt, cfg.Security.BcryptCost)
+	}
+
+	// Keep every known algorithm around so existing hashes still verify
+	hashers := []PasswordHasher{hasher}
+	for _, algorithm := range []string{HashAlgorithmBcrypt, HashAlgorithmArgon2id} {
+		if algorithm != hasher.Name() {
+			h, _ := NewPasswordHasher(algorithm, cfg.Security.BcryptCost)
+			hashers = append(hashers, h)
+		}
+	}
+
+	return &AuthService{cfg: cfg, hasher: hasher, hashers: hashers}
 }
 
-// HashPassword hashes a password using bcrypt
+// HashPassword hashes a password using the configured algorithm
 func (s *AuthService) HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), s.cfg.Security.BcryptCost)
-	return string(bytes), err
+	return s.hasher.Hash(password)
 }
 
-// VerifyPassword verifies a password against a hash
+// VerifyPassword verifies a password against a hash produced by any supported algorithm
 func (s *AuthService) VerifyPassword(hashedPassword, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	return err == nil
+	for _, hasher := range s.hashers {
+		if hasher.Owns(hashedPassword) {
+			return hasher.Verify(hashedPassword, password)
+		}
+	}
+	return false
+}
+
+// NeedsRehash reports whether a hash should be replaced using the configured algorithm
+func (s *AuthService) NeedsRehash(hashedPassword string) bool {
+	if !s.hasher.Owns(hashedPassword) {
+		return true
+	}
+	return s.hasher.NeedsRehash(hashedPassword)
 }
 
 // CreateUser creates a new user
 func (s *AuthService) CreateUser(username, password, role string) (*models.User, error) {
+	username = NormalizeUsername(username)
+
 	// Check if user exists
 	var existingUser models.User
-	if err := models.DB.Where("username = ?", username).First(&existingUser).Error; err == nil {
+	if err := models.DB.Where("LOWER(username) = ?", username).First(&existingUser).Error; err == nil {
 		return nil, ErrUserExists
 	}
 
@@ -67,7 +109,7 @@ func (s *AuthService) CreateUser(username, password, role string) (*models.User,
 // Authenticate verifies credentials and returns the user
 func (s *AuthService) Authenticate(username, password string) (*models.User, error) {
 	var user models.User
-	if err := models.DB.Where("username = ?", username).First(&user).Error; err != nil {
+	if err := models.DB.Where("LOWER(username) = ?", NormalizeUsername(username)).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrInvalidCredentials
 		}
@@ -77,27 +119,90 @@ func (s *AuthService) Authenticate(username, password string) (*models.User, err
 	if !s.VerifyPassword(user.PasswordHash, password) {
 		return nil, ErrInvalidCredentials
 	}
+	if user.Disabled {
+		return nil, ErrAccountDisabled
+	}
+
+	// Transparently migrate the stored hash to the configured algorithm
+	if s.NeedsRehash(user.PasswordHash) {
+		if hashedPassword, err := s.HashPassword(password); err == nil {
+			if err := models.DB.Model(&user).Update("password_hash", hashedPassword).Error; err != nil {
+				log.Printf("Warning: failed to rehash password for user %d: %v", user.ID, err)
+			}
+		}
+	}
 
 	return &user, nil
 }
 
-// CreateDefaultUser creates the default admin user if it doesn't exist
+// CreateDefaultUser creates the default admin user if it doesn't exist. Without a
+// configured default_user password the first admin is created by the setup wizard.
 func (s *AuthService) CreateDefaultUser() error {
+	if s.cfg.DefaultUser.Username == "" || s.cfg.DefaultUser.Password == "" {
+		return nil
+	}
+
 	var count int64
 	models.DB.Model(&models.User{}).Count(&count)
 
 	if count == 0 {
-		_, err := s.CreateUser(
+		user, err := s.CreateUser(
 			s.cfg.DefaultUser.Username,
 			s.cfg.DefaultUser.Password,
 			s.cfg.DefaultUser.Role,
 		)
-		return err
+		if err != nil {
+			return err
+		}
+
+		// The password comes from a config file and is likely shared or left at its example value
+		if err := models.DB.Model(user).Update("must_change_password", true).Error; err != nil {
+			return err
+		}
+		log.Printf("Created default user %q from config; the password must be changed on first login", user.Username)
 	}
 
 	return nil
 }
 
+// ChangeInitialPassword replaces the password of a user that must change it before
+// logging in, after verifying the current one, and clears the requirement. Users
+// without the requirement get ErrPasswordChangeNotRequired, this endpoint is not
+// a way around the authenticated password change.
+func (s *AuthService) ChangeInitialPassword(username, currentPassword, newPassword string) (*models.User, error) {
+	user, err := s.Authenticate(username, currentPassword)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MustChangePassword {
+		return nil, ErrPasswordChangeNotRequired
+	}
+	if len(newPassword) < MinPasswordLength {
+		return nil, fmt.Errorf("%w: use at least %d characters", ErrWeakPassword, MinPasswordLength)
+	}
+	if newPassword == currentPassword {
+		return nil, fmt.Errorf("%w: choose a different password than the current one", ErrWeakPassword)
+	}
+
+	hashedPassword, err := s.HashPassword(newPassword)
+	if err != nil {
+		return nil, err
+	}
+	// Only while the requirement is still set, two concurrent changes must not both succeed
+	result := models.DB.Model(user).Where("must_change_password = ?", true).Updates(map[string]interface{}{
+		"password_hash":        hashedPassword,
+		"must_change_password": false,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrPasswordChangeNotRequired
+	}
+
+	return user, nil
+}
+
 // CreateSession creates a new session record
 func (s *AuthService) CreateSession(userID uint, token string, expiresAt time.Time) error {
 	session := &models.Session{
@@ -117,12 +222,93 @@ func (s *AuthService) GetSession(token string) (*models.Session, error) {
 	return &session, nil
 }
 
-// DeleteSession deletes a session
+// DeleteSession deletes a session and any impersonation sessions started from it
 func (s *AuthService) DeleteSession(token string) error {
-	return models.DB.Where("token = ?", token).Delete(&models.Session{}).Error
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		if err := tx.Where("token = ?", token).Limit(1).Find(&session).Error; err != nil {
+			return err
+		}
+		if session.ID == 0 {
+			return nil
+		}
+		if err := tx.Where("impersonator_session_id = ?", session.ID).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&session).Error
+	})
 }
 
-// DeleteExpiredSessions removes expired sessions
-func (s *AuthService) DeleteExpiredSessions() error {
-	return models.DB.Where("expires_at < ?", time.Now()).Delete(&models.Session{}).Error
+// CheckImpersonation returns an error unless the admin in adminSession may act as target
+func (s *AuthService) CheckImpersonation(adminSession *models.Session, target *models.User) error {
+	switch {
+	case adminSession.ImpersonatedBy != nil:
+		return fmt.Errorf("%w: already impersonating a user", ErrImpersonationNotAllowed)
+	case target.ID == adminSession.UserID:
+		return fmt.Errorf("%w: cannot impersonate yourself", ErrImpersonationNotAllowed)
+	case target.Role == models.RoleAdmin:
+		return fmt.Errorf("%w: cannot impersonate an admin", ErrImpersonationNotAllowed)
+	case target.Disabled:
+		return fmt.Errorf("%w: the user is disabled", ErrImpersonationNotAllowed)
+	}
+	return nil
+}
+
+// CreateImpersonationSession records a session for target started by the admin in
+// adminSession. It ends with the admin session at the latest.
+func (s *AuthService) CreateImpersonationSession(adminSession *models.Session, target *models.User, token string, expiresAt time.Time) (*models.Session, error) {
+	if err := s.CheckImpersonation(adminSession, target); err != nil {
+		return nil, err
+	}
+	if expiresAt.After(adminSession.ExpiresAt) {
+		expiresAt = adminSession.ExpiresAt
+	}
+
+	adminID, adminSessionID := adminSession.UserID, adminSession.ID
+	session := &models.Session{
+		UserID:                target.ID,
+		Token:                 token,
+		ExpiresAt:             expiresAt,
+		ImpersonatedBy:        &adminID,
+		ImpersonatorSessionID: &adminSessionID,
+	}
+	if err := models.DB.Create(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// StopImpersonation ends an impersonation session. The admin goes back to the
+// token they already hold, it is never handed to the impersonation token.
+func (s *AuthService) StopImpersonation(session *models.Session) error {
+	if session.ImpersonatorSessionID == nil {
+		return ErrNotImpersonating
+	}
+	return models.DB.Delete(&models.Session{}, session.ID).Error
+}
+
+// DeleteExpiredSessions removes expired sessions and returns how many were removed
+func (s *AuthService) DeleteExpiredSessions() (int64, error) {
+	result := models.DB.Where("expires_at < ?", time.Now()).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
+
+// RunSessionCleanup deletes expired sessions now and then every interval until ctx is cancelled
+func (s *AuthService) RunSessionCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if deleted, err := s.DeleteExpiredSessions(); err != nil {
+			log.Printf("Session cleanup: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Session cleanup: removed %d expired sessions", deleted)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
 }
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultUserMustChangePassword(t *testing.T) {
+	cfg := setupTestDB(t)
+	cfg.DefaultUser.Username = "admin"
+	cfg.DefaultUser.Password = "changeme"
+	cfg.DefaultUser.Role = "admin"
+	service := NewAuthService(cfg)
+
+	require.NoError(t, service.CreateDefaultUser())
+	user, err := service.Authenticate("admin", "changeme")
+	require.NoError(t, err)
+	assert.True(t, user.MustChangePassword)
+
+	_, err = service.ChangeInitialPassword("admin", "wrong", "a new password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = service.ChangeInitialPassword("admin", "changeme", "short")
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	_, err = service.ChangeInitialPassword("admin", "changeme", "changeme")
+	assert.ErrorIs(t, err, ErrWeakPassword)
+
+	changed, err := service.ChangeInitialPassword("admin", "changeme", "a new password")
+	require.NoError(t, err)
+	assert.False(t, changed.MustChangePassword)
+
+	_, err = service.Authenticate("admin", "changeme")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	user, err = service.Authenticate("admin", "a new password")
+	require.NoError(t, err)
+	assert.False(t, user.MustChangePassword)
+
+	// Once changed, the unauthenticated endpoint no longer accepts changes
+	_, err = service.ChangeInitialPassword("admin", "a new password", "another password")
+	assert.ErrorIs(t, err, ErrPasswordChangeNotRequired)
+
+	// Users created any other way are not forced to change their password
+	other, err := service.CreateUser("operator", "operator password", "user")
+	require.NoError(t, err)
+	var stored models.User
+	require.NoError(t, models.DB.First(&stored, other.ID).Error)
+	assert.False(t, stored.MustChangePassword)
+}
+
+func TestImpersonationSessions(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	authService := NewAuthService(cfg)
+	jwtService := NewJWTService(cfg)
+
+	admin, err := authService.CreateUser("support", "support password", models.RoleAdmin)
+	require.NoError(t, err)
+	client, err := NewClientService(cfg).CreateClient(&CreateClientData{
+		Username:    "impersonated",
+		Password:    "testpass123",
+		ContactName: "Impersonated Client",
+		Email:       "impersonated@example.com",
+	})
+	require.NoError(t, err)
+
+	adminToken, adminExpiry, err := jwtService.GenerateToken(admin)
+	require.NoError(t, err)
+	require.NoError(t, authService.CreateSession(admin.ID, adminToken, adminExpiry))
+	adminSession, err := authService.GetSession(adminToken)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, authService.CheckImpersonation(adminSession, admin), ErrImpersonationNotAllowed)
+
+	token, expiresAt, err := jwtService.GenerateImpersonationToken(&client.User, admin, ImpersonationTTL)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(ImpersonationTTL), expiresAt, time.Minute)
+	claims, err := jwtService.VerifyToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, float64(client.User.ID), claims["user_id"])
+	assert.Equal(t, float64(admin.ID), claims["impersonated_by"])
+
+	_, err = authService.CreateImpersonationSession(adminSession, &client.User, token, expiresAt)
+	require.NoError(t, err)
+	session, err := authService.GetSession(token)
+	require.NoError(t, err)
+	assert.Equal(t, client.User.ID, session.UserID)
+	require.NotNil(t, session.ImpersonatedBy)
+	assert.Equal(t, admin.ID, *session.ImpersonatedBy)
+
+	// No impersonating from an impersonation session
+	assert.ErrorIs(t, authService.CheckImpersonation(session, &client.User), ErrImpersonationNotAllowed)
+	assert.ErrorIs(t, authService.StopImpersonation(adminSession), ErrNotImpersonating)
+
+	require.NoError(t, authService.StopImpersonation(session))
+	_, err = authService.GetSession(token)
+	assert.Error(t, err, "the impersonation session is gone")
+	_, err = authService.GetSession(adminToken)
+	assert.NoError(t, err, "the admin keeps their own session")
+
+	// Logging the admin out ends impersonation sessions started from it
+	_, err = authService.CreateImpersonationSession(adminSession, &client.User, "second-token", expiresAt)
+	require.NoError(t, err)
+	require.NoError(t, authService.DeleteSession(adminToken))
+	_, err = authService.GetSession("second-token")
+	assert.Error(t, err)
+}
+
+func TestDeleteExpiredSessions(t *testing.T) {
+	cfg := setupTestDB(t)
+	service := NewAuthService(cfg)
+	user, err := service.CreateUser("sessions", "sessions password", models.RoleUser)
+	require.NoError(t, err)
+
+	require.NoError(t, service.CreateSession(user.ID, "expired-1", time.Now().Add(-time.Hour)))
+	require.NoError(t, service.CreateSession(user.ID, "expired-2", time.Now().Add(-time.Minute)))
+	require.NoError(t, service.CreateSession(user.ID, "live", time.Now().Add(time.Hour)))
+
+	deleted, err := service.DeleteExpiredSessions()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	_, err = service.GetSession("live")
+	assert.NoError(t, err)
+	deleted, err = service.DeleteExpiredSessions()
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
@@ -2,17 +2,47 @@ package services
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"r-panel/internal/config"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	ErrBackupNotFound        = errors.New("backup not found")
+	ErrInvalidBackupName     = errors.New("backup name must be a plain file name inside the backups directory")
+	ErrUnsupportedBackupType = errors.New("backup must be a .tar.gz, .tgz, .tar, .sql or .sql.gz file")
+	ErrBackupExists          = errors.New("backup already exists")
+	ErrBackupTooLarge        = errors.New("backup exceeds the upload size limit")
+	ErrUnsafeBackupEntry     = errors.New("backup entry escapes the target directory")
 )
 
+// backupExtensions are the file types the panel writes and accepts as uploads
+var backupExtensions = []string{".tar.gz", ".tgz", ".tar", ".sql", ".sql.gz"}
+
+// compressionComment prefixes the gzip header comment that records the
+// compression level a backup was written with
+const compressionComment = "r-panel compression_level="
+
 type BackupService struct {
-	backupsPath string
+	backupsPath     string
+	freeSpaceMargin int64                            // bytes a backup must leave free
+	freeSpace       func(path string) (int64, error) // available bytes on the file system of path
+	mysqlServers    *MySQLServers                    // where client databases live, nil when MySQL is not configured
 }
 
 type BackupJob struct {
@@ -33,113 +63,228 @@ type BackupFile struct {
 	Size      int64     `json:"size"`
 	Type      string    `json:"type"`
 	CreatedAt time.Time `json:"created_at"`
+	// Compression is the level the panel wrote the backup with: 0 to 9,
+	// default or none. Empty for backups the panel did not write.
+	Compression string `json:"compression,omitempty"`
 }
 
-func NewBackupService(backupsPath string) *BackupService {
+// NewBackupService stores backups in backupsPath and refuses to start one that
+// would leave less than freeSpaceMargin bytes free there
+func NewBackupService(backupsPath string, freeSpaceMargin int64) *BackupService {
 	return &BackupService{
-		backupsPath: backupsPath,
+		backupsPath:     backupsPath,
+		freeSpaceMargin: freeSpaceMargin,
+		freeSpace:       availableSpace,
 	}
 }
 
-// CreateFileBackup creates a file backup (tar.gz)
-func (s *BackupService) CreateFileBackup(sourcePath, backupName string) (string, error) {
-	if backupName == "" {
-		backupName = fmt.Sprintf("backup_%s_%d.tar.gz", filepath.Base(sourcePath), time.Now().Unix())
+// SetMySQLServers sets the servers client backups dump and restore databases on
+func (s *BackupService) SetMySQLServers(servers *MySQLServers) {
+	s.mysqlServers = servers
+}
+
+// mysqlServer returns the named server a client database lives on
+func (s *BackupService) mysqlServer(name string) (*MySQLService, error) {
+	if s.mysqlServers == nil {
+		return nil, ErrMySQLNotConfigured
 	}
+	return s.mysqlServers.Get(name)
+}
 
-	outputPath := filepath.Join(s.backupsPath, backupName)
+// CompressionName returns how a compression level is recorded in backup metadata
+func CompressionName(level int) string {
+	switch level {
+	case config.CompressionNone:
+		return "none"
+	case config.CompressionDefault:
+		return "default"
+	}
+	return strconv.Itoa(level)
+}
 
-	// Create tar.gz file
-	file, err := os.Create(outputPath)
+// newBackupWriter wraps file in a gzip writer of the given level, which records
+// the level in its header. With config.CompressionNone the file is written as it is.
+func newBackupWriter(file io.Writer, level int) (io.WriteCloser, error) {
+	if level == config.CompressionNone {
+		return nopWriteCloser{file}, nil
+	}
+	gzWriter, err := gzip.NewWriterLevel(file, level)
 	if err != nil {
-		return "", fmt.Errorf("failed to create backup file: %w", err)
+		return nil, fmt.Errorf("invalid compression level: %w", err)
 	}
-	defer file.Close()
+	gzWriter.Comment = compressionComment + CompressionName(level)
+	return gzWriter, nil
+}
 
-	gzWriter := gzip.NewWriter(file)
-	defer gzWriter.Close()
+// countingWriter adds the bytes written through it to n
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
 
-	tarWriter := tar.NewWriter(gzWriter)
-	defer tarWriter.Close()
+func (c countingWriter) Write(p []byte) (int, error) {
+	written, err := c.w.Write(p)
+	c.n.Add(int64(written))
+	return written, err
+}
 
-	// Walk source directory and add files to archive
-	err = filepath.Walk(sourcePath, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// contextWriter fails every write once ctx is done, so a canceled backup stops
+// at the next write instead of archiving the rest of the source
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
 
-		// Skip directories themselves, only add files
-		if info.IsDir() {
-			return nil
-		}
+func (c contextWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
 
-		// Open file
-		srcFile, err := os.Open(filePath)
-		if err != nil {
-			return err
-		}
-		defer srcFile.Close()
+// countWriter wraps w in a countingWriter, or returns it as it is when n is nil
+func countWriter(w io.Writer, n *atomic.Int64) io.Writer {
+	if n == nil {
+		return w
+	}
+	return countingWriter{w: w, n: n}
+}
 
-		// Create tar header
-		header, err := tar.FileInfoHeader(info, "")
-		if err != nil {
-			return err
-		}
+type nopWriteCloser struct {
+	io.Writer
+}
 
-		// Set relative path
-		relPath, err := filepath.Rel(sourcePath, filePath)
-		if err != nil {
-			return err
-		}
-		header.Name = relPath
+func (nopWriteCloser) Close() error { return nil }
 
-		// Write header
-		if err := tarWriter.WriteHeader(header); err != nil {
-			return err
-		}
+// CreateFileBackup creates a file backup: a tar.gz, or a plain tar with
+// config.CompressionNone
+func (s *BackupService) CreateFileBackup(ctx context.Context, sourcePath, backupName string, level int) (string, error) {
+	return s.createFileBackup(ctx, sourcePath, backupName, level, nil)
+}
 
-		// Copy file content
-		if _, err := io.Copy(tarWriter, srcFile); err != nil {
-			return err
+// createFileBackup is CreateFileBackup adding the archived bytes to written when
+// it is not nil
+func (s *BackupService) createFileBackup(ctx context.Context, sourcePath, backupName string, level int, written *atomic.Int64) (string, error) {
+	if backupName == "" {
+		ext := ".tar.gz"
+		if level == config.CompressionNone {
+			ext = ".tar"
 		}
+		backupName = fmt.Sprintf("backup_%s_%d%s", filepath.Base(sourcePath), time.Now().Unix(), ext)
+	}
 
-		return nil
-	})
+	outputPath, err := s.backupPath(backupName)
+	if err != nil {
+		return "", err
+	}
+
+	size, err := directorySize(ctx, sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to measure %s: %w", sourcePath, err)
+	}
+	if err := s.checkFreeSpace(size); err != nil {
+		return "", err
+	}
+
+	// Create tar.gz file
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
 
+	compressor, err := newBackupWriter(file, level)
 	if err != nil {
+		file.Close()
+		os.Remove(outputPath)
+		return "", err
+	}
+
+	tarWriter := tar.NewWriter(contextWriter{ctx: ctx, w: countWriter(compressor, written)})
+
+	// Walk source directory and add files to archive, then write the tar
+	// trailer, the gzip footer and the file in that order: each can still fail
+	err = addDirToTar(tarWriter, sourcePath, "")
+	if err == nil {
+		err = tarWriter.Close()
+	}
+	if closeErr := compressor.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to compress backup: %w", closeErr)
+	}
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write backup file: %w", closeErr)
+	}
+	if err != nil {
+		// Never leave a partial archive that looks like a good backup
+		os.Remove(outputPath)
 		return "", fmt.Errorf("failed to create archive: %w", err)
 	}
 
 	return outputPath, nil
 }
 
-// CreateDatabaseBackup creates a database backup using mysqldump
-func (s *BackupService) CreateDatabaseBackup(database, backupName string) (string, error) {
+// CreateDatabaseBackup creates a database backup using mysqldump on the primary
+// MySQL server, compressed unless level is config.CompressionNone
+func (s *BackupService) CreateDatabaseBackup(ctx context.Context, database, backupName string, level int) (string, error) {
+	return s.createDatabaseBackup(ctx, database, backupName, level, nil)
+}
+
+// createDatabaseBackup is CreateDatabaseBackup adding the dumped bytes to
+// written when it is not nil
+func (s *BackupService) createDatabaseBackup(ctx context.Context, database, backupName string, level int, written *atomic.Int64) (string, error) {
+	if !cliDatabaseNamePattern.MatchString(database) {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeDatabaseName, database)
+	}
 	if backupName == "" {
-		backupName = fmt.Sprintf("db_%s_%d.sql.gz", database, time.Now().Unix())
+		ext := ".sql.gz"
+		if level == config.CompressionNone {
+			ext = ".sql"
+		}
+		backupName = fmt.Sprintf("db_%s_%d%s", database, time.Now().Unix(), ext)
 	}
 
-	outputPath := filepath.Join(s.backupsPath, backupName)
+	outputPath, err := s.backupPath(backupName)
+	if err != nil {
+		return "", err
+	}
 
-	// Run mysqldump
-	cmd := exec.Command("mysqldump", "--single-transaction", "--routines", "--triggers", database)
-	output, err := cmd.Output()
+	server, err := s.mysqlServer("")
 	if err != nil {
-		return "", fmt.Errorf("failed to dump database: %w", err)
+		return "", err
+	}
+	if err := s.checkFreeSpace(databaseSize(ctx, server, database)); err != nil {
+		return "", err
 	}
 
-	// Compress with gzip
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to create backup file: %w", err)
 	}
 	defer file.Close()
 
-	gzWriter := gzip.NewWriter(file)
-	defer gzWriter.Close()
+	compressor, err := newBackupWriter(file, level)
+	if err != nil {
+		os.Remove(outputPath)
+		return "", err
+	}
 
-	if _, err := gzWriter.Write(output); err != nil {
-		return "", fmt.Errorf("failed to compress backup: %w", err)
+	// Stream mysqldump through gzip instead of holding the dump in memory
+	var stderr bytes.Buffer
+	cmd := server.cliCommand(ctx, server.mysqldump, "--single-transaction", "--routines", "--triggers", database)
+	cmd.Stdout = countWriter(compressor, written)
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if closeErr := compressor.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to compress backup: %w", closeErr)
+	} else if err != nil {
+		err = fmt.Errorf("failed to dump database: %w", err)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+	}
+	if err != nil {
+		// Never leave a truncated dump that looks like a good backup
+		os.Remove(outputPath)
+		return "", err
 	}
 
 	return outputPath, nil
@@ -147,6 +292,19 @@ func (s *BackupService) CreateDatabaseBackup(database, backupName string) (strin
 
 // ListBackups returns list of backup files
 func (s *BackupService) ListBackups() ([]BackupFile, error) {
+	backups, err := s.listBackupFiles()
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups {
+		backups[i].Compression = readCompression(backups[i].Path)
+	}
+	return backups, nil
+}
+
+// listBackupFiles returns the backup files without their compression, which
+// takes opening each of them
+func (s *BackupService) listBackupFiles() ([]BackupFile, error) {
 	files, err := os.ReadDir(s.backupsPath)
 	if err != nil {
 		return nil, err
@@ -154,7 +312,8 @@ func (s *BackupService) ListBackups() ([]BackupFile, error) {
 
 	var backups []BackupFile
 	for _, entry := range files {
-		if entry.IsDir() {
+		// Hidden files are uploads still in progress
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
 			continue
 		}
 
@@ -164,7 +323,7 @@ func (s *BackupService) ListBackups() ([]BackupFile, error) {
 		}
 
 		backupType := "file"
-		if filepath.Ext(entry.Name()) == ".sql" || filepath.Ext(entry.Name()) == ".sql.gz" {
+		if strings.HasSuffix(entry.Name(), ".sql") || strings.HasSuffix(entry.Name(), ".sql.gz") {
 			backupType = "database"
 		}
 
@@ -180,10 +339,150 @@ func (s *BackupService) ListBackups() ([]BackupFile, error) {
 	return backups, nil
 }
 
+// readCompression returns the compression level recorded in a backup: the gzip
+// header comment of a compressed one, or none for a plain tar or sql file
+func readCompression(backupPath string) string {
+	if strings.HasSuffix(backupPath, ".tar") || strings.HasSuffix(backupPath, ".sql") {
+		return CompressionName(config.CompressionNone)
+	}
+	file, err := os.Open(backupPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	// Only the header is read, not the archive itself
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return ""
+	}
+	defer gzReader.Close()
+	if !strings.HasPrefix(gzReader.Comment, compressionComment) {
+		return ""
+	}
+	return strings.TrimPrefix(gzReader.Comment, compressionComment)
+}
+
 // DeleteBackup deletes a backup file
 func (s *BackupService) DeleteBackup(backupName string) error {
-	backupPath := filepath.Join(s.backupsPath, backupName)
-	return os.Remove(backupPath)
+	backupPath, err := s.backupPath(backupName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(backupPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrBackupNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// FindBackup returns the backup file called name. Only plain names inside the
+// backups directory are found, anything with a path component is rejected.
+func (s *BackupService) FindBackup(name string) (*BackupFile, error) {
+	if _, err := s.backupPath(name); err != nil {
+		return nil, err
+	}
+
+	backups, err := s.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, backup := range backups {
+		if backup.Name == name {
+			return &backup, nil
+		}
+	}
+
+	return nil, ErrBackupNotFound
+}
+
+// SaveBackup stores an uploaded backup as name in the backups directory. It never
+// replaces an existing file and fails once more than maxSize bytes are read.
+func (s *BackupService) SaveBackup(name string, src io.Reader, maxSize int64) (*BackupFile, error) {
+	targetPath, err := s.backupPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if !hasBackupExtension(name) {
+		return nil, ErrUnsupportedBackupType
+	}
+
+	if _, err := os.Lstat(targetPath); err == nil {
+		return nil, ErrBackupExists
+	}
+
+	// Write to a hidden temp file first so a partial upload is never listed
+	tmp, err := os.CreateTemp(s.backupsPath, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, io.LimitReader(src, maxSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if written > maxSize {
+		return nil, ErrBackupTooLarge
+	}
+
+	// Link instead of rename so a file created in the meantime is not replaced
+	if err := os.Link(tmp.Name(), targetPath); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, ErrBackupExists
+		}
+		return nil, fmt.Errorf("failed to store backup file: %w", err)
+	}
+
+	return s.FindBackup(name)
+}
+
+// backupPath resolves name to a file directly inside the backups directory. Names
+// with path separators or "..", hidden names, and symlinks leading out of the
+// directory are rejected with ErrInvalidBackupName.
+func (s *BackupService) backupPath(name string) (string, error) {
+	if name == "" || len(name) > 255 || strings.HasPrefix(name, ".") || strings.Contains(name, "..") || strings.ContainsAny(name, "/\\\x00") {
+		return "", ErrInvalidBackupName
+	}
+
+	root, err := filepath.Abs(s.backupsPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve backups directory: %w", err)
+	}
+	resolved := filepath.Join(root, name)
+	if filepath.Dir(resolved) != root {
+		return "", ErrInvalidBackupName
+	}
+
+	// An existing entry may be a symlink, it has to stay inside the directory as well
+	if target, err := filepath.EvalSymlinks(resolved); err == nil {
+		realRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve backups directory: %w", err)
+		}
+		if filepath.Dir(target) != realRoot {
+			return "", ErrInvalidBackupName
+		}
+	}
+
+	return resolved, nil
+}
+
+// hasBackupExtension reports whether name ends in one of the backup extensions
+func hasBackupExtension(name string) bool {
+	for _, ext := range backupExtensions {
+		if strings.HasSuffix(name, ext) && len(name) > len(ext) {
+			return true
+		}
+	}
+	return false
 }
 
 // RestoreFileBackup restores a file backup
@@ -195,15 +494,20 @@ func (s *BackupService) RestoreFileBackup(backupPath, targetPath string) error {
 	}
 	defer file.Close()
 
-	// Decompress gzip
-	gzReader, err := gzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("failed to read gzip: %w", err)
+	// Decompress gzip, backups written without compression are plain tar files
+	buffered := bufio.NewReader(file)
+	var archive io.Reader = buffered
+	if magic, _ := buffered.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gzReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return fmt.Errorf("failed to read gzip: %w", err)
+		}
+		defer gzReader.Close()
+		archive = gzReader
 	}
-	defer gzReader.Close()
 
 	// Extract tar
-	tarReader := tar.NewReader(gzReader)
+	tarReader := tar.NewReader(archive)
 	for {
 		header, err := tarReader.Next()
 		if err == io.EOF {
@@ -213,31 +517,82 @@ func (s *BackupService) RestoreFileBackup(backupPath, targetPath string) error {
 			return fmt.Errorf("failed to read tar: %w", err)
 		}
 
-		targetFilePath := filepath.Join(targetPath, header.Name)
+		if err := extractTarEntry(tarReader, header, targetPath, path.Clean(header.Name)); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// extractTarEntry writes a regular file or directory from an archive to relPath
+// below targetDir, refusing paths that would land outside of it. targetDir may
+// be a client's home, so the directories below it are walked through
+// descriptors opened without following symlinks and files are replaced by
+// renaming a new one over them: a symlink or hard link the client planted is
+// refused or replaced, never written through.
+func extractTarEntry(tarReader *tar.Reader, header *tar.Header, targetDir, relPath string) error {
+	targetPath := filepath.Join(targetDir, filepath.FromSlash(relPath))
+	rel, err := filepath.Rel(targetDir, targetPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%w: %s", ErrUnsafeBackupEntry, header.Name)
+	}
+	if header.Typeflag != tar.TypeDir && header.Typeflag != tar.TypeReg {
+		// Links and special files are never restored
+		return nil
+	}
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return err
+	}
+	dirfd, err := openDirAt(unix.AT_FDCWD, targetDir)
+	if isSymlinkError(err) {
+		return fmt.Errorf("%w: %s", ErrUnsafeBackupEntry, targetDir)
+	}
+	if err != nil {
+		return err
+	}
 
-		// Create directory if needed
-		if header.Typeflag == tar.TypeDir {
-			os.MkdirAll(targetFilePath, os.FileMode(header.Mode))
+	dirs := strings.Split(rel, string(filepath.Separator))
+	name := ""
+	if header.Typeflag == tar.TypeReg {
+		dirs, name = dirs[:len(dirs)-1], dirs[len(dirs)-1]
+	}
+	for _, dir := range dirs {
+		if dir == "." {
 			continue
 		}
-
-		// Create file
-		targetFile, err := os.Create(targetFilePath)
-		if err != nil {
-			return fmt.Errorf("failed to create file: %w", err)
+		next, _, err := mkdirOpenAt(dirfd, dir, 0755)
+		unix.Close(dirfd)
+		if isSymlinkError(err) {
+			return fmt.Errorf("%w: %s", ErrUnsafeBackupEntry, header.Name)
 		}
-
-		if _, err := io.Copy(targetFile, tarReader); err != nil {
-			targetFile.Close()
-			return fmt.Errorf("failed to extract file: %w", err)
+		if err != nil {
+			return err
 		}
-		targetFile.Close()
-
-		// Set permissions
-		os.Chmod(targetFilePath, os.FileMode(header.Mode))
+		dirfd = next
+	}
+	defer unix.Close(dirfd)
+	if name == "" {
+		return nil
 	}
 
-	return nil
+	tmp := tempName(name)
+	fd, err := unix.Openat(dirfd, tmp, unix.O_WRONLY|unix.O_CREAT|unix.O_EXCL|unix.O_NOFOLLOW|unix.O_CLOEXEC, uint32(os.FileMode(header.Mode).Perm()))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", header.Name, err)
+	}
+	file := os.NewFile(uintptr(fd), tmp)
+	defer unix.Unlinkat(dirfd, tmp, 0)
+	if _, err := io.Copy(file, tarReader); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	// renameat replaces whatever is at name, a symlink included, without following it
+	return unix.Renameat(dirfd, tmp, dirfd, name)
 }
 
 // CleanOldBackups removes backups older than retention days
@@ -260,3 +615,62 @@ func (s *BackupService) CleanOldBackups(retentionDays int) error {
 
 	return nil
 }
+
+// addDirToTar adds the regular files under sourcePath to tw, named relative to
+// sourcePath and placed under prefix
+func addDirToTar(tw *tar.Writer, sourcePath, prefix string) error {
+	return filepath.Walk(sourcePath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories, symlinks and special files, only add regular files
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		// Open file
+		srcFile, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer srcFile.Close()
+
+		// Create tar header
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		// Set relative path
+		relPath, err := filepath.Rel(sourcePath, filePath)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(prefix, relPath))
+
+		// Write header
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		// Copy file content
+		_, err = io.Copy(tw, srcFile)
+		return err
+	})
+}
+
+// addBytesToTar adds an in-memory file to tw
+func addBytesToTar(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
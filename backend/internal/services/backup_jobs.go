@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrInvalidBackupType = errors.New("invalid backup type, use file or database")
+	ErrBackupRunning     = errors.New("a backup of this source is already running")
+	ErrBackupJobNotFound = errors.New("backup job not found")
+	ErrBackupNotRunning  = errors.New("backup job is not running")
+	ErrBackupCanceled    = errors.New("backup was canceled")
+)
+
+// Backup job states
+const (
+	BackupJobRunning   = "running"
+	BackupJobCompleted = "completed"
+	BackupJobFailed    = "failed"
+	BackupJobCanceled  = "canceled"
+)
+
+// finishedBackupJobTTL is how long a finished job can still be looked up
+const finishedBackupJobTTL = time.Hour
+
+// BackupRequest describes a backup to start with StartBackup
+type BackupRequest struct {
+	Type   string // file or database
+	Source string // path or database name
+	Name   string // backup file name, generated when empty
+	Level  int    // compression level, see config.ParseCompressionLevel
+}
+
+// BackupJobStatus is a snapshot of a backup started with StartBackup
+type BackupJobStatus struct {
+	ID           string     `json:"id"`
+	Type         string     `json:"type"`
+	Source       string     `json:"source"`
+	Compression  string     `json:"compression"`
+	State        string     `json:"state"`
+	BytesWritten int64      `json:"bytes_written"` // archived or dumped so far, before compression
+	Path         string     `json:"path,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+type backupJob struct {
+	status  BackupJobStatus // guarded by the registry, BytesWritten comes from written
+	key     string
+	written atomic.Int64
+	err     error
+	done    chan struct{}
+
+	cancel   context.CancelFunc // stops the backup, set before the job is registered
+	canceled bool               // guarded by the registry
+}
+
+// backupJobRegistry tracks running and recently finished backups and allows one
+// running backup per source
+type backupJobRegistry struct {
+	mu     sync.Mutex
+	jobs   map[string]*backupJob
+	active map[string]*backupJob // by source key
+}
+
+// backupJobs is shared by every BackupService, handlers each create their own
+var backupJobs = &backupJobRegistry{
+	jobs:   map[string]*backupJob{},
+	active: map[string]*backupJob{},
+}
+
+// backupSourceKey identifies what a backup reads, so equal sources clash
+// however they are spelled
+func backupSourceKey(backupType, source string) string {
+	if backupType == "file" {
+		source = filepath.Clean(source)
+	}
+	return backupType + ":" + source
+}
+
+func newBackupJobID() string {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(raw)
+}
+
+// start registers a running job for req, or fails if its source is being backed up
+func (r *backupJobRegistry) start(req BackupRequest, cancel context.CancelFunc) (*backupJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(time.Now())
+	key := backupSourceKey(req.Type, req.Source)
+	if running, ok := r.active[key]; ok {
+		return nil, fmt.Errorf("%w: job %s", ErrBackupRunning, running.status.ID)
+	}
+
+	job := &backupJob{
+		key: key,
+		status: BackupJobStatus{
+			ID:          newBackupJobID(),
+			Type:        req.Type,
+			Source:      req.Source,
+			Compression: CompressionName(req.Level),
+			State:       BackupJobRunning,
+			StartedAt:   time.Now(),
+		},
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	r.jobs[job.status.ID] = job
+	r.active[key] = job
+	return job, nil
+}
+
+// finish records the outcome of job and wakes up everyone waiting for it
+func (r *backupJobRegistry) finish(job *backupJob, path string, err error) BackupJobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	job.status.FinishedAt = &now
+	// A backup that finished while it was being canceled still counts
+	if err != nil && job.canceled {
+		err = ErrBackupCanceled
+	}
+	job.err = err
+	switch {
+	case job.canceled && err != nil:
+		job.status.State = BackupJobCanceled
+		job.status.Error = err.Error()
+	case err != nil:
+		job.status.State = BackupJobFailed
+		job.status.Error = err.Error()
+	default:
+		job.status.State = BackupJobCompleted
+		job.status.Path = path
+	}
+	delete(r.active, job.key)
+	close(job.done)
+	return r.snapshot(job)
+}
+
+// snapshot copies the status of job, the caller holds r.mu
+func (r *backupJobRegistry) snapshot(job *backupJob) BackupJobStatus {
+	status := job.status
+	status.BytesWritten = job.written.Load()
+	return status
+}
+
+// prune forgets jobs that finished more than finishedBackupJobTTL ago, the
+// caller holds r.mu
+func (r *backupJobRegistry) prune(now time.Time) {
+	for id, job := range r.jobs {
+		if job.status.FinishedAt != nil && now.Sub(*job.status.FinishedAt) > finishedBackupJobTTL {
+			delete(r.jobs, id)
+		}
+	}
+}
+
+// cancel marks job id as canceled and stops it
+func (r *backupJobRegistry) cancel(id string) (*backupJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, ErrBackupJobNotFound
+	}
+	if job.status.FinishedAt != nil {
+		return nil, ErrBackupNotRunning
+	}
+	job.canceled = true
+	job.cancel()
+	return job, nil
+}
+
+func (r *backupJobRegistry) get(id string) (*backupJob, BackupJobStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, BackupJobStatus{}, ErrBackupJobNotFound
+	}
+	return job, r.snapshot(job), nil
+}
+
+// StartBackup starts req in the background and returns its job right away.
+// onDone, if not nil, is called with the outcome once the backup has finished.
+// A second backup of a source that is still being backed up fails with
+// ErrBackupRunning.
+func (s *BackupService) StartBackup(req BackupRequest, onDone func(BackupJobStatus, error)) (*BackupJobStatus, error) {
+	if req.Type != "file" && req.Type != "database" {
+		return nil, ErrInvalidBackupType
+	}
+	// Reject a bad name now rather than in a job nobody may be watching
+	if req.Name != "" {
+		if _, err := s.backupPath(req.Name); err != nil {
+			return nil, err
+		}
+	}
+	// The source is the last mysqldump argument, it must not read as an option
+	if req.Type == "database" {
+		if !cliDatabaseNamePattern.MatchString(req.Source) {
+			return nil, fmt.Errorf("%w: %q", ErrUnsafeDatabaseName, req.Source)
+		}
+		if _, err := s.mysqlServer(""); err != nil {
+			return nil, err
+		}
+	}
+
+	// The job outlives the request that started it, so it gets its own
+	// context, canceled by CancelBackup
+	ctx, cancel := context.WithCancel(context.Background())
+	job, err := backupJobs.start(req, cancel)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go func() {
+		defer cancel()
+		var path string
+		var err error
+		if req.Type == "file" {
+			path, err = s.createFileBackup(ctx, req.Source, req.Name, req.Level, &job.written)
+		} else {
+			path, err = s.createDatabaseBackup(ctx, req.Source, req.Name, req.Level, &job.written)
+		}
+		status := backupJobs.finish(job, path, err)
+		if onDone != nil {
+			onDone(status, job.err)
+		}
+	}()
+
+	_, status, err := backupJobs.get(job.status.ID)
+	return &status, err
+}
+
+// WaitBackup blocks until the backup job id has finished and returns its final
+// status, along with the error the backup failed with
+func (s *BackupService) WaitBackup(id string) (*BackupJobStatus, error) {
+	job, _, err := backupJobs.get(id)
+	if err != nil {
+		return nil, err
+	}
+	<-job.done
+
+	_, status, err := backupJobs.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return &status, job.err
+}
+
+// CancelBackup stops the running backup job id, which kills its mysqldump or
+// stops archiving and removes the partial file. It returns once the job has
+// stopped. A job that finished before it could be stopped fails with
+// ErrBackupNotRunning.
+func (s *BackupService) CancelBackup(id string) (*BackupJobStatus, error) {
+	job, err := backupJobs.cancel(id)
+	if err != nil {
+		return nil, err
+	}
+	<-job.done
+
+	_, status, err := backupJobs.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if status.State != BackupJobCanceled {
+		return nil, fmt.Errorf("%w: it finished before it could be canceled", ErrBackupNotRunning)
+	}
+	return &status, nil
+}
+
+// GetBackupJob returns a running backup or one that finished within the last hour
+func (s *BackupService) GetBackupJob(id string) (*BackupJobStatus, error) {
+	_, status, err := backupJobs.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// RunningBackups returns the backups that are in progress, oldest first
+func (s *BackupService) RunningBackups() []BackupJobStatus {
+	backupJobs.mu.Lock()
+	defer backupJobs.mu.Unlock()
+
+	running := []BackupJobStatus{}
+	for _, job := range backupJobs.active {
+		running = append(running, backupJobs.snapshot(job))
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].StartedAt.Before(running[j].StartedAt) })
+	return running
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"r-panel/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartBackupReportsProgress(t *testing.T) {
+	source := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(source, "index.html"), make([]byte, 4096), 0644))
+	service := NewBackupService(t.TempDir(), 0)
+
+	finished := make(chan BackupJobStatus, 1)
+	job, err := service.StartBackup(BackupRequest{Type: "file", Source: source, Level: config.CompressionDefault},
+		func(status BackupJobStatus, err error) {
+			assert.NoError(t, err)
+			finished <- status
+		})
+	require.NoError(t, err)
+	assert.NotEmpty(t, job.ID)
+
+	done, err := service.WaitBackup(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, BackupJobCompleted, done.State)
+	assert.FileExists(t, done.Path)
+	assert.Greater(t, done.BytesWritten, int64(4096), "the tar headers and the file are counted")
+	assert.Equal(t, "default", done.Compression)
+	assert.Equal(t, done.Path, (<-finished).Path)
+
+	found, err := service.GetBackupJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, BackupJobCompleted, found.State, "finished jobs can still be looked up")
+	assert.NotContains(t, service.RunningBackups(), *found)
+
+	_, err = service.GetBackupJob("missing")
+	assert.ErrorIs(t, err, ErrBackupJobNotFound)
+}
+
+func TestCancelBackupRemovesThePartialFile(t *testing.T) {
+	source := t.TempDir()
+	for i := 0; i < 200; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(source, fmt.Sprintf("file%d", i)), make([]byte, 64*1024), 0644))
+	}
+	backups := t.TempDir()
+	service := NewBackupService(backups, 0)
+
+	finished := make(chan error, 1)
+	job, err := service.StartBackup(BackupRequest{Type: "file", Source: source, Name: "site.tar", Level: config.CompressionNone},
+		func(status BackupJobStatus, err error) { finished <- err })
+	require.NoError(t, err)
+
+	canceled, err := service.CancelBackup(job.ID)
+	if errors.Is(err, ErrBackupNotRunning) {
+		t.Skip("the backup finished before it could be canceled")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, BackupJobCanceled, canceled.State)
+	assert.Empty(t, canceled.Path)
+	assert.ErrorIs(t, <-finished, ErrBackupCanceled)
+	assert.NoFileExists(t, filepath.Join(backups, "site.tar"))
+	assert.Empty(t, service.RunningBackups())
+
+	_, err = service.CancelBackup(job.ID)
+	assert.ErrorIs(t, err, ErrBackupNotRunning, "a canceled job cannot be canceled again")
+	_, err = service.CancelBackup("missing")
+	assert.ErrorIs(t, err, ErrBackupJobNotFound)
+}
+
+func TestStartBackupRejectsBadRequests(t *testing.T) {
+	service := NewBackupService(t.TempDir(), 0)
+
+	_, err := service.StartBackup(BackupRequest{Type: "mail", Source: "/home"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidBackupType)
+	_, err = service.StartBackup(BackupRequest{Type: "file", Source: "/home", Name: "../escape.tar.gz"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidBackupName)
+	_, err = service.StartBackup(BackupRequest{Type: "database", Source: "app"}, nil)
+	assert.ErrorIs(t, err, ErrMySQLNotConfigured)
+
+	service.SetMySQLServers(fakeMySQLServers(fakeMysqldump(t, `echo "should not run"`)))
+	for _, source := range []string{"--result-file=/etc/passwd", "--all-databases", "-r/tmp/x", "a b", ""} {
+		_, err = service.StartBackup(BackupRequest{Type: "database", Source: source}, nil)
+		assert.ErrorIs(t, err, ErrUnsafeDatabaseName, source)
+	}
+	assert.Empty(t, service.RunningBackups())
+}
+
+func TestStartBackupDumpsOnPrimaryServer(t *testing.T) {
+	dir := t.TempDir()
+	service := NewBackupService(dir, 0)
+	server := fakeMysqldump(t, `echo "-- $@"`)
+	server.cliArgs = []string{"--host=db.internal"}
+	service.SetMySQLServers(fakeMySQLServers(server))
+
+	job, err := service.StartBackup(BackupRequest{Type: "database", Source: "app", Name: "app.sql", Level: config.CompressionNone}, nil)
+	require.NoError(t, err)
+	job, err = service.WaitBackup(job.ID)
+	require.NoError(t, err)
+
+	dump, err := os.ReadFile(job.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "-- --host=db.internal --single-transaction --routines --triggers app\n", string(dump))
+}
+
+func TestBackupJobRegistryAllowsOneBackupPerSource(t *testing.T) {
+	registry := &backupJobRegistry{jobs: map[string]*backupJob{}, active: map[string]*backupJob{}}
+
+	job, err := registry.start(BackupRequest{Type: "file", Source: "/home/site"}, func() {})
+	require.NoError(t, err)
+
+	_, err = registry.start(BackupRequest{Type: "file", Source: "/home/site/"}, func() {})
+	assert.ErrorIs(t, err, ErrBackupRunning, "the same directory spelled differently")
+	_, err = registry.start(BackupRequest{Type: "database", Source: "/home/site"}, func() {})
+	assert.NoError(t, err, "a database is a different source")
+
+	registry.finish(job, "/backups/site.tar.gz", nil)
+	_, err = registry.start(BackupRequest{Type: "file", Source: "/home/site"}, func() {})
+	assert.NoError(t, err, "a finished backup no longer blocks its source")
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrInvalidBackupListOptions = errors.New("invalid backup list options")
+
+// BackupListOptions filters, sorts and pages the result of ListBackupsFiltered
+type BackupListOptions struct {
+	Sort  string // name, size or created_at (default)
+	Order string // asc or desc, default desc
+	Type  string // file or database, empty for both
+	Page  int    // 1-based, 0 for every backup on one page
+	Limit int    // backups per page, 15 by default and at most 100
+}
+
+// BackupListResult is one page of backups
+type BackupListResult struct {
+	Data       []BackupFile `json:"data"`
+	Total      int          `json:"total"`
+	Page       int          `json:"page"`
+	Limit      int          `json:"limit"`
+	TotalPages int          `json:"total_pages"`
+}
+
+// ListBackupsFiltered returns the backups matching opts, newest first unless
+// opts asks for another order. Without a page every match is returned.
+func (s *BackupService) ListBackupsFiltered(opts BackupListOptions) (*BackupListResult, error) {
+	less, err := backupLess(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+	desc := true
+	switch opts.Order {
+	case "", "desc":
+	case "asc":
+		desc = false
+	default:
+		return nil, fmt.Errorf("%w: order must be asc or desc", ErrInvalidBackupListOptions)
+	}
+	if opts.Type != "" && opts.Type != "file" && opts.Type != "database" {
+		return nil, fmt.Errorf("%w: type must be file or database", ErrInvalidBackupListOptions)
+	}
+
+	files, err := s.listBackupFiles()
+	if err != nil {
+		return nil, err
+	}
+	backups := []BackupFile{}
+	for _, backup := range files {
+		if opts.Type == "" || backup.Type == opts.Type {
+			backups = append(backups, backup)
+		}
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		if desc {
+			return less(backups[j], backups[i])
+		}
+		return less(backups[i], backups[j])
+	})
+
+	result := &BackupListResult{Total: len(backups), Page: 1, Limit: len(backups), TotalPages: 1}
+	if opts.Page > 0 || opts.Limit > 0 {
+		result.Page = max(opts.Page, 1)
+		result.Limit = opts.Limit
+		if result.Limit < 1 {
+			result.Limit = 15
+		}
+		result.Limit = min(result.Limit, 100)
+		result.TotalPages = (result.Total + result.Limit - 1) / result.Limit
+		start := min((result.Page-1)*result.Limit, len(backups))
+		backups = backups[start:min(start+result.Limit, len(backups))]
+	}
+
+	// Reading the compression opens every file, only do it for the page
+	for i := range backups {
+		backups[i].Compression = readCompression(backups[i].Path)
+	}
+	result.Data = backups
+	return result, nil
+}
+
+// backupLess returns how to compare backups for the sort field, ties broken by name
+func backupLess(field string) (func(a, b BackupFile) bool, error) {
+	switch field {
+	case "", "created_at":
+		return func(a, b BackupFile) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.Name < b.Name
+		}, nil
+	case "size":
+		return func(a, b BackupFile) bool {
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+			return a.Name < b.Name
+		}, nil
+	case "name":
+		return func(a, b BackupFile) bool { return a.Name < b.Name }, nil
+	}
+	return nil, fmt.Errorf("%w: sort must be name, size or created_at", ErrInvalidBackupListOptions)
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListBackupsFiltered(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, backup := range []struct {
+		name string
+		size int
+	}{
+		{"a.tar.gz", 30},
+		{"b.sql", 10},
+		{"c.tar", 20},
+		{"d.sql.gz", 40},
+	} {
+		path := filepath.Join(dir, backup.name)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", backup.size)), 0644))
+		modTime := now.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	service := NewBackupService(dir, 0)
+
+	names := func(result *BackupListResult) []string {
+		var out []string
+		for _, backup := range result.Data {
+			out = append(out, backup.Name)
+		}
+		return out
+	}
+
+	// Newest first by default, everything on one page
+	result, err := service.ListBackupsFiltered(BackupListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d.sql.gz", "c.tar", "b.sql", "a.tar.gz"}, names(result))
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, "none", result.Data[1].Compression)
+
+	result, err = service.ListBackupsFiltered(BackupListOptions{Sort: "size", Order: "asc"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.sql", "c.tar", "a.tar.gz", "d.sql.gz"}, names(result))
+
+	result, err = service.ListBackupsFiltered(BackupListOptions{Sort: "name", Type: "database"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d.sql.gz", "b.sql"}, names(result))
+
+	result, err = service.ListBackupsFiltered(BackupListOptions{Sort: "name", Order: "asc", Page: 2, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d.sql.gz"}, names(result))
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 2, result.TotalPages)
+
+	result, err = service.ListBackupsFiltered(BackupListOptions{Page: 5, Limit: 3})
+	require.NoError(t, err)
+	assert.Empty(t, result.Data)
+
+	for _, opts := range []BackupListOptions{{Sort: "owner"}, {Order: "up"}, {Type: "mail"}} {
+		_, err := service.ListBackupsFiltered(opts)
+		assert.ErrorIs(t, err, ErrInvalidBackupListOptions, "%+v", opts)
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var ErrInsufficientSpace = errors.New("not enough free space for the backup")
+
+// availableSpace returns the bytes an unprivileged user can still write on the
+// file system of path
+func availableSpace(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+// checkFreeSpace fails with ErrInsufficientSpace unless a backup of about size
+// bytes still leaves the free space margin on the backups file system
+func (s *BackupService) checkFreeSpace(size int64) error {
+	available, err := s.freeSpace(s.backupsPath)
+	if err != nil {
+		return fmt.Errorf("failed to check free space: %w", err)
+	}
+	if size+s.freeSpaceMargin > available {
+		return fmt.Errorf("%w: it needs up to %s and %s must stay free, but only %s is available",
+			ErrInsufficientSpace, formatSizeMB(size), formatSizeMB(s.freeSpaceMargin), formatSizeMB(available))
+	}
+	return nil
+}
+
+// directorySize adds up the regular files under root, the files a file backup
+// archives. Compression only makes the archive smaller.
+func directorySize(ctx context.Context, root string) (int64, error) {
+	var size int64
+	err := filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// databaseSize estimates the dump of database from the data and index sizes
+// information_schema reports, using the same server and credentials as
+// mysqldump. It returns 0 when the size cannot be read, so only the margin is
+// checked and mysqldump reports the actual problem.
+func databaseSize(ctx context.Context, server *MySQLService, database string) int64 {
+	if !cliDatabaseNamePattern.MatchString(database) {
+		return 0
+	}
+	query := "SELECT COALESCE(SUM(data_length + index_length), 0) FROM information_schema.TABLES WHERE table_schema = '" + database + "'"
+	out, err := server.cliCommand(ctx, server.mysql, "--batch", "--skip-column-names", "-e", query).Output()
+	if err != nil {
+		log.Printf("Failed to estimate the size of database %s: %v", database, err)
+		return 0
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		log.Printf("Failed to estimate the size of database %s: %v", database, err)
+		return 0
+	}
+	return size
+}
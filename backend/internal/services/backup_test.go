@@ -0,0 +1,249 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"r-panel/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupServiceSaveBackup(t *testing.T) {
+	dir := t.TempDir()
+	service := NewBackupService(dir, 0)
+
+	backup, err := service.SaveBackup("site.tar.gz", strings.NewReader("archive"), 16)
+	require.NoError(t, err)
+	assert.Equal(t, "site.tar.gz", backup.Name)
+	assert.Equal(t, int64(7), backup.Size)
+
+	found, err := service.FindBackup("site.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "site.tar.gz"), found.Path)
+
+	_, err = service.SaveBackup("site.tar.gz", strings.NewReader("other"), 16)
+	assert.ErrorIs(t, err, ErrBackupExists)
+
+	_, err = service.SaveBackup("big.sql", strings.NewReader(strings.Repeat("x", 17)), 16)
+	assert.ErrorIs(t, err, ErrBackupTooLarge)
+
+	_, err = service.SaveBackup("notes.txt", strings.NewReader("x"), 16)
+	assert.ErrorIs(t, err, ErrUnsupportedBackupType)
+
+	for _, name := range []string{"", ".tar.gz", ".hidden.sql", "../escape.sql", "sub/dir.sql", `..\escape.sql`} {
+		_, err := service.SaveBackup(name, strings.NewReader("x"), 16)
+		assert.ErrorIs(t, err, ErrInvalidBackupName, name)
+	}
+
+	// Rejected and oversized uploads leave nothing behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "site.tar.gz", entries[0].Name())
+}
+
+// maliciousBackupNames all point at secret.sql next to the backups directory
+func maliciousBackupNames(parent string) []string {
+	return []string{
+		"../secret.sql",
+		"..",
+		".",
+		"./../secret.sql",
+		`..\secret.sql`,
+		"..%2fsecret.sql",
+		"backups/../../secret.sql",
+		filepath.Join(parent, "secret.sql"),
+		"secret.sql\x00.tar.gz",
+		"link.sql", // symlink to ../secret.sql
+	}
+}
+
+// newTraversalFixture returns a backups directory with a secret file next to it
+// and a symlink inside it that points at the secret
+func newTraversalFixture(t *testing.T) (parent, dir, secret string) {
+	t.Helper()
+	parent = t.TempDir()
+	dir = filepath.Join(parent, "backups")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	secret = filepath.Join(parent, "secret.sql")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(secret, filepath.Join(dir, "link.sql")))
+	return parent, dir, secret
+}
+
+func TestBackupServiceRejectsMaliciousNames(t *testing.T) {
+	parent, dir, secret := newTraversalFixture(t)
+	service := NewBackupService(dir, 0)
+
+	for _, name := range maliciousBackupNames(parent) {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.FindBackup(name)
+			assert.ErrorIs(t, err, ErrInvalidBackupName)
+
+			assert.ErrorIs(t, service.DeleteBackup(name), ErrInvalidBackupName)
+			assert.FileExists(t, secret)
+
+			_, err = service.CreateDatabaseBackup(context.Background(), "app", name, config.CompressionDefault)
+			assert.ErrorIs(t, err, ErrInvalidBackupName)
+			_, err = service.CreateFileBackup(context.Background(), t.TempDir(), name, config.CompressionDefault)
+			assert.ErrorIs(t, err, ErrInvalidBackupName)
+
+			content, err := os.ReadFile(secret)
+			require.NoError(t, err)
+			assert.Equal(t, "secret", string(content), "secret was overwritten")
+		})
+	}
+
+	t.Run("only plain names resolve", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "site..old.tar.gz"), nil, 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "site.tar.gz"), nil, 0644))
+
+		assert.ErrorIs(t, service.DeleteBackup("site..old.tar.gz"), ErrInvalidBackupName)
+		assert.NoError(t, service.DeleteBackup("site.tar.gz"))
+		assert.ErrorIs(t, service.DeleteBackup("site.tar.gz"), ErrBackupNotFound)
+	})
+}
+
+func TestRestoreFileBackupRejectsEscapingEntries(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "evil.tar.gz")
+	file, err := os.Create(archive)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, addBytesToTar(tw, "ok.txt", []byte("fine")))
+	require.NoError(t, addBytesToTar(tw, "../../escaped.txt", []byte("evil")))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, file.Close())
+
+	target := filepath.Join(t.TempDir(), "a", "b")
+	require.NoError(t, os.MkdirAll(target, 0755))
+
+	err = NewBackupService(dir, 0).RestoreFileBackup(archive, target)
+	assert.ErrorIs(t, err, ErrUnsafeBackupEntry)
+	assert.FileExists(t, filepath.Join(target, "ok.txt"))
+	assert.NoFileExists(t, filepath.Join(target, "..", "..", "escaped.txt"))
+}
+
+func TestRestoreFileBackupDoesNotFollowSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "home.tar.gz")
+	file, err := os.Create(archive)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, addBytesToTar(tw, "planted.txt", []byte("from backup")))
+	require.NoError(t, addBytesToTar(tw, "linked/file.txt", []byte("from backup")))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, file.Close())
+
+	// A home the client owns, with links to a file and a directory outside it
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0600))
+	target := t.TempDir()
+	require.NoError(t, os.Symlink(secret, filepath.Join(target, "planted.txt")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(target, "linked")))
+
+	err = NewBackupService(dir, 0).RestoreFileBackup(archive, target)
+	assert.ErrorIs(t, err, ErrUnsafeBackupEntry)
+
+	content, err := os.ReadFile(secret)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(content), "the link target is untouched")
+	assert.NoFileExists(t, filepath.Join(outside, "file.txt"))
+	info, err := os.Lstat(filepath.Join(target, "planted.txt"))
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular(), "the planted link is replaced")
+}
+
+// cancelAfter is a context that is cancelled once Err has been asked calls times
+type cancelAfter struct {
+	context.Context
+	calls int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.calls--; c.calls < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestCreateFileBackupFailsOnUnwrittenTrailer(t *testing.T) {
+	dir := t.TempDir()
+	service := NewBackupService(dir, 0)
+
+	// Measuring the empty source asks once, the tar trailer is the first write
+	ctx := &cancelAfter{Context: context.Background(), calls: 1}
+	_, err := service.CreateFileBackup(ctx, t.TempDir(), "empty.tar.gz", config.CompressionDefault)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoFileExists(t, filepath.Join(dir, "empty.tar.gz"), "the truncated archive is removed")
+}
+
+func TestCreateFileBackupCompressionLevels(t *testing.T) {
+	source := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(source, "index.html"), []byte(strings.Repeat("hello ", 1000)), 0644))
+	dir := t.TempDir()
+	service := NewBackupService(dir, 0)
+
+	fast, err := service.CreateFileBackup(context.Background(), source, "fast.tar.gz", 1)
+	require.NoError(t, err)
+	plain, err := service.CreateFileBackup(context.Background(), source, "", config.CompressionNone)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(plain, ".tar"), "uncompressed backups are named .tar")
+	_, err = service.CreateFileBackup(context.Background(), source, "default.tar.gz", config.CompressionDefault)
+	require.NoError(t, err)
+
+	backups, err := service.ListBackups()
+	require.NoError(t, err)
+	recorded := map[string]string{}
+	for _, backup := range backups {
+		recorded[backup.Name] = backup.Compression
+	}
+	assert.Equal(t, map[string]string{
+		"fast.tar.gz":        "1",
+		filepath.Base(plain): "none",
+		"default.tar.gz":     "default",
+	}, recorded)
+
+	// Both kinds restore, the gzip magic decides how the archive is read
+	for _, archive := range []string{fast, plain} {
+		target := t.TempDir()
+		require.NoError(t, service.RestoreFileBackup(archive, target))
+		content, err := os.ReadFile(filepath.Join(target, "index.html"))
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat("hello ", 1000), string(content))
+	}
+}
+
+func TestBackupRefusesWithoutFreeSpace(t *testing.T) {
+	source := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(source, "index.html"), make([]byte, 4096), 0644))
+	backups := t.TempDir()
+	service := NewBackupService(backups, 1000)
+
+	service.freeSpace = func(string) (int64, error) { return 4096 + 999, nil }
+	_, err := service.CreateFileBackup(context.Background(), source, "site.tar.gz", config.CompressionDefault)
+	assert.ErrorIs(t, err, ErrInsufficientSpace, "the margin must stay free")
+	assert.NoFileExists(t, filepath.Join(backups, "site.tar.gz"))
+
+	service.freeSpace = func(string) (int64, error) { return 4096 + 1000, nil }
+	path, err := service.CreateFileBackup(context.Background(), source, "site.tar.gz", config.CompressionDefault)
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+
+	service.SetMySQLServers(fakeMySQLServers(fakeMysqldump(t, `echo "should not run"`)))
+	service.freeSpace = func(string) (int64, error) { return 999, nil }
+	_, err = service.CreateDatabaseBackup(context.Background(), "app", "app.sql.gz", config.CompressionDefault)
+	assert.ErrorIs(t, err, ErrInsufficientSpace, "an unknown database size still needs the margin")
+}
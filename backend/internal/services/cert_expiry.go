@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// maxCertFileSize skips files in the certificate directories too large to be a certificate
+const maxCertFileSize = 1 << 20
+
+// certExpiryScanInterval is how often certificates are scanned for expiry
+const certExpiryScanInterval = 24 * time.Hour
+
+// settingCertReportedPrefix prefixes the settings recording which expiring
+// certificates were reported, e.g. cert_reported:<sha256 of path and expiry>
+const settingCertReportedPrefix = "cert_reported:"
+
+// Where a scanned certificate comes from
+const (
+	CertSourceAutocert = "autocert" // obtained by the panel through ACME
+	CertSourceUploaded = "uploaded" // uploaded for a site, see paths.ssl_certificates
+	CertSourceCustom   = "custom"   // found in notifications.cert_dirs
+)
+
+// CertificateExpiry describes a certificate that expires soon. It is also the
+// webhook data sent for cert.expiring.
+type CertificateExpiry struct {
+	Name     string    `json:"name"` // DNS names, or the common name
+	Path     string    `json:"path"`
+	Source   string    `json:"source"`
+	NotAfter time.Time `json:"not_after"`
+	DaysLeft int       `json:"days_left"` // negative once expired
+	Expired  bool      `json:"expired"`
+}
+
+// certDir is a directory scanned for certificates
+type certDir struct {
+	source string
+	path   string
+}
+
+// CertExpiryScanner scans the autocert cache, the uploaded site certificates
+// and any extra certificate directories once a day. Certificates expiring
+// within the warning window are emailed to admins, sent to cert.expiring
+// webhooks and written to the audit log, once each until they are renewed.
+// The reported certificates are kept in the settings table so a panel
+// restart does not report them again.
+type CertExpiryScanner struct {
+	notifier *NotificationService
+	dispatch func(event WebhookEvent, data interface{})
+	dirs     []certDir
+	days     int
+	now      func() time.Time
+}
+
+func NewCertExpiryScanner(notifier *NotificationService, webhooks *WebhookService, cfg *config.Config) *CertExpiryScanner {
+	days := cfg.Notifications.CertExpiryDays
+	if days <= 0 {
+		days = config.DefaultCertExpiryDays
+	}
+
+	return &CertExpiryScanner{
+		notifier: notifier,
+		dispatch: webhooks.Dispatch,
+		dirs:     certExpiryDirs(cfg),
+		days:     days,
+		now:      time.Now,
+	}
+}
+
+// certExpiryDirs returns the directories certificates are scanned in: the TLS
+// cache when TLS is on, the uploaded certificates and notifications.cert_dirs
+func certExpiryDirs(cfg *config.Config) []certDir {
+	var dirs []certDir
+	seen := map[string]bool{}
+	add := func(source, path string) {
+		if path == "" || seen[filepath.Clean(path)] {
+			return
+		}
+		seen[filepath.Clean(path)] = true
+		dirs = append(dirs, certDir{source: source, path: path})
+	}
+
+	if cfg.Server.TLS.Enabled {
+		add(CertSourceAutocert, cfg.Server.TLS.CertCacheDir())
+	}
+	add(CertSourceUploaded, cfg.Paths.SSLCertificates)
+	for _, path := range cfg.Notifications.CertDirs {
+		add(CertSourceCustom, path)
+	}
+	return dirs
+}
+
+// WarningDays returns how many days before expiry certificates are reported
+func (s *CertExpiryScanner) WarningDays() int {
+	return s.days
+}
+
+// Run scans immediately and then once a day until ctx is cancelled
+func (s *CertExpiryScanner) Run(ctx context.Context) {
+	ticker := time.NewTicker(certExpiryScanInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Check(); err != nil {
+			log.Printf("Certificate expiry scan failed: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Expiring returns the certificates that expire within days, expired ones
+// included, soonest first. Files and directories that cannot be read are
+// logged and skipped.
+func (s *CertExpiryScanner) Expiring(days int) ([]CertificateExpiry, error) {
+	now := s.now()
+	window := time.Duration(days) * 24 * time.Hour
+
+	expiring := []CertificateExpiry{}
+	for _, dir := range s.dirs {
+		err := filepath.WalkDir(dir.path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == dir.path {
+					return filepath.SkipDir
+				}
+				log.Printf("Certificate expiry scan skipped %s: %v", path, err)
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			cert, err := readLeafCertificate(path)
+			if err != nil {
+				log.Printf("Certificate expiry scan skipped %s: %v", path, err)
+				return nil
+			}
+			if cert == nil {
+				return nil
+			}
+			expiresIn := cert.NotAfter.Sub(now)
+			if expiresIn > window {
+				return nil
+			}
+
+			expiring = append(expiring, CertificateExpiry{
+				Name:     certificateName(cert),
+				Path:     path,
+				Source:   dir.source,
+				NotAfter: cert.NotAfter,
+				DaysLeft: int(expiresIn.Hours() / 24),
+				Expired:  expiresIn <= 0,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", dir.path, err)
+		}
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].NotAfter.Before(expiring[j].NotAfter)
+	})
+	return expiring, nil
+}
+
+// Check reports the certificates expiring within the warning window that were
+// not reported yet
+func (s *CertExpiryScanner) Check() error {
+	expiring, err := s.Expiring(s.days)
+	if err != nil {
+		return err
+	}
+
+	active := map[string]bool{}
+	for _, cert := range expiring {
+		key := certReportedKey(cert)
+		active[key] = true
+		if s.firstReport(key, cert) {
+			s.report(cert)
+		}
+	}
+	s.forgetRenewed(active)
+	return nil
+}
+
+// certReportedKey returns the setting recording that cert was reported. The
+// path is hashed to fit the key column.
+func certReportedKey(cert CertificateExpiry) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", cert.Path, cert.NotAfter.Unix())))
+	return settingCertReportedPrefix + hex.EncodeToString(sum[:])
+}
+
+// report notifies admins and webhooks about an expiring certificate and
+// records it in the audit log
+func (s *CertExpiryScanner) report(cert CertificateExpiry) {
+	subject := fmt.Sprintf("Certificate for %s expires in %d days", cert.Name, cert.DaysLeft)
+	if cert.Expired {
+		subject = fmt.Sprintf("Certificate for %s has expired", cert.Name)
+	}
+	details := fmt.Sprintf("The TLS certificate for %s in %s expires on %s.",
+		cert.Name, cert.Path, cert.NotAfter.Format(time.RFC1123))
+
+	if err := s.notifier.Notify(EventCertExpiry, subject, details); err != nil {
+		log.Printf("Failed to send %s notification: %v", EventCertExpiry, err)
+	}
+	s.dispatch(WebhookCertExpiring, cert)
+
+	// Recorded without a user, the panel itself noticed
+	if models.DB != nil {
+		if err := models.DB.Create(&models.AuditLog{
+			Action:     "cert_expiring",
+			Resource:   "certificate",
+			ResourceID: cert.Name,
+			Details:    details,
+		}).Error; err != nil {
+			log.Printf("Failed to audit expiring certificate %s: %v", cert.Name, err)
+		}
+	}
+}
+
+// firstReport reports true only the first time a certificate is seen
+// expiring. Without a readable record the certificate is reported, a repeated
+// warning is better than a missed one.
+func (s *CertExpiryScanner) firstReport(key string, cert CertificateExpiry) bool {
+	if models.DB == nil {
+		return true
+	}
+	setting := models.Setting{Key: key, Value: fmt.Sprintf("%s expiring %s", cert.Path, cert.NotAfter.UTC().Format(time.RFC3339))}
+	result := models.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&setting)
+	if result.Error != nil {
+		log.Printf("Failed to record expiring certificate %s: %v", cert.Path, result.Error)
+		return true
+	}
+	return result.RowsAffected > 0
+}
+
+// forgetRenewed drops the certificates no longer expiring, a renewed
+// certificate that nears expiry again is reported again
+func (s *CertExpiryScanner) forgetRenewed(active map[string]bool) {
+	if models.DB == nil {
+		return
+	}
+	var keys []string
+	if err := models.DB.Model(&models.Setting{}).Where("`key` LIKE ?", settingCertReportedPrefix+"%").Pluck("key", &keys).Error; err != nil {
+		log.Printf("Failed to read reported certificates: %v", err)
+		return
+	}
+
+	var renewed []string
+	for _, key := range keys {
+		if !active[key] {
+			renewed = append(renewed, key)
+		}
+	}
+	if len(renewed) == 0 {
+		return
+	}
+	if err := models.DB.Delete(&models.Setting{}, "`key` IN ?", renewed).Error; err != nil {
+		log.Printf("Failed to forget renewed certificates: %v", err)
+	}
+}
+
+// readLeafCertificate returns the first certificate in a PEM file, or nil if
+// it has none or is too large to be a certificate
+func readLeafCertificate(path string) (*x509.Certificate, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxCertFileSize {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+		return cert, nil
+	}
+}
+
+func certificateName(cert *x509.Certificate) string {
+	if len(cert.DNSNames) > 0 {
+		return strings.Join(cert.DNSNames, ", ")
+	}
+	return cert.Subject.CommonName
+}
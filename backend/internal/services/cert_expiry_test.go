@@ -0,0 +1,140 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCertificate(t *testing.T, path, domain string, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	// autocert stores the private key ahead of the chain
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+func newTestCertExpiryScanner(t *testing.T, now time.Time) (*CertExpiryScanner, *fakeEmailSender, *[]interface{}, string) {
+	t.Helper()
+	cfg := setupTestDB(t)
+	root := t.TempDir()
+	cfg.Server.TLS.Enabled = true
+	cfg.Server.TLS.CacheDir = filepath.Join(root, "autocert")
+	cfg.Paths.SSLCertificates = filepath.Join(root, "ssl")
+	cfg.Notifications = config.NotificationsConfig{
+		Recipients:     []string{"admin@example.com"},
+		Events:         config.NotificationEventsConfig{CertExpiry: true},
+		CertExpiryDays: 14,
+		CertDirs:       []string{filepath.Join(root, "extra"), filepath.Join(root, "missing"), filepath.Join(root, "ssl")},
+	}
+
+	sender := &fakeEmailSender{}
+	scanner := NewCertExpiryScanner(newTestNotificationService(sender, cfg.Notifications), NewWebhookService(cfg), cfg)
+	dispatched := &[]interface{}{}
+	scanner.dispatch = func(event WebhookEvent, data interface{}) {
+		assert.Equal(t, WebhookCertExpiring, event)
+		*dispatched = append(*dispatched, data)
+	}
+	scanner.now = func() time.Time { return now }
+	return scanner, sender, dispatched, root
+}
+
+func TestCertExpiryScannerExpiring(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	scanner, _, _, root := newTestCertExpiryScanner(t, now)
+	writeTestCertificate(t, filepath.Join(root, "autocert", "soon.example.com"), "soon.example.com", now.Add(5*24*time.Hour))
+	writeTestCertificate(t, filepath.Join(root, "autocert", "later.example.com"), "later.example.com", now.Add(60*24*time.Hour))
+	writeTestCertificate(t, filepath.Join(root, "ssl", "shop.example.com.crt"), "shop.example.com", now.Add(-time.Hour))
+	writeTestCertificate(t, filepath.Join(root, "extra", "live", "old.example.com", "cert.pem"), "old.example.com", now.Add(10*24*time.Hour))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "autocert", "acme_account+key"), []byte("not a certificate"), 0600))
+	// A broken certificate is logged and skipped, it does not end the scan
+	broken := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("broken")})
+	require.NoError(t, os.WriteFile(filepath.Join(root, "autocert", "broken.example.com"), broken, 0600))
+
+	expiring, err := scanner.Expiring(scanner.WarningDays())
+	require.NoError(t, err)
+	require.Len(t, expiring, 3)
+	assert.Equal(t, "shop.example.com", expiring[0].Name)
+	assert.Equal(t, CertSourceUploaded, expiring[0].Source, "a directory listed twice keeps its first source")
+	assert.True(t, expiring[0].Expired)
+	assert.Equal(t, "soon.example.com", expiring[1].Name)
+	assert.Equal(t, CertSourceAutocert, expiring[1].Source)
+	assert.Equal(t, 5, expiring[1].DaysLeft)
+	assert.False(t, expiring[1].Expired)
+	assert.Equal(t, "old.example.com", expiring[2].Name)
+	assert.Equal(t, CertSourceCustom, expiring[2].Source)
+
+	expiring, err = scanner.Expiring(90)
+	require.NoError(t, err)
+	assert.Len(t, expiring, 4)
+}
+
+func TestCertExpiryScannerCheck(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	scanner, sender, dispatched, root := newTestCertExpiryScanner(t, now)
+	path := filepath.Join(root, "autocert", "soon.example.com")
+	writeTestCertificate(t, path, "soon.example.com", now.Add(5*24*time.Hour))
+	writeTestCertificate(t, filepath.Join(root, "ssl", "expired.example.com.crt"), "expired.example.com", now.Add(-time.Hour))
+
+	require.NoError(t, scanner.Check())
+	assert.ElementsMatch(t, []string{
+		"admin@example.com: [R-Panel] Certificate for soon.example.com expires in 5 days",
+		"admin@example.com: [R-Panel] Certificate for expired.example.com has expired",
+	}, sender.sent)
+	require.Len(t, *dispatched, 2)
+	assert.Equal(t, "expired.example.com", (*dispatched)[0].(CertificateExpiry).Name)
+
+	var logs []models.AuditLog
+	require.NoError(t, models.DB.Where("action = ?", "cert_expiring").Order("id").Find(&logs).Error)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "certificate", logs[0].Resource)
+	assert.Equal(t, "expired.example.com", logs[0].ResourceID)
+	assert.Zero(t, logs[0].UserID)
+
+	require.NoError(t, scanner.Check())
+	assert.Len(t, sender.sent, 2, "expiring certificates are reported once")
+	assert.Len(t, *dispatched, 2)
+
+	// Also after a panel restart
+	restarted := NewCertExpiryScanner(scanner.notifier, NewWebhookService(&config.Config{}), &config.Config{})
+	restarted.dirs, restarted.now, restarted.dispatch = scanner.dirs, scanner.now, scanner.dispatch
+	require.NoError(t, restarted.Check())
+	assert.Len(t, sender.sent, 2, "reported certificates are kept in the settings table")
+
+	// A renewed certificate is reported again when it nears expiry
+	writeTestCertificate(t, path, "soon.example.com", now.Add(80*24*time.Hour))
+	require.NoError(t, scanner.Check())
+	assert.Len(t, sender.sent, 2)
+	scanner.now = func() time.Time { return now.Add(70 * 24 * time.Hour) }
+	require.NoError(t, scanner.Check())
+	assert.Contains(t, sender.sent, "admin@example.com: [R-Panel] Certificate for soon.example.com expires in 10 days")
+}
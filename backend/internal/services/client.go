@@ -5,28 +5,74 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"r-panel/internal/config"
 	"r-panel/internal/models"
-	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	ErrClientNotFound   = errors.New("client not found")
 	ErrClientExists     = errors.New("client already exists")
 	ErrCustomerNoExists = errors.New("customer number already exists")
+	ErrClientNotInTrash = errors.New("client is not in trash")
+	ErrClientModified   = errors.New("client was changed by someone else since it was loaded, reload it and try again")
 )
 
 type ClientService struct {
-	authService *AuthService
+	cfg           *config.Config
+	authService   *AuthService
+	backupService *BackupService
+	homeBase      string
+
+	// Where ReconcileLinuxUsers looks for Linux users, replaced in tests
+	passwdFile      string
+	linuxUserExists func(username string) bool
 }
 
 func NewClientService(cfg *config.Config) *ClientService {
+	// Load has validated paths.home_base already
+	homeBase, err := cfg.Paths.HomeBaseDir()
+	if err != nil {
+		homeBase = config.DefaultHomeBase
+	}
 	return &ClientService{
-		authService: NewAuthService(cfg),
+		cfg:             cfg,
+		authService:     NewAuthService(cfg),
+		backupService:   NewBackupService(cfg.Paths.Backups, cfg.Backup.FreeSpaceMargin()),
+		homeBase:        homeBase,
+		passwdFile:      "/etc/passwd",
+		linuxUserExists: linuxUserExists,
+	}
+}
+
+// SetMySQLServers sets the servers the databases of backed up and restored clients live on
+func (s *ClientService) SetMySQLServers(servers *MySQLServers) {
+	s.backupService.SetMySQLServers(servers)
+}
+
+// homeDir returns the home directory of an existing client Linux user, as
+// passwd has it, falling back to <home_base>/<user>
+func (s *ClientService) homeDir(linuxUsername string) string {
+	if home, ok := linuxUserHome(linuxUsername); ok {
+		return home
+	}
+	return filepath.Join(s.homeBase, linuxUsername)
+}
+
+// newHomeDir picks the home directory of a new Linux user: <home_base>/<user>,
+// or <home_base>/resellers/<reseller>/<user> for a client of a reseller
+func (s *ClientService) newHomeDir(linuxUsername string, parentClientID uint) string {
+	if parentClientID != 0 {
+		var parent models.Client
+		if err := models.DB.Unscoped().First(&parent, parentClientID).Error; err == nil && parent.Reseller && parent.LinuxUsername != "" {
+			return filepath.Join(s.homeBase, "resellers", parent.LinuxUsername, linuxUsername)
+		}
 	}
+	return filepath.Join(s.homeBase, linuxUsername)
 }
 
 // GetClients returns all clients with preloaded User and ClientLimits
@@ -140,29 +186,15 @@ func (s *ClientService) GetClientByUserID(userID uint) (*models.Client, error) {
 	return &client, nil
 }
 
-// GenerateCustomerNo generates a unique customer number
-func (s *ClientService) GenerateCustomerNo() (string, error) {
-	var count int64
-	if err := models.DB.Model(&models.Client{}).Count(&count).Error; err != nil {
-		return "", err
-	}
-
-	customerNo := fmt.Sprintf("C%d", count+1)
-
-	// Check if it exists (shouldn't happen, but just in case)
-	var existing models.Client
-	if err := models.DB.Where("customer_no = ?", customerNo).First(&existing).Error; err == nil {
-		// If exists, try next number
-		customerNo = fmt.Sprintf("C%d", count+2)
-	}
-
-	return customerNo, nil
+// skipLinuxUser reports whether Linux user management is disabled, as in tests
+func skipLinuxUser() bool {
+	return os.Getenv("SKIP_LINUX_USER") == "true" || os.Getenv("TEST_MODE") == "true"
 }
 
 // createLinuxUser creates a Linux system user for the client
 func (s *ClientService) createLinuxUser(username string, homeDir string) error {
 	// Skip Linux user creation in test environment
-	if os.Getenv("SKIP_LINUX_USER") == "true" || os.Getenv("TEST_MODE") == "true" {
+	if skipLinuxUser() {
 		return nil
 	}
 
@@ -177,14 +209,15 @@ func (s *ClientService) createLinuxUser(username string, homeDir string) error {
 	// -m: create home directory
 	// -s /bin/bash: set shell to bash
 	// -d: specify home directory
-	args := []string{"-m", "-s", "/bin/bash"}
-	if homeDir != "" {
-		args = append(args, "-d", homeDir)
-	} else {
-		// Default home directory: /home/{username}
-		args = append(args, "-d", fmt.Sprintf("/home/%s", username))
+	if homeDir == "" {
+		// Default home directory: <home_base>/{username}
+		homeDir = filepath.Join(s.homeBase, username)
+	}
+	// useradd -m only creates the last directory, a reseller's tree may be new
+	if err := os.MkdirAll(filepath.Dir(homeDir), 0755); err != nil {
+		return fmt.Errorf("failed to create home directory base: %w", err)
 	}
-	args = append(args, username)
+	args := []string{"-m", "-s", "/bin/bash", "-d", homeDir, username}
 
 	cmd = exec.Command("useradd", args...)
 	output, err := cmd.CombinedOutput()
@@ -204,6 +237,9 @@ func (s *ClientService) deleteLinuxUser(username string) error {
 		return nil
 	}
 
+	// Look before userdel, which forgets where the home was
+	home := s.homeDir(username)
+
 	// Delete user and home directory
 	// -r: remove home directory and mail spool
 	cmd = exec.Command("userdel", "-r", username)
@@ -212,134 +248,66 @@ func (s *ClientService) deleteLinuxUser(username string) error {
 		return fmt.Errorf("failed to delete Linux user: %s", string(output))
 	}
 
+	// Drop the reseller's directory once its last client is gone
+	resellers := filepath.Join(s.homeBase, "resellers")
+	if parent := filepath.Dir(home); filepath.Dir(parent) == resellers {
+		os.Remove(parent)
+	}
+
 	return nil
 }
 
-// CreateClient creates a new User, Client, and ClientLimits
-func (s *ClientService) CreateClient(data *CreateClientData) (*models.Client, error) {
-	// Validate required fields
-	if data.Username == "" || data.Password == "" || data.Email == "" || data.ContactName == "" {
-		return nil, errors.New("username, password, email, and contact_name are required")
+// disableLinuxUser locks a Linux system user without removing its files
+func (s *ClientService) disableLinuxUser(username string) error {
+	// Check if user exists
+	cmd := exec.Command("id", username)
+	if err := cmd.Run(); err != nil {
+		// User doesn't exist, nothing to disable
+		return nil
 	}
 
-	// Check if username already exists
-	var existingUser models.User
-	if err := models.DB.Where("username = ?", data.Username).First(&existingUser).Error; err == nil {
-		return nil, ErrUserExists
+	// -L: lock the password, -e 1: expire the account so key logins fail too
+	cmd = exec.Command("usermod", "-L", "-e", "1", username)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to disable Linux user: %s", string(output))
 	}
 
-	// Check if email already exists
-	var existingClient models.Client
-	if err := models.DB.Where("email = ?", data.Email).First(&existingClient).Error; err == nil {
-		return nil, ErrClientExists
-	}
+	return nil
+}
 
-	// Generate customer number
-	customerNo, err := s.GenerateCustomerNo()
-	if err != nil {
-		return nil, err
+// enableLinuxUser reverts disableLinuxUser
+func (s *ClientService) enableLinuxUser(username string) error {
+	// Check if user exists
+	cmd := exec.Command("id", username)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Linux user '%s' does not exist", username)
 	}
 
-	// Create User with role "user"
-	user, err := s.authService.CreateUser(data.Username, data.Password, "user")
+	cmd = exec.Command("usermod", "-U", "-e", "", username)
+	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return nil, err
-	}
-
-	// Prepare Client data
-	client := &models.Client{
-		UserID:            user.ID,
-		CompanyName:       data.CompanyName,
-		VATID:             data.VATID,
-		CompanyID:         data.CompanyID,
-		Gender:            data.Gender,
-		ContactFirstname:  data.ContactFirstname,
-		ContactName:       data.ContactName,
-		Email:             data.Email,
-		Telephone:         data.Telephone,
-		Mobile:            data.Mobile,
-		Fax:               data.Fax,
-		Street:            data.Street,
-		ZIP:               data.ZIP,
-		City:              data.City,
-		State:             data.State,
-		Country:           data.Country,
-		BankAccountOwner: data.BankAccountOwner,
-		BankAccountNumber: data.BankAccountNumber,
-		BankCode:          data.BankCode,
-		BankName:          data.BankName,
-		BankAccountIBAN:   data.BankAccountIBAN,
-		BankAccountSWIFT:  data.BankAccountSWIFT,
-		CustomerNo:       customerNo,
-		Language:          data.Language,
-		UserTheme:         data.UserTheme,
-		Locked:            data.Locked,
-		Canceled:          data.Canceled,
-		AddedDate:         data.AddedDate,
-		AddedBy:           data.AddedBy,
-		Notes:             data.Notes,
-		Internet:          data.Internet,
-		PaypalEmail:       data.PaypalEmail,
-		TemplateMaster:    data.TemplateMaster,
-		TemplateAdditional: data.TemplateAdditional,
-		ParentClientID:    data.ParentClientID,
-		Reseller:          data.Reseller,
+		return fmt.Errorf("failed to enable Linux user: %s", string(output))
 	}
 
-	// Set defaults
-	if client.Language == "" {
-		client.Language = "en"
-	}
-	if client.UserTheme == "" {
-		client.UserTheme = "default"
-	}
-	if client.AddedDate.IsZero() {
-		client.AddedDate = time.Now()
-	}
+	return nil
+}
 
-	// Generate Linux username from client username (sanitize for Linux)
-	linuxUsername := strings.ToLower(data.Username)
-	// Remove invalid characters for Linux username (only allow a-z, 0-9, _, -)
-	linuxUsername = strings.Map(func(r rune) rune {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
-			return r
-		}
-		return -1
-	}, linuxUsername)
-	// Ensure username starts with a letter
-	if len(linuxUsername) > 0 && (linuxUsername[0] < 'a' || linuxUsername[0] > 'z') {
-		linuxUsername = "u" + linuxUsername
-	}
-	// Ensure minimum length
-	if len(linuxUsername) < 3 {
-		linuxUsername = linuxUsername + "123"
-	}
-	// Ensure maximum length (Linux username max is 32 chars)
-	if len(linuxUsername) > 32 {
-		linuxUsername = linuxUsername[:32]
-	}
+// CreateClient creates a new User, Client, and ClientLimits
+func (s *ClientService) CreateClient(data *CreateClientData) (*models.Client, error) {
+	data.Username = NormalizeUsername(data.Username)
 
-	// Create Linux user
-	homeDir := fmt.Sprintf("/home/%s", linuxUsername)
-	if err := s.createLinuxUser(linuxUsername, homeDir); err != nil {
-		// Rollback: delete user if Linux user creation fails
-		models.DB.Delete(user)
-		return nil, fmt.Errorf("failed to create Linux user: %w", err)
+	// Validate required fields
+	if data.Username == "" || data.Password == "" || data.Email == "" || data.ContactName == "" {
+		return nil, errors.New("username, password, email, and contact_name are required")
 	}
 
-	client.LinuxUsername = linuxUsername
-
-	// Create Client
-	if err := models.DB.Create(client).Error; err != nil {
-		// Rollback: delete Linux user and database user if client creation fails
-		s.deleteLinuxUser(linuxUsername)
-		models.DB.Delete(user)
+	if err := validateClientFields(&data.Email, &data.Country, &data.Gender); err != nil {
 		return nil, err
 	}
 
-	// Create ClientLimits
+	// Prepare ClientLimits, the client's templates override what they set
 	limits := &models.ClientLimits{
-		ClientID: client.ID,
 		WebServers: data.WebServers,
 		LimitWebDomain: data.LimitWebDomain,
 		LimitWebQuota: data.LimitWebQuota,
@@ -363,6 +331,9 @@ func (s *ClientService) CreateClient(data *CreateClientData) (*models.Client, er
 		LimitWebdavUser: data.LimitWebdavUser,
 		LimitBackup: data.LimitBackup,
 		LimitDirectiveSnippets: data.LimitDirectiveSnippets,
+		LimitWebRate: data.LimitWebRate,
+		LimitWebRateAfter: data.LimitWebRateAfter,
+		LimitWebConnections: data.LimitWebConnections,
 		MailServers: data.MailServers,
 		LimitMaildomain: data.LimitMaildomain,
 		LimitMailbox: data.LimitMailbox,
@@ -406,6 +377,10 @@ func (s *ClientService) CreateClient(data *CreateClientData) (*models.Client, er
 		LimitOpenvzVMTemplateID: data.LimitOpenvzVMTemplateID,
 	}
 
+	if err := applyClientTemplates(models.DB, limits, data.TemplateMaster, data.TemplateAdditional); err != nil {
+		return nil, err
+	}
+
 	// Set defaults for limits
 	if limits.LimitCronType == "" {
 		limits.LimitCronType = "url"
@@ -414,12 +389,117 @@ func (s *ClientService) CreateClient(data *CreateClientData) (*models.Client, er
 		limits.LimitCronFrequency = 5
 	}
 
-	if err := models.DB.Create(limits).Error; err != nil {
-		// Rollback: delete Linux user, client, and user if limits creation fails
-		if client.LinuxUsername != "" {
-			s.deleteLinuxUser(client.LinuxUsername)
+	if err := SiteBandwidthFor(*limits).Validate(); err != nil {
+		return nil, err
+	}
+
+	// Check if username already exists
+	var existingUser models.User
+	if err := models.DB.Where("LOWER(username) = ?", data.Username).First(&existingUser).Error; err == nil {
+		return nil, ErrUserExists
+	}
+
+	// Check if email already exists
+	var existingClient models.Client
+	if err := models.DB.Unscoped().Where("LOWER(email) = ?", data.Email).First(&existingClient).Error; err == nil {
+		return nil, ErrClientExists
+	}
+
+	// Refuse a client that must be jailed before anything is created
+	if wantsShellJail(*limits) && !skipLinuxUser() {
+		if err := checkJailkit(); err != nil {
+			return nil, err
 		}
-		models.DB.Delete(client)
+	}
+
+	linuxUsername, err := s.newLinuxUsername(data.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create User with role "user"
+	user, err := s.authService.CreateUser(data.Username, data.Password, "user")
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare Client data
+	client := &models.Client{
+		UserID:            user.ID,
+		CompanyName:       data.CompanyName,
+		VATID:             data.VATID,
+		CompanyID:         data.CompanyID,
+		Gender:            data.Gender,
+		ContactFirstname:  data.ContactFirstname,
+		ContactName:       data.ContactName,
+		Email:             data.Email,
+		Telephone:         data.Telephone,
+		Mobile:            data.Mobile,
+		Fax:               data.Fax,
+		Street:            data.Street,
+		ZIP:               data.ZIP,
+		City:              data.City,
+		State:             data.State,
+		Country:           data.Country,
+		BankAccountOwner: data.BankAccountOwner,
+		BankAccountNumber: data.BankAccountNumber,
+		BankCode:          data.BankCode,
+		BankName:          data.BankName,
+		BankAccountIBAN:   data.BankAccountIBAN,
+		BankAccountSWIFT:  data.BankAccountSWIFT,
+		Language:          data.Language,
+		UserTheme:         data.UserTheme,
+		Locked:            data.Locked,
+		Canceled:          data.Canceled,
+		AddedDate:         data.AddedDate,
+		AddedBy:           data.AddedBy,
+		Notes:             data.Notes,
+		Internet:          data.Internet,
+		PaypalEmail:       data.PaypalEmail,
+		TemplateMaster:    data.TemplateMaster,
+		TemplateAdditional: data.TemplateAdditional,
+		ParentClientID:    data.ParentClientID,
+		Reseller:          data.Reseller,
+	}
+
+	// Set defaults
+	if client.Language == "" {
+		client.Language = "en"
+	}
+	if client.UserTheme == "" {
+		client.UserTheme = "default"
+	}
+	if client.AddedDate.IsZero() {
+		client.AddedDate = time.Now()
+	}
+
+	// Create Linux user
+	homeDir := s.newHomeDir(linuxUsername, data.ParentClientID)
+	if err := s.createLinuxUser(linuxUsername, homeDir); err != nil {
+		// Rollback: delete user if Linux user creation fails
+		models.DB.Delete(user)
+		return nil, fmt.Errorf("failed to create Linux user: %w", err)
+	}
+
+	client.LinuxUsername = linuxUsername
+
+	// Create Client and ClientLimits, drawing the customer number in the same
+	// transaction so a failed create does not use it up
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		customerNo, err := s.nextCustomerNo(tx)
+		if err != nil {
+			return err
+		}
+		client.CustomerNo = customerNo
+		if err := tx.Create(client).Error; err != nil {
+			return err
+		}
+		limits.ClientID = client.ID
+		return tx.Create(limits).Error
+	})
+	if err != nil {
+		// Rollback: delete Linux user and database user if client creation fails
+		s.deleteLinuxUser(linuxUsername)
 		models.DB.Delete(user)
 		return nil, err
 	}
@@ -428,11 +508,19 @@ func (s *ClientService) CreateClient(data *CreateClientData) (*models.Client, er
 	client.User = *user
 	client.User.PasswordHash = ""
 
+	if err := s.applyShellJail(client.LinuxUsername, *limits); err != nil {
+		fmt.Printf("Warning: failed to jail Linux user '%s': %v\n", client.LinuxUsername, err)
+	}
+
 	return client, nil
 }
 
 // UpdateClient updates client data and limits
 func (s *ClientService) UpdateClient(id uint, data *UpdateClientData) (*models.Client, error) {
+	if err := validateClientFields(data.Email, data.Country, data.Gender); err != nil {
+		return nil, err
+	}
+
 	var client models.Client
 	if err := models.DB.Preload("User").Preload("ClientLimits").First(&client, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -463,7 +551,7 @@ func (s *ClientService) UpdateClient(id uint, data *UpdateClientData) (*models.C
 	if data.Email != nil {
 		// Check if email is already taken by another client
 		var existing models.Client
-		if err := models.DB.Where("email = ? AND id != ?", *data.Email, id).First(&existing).Error; err == nil {
+		if err := models.DB.Unscoped().Where("LOWER(email) = ? AND id != ?", *data.Email, id).First(&existing).Error; err == nil {
 			return nil, ErrClientExists
 		}
 		client.Email = *data.Email
@@ -513,7 +601,7 @@ func (s *ClientService) UpdateClient(id uint, data *UpdateClientData) (*models.C
 	if data.CustomerNo != nil {
 		// Check if customer_no is already taken
 		var existing models.Client
-		if err := models.DB.Where("customer_no = ? AND id != ?", *data.CustomerNo, id).First(&existing).Error; err == nil {
+		if err := models.DB.Unscoped().Where("customer_no = ? AND id != ?", *data.CustomerNo, id).First(&existing).Error; err == nil {
 			return nil, ErrCustomerNoExists
 		}
 		client.CustomerNo = *data.CustomerNo
@@ -545,6 +633,13 @@ func (s *ClientService) UpdateClient(id uint, data *UpdateClientData) (*models.C
 	if data.TemplateAdditional != nil {
 		client.TemplateAdditional = *data.TemplateAdditional
 	}
+	// Templates apply when a client is created or a template changes, here
+	// they only have to exist
+	if data.TemplateMaster != nil || data.TemplateAdditional != nil {
+		if _, err := clientTemplates(models.DB, client.TemplateMaster, client.TemplateAdditional); err != nil {
+			return nil, err
+		}
+	}
 	if data.ParentClientID != nil {
 		client.ParentClientID = *data.ParentClientID
 	}
@@ -552,31 +647,62 @@ func (s *ClientService) UpdateClient(id uint, data *UpdateClientData) (*models.C
 		client.Reseller = *data.Reseller
 	}
 
-	// Update client
-	if err := models.DB.Save(&client).Error; err != nil {
+	// Update client and limits together, a failing limits update must not
+	// leave the client fields half applied
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&client).Select("*").Omit("created_at", clause.Associations)
+		if data.UpdatedAt != nil {
+			// Match the row as the editor loaded it in the UPDATE itself, so a
+			// concurrent update between the check and the write is not missed
+			query = query.Where("updated_at = ?", *data.UpdatedAt)
+		}
+		result := query.Updates(&client)
+		if result.Error != nil {
+			return result.Error
+		}
+		if data.UpdatedAt != nil && result.RowsAffected == 0 {
+			return ErrClientModified
+		}
+		if data.Limits != nil {
+			return s.updateClientLimits(tx, client.ID, data.Limits)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Update limits if provided
+	// Reload with relations
+	models.DB.Preload("User").Preload("ClientLimits").First(&client, id)
+	client.User.PasswordHash = ""
+
 	if data.Limits != nil {
-		if err := s.UpdateClientLimits(client.ID, data.Limits); err != nil {
+		if err := s.applyShellJail(client.LinuxUsername, client.ClientLimits); err != nil {
 			return nil, err
 		}
-		// Reload limits
-		models.DB.Preload("ClientLimits").First(&client, id)
 	}
 
-	// Reload with relations
-	models.DB.Preload("User").Preload("ClientLimits").First(&client, id)
-	client.User.PasswordHash = ""
-
 	return &client, nil
 }
 
-// UpdateClientLimits updates only the limits for a client
+// UpdateClientLimits updates only the limits for a client and jails its Linux
+// user when the new limits ask for it
 func (s *ClientService) UpdateClientLimits(clientID uint, data *UpdateClientLimitsData) error {
+	if err := s.updateClientLimits(models.DB, clientID, data); err != nil {
+		return err
+	}
+
+	var client models.Client
+	if err := models.DB.Preload("ClientLimits").First(&client, clientID).Error; err != nil {
+		return err
+	}
+	return s.applyShellJail(client.LinuxUsername, client.ClientLimits)
+}
+
+// updateClientLimits is UpdateClientLimits using db, which may be a transaction
+func (s *ClientService) updateClientLimits(db *gorm.DB, clientID uint, data *UpdateClientLimitsData) error {
 	var limits models.ClientLimits
-	if err := models.DB.Where("client_id = ?", clientID).First(&limits).Error; err != nil {
+	if err := db.Where("client_id = ?", clientID).First(&limits).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// Create if doesn't exist
 			limits.ClientID = clientID
@@ -655,6 +781,15 @@ func (s *ClientService) UpdateClientLimits(clientID uint, data *UpdateClientLimi
 	if data.LimitDirectiveSnippets != nil {
 		limits.LimitDirectiveSnippets = *data.LimitDirectiveSnippets
 	}
+	if data.LimitWebRate != nil {
+		limits.LimitWebRate = *data.LimitWebRate
+	}
+	if data.LimitWebRateAfter != nil {
+		limits.LimitWebRateAfter = *data.LimitWebRateAfter
+	}
+	if data.LimitWebConnections != nil {
+		limits.LimitWebConnections = *data.LimitWebConnections
+	}
 	if data.MailServers != nil {
 		limits.MailServers = *data.MailServers
 	}
@@ -779,42 +914,208 @@ func (s *ClientService) UpdateClientLimits(clientID uint, data *UpdateClientLimi
 		limits.LimitOpenvzVMTemplateID = *data.LimitOpenvzVMTemplateID
 	}
 
-	return models.DB.Save(&limits).Error
+	if err := SiteBandwidthFor(limits).Validate(); err != nil {
+		return err
+	}
+
+	return db.Save(&limits).Error
 }
 
-// DeleteClient deletes a client, its limits, and the associated user
-func (s *ClientService) DeleteClient(id uint) error {
+// ClientDeletionPlan describes everything DeleteClient or PurgeClient changes for a client
+type ClientDeletionPlan struct {
+	ClientID      uint     `json:"client_id"`
+	CustomerNo    string   `json:"customer_no"`
+	Purge         bool     `json:"purge"`
+	Backup        bool     `json:"backup"`
+	LinuxUsername string   `json:"linux_username,omitempty"`
+	LimitsID      uint     `json:"limits_id,omitempty"`
+	UserID        uint     `json:"user_id,omitempty"`
+	Actions       []string `json:"actions"`
+
+	// BackupPath is set once the pre-deletion backup has been written
+	BackupPath string `json:"backup_path,omitempty"`
+
+	client *models.Client
+}
+
+// ClientDeletionOptions controls how a client is deleted
+type ClientDeletionOptions struct {
+	// Purge removes the client for good instead of moving it to the trash
+	Purge bool
+	// Backup overrides backup.before_client_delete for a purge; nil uses the config default
+	Backup *bool
+}
+
+// PlanClientDeletion builds the deletion plan for a client without changing anything.
+// Without purge the client is moved to the trash; with purge it is removed for good.
+func (s *ClientService) PlanClientDeletion(id uint, opts ClientDeletionOptions) (*ClientDeletionPlan, error) {
+	query := models.DB
+	if opts.Purge {
+		// Purge works on trashed clients as well
+		query = query.Unscoped()
+	}
+
 	var client models.Client
-	if err := models.DB.First(&client, id).Error; err != nil {
+	if err := query.Preload("ClientLimits").First(&client, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrClientNotFound
+			return nil, ErrClientNotFound
+		}
+		return nil, err
+	}
+
+	plan := &ClientDeletionPlan{
+		ClientID:      client.ID,
+		CustomerNo:    client.CustomerNo,
+		Purge:         opts.Purge,
+		LinuxUsername: client.LinuxUsername,
+		LimitsID:      client.ClientLimits.ID,
+		UserID:        client.UserID,
+		client:        &client,
+	}
+
+	if !opts.Purge {
+		plan.Actions = append(plan.Actions, fmt.Sprintf("move client #%d (%s) to trash", client.ID, client.CustomerNo))
+		if plan.LinuxUsername != "" {
+			plan.Actions = append(plan.Actions, fmt.Sprintf("disable linux user '%s'", plan.LinuxUsername))
 		}
+		return plan, nil
+	}
+
+	// Only a purge destroys data, so only a purge is backed up first
+	plan.Backup = s.cfg.Backup.BeforeClientDelete
+	if opts.Backup != nil {
+		plan.Backup = *opts.Backup
+	}
+
+	if plan.Backup {
+		plan.Actions = append(plan.Actions, fmt.Sprintf("back up client #%d (%s) to %s", client.ID, client.CustomerNo, s.cfg.Paths.Backups))
+	}
+	if plan.LimitsID != 0 {
+		plan.Actions = append(plan.Actions, fmt.Sprintf("delete client limits #%d", plan.LimitsID))
+	}
+	plan.Actions = append(plan.Actions, fmt.Sprintf("delete client #%d (%s)", client.ID, client.CustomerNo))
+	if plan.LinuxUsername != "" {
+		plan.Actions = append(plan.Actions, fmt.Sprintf("delete linux user '%s' and its home directory", plan.LinuxUsername))
+	}
+	if plan.UserID != 0 {
+		plan.Actions = append(plan.Actions, fmt.Sprintf("delete panel user #%d", plan.UserID))
+	}
+
+	return plan, nil
+}
+
+// DeleteClient moves a client to the trash and disables its Linux user
+func (s *ClientService) DeleteClient(id uint) error {
+	plan, err := s.PlanClientDeletion(id, ClientDeletionOptions{})
+	if err != nil {
 		return err
 	}
 
-	// Store Linux username before deletion
-	linuxUsername := client.LinuxUsername
+	return s.executeClientDeletion(plan)
+}
+
+// PurgeClient permanently deletes a client, its limits, the Linux user, and the associated user.
+// Unless disabled it first writes a client backup and returns the plan with its path.
+func (s *ClientService) PurgeClient(id uint, backup *bool) (*ClientDeletionPlan, error) {
+	plan, err := s.PlanClientDeletion(id, ClientDeletionOptions{Purge: true, Backup: backup})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.executeClientDeletion(plan); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// backupClient writes a full client backup ahead of a purge
+func (s *ClientService) backupClient(client *models.Client) (string, error) {
+	manifest := &ClientBackupManifest{Client: *client}
+
+	if client.UserID != 0 {
+		var user models.User
+		if err := models.DB.First(&user, client.UserID).Error; err == nil {
+			manifest.Username = user.Username
+			manifest.PasswordHash = user.PasswordHash
+			manifest.Role = user.Role
+		}
+	}
+
+	if err := models.DB.Where("client_id = ?", client.ID).Find(&manifest.Databases).Error; err != nil {
+		return "", err
+	}
+
+	homeDir := ""
+	if client.LinuxUsername != "" {
+		homeDir = s.homeDir(client.LinuxUsername)
+	}
+
+	return s.backupService.CreateClientBackup(manifest, homeDir)
+}
+
+// executeClientDeletion performs the actions described by a deletion plan
+func (s *ClientService) executeClientDeletion(plan *ClientDeletionPlan) error {
+	if !plan.Purge {
+		// Soft delete keeps limits, the panel user, and the Linux user's files,
+		// but the panel user can no longer log in and loses its sessions and API keys
+		if err := models.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(plan.client).Error; err != nil {
+				return err
+			}
+			if plan.UserID == 0 {
+				return nil
+			}
+			return disablePanelUser(tx, plan.UserID)
+		}); err != nil {
+			return err
+		}
+
+		if plan.LinuxUsername != "" {
+			if err := s.disableLinuxUser(plan.LinuxUsername); err != nil {
+				fmt.Printf("Warning: failed to disable Linux user '%s': %v\n", plan.LinuxUsername, err)
+			}
+		}
+
+		return nil
+	}
+
+	// Back up before anything is destroyed; a failed backup aborts the purge
+	if plan.Backup {
+		backupPath, err := s.backupClient(plan.client)
+		if err != nil {
+			return fmt.Errorf("backup before deletion failed: %w", err)
+		}
+		plan.BackupPath = backupPath
+	}
 
 	// Delete limits first
-	models.DB.Where("client_id = ?", id).Delete(&models.ClientLimits{})
+	models.DB.Where("client_id = ?", plan.ClientID).Delete(&models.ClientLimits{})
 
 	// Delete client
-	if err := models.DB.Delete(&client).Error; err != nil {
+	if err := models.DB.Unscoped().Delete(plan.client).Error; err != nil {
 		return err
 	}
 
 	// Delete Linux user if exists
-	if linuxUsername != "" {
-		if err := s.deleteLinuxUser(linuxUsername); err != nil {
+	if plan.LinuxUsername != "" {
+		// Look before userdel, the jail is only known from the user's home
+		jailed := shellJailed(plan.LinuxUsername)
+		jail := s.homeDir(plan.LinuxUsername)
+		if err := s.deleteLinuxUser(plan.LinuxUsername); err != nil {
 			// Log error but don't fail the deletion
 			// The Linux user might have been manually deleted
-			fmt.Printf("Warning: failed to delete Linux user '%s': %v\n", linuxUsername, err)
+			fmt.Printf("Warning: failed to delete Linux user '%s': %v\n", plan.LinuxUsername, err)
+		} else if jailed {
+			if err := removeShellJail(jail); err != nil {
+				fmt.Printf("Warning: failed to remove jail of Linux user '%s': %v\n", plan.LinuxUsername, err)
+			}
 		}
 	}
 
 	// Delete associated user
-	if client.UserID != 0 {
-		if err := models.DB.Delete(&models.User{}, client.UserID).Error; err != nil {
+	if plan.UserID != 0 {
+		if err := models.DB.Delete(&models.User{}, plan.UserID).Error; err != nil {
 			return err
 		}
 	}
@@ -822,6 +1123,73 @@ func (s *ClientService) DeleteClient(id uint) error {
 	return nil
 }
 
+// GetTrashedClients returns soft-deleted clients with preloaded User and ClientLimits
+func (s *ClientService) GetTrashedClients() ([]models.Client, error) {
+	var clients []models.Client
+	if err := models.DB.Unscoped().Preload("User").Preload("ClientLimits").
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&clients).Error; err != nil {
+		return nil, err
+	}
+
+	// Clear password hashes
+	for i := range clients {
+		if clients[i].User.ID != 0 {
+			clients[i].User.PasswordHash = ""
+		}
+	}
+
+	return clients, nil
+}
+
+// disablePanelUser refuses login to a user and revokes its sessions and API keys
+func disablePanelUser(tx *gorm.DB, userID uint) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("disabled", true).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.Session{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.APIKey{}).Error
+}
+
+// RestoreClient brings a client back from the trash and re-enables its panel
+// and Linux users. Sessions and API keys revoked by the deletion stay revoked.
+func (s *ClientService) RestoreClient(id uint) (*models.Client, error) {
+	var client models.Client
+	if err := models.DB.Unscoped().First(&client, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientNotFound
+		}
+		return nil, err
+	}
+
+	if !client.DeletedAt.Valid {
+		return nil, ErrClientNotInTrash
+	}
+
+	if err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&client).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if client.UserID == 0 {
+			return nil
+		}
+		return tx.Model(&models.User{}).Where("id = ?", client.UserID).Update("disabled", false).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	if client.LinuxUsername != "" {
+		if err := s.enableLinuxUser(client.LinuxUsername); err != nil {
+			fmt.Printf("Warning: failed to enable Linux user '%s': %v\n", client.LinuxUsername, err)
+		}
+	}
+
+	return s.GetClient(id)
+}
+
 // Data structures for service methods
 
 type CreateClientData struct {
@@ -889,6 +1257,9 @@ type CreateClientData struct {
 	LimitWebdavUser       int
 	LimitBackup           bool
 	LimitDirectiveSnippets bool
+	LimitWebRate          int
+	LimitWebRateAfter     int
+	LimitWebConnections   int
 	MailServers           models.StringArray
 	LimitMaildomain       int
 	LimitMailbox          int
@@ -967,6 +1338,9 @@ type UpdateClientData struct {
 	ParentClientID     *uint
 	Reseller           *bool
 	Limits             *UpdateClientLimitsData
+	// UpdatedAt is the updated_at of the client as the editor loaded it, the
+	// update fails with ErrClientModified if the stored row no longer has it
+	UpdatedAt          *time.Time
 }
 
 type UpdateClientLimitsData struct {
@@ -993,6 +1367,9 @@ type UpdateClientLimitsData struct {
 	LimitWebdavUser       *int
 	LimitBackup           *bool
 	LimitDirectiveSnippets *bool
+	LimitWebRate          *int
+	LimitWebRateAfter     *int
+	LimitWebConnections   *int
 	MailServers           *models.StringArray
 	LimitMaildomain       *int
 	LimitMailbox          *int
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"r-panel/internal/models"
+)
+
+// clientBackupVersion is bumped when the layout of client backups changes
+const clientBackupVersion = 1
+
+// Entries inside a client backup archive
+const (
+	clientBackupManifest = "client.json"
+	clientBackupHomeDir  = "home"
+	clientBackupDBDir    = "databases"
+)
+
+// ClientBackupManifest is stored as client.json in a client backup and holds
+// everything needed to recreate the account
+type ClientBackupManifest struct {
+	Version      int                     `json:"version"`
+	CreatedAt    time.Time               `json:"created_at"`
+	Client       models.Client           `json:"client"`
+	Username     string                  `json:"username"`
+	PasswordHash string                  `json:"password_hash"`
+	Role         string                  `json:"role"`
+	Databases    []models.ClientDatabase `json:"databases"`
+}
+
+// CreateClientBackup writes a tar.gz with the client manifest, the client's home
+// directory (if present) and a dump of each client database
+func (s *BackupService) CreateClientBackup(manifest *ClientBackupManifest, homeDir string) (string, error) {
+	manifest.Version = clientBackupVersion
+	manifest.CreatedAt = time.Now()
+
+	// Never reuse the name of an existing backup, a failed write removes the file.
+	// Only root may read it, the manifest holds the password hash.
+	base := fmt.Sprintf("client_%s_%d", manifest.Client.CustomerNo, manifest.CreatedAt.Unix())
+	outputPath := filepath.Join(s.backupsPath, base+".tar.gz")
+	file, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	for i := 2; errors.Is(err, os.ErrExist); i++ {
+		outputPath = filepath.Join(s.backupsPath, fmt.Sprintf("%s_%d.tar.gz", base, i))
+		file, err = os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+
+	if err := s.writeClientBackup(file, manifest, homeDir); err != nil {
+		os.Remove(outputPath)
+		return "", err
+	}
+
+	return outputPath, nil
+}
+
+func (s *BackupService) writeClientBackup(file *os.File, manifest *ClientBackupManifest, homeDir string) error {
+	defer file.Close()
+
+	gzWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzWriter)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode client manifest: %w", err)
+	}
+	if err := addBytesToTar(tarWriter, clientBackupManifest, data); err != nil {
+		return fmt.Errorf("failed to write client manifest: %w", err)
+	}
+
+	if homeDir != "" {
+		if info, err := os.Stat(homeDir); err == nil && info.IsDir() {
+			if err := addDirToTar(tarWriter, homeDir, clientBackupHomeDir); err != nil {
+				return fmt.Errorf("failed to archive home directory: %w", err)
+			}
+		}
+	}
+
+	for _, database := range manifest.Databases {
+		server, err := s.mysqlServer(database.Server)
+		if err != nil {
+			return fmt.Errorf("failed to dump database %s: %w", database.Name, err)
+		}
+		cmd := server.cliCommand(context.Background(), server.mysqldump, "--single-transaction", "--routines", "--triggers", database.Name)
+		dump, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("failed to dump database %s: %w", database.Name, err)
+		}
+		if err := addBytesToTar(tarWriter, filepath.ToSlash(filepath.Join(clientBackupDBDir, database.Name+".sql")), dump); err != nil {
+			return fmt.Errorf("failed to archive database %s: %w", database.Name, err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gzWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return file.Close()
+}
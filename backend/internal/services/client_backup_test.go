@@ -0,0 +1,116 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readClientBackupManifest returns the client.json manifest from a client backup archive
+func readClientBackupManifest(t *testing.T, path string) *ClientBackupManifest {
+	t.Helper()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if header.Name == clientBackupManifest {
+			var manifest ClientBackupManifest
+			require.NoError(t, json.NewDecoder(tr).Decode(&manifest))
+			return &manifest
+		}
+	}
+
+	t.Fatalf("%s not found in %s", clientBackupManifest, path)
+	return nil
+}
+
+func createPurgeTestClient(t *testing.T, service *ClientService, username string) *models.Client {
+	t.Helper()
+	client, err := service.CreateClient(&CreateClientData{
+		Username:    username,
+		Password:    "testpass123",
+		ContactName: "Purge Client",
+		Email:       username + "@example.com",
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestPurgeClientBacksUpFirst(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	cfg.Backup.BeforeClientDelete = true
+	service := NewClientService(cfg)
+
+	t.Run("backup produced before deletion", func(t *testing.T) {
+		client := createPurgeTestClient(t, service, "purgeme")
+
+		plan, err := service.PurgeClient(client.ID, nil)
+		require.NoError(t, err)
+		require.True(t, plan.Backup)
+		require.FileExists(t, plan.BackupPath)
+
+		manifest := readClientBackupManifest(t, plan.BackupPath)
+		assert.Equal(t, client.CustomerNo, manifest.Client.CustomerNo)
+		assert.Equal(t, "purgeme", manifest.Username)
+		assert.NotEmpty(t, manifest.PasswordHash)
+
+		_, err = service.GetClient(client.ID)
+		assert.ErrorIs(t, err, ErrClientNotFound)
+	})
+
+	t.Run("per-request override skips the backup", func(t *testing.T) {
+		client := createPurgeTestClient(t, service, "nobackup")
+
+		skip := false
+		plan, err := service.PurgeClient(client.ID, &skip)
+		require.NoError(t, err)
+		assert.False(t, plan.Backup)
+		assert.Empty(t, plan.BackupPath)
+	})
+
+	t.Run("failed backup aborts the purge", func(t *testing.T) {
+		client := createPurgeTestClient(t, service, "keepme")
+
+		// A client database whose dump cannot succeed
+		require.NoError(t, models.DB.Create(&models.ClientDatabase{
+			ClientID: client.ID,
+			Name:     "keepme_missing_db",
+			Username: "keepme",
+			Host:     "localhost",
+		}).Error)
+		t.Setenv("PATH", t.TempDir())
+
+		before, err := os.ReadDir(cfg.Paths.Backups)
+		require.NoError(t, err)
+
+		_, err = service.PurgeClient(client.ID, nil)
+		require.Error(t, err)
+
+		_, err = service.GetClient(client.ID)
+		assert.NoError(t, err, "client must survive a failed backup")
+
+		after, err := os.ReadDir(cfg.Paths.Backups)
+		require.NoError(t, err)
+		assert.Equal(t, len(before), len(after), "partial backup left behind")
+	})
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidBulkAction = errors.New("invalid bulk action")
+
+// Actions BulkAction can apply to clients
+const (
+	BulkActionLock   = "lock"
+	BulkActionUnlock = "unlock"
+	BulkActionCancel = "cancel"
+	BulkActionDelete = "delete"
+)
+
+// MaxBulkClients is how many clients one BulkAction call may change
+const MaxBulkClients = 500
+
+// BulkActionResult is the outcome of a bulk action for one client
+type BulkActionResult struct {
+	ClientID uint   `json:"client_id"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BulkAction applies action to each client in ids, in order and each on its
+// own, so one failing client does not stop the others. Lock, unlock and cancel
+// set the client's flags; delete moves the client to the trash like
+// DeleteClient. Repeated ids are only acted on once.
+func (s *ClientService) BulkAction(action string, ids []uint) ([]BulkActionResult, error) {
+	var apply func(id uint) error
+	setFlag := func(locked, canceled *bool) func(id uint) error {
+		return func(id uint) error {
+			_, err := s.UpdateClient(id, &UpdateClientData{Locked: locked, Canceled: canceled})
+			return err
+		}
+	}
+	yes, no := true, false
+	switch action {
+	case BulkActionLock:
+		apply = setFlag(&yes, nil)
+	case BulkActionUnlock:
+		apply = setFlag(&no, nil)
+	case BulkActionCancel:
+		apply = setFlag(nil, &yes)
+	case BulkActionDelete:
+		apply = s.DeleteClient
+	default:
+		return nil, fmt.Errorf("%w %q: use lock, unlock, cancel or delete", ErrInvalidBulkAction, action)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no client ids given", ErrInvalidBulkAction)
+	}
+	if len(ids) > MaxBulkClients {
+		return nil, fmt.Errorf("%w: at most %d clients at once", ErrInvalidBulkAction, MaxBulkClients)
+	}
+
+	results := []BulkActionResult{}
+	seen := map[uint]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := BulkActionResult{ClientID: id, Success: true}
+		if err := apply(id); err != nil {
+			result = BulkActionResult{ClientID: id, Error: err.Error()}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDatabaseLimitReached     = errors.New("database limit reached for this client")
+	ErrDatabaseUserLimitReached = errors.New("database user limit reached for this client")
+	ErrInvalidDatabaseName      = errors.New("database name may only contain lowercase letters, digits and underscores")
+	ErrDatabaseExists           = errors.New("database already exists")
+	ErrDatabaseServerNotAllowed = errors.New("client is not allowed to use this database server")
+)
+
+const (
+	maxMySQLDatabaseName = 64
+	maxMySQLUsername     = 32
+	clientDatabaseHost   = "localhost"
+	generatedPasswordLen = 20
+)
+
+var databaseNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// ClientDatabaseService provisions MySQL databases for clients within their limits
+type ClientDatabaseService struct {
+	servers *MySQLServers
+}
+
+// ClientDatabaseCredentials is returned once when a client database is created
+type ClientDatabaseCredentials struct {
+	Database string `json:"database"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Host     string `json:"host"`
+	Server   string `json:"server"`
+}
+
+func NewClientDatabaseService(servers *MySQLServers) *ClientDatabaseService {
+	return &ClientDatabaseService{
+		servers: servers,
+	}
+}
+
+// GetClientDatabases returns the databases provisioned for a client
+func (s *ClientDatabaseService) GetClientDatabases(clientID uint) ([]models.ClientDatabase, error) {
+	var databases []models.ClientDatabase
+	if err := models.DB.Where("client_id = ?", clientID).Order("name").Find(&databases).Error; err != nil {
+		return nil, err
+	}
+	return databases, nil
+}
+
+// CreateClientDatabase creates a database prefixed with the client's customer number,
+// a dedicated user with full privileges on it, and records both for limit accounting.
+// server defaults to the first of the client's db_servers, or the primary server when
+// the client has none; a client with db_servers may only use those.
+func (s *ClientDatabaseService) CreateClientDatabase(clientID uint, name, server string) (*ClientDatabaseCredentials, error) {
+	var client models.Client
+	if err := models.DB.Preload("ClientLimits").First(&client, clientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientNotFound
+		}
+		return nil, err
+	}
+
+	allowed := client.ClientLimits.DBServers
+	if server == "" {
+		server = config.PrimaryMySQLServer
+		if len(allowed) > 0 {
+			server = allowed[0]
+		}
+	}
+	if len(allowed) > 0 && !slices.Contains(allowed, server) {
+		return nil, fmt.Errorf("%w: %s", ErrDatabaseServerNotAllowed, server)
+	}
+	mysqlService, err := s.servers.Get(server)
+	if err != nil {
+		return nil, err
+	}
+	host := s.servers.clientHost(server)
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !databaseNamePattern.MatchString(name) {
+		return nil, ErrInvalidDatabaseName
+	}
+
+	dbName := strings.ToLower(client.CustomerNo) + "_" + name
+	if len(dbName) > maxMySQLDatabaseName {
+		return nil, fmt.Errorf("database name '%s' exceeds %d characters", dbName, maxMySQLDatabaseName)
+	}
+	username := dbName
+	if len(username) > maxMySQLUsername {
+		username = username[:maxMySQLUsername]
+	}
+
+	// Check current usage against limits; every database gets exactly one user
+	var count int64
+	if err := models.DB.Model(&models.ClientDatabase{}).Where("client_id = ?", clientID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if limitReached(client.ClientLimits.LimitDatabase, count) {
+		return nil, ErrDatabaseLimitReached
+	}
+	if limitReached(client.ClientLimits.LimitDatabaseUser, count) {
+		return nil, ErrDatabaseUserLimitReached
+	}
+
+	var existing models.ClientDatabase
+	if err := models.DB.Where("name = ? OR username = ?", dbName, username).First(&existing).Error; err == nil {
+		return nil, ErrDatabaseExists
+	}
+
+	// The route cannot check the server, it is only known here
+	if err := mysqlService.EnsureConnected(); err != nil {
+		return nil, err
+	}
+
+	password, err := generatePassword(generatedPasswordLen)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mysqlService.CreateDatabase(dbName); err != nil {
+		return nil, fmt.Errorf("failed to create database: %w", err)
+	}
+
+	if err := mysqlService.CreateUser(username, password, host); err != nil {
+		mysqlService.DeleteDatabase(dbName)
+		return nil, fmt.Errorf("failed to create database user: %w", err)
+	}
+
+	if err := mysqlService.GrantPrivileges(username, host, dbName, "ALL PRIVILEGES"); err != nil {
+		mysqlService.DeleteUser(username, host)
+		mysqlService.DeleteDatabase(dbName)
+		return nil, fmt.Errorf("failed to grant privileges: %w", err)
+	}
+
+	record := models.ClientDatabase{
+		ClientID: clientID,
+		Name:     dbName,
+		Username: username,
+		Host:     host,
+		Server:   server,
+	}
+	if err := models.DB.Create(&record).Error; err != nil {
+		mysqlService.DeleteUser(username, host)
+		mysqlService.DeleteDatabase(dbName)
+		return nil, err
+	}
+
+	return &ClientDatabaseCredentials{
+		Database: dbName,
+		Username: username,
+		Password: password,
+		Host:     host,
+		Server:   server,
+	}, nil
+}
+
+// limitReached reports whether used has reached limit; -1 means unlimited
+func limitReached(limit int, used int64) bool {
+	return limit >= 0 && used >= int64(limit)
+}
+
+// generatePassword returns a random alphanumeric password of length n
+func generatePassword(n int) (string, error) {
+	const alphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		b[i] = alphabet[idx.Int64()]
+	}
+	return string(b), nil
+}
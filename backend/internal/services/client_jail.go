@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"slices"
+	"strings"
+
+	"r-panel/internal/models"
+)
+
+var ErrJailkitNotInstalled = errors.New("jailkit is not installed, install it to jail shell users")
+
+// jailkitSections are the jk_init sections a jailed shell user gets
+var jailkitSections = []string{"basicshell", "editors", "extendedshell", "netutils", "ssh", "sftp", "scp"}
+
+// jailRequired reports whether limits only allow jailed shell logins:
+// ssh_chroot lists the chroot modes a client may use, and "no" is not one of them
+func jailRequired(limits models.ClientLimits) bool {
+	return len(limits.SSHChroot) > 0 && !slices.Contains(limits.SSHChroot, "no")
+}
+
+// wantsShellJail reports whether the client's Linux user belongs in a jailkit
+// chroot: it has shell access and jailkit is the only way it may log in
+func wantsShellJail(limits models.ClientLimits) bool {
+	return limits.LimitShellUser != 0 && jailRequired(limits) && slices.Contains(limits.SSHChroot, "jailkit")
+}
+
+// checkJailkit fails with ErrJailkitNotInstalled unless the jailkit tools are on PATH
+func checkJailkit() error {
+	for _, tool := range []string{"jk_init", "jk_jailuser"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return fmt.Errorf("%w: %s not found", ErrJailkitNotInstalled, tool)
+		}
+	}
+	return nil
+}
+
+// shellJailed reports whether username lives in a jail, jk_jailuser points its
+// home at <jail>/./home/<user>
+func shellJailed(username string) bool {
+	account, err := user.Lookup(username)
+	return err == nil && strings.Contains(account.HomeDir, "/./")
+}
+
+// applyShellJail jails the client's Linux user when limits ask for it and it
+// is not jailed yet. The jail is the user's home directory, so their sites stay
+// reachable from inside it. Lifting a jail when the limits change is left to
+// the admin. Skipped in tests like Linux user creation.
+func (s *ClientService) applyShellJail(username string, limits models.ClientLimits) error {
+	if username == "" || skipLinuxUser() || !wantsShellJail(limits) || shellJailed(username) {
+		return nil
+	}
+	if err := checkJailkit(); err != nil {
+		return err
+	}
+
+	jail := s.homeDir(username)
+	// The chroot must be owned by root and writable by nobody else
+	if err := os.Chown(jail, 0, 0); err != nil {
+		return fmt.Errorf("failed to prepare jail: %w", err)
+	}
+	if err := os.Chmod(jail, 0755); err != nil {
+		return fmt.Errorf("failed to prepare jail: %w", err)
+	}
+
+	args := append([]string{"-j", jail}, jailkitSections...)
+	if output, err := exec.Command("jk_init", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to initialize jail: %s", strings.TrimSpace(string(output)))
+	}
+	if output, err := exec.Command("jk_jailuser", "-n", "-s", "/bin/bash", "-j", jail, username).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to jail Linux user: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// removeShellJail deletes the jail of a Linux user that was jailed before it
+// was deleted. userdel -r only removes the home inside the jail.
+func removeShellJail(jail string) error {
+	return os.RemoveAll(jail)
+}
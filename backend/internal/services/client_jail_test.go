@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWantsShellJail(t *testing.T) {
+	tests := []struct {
+		shellUsers int
+		chroot     models.StringArray
+		want       bool
+	}{
+		{1, models.StringArray{"jailkit"}, true},
+		{-1, models.StringArray{"jailkit"}, true},
+		{0, models.StringArray{"jailkit"}, false},
+		{1, models.StringArray{"no", "jailkit"}, false},
+		{1, models.StringArray{}, false},
+	}
+	for _, tt := range tests {
+		limits := models.ClientLimits{LimitShellUser: tt.shellUsers, SSHChroot: tt.chroot}
+		assert.Equal(t, tt.want, wantsShellJail(limits), "%d %v", tt.shellUsers, tt.chroot)
+	}
+}
+
+func TestCheckJailkitNeedsTheTools(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	assert.ErrorIs(t, checkJailkit(), ErrJailkitNotInstalled)
+}
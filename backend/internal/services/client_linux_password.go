@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"r-panel/internal/models"
+)
+
+var (
+	ErrNoLinuxUser   = errors.New("client has no Linux user")
+	ErrNoShellAccess = errors.New("client has no shell access, raise limit_shell_user first")
+	ErrShellJailOnly = errors.New("client may only log in through a jailkit chroot, which is not set up")
+)
+
+// checkShellAccess fails unless the limits of client let it log in over SSH,
+// which for a client that may only log in jailed takes a jail
+func checkShellAccess(client *models.Client) error {
+	if client.ClientLimits.LimitShellUser == 0 {
+		return ErrNoShellAccess
+	}
+	if jailRequired(client.ClientLimits) && !shellJailed(client.LinuxUsername) {
+		return ErrShellJailOnly
+	}
+	return nil
+}
+
+// SetLinuxPassword sets the password of a client's Linux user, which clients
+// with shell access use for SSH and SFTP
+func (s *ClientService) SetLinuxPassword(ctx context.Context, id uint, password string) (*models.Client, error) {
+	if len(password) < MinPasswordLength {
+		return nil, fmt.Errorf("%w: use at least %d characters", ErrWeakPassword, MinPasswordLength)
+	}
+	// chpasswd reads one user:password pair per line
+	if strings.ContainsAny(password, "\r\n") {
+		return nil, fmt.Errorf("%w: line breaks are not allowed", ErrWeakPassword)
+	}
+
+	client, err := s.GetClient(id)
+	if err != nil {
+		return nil, err
+	}
+	if client.LinuxUsername == "" {
+		return nil, ErrNoLinuxUser
+	}
+	if err := checkShellAccess(client); err != nil {
+		return nil, err
+	}
+
+	// Skip in test environment, like Linux user creation
+	if skipLinuxUser() {
+		return client, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "chpasswd")
+	cmd.Stdin = strings.NewReader(client.LinuxUsername + ":" + password + "\n")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to set Linux password: %s", strings.TrimSpace(string(output)))
+	}
+	return client, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLinuxPassword(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	service := NewClientService(cfg)
+	client := createPurgeTestClient(t, service, "shelluser")
+	ctx := context.Background()
+
+	_, err := service.SetLinuxPassword(ctx, client.ID, "short")
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	_, err = service.SetLinuxPassword(ctx, client.ID, "secret123\nroot:owned")
+	assert.ErrorIs(t, err, ErrWeakPassword, "a line break would set a second user's password")
+	_, err = service.SetLinuxPassword(ctx, client.ID, "secret123")
+	assert.ErrorIs(t, err, ErrNoShellAccess)
+	_, err = service.SetLinuxPassword(ctx, 999999, "secret123")
+	assert.ErrorIs(t, err, ErrClientNotFound)
+
+	require.NoError(t, models.DB.Model(&models.ClientLimits{}).Where("client_id = ?", client.ID).Update("limit_shell_user", 1).Error)
+	updated, err := service.SetLinuxPassword(ctx, client.ID, "secret123")
+	require.NoError(t, err)
+	assert.Equal(t, "shelluser", updated.LinuxUsername)
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"r-panel/internal/models"
+)
+
+// Kinds of LinuxUserMismatch
+const (
+	LinuxUserMissing = "missing_user" // a client's Linux user does not exist
+	LinuxUserOrphan  = "orphan_user"  // a Linux user under home_base belongs to no client
+)
+
+// Regular Linux users have UIDs from minRegularUID, nobody excluded
+const (
+	minRegularUID = 1000
+	nobodyUID     = 65534
+)
+
+// LinuxUserMismatch is a difference between the clients and the Linux users
+type LinuxUserMismatch struct {
+	Kind          string `json:"kind"`
+	LinuxUsername string `json:"linux_username"`
+	ClientID      uint   `json:"client_id,omitempty"`
+	Trashed       bool   `json:"trashed,omitempty"` // the client is in the trash
+	Home          string `json:"home,omitempty"`    // home directory of an orphan user
+}
+
+// LinuxUserReconciliation reports how the clients' Linux users drifted
+type LinuxUserReconciliation struct {
+	Checked    int                 `json:"checked"` // clients with a Linux user
+	Mismatches []LinuxUserMismatch `json:"mismatches"`
+	Recreated  []string            `json:"recreated,omitempty"`
+	Failed     map[string]string   `json:"failed,omitempty"` // Linux users that could not be recreated, with the reason
+}
+
+// linuxUserExists checks a Linux user with id, which also sees users of
+// directory services passwd does not list
+func linuxUserExists(username string) bool {
+	return exec.Command("id", username).Run() == nil
+}
+
+// ReconcileLinuxUsers compares the clients with the Linux users of the host.
+// It reports clients, trashed ones included, whose Linux user was removed
+// outside the panel, and regular users with a home under home_base that no
+// client owns, such as the leftovers of a client deleted by hand. Nothing is
+// changed, see RecreateMissingLinuxUsers.
+func (s *ClientService) ReconcileLinuxUsers() (*LinuxUserReconciliation, error) {
+	var clients []models.Client
+	if err := models.DB.Unscoped().Where("linux_username <> ''").Order("id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+
+	result := &LinuxUserReconciliation{Checked: len(clients), Mismatches: []LinuxUserMismatch{}}
+	owned := make(map[string]bool, len(clients))
+	for _, client := range clients {
+		owned[client.LinuxUsername] = true
+		if !s.linuxUserExists(client.LinuxUsername) {
+			result.Mismatches = append(result.Mismatches, LinuxUserMismatch{
+				Kind:          LinuxUserMissing,
+				LinuxUsername: client.LinuxUsername,
+				ClientID:      client.ID,
+				Trashed:       client.DeletedAt.Valid,
+			})
+		}
+	}
+
+	orphans, err := s.homeBaseUsers()
+	if err != nil {
+		return nil, err
+	}
+	for _, orphan := range orphans {
+		if !owned[orphan.LinuxUsername] {
+			result.Mismatches = append(result.Mismatches, orphan)
+		}
+	}
+	return result, nil
+}
+
+// homeBaseUsers returns the regular users of the passwd file whose home lies
+// under home_base, sorted by name
+func (s *ClientService) homeBaseUsers() ([]LinuxUserMismatch, error) {
+	f, err := os.Open(s.passwdFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Linux users: %w", err)
+	}
+	defer f.Close()
+
+	users := []LinuxUserMismatch{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// name:password:uid:gid:gecos:home:shell
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 7 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil || uid < minRegularUID || uid == nobodyUID {
+			continue
+		}
+		// A jailed user's home is <jail>/./home/<user>, the jail is what counts
+		home, _, _ := strings.Cut(fields[5], "/./")
+		home = filepath.Clean(home)
+		if rel, err := filepath.Rel(s.homeBase, home); err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		users = append(users, LinuxUserMismatch{Kind: LinuxUserOrphan, LinuxUsername: fields[0], Home: home})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Linux users: %w", err)
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].LinuxUsername < users[j].LinuxUsername })
+	return users, nil
+}
+
+// RecreateMissingLinuxUsers creates the Linux users of clients that lost
+// theirs, then reports what is still out of sync. Trashed clients are left
+// alone, their users come back on restore. A home directory that survived is
+// reused and handed to the new user, its UID may differ from the old one.
+// The user is locked again for a locked client and jailed when the plan says
+// so. Orphan users are only reported, deleting them is left to the admin.
+func (s *ClientService) RecreateMissingLinuxUsers() (*LinuxUserReconciliation, error) {
+	before, err := s.ReconcileLinuxUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	var recreated []string
+	failed := map[string]string{}
+	for _, mismatch := range before.Mismatches {
+		if mismatch.Kind != LinuxUserMissing || mismatch.Trashed {
+			continue
+		}
+		var client models.Client
+		if err := models.DB.Preload("ClientLimits").First(&client, mismatch.ClientID).Error; err != nil {
+			failed[mismatch.LinuxUsername] = err.Error()
+			continue
+		}
+		if err := s.recreateLinuxUser(&client); err != nil {
+			failed[mismatch.LinuxUsername] = err.Error()
+			continue
+		}
+		recreated = append(recreated, client.LinuxUsername)
+	}
+
+	result, err := s.ReconcileLinuxUsers()
+	if err != nil {
+		return nil, err
+	}
+	result.Recreated = recreated
+	if len(failed) > 0 {
+		result.Failed = failed
+	}
+	return result, nil
+}
+
+// recreateLinuxUser creates a client's Linux user again as CreateClient did
+func (s *ClientService) recreateLinuxUser(client *models.Client) error {
+	username := client.LinuxUsername
+	home := s.newHomeDir(username, client.ParentClientID)
+	_, statErr := os.Stat(home)
+
+	if err := s.createLinuxUser(username, home); err != nil {
+		return err
+	}
+	if statErr == nil && !skipLinuxUser() {
+		// user: is the user and its login group
+		if output, err := exec.Command("chown", "-R", username+":", home).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to hand %s to %s: %s", home, username, strings.TrimSpace(string(output)))
+		}
+	}
+	if client.Locked {
+		if err := s.disableLinuxUser(username); err != nil {
+			return err
+		}
+	}
+	return s.applyShellJail(username, client.ClientLimits)
+}
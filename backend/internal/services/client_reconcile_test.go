@@ -0,0 +1,53 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileLinuxUsers(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	service := NewClientService(cfg)
+	alice := createPurgeTestClient(t, service, "alice")
+	bob := createPurgeTestClient(t, service, "bob")
+	carol := createPurgeTestClient(t, service, "carol")
+	require.NoError(t, service.DeleteClient(carol.ID))
+
+	existing := map[string]bool{alice.LinuxUsername: true}
+	service.linuxUserExists = func(username string) bool { return existing[username] }
+	service.passwdFile = filepath.Join(t.TempDir(), "passwd")
+	passwd := "root:x:0:0:root:/root:/bin/bash\n" +
+		alice.LinuxUsername + ":x:1001:1001::/home/" + alice.LinuxUsername + ":/bin/bash\n" +
+		"dave:x:1002:1002::/home/dave:/bin/bash\n" +
+		"erin:x:1003:1003::/home/erin/./home/erin:/bin/bash\n" +
+		"resold:x:1005:1005::/home/resellers/acme/resold:/bin/bash\n" +
+		"deploy:x:1004:1004::/srv/deploy:/bin/bash\n" +
+		"nobody:x:65534:65534:nobody:/home:/usr/sbin/nologin\n"
+	require.NoError(t, os.WriteFile(service.passwdFile, []byte(passwd), 0644))
+
+	result, err := service.ReconcileLinuxUsers()
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Checked)
+	assert.Equal(t, []LinuxUserMismatch{
+		{Kind: LinuxUserMissing, LinuxUsername: bob.LinuxUsername, ClientID: bob.ID},
+		{Kind: LinuxUserMissing, LinuxUsername: carol.LinuxUsername, ClientID: carol.ID, Trashed: true},
+		{Kind: LinuxUserOrphan, LinuxUsername: "dave", Home: "/home/dave"},
+		{Kind: LinuxUserOrphan, LinuxUsername: "erin", Home: "/home/erin"},
+		{Kind: LinuxUserOrphan, LinuxUsername: "resold", Home: "/home/resellers/acme/resold"},
+	}, result.Mismatches)
+
+	// Only clients that are not in the trash get their user back
+	result, err = service.RecreateMissingLinuxUsers()
+	require.NoError(t, err)
+	assert.Equal(t, []string{bob.LinuxUsername}, result.Recreated)
+	assert.Empty(t, result.Failed)
+
+	service.passwdFile = filepath.Join(t.TempDir(), "missing")
+	_, err = service.ReconcileLinuxUsers()
+	assert.Error(t, err)
+}
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidClientBackup     = errors.New("not a valid client backup")
+	ErrInvalidConflictStrategy = errors.New("unknown conflict strategy")
+)
+
+// Ways to resolve a clash between a restored client and an existing account
+const (
+	RestoreConflictFail   = "fail"
+	RestoreConflictRename = "rename"
+)
+
+// ClientRestoreOptions controls how a client backup is restored
+type ClientRestoreOptions struct {
+	// OnConflict is "fail" (default) or "rename". Rename picks a free username,
+	// Linux username and customer number; a clashing email always fails.
+	OnConflict string
+}
+
+// ClientRestoreResult describes a client recreated from a backup. Database
+// passwords are not part of a backup, so restored databases get new ones.
+type ClientRestoreResult struct {
+	Client    *models.Client              `json:"client"`
+	Renamed   bool                        `json:"renamed"`
+	Databases []ClientDatabaseCredentials `json:"databases"`
+}
+
+// clientRestore is the resolved identity of a client being restored and what
+// has been created for it so far, so a failed restore can be rolled back
+type clientRestore struct {
+	manifest      *ClientBackupManifest
+	username      string
+	linuxUsername string
+	customerNo    string
+	renamed       bool
+
+	// databases maps the name in the backup to the database to create
+	databases map[string]models.ClientDatabase
+
+	homeDir          string
+	homeExisted      bool
+	linuxUserCreated bool
+	created          []models.ClientDatabase
+	credentials      []ClientDatabaseCredentials
+}
+
+// RestoreClientBackup recreates a client, its panel user, limits, home directory
+// and databases from a backup written by CreateClientBackup
+func (s *ClientService) RestoreClientBackup(archivePath string, opts ClientRestoreOptions) (*ClientRestoreResult, error) {
+	switch opts.OnConflict {
+	case "":
+		opts.OnConflict = RestoreConflictFail
+	case RestoreConflictFail, RestoreConflictRename:
+	default:
+		return nil, fmt.Errorf("%w '%s', use '%s' or '%s'", ErrInvalidConflictStrategy, opts.OnConflict, RestoreConflictFail, RestoreConflictRename)
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClientBackup, err)
+	}
+	defer gzReader.Close()
+	tarReader := tar.NewReader(gzReader)
+
+	manifest, err := readClientManifest(tarReader)
+	if err != nil {
+		return nil, err
+	}
+
+	restore, err := s.resolveClientRestore(manifest, opts.OnConflict == RestoreConflictRename)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.runClientRestore(restore, tarReader)
+	if err != nil {
+		s.rollbackClientRestore(restore)
+		return nil, err
+	}
+
+	return &ClientRestoreResult{
+		Client:    client,
+		Renamed:   restore.renamed,
+		Databases: restore.credentials,
+	}, nil
+}
+
+// readClientManifest reads client.json, which CreateClientBackup writes as the first entry
+func readClientManifest(tarReader *tar.Reader) (*ClientBackupManifest, error) {
+	header, err := tarReader.Next()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClientBackup, err)
+	}
+	if header.Name != clientBackupManifest {
+		return nil, fmt.Errorf("%w: %s is missing", ErrInvalidClientBackup, clientBackupManifest)
+	}
+
+	var manifest ClientBackupManifest
+	if err := json.NewDecoder(tarReader).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClientBackup, err)
+	}
+
+	if manifest.Version < 1 || manifest.Version > clientBackupVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidClientBackup, manifest.Version)
+	}
+	if manifest.Username == "" || manifest.PasswordHash == "" {
+		return nil, fmt.Errorf("%w: panel user is missing", ErrInvalidClientBackup)
+	}
+	// A client backup only ever holds a client account
+	if manifest.Role != "" && manifest.Role != models.RoleUser {
+		return nil, fmt.Errorf("%w: role '%s' is not a client role", ErrInvalidClientBackup, manifest.Role)
+	}
+
+	return &manifest, nil
+}
+
+// resolveClientRestore checks the backup against existing accounts and, when
+// rename is set, picks free names instead of failing
+func (s *ClientService) resolveClientRestore(manifest *ClientBackupManifest, rename bool) (*clientRestore, error) {
+	restore := &clientRestore{
+		manifest:      manifest,
+		username:      NormalizeUsername(manifest.Username),
+		linuxUsername: manifest.Client.LinuxUsername,
+		customerNo:    manifest.Client.CustomerNo,
+		databases:     map[string]models.ClientDatabase{},
+	}
+
+	var existingClient models.Client
+	if err := models.DB.Unscoped().Where("LOWER(email) = ?", NormalizeEmail(manifest.Client.Email)).First(&existingClient).Error; err == nil {
+		return nil, fmt.Errorf("%w: email '%s' belongs to client #%d", ErrClientExists, manifest.Client.Email, existingClient.ID)
+	}
+
+	usernameTaken := func(name string) bool {
+		return models.DB.Where("LOWER(username) = ?", name).First(&models.User{}).Error == nil
+	}
+	if usernameTaken(restore.username) {
+		if !rename {
+			return nil, fmt.Errorf("%w: username '%s'", ErrUserExists, restore.username)
+		}
+		restore.username = freeName(restore.username, 0, usernameTaken)
+		restore.renamed = true
+	}
+
+	if restore.linuxUsername != "" {
+		policy, err := s.cfg.Clients.LinuxUsername.WithDefaults()
+		if err != nil {
+			return nil, err
+		}
+		if !validLinuxUsername(restore.linuxUsername, policy) {
+			return nil, fmt.Errorf("%w: invalid Linux username '%s'", ErrInvalidClientBackup, restore.linuxUsername)
+		}
+		if s.linuxUsernameTaken(restore.linuxUsername) {
+			if !rename {
+				return nil, fmt.Errorf("%w: Linux user '%s'", ErrUserExists, restore.linuxUsername)
+			}
+			restore.linuxUsername = freeName(restore.linuxUsername, config.MaxLinuxUsernameLength, s.linuxUsernameTaken)
+			restore.renamed = true
+		}
+		restore.homeDir = s.newHomeDir(restore.linuxUsername, manifest.Client.ParentClientID)
+	}
+
+	if models.DB.Unscoped().Where("customer_no = ?", restore.customerNo).First(&models.Client{}).Error == nil {
+		if !rename {
+			return nil, fmt.Errorf("%w: '%s'", ErrCustomerNoExists, restore.customerNo)
+		}
+		customerNo, err := s.GenerateCustomerNo()
+		if err != nil {
+			return nil, err
+		}
+		restore.customerNo = customerNo
+		restore.renamed = true
+	}
+
+	// Database names carry the customer number as prefix, follow it if it changed
+	oldPrefix := strings.ToLower(manifest.Client.CustomerNo) + "_"
+	newPrefix := strings.ToLower(restore.customerNo) + "_"
+	for _, database := range manifest.Databases {
+		if !databaseNamePattern.MatchString(database.Name) || !databaseNamePattern.MatchString(database.Username) || !mysqlHostPattern.MatchString(database.Host) {
+			return nil, fmt.Errorf("%w: invalid database '%s'", ErrInvalidClientBackup, database.Name)
+		}
+		if _, err := s.backupService.mysqlServer(database.Server); err != nil {
+			return nil, fmt.Errorf("cannot restore database '%s': %w", database.Name, err)
+		}
+
+		target := models.ClientDatabase{
+			Server:   database.Server,
+			Name:     database.Name,
+			Username: database.Username,
+			Host:     database.Host,
+		}
+		if oldPrefix != newPrefix && strings.HasPrefix(target.Name, oldPrefix) {
+			target.Name = newPrefix + strings.TrimPrefix(target.Name, oldPrefix)
+			if len(target.Name) > maxMySQLDatabaseName {
+				return nil, fmt.Errorf("database name '%s' exceeds %d characters", target.Name, maxMySQLDatabaseName)
+			}
+			target.Username = target.Name
+			if len(target.Username) > maxMySQLUsername {
+				target.Username = target.Username[:maxMySQLUsername]
+			}
+		}
+
+		var existing models.ClientDatabase
+		if err := models.DB.Where("name = ? OR username = ?", target.Name, target.Username).First(&existing).Error; err == nil {
+			return nil, fmt.Errorf("%w: '%s'", ErrDatabaseExists, target.Name)
+		}
+
+		restore.databases[database.Name] = target
+	}
+
+	return restore, nil
+}
+
+// runClientRestore creates the Linux user, unpacks the home directory and
+// databases and finally recreates the panel records
+func (s *ClientService) runClientRestore(restore *clientRestore, tarReader *tar.Reader) (*models.Client, error) {
+	if restore.linuxUsername != "" {
+		if _, err := os.Stat(restore.homeDir); err == nil {
+			restore.homeExisted = true
+		}
+		if err := s.createLinuxUser(restore.linuxUsername, restore.homeDir); err != nil {
+			return nil, fmt.Errorf("failed to create Linux user: %w", err)
+		}
+		restore.linuxUserCreated = !skipLinuxUser()
+	}
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidClientBackup, err)
+		}
+
+		name := path.Clean(header.Name)
+		switch {
+		case strings.HasPrefix(name, clientBackupHomeDir+"/"):
+			if restore.homeDir == "" {
+				continue
+			}
+			relPath := strings.TrimPrefix(name, clientBackupHomeDir+"/")
+			if err := extractTarEntry(tarReader, header, restore.homeDir, relPath); err != nil {
+				return nil, fmt.Errorf("failed to restore home directory: %w", err)
+			}
+
+		case path.Dir(name) == clientBackupDBDir && path.Ext(name) == ".sql":
+			original := strings.TrimSuffix(path.Base(name), ".sql")
+			database, ok := restore.databases[original]
+			if !ok {
+				return nil, fmt.Errorf("%w: dump of unlisted database '%s'", ErrInvalidClientBackup, original)
+			}
+			credentials, err := s.backupService.restoreClientDatabase(database, tarReader)
+			if err != nil {
+				return nil, err
+			}
+			restore.created = append(restore.created, database)
+			restore.credentials = append(restore.credentials, *credentials)
+		}
+	}
+
+	if len(restore.created) != len(restore.databases) {
+		return nil, fmt.Errorf("%w: %d of %d database dumps found", ErrInvalidClientBackup, len(restore.created), len(restore.databases))
+	}
+
+	if restore.linuxUserCreated {
+		owner := restore.linuxUsername + ":" + restore.linuxUsername
+		if output, err := exec.Command("chown", "-R", owner, restore.homeDir).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to chown home directory: %s", strings.TrimSpace(string(output)))
+		}
+	}
+
+	var clientID uint
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		user := &models.User{
+			Username:     restore.username,
+			PasswordHash: restore.manifest.PasswordHash,
+			Role:         models.RoleUser,
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		client := restore.manifest.Client
+		limits := client.ClientLimits
+		client.ID = 0
+		client.UserID = user.ID
+		client.CustomerNo = restore.customerNo
+		client.LinuxUsername = restore.linuxUsername
+		client.DeletedAt = gorm.DeletedAt{}
+		if err := tx.Omit(clause.Associations).Create(&client).Error; err != nil {
+			return err
+		}
+
+		limits.ID = 0
+		limits.ClientID = client.ID
+		if err := tx.Create(&limits).Error; err != nil {
+			return err
+		}
+
+		for _, database := range restore.created {
+			database.ClientID = client.ID
+			if err := tx.Create(&database).Error; err != nil {
+				return err
+			}
+		}
+
+		clientID = client.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetClient(clientID)
+}
+
+// rollbackClientRestore removes what a failed restore created on the host
+func (s *ClientService) rollbackClientRestore(restore *clientRestore) {
+	for _, database := range restore.created {
+		if err := s.backupService.dropClientDatabase(database); err != nil {
+			fmt.Printf("Warning: failed to drop restored database '%s': %v\n", database.Name, err)
+		}
+	}
+
+	if restore.linuxUserCreated {
+		if err := s.deleteLinuxUser(restore.linuxUsername); err != nil {
+			fmt.Printf("Warning: failed to delete Linux user '%s': %v\n", restore.linuxUsername, err)
+		}
+	}
+	if restore.homeDir != "" && !restore.homeExisted {
+		os.RemoveAll(restore.homeDir)
+	}
+}
+
+// restoreClientDatabase creates a database with its user and a new password on
+// the database's server and loads dump into it
+func (s *BackupService) restoreClientDatabase(database models.ClientDatabase, dump io.Reader) (*ClientDatabaseCredentials, error) {
+	server, err := s.mysqlServer(database.Server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database %s: %w", database.Name, err)
+	}
+	password, err := generatePassword(generatedPasswordLen)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never drop a database this restore did not create
+	ctx := context.Background()
+	createDatabase := fmt.Sprintf("CREATE DATABASE `%s`", database.Name)
+	if output, err := server.cliCommand(ctx, server.mysql, "-e", createDatabase).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to create database %s: %s", database.Name, strings.TrimSpace(string(output)))
+	}
+
+	account := quoteAccount(database.Username, database.Host)
+	createUser := fmt.Sprintf("CREATE USER %s IDENTIFIED BY %s; GRANT ALL PRIVILEGES ON `%s`.* TO %s",
+		account, quoteString(password), database.Name, account)
+	if output, err := server.cliCommand(ctx, server.mysql, "-e", createUser).CombinedOutput(); err != nil {
+		s.dropClientDatabase(database)
+		return nil, fmt.Errorf("failed to create database user %s: %s", database.Username, strings.TrimSpace(string(output)))
+	}
+
+	cmd := server.cliCommand(ctx, server.mysql, database.Name)
+	cmd.Stdin = dump
+	if output, err := cmd.CombinedOutput(); err != nil {
+		s.dropClientDatabase(database)
+		return nil, fmt.Errorf("failed to import database %s: %s", database.Name, strings.TrimSpace(string(output)))
+	}
+
+	return &ClientDatabaseCredentials{
+		Database: database.Name,
+		Username: database.Username,
+		Password: password,
+		Host:     database.Host,
+	}, nil
+}
+
+// dropClientDatabase removes a database and user created by restoreClientDatabase
+func (s *BackupService) dropClientDatabase(database models.ClientDatabase) error {
+	server, err := s.mysqlServer(database.Server)
+	if err != nil {
+		return err
+	}
+	statements := fmt.Sprintf("DROP DATABASE IF EXISTS `%s`; DROP USER IF EXISTS %s", database.Name, quoteAccount(database.Username, database.Host))
+	if output, err := server.cliCommand(context.Background(), server.mysql, "-e", statements).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// freeName returns name, or name with the lowest numeric suffix taken does not
+// report, shortened to maxLen if set
+func freeName(name string, maxLen int, taken func(string) bool) string {
+	candidate := name
+	for i := 2; taken(candidate); i++ {
+		suffix := strconv.Itoa(i)
+		base := name
+		if maxLen > 0 && len(base)+len(suffix) > maxLen {
+			base = base[:maxLen-len(suffix)]
+		}
+		candidate = base + suffix
+	}
+	return candidate
+}
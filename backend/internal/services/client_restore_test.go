@@ -0,0 +1,173 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreClientBackupRoundTrip(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	service := NewClientService(cfg)
+	service.homeBase = t.TempDir()
+
+	original := createPurgeTestClient(t, service, "roundtrip")
+	require.NoError(t, models.DB.Model(&models.ClientLimits{}).
+		Where("client_id = ?", original.ID).Update("limit_web_domain", 7).Error)
+
+	indexPath := filepath.Join(service.homeDir("roundtrip"), "public_html", "index.html")
+	require.NoError(t, os.MkdirAll(filepath.Dir(indexPath), 0755))
+	require.NoError(t, os.WriteFile(indexPath, []byte("<h1>hello</h1>"), 0644))
+
+	backup := true
+	plan, err := service.PurgeClient(original.ID, &backup)
+	require.NoError(t, err)
+	require.FileExists(t, plan.BackupPath)
+	info, err := os.Stat(plan.BackupPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the archive holds the password hash")
+
+	// Purging with SKIP_LINUX_USER leaves the home directory, remove it like userdel -r would
+	require.NoError(t, os.RemoveAll(service.homeDir("roundtrip")))
+
+	t.Run("restores client, user, limits and home directory", func(t *testing.T) {
+		result, err := service.RestoreClientBackup(plan.BackupPath, ClientRestoreOptions{})
+		require.NoError(t, err)
+		assert.False(t, result.Renamed)
+
+		restored := result.Client
+		assert.NotEqual(t, original.ID, restored.ID)
+		assert.Equal(t, original.CustomerNo, restored.CustomerNo)
+		assert.Equal(t, original.Email, restored.Email)
+		assert.Equal(t, "roundtrip", restored.LinuxUsername)
+		assert.Equal(t, 7, restored.ClientLimits.LimitWebDomain)
+
+		user, err := service.authService.Authenticate("roundtrip", "testpass123")
+		require.NoError(t, err, "restored user keeps the original password")
+		assert.Equal(t, restored.UserID, user.ID)
+
+		content, err := os.ReadFile(indexPath)
+		require.NoError(t, err)
+		assert.Equal(t, "<h1>hello</h1>", string(content))
+	})
+
+	t.Run("existing account is a conflict", func(t *testing.T) {
+		_, err := service.RestoreClientBackup(plan.BackupPath, ClientRestoreOptions{})
+		assert.ErrorIs(t, err, ErrClientExists)
+	})
+
+	t.Run("rename resolves username and customer number clashes", func(t *testing.T) {
+		restored, err := service.GetClientByUserID(mustFindUser(t, "roundtrip").ID)
+		require.NoError(t, err)
+		// Free the email but keep the username, Linux user and customer number taken
+		require.NoError(t, models.DB.Model(&models.Client{}).Where("id = ?", restored.ID).Update("email", "moved@example.com").Error)
+
+		_, err = service.RestoreClientBackup(plan.BackupPath, ClientRestoreOptions{})
+		assert.ErrorIs(t, err, ErrUserExists)
+
+		result, err := service.RestoreClientBackup(plan.BackupPath, ClientRestoreOptions{OnConflict: RestoreConflictRename})
+		require.NoError(t, err)
+		assert.True(t, result.Renamed)
+		assert.Equal(t, "roundtrip2", result.Client.User.Username)
+		assert.Equal(t, "roundtrip2", result.Client.LinuxUsername)
+		assert.NotEqual(t, original.CustomerNo, result.Client.CustomerNo)
+		assert.FileExists(t, filepath.Join(service.homeDir("roundtrip2"), "public_html", "index.html"))
+	})
+
+	t.Run("rejects archives that are not client backups", func(t *testing.T) {
+		bogus := filepath.Join(t.TempDir(), "bogus.tar.gz")
+		require.NoError(t, os.WriteFile(bogus, []byte("not a backup"), 0644))
+
+		_, err := service.RestoreClientBackup(bogus, ClientRestoreOptions{})
+		assert.ErrorIs(t, err, ErrInvalidClientBackup)
+	})
+
+	t.Run("rejects Linux usernames outside the policy", func(t *testing.T) {
+		for _, name := range []string{"../../etc", "-oroot", "Upper", "way-too-long-for-a-linux-username-1"} {
+			tampered := tamperClientBackup(t, plan.BackupPath, func(manifest *ClientBackupManifest) {
+				manifest.Username = "tampered"
+				manifest.Client.Email = "tampered@example.com"
+				manifest.Client.LinuxUsername = name
+			})
+			_, err := service.RestoreClientBackup(tampered, ClientRestoreOptions{OnConflict: RestoreConflictRename})
+			assert.ErrorIs(t, err, ErrInvalidClientBackup, name)
+		}
+		assert.NoDirExists(t, filepath.Join(service.homeBase, "..", "..", "etc", "public_html"))
+	})
+
+	t.Run("rejects roles other than a client's", func(t *testing.T) {
+		tampered := tamperClientBackup(t, plan.BackupPath, func(manifest *ClientBackupManifest) {
+			manifest.Username = "escalated"
+			manifest.Client.Email = "escalated@example.com"
+			manifest.Role = models.RoleAdmin
+		})
+		_, err := service.RestoreClientBackup(tampered, ClientRestoreOptions{OnConflict: RestoreConflictRename})
+		assert.ErrorIs(t, err, ErrInvalidClientBackup)
+		assert.Error(t, models.DB.Where("username = ?", "escalated").First(&models.User{}).Error)
+	})
+
+	t.Run("rejects unknown conflict strategies", func(t *testing.T) {
+		_, err := service.RestoreClientBackup(plan.BackupPath, ClientRestoreOptions{OnConflict: "overwrite"})
+		assert.ErrorIs(t, err, ErrInvalidConflictStrategy)
+	})
+}
+
+// tamperClientBackup writes a copy of the client backup at path with its
+// manifest changed by edit
+func tamperClientBackup(t *testing.T, path string, edit func(*ClientBackupManifest)) string {
+	t.Helper()
+	in, err := os.Open(path)
+	require.NoError(t, err)
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	tampered := filepath.Join(t.TempDir(), "tampered.tar.gz")
+	out, err := os.Create(tampered)
+	require.NoError(t, err)
+	defer out.Close()
+	gzw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gzw)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		if header.Name == clientBackupManifest {
+			var manifest ClientBackupManifest
+			require.NoError(t, json.Unmarshal(data, &manifest))
+			edit(&manifest)
+			data, err = json.Marshal(manifest)
+			require.NoError(t, err)
+			header.Size = int64(len(data))
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err = tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return tampered
+}
+
+func mustFindUser(t *testing.T, username string) *models.User {
+	t.Helper()
+	var user models.User
+	require.NoError(t, models.DB.Where("username = ?", username).First(&user).Error)
+	return &user
+}
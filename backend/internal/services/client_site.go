@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	ErrClientLocked          = errors.New("client is locked")
+	ErrWebDomainLimitReached = errors.New("web domain limit reached for this client")
+	ErrWildcardNotAllowed    = errors.New("the client's plan does not allow wildcard domains")
+	ErrUnsafeDocumentRoot    = errors.New("refusing to follow a symlink to the document root")
+)
+
+// clientWebDir holds the document roots of a client's sites, <home>/web/<domain>
+const clientWebDir = "web"
+
+// ClientSiteService provisions Nginx sites for clients within their limits
+type ClientSiteService struct {
+	nginx       *NginxService
+	homeDir     func(linuxUsername string) string
+	certDir     string // uploaded site certificates
+	autocertDir string // certificates obtained by the panel, empty when TLS is off
+}
+
+// ClientSiteOptions describes the site to create for a client
+type ClientSiteOptions struct {
+	Domain     string
+	Wildcard   bool // also serve every subdomain, needs limit_wildcard
+	ForceHTTPS bool // serve over HTTPS and redirect HTTP to it, needs limit_ssl
+}
+
+// ClientSite is a site created for a client
+type ClientSite struct {
+	Site         *NginxSite `json:"site"`
+	DocumentRoot string     `json:"document_root"`
+}
+
+func NewClientSiteService(cfg *config.Config, nginx *NginxService, clients *ClientService) *ClientSiteService {
+	service := &ClientSiteService{
+		nginx:   nginx,
+		homeDir: clients.homeDir,
+		certDir: cfg.Paths.SSLCertificates,
+	}
+	if cfg.Server.TLS.Enabled {
+		service.autocertDir = cfg.Server.TLS.CertCacheDir()
+	}
+	return service
+}
+
+// CreateSite creates and enables a site for client with its document root at
+// <home>/web/<domain>, owned by the client's Linux user. The client's plan
+// decides how many sites it may have, whether they may be wildcards or use
+// SSL, and the bandwidth caps written into the config. Nginx serves the site
+// from its next reload.
+func (s *ClientSiteService) CreateSite(client *models.Client, opts ClientSiteOptions) (*ClientSite, error) {
+	if client.Locked {
+		return nil, ErrClientLocked
+	}
+	if client.LinuxUsername == "" {
+		return nil, ErrNoLinuxUser
+	}
+	domain := strings.ToLower(strings.TrimSpace(opts.Domain))
+	if err := ValidateDomain(domain); err != nil {
+		return nil, err
+	}
+
+	limits := client.ClientLimits
+	if opts.Wildcard && !limits.LimitWildcard {
+		return nil, ErrWildcardNotAllowed
+	}
+	siteOpts := SiteOptions{Bandwidth: SiteBandwidthFor(limits)}
+	if opts.Wildcard {
+		siteOpts.Aliases = []string{"*." + domain}
+	}
+	if opts.ForceHTTPS {
+		if !limits.LimitSSL {
+			return nil, ErrSSLNotAllowed
+		}
+		cert, err := s.nginx.FindCertificate(domain, s.certDir, s.autocertDir)
+		if err != nil {
+			return nil, err
+		}
+		siteOpts.ForceHTTPS = cert
+	}
+
+	sites, err := s.nginx.GetSitesByUser(client.LinuxUsername)
+	if err != nil {
+		return nil, err
+	}
+	if limitReached(limits.LimitWebDomain, int64(len(sites))) {
+		return nil, ErrWebDomainLimitReached
+	}
+	if _, err := s.nginx.GetSite(domain); err == nil {
+		return nil, ErrSiteExists
+	}
+
+	home := s.homeDir(client.LinuxUsername)
+	root := filepath.Join(home, clientWebDir, domain)
+	config, err := s.nginx.GenerateSiteConfigWithOptions(domain, root, clientPoolSocket(client.LinuxUsername), siteOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mkdirOwned(home, root, client.LinuxUsername); err != nil {
+		return nil, err
+	}
+	if err := s.nginx.CreateSite(domain, config); err != nil {
+		return nil, err
+	}
+	if err := s.nginx.EnableSite(domain); err != nil {
+		s.nginx.DeleteSite(domain)
+		return nil, err
+	}
+
+	site, err := s.nginx.GetSite(domain)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientSite{Site: site, DocumentRoot: root}, nil
+}
+
+// clientPoolSocket is the socket of the PHP-FPM pool named after a client's
+// Linux user, as GeneratePoolConfig writes it
+func clientPoolSocket(linuxUsername string) string {
+	return "php-fpm-" + linuxUsername + ".sock"
+}
+
+// mkdirOwned creates dir and the missing directories between home and dir,
+// owned by the Linux user. Home belongs to the client, so the walk goes through
+// descriptors opened without following symlinks and hands new directories over
+// with fchown: a component swapped for a symlink on the way is refused rather
+// than followed to wherever the client pointed it.
+func mkdirOwned(home, dir, linuxUsername string) error {
+	uid, gid := -1, -1
+	if !skipLinuxUser() {
+		account, err := user.Lookup(linuxUsername)
+		if err != nil {
+			return fmt.Errorf("failed to look up Linux user: %w", err)
+		}
+		uid, _ = strconv.Atoi(account.Uid)
+		gid, _ = strconv.Atoi(account.Gid)
+	}
+
+	rel, err := filepath.Rel(home, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("%w: %s is not inside %s", ErrUnsafeDocumentRoot, dir, home)
+	}
+
+	fd, err := openDirAt(unix.AT_FDCWD, home)
+	if isSymlinkError(err) {
+		return fmt.Errorf("%w: %s", ErrUnsafeDocumentRoot, home)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open home directory: %w", err)
+	}
+	path := home
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		path = filepath.Join(path, name)
+		next, created, err := mkdirOpenAt(fd, name, 0755)
+		unix.Close(fd)
+		if isSymlinkError(err) {
+			return fmt.Errorf("%w: %s", ErrUnsafeDocumentRoot, path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create document root: %w", err)
+		}
+		fd = next
+		if created && uid >= 0 {
+			if err := unix.Fchown(fd, uid, gid); err != nil {
+				unix.Close(fd)
+				return fmt.Errorf("failed to hand %s to %s: %w", path, linuxUsername, err)
+			}
+		}
+	}
+	unix.Close(fd)
+	return nil
+}
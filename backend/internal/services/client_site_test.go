@@ -0,0 +1,73 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSiteServiceCreateSite(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	nginx, fsys, _ := newFakeNginx()
+	home := t.TempDir()
+	service := &ClientSiteService{nginx: nginx, homeDir: func(string) string { return home }}
+
+	// A site the client has already, found through the root under its home
+	existing := nginx.GenerateSiteConfig("old.example.com", "/home/client1/web/old.example.com", "php-fpm-client1.sock")
+	require.NoError(t, nginx.CreateSite("old.example.com", existing))
+
+	client := &models.Client{LinuxUsername: "client1", ClientLimits: models.ClientLimits{LimitWebDomain: 1, LimitWebRate: 512}}
+	_, err := service.CreateSite(client, ClientSiteOptions{Domain: "shop.example.com"})
+	assert.ErrorIs(t, err, ErrWebDomainLimitReached)
+
+	client.ClientLimits.LimitWebDomain = 2
+	_, err = service.CreateSite(client, ClientSiteOptions{Domain: "shop.example.com", Wildcard: true})
+	assert.ErrorIs(t, err, ErrWildcardNotAllowed)
+	_, err = service.CreateSite(client, ClientSiteOptions{Domain: "shop.example.com", ForceHTTPS: true})
+	assert.ErrorIs(t, err, ErrSSLNotAllowed)
+
+	client.ClientLimits.LimitWildcard = true
+	created, err := service.CreateSite(client, ClientSiteOptions{Domain: "Shop.Example.com", Wildcard: true})
+	require.NoError(t, err)
+	root := filepath.Join(home, "web", "shop.example.com")
+	assert.Equal(t, root, created.DocumentRoot)
+	assert.DirExists(t, root)
+	assert.True(t, created.Site.Enabled)
+
+	config := string(fsys.files["/etc/nginx/sites-available/shop.example.com"])
+	assert.Contains(t, config, "server_name shop.example.com *.shop.example.com;")
+	assert.Contains(t, config, "root "+root+";")
+	assert.Contains(t, config, "fastcgi_pass unix:/run/php/php-fpm-client1.sock;")
+	assert.Contains(t, config, "limit_rate 512k;", "the plan's bandwidth caps apply")
+
+	_, err = service.CreateSite(client, ClientSiteOptions{Domain: "shop.example.com"})
+	assert.ErrorIs(t, err, ErrSiteExists)
+	_, err = service.CreateSite(client, ClientSiteOptions{Domain: "../etc"})
+	assert.ErrorIs(t, err, ErrInvalidDomain)
+
+	client.Locked = true
+	_, err = service.CreateSite(client, ClientSiteOptions{Domain: "blog.example.com"})
+	assert.ErrorIs(t, err, ErrClientLocked)
+
+	_, err = service.CreateSite(&models.Client{}, ClientSiteOptions{Domain: "blog.example.com"})
+	assert.ErrorIs(t, err, ErrNoLinuxUser)
+}
+
+func TestClientSiteServiceRefusesSymlinkedWebDir(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	nginx, fsys, _ := newFakeNginx()
+	home, elsewhere := t.TempDir(), t.TempDir()
+	require.NoError(t, os.Symlink(elsewhere, filepath.Join(home, "web")))
+	service := &ClientSiteService{nginx: nginx, homeDir: func(string) string { return home }}
+
+	client := &models.Client{LinuxUsername: "client1", ClientLimits: models.ClientLimits{LimitWebDomain: -1}}
+	_, err := service.CreateSite(client, ClientSiteOptions{Domain: "shop.example.com"})
+	assert.ErrorIs(t, err, ErrUnsafeDocumentRoot)
+	assert.NoDirExists(t, filepath.Join(elsewhere, "shop.example.com"))
+	assert.NotContains(t, fsys.files, "/etc/nginx/sites-available/shop.example.com", "no site without its document root")
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"r-panel/internal/models"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	ErrInvalidSSHKey  = errors.New("invalid SSH public key")
+	ErrSSHKeyExists   = errors.New("SSH key is already authorized")
+	ErrSSHKeyNotFound = errors.New("SSH key not found")
+	ErrUnsafeSSHPath  = errors.New("refusing to follow a symlink in the .ssh directory")
+)
+
+// sshKeysMu serializes changes to authorized_keys files
+var sshKeysMu sync.Mutex
+
+// SSHKey is a public key in a client's authorized_keys
+type SSHKey struct {
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"` // SHA256, as ssh-keygen -l prints it
+	Comment     string `json:"comment"`
+	Key         string `json:"key"` // the authorized_keys line
+}
+
+// authorizedKeysPath returns the authorized_keys file of a client's Linux user,
+// inside the jail for a jailed user
+func (s *ClientService) authorizedKeysPath(client *models.Client) (string, error) {
+	if client.LinuxUsername == "" {
+		return "", ErrNoLinuxUser
+	}
+	home := s.homeDir(client.LinuxUsername)
+	if !skipLinuxUser() {
+		account, err := user.Lookup(client.LinuxUsername)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up Linux user: %w", err)
+		}
+		home = account.HomeDir
+	}
+	return filepath.Join(home, ".ssh", "authorized_keys"), nil
+}
+
+// GetSSHKeys lists the keys in a client's authorized_keys. Lines that are not
+// keys are left out.
+func (s *ClientService) GetSSHKeys(client *models.Client) ([]SSHKey, error) {
+	path, err := s.authorizedKeysPath(client)
+	if err != nil {
+		return nil, err
+	}
+	sshKeysMu.Lock()
+	defer sshKeysMu.Unlock()
+
+	data, err := readAuthorizedKeys(path)
+	if err != nil {
+		return nil, err
+	}
+	keys := []SSHKey{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, err := parseSSHKey(line); err == nil {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+// AddSSHKey authorizes a public key for a client with shell access. Options in
+// front of the key are dropped.
+func (s *ClientService) AddSSHKey(client *models.Client, line string) (*SSHKey, error) {
+	if err := checkShellAccess(client); err != nil {
+		return nil, err
+	}
+	key, err := parseSSHKey(line)
+	if err != nil {
+		return nil, err
+	}
+	path, err := s.authorizedKeysPath(client)
+	if err != nil {
+		return nil, err
+	}
+	sshKeysMu.Lock()
+	defer sshKeysMu.Unlock()
+
+	data, err := readAuthorizedKeys(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := []string{}
+	for _, existing := range strings.Split(string(data), "\n") {
+		if existing == "" {
+			continue
+		}
+		if parsed, err := parseSSHKey(existing); err == nil && parsed.Fingerprint == key.Fingerprint {
+			return nil, ErrSSHKeyExists
+		}
+		lines = append(lines, existing)
+	}
+	if err := s.writeAuthorizedKeys(client, path, append(lines, key.Key)); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DeleteSSHKey removes the key with fingerprint from a client's authorized_keys
+func (s *ClientService) DeleteSSHKey(client *models.Client, fingerprint string) error {
+	path, err := s.authorizedKeysPath(client)
+	if err != nil {
+		return err
+	}
+	sshKeysMu.Lock()
+	defer sshKeysMu.Unlock()
+
+	data, err := readAuthorizedKeys(path)
+	if err != nil {
+		return err
+	}
+	lines := []string{}
+	found := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		if key, err := parseSSHKey(line); err == nil && key.Fingerprint == fingerprint {
+			found = true
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if !found {
+		return ErrSSHKeyNotFound
+	}
+	return s.writeAuthorizedKeys(client, path, lines)
+}
+
+// parseSSHKey parses an authorized_keys line and normalizes it to the key and
+// its comment
+func parseSSHKey(line string) (*SSHKey, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.ContainsAny(line, "\r\n") {
+		return nil, ErrInvalidSSHKey
+	}
+	pub, comment, _, rest, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil || len(bytes.TrimSpace(rest)) > 0 {
+		return nil, ErrInvalidSSHKey
+	}
+	key := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	if comment != "" {
+		key += " " + comment
+	}
+	return &SSHKey{
+		Type:        pub.Type(),
+		Fingerprint: ssh.FingerprintSHA256(pub),
+		Comment:     comment,
+		Key:         key,
+	}, nil
+}
+
+// readAuthorizedKeys reads path, or nothing if it does not exist yet. The home
+// directory belongs to the client, so symlinks are refused rather than letting
+// them point the panel at other files.
+func readAuthorizedKeys(path string) ([]byte, error) {
+	dirfd, err := openSSHDir(path, false)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(dirfd)
+
+	fd, err := unix.Openat(dirfd, filepath.Base(path), unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if errors.Is(err, unix.ENOENT) {
+		return nil, nil
+	}
+	if isSymlinkError(err) {
+		return nil, ErrUnsafeSSHPath
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read authorized_keys: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), path)
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// openSSHDir opens the .ssh directory holding path, creating it when create
+// is set. The home above is opened first and neither is followed if it is a
+// symlink: everything after happens relative to the returned descriptor, so
+// the client cannot swap .ssh for a link to another user's while the panel
+// works in it.
+func openSSHDir(path string, create bool) (int, error) {
+	dir := filepath.Dir(path)
+	home := filepath.Dir(dir)
+	if create {
+		if err := os.MkdirAll(home, 0755); err != nil {
+			return -1, fmt.Errorf("failed to create home directory: %w", err)
+		}
+	}
+
+	homefd, err := openDirAt(unix.AT_FDCWD, home)
+	if errors.Is(err, unix.ENOENT) {
+		return -1, os.ErrNotExist
+	}
+	if isSymlinkError(err) {
+		return -1, ErrUnsafeSSHPath
+	}
+	if err != nil {
+		return -1, fmt.Errorf("failed to open home directory: %w", err)
+	}
+	defer unix.Close(homefd)
+
+	var dirfd int
+	if create {
+		dirfd, _, err = mkdirOpenAt(homefd, filepath.Base(dir), 0700)
+	} else {
+		dirfd, err = openDirAt(homefd, filepath.Base(dir))
+	}
+	switch {
+	case errors.Is(err, unix.ENOENT):
+		return -1, os.ErrNotExist
+	case isSymlinkError(err):
+		return -1, ErrUnsafeSSHPath
+	case err != nil:
+		return -1, fmt.Errorf("failed to open .ssh directory: %w", err)
+	}
+	return dirfd, nil
+}
+
+// writeAuthorizedKeys replaces path with lines, owned by the client's Linux
+// user with the permissions sshd insists on
+func (s *ClientService) writeAuthorizedKeys(client *models.Client, path string, lines []string) error {
+	uid, gid := -1, -1
+	if !skipLinuxUser() {
+		account, err := user.Lookup(client.LinuxUsername)
+		if err != nil {
+			return fmt.Errorf("failed to look up Linux user: %w", err)
+		}
+		uid, _ = strconv.Atoi(account.Uid)
+		gid, _ = strconv.Atoi(account.Gid)
+	}
+
+	dirfd, err := openSSHDir(path, true)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(dirfd)
+	if err := unix.Fchmod(dirfd, 0700); err != nil {
+		return fmt.Errorf("failed to secure .ssh directory: %w", err)
+	}
+
+	name := filepath.Base(path)
+	tmp := tempName(name)
+	fd, err := unix.Openat(dirfd, tmp, unix.O_WRONLY|unix.O_CREAT|unix.O_EXCL|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write authorized_keys: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), tmp)
+	defer unix.Unlinkat(dirfd, tmp, 0)
+
+	content := strings.Join(lines, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write authorized_keys: %w", err)
+	}
+	if uid >= 0 {
+		// fchown on the descriptors, never by a name the client could relink
+		if err := f.Chown(uid, gid); err != nil {
+			f.Close()
+			return err
+		}
+		if err := unix.Fchown(dirfd, uid, gid); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write authorized_keys: %w", err)
+	}
+	// renameat replaces whatever is at name, a symlink included, without following it
+	if err := unix.Renameat(dirfd, tmp, dirfd, name); err != nil {
+		return fmt.Errorf("failed to write authorized_keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSSHKey      = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFSFP6DlPpz/BGbtkZSygGfn+qegEY/fPTUNYDdtQEtC alice@laptop"
+	otherTestSSHKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGAP2GkoVOi1XB/aMoWuNgrWXtq+KXSqQB3gdMfGSylG"
+)
+
+func TestClientSSHKeys(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	service := NewClientService(cfg)
+	service.homeBase = t.TempDir()
+	client := createPurgeTestClient(t, service, "sshuser")
+
+	_, err := service.AddSSHKey(client, testSSHKey)
+	assert.ErrorIs(t, err, ErrNoShellAccess)
+
+	client.ClientLimits.LimitShellUser = 1
+	client.ClientLimits.SSHChroot = models.StringArray{"jailkit"}
+	_, err = service.AddSSHKey(client, testSSHKey)
+	assert.ErrorIs(t, err, ErrShellJailOnly)
+	client.ClientLimits.SSHChroot = models.StringArray{"no", "jailkit"}
+
+	keys, err := service.GetSSHKeys(client)
+	require.NoError(t, err)
+	assert.Empty(t, keys, "no authorized_keys yet")
+
+	for _, invalid := range []string{"", "not a key", "ssh-ed25519 AAAA", testSSHKey + "\n" + otherTestSSHKey} {
+		_, err = service.AddSSHKey(client, invalid)
+		assert.ErrorIs(t, err, ErrInvalidSSHKey, invalid)
+	}
+
+	key, err := service.AddSSHKey(client, `command="/bin/sh" `+testSSHKey)
+	require.NoError(t, err)
+	assert.Equal(t, "ssh-ed25519", key.Type)
+	assert.Equal(t, "alice@laptop", key.Comment)
+	assert.Equal(t, testSSHKey, key.Key, "options are dropped")
+	_, err = service.AddSSHKey(client, testSSHKey)
+	assert.ErrorIs(t, err, ErrSSHKeyExists)
+	_, err = service.AddSSHKey(client, otherTestSSHKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(service.homeBase, "sshuser", ".ssh", "authorized_keys")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	info, err = os.Stat(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	keys, err = service.GetSSHKeys(client)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	require.NoError(t, service.DeleteSSHKey(client, key.Fingerprint))
+	assert.ErrorIs(t, service.DeleteSSHKey(client, key.Fingerprint), ErrSSHKeyNotFound)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, otherTestSSHKey+"\n", string(content))
+}
+
+func TestClientSSHKeysRefuseSymlinks(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	service := NewClientService(cfg)
+	service.homeBase = t.TempDir()
+	client := createPurgeTestClient(t, service, "sneaky")
+	client.ClientLimits.LimitShellUser = 1
+
+	target := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(service.homeBase, "sneaky"), 0755))
+	require.NoError(t, os.Symlink(target, filepath.Join(service.homeBase, "sneaky", ".ssh")))
+
+	_, err := service.AddSSHKey(client, testSSHKey)
+	assert.ErrorIs(t, err, ErrUnsafeSSHPath)
+	assert.NoFileExists(t, filepath.Join(target, "authorized_keys"))
+
+	// A linked authorized_keys is not read, and replaced rather than written through
+	sshDir := filepath.Join(service.homeBase, "sneaky", ".ssh")
+	require.NoError(t, os.Remove(sshDir))
+	require.NoError(t, os.Mkdir(sshDir, 0700))
+	outside := filepath.Join(target, "keys")
+	require.NoError(t, os.WriteFile(outside, []byte("untouched\n"), 0600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(sshDir, "authorized_keys")))
+	_, err = service.GetSSHKeys(client)
+	assert.ErrorIs(t, err, ErrUnsafeSSHPath)
+	_, err = service.AddSSHKey(client, testSSHKey)
+	assert.ErrorIs(t, err, ErrUnsafeSSHPath)
+	content, err := os.ReadFile(outside)
+	require.NoError(t, err)
+	assert.Equal(t, "untouched\n", string(content))
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"r-panel/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrClientTemplateNotFound = errors.New("client template not found")
+	ErrClientTemplateExists   = errors.New("client template already exists")
+	ErrClientTemplateInUse    = errors.New("client template is used by clients")
+	ErrInvalidClientTemplate  = errors.New("invalid client template")
+)
+
+// clientTemplateMu serializes template changes, each one may rewrite the
+// limits of every client using the template
+var clientTemplateMu sync.Mutex
+
+// ClientTemplateData holds the fields of a template that can be created or updated
+type ClientTemplateData struct {
+	Name        string
+	Description string
+	Limits      models.TemplateLimits
+}
+
+// templateLimitNames are the ClientLimits JSON names a template may set
+var templateLimitNames = func() map[string]bool {
+	skip := map[string]bool{"id": true, "client_id": true, "client": true, "created_at": true, "updated_at": true}
+	names := map[string]bool{}
+	limitsType := reflect.TypeOf(models.ClientLimits{})
+	for i := 0; i < limitsType.NumField(); i++ {
+		name, _, _ := strings.Cut(limitsType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && !skip[name] {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// applyTemplateLimits sets the limits template lists on limits, leaving the others
+func applyTemplateLimits(limits *models.ClientLimits, template *models.ClientTemplate) error {
+	for name := range template.Limits {
+		if !templateLimitNames[name] {
+			return fmt.Errorf("%w: %s: unknown limit %q", ErrInvalidClientTemplate, template.Name, name)
+		}
+	}
+	data, err := json.Marshal(template.Limits)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidClientTemplate, template.Name, err)
+	}
+	// Unmarshal only touches the fields present in data
+	if err := json.Unmarshal(data, limits); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidClientTemplate, template.Name, err)
+	}
+	return nil
+}
+
+// validateClientTemplate checks the name and limits of a template
+func validateClientTemplate(data *ClientTemplateData) error {
+	data.Name = strings.TrimSpace(data.Name)
+	if data.Name == "" || len(data.Name) > 100 {
+		return fmt.Errorf("%w: name must be 1 to 100 characters", ErrInvalidClientTemplate)
+	}
+	if len(data.Description) > 255 {
+		return fmt.Errorf("%w: description must be at most 255 characters", ErrInvalidClientTemplate)
+	}
+	var limits models.ClientLimits
+	if err := applyTemplateLimits(&limits, &models.ClientTemplate{Name: data.Name, Limits: data.Limits}); err != nil {
+		return err
+	}
+	if err := SiteBandwidthFor(limits).Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidClientTemplate, err)
+	}
+	return nil
+}
+
+// clientTemplates loads the master template and then the additional ones, in
+// the order they apply. A client without a master template gets none.
+func clientTemplates(db *gorm.DB, master uint, additional models.StringArray) ([]models.ClientTemplate, error) {
+	if master == 0 {
+		return nil, nil
+	}
+	ids := []uint{master}
+	for _, value := range additional {
+		id, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: template_additional: %q is not a template ID", ErrInvalidClientTemplate, value)
+		}
+		ids = append(ids, uint(id))
+	}
+
+	templates := make([]models.ClientTemplate, 0, len(ids))
+	for _, id := range ids {
+		var template models.ClientTemplate
+		if err := db.First(&template, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: #%d", ErrClientTemplateNotFound, id)
+			}
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
+// applyClientTemplates sets the limits of a client's templates on limits
+func applyClientTemplates(db *gorm.DB, limits *models.ClientLimits, master uint, additional models.StringArray) error {
+	templates, err := clientTemplates(db, master, additional)
+	if err != nil {
+		return err
+	}
+	for i := range templates {
+		if err := applyTemplateLimits(limits, &templates[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templateClients returns the clients, trashed ones included, that use the
+// template as master or additional template
+func templateClients(db *gorm.DB, id uint) ([]models.Client, error) {
+	var clients []models.Client
+	// template_additional is a JSON array of ID strings
+	additional := "%" + strconv.Quote(strconv.FormatUint(uint64(id), 10)) + "%"
+	err := db.Unscoped().Preload("ClientLimits").
+		Where("template_master = ? OR template_additional LIKE ?", id, additional).
+		Order("id").Find(&clients).Error
+	return clients, err
+}
+
+// checkClientTemplateNameFree fails if another template than id has name
+func checkClientTemplateNameFree(name string, id uint) error {
+	var count int64
+	if err := models.DB.Model(&models.ClientTemplate{}).Where("LOWER(name) = ? AND id <> ?", strings.ToLower(name), id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrClientTemplateExists
+	}
+	return nil
+}
+
+// GetTemplates returns all client templates
+func (s *ClientService) GetTemplates() ([]models.ClientTemplate, error) {
+	var templates []models.ClientTemplate
+	if err := models.DB.Order("name").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetTemplate returns a client template by ID
+func (s *ClientService) GetTemplate(id uint) (*models.ClientTemplate, error) {
+	var template models.ClientTemplate
+	if err := models.DB.First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientTemplateNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// CreateTemplate stores a new client template
+func (s *ClientService) CreateTemplate(data *ClientTemplateData) (*models.ClientTemplate, error) {
+	if err := validateClientTemplate(data); err != nil {
+		return nil, err
+	}
+
+	clientTemplateMu.Lock()
+	defer clientTemplateMu.Unlock()
+
+	if err := checkClientTemplateNameFree(data.Name, 0); err != nil {
+		return nil, err
+	}
+	template := &models.ClientTemplate{
+		Name:        data.Name,
+		Description: data.Description,
+		Limits:      data.Limits,
+	}
+	if err := models.DB.Create(template).Error; err != nil {
+		return nil, fmt.Errorf("failed to create client template: %w", err)
+	}
+	return template, nil
+}
+
+// UpdateTemplate replaces a client template. With apply, the templates of
+// every client using it are applied to its limits again, and the IDs of those
+// clients are returned. If the limits of one client turn out invalid nothing
+// is changed.
+func (s *ClientService) UpdateTemplate(id uint, data *ClientTemplateData, apply bool) (*models.ClientTemplate, []uint, error) {
+	if err := validateClientTemplate(data); err != nil {
+		return nil, nil, err
+	}
+
+	clientTemplateMu.Lock()
+	defer clientTemplateMu.Unlock()
+
+	template, err := s.GetTemplate(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkClientTemplateNameFree(data.Name, id); err != nil {
+		return nil, nil, err
+	}
+	template.Name = data.Name
+	template.Description = data.Description
+	template.Limits = data.Limits
+
+	applied := []uint{}
+	var updated []models.Client
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(template).Error; err != nil {
+			return fmt.Errorf("failed to update client template: %w", err)
+		}
+		if !apply {
+			return nil
+		}
+
+		clients, err := templateClients(tx, id)
+		if err != nil {
+			return err
+		}
+		for _, client := range clients {
+			limits := client.ClientLimits
+			limits.ClientID = client.ID
+			if err := applyClientTemplates(tx, &limits, client.TemplateMaster, client.TemplateAdditional); err != nil {
+				return fmt.Errorf("client #%d: %w", client.ID, err)
+			}
+			if err := SiteBandwidthFor(limits).Validate(); err != nil {
+				return fmt.Errorf("client #%d: %w", client.ID, err)
+			}
+			if err := tx.Save(&limits).Error; err != nil {
+				return err
+			}
+			client.ClientLimits = limits
+			applied = append(applied, client.ID)
+			updated = append(updated, client)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, client := range updated {
+		if err := s.applyShellJail(client.LinuxUsername, client.ClientLimits); err != nil {
+			fmt.Printf("Warning: failed to jail Linux user '%s': %v\n", client.LinuxUsername, err)
+		}
+	}
+	return template, applied, nil
+}
+
+// DeleteTemplate deletes a client template no client uses anymore
+func (s *ClientService) DeleteTemplate(id uint) error {
+	clientTemplateMu.Lock()
+	defer clientTemplateMu.Unlock()
+
+	if _, err := s.GetTemplate(id); err != nil {
+		return err
+	}
+	clients, err := templateClients(models.DB, id)
+	if err != nil {
+		return err
+	}
+	if len(clients) > 0 {
+		ids := make([]string, len(clients))
+		for i, client := range clients {
+			ids[i] = "#" + strconv.FormatUint(uint64(client.ID), 10)
+		}
+		return fmt.Errorf("%w: %s", ErrClientTemplateInUse, strings.Join(ids, ", "))
+	}
+	return models.DB.Delete(&models.ClientTemplate{}, id).Error
+}
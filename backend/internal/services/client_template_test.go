@@ -0,0 +1,110 @@
+package services
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func templateLimits(t *testing.T, limits map[string]any) models.TemplateLimits {
+	t.Helper()
+	result := models.TemplateLimits{}
+	for name, value := range limits {
+		data, err := json.Marshal(value)
+		require.NoError(t, err)
+		result[name] = data
+	}
+	return result
+}
+
+func TestClientTemplates(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	service := NewClientService(cfg)
+
+	basic, err := service.CreateTemplate(&ClientTemplateData{
+		Name:   "Basic",
+		Limits: templateLimits(t, map[string]any{"limit_web_domain": 5, "limit_database": 2, "web_php_options": []string{"php-fpm"}}),
+	})
+	require.NoError(t, err)
+	extraDB, err := service.CreateTemplate(&ClientTemplateData{
+		Name:   "More databases",
+		Limits: templateLimits(t, map[string]any{"limit_database": 10}),
+	})
+	require.NoError(t, err)
+
+	_, err = service.CreateTemplate(&ClientTemplateData{Name: "basic"})
+	assert.ErrorIs(t, err, ErrClientTemplateExists)
+	_, err = service.CreateTemplate(&ClientTemplateData{Name: "Broken", Limits: templateLimits(t, map[string]any{"client_id": 1})})
+	assert.ErrorIs(t, err, ErrInvalidClientTemplate)
+	_, err = service.CreateTemplate(&ClientTemplateData{Name: "Broken", Limits: templateLimits(t, map[string]any{"limit_web_domain": "many"})})
+	assert.ErrorIs(t, err, ErrInvalidClientTemplate)
+
+	t.Run("templates fill the limits of a new client in order", func(t *testing.T) {
+		client, err := service.CreateClient(&CreateClientData{
+			Username:           "planned",
+			Password:           "testpass123",
+			ContactName:        "Planned Client",
+			Email:              "planned@example.com",
+			LimitWebDomain:     1,
+			LimitMailbox:       3,
+			TemplateMaster:     basic.ID,
+			TemplateAdditional: models.StringArray{strconv.Itoa(int(extraDB.ID))},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 5, client.ClientLimits.LimitWebDomain)
+		assert.Equal(t, 10, client.ClientLimits.LimitDatabase, "additional templates override the master")
+		assert.Equal(t, models.StringArray{"php-fpm"}, client.ClientLimits.WebPHPOptions)
+		assert.Equal(t, 3, client.ClientLimits.LimitMailbox, "limits no template sets are kept")
+	})
+
+	t.Run("unknown templates are refused", func(t *testing.T) {
+		_, err := service.CreateClient(&CreateClientData{
+			Username:       "unplanned",
+			Password:       "testpass123",
+			ContactName:    "Unplanned Client",
+			Email:          "unplanned@example.com",
+			TemplateMaster: 9999,
+		})
+		assert.ErrorIs(t, err, ErrClientTemplateNotFound)
+	})
+
+	t.Run("changes apply to linked clients on request", func(t *testing.T) {
+		_, applied, err := service.UpdateTemplate(basic.ID, &ClientTemplateData{
+			Name:   "Basic",
+			Limits: templateLimits(t, map[string]any{"limit_web_domain": 8}),
+		}, false)
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+
+		var client models.Client
+		require.NoError(t, models.DB.Preload("ClientLimits").Where("template_master = ?", basic.ID).First(&client).Error)
+		assert.Equal(t, 5, client.ClientLimits.LimitWebDomain)
+
+		_, applied, err = service.UpdateTemplate(extraDB.ID, &ClientTemplateData{
+			Name:   "More databases",
+			Limits: templateLimits(t, map[string]any{"limit_database": 20}),
+		}, true)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{client.ID}, applied)
+
+		require.NoError(t, models.DB.Preload("ClientLimits").First(&client, client.ID).Error)
+		assert.Equal(t, 8, client.ClientLimits.LimitWebDomain, "the whole template chain is applied again")
+		assert.Equal(t, 20, client.ClientLimits.LimitDatabase)
+	})
+
+	t.Run("templates in use are not deleted", func(t *testing.T) {
+		assert.ErrorIs(t, service.DeleteTemplate(basic.ID), ErrClientTemplateInUse)
+
+		unused, err := service.CreateTemplate(&ClientTemplateData{Name: "Unused"})
+		require.NoError(t, err)
+		require.NoError(t, service.DeleteTemplate(unused.ID))
+		_, err = service.GetTemplate(unused.ID)
+		assert.ErrorIs(t, err, ErrClientTemplateNotFound)
+	})
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestUpdateClientRollsBackWhenLimitsFail(t *testing.T) {
+	cfg := setupTestDB(t)
+	t.Setenv("SKIP_LINUX_USER", "true")
+	service := NewClientService(cfg)
+	client := createPurgeTestClient(t, service, "rollback")
+
+	companyName := "Changed Ltd"
+	assertUnchanged := func(t *testing.T) {
+		t.Helper()
+		reloaded, err := service.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.Equal(t, client.CompanyName, reloaded.CompanyName, "client fields must be rolled back")
+		assert.Equal(t, client.ClientLimits.LimitWebDomain, reloaded.ClientLimits.LimitWebDomain)
+	}
+
+	t.Run("invalid limits", func(t *testing.T) {
+		rateAfter := 1024
+		_, err := service.UpdateClient(client.ID, &UpdateClientData{
+			CompanyName: &companyName,
+			Limits:      &UpdateClientLimitsData{LimitWebRateAfter: &rateAfter},
+		})
+		assert.ErrorIs(t, err, ErrInvalidBandwidthLimit)
+		assertUnchanged(t)
+	})
+
+	t.Run("failing limits save", func(t *testing.T) {
+		failLimits := errors.New("limits table is locked")
+		require.NoError(t, models.DB.Callback().Update().Before("gorm:update").Register("test:fail_limits", func(db *gorm.DB) {
+			if db.Statement.Table == "client_limits" {
+				db.AddError(failLimits)
+			}
+		}))
+		t.Cleanup(func() { models.DB.Callback().Update().Remove("test:fail_limits") })
+
+		domains := 42
+		_, err := service.UpdateClient(client.ID, &UpdateClientData{
+			CompanyName: &companyName,
+			Limits:      &UpdateClientLimitsData{LimitWebDomain: &domains},
+		})
+		assert.ErrorIs(t, err, failLimits)
+		assertUnchanged(t)
+	})
+
+	t.Run("success returns the committed client", func(t *testing.T) {
+		domains := 7
+		updated, err := service.UpdateClient(client.ID, &UpdateClientData{
+			CompanyName: &companyName,
+			Limits:      &UpdateClientLimitsData{LimitWebDomain: &domains},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, companyName, updated.CompanyName)
+		assert.Equal(t, 7, updated.ClientLimits.LimitWebDomain)
+		assert.Empty(t, updated.User.PasswordHash)
+	})
+}
+
+func TestUpdateClientRejectsStaleUpdates(t *testing.T) {
+	cfg := setupTestDB(t)
+	t.Setenv("SKIP_LINUX_USER", "true")
+	service := NewClientService(cfg)
+	created := createPurgeTestClient(t, service, "stale")
+
+	// Two admins open the same client
+	first, err := service.GetClient(created.ID)
+	require.NoError(t, err)
+	second, err := service.GetClient(created.ID)
+	require.NoError(t, err)
+
+	firstName := "First Admin Ltd"
+	saved, err := service.UpdateClient(created.ID, &UpdateClientData{CompanyName: &firstName, UpdatedAt: &first.UpdatedAt})
+	require.NoError(t, err)
+
+	secondName := "Second Admin Ltd"
+	_, err = service.UpdateClient(created.ID, &UpdateClientData{CompanyName: &secondName, UpdatedAt: &second.UpdatedAt})
+	assert.ErrorIs(t, err, ErrClientModified)
+
+	reloaded, err := service.GetClient(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, firstName, reloaded.CompanyName, "the first update is not overwritten")
+
+	// Reloading picks up the new timestamp, and omitting it skips the check
+	_, err = service.UpdateClient(created.ID, &UpdateClientData{CompanyName: &secondName, UpdatedAt: &saved.UpdatedAt})
+	require.NoError(t, err)
+	_, err = service.UpdateClient(created.ID, &UpdateClientData{CompanyName: &firstName})
+	require.NoError(t, err)
+}
+
+func TestClientEmailAndUsernameAreCaseInsensitive(t *testing.T) {
+	cfg := setupTestDB(t)
+	t.Setenv("SKIP_LINUX_USER", "true")
+	service := NewClientService(cfg)
+
+	client, err := service.CreateClient(&CreateClientData{
+		Username:    " Alice ",
+		Password:    "testpass123",
+		ContactName: "Alice",
+		Email:       " Alice@Example.COM ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", client.Email)
+	assert.Equal(t, "alice", client.User.Username)
+
+	_, err = service.CreateClient(&CreateClientData{
+		Username:    "ALICE",
+		Password:    "testpass123",
+		ContactName: "Alice again",
+		Email:       "other@example.com",
+	})
+	assert.ErrorIs(t, err, ErrUserExists)
+	_, err = service.CreateClient(&CreateClientData{
+		Username:    "bob",
+		Password:    "testpass123",
+		ContactName: "Bob",
+		Email:       "ALICE@example.com",
+	})
+	assert.ErrorIs(t, err, ErrClientExists)
+
+	bob := createPurgeTestClient(t, service, "bob")
+	email := "Alice@Example.com"
+	_, err = service.UpdateClient(bob.ID, &UpdateClientData{Email: &email})
+	assert.ErrorIs(t, err, ErrClientExists)
+	email = " Bob.New@Example.com"
+	updated, err := service.UpdateClient(bob.ID, &UpdateClientData{Email: &email})
+	require.NoError(t, err)
+	assert.Equal(t, "bob.new@example.com", updated.Email)
+
+	// Logging in does not depend on case either
+	user, err := NewAuthService(cfg).Authenticate("Alice", "testpass123")
+	require.NoError(t, err)
+	assert.Equal(t, client.UserID, user.ID)
+}
+
+func TestTrashedClientCannotLogIn(t *testing.T) {
+	cfg := setupTestDB(t)
+	t.Setenv("SKIP_LINUX_USER", "true")
+	service := NewClientService(cfg)
+	auth := NewAuthService(cfg)
+
+	client := createPurgeTestClient(t, service, "trashed")
+	require.NoError(t, auth.CreateSession(client.UserID, "trashed-token", time.Now().Add(time.Hour)))
+	_, _, err := NewAPIKeyService().CreateAPIKey(&client.User, "deploy", nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, service.DeleteClient(client.ID))
+	_, err = auth.Authenticate("trashed", "testpass123")
+	assert.ErrorIs(t, err, ErrAccountDisabled)
+	var sessions, keys int64
+	models.DB.Model(&models.Session{}).Where("user_id = ?", client.UserID).Count(&sessions)
+	models.DB.Model(&models.APIKey{}).Where("user_id = ?", client.UserID).Count(&keys)
+	assert.Zero(t, sessions)
+	assert.Zero(t, keys)
+
+	_, err = service.RestoreClient(client.ID)
+	require.NoError(t, err)
+	user, err := auth.Authenticate("trashed", "testpass123")
+	require.NoError(t, err)
+	assert.False(t, user.Disabled)
+}
+
+func TestClientFieldValidation(t *testing.T) {
+	cfg := setupTestDB(t)
+	t.Setenv("SKIP_LINUX_USER", "true")
+	service := NewClientService(cfg)
+
+	_, err := service.CreateClient(&CreateClientData{
+		Username:    "invalid",
+		Password:    "testpass123",
+		ContactName: "Invalid",
+		Email:       "Invalid <invalid@example.com>",
+		Country:     "XX",
+		Gender:      "x",
+	})
+	var validationErr *ClientValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.ErrorIs(t, err, ErrInvalidClientData)
+	assert.Equal(t, []string{"country", "email", "gender"}, sortedKeys(validationErr.Fields))
+
+	client, err := service.CreateClient(&CreateClientData{
+		Username:    "valid",
+		Password:    "testpass123",
+		ContactName: "Valid",
+		Email:       "valid@example.com",
+		Country:     " id ",
+		Gender:      "F",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ID", client.Country)
+	assert.Equal(t, "f", client.Gender)
+
+	for _, email := range []string{"not an email", "@example.com", ""} {
+		_, err = service.UpdateClient(client.ID, &UpdateClientData{Email: &email})
+		assert.ErrorIs(t, err, ErrInvalidClientData, email)
+	}
+	empty := ""
+	updated, err := service.UpdateClient(client.ID, &UpdateClientData{Country: &empty, Gender: &empty})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Country)
+	assert.Empty(t, updated.Gender)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestClientBulkAction(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	service := NewClientService(cfg)
+
+	first := createPurgeTestClient(t, service, "bulkone")
+	second := createPurgeTestClient(t, service, "bulktwo")
+
+	t.Run("rejects unknown actions and empty lists", func(t *testing.T) {
+		_, err := service.BulkAction("suspend", []uint{first.ID})
+		assert.ErrorIs(t, err, ErrInvalidBulkAction)
+		_, err = service.BulkAction(BulkActionLock, nil)
+		assert.ErrorIs(t, err, ErrInvalidBulkAction)
+	})
+
+	t.Run("lock reports each client and skips repeated ids", func(t *testing.T) {
+		results, err := service.BulkAction(BulkActionLock, []uint{first.ID, 99999, second.ID, first.ID})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+
+		assert.True(t, results[0].Success)
+		assert.False(t, results[1].Success)
+		assert.Equal(t, uint(99999), results[1].ClientID)
+		assert.NotEmpty(t, results[1].Error)
+		assert.True(t, results[2].Success)
+
+		for _, id := range []uint{first.ID, second.ID} {
+			client, err := service.GetClient(id)
+			require.NoError(t, err)
+			assert.True(t, client.Locked)
+		}
+	})
+
+	t.Run("unlock and cancel", func(t *testing.T) {
+		_, err := service.BulkAction(BulkActionUnlock, []uint{first.ID})
+		require.NoError(t, err)
+		_, err = service.BulkAction(BulkActionCancel, []uint{second.ID})
+		require.NoError(t, err)
+
+		client, err := service.GetClient(first.ID)
+		require.NoError(t, err)
+		assert.False(t, client.Locked)
+		client, err = service.GetClient(second.ID)
+		require.NoError(t, err)
+		assert.True(t, client.Canceled)
+	})
+
+	t.Run("delete moves clients to the trash", func(t *testing.T) {
+		results, err := service.BulkAction(BulkActionDelete, []uint{first.ID, second.ID})
+		require.NoError(t, err)
+		for _, result := range results {
+			assert.True(t, result.Success, result.Error)
+		}
+
+		_, err = service.GetClient(first.ID)
+		assert.ErrorIs(t, err, ErrClientNotFound)
+		trashed, err := service.GetTrashedClients()
+		require.NoError(t, err)
+		assert.Len(t, trashed, 2)
+	})
+}
+
+func TestNewHomeDir(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	cfg.Paths.HomeBase = "/var/www"
+	service := NewClientService(cfg)
+
+	reseller := createPurgeTestClient(t, service, "reseller")
+	plain := createPurgeTestClient(t, service, "plain")
+	require.NoError(t, models.DB.Model(reseller).Update("reseller", true).Error)
+
+	assert.Equal(t, "/var/www/bob", service.newHomeDir("bob", 0))
+	assert.Equal(t, "/var/www/resellers/reseller/bob", service.newHomeDir("bob", reseller.ID))
+	assert.Equal(t, "/var/www/bob", service.newHomeDir("bob", plain.ID), "only resellers get a tree")
+	assert.Equal(t, "/var/www/bob", service.newHomeDir("bob", 9999))
+}
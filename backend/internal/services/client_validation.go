@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"net/mail"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidClientData = errors.New("invalid client data")
+
+// ClientValidationError lists the client fields that failed validation, by
+// their JSON name
+type ClientValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ClientValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	problems := make([]string, len(names))
+	for i, name := range names {
+		problems[i] = name + ": " + e.Fields[name]
+	}
+	return ErrInvalidClientData.Error() + ": " + strings.Join(problems, "; ")
+}
+
+func (e *ClientValidationError) Is(target error) bool {
+	return target == ErrInvalidClientData
+}
+
+// FieldErrors returns the problem of each invalid field, for the API response
+func (e *ClientValidationError) FieldErrors() map[string]string {
+	return e.Fields
+}
+
+// validateClientFields checks the email, country and gender of a client,
+// normalizing them in place. Nil fields are not being set and are skipped.
+func validateClientFields(email, country, gender *string) error {
+	fields := map[string]string{}
+	if email != nil {
+		*email = NormalizeEmail(*email)
+		if address, err := mail.ParseAddress(*email); err != nil || address.Address != *email {
+			fields["email"] = "must be a valid email address"
+		}
+	}
+	if country != nil {
+		*country = strings.ToUpper(strings.TrimSpace(*country))
+		if *country != "" && !ValidCountryCode(*country) {
+			fields["country"] = "must be an ISO 3166-1 alpha-2 country code"
+		}
+	}
+	if gender != nil {
+		*gender = strings.ToLower(strings.TrimSpace(*gender))
+		if *gender != "" && *gender != "m" && *gender != "f" {
+			fields["gender"] = "must be m, f or empty"
+		}
+	}
+	if len(fields) > 0 {
+		return &ClientValidationError{Fields: fields}
+	}
+	return nil
+}
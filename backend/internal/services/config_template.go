@@ -0,0 +1,362 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+var (
+	ErrConfigTemplateNotFound = errors.New("config template not found")
+	ErrInvalidConfigTemplate  = errors.New("invalid config template")
+)
+
+// Names of the editable config templates
+const (
+	ConfigTemplateNginx  = "nginx"
+	ConfigTemplatePHPFPM = "phpfpm"
+)
+
+// maxConfigTemplateSize caps the source of a config template
+const maxConfigTemplateSize = 64 << 10
+
+// NginxSiteTemplateData is what the nginx template renders a site from
+type NginxSiteTemplateData struct {
+	Domain      string
+	ServerNames string // the domain followed by its aliases, space separated
+	Root        string
+	PoolSocket  string // PHP-FPM socket file under /run/php
+	ConnZone    string // limit_conn zone of the site, used when Bandwidth.Connections is set
+	Bandwidth   SiteBandwidth
+}
+
+// PHPFPMPoolTemplateData is what the phpfpm template renders a pool from
+type PHPFPMPoolTemplateData struct {
+	Name  string
+	User  string
+	Group string
+}
+
+const defaultNginxSiteTemplate = `{{if .Bandwidth.Connections}}limit_conn_zone $binary_remote_addr zone={{.ConnZone}}:10m;
+
+{{end}}server {
+    listen 80;
+    listen [::]:80;
+    server_name {{.ServerNames}};
+    root {{.Root}};
+    index index.php index.html index.htm;
+
+{{if .Bandwidth.Connections}}    limit_conn {{.ConnZone}} {{.Bandwidth.Connections}};
+{{end}}{{if .Bandwidth.RateKB}}    limit_rate {{.Bandwidth.RateKB}}k;
+{{if .Bandwidth.RateAfterKB}}    limit_rate_after {{.Bandwidth.RateAfterKB}}k;
+{{end}}{{end}}{{if or .Bandwidth.Connections .Bandwidth.RateKB}}
+{{end}}    location / {
+        try_files $uri $uri/ =404;
+    }
+
+    location ~ \.php$ {
+        include snippets/fastcgi-php.conf;
+        fastcgi_pass unix:/run/php/{{.PoolSocket}};
+    }
+
+    location ~ /\.ht {
+        deny all;
+    }
+}
+`
+
+const defaultPHPFPMPoolTemplate = `[{{.Name}}]
+user = {{.User}}
+group = {{.Group}}
+listen = /run/php/php-fpm-{{.Name}}.sock
+listen.owner = www-data
+listen.group = www-data
+listen.mode = 0660
+
+pm = dynamic
+pm.max_children = 50
+pm.start_servers = 5
+pm.min_spare_servers = 5
+pm.max_spare_servers = 35
+pm.max_requests = 500
+
+php_admin_value[memory_limit] = 128M
+php_admin_value[max_execution_time] = 30
+php_admin_value[disable_functions] = exec,passthru,shell_exec,system,proc_open,popen
+
+`
+
+// configTemplateKind describes an editable template: its file, built-in
+// source and the sample contexts a new source must render
+type configTemplateKind struct {
+	file    string
+	builtin string
+	samples []interface{}
+	check   func(sample interface{}, rendered string) error
+}
+
+var configTemplateKinds = map[string]configTemplateKind{
+	ConfigTemplateNginx: {
+		file:    "nginx-site.tmpl",
+		builtin: defaultNginxSiteTemplate,
+		samples: []interface{}{
+			NginxSiteTemplateData{Domain: "example.com", ServerNames: "example.com", Root: "/home/client1/web/example.com", PoolSocket: "php-fpm-client1.sock"},
+			NginxSiteTemplateData{
+				Domain:      "example.com",
+				ServerNames: "example.com *.example.com",
+				Root:        "/home/client1/web/example.com",
+				PoolSocket:  "php-fpm-client1.sock",
+				ConnZone:    "conn_example_dcom",
+				Bandwidth:   SiteBandwidth{RateKB: 512, RateAfterKB: 1024, Connections: 10},
+			},
+		},
+		check: func(sample interface{}, rendered string) error {
+			_, err := CheckSiteServerNames(sample.(NginxSiteTemplateData).Domain, rendered)
+			return err
+		},
+	},
+	ConfigTemplatePHPFPM: {
+		file:    "phpfpm-pool.tmpl",
+		builtin: defaultPHPFPMPoolTemplate,
+		samples: []interface{}{
+			PHPFPMPoolTemplateData{Name: "client1", User: "client1", Group: "client1"},
+		},
+		check: func(sample interface{}, rendered string) error {
+			name := sample.(PHPFPMPoolTemplateData).Name
+			if !strings.Contains(rendered, "["+name+"]") {
+				return fmt.Errorf("the pool section [%s] is missing, name it {{.Name}}", name)
+			}
+			return nil
+		},
+	},
+}
+
+// configTemplates holds the templates in use, shared by every service that
+// generates configs
+var configTemplates = struct {
+	sync.RWMutex
+	dir    string
+	parsed map[string]*template.Template
+	custom map[string]string // sources of customized templates
+}{}
+
+// ConfigTemplate is the source of an editable config template
+type ConfigTemplate struct {
+	Name       string `json:"name"`
+	Source     string `json:"source"`
+	Customized bool   `json:"customized"` // false for the built-in template
+	Preview    string `json:"preview"`    // rendered against a sample context
+}
+
+// ConfigTemplateService views and edits the templates new nginx sites and
+// PHP-FPM pools are generated from
+type ConfigTemplateService struct{}
+
+func NewConfigTemplateService() *ConfigTemplateService {
+	return &ConfigTemplateService{}
+}
+
+// LoadConfigTemplates reads the customized templates stored in dir. A missing
+// file leaves the built-in template in use, and so does one that no longer
+// parses, with a warning.
+func LoadConfigTemplates(dir string) error {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("failed to create config templates directory: %w", err)
+	}
+
+	parsed := map[string]*template.Template{}
+	custom := map[string]string{}
+	for name, kind := range configTemplateKinds {
+		data, err := os.ReadFile(filepath.Join(dir, kind.file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s template: %w", name, err)
+		}
+		tmpl, err := parseConfigTemplate(name, string(data))
+		if err != nil {
+			fmt.Printf("Warning: using the built-in %s template: %v\n", name, err)
+			continue
+		}
+		parsed[name] = tmpl
+		custom[name] = string(data)
+	}
+
+	configTemplates.Lock()
+	defer configTemplates.Unlock()
+	configTemplates.dir = dir
+	configTemplates.parsed = parsed
+	configTemplates.custom = custom
+	return nil
+}
+
+// parseConfigTemplate parses source and renders it against the samples of
+// the template kind, so a template that cannot generate a working config is
+// refused before it is used
+func parseConfigTemplate(name, source string) (*template.Template, error) {
+	kind, ok := configTemplateKinds[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrConfigTemplateNotFound, name)
+	}
+	if len(source) > maxConfigTemplateSize {
+		return nil, fmt.Errorf("%w: larger than %d KB", ErrInvalidConfigTemplate, maxConfigTemplateSize>>10)
+	}
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("%w: the template is empty", ErrInvalidConfigTemplate)
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfigTemplate, err)
+	}
+	for _, sample := range kind.samples {
+		rendered, err := executeConfigTemplate(tmpl, sample)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidConfigTemplate, err)
+		}
+		if err := kind.check(sample, rendered); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidConfigTemplate, err)
+		}
+	}
+	return tmpl, nil
+}
+
+func executeConfigTemplate(tmpl *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// builtinConfigTemplates are the parsed built-in templates
+var builtinConfigTemplates = func() map[string]*template.Template {
+	parsed := map[string]*template.Template{}
+	for name, kind := range configTemplateKinds {
+		parsed[name] = template.Must(template.New(name).Option("missingkey=error").Parse(kind.builtin))
+	}
+	return parsed
+}()
+
+// renderConfigTemplate renders the template in use under name
+func renderConfigTemplate(name string, data interface{}) (string, error) {
+	configTemplates.RLock()
+	tmpl := configTemplates.parsed[name]
+	configTemplates.RUnlock()
+	if tmpl == nil {
+		tmpl = builtinConfigTemplates[name]
+	}
+	rendered, err := executeConfigTemplate(tmpl, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return rendered, nil
+}
+
+// GetTemplates returns every editable template
+func (s *ConfigTemplateService) GetTemplates() ([]ConfigTemplate, error) {
+	names := make([]string, 0, len(configTemplateKinds))
+	for name := range configTemplateKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	templates := make([]ConfigTemplate, 0, len(names))
+	for _, name := range names {
+		tmpl, err := s.GetTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *tmpl)
+	}
+	return templates, nil
+}
+
+// GetTemplate returns the source of the template in use under name
+func (s *ConfigTemplateService) GetTemplate(name string) (*ConfigTemplate, error) {
+	kind, ok := configTemplateKinds[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrConfigTemplateNotFound, name)
+	}
+
+	configTemplates.RLock()
+	source, customized := configTemplates.custom[name]
+	configTemplates.RUnlock()
+	if !customized {
+		source = kind.builtin
+	}
+
+	preview, err := renderConfigTemplate(name, kind.samples[len(kind.samples)-1])
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigTemplate{Name: name, Source: source, Customized: customized, Preview: preview}, nil
+}
+
+// UpdateTemplate validates source and stores it as the template under name.
+// Configs generated from then on use it, existing sites and pools are kept.
+func (s *ConfigTemplateService) UpdateTemplate(name, source string) (*ConfigTemplate, error) {
+	tmpl, err := parseConfigTemplate(name, source)
+	if err != nil {
+		return nil, err
+	}
+	if err := storeConfigTemplate(name, source, tmpl); err != nil {
+		return nil, err
+	}
+	return s.GetTemplate(name)
+}
+
+// storeConfigTemplate writes a customized template and puts it in use
+func storeConfigTemplate(name, source string, tmpl *template.Template) error {
+	configTemplates.Lock()
+	defer configTemplates.Unlock()
+	if configTemplates.dir == "" {
+		return fmt.Errorf("config templates directory is not loaded")
+	}
+
+	path := filepath.Join(configTemplates.dir, configTemplateKinds[name].file)
+	if err := os.WriteFile(path+".tmp", []byte(source), 0640); err != nil {
+		return fmt.Errorf("failed to write %s template: %w", name, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("failed to write %s template: %w", name, err)
+	}
+	configTemplates.parsed[name] = tmpl
+	configTemplates.custom[name] = source
+	return nil
+}
+
+// ResetTemplate goes back to the built-in template under name
+func (s *ConfigTemplateService) ResetTemplate(name string) (*ConfigTemplate, error) {
+	if _, ok := configTemplateKinds[name]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrConfigTemplateNotFound, name)
+	}
+	if err := removeConfigTemplate(name); err != nil {
+		return nil, err
+	}
+	return s.GetTemplate(name)
+}
+
+// removeConfigTemplate deletes a customized template, the built-in one is
+// used from then on
+func removeConfigTemplate(name string) error {
+	configTemplates.Lock()
+	defer configTemplates.Unlock()
+	if configTemplates.dir != "" {
+		path := filepath.Join(configTemplates.dir, configTemplateKinds[name].file)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s template: %w", name, err)
+		}
+	}
+	delete(configTemplates.parsed, name)
+	delete(configTemplates.custom, name)
+	return nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestConfigTemplates loads the templates of a temporary directory and
+// puts the built-in ones back after the test
+func loadTestConfigTemplates(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, LoadConfigTemplates(dir))
+	t.Cleanup(func() {
+		configTemplates.Lock()
+		defer configTemplates.Unlock()
+		configTemplates.dir = ""
+		configTemplates.parsed = nil
+		configTemplates.custom = nil
+	})
+	return dir
+}
+
+func TestConfigTemplateServiceUpdatesTemplates(t *testing.T) {
+	dir := loadTestConfigTemplates(t)
+	service := NewConfigTemplateService()
+	nginx, _, _ := newFakeNginx()
+	builtin := nginx.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock")
+
+	tmpl, err := service.GetTemplate(ConfigTemplateNginx)
+	require.NoError(t, err)
+	assert.False(t, tmpl.Customized)
+	assert.Equal(t, defaultNginxSiteTemplate, tmpl.Source)
+	assert.Contains(t, tmpl.Preview, "limit_conn conn_example_dcom 10;")
+
+	source := strings.Replace(defaultNginxSiteTemplate, "index index.php", "client_max_body_size 64m;\n    index index.php", 1)
+	tmpl, err = service.UpdateTemplate(ConfigTemplateNginx, source)
+	require.NoError(t, err)
+	assert.True(t, tmpl.Customized)
+	assert.FileExists(t, filepath.Join(dir, "nginx-site.tmpl"))
+	assert.Contains(t, nginx.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock"), "client_max_body_size 64m;")
+
+	// A restart picks the stored template up again
+	require.NoError(t, LoadConfigTemplates(dir))
+	tmpl, err = service.GetTemplate(ConfigTemplateNginx)
+	require.NoError(t, err)
+	assert.Equal(t, source, tmpl.Source)
+
+	tmpl, err = service.ResetTemplate(ConfigTemplateNginx)
+	require.NoError(t, err)
+	assert.False(t, tmpl.Customized)
+	assert.NoFileExists(t, filepath.Join(dir, "nginx-site.tmpl"))
+	assert.Equal(t, builtin, nginx.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock"))
+
+	_, err = service.UpdateTemplate(ConfigTemplatePHPFPM, "[{{.Name}}]\nuser = {{.User}}\ngroup = {{.Group}}\npm = ondemand\n")
+	require.NoError(t, err)
+	config, err := NewPHPFPMService("", 0).GeneratePoolConfig("shop", "shop", "www-data")
+	require.NoError(t, err)
+	assert.Equal(t, "[shop]\nuser = shop\ngroup = www-data\npm = ondemand\n", config)
+
+	_, err = service.GetTemplate("apache")
+	assert.ErrorIs(t, err, ErrConfigTemplateNotFound)
+}
+
+func TestConfigTemplateServiceRejectsInvalidTemplates(t *testing.T) {
+	dir := loadTestConfigTemplates(t)
+	service := NewConfigTemplateService()
+
+	invalid := map[string]string{
+		"does not parse":         "server { server_name {{.Domain}; }",
+		"unknown field":          "server { server_name {{.Domain}}; root {{.DocumentRoot}}; }",
+		"no server_name":         "server { root {{.Root}}; }",
+		"server_name of another": "server { server_name shop.example.net; }",
+		"empty":                  "  \n",
+	}
+	for name, source := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.UpdateTemplate(ConfigTemplateNginx, source)
+			assert.ErrorIs(t, err, ErrInvalidConfigTemplate)
+		})
+	}
+	_, err := service.UpdateTemplate(ConfigTemplatePHPFPM, "user = {{.User}}\n")
+	assert.ErrorIs(t, err, ErrInvalidConfigTemplate, "the pool section is required")
+
+	// A template broken by hand falls back to the built-in one on load
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nginx-site.tmpl"), []byte("server { {{.Nope}} }"), 0640))
+	require.NoError(t, LoadConfigTemplates(dir))
+	tmpl, err := service.GetTemplate(ConfigTemplateNginx)
+	require.NoError(t, err)
+	assert.False(t, tmpl.Customized)
+}
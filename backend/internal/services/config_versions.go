@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrConfigVersionNotFound = errors.New("config version not found")
+
+// MaxConfigVersions is how many previous versions are kept of each config file
+const MaxConfigVersions = 20
+
+// configVersionLayout names version files, so sorting by name sorts by age
+const configVersionLayout = "20060102T150405.000000000Z"
+
+// configVersionsDir is the directory next to the nginx and php-fpm configs
+// that holds the previous versions of their files
+const configVersionsDir = "r-panel-versions"
+
+// ConfigVersion is a saved previous version of a config file
+type ConfigVersion struct {
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+// configVersions keeps the previous contents of one config file in dir, one
+// file per version
+type configVersions struct {
+	fs  FileSystem
+	dir string
+}
+
+// save stores content as the newest version and drops the oldest versions
+// beyond MaxConfigVersions. Content that is already saved as a version is not
+// saved again, so rolling back and forth does not push out older history.
+func (v configVersions) save(content []byte) error {
+	if err := v.fs.MkdirAll(v.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create config versions directory: %w", err)
+	}
+	saved, err := v.list()
+	if err != nil {
+		return err
+	}
+	for _, version := range saved {
+		if existing, err := v.read(version.Version); err == nil && bytes.Equal(existing, content) {
+			return nil
+		}
+	}
+
+	now := time.Now().UTC()
+	version := now.Format(configVersionLayout)
+	// Two saves within the clock's resolution must not overwrite each other
+	for v.exists(version) {
+		now = now.Add(time.Nanosecond)
+		version = now.Format(configVersionLayout)
+	}
+	if err := v.fs.WriteFile(filepath.Join(v.dir, version+".bak"), content, 0600); err != nil {
+		return fmt.Errorf("failed to save config version: %w", err)
+	}
+
+	versions, err := v.list()
+	if err != nil {
+		return err
+	}
+	for _, old := range versions[min(len(versions), MaxConfigVersions):] {
+		v.fs.Remove(filepath.Join(v.dir, old.Version+".bak"))
+	}
+	return nil
+}
+
+func (v configVersions) exists(version string) bool {
+	_, err := v.fs.Stat(filepath.Join(v.dir, version+".bak"))
+	return err == nil
+}
+
+// list returns the saved versions, newest first
+func (v configVersions) list() ([]ConfigVersion, error) {
+	versions := []ConfigVersion{}
+	entries, err := v.fs.ReadDir(v.dir)
+	if err != nil {
+		// Nothing was saved yet
+		return versions, nil
+	}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".bak")
+		if !ok {
+			continue
+		}
+		createdAt, err := time.Parse(configVersionLayout, name)
+		if err != nil {
+			continue
+		}
+		version := ConfigVersion{Version: name, CreatedAt: createdAt}
+		if info, err := entry.Info(); err == nil {
+			version.Size = info.Size()
+		}
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version > versions[j].Version })
+	return versions, nil
+}
+
+// read returns the content of version. Only names save produced are accepted,
+// so version can never point outside dir.
+func (v configVersions) read(version string) ([]byte, error) {
+	if _, err := time.Parse(configVersionLayout, version); err != nil {
+		return nil, ErrConfigVersionNotFound
+	}
+	data, err := v.fs.ReadFile(filepath.Join(v.dir, version+".bak"))
+	if err != nil {
+		return nil, ErrConfigVersionNotFound
+	}
+	return data, nil
+}
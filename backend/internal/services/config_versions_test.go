@@ -0,0 +1,82 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigVersionsKeepsNewestVersions(t *testing.T) {
+	fsys := newFakeFileSystem()
+	versions := configVersions{fs: fsys, dir: "/etc/nginx/r-panel-versions/example.com"}
+
+	for i := 0; i < MaxConfigVersions+5; i++ {
+		require.NoError(t, versions.save([]byte(fmt.Sprintf("config %d", i))))
+	}
+
+	list, err := versions.list()
+	require.NoError(t, err)
+	require.Len(t, list, MaxConfigVersions)
+	newest, err := versions.read(list[0].Version)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("config %d", MaxConfigVersions+4), string(newest))
+	oldest, err := versions.read(list[len(list)-1].Version)
+	require.NoError(t, err)
+	assert.Equal(t, "config 5", string(oldest))
+
+	for _, version := range []string{"", "missing", "../../sites-available/example.com", "20200101T000000.000000000Z"} {
+		_, err := versions.read(version)
+		assert.ErrorIs(t, err, ErrConfigVersionNotFound, version)
+	}
+}
+
+func TestNginxServiceRestoreSiteVersion(t *testing.T) {
+	service, fsys, _ := newFakeNginx()
+	require.NoError(t, service.CreateSite("example.com", "server { listen 80; }"))
+
+	versions, err := service.GetSiteVersions("example.com")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	require.NoError(t, service.UpdateSite("example.com", "server { listen 8080; }"))
+	// Writing the same config again is not a new version
+	require.NoError(t, service.UpdateSite("example.com", "server { listen 8080; }"))
+	versions, err = service.GetSiteVersions("example.com")
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, int64(len("server { listen 80; }")), versions[0].Size)
+	assert.Contains(t, fsys.files, "/etc/nginx/r-panel-versions/example.com/"+versions[0].Version+".bak")
+
+	restored, err := service.RestoreSiteVersion("example.com", versions[0].Version)
+	require.NoError(t, err)
+	assert.Equal(t, "server { listen 80; }", restored)
+	assert.Equal(t, restored, string(fsys.files["/etc/nginx/sites-available/example.com"]))
+
+	// The config the restore replaced can be restored in turn
+	versions, err = service.GetSiteVersions("example.com")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	undo, err := service.siteVersions("example.com").read(versions[0].Version)
+	require.NoError(t, err)
+	assert.Equal(t, "server { listen 8080; }", string(undo))
+
+	// Rolling back and forth does not add versions that push out older ones
+	for i := 0; i < 3; i++ {
+		for _, version := range versions {
+			_, err = service.RestoreSiteVersion("example.com", version.Version)
+			require.NoError(t, err)
+		}
+	}
+	again, err := service.GetSiteVersions("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, versions, again)
+
+	_, err = service.RestoreSiteVersion("example.com", "not-a-version")
+	assert.ErrorIs(t, err, ErrConfigVersionNotFound)
+	_, err = service.GetSiteVersions("missing.com")
+	assert.ErrorIs(t, err, ErrSiteNotFound)
+	_, err = service.RestoreSiteVersion("../escape", versions[0].Version)
+	assert.ErrorIs(t, err, ErrInvalidDomain)
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"r-panel/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// customerNoCounter is the counters row customer numbers are drawn from
+const customerNoCounter = "customer_no"
+
+// GenerateCustomerNo draws the next customer number from the sequence
+func (s *ClientService) GenerateCustomerNo() (string, error) {
+	var customerNo string
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		customerNo, err = s.nextCustomerNo(tx)
+		return err
+	})
+	return customerNo, err
+}
+
+// nextCustomerNo draws the next free customer number within tx. The counter
+// row stays locked until tx ends, so concurrent clients get distinct numbers,
+// and a rolled back tx gives its number back. Numbers taken outside the
+// sequence, by restored clients or before it existed, are skipped.
+func (s *ClientService) nextCustomerNo(tx *gorm.DB) (string, error) {
+	format, err := s.cfg.Clients.CustomerNo.WithDefaults()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		result := tx.Model(&models.Counter{}).Where("name = ?", customerNoCounter).
+			Update("value", gorm.Expr("value + 1"))
+		if result.Error != nil {
+			return "", result.Error
+		}
+		if result.RowsAffected == 0 {
+			// First number: continue from the clients created before the sequence
+			var count int64
+			if err := tx.Unscoped().Model(&models.Client{}).Count(&count).Error; err != nil {
+				return "", err
+			}
+			counter := models.Counter{Name: customerNoCounter, Value: uint64(count)}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&counter).Error; err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		var counter models.Counter
+		if err := tx.Where("name = ?", customerNoCounter).First(&counter).Error; err != nil {
+			return "", err
+		}
+		customerNo := format.Format(counter.Value)
+
+		var taken int64
+		if err := tx.Unscoped().Model(&models.Client{}).Where("customer_no = ?", customerNo).Count(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			return customerNo, nil
+		}
+	}
+}
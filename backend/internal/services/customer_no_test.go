@@ -0,0 +1,68 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomerNumbersAreUniqueUnderConcurrentCreates(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	cfg.Security.BcryptCost = 4
+	service := NewClientService(cfg)
+
+	const clients = 12
+	var wg sync.WaitGroup
+	errs := make([]error, clients)
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			username := fmt.Sprintf("racer%d", i)
+			_, errs[i] = service.CreateClient(&CreateClientData{
+				Username:    username,
+				Password:    "testpass123",
+				ContactName: "Racing Client",
+				Email:       username + "@example.com",
+			})
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	var numbers []string
+	require.NoError(t, models.DB.Model(&models.Client{}).Order("customer_no").Pluck("customer_no", &numbers).Error)
+	want := []string{}
+	for i := 1; i <= clients; i++ {
+		want = append(want, fmt.Sprintf("C%d", i))
+	}
+	assert.ElementsMatch(t, want, numbers, "unique and without gaps")
+}
+
+func TestCustomerNumbersSkipTakenOnes(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	cfg.Clients.CustomerNo.Digits = 3
+	service := NewClientService(cfg)
+
+	first := createPurgeTestClient(t, service, "first")
+	assert.Equal(t, "C001", first.CustomerNo)
+
+	// A restored client brought its own number along
+	require.NoError(t, models.DB.Model(&models.Client{}).Where("id = ?", first.ID).Update("customer_no", "C002").Error)
+	second := createPurgeTestClient(t, service, "second")
+	assert.Equal(t, "C003", second.CustomerNo)
+
+	// A deleted client keeps its number, it is not handed out again
+	require.NoError(t, service.DeleteClient(second.ID))
+	third := createPurgeTestClient(t, service, "third")
+	assert.Equal(t, "C004", third.CustomerNo)
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"r-panel/internal/models"
+
+	"golang.org/x/sync/errgroup"
+)
+
+var ErrMySQLNotConfigured = errors.New("MySQL is not configured")
+
+// DashboardBackups is how many of the latest backups a dashboard lists
+const DashboardBackups = 5
+
+// DashboardCacheTTL is how long a dashboard is served from cache, so a busy
+// home screen does not keep reading /proc and asking systemctl
+const DashboardCacheTTL = 5 * time.Second
+
+// DashboardCounts counts what the panel manages. For a client's dashboard only
+// their own sites and databases are counted.
+type DashboardCounts struct {
+	Clients   int64 `json:"clients"`
+	Users     int64 `json:"users"`
+	Sites     int   `json:"sites"`
+	Databases int   `json:"databases"`
+}
+
+// Dashboard is everything the home screen shows, assembled in one call
+type Dashboard struct {
+	Counts      DashboardCounts `json:"counts"`
+	Stats       *SystemStats    `json:"stats"`
+	Services    []ServiceStatus `json:"services"`
+	Backups     []BackupFile    `json:"backups,omitempty"` // only on the server-wide dashboard
+	GeneratedAt time.Time       `json:"generated_at"`
+
+	// Unavailable lists parts that could not be collected, keyed by part
+	Unavailable map[string]string `json:"unavailable,omitempty"`
+
+	mu sync.Mutex
+}
+
+// unavailable records why part of d could not be collected
+func (d *Dashboard) unavailable(part string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.Unavailable == nil {
+		d.Unavailable = map[string]string{}
+	}
+	d.Unavailable[part] = err.Error()
+}
+
+type dashboardEntry struct {
+	dashboard *Dashboard
+	expires   time.Time
+}
+
+// DashboardService assembles dashboards from the other services concurrently
+// and caches them for DashboardCacheTTL
+type DashboardService struct {
+	system  *SystemService
+	nginx   *NginxService
+	backups *BackupService
+	mysql   *MySQLServers // nil when MySQL is not configured
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]dashboardEntry
+}
+
+func NewDashboardService(system *SystemService, nginx *NginxService, backups *BackupService, mysql *MySQLServers) *DashboardService {
+	return &DashboardService{
+		system:  system,
+		nginx:   nginx,
+		backups: backups,
+		mysql:   mysql,
+		now:     time.Now,
+		cache:   map[string]dashboardEntry{},
+	}
+}
+
+// GetDashboard returns the server-wide dashboard shown to staff
+func (s *DashboardService) GetDashboard() (*Dashboard, error) {
+	return s.cached("server", func(g *errgroup.Group, ctx context.Context, d *Dashboard) {
+		g.Go(func() error {
+			return models.DB.WithContext(ctx).Model(&models.Client{}).Count(&d.Counts.Clients).Error
+		})
+		g.Go(func() error {
+			return models.DB.WithContext(ctx).Model(&models.User{}).Count(&d.Counts.Users).Error
+		})
+		g.Go(func() error {
+			sites, err := s.nginx.GetSites()
+			if err != nil {
+				d.unavailable("sites", err)
+			}
+			d.Counts.Sites = len(sites)
+			return nil
+		})
+		g.Go(func() error {
+			databases, err := s.countDatabases()
+			if err != nil {
+				d.unavailable("databases", err)
+			}
+			d.Counts.Databases = databases
+			return nil
+		})
+		g.Go(func() error {
+			result, err := s.backups.ListBackupsFiltered(BackupListOptions{Page: 1, Limit: DashboardBackups})
+			if err != nil {
+				d.unavailable("backups", err)
+				return nil
+			}
+			d.Backups = result.Data
+			return nil
+		})
+	})
+}
+
+// GetClientDashboard returns the dashboard of a client portal user. client is
+// nil for a user that has no client.
+func (s *DashboardService) GetClientDashboard(user *models.User, client *models.Client) (*Dashboard, error) {
+	key := "user:" + strconv.FormatUint(uint64(user.ID), 10)
+	return s.cached(key, func(g *errgroup.Group, ctx context.Context, d *Dashboard) {
+		d.Counts.Users = 1
+		if client == nil {
+			return
+		}
+		d.Counts.Clients = 1
+		g.Go(func() error {
+			var databases int64
+			err := models.DB.WithContext(ctx).Model(&models.ClientDatabase{}).
+				Where("client_id = ?", client.ID).Count(&databases).Error
+			d.Counts.Databases = int(databases)
+			return err
+		})
+		g.Go(func() error {
+			sites, err := s.nginx.GetSitesByUser(client.User.Username)
+			if err != nil {
+				d.unavailable("sites", err)
+			}
+			d.Counts.Sites = len(sites)
+			return nil
+		})
+	})
+}
+
+// cached returns the dashboard cached under key, or builds a new one. collect
+// starts the parts specific to the dashboard on g, the system stats and
+// service statuses every dashboard shows are collected alongside them. A
+// failing part is listed in Unavailable, only database errors fail the call.
+func (s *DashboardService) cached(key string, collect func(g *errgroup.Group, ctx context.Context, d *Dashboard)) (*Dashboard, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && s.now().Before(entry.expires) {
+		return entry.dashboard, nil
+	}
+
+	d := &Dashboard{Services: []ServiceStatus{}}
+	g, ctx := errgroup.WithContext(context.Background())
+	g.Go(func() error {
+		stats, err := s.system.GetStats(ctx)
+		if err != nil {
+			d.unavailable("stats", err)
+		}
+		d.Stats = stats
+		return nil
+	})
+	g.Go(func() error {
+		statuses, err := s.system.GetServicesStatus(ctx, MonitoredServices)
+		if err != nil {
+			d.unavailable("services", err)
+			return nil
+		}
+		d.Services = statuses
+		return nil
+	})
+	collect(g, ctx, d)
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	d.GeneratedAt = s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for k, e := range s.cache {
+		if !now.Before(e.expires) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = dashboardEntry{dashboard: d, expires: now.Add(DashboardCacheTTL)}
+	return d, nil
+}
+
+// countDatabases counts the databases on the primary MySQL server
+func (s *DashboardService) countDatabases() (int, error) {
+	if s.mysql == nil {
+		return 0, ErrMySQLNotConfigured
+	}
+	mysql, err := s.mysql.Get("")
+	if err != nil {
+		return 0, err
+	}
+	if err := mysql.EnsureConnected(); err != nil {
+		return 0, err
+	}
+	return mysql.CountDatabases()
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDashboardService(t *testing.T, backupsPath string) (*DashboardService, *fakeFileSystem) {
+	t.Helper()
+	fsys := newFakeFileSystem("/etc/nginx/sites-available", "/etc/nginx/sites-enabled")
+	runner := newFakeCommandRunner()
+	for _, name := range MonitoredServices {
+		runner.on("systemctl is-active "+name, "active\n", nil)
+	}
+	nginx := NewNginxServiceWithDeps(fsys, runner, "/etc/nginx/sites-available", "/etc/nginx/sites-enabled", "/var/log/nginx")
+	service := NewDashboardService(NewSystemServiceWithDeps(fsys, runner), nginx, NewBackupService(backupsPath, 0), nil)
+	return service, fsys
+}
+
+func TestDashboard(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	clients := NewClientService(cfg)
+	client := createPurgeTestClient(t, clients, "dashclient")
+	createPurgeTestClient(t, clients, "dashother")
+
+	service, fsys := newTestDashboardService(t, cfg.Paths.Backups)
+	require.NoError(t, fsys.WriteFile("/etc/nginx/sites-available/one.example.com", []byte("root /home/dashclient/public_html;"), 0644))
+	require.NoError(t, fsys.WriteFile("/etc/nginx/sites-available/two.example.com", []byte("root /home/dashother/public_html;"), 0644))
+
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	t.Run("server-wide counts, unavailable parts are reported", func(t *testing.T) {
+		dashboard, err := service.GetDashboard()
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(2), dashboard.Counts.Clients)
+		assert.Equal(t, int64(2), dashboard.Counts.Users)
+		assert.Equal(t, 2, dashboard.Counts.Sites)
+		assert.Len(t, dashboard.Services, len(MonitoredServices))
+		assert.Contains(t, dashboard.Unavailable, "databases")
+		assert.NotNil(t, dashboard.Backups)
+	})
+
+	t.Run("client dashboard only counts their own sites", func(t *testing.T) {
+		dashboard, err := service.GetClientDashboard(&client.User, client)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), dashboard.Counts.Clients)
+		assert.Equal(t, 1, dashboard.Counts.Sites)
+		assert.Equal(t, 0, dashboard.Counts.Databases)
+		assert.Nil(t, dashboard.Backups)
+	})
+
+	t.Run("cached until the TTL passes", func(t *testing.T) {
+		first, err := service.GetDashboard()
+		require.NoError(t, err)
+		require.NoError(t, fsys.WriteFile("/etc/nginx/sites-available/three.example.com", []byte(""), 0644))
+
+		cached, err := service.GetDashboard()
+		require.NoError(t, err)
+		assert.Same(t, first, cached)
+
+		now = now.Add(DashboardCacheTTL)
+		fresh, err := service.GetDashboard()
+		require.NoError(t, err)
+		assert.NotSame(t, first, fresh)
+		assert.Equal(t, 3, fresh.Counts.Sites)
+	})
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeFileSystem is an in-memory FileSystem. Directories must be registered with
+// mkdir; symlinks resolve through Stat like os.Stat does.
+type fakeFileSystem struct {
+	dirs  map[string]bool
+	files map[string][]byte
+	links map[string]string
+	modes map[string]fs.FileMode
+	gids  map[string]int
+}
+
+func newFakeFileSystem(dirs ...string) *fakeFileSystem {
+	f := &fakeFileSystem{
+		dirs:  map[string]bool{},
+		files: map[string][]byte{},
+		links: map[string]string{},
+		modes: map[string]fs.FileMode{},
+		gids:  map[string]int{},
+	}
+	for _, dir := range dirs {
+		f.mkdir(dir)
+	}
+	return f
+}
+
+func (f *fakeFileSystem) mkdir(dir string) {
+	for dir = path.Clean(dir); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		f.dirs[dir] = true
+	}
+}
+
+func (f *fakeFileSystem) exists(name string) bool {
+	_, isFile := f.files[name]
+	_, isLink := f.links[name]
+	return isFile || isLink || f.dirs[name]
+}
+
+func notExist(op, name string) error {
+	return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+func (f *fakeFileSystem) ReadFile(name string) ([]byte, error) {
+	name = path.Clean(name)
+	if target, ok := f.links[name]; ok {
+		name = target
+	}
+	data, ok := f.files[name]
+	if !ok {
+		return nil, notExist("open", name)
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (f *fakeFileSystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	name = path.Clean(name)
+	if !f.dirs[path.Dir(name)] {
+		return notExist("open", name)
+	}
+	// Like os.WriteFile, perm only applies to new files
+	if _, ok := f.files[name]; !ok {
+		f.modes[name] = perm
+	}
+	f.files[name] = append([]byte(nil), data...)
+	return nil
+}
+
+func (f *fakeFileSystem) Chmod(name string, mode fs.FileMode) error {
+	name = path.Clean(name)
+	if _, ok := f.files[name]; !ok {
+		return notExist("chmod", name)
+	}
+	f.modes[name] = mode
+	return nil
+}
+
+func (f *fakeFileSystem) Chown(name string, uid, gid int) error {
+	name = path.Clean(name)
+	if _, ok := f.files[name]; !ok {
+		return notExist("chown", name)
+	}
+	if gid >= 0 {
+		f.gids[name] = gid
+	}
+	return nil
+}
+
+func (f *fakeFileSystem) MkdirAll(name string, perm fs.FileMode) error {
+	f.mkdir(name)
+	return nil
+}
+
+func (f *fakeFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	name = path.Clean(name)
+	if !f.dirs[name] {
+		return nil, notExist("open", name)
+	}
+
+	var entries []fs.DirEntry
+	add := func(child string, mode fs.FileMode, size int) {
+		if path.Dir(child) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(fakeFileInfo{name: path.Base(child), mode: mode, size: int64(size)}))
+		}
+	}
+	for dir := range f.dirs {
+		add(dir, fs.ModeDir, 0)
+	}
+	for file, data := range f.files {
+		add(file, 0, len(data))
+	}
+	for link := range f.links {
+		add(link, fs.ModeSymlink, 0)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (f *fakeFileSystem) Stat(name string) (fs.FileInfo, error) {
+	name = path.Clean(name)
+	if target, ok := f.links[name]; ok {
+		if !f.exists(target) {
+			return nil, notExist("stat", name)
+		}
+		name = target
+	}
+	if f.dirs[name] {
+		return fakeFileInfo{name: path.Base(name), mode: fs.ModeDir}, nil
+	}
+	if data, ok := f.files[name]; ok {
+		return fakeFileInfo{name: path.Base(name), size: int64(len(data))}, nil
+	}
+	return nil, notExist("stat", name)
+}
+
+func (f *fakeFileSystem) Remove(name string) error {
+	name = path.Clean(name)
+	if _, ok := f.links[name]; ok {
+		delete(f.links, name)
+		return nil
+	}
+	if _, ok := f.files[name]; ok {
+		delete(f.files, name)
+		delete(f.modes, name)
+		delete(f.gids, name)
+		return nil
+	}
+	return notExist("remove", name)
+}
+
+func (f *fakeFileSystem) Symlink(oldname, newname string) error {
+	newname = path.Clean(newname)
+	if !f.dirs[path.Dir(newname)] {
+		return notExist("symlink", newname)
+	}
+	if f.exists(newname) {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: fs.ErrExist}
+	}
+	f.links[newname] = path.Clean(oldname)
+	return nil
+}
+
+type fakeFileInfo struct {
+	name string
+	mode fs.FileMode
+	size int64
+}
+
+func (i fakeFileInfo) Name() string       { return i.name }
+func (i fakeFileInfo) Size() int64        { return i.size }
+func (i fakeFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (i fakeFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i fakeFileInfo) Sys() any           { return nil }
+
+// fakeCommand is the canned result of a command run through fakeCommandRunner
+type fakeCommand struct {
+	output string
+	err    error
+}
+
+// fakeCommandRunner returns canned results keyed by the full command line and
+// records every command it was asked to run. Unknown commands fail.
+type fakeCommandRunner struct {
+	mu       sync.Mutex
+	commands map[string]fakeCommand
+	calls    []string
+}
+
+func newFakeCommandRunner() *fakeCommandRunner {
+	return &fakeCommandRunner{commands: map[string]fakeCommand{}}
+}
+
+func (r *fakeCommandRunner) on(cmdline, output string, err error) {
+	r.commands[cmdline] = fakeCommand{output: output, err: err}
+}
+
+func (r *fakeCommandRunner) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cmdline := strings.Join(append([]string{name}, args...), " ")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, cmdline)
+
+	cmd, ok := r.commands[cmdline]
+	if !ok {
+		return nil, fmt.Errorf("unexpected command: %s", cmdline)
+	}
+	return []byte(cmd.output), cmd.err
+}
+
+func (r *fakeCommandRunner) Run(ctx context.Context, name string, args ...string) error {
+	_, err := r.run(ctx, name, args...)
+	return err
+}
+
+func (r *fakeCommandRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return r.run(ctx, name, args...)
+}
+
+func (r *fakeCommandRunner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return r.run(ctx, name, args...)
+}
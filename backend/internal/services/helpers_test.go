@@ -0,0 +1,45 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestDB initializes a throwaway SQLite database for service tests
+func setupTestDB(t *testing.T) *config.Config {
+	t.Helper()
+
+	cfg := &config.Config{
+		Environment: "local",
+		Database: config.DatabaseConfig{
+			Type:   "sqlite",
+			SQLite: config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		},
+		JWT: config.JWTConfig{
+			Secret:    "test-secret-key-for-testing-only",
+			ExpiresIn: "24h",
+			Issuer:    "r-panel-test",
+		},
+		Security: config.SecurityConfig{
+			BcryptCost: 10,
+		},
+		Paths: config.PathsConfig{
+			Backups: t.TempDir(),
+		},
+	}
+
+	require.NoError(t, models.InitDB(cfg))
+	t.Cleanup(func() {
+		if sqlDB, err := models.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+		models.DB = nil
+	})
+
+	return cfg
+}
@@ -0,0 +1,16 @@
+package services
+
+import "strings"
+
+// NormalizeUsername trims and lowercases a panel username. Usernames are
+// stored normalized and compared case-insensitively, so Admin and admin are
+// the same user.
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// NormalizeEmail trims and lowercases an email address. Mail systems treat
+// addresses that differ only in case as one, so the panel does as well.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidToken           = errors.New("invalid or expired token")
+	ErrJWTRotationUnsupported = errors.New("tokens are signed with jwt.private_key_file, rotate it by replacing the file and listing the old public key in jwt.previous_public_key_files")
+)
+
+// jwtKey is a signing key held in memory: a shared secret for HS256, an RSA key
+// pair for RS256
+type jwtKey struct {
+	kid       string
+	secret    []byte
+	private   *rsa.PrivateKey // nil for previous RS256 keys, which only verify
+	public    *rsa.PublicKey
+	retiredAt time.Time // zero for the active key
+	pinned    bool      // listed in jwt.previous_public_key_files, accepted until removed there
+}
+
+// signingKey returns what key signs tokens with
+func (k jwtKey) signingKey() interface{} {
+	if k.public != nil {
+		return k.private
+	}
+	return k.secret
+}
+
+// verificationKey returns what key checks signatures with
+func (k jwtKey) verificationKey() interface{} {
+	if k.public != nil {
+		return k.public
+	}
+	return k.secret
+}
+
+// JWTService signs and verifies panel tokens. With HS256 the active secret
+// comes from the database after the first rotation and from jwt.secret before
+// that, and retired secrets stay accepted until every token they signed has
+// expired. With RS256 the keys come from the files in the config.
+type JWTService struct {
+	cfg    *config.Config
+	method jwt.SigningMethod
+
+	mu      sync.RWMutex
+	active  jwtKey
+	retired []jwtKey
+}
+
+func NewJWTService(cfg *config.Config) *JWTService {
+	s := &JWTService{cfg: cfg, method: jwt.SigningMethodHS256}
+
+	// Config.Load has validated the algorithm and keys
+	if algorithm, _ := cfg.JWT.SigningAlgorithm(); algorithm == config.JWTAlgorithmRS256 {
+		s.method = jwt.SigningMethodRS256
+		if err := s.loadRSA(); err != nil {
+			// Without a key nothing is signed or accepted, never fall back to the secret
+			log.Printf("Warning: %v, no tokens can be issued", err)
+		}
+		return s
+	}
+
+	if err := s.load(); err != nil {
+		log.Printf("Warning: %v, using jwt.secret from config", err)
+	}
+	return s
+}
+
+// TokenTTL returns the configured token lifetime
+func (s *JWTService) TokenTTL() time.Duration {
+	expiresIn, err := time.ParseDuration(s.cfg.JWT.ExpiresIn)
+	if err != nil || expiresIn <= 0 {
+		return 24 * time.Hour // Default to 24 hours
+	}
+	return expiresIn
+}
+
+// GenerateToken signs a token for user with the active secret
+func (s *JWTService) GenerateToken(user *models.User) (string, time.Time, error) {
+	return s.generateToken(user, s.TokenTTL(), nil)
+}
+
+// GenerateImpersonationToken signs a token for user on behalf of admin, valid for
+// ttl or the regular token lifetime, whichever is shorter. The token carries an
+// impersonated_by claim with the admin's user ID.
+func (s *JWTService) GenerateImpersonationToken(user, admin *models.User, ttl time.Duration) (string, time.Time, error) {
+	if ttl <= 0 || ttl > s.TokenTTL() {
+		ttl = s.TokenTTL()
+	}
+	return s.generateToken(user, ttl, jwt.MapClaims{"impersonated_by": admin.ID})
+}
+
+func (s *JWTService) generateToken(user *models.User, ttl time.Duration, extra jwt.MapClaims) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	claims := jwt.MapClaims{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+		"exp":      expiresAt.Unix(),
+		"iat":      now.Unix(),
+		"iss":      s.cfg.JWT.Issuer,
+	}
+	for name, value := range extra {
+		claims[name] = value
+	}
+
+	s.mu.RLock()
+	key := s.active
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(s.method, claims)
+	token.Header["kid"] = key.kid
+
+	tokenString, err := token.SignedString(key.signingKey())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// VerifyToken checks the signature, expiry and issuer of tokenString against the
+// active and still accepted retired keys and returns its claims. Only the
+// configured algorithm is accepted, so an RS256 public key can never be used
+// as an HS256 secret.
+func (s *JWTService) VerifyToken(tokenString string) (jwt.MapClaims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{s.method.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if s.cfg.JWT.Issuer != "" {
+		options = append(options, jwt.WithIssuer(s.cfg.JWT.Issuer))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		keys := s.acceptedKeys()
+
+		// Tokens issued before key IDs were introduced carry no kid
+		if kid, ok := token.Header["kid"].(string); ok {
+			for _, key := range keys {
+				if key.kid == kid {
+					return key.verificationKey(), nil
+				}
+			}
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+
+		set := jwt.VerificationKeySet{}
+		for _, key := range keys {
+			set.Keys = append(set.Keys, key.verificationKey())
+		}
+		return set, nil
+	}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return claims, nil
+}
+
+// ClaimsMatchSession reports whether verified token claims belong to session: the
+// same user and, for impersonation sessions, the same impersonating admin
+func ClaimsMatchSession(claims jwt.MapClaims, session *models.Session) bool {
+	userID, ok := claims["user_id"].(float64)
+	if !ok || uint(userID) != session.UserID {
+		return false
+	}
+
+	impersonatedBy, impersonating := claims["impersonated_by"].(float64)
+	if session.ImpersonatedBy == nil {
+		return !impersonating
+	}
+	return impersonating && uint(impersonatedBy) == *session.ImpersonatedBy
+}
+
+// RotateSecret generates a new active secret, retires the current one and
+// persists both. It returns the new key ID. RS256 keys come from files and
+// cannot be rotated here.
+func (s *JWTService) RotateSecret() (string, error) {
+	if s.method != jwt.SigningMethodHS256 {
+		return "", ErrJWTRotationUnsupported
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+	newKey := jwtKey{kid: jwtKeyID(secret), secret: []byte(secret)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	old := s.active
+	old.retiredAt = now
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		// The config secret has no row yet, store it so it survives a restart as a retired key
+		var count int64
+		if err := tx.Model(&models.JWTKey{}).Where("kid = ?", old.kid).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := tx.Create(&models.JWTKey{KID: old.kid, Secret: string(old.secret), RetiredAt: &now}).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Model(&models.JWTKey{}).Where("kid = ?", old.kid).
+				Updates(map[string]interface{}{"active": false, "retired_at": now}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&models.JWTKey{KID: newKey.kid, Secret: secret, Active: true}).Error; err != nil {
+			return err
+		}
+
+		// Drop retired keys whose tokens have all expired
+		return tx.Where("active = ? AND retired_at < ?", false, now.Add(-s.TokenTTL())).Delete(&models.JWTKey{}).Error
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to persist JWT secret: %w", err)
+	}
+
+	s.retired = append([]jwtKey{old}, s.retired...)
+	s.active = newKey
+
+	return newKey.kid, nil
+}
+
+// load reads the active and retired secrets from the database, falling back to jwt.secret
+func (s *JWTService) load() error {
+	var rows []models.JWTKey
+	var err error
+	if models.DB != nil {
+		if err = models.DB.Order("created_at DESC").Find(&rows).Error; err != nil {
+			err = fmt.Errorf("failed to load JWT keys: %w", err)
+			rows = nil
+		}
+	}
+
+	var active *jwtKey
+	var retired []jwtKey
+	for _, row := range rows {
+		key := jwtKey{kid: row.KID, secret: []byte(row.Secret)}
+		if row.Active && active == nil {
+			active = &key
+			continue
+		}
+		if row.RetiredAt != nil {
+			key.retiredAt = *row.RetiredAt
+		}
+		retired = append(retired, key)
+	}
+
+	if active == nil {
+		secret := s.cfg.JWT.Secret
+		if secret == "" {
+			secret = config.DefaultJWTSecret
+		}
+		active = &jwtKey{kid: jwtKeyID(secret), secret: []byte(secret)}
+	}
+
+	s.mu.Lock()
+	s.active = *active
+	s.retired = retired
+	s.mu.Unlock()
+
+	return err
+}
+
+// loadRSA reads the RS256 signing key and the previous public keys
+func (s *JWTService) loadRSA() error {
+	private, previous, err := s.cfg.JWT.RSAKeys()
+	if err != nil {
+		return err
+	}
+
+	retired := make([]jwtKey, 0, len(previous))
+	for _, public := range previous {
+		retired = append(retired, jwtKey{kid: rsaKeyID(public), public: public, pinned: true})
+	}
+
+	s.mu.Lock()
+	s.active = jwtKey{kid: rsaKeyID(&private.PublicKey), private: private, public: &private.PublicKey}
+	s.retired = retired
+	s.mu.Unlock()
+	return nil
+}
+
+// acceptedKeys returns the active key followed by retired keys that may still have live tokens
+func (s *JWTService) acceptedKeys() []jwtKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cutoff := time.Now().Add(-s.TokenTTL())
+	keys := []jwtKey{s.active}
+	for _, key := range s.retired {
+		if key.pinned || key.retiredAt.After(cutoff) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// rsaKeyID derives a stable identifier for an RSA public key
+func rsaKeyID(public *rsa.PublicKey) string {
+	sum := sha256.Sum256(x509.MarshalPKCS1PublicKey(public))
+	return hex.EncodeToString(sum[:8])
+}
+
+// jwtKeyID derives a stable, non-secret identifier for a signing secret
+func jwtKeyID(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:8])
+}
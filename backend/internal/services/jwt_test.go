@@ -0,0 +1,207 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"r-panel/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTServiceRotateSecret(t *testing.T) {
+	cfg := setupTestDB(t)
+	cfg.JWT.Secret = "initial-test-secret"
+	cfg.JWT.ExpiresIn = "1h"
+
+	service := NewJWTService(cfg)
+	user := &models.User{ID: 1, Username: "admin", Role: "admin"}
+
+	before, _, err := service.GenerateToken(user)
+	require.NoError(t, err)
+
+	kid, err := service.RotateSecret()
+	require.NoError(t, err)
+
+	after, _, err := service.GenerateToken(user)
+	require.NoError(t, err)
+
+	// Both tokens verify; the new one is signed with the new key
+	claims, err := service.VerifyToken(before)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", claims["username"])
+
+	_, err = service.VerifyToken(after)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(after, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, kid, parsed.Header["kid"])
+
+	// Rotation is persisted: a fresh instance (e.g. after a restart) still accepts both
+	restarted := NewJWTService(cfg)
+	_, err = restarted.VerifyToken(before)
+	assert.NoError(t, err)
+	_, err = restarted.VerifyToken(after)
+	assert.NoError(t, err)
+
+	// Rotating again keeps accepting every token that hasn't expired yet
+	_, err = restarted.RotateSecret()
+	require.NoError(t, err)
+	_, err = restarted.VerifyToken(before)
+	assert.NoError(t, err)
+	_, err = restarted.VerifyToken(after)
+	assert.NoError(t, err)
+}
+
+func TestJWTServiceRejectsInvalidTokens(t *testing.T) {
+	cfg := setupTestDB(t)
+	cfg.JWT.Secret = "initial-test-secret"
+	cfg.JWT.ExpiresIn = "1h"
+	service := NewJWTService(cfg)
+
+	// Legacy token without kid signed with the config secret is still accepted
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+		"iss":     cfg.JWT.Issuer,
+	}).SignedString([]byte("initial-test-secret"))
+	require.NoError(t, err)
+	_, err = service.VerifyToken(legacy)
+	assert.NoError(t, err)
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("some-other-secret"))
+	require.NoError(t, err)
+	_, err = service.VerifyToken(forged)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"exp":     time.Now().Add(-time.Minute).Unix(),
+	}).SignedString([]byte("initial-test-secret"))
+	require.NoError(t, err)
+	_, err = service.VerifyToken(expired)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	foreignIssuer, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+		"iss":     "another-panel",
+	}).SignedString([]byte("initial-test-secret"))
+	require.NoError(t, err)
+	_, err = service.VerifyToken(foreignIssuer)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+		"user_id": 1,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+		"iss":     cfg.JWT.Issuer,
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = service.VerifyToken(unsigned)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// writeRSAKey generates an RSA key and writes the private key and its public
+// key as PEM files, returning their paths
+func writeRSAKey(t *testing.T, dir, name string) (privatePath, publicPath string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	privatePath = filepath.Join(dir, name+".key")
+	publicPath = filepath.Join(dir, name+".pub")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}), 0644))
+	return privatePath, publicPath
+}
+
+func TestJWTServiceRS256(t *testing.T) {
+	cfg := setupTestDB(t)
+	cfg.JWT.Secret = "initial-test-secret"
+	cfg.JWT.ExpiresIn = "1h"
+	cfg.JWT.Algorithm = "RS256"
+	dir := t.TempDir()
+	oldKey, oldPublic := writeRSAKey(t, dir, "old")
+	newKey, _ := writeRSAKey(t, dir, "new")
+	user := &models.User{ID: 1, Username: "admin", Role: "admin"}
+
+	cfg.JWT.PrivateKeyFile = oldKey
+	old := NewJWTService(cfg)
+	oldToken, _, err := old.GenerateToken(user)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(oldToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "RS256", parsed.Header["alg"])
+	_, err = old.VerifyToken(oldToken)
+	assert.NoError(t, err)
+
+	_, err = old.RotateSecret()
+	assert.ErrorIs(t, err, ErrJWTRotationUnsupported)
+
+	// After a key change the old tokens verify while the old public key is listed
+	cfg.JWT.PrivateKeyFile = newKey
+	cfg.JWT.PreviousPublicKeyFiles = []string{oldPublic}
+	rotated := NewJWTService(cfg)
+	_, err = rotated.VerifyToken(oldToken)
+	assert.NoError(t, err)
+	newToken, _, err := rotated.GenerateToken(user)
+	require.NoError(t, err)
+	_, err = rotated.VerifyToken(newToken)
+	assert.NoError(t, err)
+	_, err = old.VerifyToken(newToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	cfg.JWT.PreviousPublicKeyFiles = nil
+	_, err = NewJWTService(cfg).VerifyToken(oldToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// The secret and the public key as an HMAC secret are both refused
+	claims := jwt.MapClaims{"user_id": 1, "exp": time.Now().Add(time.Hour).Unix(), "iss": cfg.JWT.Issuer}
+	for _, secret := range [][]byte{[]byte("initial-test-secret"), mustReadFile(t, oldPublic)} {
+		hs256, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		require.NoError(t, err)
+		_, err = rotated.VerifyToken(hs256)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	}
+
+	// A missing key issues nothing instead of falling back to the secret
+	cfg.JWT.PrivateKeyFile = filepath.Join(dir, "missing.key")
+	_, _, err = NewJWTService(cfg).GenerateToken(user)
+	assert.Error(t, err)
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
+
+func TestClaimsMatchSession(t *testing.T) {
+	adminID := uint(1)
+	session := &models.Session{UserID: 7}
+	impersonation := &models.Session{UserID: 7, ImpersonatedBy: &adminID}
+
+	assert.True(t, ClaimsMatchSession(jwt.MapClaims{"user_id": float64(7)}, session))
+	assert.False(t, ClaimsMatchSession(jwt.MapClaims{"user_id": float64(8)}, session))
+	assert.False(t, ClaimsMatchSession(jwt.MapClaims{}, session))
+	assert.False(t, ClaimsMatchSession(jwt.MapClaims{"user_id": float64(7), "impersonated_by": float64(1)}, session))
+
+	assert.True(t, ClaimsMatchSession(jwt.MapClaims{"user_id": float64(7), "impersonated_by": float64(1)}, impersonation))
+	assert.False(t, ClaimsMatchSession(jwt.MapClaims{"user_id": float64(7), "impersonated_by": float64(2)}, impersonation))
+	assert.False(t, ClaimsMatchSession(jwt.MapClaims{"user_id": float64(7)}, impersonation))
+}
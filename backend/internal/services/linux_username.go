@@ -0,0 +1,89 @@
+package services
+
+import (
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+)
+
+// LinuxUsername derives a Linux username from a panel username under policy:
+// lowercased, reduced to a-z, 0-9, _ and -, prefixed when it does not start
+// with a letter or policy.AlwaysPrefix is set, padded to policy.MinLength and
+// cut to policy.MaxLength. policy must have its defaults applied.
+func LinuxUsername(username string, policy config.LinuxUsernameConfig) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(username))
+
+	if policy.AlwaysPrefix || name == "" || name[0] < 'a' || name[0] > 'z' {
+		name = policy.Prefix + name
+	}
+	for len(name) < policy.MinLength {
+		name += "123"
+	}
+	if len(name) > policy.MaxLength {
+		name = name[:policy.MaxLength]
+	}
+	return name
+}
+
+// linuxUsernamePattern matches the names LinuxUsername derives, the prefix
+// may start with _
+var linuxUsernamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_-]*$`)
+
+// validLinuxUsername reports whether name could have been derived under
+// policy. Names from elsewhere, such as a client backup, are checked with it
+// before they reach useradd or a path.
+func validLinuxUsername(name string, policy config.LinuxUsernameConfig) bool {
+	return len(name) <= policy.MaxLength && linuxUsernamePattern.MatchString(name)
+}
+
+// reservedLinuxUsernames are directories under home_base that are not the home
+// of a client, so no client may get them as its Linux username
+var reservedLinuxUsernames = map[string]bool{
+	"resellers": true, // <home_base>/resellers/<reseller>/ holds the clients of resellers
+}
+
+// linuxUsernameTaken reports whether name is reserved or a client, trashed ones
+// included, or a Linux user already has it
+func (s *ClientService) linuxUsernameTaken(name string) bool {
+	if reservedLinuxUsernames[name] {
+		return true
+	}
+	if models.DB.Unscoped().Where("linux_username = ?", name).First(&models.Client{}).Error == nil {
+		return true
+	}
+	return !skipLinuxUser() && exec.Command("id", name).Run() == nil
+}
+
+// newLinuxUsername derives a Linux username for username that no client or
+// Linux user has yet, adding a number to the derived name when it is taken
+func (s *ClientService) newLinuxUsername(username string) (string, error) {
+	policy, err := s.cfg.Clients.LinuxUsername.WithDefaults()
+	if err != nil {
+		return "", err
+	}
+	return freeName(LinuxUsername(username, policy), policy.MaxLength, s.linuxUsernameTaken), nil
+}
+
+// linuxUserHome returns the home directory passwd lists for username. For a
+// jailed user that is the jail, the home inside it is left out.
+func linuxUserHome(username string) (string, bool) {
+	if skipLinuxUser() {
+		return "", false
+	}
+	account, err := user.Lookup(username)
+	if err != nil || account.HomeDir == "" {
+		return "", false
+	}
+	home, _, _ := strings.Cut(account.HomeDir, "/./")
+	return filepath.Clean(home), true
+}
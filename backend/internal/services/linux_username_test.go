@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+
+	"r-panel/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinuxUsername(t *testing.T) {
+	defaults, err := config.LinuxUsernameConfig{}.WithDefaults()
+	require.NoError(t, err)
+	always, err := config.LinuxUsernameConfig{Prefix: "web_", AlwaysPrefix: true, MinLength: 8, MaxLength: 12}.WithDefaults()
+	require.NoError(t, err)
+
+	tests := []struct {
+		username string
+		policy   config.LinuxUsernameConfig
+		want     string
+	}{
+		{"Alice", defaults, "alice"},
+		{"al.ice@example", defaults, "aliceexample"},
+		{"42shop", defaults, "u42shop"},
+		{"Al", defaults, "al123"},
+		{"!!", defaults, "u123"},
+		{"averyveryveryverylongclientusername", defaults, "averyveryveryverylongclientusern"},
+		{"alice", always, "web_alice"},
+		{"al", always, "web_al123"},
+		{"bobbobbob", always, "web_bobbobbo"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, LinuxUsername(tt.username, tt.policy), tt.username)
+	}
+}
+
+func TestCreateClientAvoidsLinuxUsernameCollisions(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	service := NewClientService(cfg)
+
+	first := createPurgeTestClient(t, service, "alice")
+	second := createPurgeTestClient(t, service, "al.ice")
+	third := createPurgeTestClient(t, service, "a-l.ice")
+	assert.Equal(t, "alice", first.LinuxUsername)
+	assert.Equal(t, "alice2", second.LinuxUsername, "same derived name as alice")
+	assert.Equal(t, "a-lice", third.LinuxUsername)
+
+	require.NoError(t, service.DeleteClient(first.ID))
+	fourth := createPurgeTestClient(t, service, "A.lice")
+	assert.Equal(t, "alice3", fourth.LinuxUsername, "a client in the trash keeps its Linux user")
+
+	cfg.Clients.LinuxUsername.MaxLength = 5
+	fifth := createPurgeTestClient(t, service, "alice_")
+	assert.Equal(t, "alic2", fifth.LinuxUsername, "the suffix fits within max_length")
+
+	cfg.Clients.LinuxUsername.MaxLength = 0
+	sixth := createPurgeTestClient(t, service, "resellers")
+	assert.Equal(t, "resellers2", sixth.LinuxUsername, "resellers is the directory of reseller clients")
+}
@@ -1,17 +1,52 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
+var ErrInvalidLogSource = errors.New("invalid log source")
+
+// Bounds of a combined tail
+const (
+	MaxCombinedSources = 4
+	MaxCombinedLines   = 1000
+)
+
+// CombinedLogSources lists the sources CombinedTail can interleave
+var CombinedLogSources = []string{"system", "nginx-access", "nginx-error", "phpfpm"}
+
 type LogsService struct {
 	nginxLogsPath string
+	logDir        string   // extra directory whose files ListLogFiles reports
+	phpFPMLogs    []string // glob patterns of the PHP-FPM logs, one per installed version
+}
+
+// LogFile describes a log file that can be tailed
+type LogFile struct {
+	Source  string    `json:"source"`            // nginx-access, nginx-error, phpfpm or file
+	Version string    `json:"version,omitempty"` // PHP version of a phpfpm log
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
 }
 
+// maxListedLogFiles bounds how many files of the log directory are listed
+const maxListedLogFiles = 500
+
+// phpFPMLogPattern extracts the PHP version from a PHP-FPM log name
+var phpFPMLogPattern = regexp.MustCompile(`^php(\d+\.\d+)-fpm\.log$`)
+
 type LogEntry struct {
 	Timestamp string `json:"timestamp"`
 	Level     string `json:"level"`
@@ -19,20 +54,25 @@ type LogEntry struct {
 	Source    string `json:"source"`
 }
 
-func NewLogsService(nginxLogsPath string) *LogsService {
+// NewLogsService reads Nginx logs from nginxLogsPath. logDir, when set, is a
+// directory of further logs listed by ListLogFiles.
+func NewLogsService(nginxLogsPath, logDir string) *LogsService {
 	return &LogsService{
 		nginxLogsPath: nginxLogsPath,
+		logDir:        logDir,
+		// The same locations GetPHPFPMLogs reads
+		phpFPMLogs: []string{"/var/log/php*-fpm.log", "/var/log/php/php*-fpm.log"},
 	}
 }
 
 // GetSystemLogs reads system logs using journalctl
-func (s *LogsService) GetSystemLogs(unit string, lines int) ([]string, error) {
+func (s *LogsService) GetSystemLogs(ctx context.Context, unit string, lines int) ([]string, error) {
 	args := []string{"-n", strconv.Itoa(lines), "--no-pager"}
 	if unit != "" {
 		args = append(args, "-u", unit)
 	}
 
-	cmd := exec.Command("journalctl", args...)
+	cmd := exec.CommandContext(ctx, "journalctl", args...)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to read system logs: %w", err)
@@ -50,7 +90,7 @@ func (s *LogsService) GetSystemLogs(unit string, lines int) ([]string, error) {
 }
 
 // GetNginxLogs reads Nginx logs
-func (s *LogsService) GetNginxLogs(logType string, lines int) ([]string, error) {
+func (s *LogsService) GetNginxLogs(ctx context.Context, logType string, lines int) ([]string, error) {
 	var logFile string
 
 	switch logType {
@@ -62,7 +102,7 @@ func (s *LogsService) GetNginxLogs(logType string, lines int) ([]string, error)
 		return nil, fmt.Errorf("invalid log type: %s", logType)
 	}
 
-	cmd := exec.Command("tail", "-n", strconv.Itoa(lines), logFile)
+	cmd := exec.CommandContext(ctx, "tail", "-n", strconv.Itoa(lines), logFile)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to read logs: %w", err)
@@ -80,15 +120,18 @@ func (s *LogsService) GetNginxLogs(logType string, lines int) ([]string, error)
 }
 
 // GetPHPFPMLogs reads PHP-FPM logs
-func (s *LogsService) GetPHPFPMLogs(phpVersion string, lines int) ([]string, error) {
+func (s *LogsService) GetPHPFPMLogs(ctx context.Context, phpVersion string, lines int) ([]string, error) {
+	if err := ValidatePHPVersion(phpVersion); err != nil {
+		return nil, err
+	}
 	logFile := fmt.Sprintf("/var/log/php%s-fpm.log", phpVersion)
 
-	cmd := exec.Command("tail", "-n", strconv.Itoa(lines), logFile)
+	cmd := exec.CommandContext(ctx, "tail", "-n", strconv.Itoa(lines), logFile)
 	output, err := cmd.Output()
 	if err != nil {
 		// Try alternative log path
 		logFile = fmt.Sprintf("/var/log/php/php%s-fpm.log", phpVersion)
-		cmd := exec.Command("tail", "-n", strconv.Itoa(lines), logFile)
+		cmd := exec.CommandContext(ctx, "tail", "-n", strconv.Itoa(lines), logFile)
 		output, err = cmd.Output()
 		if err != nil {
 			return nil, fmt.Errorf("failed to read PHP-FPM logs: %w", err)
@@ -107,16 +150,16 @@ func (s *LogsService) GetPHPFPMLogs(phpVersion string, lines int) ([]string, err
 }
 
 // TailLogs tails a log file (returns last N lines)
-func (s *LogsService) TailLogs(source, logFile string, lines int) ([]string, error) {
+func (s *LogsService) TailLogs(ctx context.Context, source, logFile string, lines int) ([]string, error) {
 	switch source {
 	case "system":
-		return s.GetSystemLogs("", lines)
+		return s.GetSystemLogs(ctx, "", lines)
 	case "nginx-access":
-		return s.GetNginxLogs("access", lines)
+		return s.GetNginxLogs(ctx, "access", lines)
 	case "nginx-error":
-		return s.GetNginxLogs("error", lines)
+		return s.GetNginxLogs(ctx, "error", lines)
 	case "phpfpm":
-		return s.GetPHPFPMLogs("8.1", lines) // Default to 8.1, could be parameterized
+		return s.GetPHPFPMLogs(ctx, "8.1", lines) // Default to 8.1, could be parameterized
 	default:
 		// Try to read file directly
 		data, err := os.ReadFile(logFile)
@@ -139,3 +182,222 @@ func (s *LogsService) TailLogs(source, logFile string, lines int) ([]string, err
 		return result, nil
 	}
 }
+
+// CombinedLogLine is a log line tagged with its source and, when the line
+// starts with one, its timestamp
+type CombinedLogLine struct {
+	Source    string     `json:"source"`
+	Timestamp *time.Time `json:"timestamp"`
+	Line      string     `json:"line"`
+
+	// sortKey is the timestamp of the line, or of the last timestamped line
+	// before it in the same source, so continuation lines stay in place
+	sortKey time.Time
+}
+
+// CombinedLog is the result of CombinedTail. Sources that could not be read
+// are reported in Errors instead of failing the whole tail.
+type CombinedLog struct {
+	Lines  []CombinedLogLine `json:"lines"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// CombinedTail reads the last lines of each source and merges them into the
+// last lines overall, oldest first
+func (s *LogsService) CombinedTail(ctx context.Context, sources []string, lines int) (*CombinedLog, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("%w: at least one source is required", ErrInvalidLogSource)
+	}
+	if len(sources) > MaxCombinedSources {
+		return nil, fmt.Errorf("%w: at most %d sources can be combined", ErrInvalidLogSource, MaxCombinedSources)
+	}
+	if lines <= 0 || lines > MaxCombinedLines {
+		lines = MaxCombinedLines
+	}
+
+	seen := map[string]bool{}
+	for _, source := range sources {
+		if !isCombinedLogSource(source) {
+			return nil, fmt.Errorf("%w: %q (use %s)", ErrInvalidLogSource, source, strings.Join(CombinedLogSources, ", "))
+		}
+		if seen[source] {
+			return nil, fmt.Errorf("%w: %q is listed twice", ErrInvalidLogSource, source)
+		}
+		seen[source] = true
+	}
+
+	result := &CombinedLog{}
+	bySource := map[string][]string{}
+	for _, source := range sources {
+		var sourceLines []string
+		var err error
+		if source == "system" {
+			// ISO timestamps carry the year the default journalctl output lacks
+			sourceLines, err = s.readCommandLines(ctx, "journalctl", "-n", strconv.Itoa(lines), "--no-pager", "-o", "short-iso")
+		} else {
+			sourceLines, err = s.TailLogs(ctx, source, "", lines)
+		}
+		if err != nil {
+			if result.Errors == nil {
+				result.Errors = map[string]string{}
+			}
+			result.Errors[source] = err.Error()
+			continue
+		}
+		bySource[source] = sourceLines
+	}
+
+	result.Lines = mergeLogLines(sources, bySource, lines)
+	return result, nil
+}
+
+func isCombinedLogSource(source string) bool {
+	for _, known := range CombinedLogSources {
+		if source == known {
+			return true
+		}
+	}
+	return false
+}
+
+// readCommandLines runs a command and returns the non-empty lines of its output
+func (s *LogsService) readCommandLines(ctx context.Context, name string, args ...string) ([]string, error) {
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs: %w", err)
+	}
+
+	var result []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.TrimSpace(line) != "" {
+			result = append(result, line)
+		}
+	}
+	return result, nil
+}
+
+// mergeLogLines tags the lines of each source, orders them by timestamp and
+// keeps the last limit. Lines of a source keep their order when timestamps tie.
+func mergeLogLines(sources []string, bySource map[string][]string, limit int) []CombinedLogLine {
+	merged := []CombinedLogLine{}
+	for _, source := range sources {
+		var last time.Time
+		for _, line := range bySource[source] {
+			entry := CombinedLogLine{Source: source, Line: line}
+			if ts, ok := parseLogTimestamp(line); ok {
+				entry.Timestamp = &ts
+				last = ts
+			}
+			entry.sortKey = last
+			merged = append(merged, entry)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].sortKey.Before(merged[j].sortKey)
+	})
+	if len(merged) > limit {
+		merged = merged[len(merged)-limit:]
+	}
+	return merged
+}
+
+// logTimestampFormats are the timestamp formats found at or near the start of
+// the supported logs, with the pattern that extracts them
+var logTimestampFormats = []struct {
+	pattern *regexp.Regexp
+	layouts []string
+}{
+	// journalctl -o short-iso: 2024-01-15T10:30:45+0700
+	{regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:[+-]\d{2}:?\d{2}|Z))`), []string{"2006-01-02T15:04:05-0700", time.RFC3339}},
+	// nginx error log: 2024/01/15 10:30:45 [error]
+	{regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2})`), []string{"2006/01/02 15:04:05"}},
+	// nginx access log: 1.2.3.4 - - [15/Jan/2024:10:30:45 +0000] "GET / HTTP/1.1"
+	{regexp.MustCompile(`\[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`), []string{"02/Jan/2006:15:04:05 -0700"}},
+	// php-fpm log: [15-Jan-2024 10:30:45] NOTICE:
+	{regexp.MustCompile(`^\[(\d{2}-[A-Z][a-z]{2}-\d{4} \d{2}:\d{2}:\d{2})\]`), []string{"02-Jan-2006 15:04:05"}},
+}
+
+// parseLogTimestamp returns the timestamp of a log line. Logs without a zone
+// are in the server's local time.
+func parseLogTimestamp(line string) (time.Time, bool) {
+	for _, format := range logTimestampFormats {
+		match := format.pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		for _, layout := range format.layouts {
+			if ts, err := time.ParseInLocation(layout, match[1], time.Local); err == nil {
+				return ts, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// ListLogFiles lists the logs on this server: the Nginx access and error logs,
+// the PHP-FPM log of every installed version and the files in the configured
+// log directory. Logs that do not exist are left out.
+func (s *LogsService) ListLogFiles() ([]LogFile, error) {
+	files := []LogFile{}
+	add := func(source, version, path string) {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return
+		}
+		files = append(files, LogFile{Source: source, Version: version, Path: path, Size: info.Size(), ModTime: info.ModTime()})
+	}
+
+	if s.nginxLogsPath != "" {
+		add("nginx-access", "", filepath.Join(s.nginxLogsPath, "access.log"))
+		add("nginx-error", "", filepath.Join(s.nginxLogsPath, "error.log"))
+	}
+
+	seen := map[string]bool{}
+	for _, pattern := range s.phpFPMLogs {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PHP-FPM log pattern %q: %w", pattern, err)
+		}
+		sort.Strings(matches)
+		for _, path := range matches {
+			match := phpFPMLogPattern.FindStringSubmatch(filepath.Base(path))
+			if match == nil || seen[match[1]] {
+				continue
+			}
+			seen[match[1]] = true
+			add("phpfpm", match[1], path)
+		}
+	}
+
+	if s.logDir != "" {
+		listed := 0
+		err := filepath.WalkDir(s.logDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Unreadable subdirectories are skipped, a missing log directory lists nothing
+				if d != nil && d.IsDir() && path != s.logDir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if listed >= maxListedLogFiles {
+				return filepath.SkipAll
+			}
+			// Rotated archives cannot be tailed
+			if !d.Type().IsRegular() || strings.HasSuffix(path, ".gz") {
+				return nil
+			}
+			before := len(files)
+			add("file", "", path)
+			if len(files) > before {
+				listed++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", s.logDir, err)
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogTimestamp(t *testing.T) {
+	utc7 := time.FixedZone("", 7*3600)
+	for line, want := range map[string]time.Time{
+		"2024-01-15T10:30:45+0700 web1 sshd[812]: Accepted publickey":                  time.Date(2024, 1, 15, 10, 30, 45, 0, utc7),
+		"2024-01-15T10:30:45+07:00 web1 systemd[1]: Started":                           time.Date(2024, 1, 15, 10, 30, 45, 0, utc7),
+		"2024/01/15 10:30:45 [error] 1234#1234: *1 open() failed":                      time.Date(2024, 1, 15, 10, 30, 45, 0, time.Local),
+		`1.2.3.4 - - [15/Jan/2024:10:30:45 +0700] "GET / HTTP/1.1" 200 612 "-" "curl"`: time.Date(2024, 1, 15, 10, 30, 45, 0, utc7),
+		"[15-Jan-2024 10:30:45] NOTICE: fpm is running, pid 812":                       time.Date(2024, 1, 15, 10, 30, 45, 0, time.Local),
+	} {
+		ts, ok := parseLogTimestamp(line)
+		require.True(t, ok, line)
+		assert.True(t, want.Equal(ts), "%s: got %s", line, ts)
+	}
+
+	_, ok := parseLogTimestamp("PHP Stack trace:")
+	assert.False(t, ok)
+}
+
+func TestMergeLogLines(t *testing.T) {
+	// nginx logs local time, the journal carries its zone
+	iso := func(sec int) string {
+		return time.Date(2024, 1, 15, 10, 30, sec, 0, time.Local).Format("2006-01-02T15:04:05-0700")
+	}
+	merged := mergeLogLines([]string{"nginx-error", "system"}, map[string][]string{
+		"nginx-error": {
+			"2024/01/15 10:30:40 [error] upstream timed out",
+			"2024/01/15 10:30:50 [crit] connect() failed",
+			"    while connecting to upstream",
+		},
+		"system": {
+			iso(30) + " web1 kernel: oom-killer",
+			iso(45) + " web1 systemd[1]: php8.1-fpm.service: Main process exited",
+		},
+	}, 4)
+
+	var got []string
+	for _, line := range merged {
+		got = append(got, line.Source+": "+line.Line)
+	}
+	assert.Equal(t, []string{
+		"nginx-error: 2024/01/15 10:30:40 [error] upstream timed out",
+		"system: " + iso(45) + " web1 systemd[1]: php8.1-fpm.service: Main process exited",
+		"nginx-error: 2024/01/15 10:30:50 [crit] connect() failed",
+		"nginx-error:     while connecting to upstream",
+	}, got, "the oldest line is dropped and continuations follow their line")
+	assert.Nil(t, merged[3].Timestamp)
+}
+
+func TestCombinedTailRejectsBadSources(t *testing.T) {
+	service := NewLogsService("/var/log/nginx", "")
+	for _, sources := range [][]string{
+		nil,
+		{"/etc/shadow"},
+		{"system", "system"},
+		{"system", "nginx-access", "nginx-error", "phpfpm", "system"},
+	} {
+		_, err := service.CombinedTail(context.Background(), sources, 100)
+		assert.ErrorIs(t, err, ErrInvalidLogSource, "%v", sources)
+	}
+}
+
+func TestListLogFiles(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) string {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+		return full
+	}
+	access := write("nginx/access.log", "GET /\n")
+	php81 := write("php8.1-fpm.log", "[15-Jan-2024 10:30:45] NOTICE\n")
+	php82 := write("php/php8.2-fpm.log", "")
+	write("php/php8.1-fpm.log", "shadowed by the first location")
+	syslog := write("var/syslog", "boot\n")
+	mysql := write("var/mysql/error.log", "")
+	write("var/syslog.2.gz", "archive")
+
+	service := NewLogsService(filepath.Join(root, "nginx"), filepath.Join(root, "var"))
+	service.phpFPMLogs = []string{filepath.Join(root, "php*-fpm.log"), filepath.Join(root, "php", "php*-fpm.log")}
+
+	files, err := service.ListLogFiles()
+	require.NoError(t, err)
+
+	var got []LogFile
+	for _, file := range files {
+		assert.False(t, file.ModTime.IsZero())
+		file.ModTime = time.Time{}
+		got = append(got, file)
+	}
+	assert.Equal(t, []LogFile{
+		{Source: "nginx-access", Path: access, Size: 6},
+		{Source: "phpfpm", Version: "8.1", Path: php81, Size: 30},
+		{Source: "phpfpm", Version: "8.2", Path: php82},
+		{Source: "file", Path: mysql},
+		{Source: "file", Path: syslog, Size: 5},
+	}, got, "missing logs and rotated archives are left out")
+}
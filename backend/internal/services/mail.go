@@ -0,0 +1,158 @@
+package services
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultMailStoragePath is used when paths.mail_storage is not configured
+const DefaultMailStoragePath = "/var/vmail"
+
+// MailStorage reports disk usage of the mailboxes owned by a linux user
+type MailStorage interface {
+	MailboxUsage(owner string) ([]MailboxUsage, error)
+}
+
+// MailboxUsage is the disk usage of a single mailbox
+type MailboxUsage struct {
+	Address    string `json:"address"`
+	Domain     string `json:"domain"`
+	Mailbox    string `json:"mailbox"`
+	UsedBytes  int64  `json:"used_bytes"`
+	QuotaBytes int64  `json:"quota_bytes"` // -1 = unlimited
+	OverQuota  bool   `json:"over_quota"`
+}
+
+// MailUsageReport summarizes mailbox usage for a client
+type MailUsageReport struct {
+	Mailboxes      []MailboxUsage `json:"mailboxes"`
+	TotalUsedBytes int64          `json:"total_used_bytes"`
+	QuotaMB        int            `json:"quota_mb"` // per mailbox, -1 = unlimited
+	OverQuota      bool           `json:"over_quota"`
+}
+
+// maildirStorage reads usage from a maildir tree laid out as <root>/<owner>/<domain>/<mailbox>
+type maildirStorage struct {
+	root string
+}
+
+// NewMaildirStorage creates a MailStorage backed by maildirs under root
+func NewMaildirStorage(root string) MailStorage {
+	if root == "" {
+		root = DefaultMailStoragePath
+	}
+	return &maildirStorage{root: root}
+}
+
+func (s *maildirStorage) MailboxUsage(owner string) ([]MailboxUsage, error) {
+	ownerDir := filepath.Join(s.root, owner)
+
+	domains, err := os.ReadDir(ownerDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []MailboxUsage{}, nil
+		}
+		return nil, fmt.Errorf("failed to read mail storage: %w", err)
+	}
+
+	usage := []MailboxUsage{}
+	for _, domain := range domains {
+		if !domain.IsDir() {
+			continue
+		}
+
+		mailboxes, err := os.ReadDir(filepath.Join(ownerDir, domain.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mail domain %s: %w", domain.Name(), err)
+		}
+
+		for _, mailbox := range mailboxes {
+			if !mailbox.IsDir() {
+				continue
+			}
+
+			size, err := dirSize(filepath.Join(ownerDir, domain.Name(), mailbox.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to measure mailbox %s@%s: %w", mailbox.Name(), domain.Name(), err)
+			}
+
+			usage = append(usage, MailboxUsage{
+				Address:   mailbox.Name() + "@" + domain.Name(),
+				Domain:    domain.Name(),
+				Mailbox:   mailbox.Name(),
+				UsedBytes: size,
+			})
+		}
+	}
+
+	return usage, nil
+}
+
+// dirSize returns the total size of regular files under path
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+type MailService struct {
+	storage MailStorage
+}
+
+// NewMailService creates a mail service on top of the given storage backend
+func NewMailService(storage MailStorage) *MailService {
+	return &MailService{storage: storage}
+}
+
+// GetUsage reports mailbox usage for a linux user against a per-mailbox quota in MB
+func (s *MailService) GetUsage(owner string, quotaMB int) (*MailUsageReport, error) {
+	report := &MailUsageReport{
+		Mailboxes: []MailboxUsage{},
+		QuotaMB:   quotaMB,
+	}
+	if owner == "" {
+		return report, nil
+	}
+
+	mailboxes, err := s.storage.MailboxUsage(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	quotaBytes := int64(-1)
+	if quotaMB >= 0 {
+		quotaBytes = int64(quotaMB) * 1024 * 1024
+	}
+
+	for _, mailbox := range mailboxes {
+		mailbox.QuotaBytes = quotaBytes
+		mailbox.OverQuota = quotaBytes >= 0 && mailbox.UsedBytes > quotaBytes
+		if mailbox.OverQuota {
+			report.OverQuota = true
+		}
+		report.TotalUsedBytes += mailbox.UsedBytes
+		report.Mailboxes = append(report.Mailboxes, mailbox)
+	}
+
+	sort.Slice(report.Mailboxes, func(i, j int) bool {
+		return report.Mailboxes[i].Address < report.Mailboxes[j].Address
+	})
+
+	return report, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMailFile(t *testing.T, path string, size int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+}
+
+func TestMailServiceGetUsage(t *testing.T) {
+	root := t.TempDir()
+
+	// <root>/<owner>/<domain>/<mailbox>/{cur,new,tmp}
+	writeMailFile(t, filepath.Join(root, "client1", "example.com", "info", "cur", "1.eml"), 600*1024)
+	writeMailFile(t, filepath.Join(root, "client1", "example.com", "info", "new", "2.eml"), 600*1024)
+	writeMailFile(t, filepath.Join(root, "client1", "example.com", "sales", "cur", "1.eml"), 1024)
+	writeMailFile(t, filepath.Join(root, "client1", "example.org", "admin", "new", "1.eml"), 2048)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "client1", "example.org", "empty", "tmp"), 0755))
+	writeMailFile(t, filepath.Join(root, "client2", "other.com", "info", "cur", "1.eml"), 4096)
+
+	service := NewMailService(NewMaildirStorage(root))
+
+	t.Run("reports usage against quota", func(t *testing.T) {
+		report, err := service.GetUsage("client1", 1)
+		require.NoError(t, err)
+		require.Len(t, report.Mailboxes, 4)
+
+		byAddress := map[string]MailboxUsage{}
+		for _, mailbox := range report.Mailboxes {
+			byAddress[mailbox.Address] = mailbox
+		}
+
+		assert.Equal(t, int64(1200*1024), byAddress["info@example.com"].UsedBytes)
+		assert.True(t, byAddress["info@example.com"].OverQuota)
+		assert.Equal(t, int64(1024*1024), byAddress["info@example.com"].QuotaBytes)
+		assert.False(t, byAddress["sales@example.com"].OverQuota)
+		assert.Equal(t, int64(2048), byAddress["admin@example.org"].UsedBytes)
+		assert.Equal(t, int64(0), byAddress["empty@example.org"].UsedBytes)
+
+		assert.Equal(t, int64(1200*1024+1024+2048), report.TotalUsedBytes)
+		assert.True(t, report.OverQuota)
+	})
+
+	t.Run("unlimited quota is never exceeded", func(t *testing.T) {
+		report, err := service.GetUsage("client1", -1)
+		require.NoError(t, err)
+		assert.False(t, report.OverQuota)
+		for _, mailbox := range report.Mailboxes {
+			assert.Equal(t, int64(-1), mailbox.QuotaBytes)
+			assert.False(t, mailbox.OverQuota)
+		}
+	})
+
+	t.Run("owner without mail storage", func(t *testing.T) {
+		report, err := service.GetUsage("nobody", 10)
+		require.NoError(t, err)
+		assert.Empty(t, report.Mailboxes)
+		assert.False(t, report.OverQuota)
+	})
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"r-panel/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SettingMaintenance stores the maintenance mode as JSON
+const SettingMaintenance = "maintenance"
+
+// DefaultMaintenanceRetryAfter is how long clients are told to wait when the
+// admin did not say
+const DefaultMaintenanceRetryAfter = 5 * time.Minute
+
+// MaintenanceState is the server-wide maintenance mode. While it is enabled only
+// admins may change anything, everyone can still read.
+type MaintenanceState struct {
+	Enabled    bool       `json:"enabled"`
+	Message    string     `json:"message,omitempty"`
+	RetryAfter int        `json:"retry_after,omitempty"` // seconds
+	Since      *time.Time `json:"since,omitempty"`
+}
+
+// maintenanceCache keeps the state in memory, the middleware reads it on every
+// request. It is reloaded when models.DB changes, as it does between tests.
+var maintenanceCache struct {
+	sync.Mutex
+	db    *gorm.DB
+	state MaintenanceState
+}
+
+type MaintenanceService struct{}
+
+func NewMaintenanceService() *MaintenanceService {
+	return &MaintenanceService{}
+}
+
+// Get returns the current maintenance state
+func (s *MaintenanceService) Get() (MaintenanceState, error) {
+	maintenanceCache.Lock()
+	defer maintenanceCache.Unlock()
+
+	if maintenanceCache.db == models.DB {
+		return maintenanceCache.state, nil
+	}
+
+	var setting models.Setting
+	if err := models.DB.Where("`key` = ?", SettingMaintenance).Limit(1).Find(&setting).Error; err != nil {
+		return MaintenanceState{}, fmt.Errorf("failed to read maintenance mode: %w", err)
+	}
+	state := MaintenanceState{}
+	if setting.Value != "" {
+		if err := json.Unmarshal([]byte(setting.Value), &state); err != nil {
+			return MaintenanceState{}, fmt.Errorf("failed to read maintenance mode: %w", err)
+		}
+	}
+	maintenanceCache.db = models.DB
+	maintenanceCache.state = state
+	return state, nil
+}
+
+// Set enables or disables maintenance mode and persists it, so it survives a
+// restart in the middle of an upgrade. retryAfter of zero or less uses
+// DefaultMaintenanceRetryAfter.
+func (s *MaintenanceService) Set(enabled bool, message string, retryAfter time.Duration) (MaintenanceState, error) {
+	if retryAfter <= 0 {
+		retryAfter = DefaultMaintenanceRetryAfter
+	}
+
+	state := MaintenanceState{}
+	if enabled {
+		now := time.Now()
+		state = MaintenanceState{
+			Enabled:    true,
+			Message:    strings.TrimSpace(message),
+			RetryAfter: int(retryAfter.Seconds()),
+			Since:      &now,
+		}
+	}
+
+	value, err := json.Marshal(state)
+	if err != nil {
+		return MaintenanceState{}, err
+	}
+
+	maintenanceCache.Lock()
+	defer maintenanceCache.Unlock()
+
+	if err := models.DB.Save(&models.Setting{Key: SettingMaintenance, Value: string(value)}).Error; err != nil {
+		return MaintenanceState{}, fmt.Errorf("failed to save maintenance mode: %w", err)
+	}
+	maintenanceCache.db = models.DB
+	maintenanceCache.state = state
+	return state, nil
+}
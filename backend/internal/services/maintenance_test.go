@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceServicePersists(t *testing.T) {
+	setupTestDB(t)
+	service := NewMaintenanceService()
+
+	state, err := service.Get()
+	require.NoError(t, err)
+	assert.False(t, state.Enabled)
+
+	state, err = service.Set(true, "  upgrading  ", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "upgrading", state.Message)
+	assert.Equal(t, int(DefaultMaintenanceRetryAfter.Seconds()), state.RetryAfter)
+	require.NotNil(t, state.Since)
+
+	// A restart reads it back from the settings table
+	maintenanceCache.db = nil
+	state, err = service.Get()
+	require.NoError(t, err)
+	assert.True(t, state.Enabled)
+	assert.Equal(t, "upgrading", state.Message)
+	assert.WithinDuration(t, time.Now(), *state.Since, time.Minute)
+
+	state, err = service.Set(false, "ignored", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, MaintenanceState{}, state)
+	maintenanceCache.db = nil
+	state, err = service.Get()
+	require.NoError(t, err)
+	assert.False(t, state.Enabled)
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace prefixes every metric the panel exports
+const metricsNamespace = "rpanel"
+
+// Metrics is the Prometheus registry served at /metrics. It counts HTTP
+// requests itself, everything else comes from registered collectors.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time spent handling HTTP requests, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// ObserveRequest records a handled request. route is the route pattern, not
+// the path, so IDs in paths do not create a series each.
+func (m *Metrics) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.duration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// MustRegister adds collectors to the registry and panics if one clashes with
+// a registered metric
+func (m *Metrics) MustRegister(collectors ...prometheus.Collector) {
+	m.registry.MustRegister(collectors...)
+}
+
+// Handler serves the registry in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// dashboardCollector exports the dashboard as gauges. It reads the cached
+// dashboard, so frequent scrapes do not add work on top of the home screen.
+type dashboardCollector struct {
+	dashboard *DashboardService
+
+	cpu, memoryUsed, memoryTotal, diskUsed, serviceUp *prometheus.Desc
+	clients, users, sites, databases                  *prometheus.Desc
+}
+
+// NewDashboardCollector exports system usage, service states and the
+// server-wide counts of dashboard
+func NewDashboardCollector(dashboard *DashboardService) prometheus.Collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", name), help, labels, nil)
+	}
+	return &dashboardCollector{
+		dashboard:   dashboard,
+		cpu:         desc("cpu_usage_percent", "CPU usage of the host in percent."),
+		memoryUsed:  desc("memory_used_bytes", "Memory in use on the host."),
+		memoryTotal: desc("memory_total_bytes", "Total memory of the host."),
+		diskUsed:    desc("disk_used_percent", "Used space of a mounted file system in percent.", "mount"),
+		serviceUp:   desc("service_up", "Whether a monitored systemd service is active.", "service"),
+		clients:     desc("clients", "Number of clients."),
+		users:       desc("users", "Number of panel users."),
+		sites:       desc("nginx_sites", "Number of Nginx sites."),
+		databases:   desc("databases", "Number of databases on the primary MySQL server."),
+	}
+}
+
+func (d *dashboardCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{d.cpu, d.memoryUsed, d.memoryTotal, d.diskUsed, d.serviceUp, d.clients, d.users, d.sites, d.databases} {
+		ch <- desc
+	}
+}
+
+// Collect leaves out the parts of the dashboard that were unavailable rather
+// than reporting them as zero
+func (d *dashboardCollector) Collect(ch chan<- prometheus.Metric) {
+	dashboard, err := d.dashboard.GetDashboard()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(d.clients, err)
+		return
+	}
+	gauge := func(desc *prometheus.Desc, value float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+	}
+
+	if stats := dashboard.Stats; stats != nil {
+		gauge(d.cpu, stats.CPU.UsagePercent)
+		gauge(d.memoryUsed, float64(stats.Memory.Used))
+		gauge(d.memoryTotal, float64(stats.Memory.Total))
+		// A mount point listed twice would fail the whole scrape
+		mounts := map[string]bool{}
+		for _, disk := range stats.Disk {
+			if mounts[disk.MountedOn] {
+				continue
+			}
+			mounts[disk.MountedOn] = true
+			if used, err := strconv.ParseFloat(strings.TrimSuffix(disk.UsePercent, "%"), 64); err == nil {
+				gauge(d.diskUsed, used, disk.MountedOn)
+			}
+		}
+	}
+	for _, service := range dashboard.Services {
+		up := 0.0
+		if service.Active {
+			up = 1
+		}
+		gauge(d.serviceUp, up, service.Name)
+	}
+
+	gauge(d.clients, float64(dashboard.Counts.Clients))
+	gauge(d.users, float64(dashboard.Counts.Users))
+	if _, ok := dashboard.Unavailable["sites"]; !ok {
+		gauge(d.sites, float64(dashboard.Counts.Sites))
+	}
+	if _, ok := dashboard.Unavailable["databases"]; !ok {
+		gauge(d.databases, float64(dashboard.Counts.Databases))
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	createPurgeTestClient(t, NewClientService(cfg), "metricsclient")
+
+	dashboard, fsys := newTestDashboardService(t, cfg.Paths.Backups)
+	require.NoError(t, fsys.WriteFile("/etc/nginx/sites-available/one.example.com", []byte(""), 0644))
+
+	metrics := NewMetrics()
+	metrics.MustRegister(NewDashboardCollector(dashboard))
+	metrics.ObserveRequest("GET", "/api/clients/:id", 200, 20*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+
+	assert.Contains(t, body, `rpanel_http_requests_total{method="GET",route="/api/clients/:id",status="200"} 1`)
+	assert.Contains(t, body, `rpanel_http_request_duration_seconds_count{method="GET",route="/api/clients/:id"} 1`)
+	assert.Contains(t, body, "rpanel_clients 1\n")
+	assert.Contains(t, body, "rpanel_nginx_sites 1\n")
+	assert.Contains(t, body, `rpanel_service_up{service="nginx"} 1`)
+	// MySQL is not configured, its count is left out rather than reported as 0
+	assert.NotContains(t, body, "rpanel_databases ")
+}
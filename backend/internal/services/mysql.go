@@ -1,77 +1,183 @@
 package services
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
+var (
+	ErrMySQLUnavailable   = errors.New("MySQL server is unavailable")
+	ErrDatabaseNotFound   = errors.New("database not found")
+	ErrUnsafeDatabaseName = errors.New("database name contains unsupported characters")
+	ErrSystemDatabase     = errors.New("system databases cannot be maintained from the panel")
+	ErrInvalidMySQLUser   = errors.New("invalid MySQL user")
+	ErrAnyHostNotAllowed  = errors.New("host lets the user connect from any host, confirm it with allow_any_host")
+)
+
+// systemDatabases are the MySQL schemas hidden from the panel
+var systemDatabases = map[string]bool{
+	"information_schema": true,
+	"mysql":              true,
+	"performance_schema": true,
+	"sys":                true,
+}
+
+// cliDatabaseNamePattern matches database names safe to pass to mysqldump and mysql as an argument
+var cliDatabaseNamePattern = regexp.MustCompile(`^[A-Za-z0-9_$][A-Za-z0-9_$-]{0,63}$`)
+
+var (
+	// mysqlUsernamePattern matches the user names the panel creates and manages
+	mysqlUsernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.$-]{1,32}$`)
+	// mysqlHostPattern matches host names, IP addresses, netmasks and % or _ wildcards
+	mysqlHostPattern = regexp.MustCompile(`^[A-Za-z0-9.:%_/-]{1,255}$`)
+)
+
+// mysqlErrorLinePattern finds the line number in mysql client errors such as
+// "ERROR 1064 (42000) at line 12: You have an error in your SQL syntax"
+var mysqlErrorLinePattern = regexp.MustCompile(`at line (\d+)`)
+
+// maxImportContextLen caps the statement excerpt included in import errors
+const maxImportContextLen = 200
+
+// SQLImportError reports where in a dump the mysql client stopped
+type SQLImportError struct {
+	Line      int    // 0 when mysql did not report a line
+	Statement string // the dump line mysql reported, truncated
+	Message   string
+}
+
+func (e *SQLImportError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	if e.Statement == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s (near: %s)", e.Line, e.Message, e.Statement)
+}
+
+const (
+	mysqlConnectAttempts = 5
+	mysqlInitialBackoff  = 500 * time.Millisecond
+	mysqlMaxBackoff      = 8 * time.Second
+	mysqlPingTimeout     = 3 * time.Second
+)
+
 type MySQLService struct {
-	dsn string
-	db  *sql.DB
+	dsn       string
+	db        *sql.DB
+	mysqldump string // mysqldump binary, overridden in tests
+	mysql     string // mysql client binary, overridden in tests
+
+	// Connection options and environment for mysqldump and mysql. Empty for the
+	// primary server, which the client tools reach through their own defaults.
+	cliArgs []string
+	cliEnv  []string
 }
 
 type Database struct {
-	Name string `json:"name"`
-	Size string `json:"size"`
+	Name       string `json:"name"`
+	Size       string `json:"size"` // human-readable, e.g. "12.34 MB"
+	SizeBytes  int64  `json:"size_bytes"`
+	TableCount int    `json:"table_count"`
+	Collation  string `json:"collation"`
+}
+
+// TableMaintenanceResult is the outcome of OPTIMIZE TABLE or REPAIR TABLE for one table
+type TableMaintenanceResult struct {
+	Table   string `json:"table"`
+	Status  string `json:"status"`  // Msg_type of the final row: status, error, warning, note or info
+	Message string `json:"message"` // every Msg_text MySQL returned, joined with "; "
 }
 
 type MySQLUser struct {
 	User       string   `json:"user"`
 	Host       string   `json:"host"`
+	AnyHost    bool     `json:"any_host"` // host matches every host, e.g. %
+	Remote     bool     `json:"remote"`   // host allows connections from other machines
+	Hosts      []string `json:"hosts"`    // every host an account with this user name exists on
 	Privileges []string `json:"privileges"`
 }
 
+// NewMySQLService opens the connection pool and returns without waiting for the
+// server, so a MySQL that is still starting does not hold up the panel. The
+// first connection is made in the background with backoff; if the server is
+// still down after all attempts EnsureConnected reconnects lazily once it is up.
 func NewMySQLService(dsn string) (*MySQLService, error) {
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MySQL: %w", err)
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping MySQL: %w", err)
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	s := &MySQLService{
+		dsn:       dsn,
+		db:        db,
+		mysqldump: "mysqldump",
+		mysql:     "mysql",
 	}
 
-	return &MySQLService{
-		dsn: dsn,
-		db:  db,
-	}, nil
+	go s.connect()
+	return s, nil
 }
 
-// GetDatabases returns list of all databases
-func (s *MySQLService) GetDatabases() ([]Database, error) {
-	rows, err := s.db.Query("SHOW DATABASES")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var databases []Database
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			continue
+// connect waits for the server with backoff, logging while it is unreachable
+func (s *MySQLService) connect() {
+	var err error
+	backoff := mysqlInitialBackoff
+	for attempt := 1; attempt <= mysqlConnectAttempts; attempt++ {
+		if err = s.ping(); err == nil {
+			return
+		}
+		if attempt == mysqlConnectAttempts {
+			break
 		}
 
-		// Skip system databases
-		if name == "information_schema" || name == "mysql" || name == "performance_schema" || name == "sys" {
-			continue
+		log.Printf("MySQL not reachable (attempt %d/%d): %v, retrying in %s", attempt, mysqlConnectAttempts, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > mysqlMaxBackoff {
+			backoff = mysqlMaxBackoff
 		}
+	}
 
-		// Get database size
-		size, _ := s.getDatabaseSize(name)
+	log.Printf("Warning: MySQL not reachable after %d attempts, will retry on request: %v", mysqlConnectAttempts, err)
+}
 
-		databases = append(databases, Database{
-			Name: name,
-			Size: size,
-		})
+// EnsureConnected pings the server, letting the pool open a fresh connection
+// if the previous ones were dropped
+func (s *MySQLService) EnsureConnected() error {
+	if err := s.ping(); err != nil {
+		return fmt.Errorf("%w: %v", ErrMySQLUnavailable, err)
 	}
+	return nil
+}
 
-	return databases, nil
+func (s *MySQLService) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), mysqlPingTimeout)
+	defer cancel()
+	return s.db.PingContext(ctx)
 }
 
 // CreateDatabase creates a new database
@@ -88,53 +194,140 @@ func (s *MySQLService) DeleteDatabase(name string) error {
 	return err
 }
 
-// GetUsers returns list of MySQL users
-func (s *MySQLService) GetUsers() ([]MySQLUser, error) {
-	rows, err := s.db.Query("SELECT User, Host FROM mysql.user")
-	if err != nil {
-		return nil, err
+// OptimizeDatabase runs OPTIMIZE TABLE on every table of a database
+func (s *MySQLService) OptimizeDatabase(name string) ([]TableMaintenanceResult, error) {
+	return s.maintainTables(name, "OPTIMIZE")
+}
+
+// RepairDatabase runs REPAIR TABLE on every table of a database. InnoDB tables
+// report that they don't support repair; the result says so per table.
+func (s *MySQLService) RepairDatabase(name string) ([]TableMaintenanceResult, error) {
+	return s.maintainTables(name, "REPAIR")
+}
+
+// maintainTables runs a table maintenance statement (OPTIMIZE or REPAIR) on each
+// base table of database, one table at a time so a single failure is reported
+// alongside the tables that succeeded
+func (s *MySQLService) maintainTables(database, operation string) ([]TableMaintenanceResult, error) {
+	if systemDatabases[strings.ToLower(database)] {
+		return nil, fmt.Errorf("%w: %s", ErrSystemDatabase, database)
 	}
-	defer rows.Close()
 
-	var users []MySQLUser
-	userMap := make(map[string]*MySQLUser)
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?", database).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look up database: %w", err)
+	}
+	if exists == 0 {
+		return nil, ErrDatabaseNotFound
+	}
 
+	rows, err := s.db.Query(`SELECT TABLE_NAME FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME`, database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	var tables []string
 	for rows.Next() {
-		var user, host string
-		if err := rows.Scan(&user, &host); err != nil {
-			continue
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			rows.Close()
+			return nil, err
 		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		key := fmt.Sprintf("%s@%s", user, host)
-		if _, exists := userMap[key]; !exists {
-			userMap[key] = &MySQLUser{
-				User:       user,
-				Host:       host,
-				Privileges: []string{},
-			}
+	results := make([]TableMaintenanceResult, 0, len(tables))
+	for _, table := range tables {
+		results = append(results, s.maintainTable(database, table, operation))
+	}
+	return results, nil
+}
+
+func (s *MySQLService) maintainTable(database, table, operation string) TableMaintenanceResult {
+	result := TableMaintenanceResult{Table: table}
+
+	query := fmt.Sprintf("%s TABLE %s.%s", operation, quoteIdentifier(database), quoteIdentifier(table))
+	rows, err := s.db.Query(query)
+	if err != nil {
+		result.Status = "error"
+		result.Message = err.Error()
+		return result
+	}
+	defer rows.Close()
+
+	// Each row is Table, Op, Msg_type, Msg_text
+	var messages []string
+	for rows.Next() {
+		var name, op, msgType, msgText string
+		if err := rows.Scan(&name, &op, &msgType, &msgText); err != nil {
+			result.Status = "error"
+			result.Message = err.Error()
+			return result
 		}
+		result.Status = strings.ToLower(msgType)
+		messages = append(messages, msgText)
 	}
+	if err := rows.Err(); err != nil {
+		result.Status = "error"
+		messages = append(messages, err.Error())
+	}
+	result.Message = strings.Join(messages, "; ")
+	return result
+}
+
+// quoteIdentifier quotes a MySQL identifier with backticks
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// quoteString quotes a MySQL string literal
+func quoteString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(value) + "'"
+}
+
+// quoteAccount writes the 'user'@'host' account name of a MySQL user
+func quoteAccount(username, host string) string {
+	return quoteString(username) + "@" + quoteString(host)
+}
 
-	// Get privileges for each user
-	for key, user := range userMap {
-		privs, _ := s.getUserPrivileges(user.User, user.Host)
-		user.Privileges = privs
-		users = append(users, *userMap[key])
+// ValidateMySQLAccount checks a user name and host before they go into an
+// account name. The host may be a name, an IP address, a netmask such as
+// 10.0.0.0/255.255.255.0 or a pattern with the % and _ wildcards.
+func ValidateMySQLAccount(username, host string) error {
+	if !mysqlUsernamePattern.MatchString(username) {
+		return fmt.Errorf("%w: user names are 1 to 32 letters, digits and . _ $ -", ErrInvalidMySQLUser)
 	}
+	if !mysqlHostPattern.MatchString(host) {
+		return fmt.Errorf("%w: host %q is not a host name, IP address or pattern", ErrInvalidMySQLUser, host)
+	}
+	return nil
+}
 
-	return users, nil
+// AnyMySQLHost reports whether host matches every host, such as % or %.%
+func AnyMySQLHost(host string) bool {
+	return strings.Contains(host, "%") && strings.Trim(host, "%.") == ""
 }
 
+// localMySQLHosts are the hosts that only allow connections from this machine
+var localMySQLHosts = map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true}
+
 // CreateUser creates a new MySQL user
 func (s *MySQLService) CreateUser(username, password, host string) error {
-	query := fmt.Sprintf("CREATE USER '%s'@'%s' IDENTIFIED BY '%s'", username, host, password)
+	if err := ValidateMySQLAccount(username, host); err != nil {
+		return err
+	}
+	query := fmt.Sprintf("CREATE USER %s IDENTIFIED BY %s", quoteAccount(username, host), quoteString(password))
 	_, err := s.db.Exec(query)
 	return err
 }
 
 // DeleteUser deletes a MySQL user
 func (s *MySQLService) DeleteUser(username, host string) error {
-	query := fmt.Sprintf("DROP USER '%s'@'%s'", username, host)
+	query := fmt.Sprintf("DROP USER %s", quoteAccount(username, host))
 	_, err := s.db.Exec(query)
 	return err
 }
@@ -143,9 +336,9 @@ func (s *MySQLService) DeleteUser(username, host string) error {
 func (s *MySQLService) GrantPrivileges(username, host, database, privileges string) error {
 	var query string
 	if database == "*" {
-		query = fmt.Sprintf("GRANT %s ON *.* TO '%s'@'%s'", privileges, username, host)
+		query = fmt.Sprintf("GRANT %s ON *.* TO %s", privileges, quoteAccount(username, host))
 	} else {
-		query = fmt.Sprintf("GRANT %s ON `%s`.* TO '%s'@'%s'", privileges, database, username, host)
+		query = fmt.Sprintf("GRANT %s ON %s.* TO %s", privileges, quoteIdentifier(database), quoteAccount(username, host))
 	}
 
 	_, err := s.db.Exec(query)
@@ -157,13 +350,51 @@ func (s *MySQLService) GrantPrivileges(username, host, database, privileges stri
 	return err
 }
 
-// ExecuteQuery executes a SQL query (read-only by default)
-func (s *MySQLService) ExecuteQuery(query string, readOnly bool) ([]map[string]interface{}, error) {
-	if readOnly && !s.isReadOnlyQuery(query) {
+// Row limits of ExecuteQuery
+const (
+	DefaultQueryRows = 100
+	MaxQueryRows     = 1000
+)
+
+// QueryOptions selects which rows of a result ExecuteQuery returns
+type QueryOptions struct {
+	ReadOnly bool
+	Limit    int // rows to return, DefaultQueryRows when 0, at most MaxQueryRows
+	Offset   int // rows to skip first
+}
+
+// QueryResult holds a page of a query's rows. Columns keeps the order of the
+// select list, which the row maps lose.
+type QueryResult struct {
+	Columns   []string                 `json:"columns"`
+	Rows      []map[string]interface{} `json:"results"`
+	Offset    int                      `json:"offset"`
+	Limit     int                      `json:"limit"`
+	Truncated bool                     `json:"truncated"` // more rows follow the returned ones
+}
+
+// ExecuteQuery executes a SQL query (read-only by default) and returns at most
+// opts.Limit rows after skipping opts.Offset
+func (s *MySQLService) ExecuteQuery(ctx context.Context, query string, opts QueryOptions) (*QueryResult, error) {
+	if opts.ReadOnly && !s.isReadOnlyQuery(query) {
 		return nil, fmt.Errorf("write operations are not allowed")
 	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultQueryRows
+	}
+	if opts.Limit > MaxQueryRows {
+		opts.Limit = MaxQueryRows
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
 
-	rows, err := s.db.Query(query)
+	// Cancelling drops the connection, so the server stops sending the rest
+	// of a big result instead of Close reading it to the end
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -173,9 +404,30 @@ func (s *MySQLService) ExecuteQuery(query string, readOnly bool) ([]map[string]i
 	if err != nil {
 		return nil, err
 	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
 
-	var results []map[string]interface{}
+	result := &QueryResult{
+		Columns: columns,
+		Rows:    []map[string]interface{}{},
+		Offset:  opts.Offset,
+		Limit:   opts.Limit,
+	}
+	// Skipped rows are never scanned
+	for i := 0; i < opts.Offset; i++ {
+		if !rows.Next() {
+			break
+		}
+	}
 	for rows.Next() {
+		if len(result.Rows) == opts.Limit {
+			result.Truncated = true
+			cancel()
+			return result, nil
+		}
+
 		values := make([]interface{}, len(columns))
 		valuePtrs := make([]interface{}, len(columns))
 		for i := range values {
@@ -186,19 +438,80 @@ func (s *MySQLService) ExecuteQuery(query string, readOnly bool) ([]map[string]i
 			return nil, err
 		}
 
-		result := make(map[string]interface{})
+		row := make(map[string]interface{})
 		for i, col := range columns {
-			result[col] = values[i]
+			row[col] = decodeColumnValue(values[i], columnTypes[i].DatabaseTypeName())
 		}
-		results = append(results, result)
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return results, nil
+	return result, nil
+}
+
+// mysqlTimeLayouts parse DATE, DATETIME and TIMESTAMP values the driver left as text
+var mysqlTimeLayouts = map[string]string{
+	"DATE":      "2006-01-02",
+	"DATETIME":  "2006-01-02 15:04:05.999999",
+	"TIMESTAMP": "2006-01-02 15:04:05.999999",
+}
+
+// decodeColumnValue turns the raw bytes the driver returns for most columns
+// into a value that serializes to readable JSON: numbers for numeric types,
+// times for dates and strings for the rest. NULL stays nil. Binary data that is
+// not valid UTF-8 is left as bytes and ends up base64 encoded.
+func decodeColumnValue(value interface{}, typeName string) interface{} {
+	raw, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+	text := string(raw)
+
+	switch typeName = strings.TrimPrefix(typeName, "UNSIGNED "); typeName {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR":
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(text, 10, 64); err == nil {
+			return n
+		}
+	case "FLOAT", "DOUBLE":
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	case "DECIMAL":
+		// Kept exact, a float64 would round large or precise values
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return json.Number(text)
+		}
+	case "DATE", "DATETIME", "TIMESTAMP":
+		// Zero dates like 0000-00-00 fail to parse and stay text; UTC matches
+		// what the driver's parseTime uses for the connection
+		if t, err := time.ParseInLocation(mysqlTimeLayouts[typeName], text, time.UTC); err == nil {
+			return t
+		}
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BIT", "GEOMETRY":
+		if !utf8.Valid(raw) {
+			return raw
+		}
+	}
+	return text
+}
+
+// cliCommand builds a mysql client tool invocation that connects to this service's server
+func (s *MySQLService) cliCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, append(append([]string{}, s.cliArgs...), args...)...)
+	if len(s.cliEnv) > 0 {
+		cmd.Env = append(os.Environ(), s.cliEnv...)
+	}
+	return cmd
 }
 
 // ExportDatabase exports a database to SQL file
-func (s *MySQLService) ExportDatabase(database, outputPath string) error {
-	cmd := exec.Command("mysqldump", "--single-transaction", "--routines", "--triggers", database)
+func (s *MySQLService) ExportDatabase(ctx context.Context, database, outputPath string) error {
+	cmd := s.cliCommand(ctx, s.mysqldump, "--single-transaction", "--routines", "--triggers", database)
 	output, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to export database: %w", err)
@@ -211,56 +524,198 @@ func (s *MySQLService) ExportDatabase(database, outputPath string) error {
 	return nil
 }
 
-// ImportDatabase imports a database from SQL file
-func (s *MySQLService) ImportDatabase(database, filePath string) error {
-	data, err := os.ReadFile(filePath)
+// StreamDatabaseExport writes a gzipped mysqldump of database to w without
+// touching the disk. Nothing is written to w until mysqldump has produced
+// output, so callers can still report an error if the dump fails to start.
+func (s *MySQLService) StreamDatabaseExport(ctx context.Context, database string, w io.Writer) error {
+	if !cliDatabaseNamePattern.MatchString(database) {
+		return fmt.Errorf("%w: %q", ErrUnsafeDatabaseName, database)
+	}
+
+	var exists int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?", database).Scan(&exists)
 	if err != nil {
-		return fmt.Errorf("failed to read import file: %w", err)
+		return fmt.Errorf("failed to look up database: %w", err)
 	}
+	if exists == 0 {
+		return ErrDatabaseNotFound
+	}
+
+	return s.dumpDatabase(ctx, database, w)
+}
 
-	_, err = s.db.Exec(fmt.Sprintf("USE `%s`", database))
+// dumpDatabase streams mysqldump output through gzip into w
+func (s *MySQLService) dumpDatabase(ctx context.Context, database string, w io.Writer) error {
+	cmd := s.cliCommand(ctx, s.mysqldump, "--single-transaction", "--routines", "--triggers", database)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("failed to use database: %w", err)
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start mysqldump: %w", err)
 	}
 
-	// Execute SQL statements
-	statements := strings.Split(string(data), ";")
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" {
-			continue
+	// Wait for the first output before committing to a response
+	output := bufio.NewReaderSize(stdout, 64*1024)
+	if _, err := output.Peek(1); err != nil {
+		io.Copy(io.Discard, output)
+		waitErr := cmd.Wait()
+		if waitErr == nil {
+			waitErr = errors.New("no output")
 		}
-		if _, err := s.db.Exec(stmt); err != nil {
-			return fmt.Errorf("failed to execute statement: %w", err)
+		return fmt.Errorf("failed to export database: %v: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := io.Copy(gz, output); err != nil {
+		// The client went away, stop dumping
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("export interrupted: %w", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		// Leave the gzip stream without its trailer so the partial dump can't pass as complete
+		return fmt.Errorf("mysqldump failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("export interrupted: %w", err)
+	}
+	return nil
+}
+
+// ImportDatabase loads a plain or gzipped SQL dump into database by piping it
+// into the mysql client, so DELIMITER blocks, routines and semicolons inside
+// strings work like a regular import. SQL errors are returned as *SQLImportError.
+func (s *MySQLService) ImportDatabase(ctx context.Context, database, filePath string) error {
+	if !cliDatabaseNamePattern.MatchString(database) {
+		return fmt.Errorf("%w: %q", ErrUnsafeDatabaseName, database)
+	}
+
+	dump, closeDump, err := openSQLDump(filePath)
+	if err != nil {
+		return err
+	}
+	defer closeDump()
+
+	var stderr bytes.Buffer
+	cmd := s.cliCommand(ctx, s.mysql, "--batch", database)
+	cmd.Stdin = dump
+	cmd.Stderr = &stderr
+	cmd.Stdout = io.Discard
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return fmt.Errorf("failed to import database: %w", err)
 		}
+		return newSQLImportError(filePath, message)
 	}
 
 	return nil
 }
 
-// Helper functions
+// restoreCopyLayout timestamps the databases ImportDatabaseCopy creates
+const restoreCopyLayout = "20060102150405"
+
+// RestoreCopyName returns the name of a copy of database restored at t:
+// <database>_restore_<timestamp>, with database shortened so the name fits
+// MySQL's 64 character limit
+func RestoreCopyName(database string, t time.Time) string {
+	suffix := "_restore_" + t.UTC().Format(restoreCopyLayout)
+	if len(database)+len(suffix) > 64 {
+		database = database[:64-len(suffix)]
+	}
+	return database + suffix
+}
+
+// ImportDatabaseCopy imports a dump into a new database named after database
+// by RestoreCopyName, leaving database itself untouched, and returns the name
+// of the copy. The copy is dropped again if the import fails.
+func (s *MySQLService) ImportDatabaseCopy(ctx context.Context, database, filePath string) (string, error) {
+	if !cliDatabaseNamePattern.MatchString(database) {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeDatabaseName, database)
+	}
+
+	name := RestoreCopyName(database, time.Now())
+	if err := s.CreateDatabase(name); err != nil {
+		return "", fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	if err := s.ImportDatabase(ctx, name, filePath); err != nil {
+		if dropErr := s.DeleteDatabase(name); dropErr != nil {
+			log.Printf("Failed to drop restore copy %s: %v", name, dropErr)
+		}
+		return "", err
+	}
+	return name, nil
+}
+
+// openSQLDump opens a dump file, decompressing it if it is gzipped
+func openSQLDump(filePath string) (io.Reader, func(), error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read import file: %w", err)
+	}
 
-func (s *MySQLService) getDatabaseSize(name string) (string, error) {
-	query := fmt.Sprintf(`
-		SELECT ROUND(SUM(data_length + index_length) / 1024 / 1024, 2) AS size_mb
-		FROM information_schema.tables
-		WHERE table_schema = '%s'
-	`, name)
+	// Trust the gzip magic over the extension, uploads are often misnamed
+	buffered := bufio.NewReader(file)
+	magic, _ := buffered.Peek(2)
+	if !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		if strings.HasSuffix(filePath, ".gz") {
+			file.Close()
+			return nil, nil, fmt.Errorf("failed to read import file: %s is not gzip compressed", filepath.Base(filePath))
+		}
+		return buffered, func() { file.Close() }, nil
+	}
 
-	var size sql.NullFloat64
-	err := s.db.QueryRow(query).Scan(&size)
+	gz, err := gzip.NewReader(buffered)
 	if err != nil {
-		return "0 MB", err
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to decompress import file: %w", err)
+	}
+	return gz, func() { gz.Close(); file.Close() }, nil
+}
+
+// newSQLImportError adds the dump line mysql reported to its error message
+func newSQLImportError(filePath, message string) *SQLImportError {
+	importErr := &SQLImportError{Message: message}
+
+	match := mysqlErrorLinePattern.FindStringSubmatch(message)
+	if match == nil {
+		return importErr
 	}
+	importErr.Line, _ = strconv.Atoi(match[1])
 
-	if size.Valid {
-		return fmt.Sprintf("%.2f MB", size.Float64), nil
+	dump, closeDump, err := openSQLDump(filePath)
+	if err != nil {
+		return importErr
+	}
+	defer closeDump()
+
+	scanner := bufio.NewScanner(dump)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if line == importErr.Line {
+			statement := strings.TrimSpace(scanner.Text())
+			if len(statement) > maxImportContextLen {
+				statement = statement[:maxImportContextLen] + "..."
+			}
+			importErr.Statement = statement
+			break
+		}
 	}
-	return "0 MB", nil
+	return importErr
+}
+
+// Helper functions
+
+// formatSizeMB formats a byte count the way the database listing always has
+func formatSizeMB(bytes int64) string {
+	return fmt.Sprintf("%.2f MB", float64(bytes)/1024/1024)
 }
 
 func (s *MySQLService) getUserPrivileges(user, host string) ([]string, error) {
-	query := fmt.Sprintf("SHOW GRANTS FOR '%s'@'%s'", user, host)
+	query := fmt.Sprintf("SHOW GRANTS FOR %s", quoteAccount(user, host))
 	rows, err := s.db.Query(query)
 	if err != nil {
 		return nil, err
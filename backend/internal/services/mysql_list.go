@@ -0,0 +1,222 @@
+package services
+
+import (
+	"strings"
+)
+
+// MySQLListOptions filters and pages the result of GetDatabases and GetUsers
+type MySQLListOptions struct {
+	Search string // names containing this, ignoring case; empty for every name
+	Page   int    // 1-based, 0 for every match on one page
+	Limit  int    // items per page, 15 by default and at most 100
+}
+
+// DatabaseListResult is one page of databases
+type DatabaseListResult struct {
+	Data       []Database `json:"data"`
+	Total      int        `json:"total"`
+	Page       int        `json:"page"`
+	Limit      int        `json:"limit"`
+	TotalPages int        `json:"total_pages"`
+}
+
+// MySQLUserListResult is one page of MySQL accounts
+type MySQLUserListResult struct {
+	Data       []MySQLUser `json:"data"`
+	Total      int         `json:"total"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	TotalPages int         `json:"total_pages"`
+}
+
+// mysqlPage is the LIMIT and OFFSET of one page of total items
+type mysqlPage struct {
+	page, limit, totalPages int
+	sql                     string // " LIMIT ? OFFSET ?", empty for everything
+	args                    []interface{}
+}
+
+// pageOf works out the page opts asks for out of total items. Without a page
+// every item is on the first one.
+func (opts MySQLListOptions) pageOf(total int) mysqlPage {
+	if opts.Page <= 0 && opts.Limit <= 0 {
+		return mysqlPage{page: 1, limit: total, totalPages: 1}
+	}
+	page := mysqlPage{page: max(opts.Page, 1), limit: opts.Limit}
+	if page.limit < 1 {
+		page.limit = 15
+	}
+	page.limit = min(page.limit, 100)
+	page.totalPages = (total + page.limit - 1) / page.limit
+	page.sql = " LIMIT ? OFFSET ?"
+	page.args = []interface{}{page.limit, (page.page - 1) * page.limit}
+	return page
+}
+
+// nameFilter returns the condition matching column against opts.Search, with
+// the LIKE wildcards in the search taken literally
+func (opts MySQLListOptions) nameFilter(column string) (string, []interface{}) {
+	if opts.Search == "" {
+		return "", nil
+	}
+	escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(strings.ToLower(opts.Search))
+	return " AND LOWER(" + column + ") LIKE ? ESCAPE '!'", []interface{}{"%" + escaped + "%"}
+}
+
+// placeholders returns "?, ?, ?" for n values
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// userDatabasesFilter leaves out the system schemas
+const userDatabasesFilter = "SCHEMA_NAME NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')"
+
+// GetDatabases returns the databases matching opts by name. Sizes and table
+// counts are only read for the databases on the page, summing them for
+// thousands of schemas is what makes listing slow.
+func (s *MySQLService) GetDatabases(opts MySQLListOptions) (*DatabaseListResult, error) {
+	filter, args := opts.nameFilter("SCHEMA_NAME")
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE "+userDatabasesFilter+filter, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+	page := opts.pageOf(total)
+	result := &DatabaseListResult{Data: []Database{}, Total: total, Page: page.page, Limit: page.limit, TotalPages: page.totalPages}
+
+	rows, err := s.db.Query(
+		"SELECT SCHEMA_NAME, DEFAULT_COLLATION_NAME FROM information_schema.SCHEMATA WHERE "+userDatabasesFilter+filter+
+			" ORDER BY SCHEMA_NAME"+page.sql,
+		append(args, page.args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	index := map[string]int{}
+	var names []interface{}
+	for rows.Next() {
+		var db Database
+		if err := rows.Scan(&db.Name, &db.Collation); err != nil {
+			return nil, err
+		}
+		index[db.Name] = len(result.Data)
+		names = append(names, db.Name)
+		result.Data = append(result.Data, db)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(names) > 0 {
+		sizes, err := s.db.Query(
+			"SELECT TABLE_SCHEMA, COALESCE(SUM(DATA_LENGTH + INDEX_LENGTH), 0), COUNT(*) FROM information_schema.TABLES"+
+				" WHERE TABLE_SCHEMA IN ("+placeholders(len(names))+") GROUP BY TABLE_SCHEMA",
+			names...)
+		if err != nil {
+			return nil, err
+		}
+		defer sizes.Close()
+		for sizes.Next() {
+			var name string
+			var size int64
+			var tables int
+			if err := sizes.Scan(&name, &size, &tables); err != nil {
+				return nil, err
+			}
+			if i, ok := index[name]; ok {
+				result.Data[i].SizeBytes = size
+				result.Data[i].TableCount = tables
+			}
+		}
+		if err := sizes.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range result.Data {
+		result.Data[i].Size = formatSizeMB(result.Data[i].SizeBytes)
+	}
+	return result, nil
+}
+
+// CountDatabases returns how many databases the panel shows
+func (s *MySQLService) CountDatabases() (int, error) {
+	var total int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE " + userDatabasesFilter).Scan(&total)
+	return total, err
+}
+
+// GetUsers returns the MySQL accounts whose user name matches opts, one per
+// user and host. Grants are only read for the accounts on the page, SHOW
+// GRANTS runs once per account.
+func (s *MySQLService) GetUsers(opts MySQLListOptions) (*MySQLUserListResult, error) {
+	filter, args := opts.nameFilter("User")
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM mysql.user WHERE 1 = 1"+filter, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+	page := opts.pageOf(total)
+	result := &MySQLUserListResult{Data: []MySQLUser{}, Total: total, Page: page.page, Limit: page.limit, TotalPages: page.totalPages}
+
+	rows, err := s.db.Query("SELECT User, Host FROM mysql.user WHERE 1 = 1"+filter+" ORDER BY User, Host"+page.sql, append(args, page.args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []interface{}
+	seen := map[string]bool{}
+	for rows.Next() {
+		var user, host string
+		if err := rows.Scan(&user, &host); err != nil {
+			return nil, err
+		}
+		result.Data = append(result.Data, MySQLUser{
+			User:    user,
+			Host:    host,
+			AnyHost: AnyMySQLHost(host),
+			Remote:  !localMySQLHosts[host],
+		})
+		if !seen[user] {
+			seen[user] = true
+			names = append(names, user)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Every host of the users on the page, some may be on other pages
+	hosts := map[string][]string{}
+	if len(names) > 0 {
+		hostRows, err := s.db.Query("SELECT User, Host FROM mysql.user WHERE User IN ("+placeholders(len(names))+") ORDER BY User, Host", names...)
+		if err != nil {
+			return nil, err
+		}
+		defer hostRows.Close()
+		for hostRows.Next() {
+			var user, host string
+			if err := hostRows.Scan(&user, &host); err != nil {
+				return nil, err
+			}
+			hosts[user] = append(hosts[user], host)
+		}
+		if err := hostRows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range result.Data {
+		result.Data[i].Hosts = hosts[result.Data[i].User]
+		privs, _ := s.getUserPrivileges(result.Data[i].User, result.Data[i].Host)
+		if privs == nil {
+			privs = []string{}
+		}
+		result.Data[i].Privileges = privs
+	}
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrProcessNotFound = errors.New("MySQL connection not found")
+	ErrOwnConnection   = errors.New("refusing to kill the panel's own MySQL connection")
+	ErrSystemProcess   = errors.New("refusing to kill a MySQL server thread")
+)
+
+// mysqlUnknownThread is the error MySQL returns for KILL of a thread that is gone
+const mysqlUnknownThread = 1094
+
+// systemProcessUsers are the users of threads MySQL runs itself
+var systemProcessUsers = map[string]bool{"system user": true, "event_scheduler": true}
+
+// MySQLProcess is a connection in the server's process list
+type MySQLProcess struct {
+	ID       uint64 `json:"id"`
+	User     string `json:"user"`
+	Host     string `json:"host"`
+	Database string `json:"database"`
+	Command  string `json:"command"`
+	Time     int64  `json:"time"` // seconds in the current state
+	State    string `json:"state"`
+	Info     string `json:"info"` // the statement being run
+	Own      bool   `json:"own"`  // the connection that read the list
+}
+
+// GetProcessList returns the connections to the server, as SHOW FULL
+// PROCESSLIST shows them
+func (s *MySQLService) GetProcessList(ctx context.Context) ([]MySQLProcess, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	own, err := connectionID(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.QueryContext(ctx, "SHOW FULL PROCESSLIST")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanProcessList(rows, own)
+}
+
+// scanProcessList reads process list rows by column name, MariaDB adds columns
+// MySQL does not have
+func scanProcessList(rows *sql.Rows, own uint64) ([]MySQLProcess, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	processes := []MySQLProcess{}
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		targets := make([]interface{}, len(columns))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return nil, err
+		}
+
+		var process MySQLProcess
+		for i, column := range columns {
+			value := values[i].String
+			switch strings.ToLower(column) {
+			case "id":
+				process.ID, _ = strconv.ParseUint(value, 10, 64)
+			case "user":
+				process.User = value
+			case "host":
+				process.Host = value
+			case "db":
+				process.Database = value
+			case "command":
+				process.Command = value
+			case "time":
+				process.Time, _ = strconv.ParseInt(value, 10, 64)
+			case "state":
+				process.State = value
+			case "info":
+				process.Info = value
+			}
+		}
+		process.Own = process.ID == own
+		processes = append(processes, process)
+	}
+	return processes, rows.Err()
+}
+
+// KillProcess ends a connection with KILL. The connection running the KILL
+// and the server's own threads are refused.
+func (s *MySQLService) KillProcess(ctx context.Context, id uint64) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	own, err := connectionID(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if id == own {
+		return ErrOwnConnection
+	}
+
+	var user string
+	err = conn.QueryRowContext(ctx, "SELECT USER FROM information_schema.PROCESSLIST WHERE ID = ?", id).Scan(&user)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: #%d", ErrProcessNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+	if systemProcessUsers[user] {
+		return fmt.Errorf("%w: #%d belongs to %s", ErrSystemProcess, id, user)
+	}
+
+	if _, err := conn.ExecContext(ctx, "KILL "+strconv.FormatUint(id, 10)); err != nil {
+		var mysqlErr *mysqldriver.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlUnknownThread {
+			return fmt.Errorf("%w: #%d", ErrProcessNotFound, id)
+		}
+		return err
+	}
+	return nil
+}
+
+// connectionID returns the server's ID of conn
+func connectionID(ctx context.Context, conn *sql.Conn) (uint64, error) {
+	var id uint64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to get connection ID: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanProcessList(t *testing.T) {
+	// Any database/sql driver will do for the scanning, sqlite is at hand
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "processlist.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	rows, err := db.Query(`SELECT 7 AS Id, 'shop' AS User, 'localhost:40312' AS Host, 'shop' AS db, 'Query' AS Command,
+			312 AS Time, 'Waiting for table metadata lock' AS State, 'ALTER TABLE orders ADD note TEXT' AS Info, 0.0 AS Progress
+		UNION ALL SELECT 9, 'panel', 'localhost', NULL, 'Query', 0, 'starting', 'SHOW FULL PROCESSLIST', 0.0`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	processes, err := scanProcessList(rows, 9)
+	require.NoError(t, err)
+	require.Len(t, processes, 2)
+	assert.Equal(t, MySQLProcess{
+		ID:       7,
+		User:     "shop",
+		Host:     "localhost:40312",
+		Database: "shop",
+		Command:  "Query",
+		Time:     312,
+		State:    "Waiting for table metadata lock",
+		Info:     "ALTER TABLE orders ADD note TEXT",
+	}, processes[0])
+	assert.Equal(t, "", processes[1].Database, "NULL db reads as empty")
+	assert.True(t, processes[1].Own)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"r-panel/internal/config"
+)
+
+var ErrUnknownMySQLServer = errors.New("unknown MySQL server")
+
+// MySQLServer describes a MySQL server the panel manages
+type MySQLServer struct {
+	Name    string `json:"name"`
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	Primary bool   `json:"primary"`
+}
+
+// MySQLServers holds a MySQLService for the primary server and each server in
+// database.mysql_servers, looked up by name
+type MySQLServers struct {
+	services    map[string]*MySQLService
+	servers     []MySQLServer
+	clientHosts map[string]string
+}
+
+// NewMySQLServers connects to every configured MySQL server. Servers that are
+// down are retried lazily like a single MySQLService.
+func NewMySQLServers(cfg *config.Config) (*MySQLServers, error) {
+	m := &MySQLServers{
+		services:    map[string]*MySQLService{},
+		clientHosts: map[string]string{},
+	}
+
+	primary := config.MySQLServerConfig{Name: config.PrimaryMySQLServer, MySQLConfig: cfg.Database.MySQL}
+	all := append([]config.MySQLServerConfig{primary}, cfg.Database.MySQLServers...)
+
+	for _, server := range all {
+		service, err := NewMySQLService(server.DSN())
+		if err != nil {
+			return nil, fmt.Errorf("MySQL server %s: %w", server.Name, err)
+		}
+		if server.Name != config.PrimaryMySQLServer {
+			service.cliArgs, service.cliEnv = mysqlCLIOptions(server.MySQLConfig)
+		}
+
+		clientHost := server.ClientHost
+		if clientHost == "" {
+			clientHost = clientDatabaseHost
+		}
+		m.add(MySQLServer{
+			Name:    server.Name,
+			Host:    server.Host,
+			Port:    server.Port,
+			Primary: server.Name == config.PrimaryMySQLServer,
+		}, service, clientHost)
+	}
+
+	return m, nil
+}
+
+func (m *MySQLServers) add(server MySQLServer, service *MySQLService, clientHost string) {
+	m.services[server.Name] = service
+	m.servers = append(m.servers, server)
+	m.clientHosts[server.Name] = clientHost
+}
+
+// Get returns the service for the named server, the primary when name is empty
+func (m *MySQLServers) Get(name string) (*MySQLService, error) {
+	if name == "" {
+		name = config.PrimaryMySQLServer
+	}
+	service, ok := m.services[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMySQLServer, name)
+	}
+	return service, nil
+}
+
+// MySQLServerStatus is a configured server and whether it answers
+type MySQLServerStatus struct {
+	MySQLServer
+	Available bool   `json:"available"`
+	Error     string `json:"error,omitempty"` // why the server is unavailable
+}
+
+// Statuses pings every server concurrently and reports each one, primary first.
+// A server that is down does not keep the others from being listed.
+func (m *MySQLServers) Statuses() []MySQLServerStatus {
+	statuses := make([]MySQLServerStatus, len(m.servers))
+	var wg sync.WaitGroup
+	for i, server := range m.servers {
+		statuses[i].MySQLServer = server
+		wg.Add(1)
+		go func(status *MySQLServerStatus) {
+			defer wg.Done()
+			if err := m.services[status.Name].EnsureConnected(); err != nil {
+				status.Error = err.Error()
+				return
+			}
+			status.Available = true
+		}(&statuses[i])
+	}
+	wg.Wait()
+	return statuses
+}
+
+// clientHost returns the host client database users on the named server are granted access from
+func (m *MySQLServers) clientHost(name string) string {
+	if host, ok := m.clientHosts[name]; ok {
+		return host
+	}
+	return clientDatabaseHost
+}
+
+// mysqlCLIOptions returns the mysqldump/mysql options and environment that connect
+// to server. The password goes through MYSQL_PWD to keep it out of the process list.
+func mysqlCLIOptions(server config.MySQLConfig) ([]string, []string) {
+	args := []string{"--host=" + server.Host, "--user=" + server.Username}
+	if server.Port != 0 {
+		args = append(args, "--port="+strconv.Itoa(server.Port))
+	}
+	var env []string
+	if server.Password != "" {
+		env = append(env, "MYSQL_PWD="+server.Password)
+	}
+	return args, env
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMySQLServers() *MySQLServers {
+	m := &MySQLServers{services: map[string]*MySQLService{}, clientHosts: map[string]string{}}
+	m.add(MySQLServer{Name: config.PrimaryMySQLServer, Primary: true}, &MySQLService{}, clientDatabaseHost)
+	m.add(MySQLServer{Name: "db2", Host: "10.0.0.2"}, &MySQLService{}, "10.0.0.%")
+	return m
+}
+
+func TestMySQLServersGet(t *testing.T) {
+	servers := testMySQLServers()
+
+	primary, err := servers.Get("")
+	require.NoError(t, err)
+	named, err := servers.Get(config.PrimaryMySQLServer)
+	require.NoError(t, err)
+	assert.Same(t, primary, named, "an empty name selects the primary")
+
+	db2, err := servers.Get("db2")
+	require.NoError(t, err)
+	assert.NotSame(t, primary, db2)
+
+	_, err = servers.Get("db3")
+	assert.ErrorIs(t, err, ErrUnknownMySQLServer)
+
+	assert.Equal(t, "10.0.0.%", servers.clientHost("db2"))
+	assert.Equal(t, clientDatabaseHost, servers.clientHost(config.PrimaryMySQLServer))
+}
+
+func TestMySQLServiceCLIConnectsToItsServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mysqldump")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\necho \"$@\"\necho \"$MYSQL_PWD\"\n"), 0755))
+
+	service := &MySQLService{mysqldump: path}
+	service.cliArgs, service.cliEnv = mysqlCLIOptions(config.MySQLConfig{
+		Host: "10.0.0.2", Port: 3307, Username: "panel", Password: "s3cret",
+	})
+
+	output, err := service.cliCommand(t.Context(), service.mysqldump, "blog").Output()
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	assert.Equal(t, []string{"--host=10.0.0.2 --user=panel --port=3307 blog", "s3cret"}, lines,
+		"the password must travel in the environment, not the arguments")
+}
+
+func TestCreateClientDatabaseRestrictsServers(t *testing.T) {
+	cfg := setupTestDB(t)
+	t.Setenv("SKIP_LINUX_USER", "true")
+	client, err := NewClientService(cfg).CreateClient(&CreateClientData{
+		Username:    "dbclient",
+		Password:    "testpass123",
+		ContactName: "DB Client",
+		Email:       "dbclient@example.com",
+		DBServers:   models.StringArray{"db2"},
+	})
+	require.NoError(t, err)
+
+	service := NewClientDatabaseService(testMySQLServers())
+
+	_, err = service.CreateClientDatabase(client.ID, "shop", config.PrimaryMySQLServer)
+	assert.ErrorIs(t, err, ErrDatabaseServerNotAllowed)
+	_, err = service.CreateClientDatabase(client.ID, "shop", "db3")
+	assert.ErrorIs(t, err, ErrDatabaseServerNotAllowed)
+
+	var count int64
+	require.NoError(t, models.DB.Model(&models.ClientDatabase{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestMySQLServersStatuses(t *testing.T) {
+	// Nothing listens on port 1, both servers are down
+	down := func() *MySQLService {
+		db, err := sql.Open("mysql", "panel@tcp(127.0.0.1:1)/")
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return &MySQLService{db: db}
+	}
+	m := &MySQLServers{services: map[string]*MySQLService{}, clientHosts: map[string]string{}}
+	m.add(MySQLServer{Name: config.PrimaryMySQLServer, Primary: true}, down(), clientDatabaseHost)
+	m.add(MySQLServer{Name: "db2", Host: "10.0.0.2"}, down(), "10.0.0.%")
+
+	statuses := m.Statuses()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, config.PrimaryMySQLServer, statuses[0].Name)
+	assert.Equal(t, "db2", statuses[1].Name)
+	for _, status := range statuses {
+		assert.False(t, status.Available)
+		assert.Contains(t, status.Error, ErrMySQLUnavailable.Error())
+	}
+}
+
+func TestClientBackupDumpsOnTheDatabaseServer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mysqldump")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\necho \"-- $@\"\n"), 0755))
+	db2 := &MySQLService{mysqldump: path}
+	db2.cliArgs, db2.cliEnv = mysqlCLIOptions(config.MySQLConfig{Host: "10.0.0.2", Username: "panel"})
+	m := &MySQLServers{services: map[string]*MySQLService{}, clientHosts: map[string]string{}}
+	m.add(MySQLServer{Name: "db2", Host: "10.0.0.2"}, db2, "10.0.0.%")
+
+	backups := NewBackupService(dir, 0)
+	backups.SetMySQLServers(m)
+	archive, err := backups.CreateClientBackup(&ClientBackupManifest{
+		Client:    models.Client{CustomerNo: "C1"},
+		Databases: []models.ClientDatabase{{Server: "db2", Name: "c1_shop"}},
+	}, "")
+	require.NoError(t, err)
+
+	file, err := os.Open(archive)
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		require.NoError(t, err, "dump not found")
+		if header.Name == clientBackupDBDir+"/c1_shop.sql" {
+			dump, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			assert.Equal(t, "-- --host=10.0.0.2 --user=panel --single-transaction --routines --triggers c1_shop\n", string(dump))
+			break
+		}
+	}
+
+	// Without the servers there is nothing to dump from
+	_, err = NewBackupService(dir, 0).CreateClientBackup(&ClientBackupManifest{
+		Client:    models.Client{CustomerNo: "C2"},
+		Databases: []models.ClientDatabase{{Name: "c2_shop"}},
+	}, "")
+	assert.ErrorIs(t, err, ErrMySQLNotConfigured)
+}
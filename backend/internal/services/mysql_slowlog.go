@@ -0,0 +1,219 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSlowLogDisabled = errors.New("the MySQL slow query log is disabled")
+	ErrSlowLogNotFound = errors.New("the MySQL slow query log file was not found on this host")
+)
+
+// Bounds of a slow log read
+const (
+	DefaultSlowLogEntries = 50
+	MaxSlowLogEntries     = 500
+	// maxSlowLogRead caps how much of the end of the log is read, the file
+	// grows without bound when nothing rotates it
+	maxSlowLogRead = 4 << 20
+)
+
+// SlowQuery is an entry of the MySQL slow query log
+type SlowQuery struct {
+	Timestamp    *time.Time `json:"timestamp"`
+	User         string     `json:"user"`
+	Host         string     `json:"host"`
+	Database     string     `json:"database,omitempty"`
+	QueryTime    float64    `json:"query_time"` // seconds
+	LockTime     float64    `json:"lock_time"`  // seconds
+	RowsSent     int64      `json:"rows_sent"`
+	RowsExamined int64      `json:"rows_examined"`
+	Query        string     `json:"query"`
+}
+
+// SlowLog is the tail of the slow query log
+type SlowLog struct {
+	File    string      `json:"file"`
+	Entries []SlowQuery `json:"entries"`
+}
+
+var (
+	// slowLogUserHostPattern matches "# User@Host: shop[shop] @ localhost [127.0.0.1]"
+	slowLogUserHostPattern = regexp.MustCompile(`^# User@Host:\s*(\S*?)\[[^\]]*\]\s*@\s*(\S*)\s*\[([^\]]*)\]`)
+	// slowLogFieldPattern matches the "Name: value" pairs of the statistics lines
+	slowLogFieldPattern = regexp.MustCompile(`(\w+):\s*(\S+)`)
+	// slowLogTimestampPattern matches the SET timestamp statement of an entry
+	slowLogTimestampPattern = regexp.MustCompile(`^SET timestamp=(\d+);$`)
+	// slowLogServerHeader matches the lines mysqld writes when it (re)opens the log
+	slowLogServerHeader = regexp.MustCompile(`^(\S+, Version: .*started with:|Tcp port: .*|Time\s+Id\s+Command\s+Argument)$`)
+)
+
+// SlowLogFile returns the path of the server's slow query log, or
+// ErrSlowLogDisabled when the server does not write one. A relative path is
+// resolved against the server's data directory, as mysqld does.
+func (s *MySQLService) SlowLogFile(ctx context.Context) (string, error) {
+	var enabled bool
+	var file, dataDir string
+	err := s.db.QueryRowContext(ctx, "SELECT @@slow_query_log, @@slow_query_log_file, @@datadir").Scan(&enabled, &file, &dataDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read slow log settings: %w", err)
+	}
+	if !enabled {
+		return "", ErrSlowLogDisabled
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dataDir, file)
+	}
+	return file, nil
+}
+
+// GetSlowLog returns the last entries of the slow query log, oldest first. The
+// log is read from the local filesystem, so it is only found when MySQL runs
+// on the panel's host.
+func (s *MySQLService) GetSlowLog(ctx context.Context, entries int) (*SlowLog, error) {
+	file, err := s.SlowLogFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	queries, err := readSlowLog(file, entries)
+	if err != nil {
+		return nil, err
+	}
+	return &SlowLog{File: file, Entries: queries}, nil
+}
+
+// readSlowLog parses the last entries of the slow log at path, reading at most
+// maxSlowLogRead bytes from its end
+func readSlowLog(path string, entries int) ([]SlowQuery, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrSlowLogNotFound, path)
+		}
+		return nil, fmt.Errorf("failed to open slow log: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read slow log: %w", err)
+	}
+	offset := info.Size() - maxSlowLogRead
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read slow log: %w", err)
+	}
+
+	queries, err := parseSlowLog(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read slow log: %w", err)
+	}
+	// Reading from the middle of the file most likely cut the first entry
+	if offset > 0 && len(queries) > 0 {
+		queries = queries[1:]
+	}
+	if len(queries) > entries {
+		queries = queries[len(queries)-entries:]
+	}
+	return queries, nil
+}
+
+// parseSlowLog parses the entries of a slow query log as MySQL and MariaDB
+// write them. Lines before the first entry header are skipped.
+func parseSlowLog(r io.Reader) ([]SlowQuery, error) {
+	queries := []SlowQuery{}
+	var current *SlowQuery
+	var logTime *time.Time // of the last # Time line, MariaDB omits it for entries logged in the same second
+	var query []string
+
+	flush := func() {
+		if current != nil && len(query) > 0 {
+			current.Query = strings.Join(query, "\n")
+			if current.Timestamp == nil {
+				current.Timestamp = logTime
+			}
+			queries = append(queries, *current)
+		}
+		current = nil
+		query = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxSlowLogRead)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case strings.HasPrefix(line, "# Time:"):
+			flush()
+			logTime = parseSlowLogTime(strings.TrimSpace(strings.TrimPrefix(line, "# Time:")))
+		case strings.HasPrefix(line, "# User@Host:"):
+			flush()
+			current = &SlowQuery{}
+			if match := slowLogUserHostPattern.FindStringSubmatch(line); match != nil {
+				current.User = match[1]
+				current.Host = match[2]
+				if current.Host == "" {
+					current.Host = match[3]
+				}
+			}
+		case current == nil, slowLogServerHeader.MatchString(line):
+			// Before the first entry, or mysqld reopening the log
+		case strings.HasPrefix(line, "# administrator command:"):
+			query = append(query, strings.TrimPrefix(line, "# "))
+		case strings.HasPrefix(line, "#"):
+			for _, field := range slowLogFieldPattern.FindAllStringSubmatch(line, -1) {
+				current.setField(field[1], field[2])
+			}
+		case strings.HasPrefix(line, "use ") && len(query) == 0:
+			current.Database = strings.Trim(strings.TrimSuffix(strings.TrimPrefix(line, "use "), ";"), "`")
+		case slowLogTimestampPattern.MatchString(line) && len(query) == 0:
+			seconds, _ := strconv.ParseInt(slowLogTimestampPattern.FindStringSubmatch(line)[1], 10, 64)
+			timestamp := time.Unix(seconds, 0).UTC()
+			current.Timestamp = &timestamp
+		default:
+			query = append(query, line)
+		}
+	}
+	flush()
+	return queries, scanner.Err()
+}
+
+// setField stores a value of the statistics lines of an entry
+func (q *SlowQuery) setField(name, value string) {
+	switch name {
+	case "Query_time":
+		q.QueryTime, _ = strconv.ParseFloat(value, 64)
+	case "Lock_time":
+		q.LockTime, _ = strconv.ParseFloat(value, 64)
+	case "Rows_sent":
+		q.RowsSent, _ = strconv.ParseInt(value, 10, 64)
+	case "Rows_examined":
+		q.RowsExamined, _ = strconv.ParseInt(value, 10, 64)
+	case "Schema":
+		q.Database = value
+	}
+}
+
+// parseSlowLogTime parses the # Time line of MySQL (RFC 3339) or MariaDB
+// (yymmdd hh:mm:ss, the hour space padded), nil when it is neither
+func parseSlowLogTime(value string) *time.Time {
+	value = strings.Join(strings.Fields(value), " ")
+	for _, layout := range []string{time.RFC3339Nano, "060102 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
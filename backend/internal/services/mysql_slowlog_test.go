@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mysqlSlowLog = `/usr/sbin/mysqld, Version: 8.0.36 (MySQL Community Server - GPL). started with:
+Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock
+Time                 Id Command    Argument
+# Time: 2024-01-15T10:23:45.123456Z
+# User@Host: shop[shop] @ localhost []  Id:    42
+# Query_time: 2.500123  Lock_time: 0.000120 Rows_sent: 1  Rows_examined: 500000
+use shop;
+SET timestamp=1705314225;
+SELECT *
+FROM orders WHERE note LIKE '%gift%';
+# Time: 2024-01-15T10:24:00.000000Z
+# User@Host: root[root] @  [10.0.0.5]  Id:    43
+# Query_time: 1.000000  Lock_time: 0.500000 Rows_sent: 0  Rows_examined: 0
+SET timestamp=1705314240;
+# administrator command: Quit;
+`
+
+const mariaDBSlowLog = `# Time: 240115  9:23:45
+# User@Host: blog[blog] @ localhost []
+# Thread_id: 8  Schema: blog  QC_hit: No
+# Query_time: 3.000000  Lock_time: 0.000050  Rows_sent: 10  Rows_examined: 20000
+# Rows_affected: 0  Bytes_sent: 512
+SELECT id FROM posts ORDER BY RAND() LIMIT 10;
+# User@Host: blog[blog] @ localhost []
+# Thread_id: 8  Schema: blog  QC_hit: No
+# Query_time: 2.000000  Lock_time: 0.000000  Rows_sent: 0  Rows_examined: 100
+# Rows_affected: 100  Bytes_sent: 52
+UPDATE posts SET views = views + 1;
+`
+
+func TestParseSlowLog(t *testing.T) {
+	queries, err := parseSlowLog(strings.NewReader(mysqlSlowLog))
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+
+	timestamp := time.Unix(1705314225, 0).UTC()
+	assert.Equal(t, SlowQuery{
+		Timestamp:    &timestamp,
+		User:         "shop",
+		Host:         "localhost",
+		Database:     "shop",
+		QueryTime:    2.500123,
+		LockTime:     0.00012,
+		RowsSent:     1,
+		RowsExamined: 500000,
+		Query:        "SELECT *\nFROM orders WHERE note LIKE '%gift%';",
+	}, queries[0])
+	assert.Equal(t, "root", queries[1].User)
+	assert.Equal(t, "10.0.0.5", queries[1].Host, "the IP stands in for a missing host name")
+	assert.Equal(t, 0.5, queries[1].LockTime)
+	assert.Equal(t, "administrator command: Quit;", queries[1].Query)
+
+	queries, err = parseSlowLog(strings.NewReader(mariaDBSlowLog))
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+	logTime := time.Date(2024, 1, 15, 9, 23, 45, 0, time.UTC)
+	for _, query := range queries {
+		require.NotNil(t, query.Timestamp)
+		assert.Equal(t, logTime, *query.Timestamp, "entries in the same second share the # Time line")
+		assert.Equal(t, "blog", query.Database)
+	}
+	assert.Equal(t, 3.0, queries[0].QueryTime)
+	assert.Equal(t, int64(10), queries[0].RowsSent)
+	assert.Equal(t, "UPDATE posts SET views = views + 1;", queries[1].Query)
+}
+
+func TestReadSlowLogKeepsLastEntries(t *testing.T) {
+	var log strings.Builder
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(&log, "# User@Host: shop[shop] @ localhost []\n# Query_time: %d.0  Lock_time: 0.0 Rows_sent: 0  Rows_examined: 0\nSELECT %d;\n", i, i)
+	}
+	path := filepath.Join(t.TempDir(), "slow.log")
+	require.NoError(t, os.WriteFile(path, []byte(log.String()), 0644))
+
+	queries, err := readSlowLog(path, 2)
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+	assert.Equal(t, "SELECT 4;", queries[0].Query)
+	assert.Equal(t, "SELECT 5;", queries[1].Query)
+
+	_, err = readSlowLog(filepath.Join(t.TempDir(), "missing.log"), 2)
+	assert.ErrorIs(t, err, ErrSlowLogNotFound)
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"r-panel/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMysqldump writes a shell script standing in for mysqldump
+func fakeMysqldump(t *testing.T, script string) *MySQLService {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mysqldump")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return &MySQLService{mysqldump: path}
+}
+
+// fakeMySQLServers configures primary as the only MySQL server
+func fakeMySQLServers(primary *MySQLService) *MySQLServers {
+	return &MySQLServers{
+		services:    map[string]*MySQLService{config.PrimaryMySQLServer: primary},
+		clientHosts: map[string]string{},
+	}
+}
+
+func TestMySQLServiceDumpDatabaseStreamsGzip(t *testing.T) {
+	service := fakeMysqldump(t, `echo "-- dump of $4"; echo "CREATE TABLE posts (id int);"`)
+
+	var out bytes.Buffer
+	require.NoError(t, service.dumpDatabase(context.Background(), "blog", &out))
+
+	gz, err := gzip.NewReader(&out)
+	require.NoError(t, err)
+	dump, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "-- dump of blog\nCREATE TABLE posts (id int);\n", string(dump))
+}
+
+func TestMySQLServiceDumpDatabaseFailures(t *testing.T) {
+	t.Run("failure before output writes nothing", func(t *testing.T) {
+		service := fakeMysqldump(t, `echo "mysqldump: Got error: 1044: Access denied" >&2; exit 2`)
+
+		var out bytes.Buffer
+		err := service.dumpDatabase(context.Background(), "blog", &out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Access denied")
+		assert.Zero(t, out.Len(), "nothing may be written so the caller can still send an error response")
+	})
+
+	t.Run("failure mid-stream leaves the archive truncated", func(t *testing.T) {
+		service := fakeMysqldump(t, `echo "CREATE TABLE posts (id int);"; echo "Lost connection" >&2; exit 3`)
+
+		var out bytes.Buffer
+		err := service.dumpDatabase(context.Background(), "blog", &out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Lost connection")
+
+		gz, err := gzip.NewReader(&out)
+		if err == nil {
+			_, err = io.ReadAll(gz)
+		}
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run("unsafe names never reach mysqldump", func(t *testing.T) {
+		service := fakeMysqldump(t, `echo "should not run"`)
+		for _, name := range []string{"", "--all-databases", "-r/tmp/x", "a b", "db;drop", "../db"} {
+			var out bytes.Buffer
+			assert.ErrorIs(t, service.StreamDatabaseExport(context.Background(), name, &out), ErrUnsafeDatabaseName, name)
+			assert.Zero(t, out.Len())
+		}
+	})
+}
+
+// fakeMysqlClient writes a mysql client stand-in that saves what it reads on stdin to out
+func fakeMysqlClient(t *testing.T, script string) (service *MySQLService, out string) {
+	t.Helper()
+	dir := t.TempDir()
+	out = filepath.Join(dir, "stdin.sql")
+	path := filepath.Join(dir, "mysql")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\ncat > "+out+"\n"+script), 0755))
+	return &MySQLService{mysql: path}, out
+}
+
+const routineDump = `CREATE TABLE notes (body text);
+INSERT INTO notes VALUES ('a; b'), ('c;');
+DELIMITER ;;
+CREATE PROCEDURE touch() BEGIN UPDATE notes SET body = 'x;y'; END ;;
+DELIMITER ;
+`
+
+func TestMySQLServiceImportDatabase(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "dump.sql")
+	require.NoError(t, os.WriteFile(plain, []byte(routineDump), 0644))
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write([]byte(routineDump))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	gzipped := filepath.Join(dir, "dump.sql.gz")
+	require.NoError(t, os.WriteFile(gzipped, compressed.Bytes(), 0644))
+	misnamed := filepath.Join(dir, "dump.sql.upload")
+	require.NoError(t, os.WriteFile(misnamed, compressed.Bytes(), 0644))
+
+	for _, path := range []string{plain, gzipped, misnamed} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			service, stdin := fakeMysqlClient(t, "")
+			require.NoError(t, service.ImportDatabase(context.Background(), "blog", path))
+
+			// The dump reaches the client untouched, semicolons and delimiters included
+			received, err := os.ReadFile(stdin)
+			require.NoError(t, err)
+			assert.Equal(t, routineDump, string(received))
+		})
+	}
+
+	t.Run("sql errors carry the failing line", func(t *testing.T) {
+		service, _ := fakeMysqlClient(t, `echo "ERROR 1064 (42000) at line 2: You have an error in your SQL syntax" >&2; exit 1`)
+
+		err := service.ImportDatabase(context.Background(), "blog", gzipped)
+		var importErr *SQLImportError
+		require.ErrorAs(t, err, &importErr)
+		assert.Equal(t, 2, importErr.Line)
+		assert.Equal(t, "INSERT INTO notes VALUES ('a; b'), ('c;');", importErr.Statement)
+		assert.Contains(t, err.Error(), "line 2: ERROR 1064")
+	})
+
+	t.Run("gz files must be gzipped", func(t *testing.T) {
+		service, _ := fakeMysqlClient(t, "")
+		fake := filepath.Join(dir, "plain.sql.gz")
+		require.NoError(t, os.WriteFile(fake, []byte(routineDump), 0644))
+
+		err := service.ImportDatabase(context.Background(), "blog", fake)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not gzip compressed")
+	})
+
+	t.Run("unsafe names never reach mysql", func(t *testing.T) {
+		service, stdin := fakeMysqlClient(t, "")
+		assert.ErrorIs(t, service.ImportDatabase(context.Background(), "--execute=DROP DATABASE x", plain), ErrUnsafeDatabaseName)
+		assert.NoFileExists(t, stdin)
+	})
+}
+
+func TestRestoreCopyName(t *testing.T) {
+	at := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+	assert.Equal(t, "shop_restore_20240305143000", RestoreCopyName("shop", at))
+
+	long := RestoreCopyName(strings.Repeat("a", 64), at)
+	assert.Len(t, long, 64)
+	assert.True(t, strings.HasSuffix(long, "_restore_20240305143000"))
+	assert.Regexp(t, cliDatabaseNamePattern, long)
+
+	service, stdin := fakeMysqlClient(t, "")
+	_, err := service.ImportDatabaseCopy(context.Background(), "--execute=DROP DATABASE x", "dump.sql")
+	assert.ErrorIs(t, err, ErrUnsafeDatabaseName)
+	assert.NoFileExists(t, stdin)
+}
+
+func TestMySQLServiceMaintenanceRefusesSystemDatabases(t *testing.T) {
+	service := &MySQLService{}
+	for _, name := range []string{"mysql", "information_schema", "Performance_Schema", "sys"} {
+		_, err := service.OptimizeDatabase(name)
+		assert.ErrorIs(t, err, ErrSystemDatabase, name)
+		_, err = service.RepairDatabase(name)
+		assert.ErrorIs(t, err, ErrSystemDatabase, name)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, "`wp_posts`", quoteIdentifier("wp_posts"))
+	assert.Equal(t, "`odd``name`", quoteIdentifier("odd`name"))
+}
+
+func TestQuoteAccount(t *testing.T) {
+	assert.Equal(t, "'app'@'localhost'", quoteAccount("app", "localhost"))
+	assert.Equal(t, `'o''brien'@'\\'`, quoteAccount("o'brien", `\`))
+}
+
+func TestValidateMySQLAccount(t *testing.T) {
+	for _, host := range []string{"localhost", "%", "10.0.0.%", "db.example.com", "::1", "10.0.0.0/255.255.255.0"} {
+		assert.NoError(t, ValidateMySQLAccount("app_user", host), host)
+	}
+	for _, host := range []string{"", "local host", "x'@'%", "a\\b"} {
+		assert.ErrorIs(t, ValidateMySQLAccount("app_user", host), ErrInvalidMySQLUser, host)
+	}
+	for _, username := range []string{"", "bad'user", "user name", "this_name_is_far_too_long_for_mysql_users"} {
+		assert.ErrorIs(t, ValidateMySQLAccount(username, "localhost"), ErrInvalidMySQLUser, username)
+	}
+}
+
+func TestAnyMySQLHost(t *testing.T) {
+	for _, host := range []string{"%", "%%", "%.%"} {
+		assert.True(t, AnyMySQLHost(host), host)
+	}
+	for _, host := range []string{"localhost", "10.0.0.%", "%.example.com", ""} {
+		assert.False(t, AnyMySQLHost(host), host)
+	}
+}
+
+func TestMySQLServiceGetUsersListsHosts(t *testing.T) {
+	// mysql.user is a table in an attached sqlite database here
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "main.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec("ATTACH DATABASE ? AS mysql", filepath.Join(t.TempDir(), "mysql.db"))
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE mysql.user (User TEXT, Host TEXT)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO mysql.user VALUES ('shop', '%'), ('blog', 'localhost'), ('shop', 'localhost'), ('backup', '10.0.0.%')")
+	require.NoError(t, err)
+
+	result, err := (&MySQLService{db: db}).GetUsers(MySQLListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Total)
+	users := result.Data
+	require.Len(t, users, 4)
+	assert.Equal(t, MySQLUser{User: "backup", Host: "10.0.0.%", Remote: true, Hosts: []string{"10.0.0.%"}, Privileges: []string{}}, users[0])
+	assert.Equal(t, MySQLUser{User: "blog", Host: "localhost", Hosts: []string{"localhost"}, Privileges: []string{}}, users[1])
+	assert.Equal(t, MySQLUser{User: "shop", Host: "%", AnyHost: true, Remote: true, Hosts: []string{"%", "localhost"}, Privileges: []string{}}, users[2])
+	assert.Equal(t, "localhost", users[3].Host)
+	assert.False(t, users[3].Remote)
+}
+
+func TestMySQLServiceGetUsersPagesAndFilters(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "main.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec("ATTACH DATABASE ? AS mysql", filepath.Join(t.TempDir(), "mysql.db"))
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE mysql.user (User TEXT, Host TEXT)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO mysql.user VALUES ('shop', '%'), ('blog', 'localhost'), ('shop', 'localhost'), ('backup', '10.0.0.%'), ('shop_ro', 'localhost')")
+	require.NoError(t, err)
+	service := &MySQLService{db: db}
+
+	result, err := service.GetUsers(MySQLListOptions{Page: 2, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Total)
+	assert.Equal(t, 3, result.TotalPages)
+	require.Len(t, result.Data, 2)
+	assert.Equal(t, "shop", result.Data[0].User)
+	assert.Equal(t, "%", result.Data[0].Host)
+	assert.Equal(t, []string{"%", "localhost"}, result.Data[0].Hosts, "hosts on other pages are listed too")
+
+	result, err = service.GetUsers(MySQLListOptions{Search: "SHOP"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Total)
+	assert.Len(t, result.Data, 3)
+
+	result, err = service.GetUsers(MySQLListOptions{Search: "p_"})
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Total, "the underscore is not a wildcard")
+	assert.Equal(t, "shop_ro", result.Data[0].User)
+
+	result, err = service.GetUsers(MySQLListOptions{Page: 9, Limit: 500})
+	require.NoError(t, err)
+	assert.Equal(t, 100, result.Limit)
+	assert.Empty(t, result.Data)
+}
+
+func TestMySQLServiceGetDatabasesPagesAndFilters(t *testing.T) {
+	// information_schema is an attached sqlite database here
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "main.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec("ATTACH DATABASE ? AS information_schema", filepath.Join(t.TempDir(), "information_schema.db"))
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		"CREATE TABLE information_schema.SCHEMATA (SCHEMA_NAME TEXT, DEFAULT_COLLATION_NAME TEXT)",
+		"CREATE TABLE information_schema.TABLES (TABLE_SCHEMA TEXT, TABLE_NAME TEXT, DATA_LENGTH INTEGER, INDEX_LENGTH INTEGER)",
+		"INSERT INTO information_schema.SCHEMATA VALUES ('mysql', 'utf8mb4_bin'), ('shop', 'utf8mb4_unicode_ci'), ('blog', 'utf8mb4_unicode_ci'), ('shop_test', 'utf8mb4_unicode_ci')",
+		"INSERT INTO information_schema.TABLES VALUES ('shop', 'orders', 1048576, 1048576), ('shop', 'items', 0, 0), ('blog', 'posts', 10, 0), ('mysql', 'user', 99, 0)",
+	} {
+		_, err = db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	service := &MySQLService{db: db}
+
+	result, err := service.GetDatabases(MySQLListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Total)
+	require.Len(t, result.Data, 3)
+	assert.Equal(t, "blog", result.Data[0].Name)
+	assert.Equal(t, Database{Name: "shop", Size: "2.00 MB", SizeBytes: 2 << 20, TableCount: 2, Collation: "utf8mb4_unicode_ci"}, result.Data[1])
+	assert.Equal(t, 0, result.Data[2].TableCount, "databases without tables are listed")
+
+	result, err = service.GetDatabases(MySQLListOptions{Search: "shop", Page: 2, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+	assert.Equal(t, 2, result.TotalPages)
+	require.Len(t, result.Data, 1)
+	assert.Equal(t, "shop_test", result.Data[0].Name)
+
+	count, err := service.CountDatabases()
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestMySQLServiceExecuteQueryPages(t *testing.T) {
+	// Any database/sql driver will do for the paging, sqlite is at hand
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "query.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec("CREATE TABLE posts (id INTEGER, title TEXT, author TEXT)")
+	require.NoError(t, err)
+	for i := 1; i <= 5; i++ {
+		_, err = db.Exec("INSERT INTO posts VALUES (?, ?, ?)", i, "post", "ann")
+		require.NoError(t, err)
+	}
+	service := &MySQLService{db: db}
+
+	result, err := service.ExecuteQuery(context.Background(), "SELECT title, id, author FROM posts ORDER BY id", QueryOptions{ReadOnly: true, Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"title", "id", "author"}, result.Columns, "select list order is kept")
+	require.Len(t, result.Rows, 2)
+	assert.EqualValues(t, 2, result.Rows[0]["id"])
+	assert.EqualValues(t, 3, result.Rows[1]["id"])
+	assert.True(t, result.Truncated)
+
+	result, err = service.ExecuteQuery(context.Background(), "SELECT id FROM posts ORDER BY id", QueryOptions{Limit: 2, Offset: 3})
+	require.NoError(t, err)
+	assert.Len(t, result.Rows, 2)
+	assert.False(t, result.Truncated, "the last page is complete")
+
+	result, err = service.ExecuteQuery(context.Background(), "SELECT id FROM posts", QueryOptions{Offset: 10})
+	require.NoError(t, err)
+	assert.Empty(t, result.Rows)
+	assert.Equal(t, DefaultQueryRows, result.Limit)
+
+	result, err = service.ExecuteQuery(context.Background(), "SELECT id FROM posts", QueryOptions{Limit: MaxQueryRows + 1})
+	require.NoError(t, err)
+	assert.Equal(t, MaxQueryRows, result.Limit, "the limit is capped")
+
+	_, err = service.ExecuteQuery(context.Background(), "DELETE FROM posts", QueryOptions{ReadOnly: true})
+	assert.Error(t, err)
+}
+
+func TestDecodeColumnValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		typeName string
+		want     interface{}
+	}{
+		{"null", nil, "VARCHAR", nil},
+		{"text", []byte("hello"), "VARCHAR", "hello"},
+		{"int", []byte("-42"), "INT", int64(-42)},
+		{"unsigned bigint", []byte("18446744073709551615"), "UNSIGNED BIGINT", uint64(18446744073709551615)},
+		{"double", []byte("1.5"), "DOUBLE", 1.5},
+		{"decimal stays exact", []byte("12345678901234567.89"), "DECIMAL", json.Number("12345678901234567.89")},
+		{"datetime", []byte("2024-03-01 10:20:30.5"), "DATETIME", time.Date(2024, 3, 1, 10, 20, 30, 500000000, time.UTC)},
+		{"date", []byte("2024-03-01"), "DATE", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"zero date", []byte("0000-00-00"), "DATE", "0000-00-00"},
+		{"time", []byte("12:34:56"), "TIME", "12:34:56"},
+		{"text blob", []byte("notes"), "BLOB", "notes"},
+		{"binary blob", []byte{0xff, 0x00, 0xfe}, "BLOB", []byte{0xff, 0x00, 0xfe}},
+		{"already typed", int64(7), "INT", int64(7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, decodeColumnValue(tt.value, tt.typeName))
+		})
+	}
+}
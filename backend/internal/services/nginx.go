@@ -1,31 +1,69 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+)
+
+var (
+	ErrInvalidBandwidthLimit = errors.New("invalid bandwidth limit")
+	ErrInvalidDomain         = errors.New("invalid domain: use letters, digits, hyphens, underscores and dots only")
+	ErrNginxConfigInvalid    = errors.New("nginx config test failed")
+	ErrSiteNotFound          = errors.New("site not found")
+	ErrSiteExists            = errors.New("site already exists")
 )
 
+// siteNamePattern matches dot-separated hostname labels, which is also what site files are named
+var siteNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9_-]*[A-Za-z0-9_])?(\.[A-Za-z0-9_]([A-Za-z0-9_-]*[A-Za-z0-9_])?)*$`)
+
+// maxDomainLength is the longest valid hostname
+const maxDomainLength = 253
+
+// nginxServiceName is the systemd unit of Nginx
+const nginxServiceName = "nginx"
+
 type NginxService struct {
 	sitesAvailablePath string
 	sitesEnabledPath   string
 	logsPath           string
+	homeBase           string // paths.home_base, where a client's home is when passwd does not know its user
+	fs                 FileSystem
+	runner             CommandRunner
 }
 
 type NginxSite struct {
-	Domain   string `json:"domain"`
-	Enabled  bool   `json:"enabled"`
-	Config   string `json:"config"`
-	FilePath string `json:"file_path"`
+	Domain     string `json:"domain"`
+	Enabled    bool   `json:"enabled"`
+	ForceHTTPS bool   `json:"force_https"`
+	Config     string `json:"config"`
+	FilePath   string `json:"file_path"`
+}
+
+func NewNginxService(cfg *config.Config) *NginxService {
+	s := NewNginxServiceWithDeps(osFileSystem{}, osCommandRunner{}, cfg.Paths.NginxSitesAvailable, cfg.Paths.NginxSitesEnabled, cfg.Paths.NginxLogs)
+	// Load has validated paths.home_base already
+	if homeBase, err := cfg.Paths.HomeBaseDir(); err == nil {
+		s.homeBase = homeBase
+	}
+	return s
 }
 
-func NewNginxService(sitesAvailable, sitesEnabled, logsPath string) *NginxService {
+// NewNginxServiceWithDeps creates an Nginx service that uses fsys and runner instead of the host
+func NewNginxServiceWithDeps(fsys FileSystem, runner CommandRunner, sitesAvailable, sitesEnabled, logsPath string) *NginxService {
 	return &NginxService{
 		sitesAvailablePath: sitesAvailable,
 		sitesEnabledPath:   sitesEnabled,
 		logsPath:           logsPath,
+		homeBase:           config.DefaultHomeBase,
+		fs:                 fsys,
+		runner:             runner,
 	}
 }
 
@@ -33,7 +71,7 @@ func NewNginxService(sitesAvailable, sitesEnabled, logsPath string) *NginxServic
 func (s *NginxService) GetSites() ([]NginxSite, error) {
 	var sites []NginxSite
 
-	files, err := os.ReadDir(s.sitesAvailablePath)
+	files, err := s.fs.ReadDir(s.sitesAvailablePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read sites-available: %w", err)
 	}
@@ -49,52 +87,153 @@ func (s *NginxService) GetSites() ([]NginxSite, error) {
 
 		// Check if site is enabled
 		enabled := false
-		if _, err := os.Stat(enabledPath); err == nil {
+		if _, err := s.fs.Stat(enabledPath); err == nil {
 			enabled = true
 		}
 
-		config, _ := s.GetSiteConfig(domain)
+		// Read directly, files created outside the panel may not be valid domains
+		config := ""
+		if data, err := s.fs.ReadFile(filePath); err == nil {
+			config = string(data)
+		}
 
 		sites = append(sites, NginxSite{
-			Domain:   domain,
-			Enabled:  enabled,
-			Config:   config,
-			FilePath: filePath,
+			Domain:     domain,
+			Enabled:    enabled,
+			ForceHTTPS: ForceHTTPSEnabled(config),
+			Config:     config,
+			FilePath:   filePath,
 		})
 	}
 
 	return sites, nil
 }
 
+// sitePaths validates domain and returns its files in sites-available and sites-enabled.
+// Every method taking a domain from a caller goes through here, so a domain can
+// never point outside the Nginx site directories.
+func (s *NginxService) sitePaths(domain string) (availablePath, enabledPath string, err error) {
+	if err := ValidateDomain(domain); err != nil {
+		return "", "", err
+	}
+	return filepath.Join(s.sitesAvailablePath, domain), filepath.Join(s.sitesEnabledPath, domain), nil
+}
+
+// ValidateDomain checks that domain is a hostname and safe to use as a file name
+func ValidateDomain(domain string) error {
+	if len(domain) == 0 || len(domain) > maxDomainLength || !siteNamePattern.MatchString(domain) {
+		return fmt.Errorf("%w: %q", ErrInvalidDomain, domain)
+	}
+	return nil
+}
+
 // GetSite returns a specific site
 func (s *NginxService) GetSite(domain string) (*NginxSite, error) {
-	filePath := filepath.Join(s.sitesAvailablePath, domain)
+	filePath, enabledPath, err := s.sitePaths(domain)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := os.Stat(filePath)
+	_, err = s.fs.Stat(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("site not found")
+		return nil, ErrSiteNotFound
 	}
 
-	enabledPath := filepath.Join(s.sitesEnabledPath, domain)
 	enabled := false
-	if _, err := os.Stat(enabledPath); err == nil {
+	if _, err := s.fs.Stat(enabledPath); err == nil {
 		enabled = true
 	}
 
 	config, _ := s.GetSiteConfig(domain)
 
 	return &NginxSite{
-		Domain:   domain,
-		Enabled:  enabled,
-		Config:   config,
-		FilePath: filePath,
+		Domain:     domain,
+		Enabled:    enabled,
+		ForceHTTPS: ForceHTTPSEnabled(config),
+		Config:     config,
+		FilePath:   filePath,
 	}, nil
 }
 
+// GetSitesByUser returns the sites whose config references the given linux user,
+// either through a root under the user's home or a "user" directive
+func (s *NginxService) GetSitesByUser(username string) ([]NginxSite, error) {
+	sites, err := s.GetSites()
+	if err != nil {
+		return nil, err
+	}
+
+	owned := []NginxSite{}
+	for _, site := range sites {
+		if siteReferencesUser(site.Config, username, s.homeBase) {
+			owned = append(owned, site)
+		}
+	}
+
+	return owned, nil
+}
+
+// SiteOwner returns the client, with its limits, whose linux user the site config
+// references, or nil when the site belongs to no client
+func (s *NginxService) SiteOwner(config string) (*models.Client, error) {
+	var clients []models.Client
+	if err := models.DB.Preload("ClientLimits").Where("linux_username <> ''").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	for i := range clients {
+		if siteReferencesUser(config, clients[i].LinuxUsername, s.homeBase) {
+			return &clients[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// siteReferencesUser scans root and user directives in a site config for the
+// given user. The home of a user passwd does not know is looked for in homeBase.
+func siteReferencesUser(siteConfig, username, homeBase string) bool {
+	if username == "" {
+		return false
+	}
+	homeDir, ok := linuxUserHome(username)
+	if !ok {
+		homeDir = filepath.Join(homeBase, username)
+	}
+
+	for _, line := range strings.Split(siteConfig, "\n") {
+		// Strip comments
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		value := strings.Trim(strings.TrimSuffix(fields[1], ";"), `"'`)
+
+		switch fields[0] {
+		case "root":
+			root := filepath.Clean(value)
+			if root == homeDir || strings.HasPrefix(root, homeDir+"/") {
+				return true
+			}
+		case "user":
+			if value == username {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // GetSiteConfig reads site configuration
 func (s *NginxService) GetSiteConfig(domain string) (string, error) {
-	filePath := filepath.Join(s.sitesAvailablePath, domain)
-	data, err := os.ReadFile(filePath)
+	filePath, _, err := s.sitePaths(domain)
+	if err != nil {
+		return "", err
+	}
+	data, err := s.fs.ReadFile(filePath)
 	if err != nil {
 		return "", err
 	}
@@ -103,57 +242,116 @@ func (s *NginxService) GetSiteConfig(domain string) (string, error) {
 
 // CreateSite creates a new Nginx site
 func (s *NginxService) CreateSite(domain, config string) error {
-	filePath := filepath.Join(s.sitesAvailablePath, domain)
+	filePath, _, err := s.sitePaths(domain)
+	if err != nil {
+		return err
+	}
 
 	// Check if site already exists
-	if _, err := os.Stat(filePath); err == nil {
-		return fmt.Errorf("site already exists")
+	if _, err := s.fs.Stat(filePath); err == nil {
+		return ErrSiteExists
 	}
 
 	// Write configuration file
-	if err := os.WriteFile(filePath, []byte(config), 0644); err != nil {
+	if err := s.fs.WriteFile(filePath, []byte(config), 0644); err != nil {
 		return fmt.Errorf("failed to write site config: %w", err)
 	}
 
 	return nil
 }
 
-// UpdateSite updates an existing site configuration
+// UpdateSite updates an existing site configuration, keeping the previous one
+// as a version that RestoreSiteVersion can bring back
 func (s *NginxService) UpdateSite(domain, config string) error {
-	filePath := filepath.Join(s.sitesAvailablePath, domain)
+	filePath, enabledPath, err := s.sitePaths(domain)
+	if err != nil {
+		return err
+	}
 
 	// Check if site exists
-	if _, err := os.Stat(filePath); err != nil {
-		return fmt.Errorf("site not found")
+	if _, err := s.fs.Stat(filePath); err != nil {
+		return ErrSiteNotFound
+	}
+
+	previous, err := s.fs.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read site config: %w", err)
+	}
+	if string(previous) != config {
+		if err := s.siteVersions(domain).save(previous); err != nil {
+			return err
+		}
 	}
 
 	// Write configuration file
-	if err := os.WriteFile(filePath, []byte(config), 0644); err != nil {
+	if err := s.fs.WriteFile(filePath, []byte(config), 0644); err != nil {
 		return fmt.Errorf("failed to write site config: %w", err)
 	}
+	if string(previous) != config && s.linked(enabledPath) {
+		markReloadPending(nginxServiceName)
+	}
 
 	return nil
 }
 
+// linked reports whether the sites-enabled link of a site exists, only those
+// sites are loaded by Nginx
+func (s *NginxService) linked(enabledPath string) bool {
+	_, err := s.fs.Stat(enabledPath)
+	return err == nil
+}
+
+// siteVersions holds the previous configs of a site, outside the site directories
+func (s *NginxService) siteVersions(domain string) configVersions {
+	return configVersions{fs: s.fs, dir: filepath.Join(filepath.Dir(s.sitesAvailablePath), configVersionsDir, domain)}
+}
+
+// GetSiteVersions returns the saved previous configs of a site, newest first
+func (s *NginxService) GetSiteVersions(domain string) ([]ConfigVersion, error) {
+	if _, err := s.GetSite(domain); err != nil {
+		return nil, err
+	}
+	return s.siteVersions(domain).list()
+}
+
+// RestoreSiteVersion puts a saved config of a site back and returns it. The
+// config it replaces is saved as a version in turn, so a restore can be undone.
+func (s *NginxService) RestoreSiteVersion(domain, version string) (string, error) {
+	if _, err := s.GetSite(domain); err != nil {
+		return "", err
+	}
+	config, err := s.siteVersions(domain).read(version)
+	if err != nil {
+		return "", err
+	}
+	if err := s.UpdateSite(domain, string(config)); err != nil {
+		return "", err
+	}
+	return string(config), nil
+}
+
 // DeleteSite deletes a site
 func (s *NginxService) DeleteSite(domain string) error {
-	availablePath := filepath.Join(s.sitesAvailablePath, domain)
-	enabledPath := filepath.Join(s.sitesEnabledPath, domain)
+	availablePath, enabledPath, err := s.sitePaths(domain)
+	if err != nil {
+		return err
+	}
 
 	// Check if site exists
-	if _, err := os.Stat(availablePath); err != nil {
-		return fmt.Errorf("site not found")
+	if _, err := s.fs.Stat(availablePath); err != nil {
+		return ErrSiteNotFound
 	}
 
 	// Remove from enabled if exists
-	if _, err := os.Stat(enabledPath); err == nil {
-		if err := os.Remove(enabledPath); err != nil {
+	if s.linked(enabledPath) {
+		if err := s.fs.Remove(enabledPath); err != nil {
 			return fmt.Errorf("failed to remove enabled link: %w", err)
 		}
+		markReloadPending(nginxServiceName)
 	}
 
 	// Remove from available
-	if err := os.Remove(availablePath); err != nil {
+	if err := s.fs.Remove(availablePath); err != nil {
 		return fmt.Errorf("failed to delete site: %w", err)
 	}
 
@@ -162,62 +360,107 @@ func (s *NginxService) DeleteSite(domain string) error {
 
 // EnableSite enables a site by creating symlink
 func (s *NginxService) EnableSite(domain string) error {
-	availablePath := filepath.Join(s.sitesAvailablePath, domain)
-	enabledPath := filepath.Join(s.sitesEnabledPath, domain)
+	availablePath, enabledPath, err := s.sitePaths(domain)
+	if err != nil {
+		return err
+	}
 
 	// Check if site exists
-	if _, err := os.Stat(availablePath); err != nil {
-		return fmt.Errorf("site not found")
+	if _, err := s.fs.Stat(availablePath); err != nil {
+		return ErrSiteNotFound
 	}
 
 	// Check if already enabled
-	if _, err := os.Stat(enabledPath); err == nil {
+	if s.linked(enabledPath) {
 		return nil // Already enabled
 	}
 
 	// Create symlink
-	if err := os.Symlink(availablePath, enabledPath); err != nil {
+	if err := s.fs.Symlink(availablePath, enabledPath); err != nil {
 		return fmt.Errorf("failed to enable site: %w", err)
 	}
+	markReloadPending(nginxServiceName)
 
 	return nil
 }
 
 // DisableSite disables a site by removing symlink
 func (s *NginxService) DisableSite(domain string) error {
-	enabledPath := filepath.Join(s.sitesEnabledPath, domain)
+	_, enabledPath, err := s.sitePaths(domain)
+	if err != nil {
+		return err
+	}
 
 	// Check if enabled
-	if _, err := os.Stat(enabledPath); err != nil {
+	if !s.linked(enabledPath) {
 		return nil // Already disabled
 	}
 
 	// Remove symlink
-	if err := os.Remove(enabledPath); err != nil {
+	if err := s.fs.Remove(enabledPath); err != nil {
 		return fmt.Errorf("failed to disable site: %w", err)
 	}
+	markReloadPending(nginxServiceName)
 
 	return nil
 }
 
 // TestConfig tests Nginx configuration
-func (s *NginxService) TestConfig() error {
-	cmd := exec.Command("nginx", "-t")
-	output, err := cmd.CombinedOutput()
+func (s *NginxService) TestConfig(ctx context.Context) error {
+	output, err := s.runner.CombinedOutput(ctx, "nginx", "-t")
 	if err != nil {
-		return fmt.Errorf("nginx config test failed: %s", string(output))
+		return fmt.Errorf("%w: %s", ErrNginxConfigInvalid, strings.TrimSpace(string(output)))
 	}
 	return nil
 }
 
-// Reload reloads Nginx service
-func (s *NginxService) Reload() error {
-	cmd := exec.Command("systemctl", "reload", "nginx")
-	return cmd.Run()
+// Reload tests the configuration and reloads Nginx only if the test passes,
+// so a broken config never takes down the running server
+func (s *NginxService) Reload(ctx context.Context) error {
+	if err := s.TestConfig(ctx); err != nil {
+		return fmt.Errorf("reload aborted: %w", err)
+	}
+	return s.ForceReload(ctx)
+}
+
+// ForceReload reloads Nginx without testing the configuration first
+func (s *NginxService) ForceReload(ctx context.Context) error {
+	return s.systemctl(ctx, "reload")
+}
+
+// Restart tests the configuration and restarts Nginx only if the test passes.
+// Unlike a reload it drops open connections, but also recovers a stopped or
+// wedged server.
+func (s *NginxService) Restart(ctx context.Context) error {
+	if err := s.TestConfig(ctx); err != nil {
+		return fmt.Errorf("restart aborted: %w", err)
+	}
+	return s.ForceRestart(ctx)
+}
+
+// ForceRestart restarts Nginx without testing the configuration first
+func (s *NginxService) ForceRestart(ctx context.Context) error {
+	return s.systemctl(ctx, "restart")
+}
+
+// ReloadState returns whether Nginx has site changes it has not loaded yet
+func (s *NginxService) ReloadState() (ReloadState, error) {
+	return ReloadPending(nginxServiceName)
+}
+
+// systemctl runs a systemctl action on Nginx. A successful reload or restart
+// loads every pending change.
+func (s *NginxService) systemctl(ctx context.Context, action string) error {
+	output, err := s.runner.CombinedOutput(ctx, "systemctl", action, nginxServiceName)
+	if err != nil {
+		return serviceCommandError(action, nginxServiceName, output, err)
+	}
+	clearReloadPending(nginxServiceName)
+	return nil
 }
 
 // GetLogs reads Nginx logs
-func (s *NginxService) GetLogs(logType string, lines int) ([]string, error) {
+func (s *NginxService) GetLogs(ctx context.Context, logType string, lines int) ([]string, error) {
 	var logFile string
 
 	switch logType {
@@ -230,8 +473,7 @@ func (s *NginxService) GetLogs(logType string, lines int) ([]string, error) {
 	}
 
 	// Use tail command to get last N lines
-	cmd := exec.Command("tail", "-n", fmt.Sprintf("%d", lines), logFile)
-	output, err := cmd.Output()
+	output, err := s.runner.Output(ctx, "tail", "-n", fmt.Sprintf("%d", lines), logFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read logs: %w", err)
 	}
@@ -247,28 +489,110 @@ func (s *NginxService) GetLogs(logType string, lines int) ([]string, error) {
 	return result, nil
 }
 
+// Upper bounds for the bandwidth caps of a client plan
+const (
+	maxWebRateKB      = 10 << 20  // 10 GB/s
+	maxWebRateAfterKB = 100 << 20 // 100 GB
+	maxWebConnections = 10000
+)
+
+// SiteBandwidth caps what a generated site may serve; zero disables a cap
+type SiteBandwidth struct {
+	RateKB      int // limit_rate per connection, KB/s
+	RateAfterKB int // limit_rate_after, KB sent at full speed first
+	Connections int // limit_conn, concurrent connections per visitor IP
+}
+
+// SiteBandwidthFor returns the bandwidth caps of a client's plan
+func SiteBandwidthFor(limits models.ClientLimits) SiteBandwidth {
+	return SiteBandwidth{
+		RateKB:      limits.LimitWebRate,
+		RateAfterKB: limits.LimitWebRateAfter,
+		Connections: limits.LimitWebConnections,
+	}
+}
+
+// Validate checks that the caps are in range and consistent
+func (b SiteBandwidth) Validate() error {
+	switch {
+	case b.RateKB < 0 || b.RateKB > maxWebRateKB:
+		return fmt.Errorf("%w: limit_web_rate must be between 0 and %d KB/s", ErrInvalidBandwidthLimit, maxWebRateKB)
+	case b.RateAfterKB < 0 || b.RateAfterKB > maxWebRateAfterKB:
+		return fmt.Errorf("%w: limit_web_rate_after must be between 0 and %d KB", ErrInvalidBandwidthLimit, maxWebRateAfterKB)
+	case b.RateAfterKB > 0 && b.RateKB == 0:
+		return fmt.Errorf("%w: limit_web_rate_after requires limit_web_rate", ErrInvalidBandwidthLimit)
+	case b.Connections < 0 || b.Connections > maxWebConnections:
+		return fmt.Errorf("%w: limit_web_connections must be between 0 and %d", ErrInvalidBandwidthLimit, maxWebConnections)
+	}
+	return nil
+}
+
 // GenerateSiteConfig generates a default Nginx site configuration
 func (s *NginxService) GenerateSiteConfig(domain, root, poolName string) string {
-	config := fmt.Sprintf(`server {
-    listen 80;
-    listen [::]:80;
-    server_name %s;
-    root %s;
-    index index.php index.html index.htm;
-
-    location / {
-        try_files $uri $uri/ =404;
-    }
-
-    location ~ \.php$ {
-        include snippets/fastcgi-php.conf;
-        fastcgi_pass unix:/run/php/%s;
-    }
-
-    location ~ /\.ht {
-        deny all;
-    }
-}
-`, domain, root, poolName)
+	config, _ := s.GenerateClientSiteConfig(domain, root, poolName, SiteBandwidth{})
 	return config
 }
+
+// GenerateClientSiteConfig generates a default Nginx site configuration that
+// enforces the bandwidth caps of the owning client's plan
+func (s *NginxService) GenerateClientSiteConfig(domain, root, poolName string, bandwidth SiteBandwidth) (string, error) {
+	return s.GenerateSiteConfigWithOptions(domain, root, poolName, SiteOptions{Bandwidth: bandwidth})
+}
+
+// SiteOptions are the optional parts of a generated site configuration
+type SiteOptions struct {
+	Bandwidth SiteBandwidth
+	// Aliases are further server names, such as *.example.com
+	Aliases []string
+	// ForceHTTPS serves the site over HTTPS with this certificate and redirects HTTP to it
+	ForceHTTPS *SiteCertificate
+}
+
+// GenerateSiteConfigWithOptions generates a default Nginx site configuration with opts applied
+func (s *NginxService) GenerateSiteConfigWithOptions(domain, root, poolName string, opts SiteOptions) (string, error) {
+	bandwidth := opts.Bandwidth
+	if err := bandwidth.Validate(); err != nil {
+		return "", err
+	}
+
+	// The zone is declared above the server block, limit_conn_zone belongs to
+	// the http block, which includes this file
+	config, err := renderConfigTemplate(ConfigTemplateNginx, NginxSiteTemplateData{
+		Domain:      domain,
+		ServerNames: strings.Join(append([]string{domain}, opts.Aliases...), " "),
+		Root:        root,
+		PoolSocket:  poolName,
+		ConnZone:    "conn_" + nginxZoneName(domain),
+		Bandwidth:   bandwidth,
+	})
+	if err != nil {
+		return "", err
+	}
+	if opts.ForceHTTPS != nil {
+		return renderForceHTTPS(config, domain, opts.ForceHTTPS)
+	}
+	return config, nil
+}
+
+// nginxZoneName turns a domain into a valid shared memory zone name. Other
+// characters than letters and digits are escaped with an underscore, so two
+// domains never share a zone: _ becomes __, . becomes _d and - becomes _h.
+func nginxZoneName(domain string) string {
+	var name strings.Builder
+	for i := 0; i < len(domain); i++ {
+		b := domain[i]
+		switch {
+		case (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9'):
+			name.WriteByte(b)
+		case b == '_':
+			name.WriteString("__")
+		case b == '.':
+			name.WriteString("_d")
+		case b == '-':
+			name.WriteString("_h")
+		default:
+			fmt.Fprintf(&name, "_x%02x", b)
+		}
+	}
+	return name.String()
+}
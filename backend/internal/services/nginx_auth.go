@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidAuthUser  = errors.New("invalid basic auth user")
+	ErrAuthUserNotFound = errors.New("basic auth user not found")
+	ErrNoAuthUsers      = errors.New("add a basic auth user before enabling protection")
+	ErrNoServerBlock    = errors.New("site config has no server block")
+)
+
+// Every line basic auth adds to a site config ends with this tag
+const basicAuthTag = "# r-panel basic-auth"
+
+// basicAuthRealm is shown by browsers in the login prompt
+const basicAuthRealm = "Restricted"
+
+// nginxGroup is the group Nginx workers run as, like in the default pool
+// template. Password files are readable by it and nobody else.
+const nginxGroup = "www-data"
+
+// authUserPattern keeps names free of the ":" separator and whitespace
+var authUserPattern = regexp.MustCompile(`^[A-Za-z0-9._@-]{1,64}$`)
+
+// nginxAuthMu serializes changes to password files and the configs using them
+var nginxAuthMu sync.Mutex
+
+// NginxAuthService manages HTTP basic auth for sites: one htpasswd file per site
+// in a directory outside every web root, and the directives that use it
+type NginxAuthService struct {
+	nginx       *NginxService
+	dir         string
+	lookupGroup func(name string) (*user.Group, error)
+}
+
+// SiteAuth describes the basic auth protection of a site
+type SiteAuth struct {
+	Enabled bool     `json:"enabled"`
+	Users   []string `json:"users"`
+}
+
+// NewNginxAuthService stores password files in dir, or in the htpasswd directory
+// next to sites-available when dir is empty
+func NewNginxAuthService(nginx *NginxService, dir string) *NginxAuthService {
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(nginx.sitesAvailablePath), "htpasswd")
+	}
+	return &NginxAuthService{nginx: nginx, dir: dir, lookupGroup: user.LookupGroup}
+}
+
+// passwordFile returns the htpasswd file of a site
+func (s *NginxAuthService) passwordFile(domain string) string {
+	return filepath.Join(s.dir, domain+".htpasswd")
+}
+
+// GetSiteAuth returns whether a site is protected and the users that may log in
+func (s *NginxAuthService) GetSiteAuth(domain string) (*SiteAuth, error) {
+	site, err := s.nginx.GetSite(domain)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.readUsers(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := &SiteAuth{Enabled: BasicAuthEnabled(site.Config), Users: []string{}}
+	for _, entry := range entries {
+		auth.Users = append(auth.Users, entry[0])
+	}
+	return auth, nil
+}
+
+// SetUser adds a user to a site's password file, or changes the password of an
+// existing one. Passwords are stored as bcrypt hashes.
+func (s *NginxAuthService) SetUser(domain, username, password string) error {
+	if !authUserPattern.MatchString(username) {
+		return fmt.Errorf("%w: username may only contain letters, digits, '.', '_', '@' and '-'", ErrInvalidAuthUser)
+	}
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrInvalidAuthUser, MinPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	nginxAuthMu.Lock()
+	defer nginxAuthMu.Unlock()
+
+	if _, err := s.nginx.GetSite(domain); err != nil {
+		return err
+	}
+	entries, err := s.readUsers(domain)
+	if err != nil {
+		return err
+	}
+
+	updated := false
+	for i := range entries {
+		if entries[i][0] == username {
+			entries[i][1] = string(hash)
+			updated = true
+		}
+	}
+	if !updated {
+		entries = append(entries, [2]string{username, string(hash)})
+	}
+	return s.writeUsers(domain, entries)
+}
+
+// RemoveUser removes a user from a site's password file. The last user of a
+// protected site cannot be removed, nobody could log in anymore.
+func (s *NginxAuthService) RemoveUser(domain, username string) error {
+	nginxAuthMu.Lock()
+	defer nginxAuthMu.Unlock()
+
+	site, err := s.nginx.GetSite(domain)
+	if err != nil {
+		return err
+	}
+	entries, err := s.readUsers(domain)
+	if err != nil {
+		return err
+	}
+
+	remaining := entries[:0]
+	for _, entry := range entries {
+		if entry[0] != username {
+			remaining = append(remaining, entry)
+		}
+	}
+	if len(remaining) == len(entries) {
+		return ErrAuthUserNotFound
+	}
+	if len(remaining) == 0 && BasicAuthEnabled(site.Config) {
+		return fmt.Errorf("%w: disable protection before removing the last user", ErrInvalidAuthUser)
+	}
+	return s.writeUsers(domain, remaining)
+}
+
+// SetEnabled adds or removes the auth_basic directives in the site config and
+// reloads Nginx if the new config passes its test; otherwise the previous
+// config is put back
+func (s *NginxAuthService) SetEnabled(ctx context.Context, domain string, enabled bool) error {
+	nginxAuthMu.Lock()
+	defer nginxAuthMu.Unlock()
+
+	if _, err := s.nginx.GetSite(domain); err != nil {
+		return err
+	}
+	previous, err := s.nginx.GetSiteConfig(domain)
+	if err != nil {
+		return err
+	}
+
+	config := removeBasicAuth(previous)
+	if enabled {
+		entries, err := s.readUsers(domain)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return ErrNoAuthUsers
+		}
+		if config, err = renderBasicAuth(config, s.passwordFile(domain)); err != nil {
+			return err
+		}
+	}
+
+	if err := s.nginx.UpdateSite(domain, config); err != nil {
+		return err
+	}
+	if err := s.nginx.Reload(ctx); err != nil {
+		if errors.Is(err, ErrNginxConfigInvalid) {
+			s.nginx.UpdateSite(domain, previous)
+		}
+		return err
+	}
+	return nil
+}
+
+// RemoveSite deletes the password file of a deleted site
+func (s *NginxAuthService) RemoveSite(domain string) error {
+	if err := ValidateDomain(domain); err != nil {
+		return err
+	}
+	path := s.passwordFile(domain)
+	if _, err := s.nginx.fs.Stat(path); err != nil {
+		return nil
+	}
+	return s.nginx.fs.Remove(path)
+}
+
+// readUsers parses a site's password file into user and hash pairs
+func (s *NginxAuthService) readUsers(domain string) ([][2]string, error) {
+	path := s.passwordFile(domain)
+	if _, err := s.nginx.fs.Stat(path); err != nil {
+		return nil, nil
+	}
+	data, err := s.nginx.fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password file: %w", err)
+	}
+
+	var entries [][2]string
+	for _, line := range strings.Split(string(data), "\n") {
+		user, hash, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && user != "" {
+			entries = append(entries, [2]string{user, hash})
+		}
+	}
+	return entries, nil
+}
+
+// writeUsers replaces a site's password file. Nginx reads it on every request,
+// so changes apply without a reload.
+func (s *NginxAuthService) writeUsers(domain string, entries [][2]string) error {
+	sort.Slice(entries, func(i, j int) bool { return entries[i][0] < entries[j][0] })
+	var b strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%s:%s\n", entry[0], entry[1])
+	}
+
+	gid := -1
+	if !skipLinuxUser() {
+		group, err := s.lookupGroup(nginxGroup)
+		if err != nil {
+			return fmt.Errorf("failed to look up Nginx group: %w", err)
+		}
+		gid, _ = strconv.Atoi(group.Gid)
+	}
+
+	if err := s.nginx.fs.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create password directory: %w", err)
+	}
+	// Only Nginx workers need to read the hashes. The mode is set again as
+	// WriteFile keeps that of an existing file.
+	path := s.passwordFile(domain)
+	if err := s.nginx.fs.WriteFile(path, []byte(b.String()), 0640); err != nil {
+		return fmt.Errorf("failed to write password file: %w", err)
+	}
+	if err := s.nginx.fs.Chown(path, -1, gid); err != nil {
+		return fmt.Errorf("failed to set password file group: %w", err)
+	}
+	if err := s.nginx.fs.Chmod(path, 0640); err != nil {
+		return fmt.Errorf("failed to set password file mode: %w", err)
+	}
+	return nil
+}
+
+// BasicAuthEnabled reports whether config was protected by NginxAuthService
+func BasicAuthEnabled(config string) bool {
+	return strings.Contains(config, basicAuthTag)
+}
+
+// renderBasicAuth protects every server block of config with passwordFile,
+// except the force-https redirect which only redirects
+func renderBasicAuth(config, passwordFile string) (string, error) {
+	var out []string
+	protected := 0
+	inRedirect := false
+	for _, line := range strings.Split(config, "\n") {
+		out = append(out, line)
+		switch strings.TrimSpace(line) {
+		case forceHTTPSBegin:
+			inRedirect = true
+		case forceHTTPSEnd:
+			inRedirect = false
+		}
+		match := serverBlockPattern.FindStringSubmatch(line)
+		if match == nil || inRedirect {
+			continue
+		}
+		indent := match[1] + "    "
+		out = append(out,
+			fmt.Sprintf(`%sauth_basic "%s"; %s`, indent, basicAuthRealm, basicAuthTag),
+			fmt.Sprintf("%sauth_basic_user_file %s; %s", indent, passwordFile, basicAuthTag))
+		protected++
+	}
+	if protected == 0 {
+		return "", ErrNoServerBlock
+	}
+	return strings.Join(out, "\n"), nil
+}
+
+// removeBasicAuth drops the lines renderBasicAuth added
+func removeBasicAuth(config string) string {
+	var out []string
+	for _, line := range strings.Split(config, "\n") {
+		if !strings.HasSuffix(strings.TrimSpace(line), basicAuthTag) {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os/user"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const htpasswdFile = "/etc/nginx/htpasswd/example.com.htpasswd"
+
+func TestNginxAuthServiceUsers(t *testing.T) {
+	nginx, fsys, _ := newFakeNginx()
+	require.NoError(t, nginx.CreateSite("example.com", nginx.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock")))
+	service := NewNginxAuthService(nginx, "")
+	t.Setenv("SKIP_LINUX_USER", "")
+	t.Setenv("TEST_MODE", "")
+	service.lookupGroup = func(name string) (*user.Group, error) {
+		assert.Equal(t, "www-data", name)
+		return &user.Group{Gid: "33", Name: name}, nil
+	}
+
+	// A file written before its mode was tightened is fixed on the next write
+	fsys.mkdir("/etc/nginx/htpasswd")
+	require.NoError(t, fsys.WriteFile(htpasswdFile, nil, 0644))
+
+	assert.ErrorIs(t, service.SetUser("example.com", "bad:name", "long enough"), ErrInvalidAuthUser)
+	assert.ErrorIs(t, service.SetUser("example.com", "staging", "short"), ErrInvalidAuthUser)
+	assert.ErrorIs(t, service.SetUser("missing.com", "staging", "long enough"), ErrSiteNotFound)
+
+	require.NoError(t, service.SetUser("example.com", "staging", "long enough"))
+	require.NoError(t, service.SetUser("example.com", "client", "first password"))
+	require.NoError(t, service.SetUser("example.com", "client", "second password"))
+
+	// One bcrypt line per user, outside the web root
+	lines := strings.Split(strings.TrimSpace(string(fsys.files[htpasswdFile])), "\n")
+	require.Len(t, lines, 2)
+	user, hash, _ := strings.Cut(lines[0], ":")
+	assert.Equal(t, "client", user)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("second password")))
+	assert.Equal(t, fs.FileMode(0640), fsys.modes[htpasswdFile], "only the Nginx group may read the hashes")
+	assert.Equal(t, 33, fsys.gids[htpasswdFile])
+
+	auth, err := service.GetSiteAuth("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, &SiteAuth{Enabled: false, Users: []string{"client", "staging"}}, auth)
+
+	require.NoError(t, service.RemoveUser("example.com", "client"))
+	assert.ErrorIs(t, service.RemoveUser("example.com", "client"), ErrAuthUserNotFound)
+
+	require.NoError(t, service.RemoveSite("example.com"))
+	assert.NotContains(t, fsys.files, htpasswdFile)
+}
+
+func TestNginxAuthServiceToggle(t *testing.T) {
+	nginx, fsys, runner := newFakeNginx()
+	plain := nginx.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock")
+	require.NoError(t, nginx.CreateSite("example.com", plain))
+	service := NewNginxAuthService(nginx, "")
+	runner.on("nginx -t", "", nil)
+	runner.on("systemctl reload nginx", "", nil)
+
+	assert.ErrorIs(t, service.SetEnabled(context.Background(), "example.com", true), ErrNoAuthUsers)
+	require.NoError(t, service.SetUser("example.com", "staging", "long enough"))
+
+	require.NoError(t, service.SetEnabled(context.Background(), "example.com", true))
+	require.NoError(t, service.SetEnabled(context.Background(), "example.com", true))
+	config := string(fsys.files[snippetSite])
+	assert.Equal(t, 1, strings.Count(config, "auth_basic_user_file "+htpasswdFile+";"))
+	assert.Contains(t, config, "server {\n    auth_basic \"Restricted\"; # r-panel basic-auth\n")
+	assert.Equal(t, []string{"nginx -t", "systemctl reload nginx", "nginx -t", "systemctl reload nginx"}, runner.calls)
+
+	assert.ErrorIs(t, service.RemoveUser("example.com", "staging"), ErrInvalidAuthUser, "the last user of a protected site stays")
+
+	// The force-https redirect is left unprotected
+	protected, err := renderBasicAuth(removeBasicAuth(config), htpasswdFile)
+	require.NoError(t, err)
+	https, err := ForceHTTPSConfig(removeBasicAuth(config), "example.com", &SiteCertificate{Certificate: "c", Key: "k"})
+	require.NoError(t, err)
+	httpsProtected, err := renderBasicAuth(https, htpasswdFile)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Count(protected, "auth_basic_user_file"), strings.Count(httpsProtected, "auth_basic_user_file"))
+
+	// A failed config test keeps the protected config
+	runner.on("nginx -t", "nginx: [emerg] open() failed", errors.New("exit status 1"))
+	assert.ErrorIs(t, service.SetEnabled(context.Background(), "example.com", false), ErrNginxConfigInvalid)
+	assert.Equal(t, config, string(fsys.files[snippetSite]))
+
+	runner.on("nginx -t", "", nil)
+	require.NoError(t, service.SetEnabled(context.Background(), "example.com", false))
+	assert.Equal(t, plain, string(fsys.files[snippetSite]))
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"strings"
+)
+
+// CloneSite writes a copy of the site domain as newDomain, with the server
+// names, roots and connection zones of domain switched to newDomain, and
+// returns the new config. The copy is not enabled so it can be reviewed first.
+// Force-https, basic auth and snippets belong to the source site and are not
+// carried over.
+func (s *NginxService) CloneSite(domain, newDomain string) (string, error) {
+	if err := ValidateDomain(newDomain); err != nil {
+		return "", err
+	}
+	if _, err := s.GetSite(domain); err != nil {
+		return "", err
+	}
+	config, err := s.GetSiteConfig(domain)
+	if err != nil {
+		return "", err
+	}
+
+	cloned, err := CloneSiteConfig(config, domain, newDomain)
+	if err != nil {
+		return "", err
+	}
+	if err := s.CreateSite(newDomain, cloned); err != nil {
+		return "", err
+	}
+	return cloned, nil
+}
+
+// CloneSiteConfig returns config rewritten from domain to newDomain
+func CloneSiteConfig(config, domain, newDomain string) (string, error) {
+	// The certificate, password file and snippet attachments are the source's
+	config = removeForceHTTPS(config)
+	config = removeBasicAuth(config)
+	config, err := renderSiteSnippets(config, nil)
+	if err != nil {
+		return "", err
+	}
+
+	oldZone, newZone := siteConnZone(config, domain), "conn_"+nginxZoneName(newDomain)
+	lines := strings.Split(config, "\n")
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "server_name":
+			lines[i] = cloneServerNames(line, domain, newDomain)
+		case "root":
+			lines[i] = strings.ReplaceAll(line, domain, newDomain)
+		case "limit_conn_zone", "limit_conn":
+			// Zone names are global to nginx, two sites cannot share one
+			lines[i] = strings.ReplaceAll(line, "zone="+oldZone+":", "zone="+newZone+":")
+			lines[i] = strings.Replace(lines[i], "limit_conn "+oldZone+" ", "limit_conn "+newZone+" ", 1)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// siteConnZone returns the limit_conn zone declared in config. Sites written
+// before zone names were escaped use another name than nginxZoneName gives.
+func siteConnZone(config, domain string) string {
+	for _, line := range strings.Split(config, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "limit_conn_zone" {
+			continue
+		}
+		for _, field := range fields[2:] {
+			if zone, ok := strings.CutPrefix(field, "zone="); ok {
+				if name, _, ok := strings.Cut(zone, ":"); ok {
+					return name
+				}
+			}
+		}
+	}
+	return "conn_" + nginxZoneName(domain)
+}
+
+// cloneServerNames switches domain and its subdomains in a server_name line to
+// newDomain, other names are kept
+func cloneServerNames(line, domain, newDomain string) string {
+	code, comment, _ := strings.Cut(line, "#")
+	indent := code[:len(code)-len(strings.TrimLeft(code, " \t"))]
+	fields := strings.Fields(strings.Replace(code, ";", " ;", 1))
+
+	for i, name := range fields[1:] {
+		switch {
+		case name == domain:
+			fields[i+1] = newDomain
+		case strings.HasSuffix(name, "."+domain):
+			fields[i+1] = strings.TrimSuffix(name, domain) + newDomain
+		}
+	}
+	rewritten := indent + strings.Replace(strings.Join(fields, " "), " ;", ";", 1)
+	if comment != "" {
+		rewritten += " #" + comment
+	}
+	return rewritten
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNginxServiceCloneSite(t *testing.T) {
+	service, fsys, _ := newFakeNginx()
+	config, err := service.GenerateClientSiteConfig("example.com", "/home/client1/web/example.com", "client1.sock", SiteBandwidth{Connections: 10})
+	require.NoError(t, err)
+	config = strings.Replace(config, "server_name example.com;", "server_name example.com www.example.com other.org; # main", 1)
+	config, err = ForceHTTPSConfig(config, "example.com", &SiteCertificate{
+		Certificate: "/etc/ssl/example.com.crt",
+		Key:         "/etc/ssl/example.com.key",
+	})
+	require.NoError(t, err)
+	config, err = renderBasicAuth(config, "/etc/nginx/htpasswd/example.com.htpasswd")
+	require.NoError(t, err)
+	require.NoError(t, service.CreateSite("example.com", config))
+
+	cloned, err := service.CloneSite("example.com", "staging.example.net")
+	require.NoError(t, err)
+	assert.Equal(t, cloned, string(fsys.files["/etc/nginx/sites-available/staging.example.net"]))
+	assert.NotContains(t, fsys.links, "/etc/nginx/sites-enabled/staging.example.net", "the clone is left disabled for review")
+
+	assert.Contains(t, cloned, "server_name staging.example.net www.staging.example.net other.org; # main")
+	assert.Contains(t, cloned, "root /home/client1/web/staging.example.net;")
+	assert.Contains(t, cloned, "zone=conn_staging_dexample_dnet:10m;")
+	assert.Contains(t, cloned, "limit_conn conn_staging_dexample_dnet 10;")
+	assert.Contains(t, cloned, "listen 80;")
+	for _, leftover := range []string{"example.com", "ssl_certificate", "auth_basic", forceHTTPSBegin} {
+		assert.NotContains(t, cloned, leftover)
+	}
+
+	// The source is untouched
+	source, err := service.GetSiteConfig("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, config, source)
+
+	_, err = service.CloneSite("example.com", "staging.example.net")
+	assert.ErrorIs(t, err, ErrSiteExists)
+	_, err = service.CloneSite("missing.com", "copy.example.net")
+	assert.ErrorIs(t, err, ErrSiteNotFound)
+	_, err = service.CloneSite("example.com", "../escape")
+	assert.ErrorIs(t, err, ErrInvalidDomain)
+}
+
+func TestCloneSiteConfigLegacyZoneName(t *testing.T) {
+	// Written before zone names were escaped
+	config := "limit_conn_zone $binary_remote_addr zone=conn_example_com:10m;\n" +
+		"server {\n    server_name example.com;\n    limit_conn conn_example_com 10;\n}\n"
+
+	cloned, err := CloneSiteConfig(config, "example.com", "example.net")
+	require.NoError(t, err)
+	assert.Contains(t, cloned, "zone=conn_example_dnet:10m;")
+	assert.Contains(t, cloned, "limit_conn conn_example_dnet 10;")
+	assert.NotContains(t, cloned, "conn_example_com")
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrNoCertificate     = errors.New("no certificate found for site")
+	ErrNoPlainHTTPServer = errors.New("site config has no server listening on port 80")
+	ErrSSLNotAllowed     = errors.New("the site owner's plan does not allow SSL")
+)
+
+// The redirect server added by force-https sits between these markers, and
+// every line it changes in the site's own server blocks ends with forceHTTPSTag
+const (
+	forceHTTPSBegin = "# BEGIN r-panel force-https"
+	forceHTTPSEnd   = "# END r-panel force-https"
+	forceHTTPSTag   = "# r-panel force-https"
+)
+
+var (
+	plainListenPattern = regexp.MustCompile(`^(\s*)listen\s+(\[::\]:)?80((?:\s+[a-z_]+)*)\s*;\s*$`)
+	serverNamePattern  = regexp.MustCompile(`^\s*server_name\s+([^;#]+);`)
+)
+
+// SiteCertificate is the certificate and key a site is served over HTTPS with.
+// Both may be the same file when it holds the chain and the key.
+type SiteCertificate struct {
+	Certificate string `json:"certificate"`
+	Key         string `json:"key"`
+}
+
+// FindCertificate returns the certificate for domain: an uploaded <domain>.crt
+// and <domain>.key in uploadedDir, otherwise the combined PEM autocert caches
+// in autocertDir. Either directory may be empty to skip it.
+func (s *NginxService) FindCertificate(domain, uploadedDir, autocertDir string) (*SiteCertificate, error) {
+	if err := ValidateDomain(domain); err != nil {
+		return nil, err
+	}
+
+	if uploadedDir != "" {
+		cert := &SiteCertificate{
+			Certificate: filepath.Join(uploadedDir, domain+".crt"),
+			Key:         filepath.Join(uploadedDir, domain+".key"),
+		}
+		if s.exists(cert.Certificate) && s.exists(cert.Key) {
+			return cert, nil
+		}
+	}
+	if autocertDir != "" {
+		if path, err := filepath.Abs(filepath.Join(autocertDir, domain)); err == nil && s.exists(path) {
+			return &SiteCertificate{Certificate: path, Key: path}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: upload %s.crt and %s.key or let the panel obtain one", ErrNoCertificate, domain, domain)
+}
+
+func (s *NginxService) exists(path string) bool {
+	_, err := s.fs.Stat(path)
+	return err == nil
+}
+
+// SetForceHTTPS switches a site to HTTPS with cert and redirects HTTP to it, or
+// back to plain HTTP when cert is nil. Nginx is reloaded only if the new config
+// passes its test; otherwise the previous config is put back.
+func (s *NginxService) SetForceHTTPS(ctx context.Context, domain string, cert *SiteCertificate) error {
+	if _, err := s.GetSite(domain); err != nil {
+		return err
+	}
+	previous, err := s.GetSiteConfig(domain)
+	if err != nil {
+		return err
+	}
+
+	config := removeForceHTTPS(previous)
+	if cert != nil {
+		if config, err = ForceHTTPSConfig(previous, domain, cert); err != nil {
+			return err
+		}
+	}
+
+	if err := s.UpdateSite(domain, config); err != nil {
+		return err
+	}
+	if err := s.Reload(ctx); err != nil {
+		if errors.Is(err, ErrNginxConfigInvalid) {
+			s.UpdateSite(domain, previous)
+		}
+		return err
+	}
+	return nil
+}
+
+// ForceHTTPSEnabled reports whether config was switched to HTTPS by SetForceHTTPS
+func ForceHTTPSEnabled(config string) bool {
+	for _, line := range strings.Split(config, "\n") {
+		if strings.TrimSpace(line) == forceHTTPSBegin {
+			return true
+		}
+	}
+	return false
+}
+
+// ForceHTTPSConfig returns config switched to HTTPS with cert and an HTTP redirect
+func ForceHTTPSConfig(config, domain string, cert *SiteCertificate) (string, error) {
+	return renderForceHTTPS(removeForceHTTPS(config), domain, cert)
+}
+
+// renderForceHTTPS moves every server listening on port 80 to 443 with cert and
+// adds a server that redirects port 80 to HTTPS in front of them
+func renderForceHTTPS(config, domain string, cert *SiteCertificate) (string, error) {
+	serverName := domain
+	var out []string
+	converted := 0
+	certIndent := ""
+	for _, line := range strings.Split(config, "\n") {
+		if match := serverNamePattern.FindStringSubmatch(line); match != nil && converted == 0 {
+			serverName = strings.TrimSpace(match[1])
+		}
+
+		if match := plainListenPattern.FindStringSubmatch(line); match != nil {
+			out = append(out, fmt.Sprintf("%slisten %s443 ssl%s; %s", match[1], match[2], match[3], forceHTTPSTag))
+			certIndent = match[1]
+			converted++
+			continue
+		}
+		// The certificate goes right after the last listen directive of a server
+		if certIndent != "" {
+			out = append(out,
+				fmt.Sprintf("%sssl_certificate %s; %s", certIndent, cert.Certificate, forceHTTPSTag),
+				fmt.Sprintf("%sssl_certificate_key %s; %s", certIndent, cert.Key, forceHTTPSTag))
+			certIndent = ""
+		}
+		out = append(out, line)
+	}
+	if converted == 0 {
+		return "", ErrNoPlainHTTPServer
+	}
+
+	redirect := fmt.Sprintf(`%s
+server {
+    listen 80;
+    listen [::]:80;
+    server_name %s;
+    return 301 https://$host$request_uri;
+}
+%s
+
+`, forceHTTPSBegin, serverName, forceHTTPSEnd)
+	return redirect + strings.Join(out, "\n"), nil
+}
+
+// removeForceHTTPS undoes renderForceHTTPS, leaving the rest of config as it is
+func removeForceHTTPS(config string) string {
+	var out []string
+	inRedirect := false
+	skipBlank := false
+	for _, line := range strings.Split(config, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == forceHTTPSBegin:
+			inRedirect = true
+			continue
+		case trimmed == forceHTTPSEnd:
+			inRedirect = false
+			skipBlank = true
+			continue
+		case inRedirect:
+			continue
+		case skipBlank && trimmed == "":
+			skipBlank = false
+			continue
+		}
+		skipBlank = false
+
+		if !strings.HasSuffix(trimmed, forceHTTPSTag) {
+			out = append(out, line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "ssl_certificate") {
+			continue
+		}
+		line = strings.TrimRight(strings.TrimSuffix(strings.TrimRight(line, " \t"), forceHTTPSTag), " \t")
+		out = append(out, strings.Replace(line, "443 ssl", "80", 1))
+	}
+	return strings.Join(out, "\n")
+}
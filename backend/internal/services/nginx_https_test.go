@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSiteConfigForceHTTPS(t *testing.T) {
+	service, _, _ := newFakeNginx()
+	plain := service.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock")
+	cert := &SiteCertificate{Certificate: "/etc/ssl/r-panel/example.com.crt", Key: "/etc/ssl/r-panel/example.com.key"}
+
+	config, err := service.GenerateSiteConfigWithOptions("example.com", "/home/client1/web", "client1.sock", SiteOptions{ForceHTTPS: cert})
+	require.NoError(t, err)
+	assert.True(t, ForceHTTPSEnabled(config))
+	assert.Contains(t, config, "server {\n    listen 80;\n    listen [::]:80;\n    server_name example.com;\n    return 301 https://$host$request_uri;\n}\n")
+	assert.Contains(t, config, `    listen 443 ssl; # r-panel force-https
+    listen [::]:443 ssl; # r-panel force-https
+    ssl_certificate /etc/ssl/r-panel/example.com.crt; # r-panel force-https
+    ssl_certificate_key /etc/ssl/r-panel/example.com.key; # r-panel force-https
+    server_name example.com;
+`)
+
+	// Switching back restores the generated config exactly
+	assert.Equal(t, plain, removeForceHTTPS(config))
+	assert.False(t, ForceHTTPSEnabled(plain))
+
+	_, err = ForceHTTPSConfig("server {\n    listen 8080;\n}\n", "example.com", cert)
+	assert.ErrorIs(t, err, ErrNoPlainHTTPServer)
+}
+
+func TestNginxServiceSetForceHTTPS(t *testing.T) {
+	service, fsys, runner := newFakeNginx()
+	plain := service.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock")
+	require.NoError(t, service.CreateSite("example.com", plain))
+	fsys.files["/etc/ssl/r-panel/example.com.crt"] = []byte("cert")
+	fsys.files["/etc/ssl/r-panel/example.com.key"] = []byte("key")
+
+	cert, err := service.FindCertificate("example.com", "/etc/ssl/r-panel", "")
+	require.NoError(t, err)
+	_, err = service.FindCertificate("other.com", "/etc/ssl/r-panel", "/var/lib/r-panel/certs")
+	assert.ErrorIs(t, err, ErrNoCertificate)
+
+	runner.on("nginx -t", "", nil)
+	runner.on("systemctl reload nginx", "", nil)
+	require.NoError(t, service.SetForceHTTPS(context.Background(), "example.com", cert))
+	site, err := service.GetSite("example.com")
+	require.NoError(t, err)
+	assert.True(t, site.ForceHTTPS)
+
+	// Enabling again does not stack a second redirect
+	require.NoError(t, service.SetForceHTTPS(context.Background(), "example.com", cert))
+	assert.Equal(t, 1, strings.Count(string(fsys.files[snippetSite]), forceHTTPSBegin))
+
+	// A config that fails its test is rolled back and Nginx is not reloaded
+	runner.on("nginx -t", "nginx: [emerg] cannot load certificate", errors.New("exit status 1"))
+	runner.calls = nil
+	before := string(fsys.files[snippetSite])
+	assert.ErrorIs(t, service.SetForceHTTPS(context.Background(), "example.com", nil), ErrNginxConfigInvalid)
+	assert.Equal(t, before, string(fsys.files[snippetSite]))
+	assert.Equal(t, []string{"nginx -t"}, runner.calls)
+
+	runner.on("nginx -t", "", nil)
+	require.NoError(t, service.SetForceHTTPS(context.Background(), "example.com", nil))
+	assert.Equal(t, plain, string(fsys.files[snippetSite]))
+
+	assert.ErrorIs(t, service.SetForceHTTPS(context.Background(), "missing.com", cert), ErrSiteNotFound)
+}
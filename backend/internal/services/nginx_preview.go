@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+var ErrNoSitesInclude = errors.New("nginx.conf does not include the sites-enabled directory")
+
+// includePattern matches an include directive and captures its indent and path
+var includePattern = regexp.MustCompile(`^(\s*)include\s+["']?([^"';\s]+)["']?\s*;`)
+
+// nginxStagingMu serializes config tests that use the staging files
+var nginxStagingMu sync.Mutex
+
+// SitePreview compares a proposed site config with the one on disk
+type SitePreview struct {
+	Changed    bool   `json:"changed"`
+	Diff       string `json:"diff"`        // unified diff from the current to the proposed config
+	Valid      bool   `json:"valid"`       // whether nginx -t passes with the proposed config
+	TestOutput string `json:"test_output"` // what nginx -t printed
+}
+
+// PreviewSiteUpdate diffs config against the current config of domain and tests
+// it with nginx -t in a staging copy of the main config. The site is not written.
+func (s *NginxService) PreviewSiteUpdate(ctx context.Context, domain, config string) (*SitePreview, error) {
+	if _, err := s.GetSite(domain); err != nil {
+		return nil, err
+	}
+	current, err := s.GetSiteConfig(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(current),
+		B:        difflib.SplitLines(config),
+		FromFile: "a/" + domain,
+		ToFile:   "b/" + domain,
+		Context:  3,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff site config: %w", err)
+	}
+
+	output, valid, err := s.testStagedSite(ctx, domain, config)
+	if err != nil {
+		return nil, err
+	}
+	return &SitePreview{
+		Changed:    current != config,
+		Diff:       diff,
+		Valid:      valid,
+		TestOutput: output,
+	}, nil
+}
+
+// testStagedSite runs nginx -t against a copy of nginx.conf whose sites-enabled
+// include is replaced by the other enabled sites plus config for domain. The
+// staging files sit next to nginx.conf, so relative includes still resolve,
+// and are removed afterwards.
+func (s *NginxService) testStagedSite(ctx context.Context, domain, config string) (output string, valid bool, err error) {
+	confDir := filepath.Dir(s.sitesAvailablePath)
+	mainConf, err := s.fs.ReadFile(filepath.Join(confDir, "nginx.conf"))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read nginx.conf: %w", err)
+	}
+
+	nginxStagingMu.Lock()
+	defer nginxStagingMu.Unlock()
+
+	stagedSite := filepath.Join(confDir, ".r-panel-staging-"+domain+".site")
+	stagedConf := filepath.Join(confDir, ".r-panel-staging-"+domain+".conf")
+
+	sites := []string{}
+	entries, err := s.fs.ReadDir(s.sitesEnabledPath)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list enabled sites: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != domain {
+			sites = append(sites, filepath.Join(s.sitesEnabledPath, entry.Name()))
+		}
+	}
+	sites = append(sites, stagedSite)
+
+	staged, err := stageSitesInclude(string(mainConf), confDir, s.sitesEnabledPath, sites)
+	if err != nil {
+		return "", false, err
+	}
+
+	if err := s.fs.WriteFile(stagedSite, []byte(config), 0644); err != nil {
+		return "", false, fmt.Errorf("failed to write staging config: %w", err)
+	}
+	defer s.fs.Remove(stagedSite)
+	if err := s.fs.WriteFile(stagedConf, []byte(staged), 0644); err != nil {
+		return "", false, fmt.Errorf("failed to write staging config: %w", err)
+	}
+	defer s.fs.Remove(stagedConf)
+
+	out, err := s.runner.CombinedOutput(ctx, "nginx", "-t", "-c", stagedConf)
+	return strings.TrimSpace(string(out)), err == nil, nil
+}
+
+// stageSitesInclude replaces the include of sitesEnabled/* in mainConf with an
+// include of each of sites
+func stageSitesInclude(mainConf, confDir, sitesEnabled string, sites []string) (string, error) {
+	wildcard := filepath.Join(sitesEnabled, "*")
+	lines := strings.Split(mainConf, "\n")
+	replaced := false
+	for i, line := range lines {
+		match := includePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		path := match[2]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(confDir, path)
+		}
+		if filepath.Clean(path) != wildcard {
+			continue
+		}
+
+		includes := make([]string, len(sites))
+		for j, site := range sites {
+			includes[j] = fmt.Sprintf("%sinclude %s;", match[1], site)
+		}
+		lines[i] = strings.Join(includes, "\n")
+		replaced = true
+	}
+	if !replaced {
+		return "", fmt.Errorf("%w: %s", ErrNoSitesInclude, wildcard)
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNginxConf = `user www-data;
+events {}
+http {
+    include /etc/nginx/mime.types;
+    include /etc/nginx/conf.d/*.conf;
+    include sites-enabled/*;
+}
+`
+
+func TestNginxServicePreviewSiteUpdate(t *testing.T) {
+	service, fsys, runner := newFakeNginx()
+	fsys.files["/etc/nginx/nginx.conf"] = []byte(testNginxConf)
+	config := service.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock")
+	require.NoError(t, service.CreateSite("example.com", config))
+	require.NoError(t, service.EnableSite("example.com"))
+	require.NoError(t, service.CreateSite("other.com", config))
+	require.NoError(t, service.EnableSite("other.com"))
+
+	stagedConf := "/etc/nginx/.r-panel-staging-example.com.conf"
+	runner.on("nginx -t -c "+stagedConf, "nginx: configuration file test is successful", nil)
+
+	proposed := strings.Replace(config, "index index.php", "index index.html", 1)
+	preview, err := service.PreviewSiteUpdate(context.Background(), "example.com", proposed)
+	require.NoError(t, err)
+
+	assert.True(t, preview.Changed)
+	assert.True(t, preview.Valid)
+	assert.Equal(t, []string{"nginx -t -c " + stagedConf}, runner.calls)
+	assert.Contains(t, preview.Diff, "--- a/example.com\n+++ b/example.com\n")
+	assert.Contains(t, preview.Diff, "\n-    index index.php index.html index.htm;\n+    index index.html index.html index.htm;\n")
+	assert.Equal(t, "nginx: configuration file test is successful", preview.TestOutput)
+
+	// Nothing is written: the site is unchanged and the staging files are gone
+	current, err := service.GetSiteConfig("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, config, current)
+	assert.NotContains(t, fsys.files, stagedConf)
+	assert.NotContains(t, fsys.files, "/etc/nginx/.r-panel-staging-example.com.site")
+
+	t.Run("failing test", func(t *testing.T) {
+		runner.on("nginx -t -c "+stagedConf, "nginx: [emerg] unknown directive \"bogus\"", errors.New("exit status 1"))
+		preview, err := service.PreviewSiteUpdate(context.Background(), "example.com", config+"bogus;\n")
+		require.NoError(t, err)
+		assert.False(t, preview.Valid)
+		assert.Contains(t, preview.TestOutput, "unknown directive")
+	})
+
+	t.Run("unchanged config", func(t *testing.T) {
+		runner.on("nginx -t -c "+stagedConf, "", nil)
+		preview, err := service.PreviewSiteUpdate(context.Background(), "example.com", config)
+		require.NoError(t, err)
+		assert.False(t, preview.Changed)
+		assert.Empty(t, preview.Diff)
+	})
+
+	t.Run("missing site", func(t *testing.T) {
+		_, err := service.PreviewSiteUpdate(context.Background(), "missing.com", config)
+		assert.ErrorIs(t, err, ErrSiteNotFound)
+	})
+}
+
+func TestStageSitesInclude(t *testing.T) {
+	sites := []string{"/etc/nginx/sites-enabled/other.com", "/etc/nginx/.r-panel-staging-example.com.site"}
+	staged, err := stageSitesInclude(testNginxConf, "/etc/nginx", "/etc/nginx/sites-enabled", sites)
+	require.NoError(t, err)
+	assert.Contains(t, staged, "    include /etc/nginx/conf.d/*.conf;\n"+
+		"    include /etc/nginx/sites-enabled/other.com;\n"+
+		"    include /etc/nginx/.r-panel-staging-example.com.site;\n}")
+	assert.NotContains(t, staged, "sites-enabled/*")
+
+	_, err = stageSitesInclude("events {}\nhttp {}\n", "/etc/nginx", "/etc/nginx/sites-enabled", sites)
+	assert.ErrorIs(t, err, ErrNoSitesInclude)
+}
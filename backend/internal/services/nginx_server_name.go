@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrServerNameMismatch = errors.New("no server_name in the config matches the site domain")
+
+// SiteServerNames returns the names of every server_name directive in config,
+// in order and without repeats
+func SiteServerNames(config string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(config, "\n") {
+		match := serverNamePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		for _, name := range strings.Fields(match[1]) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// ServerNameMatches reports whether nginx would pick a server named name for
+// requests to domain: the exact name, a wildcard such as *.example.com or
+// .example.com, or a ~regular expression
+func ServerNameMatches(name, domain string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	switch {
+	case strings.HasPrefix(name, "~"):
+		pattern, err := regexp.Compile(name[1:])
+		return err == nil && pattern.MatchString(domain)
+	case strings.HasPrefix(name, "*."):
+		return strings.HasSuffix(domain, name[1:])
+	case strings.HasSuffix(name, ".*"):
+		return strings.HasPrefix(domain, name[:len(name)-1])
+	case strings.HasPrefix(name, "."):
+		return domain == name[1:] || strings.HasSuffix(domain, name)
+	}
+	return name == domain
+}
+
+// CheckSiteServerNames returns the server names of a site config, and an
+// ErrServerNameMismatch when none of them serves domain. Saving a config
+// copied from another site without fixing its server_name is an easy mistake
+// that routes the domain to whichever site nginx picks as default.
+func CheckSiteServerNames(domain, config string) ([]string, error) {
+	names := SiteServerNames(config)
+	if len(names) == 0 {
+		return names, fmt.Errorf("%w: the config has no server_name, expected %s", ErrServerNameMismatch, domain)
+	}
+	for _, name := range names {
+		if ServerNameMatches(name, domain) {
+			return names, nil
+		}
+	}
+	return names, fmt.Errorf("%w: %s is not among %s", ErrServerNameMismatch, domain, strings.Join(names, ", "))
+}
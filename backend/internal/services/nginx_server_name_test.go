@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSiteServerNames(t *testing.T) {
+	config := `server {
+    listen 80;
+    server_name example.com www.example.com; # main
+    # server_name old.example.com;
+}
+server {
+    listen 443 ssl;
+	server_name example.com;
+}`
+	assert.Equal(t, []string{"example.com", "www.example.com"}, SiteServerNames(config))
+	assert.Empty(t, SiteServerNames("server {\n    listen 80;\n}"))
+}
+
+func TestServerNameMatches(t *testing.T) {
+	matches := map[string]string{
+		"example.com":                 "example.com",
+		"Example.COM.":                "example.com",
+		"*.example.com":               "shop.example.com",
+		".example.com":                "example.com",
+		"www.*":                       "www.example.com",
+		`~^(www\.)?example\.com$`:     "www.example.com",
+		`~^(?<sub>.+)\.example\.com$`: "a.example.com",
+	}
+	for name, domain := range matches {
+		assert.True(t, ServerNameMatches(name, domain), "%s should match %s", name, domain)
+	}
+
+	mismatches := map[string]string{
+		"example.org":      "example.com",
+		"*.example.com":    "example.com",
+		".example.com":     "notexample.com",
+		"_":                "example.com",
+		"~[":               "example.com",
+		`~^shop\.example$`: "example.com",
+	}
+	for name, domain := range mismatches {
+		assert.False(t, ServerNameMatches(name, domain), "%s should not match %s", name, domain)
+	}
+}
+
+func TestCheckSiteServerNames(t *testing.T) {
+	service, _, _ := newFakeNginx()
+	config := service.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock")
+
+	names, err := CheckSiteServerNames("example.com", config)
+	require.NoError(t, err)
+	assert.Contains(t, names, "example.com")
+
+	// A config copied from another site
+	names, err = CheckSiteServerNames("shop.example.org", config)
+	assert.ErrorIs(t, err, ErrServerNameMismatch)
+	assert.Contains(t, err.Error(), "shop.example.org is not among example.com")
+	assert.Contains(t, names, "example.com")
+
+	names, err = CheckSiteServerNames("example.com", "server {\n    listen 80;\n}")
+	assert.ErrorIs(t, err, ErrServerNameMismatch)
+	assert.Empty(t, names)
+}
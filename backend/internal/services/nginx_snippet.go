@@ -0,0 +1,457 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"r-panel/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNginxSnippetNotFound = errors.New("nginx snippet not found")
+	ErrNginxSnippetExists   = errors.New("nginx snippet already exists")
+	ErrInvalidNginxSnippet  = errors.New("invalid nginx snippet")
+	ErrSnippetsNotAllowed   = errors.New("the site owner's plan does not allow directive snippets")
+)
+
+// Markers around the snippets rendered into a site config. Everything between
+// them is owned by the panel and replaced whenever the attached snippets change.
+const (
+	snippetBlockBegin = "# BEGIN r-panel snippets"
+	snippetBlockEnd   = "# END r-panel snippets"
+)
+
+var (
+	snippetNamePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,99}$`)
+	serverBlockPattern  = regexp.MustCompile(`^(\s*)server\s*\{\s*(#.*)?$`)
+	snippetBlockPattern = regexp.MustCompile(`(?m)^\s*# (BEGIN|END) r-panel snippets\s*$`)
+)
+
+// nginxSnippetMu serializes snippet changes, each one rewrites and tests site configs
+var nginxSnippetMu sync.Mutex
+
+// NginxSnippetData holds the fields of a snippet that can be created or updated
+type NginxSnippetData struct {
+	Name        string
+	Description string
+	Content     string
+}
+
+// NginxSnippetService stores reusable server-block directives and renders the
+// ones attached to a site into its config
+type NginxSnippetService struct {
+	nginx *NginxService
+}
+
+func NewNginxSnippetService(nginx *NginxService) *NginxSnippetService {
+	return &NginxSnippetService{nginx: nginx}
+}
+
+// GetSnippets returns all snippets
+func (s *NginxSnippetService) GetSnippets() ([]models.NginxSnippet, error) {
+	var snippets []models.NginxSnippet
+	if err := models.DB.Order("name").Find(&snippets).Error; err != nil {
+		return nil, err
+	}
+	return snippets, nil
+}
+
+// GetSnippet returns a snippet by ID
+func (s *NginxSnippetService) GetSnippet(id uint) (*models.NginxSnippet, error) {
+	var snippet models.NginxSnippet
+	if err := models.DB.First(&snippet, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNginxSnippetNotFound
+		}
+		return nil, err
+	}
+	return &snippet, nil
+}
+
+// CreateSnippet stores a new snippet
+func (s *NginxSnippetService) CreateSnippet(data *NginxSnippetData) (*models.NginxSnippet, error) {
+	if err := validateNginxSnippet(data); err != nil {
+		return nil, err
+	}
+
+	nginxSnippetMu.Lock()
+	defer nginxSnippetMu.Unlock()
+
+	if err := checkSnippetNameFree(data.Name, 0); err != nil {
+		return nil, err
+	}
+	snippet := &models.NginxSnippet{
+		Name:        data.Name,
+		Description: data.Description,
+		Content:     data.Content,
+	}
+	if err := models.DB.Create(snippet).Error; err != nil {
+		return nil, fmt.Errorf("failed to create snippet: %w", err)
+	}
+	return snippet, nil
+}
+
+// UpdateSnippet replaces a snippet and regenerates every site it is attached to.
+// If the resulting Nginx config fails its test, the sites are restored and the
+// snippet is left unchanged.
+func (s *NginxSnippetService) UpdateSnippet(ctx context.Context, id uint, data *NginxSnippetData) (*models.NginxSnippet, error) {
+	if err := validateNginxSnippet(data); err != nil {
+		return nil, err
+	}
+
+	nginxSnippetMu.Lock()
+	defer nginxSnippetMu.Unlock()
+
+	snippet, err := s.GetSnippet(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSnippetNameFree(data.Name, id); err != nil {
+		return nil, err
+	}
+	snippet.Name = data.Name
+	snippet.Description = data.Description
+	snippet.Content = data.Content
+
+	updates, err := s.sitesUsing(id, func(snippets []models.NginxSnippet) []models.NginxSnippet {
+		for i := range snippets {
+			if snippets[i].ID == id {
+				snippets[i] = *snippet
+			}
+		}
+		return snippets
+	})
+	if err != nil {
+		return nil, err
+	}
+	restore, err := s.applySites(ctx, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := models.DB.Save(snippet).Error; err != nil {
+		restore()
+		return nil, fmt.Errorf("failed to update snippet: %w", err)
+	}
+	return snippet, nil
+}
+
+// DeleteSnippet removes a snippet from every site it is attached to and deletes it
+func (s *NginxSnippetService) DeleteSnippet(ctx context.Context, id uint) error {
+	nginxSnippetMu.Lock()
+	defer nginxSnippetMu.Unlock()
+
+	if _, err := s.GetSnippet(id); err != nil {
+		return err
+	}
+
+	updates, err := s.sitesUsing(id, func(snippets []models.NginxSnippet) []models.NginxSnippet {
+		return withoutSnippet(snippets, id)
+	})
+	if err != nil {
+		return err
+	}
+	restore, err := s.applySites(ctx, updates)
+	if err != nil {
+		return err
+	}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("snippet_id = ?", id).Delete(&models.NginxSiteSnippet{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.NginxSnippet{}, id).Error
+	})
+	if err != nil {
+		restore()
+		return err
+	}
+	return nil
+}
+
+// GetSiteSnippets returns the snippets attached to a site, in the order they are rendered
+func (s *NginxSnippetService) GetSiteSnippets(domain string) ([]models.NginxSnippet, error) {
+	if err := ValidateDomain(domain); err != nil {
+		return nil, err
+	}
+	return siteSnippets(domain)
+}
+
+// AttachSnippet attaches a snippet to a site and regenerates its config. Sites
+// owned by a client need the client's LimitDirectiveSnippets permission.
+func (s *NginxSnippetService) AttachSnippet(ctx context.Context, domain string, id uint) error {
+	nginxSnippetMu.Lock()
+	defer nginxSnippetMu.Unlock()
+
+	site, err := s.nginx.GetSite(domain)
+	if err != nil {
+		return err
+	}
+	snippet, err := s.GetSnippet(id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkSnippetsAllowed(site.Config); err != nil {
+		return err
+	}
+
+	snippets, err := siteSnippets(domain)
+	if err != nil {
+		return err
+	}
+	for _, attached := range snippets {
+		if attached.ID == id {
+			return nil // Already attached
+		}
+	}
+
+	restore, err := s.applySites(ctx, map[string][]models.NginxSnippet{domain: append(snippets, *snippet)})
+	if err != nil {
+		return err
+	}
+	if err := models.DB.Create(&models.NginxSiteSnippet{Domain: domain, SnippetID: id}).Error; err != nil {
+		restore()
+		return fmt.Errorf("failed to attach snippet: %w", err)
+	}
+	return nil
+}
+
+// DetachSnippet removes a snippet from a site and regenerates its config
+func (s *NginxSnippetService) DetachSnippet(ctx context.Context, domain string, id uint) error {
+	nginxSnippetMu.Lock()
+	defer nginxSnippetMu.Unlock()
+
+	if _, err := s.nginx.GetSite(domain); err != nil {
+		return err
+	}
+	snippets, err := siteSnippets(domain)
+	if err != nil {
+		return err
+	}
+	remaining := withoutSnippet(snippets, id)
+	if len(remaining) == len(snippets) {
+		return nil // Not attached
+	}
+
+	restore, err := s.applySites(ctx, map[string][]models.NginxSnippet{domain: remaining})
+	if err != nil {
+		return err
+	}
+	if err := models.DB.Where("domain = ? AND snippet_id = ?", domain, id).Delete(&models.NginxSiteSnippet{}).Error; err != nil {
+		restore()
+		return fmt.Errorf("failed to detach snippet: %w", err)
+	}
+	return nil
+}
+
+// ForgetSite drops the snippet attachments of a deleted site
+func (s *NginxSnippetService) ForgetSite(domain string) error {
+	return models.DB.Where("domain = ?", domain).Delete(&models.NginxSiteSnippet{}).Error
+}
+
+// sitesUsing returns the snippets every site with snippet id attached should
+// render, after change has been applied to its current list
+func (s *NginxSnippetService) sitesUsing(id uint, change func([]models.NginxSnippet) []models.NginxSnippet) (map[string][]models.NginxSnippet, error) {
+	var domains []string
+	if err := models.DB.Model(&models.NginxSiteSnippet{}).Where("snippet_id = ?", id).Pluck("domain", &domains).Error; err != nil {
+		return nil, err
+	}
+
+	updates := map[string][]models.NginxSnippet{}
+	for _, domain := range domains {
+		snippets, err := siteSnippets(domain)
+		if err != nil {
+			return nil, err
+		}
+		updates[domain] = change(snippets)
+	}
+	return updates, nil
+}
+
+// applySites renders snippets into the config of each site and tests the result.
+// When anything fails every site is put back; on success the returned function
+// does the same, for callers whose own follow-up step fails.
+func (s *NginxSnippetService) applySites(ctx context.Context, updates map[string][]models.NginxSnippet) (func(), error) {
+	previous := map[string]string{}
+	restore := func() {
+		for domain, config := range previous {
+			s.nginx.UpdateSite(domain, config)
+		}
+	}
+
+	for domain, snippets := range updates {
+		config, err := s.nginx.GetSiteConfig(domain)
+		if err != nil {
+			// Sites removed outside the panel have nothing left to regenerate
+			continue
+		}
+		rendered, err := renderSiteSnippets(config, snippets)
+		if err != nil {
+			restore()
+			return nil, fmt.Errorf("%s: %w", domain, err)
+		}
+		if err := s.nginx.UpdateSite(domain, rendered); err != nil {
+			restore()
+			return nil, err
+		}
+		previous[domain] = config
+	}
+
+	if len(previous) > 0 {
+		if err := s.nginx.TestConfig(ctx); err != nil {
+			restore()
+			return nil, err
+		}
+	}
+	return restore, nil
+}
+
+// siteSnippets loads the snippets attached to domain in attachment order
+func siteSnippets(domain string) ([]models.NginxSnippet, error) {
+	var attachments []models.NginxSiteSnippet
+	if err := models.DB.Preload("Snippet").Where("domain = ?", domain).Order("id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	snippets := make([]models.NginxSnippet, 0, len(attachments))
+	for _, attachment := range attachments {
+		snippets = append(snippets, attachment.Snippet)
+	}
+	return snippets, nil
+}
+
+func withoutSnippet(snippets []models.NginxSnippet, id uint) []models.NginxSnippet {
+	remaining := make([]models.NginxSnippet, 0, len(snippets))
+	for _, snippet := range snippets {
+		if snippet.ID != id {
+			remaining = append(remaining, snippet)
+		}
+	}
+	return remaining
+}
+
+// checkSnippetsAllowed rejects the attachment when the site belongs to a client
+// whose plan does not include directive snippets. Sites no client owns are the
+// admin's and always allowed.
+func (s *NginxSnippetService) checkSnippetsAllowed(config string) error {
+	owner, err := s.nginx.SiteOwner(config)
+	if err != nil {
+		return err
+	}
+	if owner != nil && !owner.ClientLimits.LimitDirectiveSnippets {
+		return ErrSnippetsNotAllowed
+	}
+	return nil
+}
+
+func checkSnippetNameFree(name string, id uint) error {
+	var count int64
+	if err := models.DB.Model(&models.NginxSnippet{}).Where("name = ? AND id <> ?", name, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrNginxSnippetExists
+	}
+	return nil
+}
+
+func validateNginxSnippet(data *NginxSnippetData) error {
+	data.Name = strings.TrimSpace(data.Name)
+	data.Content = strings.TrimSpace(data.Content)
+
+	if !snippetNamePattern.MatchString(data.Name) {
+		return fmt.Errorf("%w: name may only contain lowercase letters, digits, - and _", ErrInvalidNginxSnippet)
+	}
+	if data.Content == "" {
+		return fmt.Errorf("%w: content is required", ErrInvalidNginxSnippet)
+	}
+	if snippetBlockPattern.MatchString(data.Content) {
+		return fmt.Errorf("%w: content may not contain the r-panel snippet markers", ErrInvalidNginxSnippet)
+	}
+
+	// Unbalanced braces would close the server block early or swallow the rest of it
+	depth := 0
+	for _, line := range strings.Split(data.Content, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		for _, r := range line {
+			switch r {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			if depth < 0 {
+				return fmt.Errorf("%w: unbalanced braces", ErrInvalidNginxSnippet)
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("%w: unbalanced braces", ErrInvalidNginxSnippet)
+	}
+	return nil
+}
+
+// renderSiteSnippets replaces the panel-managed snippet block of every server
+// block in config with snippets. No snippets removes the blocks.
+func renderSiteSnippets(config string, snippets []models.NginxSnippet) (string, error) {
+	var out []string
+	inBlock := false
+	for _, line := range strings.Split(config, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == snippetBlockBegin:
+			inBlock = true
+		case trimmed == snippetBlockEnd:
+			inBlock = false
+		case !inBlock:
+			out = append(out, line)
+		}
+	}
+	if len(snippets) == 0 {
+		return strings.Join(out, "\n"), nil
+	}
+
+	var rendered []string
+	servers := 0
+	inRedirect := false
+	for _, line := range out {
+		rendered = append(rendered, line)
+		// The force-https redirect server only redirects, snippets belong to the site
+		switch strings.TrimSpace(line) {
+		case forceHTTPSBegin:
+			inRedirect = true
+		case forceHTTPSEnd:
+			inRedirect = false
+		}
+		match := serverBlockPattern.FindStringSubmatch(line)
+		if match == nil || inRedirect {
+			continue
+		}
+		servers++
+
+		indent := match[1] + "    "
+		rendered = append(rendered, indent+snippetBlockBegin)
+		for _, snippet := range snippets {
+			rendered = append(rendered, indent+"# snippet: "+snippet.Name)
+			for _, content := range strings.Split(snippet.Content, "\n") {
+				if strings.TrimSpace(content) == "" {
+					rendered = append(rendered, "")
+				} else {
+					rendered = append(rendered, indent+content)
+				}
+			}
+		}
+		rendered = append(rendered, indent+snippetBlockEnd)
+	}
+	if servers == 0 {
+		return "", fmt.Errorf("%w: site config has no server block to add snippets to", ErrInvalidNginxSnippet)
+	}
+	return strings.Join(rendered, "\n"), nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const snippetSite = "/etc/nginx/sites-available/example.com"
+
+func TestRenderSiteSnippets(t *testing.T) {
+	config := "server {\n    listen 80;\n}\n"
+	snippets := []models.NginxSnippet{
+		{Name: "gzip", Content: "gzip on;\ngzip_types text/css;"},
+		{Name: "deny-git", Content: "location ~ /\\.git {\n    deny all;\n}"},
+	}
+
+	rendered, err := renderSiteSnippets(config, snippets)
+	require.NoError(t, err)
+	assert.Equal(t, `server {
+    # BEGIN r-panel snippets
+    # snippet: gzip
+    gzip on;
+    gzip_types text/css;
+    # snippet: deny-git
+    location ~ /\.git {
+        deny all;
+    }
+    # END r-panel snippets
+    listen 80;
+}
+`, rendered)
+
+	// Rendering again replaces the block instead of adding another
+	again, err := renderSiteSnippets(rendered, snippets[:1])
+	require.NoError(t, err)
+	assert.Equal(t, "server {\n    # BEGIN r-panel snippets\n    # snippet: gzip\n    gzip on;\n    gzip_types text/css;\n    # END r-panel snippets\n    listen 80;\n}\n", again)
+
+	removed, err := renderSiteSnippets(again, nil)
+	require.NoError(t, err)
+	assert.Equal(t, config, removed)
+
+	_, err = renderSiteSnippets("# empty\n", snippets)
+	assert.ErrorIs(t, err, ErrInvalidNginxSnippet)
+}
+
+func TestValidateNginxSnippet(t *testing.T) {
+	for _, data := range []NginxSnippetData{
+		{Name: "Bad Name", Content: "gzip on;"},
+		{Name: "gzip", Content: "  "},
+		{Name: "escape", Content: "}\nserver {"},
+		{Name: "open", Content: "location / {"},
+		{Name: "markers", Content: "# END r-panel snippets"},
+	} {
+		assert.ErrorIs(t, validateNginxSnippet(&data), ErrInvalidNginxSnippet, data.Name)
+	}
+	assert.NoError(t, validateNginxSnippet(&NginxSnippetData{Name: "cache", Content: "location /static { expires 7d; } # {"}))
+}
+
+func TestNginxSnippetLifecycle(t *testing.T) {
+	setupTestDB(t)
+	nginx, fsys, runner := newFakeNginx()
+	runner.on("nginx -t", "", nil)
+	service := NewNginxSnippetService(nginx)
+
+	require.NoError(t, nginx.CreateSite("example.com", "server {\n    listen 80;\n}\n"))
+
+	snippet, err := service.CreateSnippet(&NginxSnippetData{Name: "gzip", Content: "gzip on;"})
+	require.NoError(t, err)
+	_, err = service.CreateSnippet(&NginxSnippetData{Name: "gzip", Content: "gzip off;"})
+	assert.ErrorIs(t, err, ErrNginxSnippetExists)
+
+	require.NoError(t, service.AttachSnippet(context.Background(), "example.com", snippet.ID))
+	require.NoError(t, service.AttachSnippet(context.Background(), "example.com", snippet.ID), "attaching twice is a no-op")
+	assert.Contains(t, string(fsys.files[snippetSite]), "    gzip on;\n")
+	assert.ErrorIs(t, service.AttachSnippet(context.Background(), "missing.com", snippet.ID), ErrSiteNotFound)
+
+	attached, err := service.GetSiteSnippets("example.com")
+	require.NoError(t, err)
+	require.Len(t, attached, 1)
+
+	// Updating the snippet regenerates the sites using it
+	_, err = service.UpdateSnippet(context.Background(), snippet.ID, &NginxSnippetData{Name: "gzip", Content: "gzip on;\ngzip_comp_level 5;"})
+	require.NoError(t, err)
+	assert.Contains(t, string(fsys.files[snippetSite]), "    gzip_comp_level 5;\n")
+
+	// A failed config test restores the sites and keeps the snippet unchanged
+	runner.on("nginx -t", "nginx: [emerg] unknown directive", errors.New("exit status 1"))
+	before := string(fsys.files[snippetSite])
+	_, err = service.UpdateSnippet(context.Background(), snippet.ID, &NginxSnippetData{Name: "gzip", Content: "gzip maybe;"})
+	assert.ErrorIs(t, err, ErrNginxConfigInvalid)
+	assert.Equal(t, before, string(fsys.files[snippetSite]))
+	stored, err := service.GetSnippet(snippet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "gzip on;\ngzip_comp_level 5;", stored.Content)
+	runner.on("nginx -t", "", nil)
+
+	require.NoError(t, service.DeleteSnippet(context.Background(), snippet.ID))
+	assert.Equal(t, "server {\n    listen 80;\n}\n", string(fsys.files[snippetSite]))
+	var count int64
+	require.NoError(t, models.DB.Model(&models.NginxSiteSnippet{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestNginxSnippetRequiresClientPermission(t *testing.T) {
+	cfg := setupTestDB(t)
+	t.Setenv("SKIP_LINUX_USER", "true")
+	client, err := NewClientService(cfg).CreateClient(&CreateClientData{
+		Username:    "snippetclient",
+		Password:    "testpass123",
+		ContactName: "Snippet Client",
+		Email:       "snippetclient@example.com",
+	})
+	require.NoError(t, err)
+
+	nginx, _, runner := newFakeNginx()
+	runner.on("nginx -t", "", nil)
+	service := NewNginxSnippetService(nginx)
+	require.NoError(t, nginx.CreateSite("example.com", nginx.GenerateSiteConfig("example.com", "/home/"+client.LinuxUsername+"/web", "pool.sock")))
+
+	snippet, err := service.CreateSnippet(&NginxSnippetData{Name: "gzip", Content: "gzip on;"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.AttachSnippet(context.Background(), "example.com", snippet.ID), ErrSnippetsNotAllowed)
+
+	require.NoError(t, models.DB.Model(&models.ClientLimits{}).Where("client_id = ?", client.ID).
+		Update("limit_directive_snippets", true).Error)
+	assert.NoError(t, service.AttachSnippet(context.Background(), "example.com", snippet.ID))
+}
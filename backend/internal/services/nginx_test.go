@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeNginx() (*NginxService, *fakeFileSystem, *fakeCommandRunner) {
+	fsys := newFakeFileSystem("/etc/nginx/sites-available", "/etc/nginx/sites-enabled", "/var/log/nginx")
+	runner := newFakeCommandRunner()
+	service := NewNginxServiceWithDeps(fsys, runner, "/etc/nginx/sites-available", "/etc/nginx/sites-enabled", "/var/log/nginx")
+	return service, fsys, runner
+}
+
+func TestNginxServiceSiteLifecycle(t *testing.T) {
+	service, fsys, _ := newFakeNginx()
+	config := service.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock")
+
+	require.NoError(t, service.CreateSite("example.com", config))
+	assert.Equal(t, config, string(fsys.files["/etc/nginx/sites-available/example.com"]))
+	assert.Error(t, service.CreateSite("example.com", config), "duplicate site should fail")
+
+	site, err := service.GetSite("example.com")
+	require.NoError(t, err)
+	assert.False(t, site.Enabled)
+
+	// Enable creates the symlink, enabling twice is a no-op
+	require.NoError(t, service.EnableSite("example.com"))
+	require.NoError(t, service.EnableSite("example.com"))
+	assert.Equal(t, "/etc/nginx/sites-available/example.com", fsys.links["/etc/nginx/sites-enabled/example.com"])
+
+	sites, err := service.GetSites()
+	require.NoError(t, err)
+	require.Len(t, sites, 1)
+	assert.True(t, sites[0].Enabled)
+
+	owned, err := service.GetSitesByUser("client1")
+	require.NoError(t, err)
+	assert.Len(t, owned, 1)
+
+	// Disable removes the symlink only
+	require.NoError(t, service.DisableSite("example.com"))
+	require.NoError(t, service.DisableSite("example.com"))
+	assert.NotContains(t, fsys.links, "/etc/nginx/sites-enabled/example.com")
+	assert.Contains(t, fsys.files, "/etc/nginx/sites-available/example.com")
+
+	require.NoError(t, service.EnableSite("example.com"))
+	require.NoError(t, service.DeleteSite("example.com"))
+	assert.Empty(t, fsys.files)
+	assert.Empty(t, fsys.links)
+}
+
+func TestNginxServiceMissingSite(t *testing.T) {
+	service, _, _ := newFakeNginx()
+
+	_, err := service.GetSite("missing.com")
+	assert.Error(t, err)
+	assert.Error(t, service.EnableSite("missing.com"))
+	assert.Error(t, service.UpdateSite("missing.com", "server {}"))
+	assert.Error(t, service.DeleteSite("missing.com"))
+}
+
+func TestNginxServiceRejectsTraversalDomains(t *testing.T) {
+	service, fsys, _ := newFakeNginx()
+	fsys.files["/etc/nginx/nginx.conf"] = []byte("main")
+	fsys.files["/etc/nginx/sites-available/example.com"] = []byte("site")
+
+	domains := []string{
+		"",
+		".",
+		"..",
+		"../nginx.conf",
+		"../../etc/nginx/nginx.conf",
+		"/etc/nginx/nginx.conf",
+		"example.com/../../nginx.conf",
+		`..\nginx.conf`,
+		"a/b",
+		"example..com",
+		".example.com",
+		"example.com.",
+		"-example.com",
+		"example.com\x00",
+		"exa mple.com",
+		strings.Repeat("a", 254),
+	}
+	for _, domain := range domains {
+		t.Run(domain, func(t *testing.T) {
+			_, err := service.GetSite(domain)
+			assert.ErrorIs(t, err, ErrInvalidDomain)
+			_, err = service.GetSiteConfig(domain)
+			assert.ErrorIs(t, err, ErrInvalidDomain)
+			assert.ErrorIs(t, service.CreateSite(domain, "evil"), ErrInvalidDomain)
+			assert.ErrorIs(t, service.UpdateSite(domain, "evil"), ErrInvalidDomain)
+			assert.ErrorIs(t, service.EnableSite(domain), ErrInvalidDomain)
+			assert.ErrorIs(t, service.DisableSite(domain), ErrInvalidDomain)
+			assert.ErrorIs(t, service.DeleteSite(domain), ErrInvalidDomain)
+		})
+	}
+
+	// Nothing outside the site directories was touched
+	assert.Equal(t, "main", string(fsys.files["/etc/nginx/nginx.conf"]))
+	assert.Equal(t, "site", string(fsys.files["/etc/nginx/sites-available/example.com"]))
+	assert.Len(t, fsys.files, 2)
+	assert.Empty(t, fsys.links)
+
+	for _, domain := range []string{"example.com", "www.example-site.co.uk", "default", "_", "xn--bcher-kva.example"} {
+		assert.NoError(t, ValidateDomain(domain), domain)
+	}
+}
+
+func TestNginxServiceCommands(t *testing.T) {
+	service, _, runner := newFakeNginx()
+	runner.on("nginx -t", "nginx: configuration file /etc/nginx/nginx.conf test is successful", nil)
+	runner.on("systemctl reload nginx", "", nil)
+	runner.on("tail -n 2 /var/log/nginx/error.log", "line one\n\nline two\n", nil)
+
+	require.NoError(t, service.TestConfig(context.Background()))
+	require.NoError(t, service.Reload(context.Background()))
+
+	lines, err := service.GetLogs(context.Background(), "error", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line one", "line two"}, lines)
+
+	assert.Equal(t, []string{"nginx -t", "nginx -t", "systemctl reload nginx", "tail -n 2 /var/log/nginx/error.log"}, runner.calls)
+
+	runner.on("nginx -t", "unknown directive \"foo\"", errors.New("exit status 1"))
+	err = service.TestConfig(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown directive")
+}
+
+func TestNginxServiceReloadRequiresPassingConfigTest(t *testing.T) {
+	service, _, runner := newFakeNginx()
+	runner.on("nginx -t", "nginx: [emerg] unknown directive \"foo\"", errors.New("exit status 1"))
+	runner.on("systemctl reload nginx", "", nil)
+
+	err := service.Reload(context.Background())
+	assert.ErrorIs(t, err, ErrNginxConfigInvalid)
+	assert.Contains(t, err.Error(), "unknown directive \"foo\"")
+	assert.Equal(t, []string{"nginx -t"}, runner.calls, "reload must not run after a failed config test")
+
+	// Forcing skips the test
+	require.NoError(t, service.ForceReload(context.Background()))
+	assert.Equal(t, []string{"nginx -t", "systemctl reload nginx"}, runner.calls)
+}
+
+func TestNginxServiceRestart(t *testing.T) {
+	service, _, runner := newFakeNginx()
+	runner.on("nginx -t", "nginx: configuration file /etc/nginx/nginx.conf test is successful", nil)
+	runner.on("systemctl restart nginx", "", nil)
+
+	require.NoError(t, service.Restart(context.Background()))
+	assert.Equal(t, []string{"nginx -t", "systemctl restart nginx"}, runner.calls)
+
+	runner.on("nginx -t", "nginx: [emerg] unknown directive \"foo\"", errors.New("exit status 1"))
+	assert.ErrorIs(t, service.Restart(context.Background()), ErrNginxConfigInvalid)
+	assert.Equal(t, []string{"nginx -t", "systemctl restart nginx", "nginx -t"}, runner.calls, "restart must not run after a failed config test")
+}
+
+func TestNginxServiceReportsSystemctlOutput(t *testing.T) {
+	service, _, runner := newFakeNginx()
+	runner.on("systemctl reload nginx", "nginx.service is not active, cannot reload.\n", errors.New("exit status 1"))
+	runner.on("systemctl restart nginx", "", errors.New("exit status 1"))
+
+	err := service.ForceReload(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "systemctl reload nginx failed: nginx.service is not active, cannot reload.", err.Error())
+
+	// Without output the exit error is all there is to report
+	err = service.ForceRestart(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "systemctl restart nginx failed: exit status 1", err.Error())
+}
+
+func TestNginxServiceTracksPendingReload(t *testing.T) {
+	setupTestDB(t)
+	service, _, runner := newFakeNginx()
+	runner.on("nginx -t", "", nil)
+	runner.on("systemctl reload nginx", "", errors.New("exit status 1"))
+	config := service.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock")
+
+	// A site Nginx does not load yet changes nothing
+	require.NoError(t, service.CreateSite("example.com", config))
+	require.NoError(t, service.UpdateSite("example.com", config+"# changed\n"))
+	state, err := service.ReloadState()
+	require.NoError(t, err)
+	assert.False(t, state.ReloadPending)
+
+	require.NoError(t, service.EnableSite("example.com"))
+	state, err = service.ReloadState()
+	require.NoError(t, err)
+	assert.True(t, state.ReloadPending)
+	require.NotNil(t, state.ChangedSince)
+	since := *state.ChangedSince
+
+	// Only the first change is kept, and a failed reload keeps it
+	require.NoError(t, service.UpdateSite("example.com", config))
+	assert.Error(t, service.Reload(context.Background()))
+	state, err = service.ReloadState()
+	require.NoError(t, err)
+	assert.True(t, state.ReloadPending)
+	assert.Equal(t, since, *state.ChangedSince)
+
+	runner.on("systemctl reload nginx", "", nil)
+	require.NoError(t, service.Reload(context.Background()))
+	state, err = service.ReloadState()
+	require.NoError(t, err)
+	assert.Equal(t, ReloadState{}, state)
+
+	require.NoError(t, service.DeleteSite("example.com"))
+	state, err = service.ReloadState()
+	require.NoError(t, err)
+	assert.True(t, state.ReloadPending, "deleting an enabled site needs a reload")
+}
+
+func TestNginxZoneName(t *testing.T) {
+	assert.Equal(t, "shop_dexample_dcom", nginxZoneName("shop.example.com"))
+
+	// Domains that only differ in their separators get their own zones
+	seen := map[string]string{}
+	for _, domain := range []string{"a-b.com", "a.b.com", "a_b.com", "a_b_dcom", "a__b.com", "a_.b.com", "a._b.com", "a*b.com"} {
+		name := nginxZoneName(domain)
+		assert.NotContains(t, seen, name, "%s and %s", domain, seen[name])
+		seen[name] = domain
+	}
+}
+
+func TestNginxServiceGenerateClientSiteConfigBandwidth(t *testing.T) {
+	service, _, _ := newFakeNginx()
+
+	t.Run("caps from the client plan become directives", func(t *testing.T) {
+		limits := models.ClientLimits{LimitWebRate: 512, LimitWebRateAfter: 2048, LimitWebConnections: 20}
+		config, err := service.GenerateClientSiteConfig("shop.example.com", "/home/client1/web", "client1.sock", SiteBandwidthFor(limits))
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(config, "limit_conn_zone $binary_remote_addr zone=conn_shop_dexample_dcom:10m;\n"))
+		assert.Contains(t, config, "    limit_conn conn_shop_dexample_dcom 20;\n")
+		assert.Contains(t, config, "    limit_rate 512k;\n")
+		assert.Contains(t, config, "    limit_rate_after 2048k;\n")
+	})
+
+	t.Run("no caps generate the default config", func(t *testing.T) {
+		config, err := service.GenerateClientSiteConfig("example.com", "/home/client1/web", "client1.sock", SiteBandwidth{})
+		require.NoError(t, err)
+		assert.Equal(t, service.GenerateSiteConfig("example.com", "/home/client1/web", "client1.sock"), config)
+		assert.NotContains(t, config, "limit_")
+	})
+
+	t.Run("invalid caps are rejected", func(t *testing.T) {
+		for _, bandwidth := range []SiteBandwidth{
+			{RateKB: -1},
+			{RateAfterKB: 1024},
+			{Connections: -5},
+			{Connections: maxWebConnections + 1},
+			{RateKB: maxWebRateKB + 1},
+		} {
+			_, err := service.GenerateClientSiteConfig("example.com", "/home/client1/web", "client1.sock", bandwidth)
+			assert.ErrorIs(t, err, ErrInvalidBandwidthLimit, "%+v", bandwidth)
+		}
+	})
+}
+
+func TestSiteReferencesUserUsesHomeBase(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	siteConfig := "server {\n    root /srv/clients/client1/web;\n}\n"
+	assert.False(t, siteReferencesUser(siteConfig, "client1", config.DefaultHomeBase))
+	assert.True(t, siteReferencesUser(siteConfig, "client1", "/srv/clients"))
+	assert.False(t, siteReferencesUser(siteConfig, "client", "/srv/clients"))
+
+	cfg := &config.Config{}
+	cfg.Paths.HomeBase = "/srv/clients"
+	assert.Equal(t, "/srv/clients", NewNginxService(cfg).homeBase)
+	assert.Equal(t, config.DefaultHomeBase, NewNginxService(&config.Config{}).homeBase)
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidProbeMode = errors.New("invalid check: use http or tcp")
+
+// Upstream probes: how long each may take, how many run at once and how many
+// backends of one site are probed at most
+const (
+	upstreamProbeTimeout = 3 * time.Second
+	maxConcurrentProbes  = 8
+	maxUpstreamBackends  = 64
+)
+
+// Upstream probe modes
+const (
+	UpstreamProbeHTTP = "http"
+	UpstreamProbeTCP  = "tcp"
+)
+
+// Upstream backend states
+const (
+	UpstreamBackendUp      = "up"
+	UpstreamBackendDown    = "down"
+	UpstreamBackendSkipped = "skipped"
+)
+
+var (
+	upstreamBlockPattern  = regexp.MustCompile(`^\s*upstream\s+([^\s{]+)\s*\{`)
+	upstreamServerPattern = regexp.MustCompile(`^\s*server\s+([^\s;]+)[^;]*;`)
+	proxyPassPattern      = regexp.MustCompile(`^\s*proxy_pass\s+([^\s;]+)\s*;`)
+)
+
+// UpstreamBackend is a backend a site proxies to and the outcome of probing it
+type UpstreamBackend struct {
+	Upstream   string  `json:"upstream,omitempty"` // name of the upstream block it belongs to
+	Address    string  `json:"address"`            // host:port or unix:/path
+	Scheme     string  `json:"scheme"`             // http or https, from proxy_pass
+	Status     string  `json:"status"`             // up, down or skipped
+	StatusCode int     `json:"status_code,omitempty"`
+	LatencyMS  float64 `json:"latency_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// GetUpstreamHealth finds the backends the site config of domain proxies to,
+// through proxy_pass directly or an upstream block in the same file, and probes
+// each of them. mode http sends a GET / and counts any response as up, mode tcp
+// only connects.
+func (s *NginxService) GetUpstreamHealth(domain, mode string) ([]UpstreamBackend, error) {
+	switch mode {
+	case "":
+		mode = UpstreamProbeHTTP
+	case UpstreamProbeHTTP, UpstreamProbeTCP:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidProbeMode, mode)
+	}
+	if _, err := s.GetSite(domain); err != nil {
+		return nil, err
+	}
+	config, err := s.GetSiteConfig(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	backends := ParseUpstreamBackends(config)
+	if len(backends) > maxUpstreamBackends {
+		backends = backends[:maxUpstreamBackends]
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxConcurrentProbes)
+	for i := range backends {
+		if backends[i].Status == UpstreamBackendSkipped {
+			continue
+		}
+		wg.Add(1)
+		go func(backend *UpstreamBackend) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			probeUpstream(backend, mode)
+		}(&backends[i])
+	}
+	wg.Wait()
+	return backends, nil
+}
+
+// ParseUpstreamBackends returns the backends of every proxy_pass in config,
+// expanding upstream blocks defined in the same config. Backends named through
+// variables cannot be resolved without a request and are marked skipped.
+func ParseUpstreamBackends(config string) []UpstreamBackend {
+	upstreams := map[string][]string{}
+	var proxyPasses []string
+	current := ""
+	for _, line := range strings.Split(config, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		if match := upstreamBlockPattern.FindStringSubmatch(line); match != nil {
+			current = match[1]
+			upstreams[current] = nil
+			continue
+		}
+		if current != "" {
+			if match := upstreamServerPattern.FindStringSubmatch(line); match != nil {
+				upstreams[current] = append(upstreams[current], match[1])
+			}
+			if strings.Contains(line, "}") {
+				current = ""
+			}
+			continue
+		}
+		if match := proxyPassPattern.FindStringSubmatch(line); match != nil {
+			proxyPasses = append(proxyPasses, match[1])
+		}
+	}
+
+	seen := map[string]bool{}
+	backends := []UpstreamBackend{}
+	add := func(backend UpstreamBackend) {
+		key := backend.Scheme + "://" + backend.Address
+		if !seen[key] {
+			seen[key] = true
+			backends = append(backends, backend)
+		}
+	}
+	for _, target := range proxyPasses {
+		if strings.Contains(target, "$") {
+			add(UpstreamBackend{Address: target, Status: UpstreamBackendSkipped, Error: "proxy_pass uses variables"})
+			continue
+		}
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			add(UpstreamBackend{Address: target, Status: UpstreamBackendSkipped, Error: "not an http or https proxy_pass"})
+			continue
+		}
+
+		// proxy_pass http://unix:/path/to.sock:/uri
+		if u.Host == "unix:" {
+			socket, _, _ := strings.Cut(u.Path, ":")
+			add(UpstreamBackend{Address: "unix:" + socket, Scheme: u.Scheme})
+			continue
+		}
+		if servers, ok := upstreams[u.Host]; ok {
+			for _, server := range servers {
+				add(UpstreamBackend{Upstream: u.Host, Address: withDefaultPort(server, u.Scheme), Scheme: u.Scheme})
+			}
+			continue
+		}
+		add(UpstreamBackend{Address: withDefaultPort(u.Host, u.Scheme), Scheme: u.Scheme})
+	}
+	sort.SliceStable(backends, func(i, j int) bool { return backends[i].Upstream < backends[j].Upstream })
+	return backends
+}
+
+// withDefaultPort adds the port nginx assumes for scheme to an address without one
+func withDefaultPort(address, scheme string) string {
+	if strings.HasPrefix(address, "unix:") {
+		return address
+	}
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	port := "80"
+	if scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(strings.Trim(address, "[]"), port)
+}
+
+// probeUpstream checks backend and records the outcome on it
+func probeUpstream(backend *UpstreamBackend, mode string) {
+	network, address := "tcp", backend.Address
+	if socket, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", socket
+	}
+
+	start := time.Now()
+	defer func() { backend.LatencyMS = float64(time.Since(start).Microseconds()) / 1000 }()
+
+	if mode == UpstreamProbeTCP {
+		conn, err := net.DialTimeout(network, address, upstreamProbeTimeout)
+		if err != nil {
+			backend.Status, backend.Error = UpstreamBackendDown, err.Error()
+			return
+		}
+		conn.Close()
+		backend.Status = UpstreamBackendUp
+		return
+	}
+
+	dialer := &net.Dialer{Timeout: upstreamProbeTimeout}
+	client := &http.Client{
+		Timeout: upstreamProbeTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, address)
+			},
+			// Backends behind a proxy often have self-signed certificates, and
+			// only reachability is checked here
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		// A redirect is an answer, it need not be followed
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	host := "localhost"
+	if network == "tcp" {
+		host = address
+	}
+	resp, err := client.Get(backend.Scheme + "://" + host + "/")
+	if err != nil {
+		backend.Status, backend.Error = UpstreamBackendDown, err.Error()
+		return
+	}
+	resp.Body.Close()
+	backend.Status = UpstreamBackendUp
+	backend.StatusCode = resp.StatusCode
+}
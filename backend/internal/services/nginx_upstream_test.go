@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUpstreamBackends(t *testing.T) {
+	config := `upstream app {
+    server 10.0.0.1:8080 weight=5;
+    server backend.internal max_fails=3; # default port
+    server unix:/run/app.sock;
+}
+
+server {
+    listen 80;
+    location / {
+        proxy_pass http://app;
+    }
+    location /api/ {
+        proxy_pass https://api.internal/v1/;
+    }
+    location /ws/ {
+        proxy_pass http://unix:/run/ws.sock:/socket;
+    }
+    location /dyn/ {
+        proxy_pass http://$backend;
+    }
+    location /again/ {
+        proxy_pass http://app;
+    }
+    # proxy_pass http://commented.out;
+}`
+
+	backends := ParseUpstreamBackends(config)
+	var addresses []string
+	for _, backend := range backends {
+		addresses = append(addresses, backend.Scheme+" "+backend.Address)
+	}
+	assert.Equal(t, []string{
+		"https api.internal:443",
+		"http unix:/run/ws.sock",
+		" http://$backend",
+		"http 10.0.0.1:8080",
+		"http backend.internal:80",
+		"http unix:/run/app.sock",
+	}, addresses)
+	assert.Equal(t, "app", backends[3].Upstream)
+	assert.Equal(t, UpstreamBackendSkipped, backends[2].Status)
+
+	assert.Empty(t, ParseUpstreamBackends("server {\n    root /var/www;\n}\n"))
+}
+
+func TestNginxServiceGetUpstreamHealth(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer up.Close()
+
+	// A port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down := listener.Addr().String()
+	listener.Close()
+
+	service, _, _ := newFakeNginx()
+	require.NoError(t, service.CreateSite("app.example.com", fmt.Sprintf(`upstream app {
+    server %s;
+    server %s;
+}
+server {
+    location / { 
+        proxy_pass http://app;
+    }
+}`, up.Listener.Addr(), down)))
+
+	backends, err := service.GetUpstreamHealth("app.example.com", "")
+	require.NoError(t, err)
+	require.Len(t, backends, 2)
+	assert.Equal(t, UpstreamBackendUp, backends[0].Status)
+	assert.Equal(t, http.StatusServiceUnavailable, backends[0].StatusCode, "an error response still means the backend is alive")
+	assert.Equal(t, UpstreamBackendDown, backends[1].Status)
+	assert.NotEmpty(t, backends[1].Error)
+
+	backends, err = service.GetUpstreamHealth("app.example.com", UpstreamProbeTCP)
+	require.NoError(t, err)
+	assert.Equal(t, UpstreamBackendUp, backends[0].Status)
+	assert.Zero(t, backends[0].StatusCode)
+	assert.Equal(t, UpstreamBackendDown, backends[1].Status)
+
+	_, err = service.GetUpstreamHealth("app.example.com", "icmp")
+	assert.ErrorIs(t, err, ErrInvalidProbeMode)
+	_, err = service.GetUpstreamHealth("missing.example.com", "")
+	assert.ErrorIs(t, err, ErrSiteNotFound)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/textproto"
+	"time"
+
+	"r-panel/internal/config"
+)
+
+var ErrNoNotificationRecipients = errors.New("no notification recipients configured")
+
+// NotificationEvent is a kind of event admins can opt in to be emailed about
+type NotificationEvent string
+
+const (
+	EventBackupFailed NotificationEvent = "backup_failed"
+	EventQuotaWarning NotificationEvent = "quota_warning"
+	EventCertExpiry   NotificationEvent = "cert_expiry"
+)
+
+// defaultNotificationRetryDelay is the first delay between delivery attempts; it doubles per attempt
+const defaultNotificationRetryDelay = 2 * time.Second
+
+// EmailSender delivers a single email, SMTPService in production
+type EmailSender interface {
+	SendEmail(to, subject, body string) error
+}
+
+type NotificationService struct {
+	sender     EmailSender
+	cfg        config.NotificationsConfig
+	retryDelay time.Duration
+}
+
+func NewNotificationService(cfg *config.Config) *NotificationService {
+	return NewNotificationServiceWithSender(NewSMTPService(cfg.SMTP), cfg.Notifications)
+}
+
+// NewNotificationServiceWithSender creates a notification service that delivers through sender
+func NewNotificationServiceWithSender(sender EmailSender, cfg config.NotificationsConfig) *NotificationService {
+	return &NotificationService{
+		sender:     sender,
+		cfg:        cfg,
+		retryDelay: defaultNotificationRetryDelay,
+	}
+}
+
+// Enabled reports whether admins opted in to event
+func (s *NotificationService) Enabled(event NotificationEvent) bool {
+	switch event {
+	case EventBackupFailed:
+		return s.cfg.Events.BackupFailed
+	case EventQuotaWarning:
+		return s.cfg.Events.QuotaWarning
+	case EventCertExpiry:
+		return s.cfg.Events.CertExpiry
+	}
+	return false
+}
+
+// Recipients returns the admin addresses notifications are sent to
+func (s *NotificationService) Recipients() []string {
+	return s.cfg.Recipients
+}
+
+// SendEmail sends an email, retrying with backoff while the SMTP server reports transient errors
+func (s *NotificationService) SendEmail(to, subject, body string) error {
+	attempts := s.cfg.RetryAttempts
+	if attempts <= 0 {
+		attempts = config.DefaultNotificationRetryAttempts
+	}
+
+	delay := s.retryDelay
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = s.sender.SendEmail(to, subject, body); err == nil || !isTransientSMTPError(err) {
+			return err
+		}
+		if attempt < attempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", attempts, err)
+}
+
+// Notify emails every recipient about event. Events admins did not opt in to are dropped.
+func (s *NotificationService) Notify(event NotificationEvent, subject, body string) error {
+	if !s.Enabled(event) {
+		return nil
+	}
+	if len(s.cfg.Recipients) == 0 {
+		return ErrNoNotificationRecipients
+	}
+
+	var errs []error
+	for _, to := range s.cfg.Recipients {
+		if err := s.SendEmail(to, "[R-Panel] "+subject, body); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", to, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyInBackground sends a notification without blocking the caller and logs delivery failures
+func (s *NotificationService) NotifyInBackground(event NotificationEvent, subject, body string) {
+	if !s.Enabled(event) {
+		return
+	}
+	go func() {
+		if err := s.Notify(event, subject, body); err != nil {
+			log.Printf("Failed to send %s notification: %v", event, err)
+		}
+	}()
+}
+
+// BackupFailed notifies admins that a backup could not be created
+func (s *NotificationService) BackupFailed(source string, err error) {
+	s.NotifyInBackground(EventBackupFailed,
+		"Backup failed: "+source,
+		fmt.Sprintf("The backup of %s failed at %s:\n\n%v", source, time.Now().Format(time.RFC1123), err))
+}
+
+// isTransientSMTPError reports whether sending may succeed when retried:
+// 4xx replies from the server and network failures
+func isTransientSMTPError(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
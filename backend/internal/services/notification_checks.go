@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+)
+
+// NotificationMonitor periodically checks mail quotas and notifies admins. Each
+// problem is reported once until it clears. Certificate expiry is left to
+// CertExpiryScanner.
+type NotificationMonitor struct {
+	notifier     *NotificationService
+	mailService  *MailService
+	quotaPercent int
+
+	mu       sync.Mutex
+	reported map[string]bool
+}
+
+func NewNotificationMonitor(notifier *NotificationService, mailService *MailService, cfg config.NotificationsConfig) *NotificationMonitor {
+	quotaPercent := cfg.QuotaWarningPercent
+	if quotaPercent <= 0 || quotaPercent > 100 {
+		quotaPercent = config.DefaultQuotaWarningPercent
+	}
+
+	return &NotificationMonitor{
+		notifier:     notifier,
+		mailService:  mailService,
+		quotaPercent: quotaPercent,
+		reported:     map[string]bool{},
+	}
+}
+
+// Run checks immediately and then every interval until ctx is cancelled
+func (m *NotificationMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.CheckAll()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CheckAll runs every enabled check and logs failures
+func (m *NotificationMonitor) CheckAll() {
+	if m.notifier.Enabled(EventQuotaWarning) {
+		if err := m.CheckMailQuotas(); err != nil {
+			log.Printf("Quota check failed: %v", err)
+		}
+	}
+}
+
+// CheckMailQuotas notifies admins about mailboxes that reached the warning share of their quota
+func (m *NotificationMonitor) CheckMailQuotas() error {
+	var clients []models.Client
+	if err := models.DB.Preload("ClientLimits").Find(&clients).Error; err != nil {
+		return fmt.Errorf("failed to load clients: %w", err)
+	}
+
+	for _, client := range clients {
+		if client.LinuxUsername == "" {
+			continue
+		}
+		report, err := m.mailService.GetUsage(client.LinuxUsername, client.ClientLimits.LimitMailquota)
+		if err != nil {
+			log.Printf("Quota check: skipping client %s: %v", client.CustomerNo, err)
+			continue
+		}
+
+		for _, mailbox := range report.Mailboxes {
+			nearQuota := mailbox.QuotaBytes > 0 && mailbox.UsedBytes*100 >= mailbox.QuotaBytes*int64(m.quotaPercent)
+			if !m.firstReport("quota:"+mailbox.Address, nearQuota) {
+				continue
+			}
+
+			percent := mailbox.UsedBytes * 100 / mailbox.QuotaBytes
+			m.notify(EventQuotaWarning,
+				fmt.Sprintf("Mailbox %s is at %d%% of its quota", mailbox.Address, percent),
+				fmt.Sprintf("Mailbox %s of client %s (%s) uses %s of its %s quota (%d%%).",
+					mailbox.Address, client.CompanyName, client.CustomerNo,
+					formatMB(mailbox.UsedBytes), formatMB(mailbox.QuotaBytes), percent))
+		}
+	}
+
+	return nil
+}
+
+// firstReport records whether a problem is active and reports true only the first
+// time it is seen, so admins get one email per problem instead of one per check
+func (m *NotificationMonitor) firstReport(key string, active bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !active {
+		delete(m.reported, key)
+		return false
+	}
+	if m.reported[key] {
+		return false
+	}
+	m.reported[key] = true
+	return true
+}
+
+func (m *NotificationMonitor) notify(event NotificationEvent, subject, body string) {
+	if err := m.notifier.Notify(event, subject, body); err != nil {
+		log.Printf("Failed to send %s notification: %v", event, err)
+	}
+}
+
+func formatMB(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"r-panel/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmailSender records sent emails and fails with queued errors first
+type fakeEmailSender struct {
+	mu     sync.Mutex
+	errs   []error
+	sent   []string // "to: subject"
+	bodies []string
+	tries  int
+}
+
+func (f *fakeEmailSender) SendEmail(to, subject, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tries++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	f.sent = append(f.sent, to+": "+subject)
+	f.bodies = append(f.bodies, body)
+	return nil
+}
+
+func newTestNotificationService(sender EmailSender, cfg config.NotificationsConfig) *NotificationService {
+	service := NewNotificationServiceWithSender(sender, cfg)
+	service.retryDelay = time.Millisecond
+	return service
+}
+
+func TestNotificationServiceRetriesTransientErrors(t *testing.T) {
+	busy := &textproto.Error{Code: 421, Msg: "try again later"}
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	t.Run("succeeds after transient failures", func(t *testing.T) {
+		sender := &fakeEmailSender{errs: []error{busy, refused}}
+		service := newTestNotificationService(sender, config.NotificationsConfig{})
+
+		require.NoError(t, service.SendEmail("admin@example.com", "subject", "body"))
+		assert.Equal(t, 3, sender.tries)
+		assert.Equal(t, []string{"admin@example.com: subject"}, sender.sent)
+	})
+
+	t.Run("gives up after the configured attempts", func(t *testing.T) {
+		sender := &fakeEmailSender{errs: []error{busy, busy, busy}}
+		service := newTestNotificationService(sender, config.NotificationsConfig{RetryAttempts: 2})
+
+		err := service.SendEmail("admin@example.com", "subject", "body")
+		assert.ErrorIs(t, err, error(busy))
+		assert.Equal(t, 2, sender.tries)
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		for _, permanent := range []error{
+			&textproto.Error{Code: 550, Msg: "mailbox unavailable"},
+			ErrSMTPNotConfigured,
+		} {
+			sender := &fakeEmailSender{errs: []error{permanent}}
+			service := newTestNotificationService(sender, config.NotificationsConfig{})
+
+			assert.ErrorIs(t, service.SendEmail("admin@example.com", "subject", "body"), permanent)
+			assert.Equal(t, 1, sender.tries)
+		}
+	})
+}
+
+func TestNotificationServiceNotifyIsOptIn(t *testing.T) {
+	sender := &fakeEmailSender{}
+	service := newTestNotificationService(sender, config.NotificationsConfig{
+		Recipients: []string{"a@example.com", "b@example.com"},
+		Events:     config.NotificationEventsConfig{BackupFailed: true},
+	})
+
+	require.NoError(t, service.Notify(EventQuotaWarning, "ignored", "body"))
+	require.NoError(t, service.Notify(EventCertExpiry, "ignored", "body"))
+	assert.Empty(t, sender.sent)
+
+	require.NoError(t, service.Notify(EventBackupFailed, "Backup failed: /home/site", "body"))
+	assert.Equal(t, []string{
+		"a@example.com: [R-Panel] Backup failed: /home/site",
+		"b@example.com: [R-Panel] Backup failed: /home/site",
+	}, sender.sent)
+
+	noRecipients := newTestNotificationService(sender, config.NotificationsConfig{
+		Events: config.NotificationEventsConfig{BackupFailed: true},
+	})
+	assert.ErrorIs(t, noRecipients.Notify(EventBackupFailed, "subject", "body"), ErrNoNotificationRecipients)
+}
+
+func TestNotificationMonitorQuotaWarnings(t *testing.T) {
+	t.Setenv("SKIP_LINUX_USER", "true")
+	cfg := setupTestDB(t)
+	client, err := NewClientService(cfg).CreateClient(&CreateClientData{
+		Username:       "quotaclient",
+		Password:       "testpass123",
+		ContactName:    "Quota Client",
+		Email:          "quotaclient@example.com",
+		LimitMailquota: 1,
+	})
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	inbox := filepath.Join(root, client.LinuxUsername, "example.com", "info", "cur")
+	writeMailFile(t, filepath.Join(inbox, "1.eml"), 950*1024)
+	writeMailFile(t, filepath.Join(root, client.LinuxUsername, "example.com", "sales", "cur", "1.eml"), 100*1024)
+
+	sender := &fakeEmailSender{}
+	notifications := config.NotificationsConfig{
+		Recipients: []string{"admin@example.com"},
+		Events:     config.NotificationEventsConfig{QuotaWarning: true},
+	}
+	monitor := NewNotificationMonitor(newTestNotificationService(sender, notifications),
+		NewMailService(NewMaildirStorage(root)), notifications)
+
+	require.NoError(t, monitor.CheckMailQuotas())
+	require.Equal(t, []string{"admin@example.com: [R-Panel] Mailbox info@example.com is at 92% of its quota"}, sender.sent)
+	assert.Contains(t, sender.bodies[0], client.CustomerNo)
+
+	// Reported once while the mailbox stays near its quota
+	require.NoError(t, monitor.CheckMailQuotas())
+	assert.Len(t, sender.sent, 1)
+
+	// And again after it dropped below the threshold and came back
+	require.NoError(t, os.Remove(filepath.Join(inbox, "1.eml")))
+	require.NoError(t, monitor.CheckMailQuotas())
+	writeMailFile(t, filepath.Join(inbox, "2.eml"), 1100*1024)
+	require.NoError(t, monitor.CheckMailQuotas())
+	assert.Len(t, sender.sent, 2)
+}
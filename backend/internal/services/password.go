@@ -0,0 +1,178 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported password hash algorithms
+const (
+	HashAlgorithmBcrypt   = "bcrypt"
+	HashAlgorithmArgon2id = "argon2id"
+)
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// PasswordHasher hashes and verifies passwords with a specific algorithm.
+// Hashes carry an algorithm identifier prefix so they can be told apart.
+type PasswordHasher interface {
+	// Name returns the algorithm identifier
+	Name() string
+	// Hash hashes a password
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash
+	Verify(hash, password string) bool
+	// Owns reports whether hash was produced by this algorithm
+	Owns(hash string) bool
+	// NeedsRehash reports whether hash was produced with outdated parameters
+	NeedsRehash(hash string) bool
+}
+
+// NewPasswordHasher returns the hasher for the given algorithm (bcrypt when empty)
+func NewPasswordHasher(algorithm string, bcryptCost int) (PasswordHasher, error) {
+	switch algorithm {
+	case "", HashAlgorithmBcrypt:
+		return &bcryptHasher{cost: bcryptCost}, nil
+	case HashAlgorithmArgon2id:
+		return newArgon2idHasher(), nil
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm: %s", algorithm)
+	}
+}
+
+// bcryptHasher hashes passwords with bcrypt ($2a$/$2b$/$2y$ prefixes)
+type bcryptHasher struct {
+	cost int
+}
+
+func (h *bcryptHasher) Name() string {
+	return HashAlgorithmBcrypt
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	return string(bytes), err
+}
+
+func (h *bcryptHasher) Verify(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (h *bcryptHasher) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// NeedsRehash reports hashes made with a different cost, so changing the
+// configured cost migrates passwords as users log in
+func (h *bcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.effectiveCost()
+}
+
+// effectiveCost is the cost GenerateFromPassword uses, which replaces costs below the minimum with its default
+func (h *bcryptHasher) effectiveCost() int {
+	if h.cost < bcrypt.MinCost {
+		return bcrypt.DefaultCost
+	}
+	return h.cost
+}
+
+// argon2idHasher hashes passwords with argon2id using the PHC string format:
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>
+type argon2idHasher struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	keyLen  uint32
+	saltLen int
+}
+
+func newArgon2idHasher() *argon2idHasher {
+	return &argon2idHasher{
+		time:    1,
+		memory:  64 * 1024,
+		threads: 4,
+		keyLen:  32,
+		saltLen: 16,
+	}
+}
+
+func (h *argon2idHasher) Name() string {
+	return HashAlgorithmArgon2id
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.time, h.memory, h.threads, h.keyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.memory, h.time, h.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) Verify(hash, password string) bool {
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return false
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(computed, key) == 1
+}
+
+func (h *argon2idHasher) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h *argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+	return params.time != h.time || params.memory != h.memory || params.threads != h.threads
+}
+
+// decodeArgon2idHash parses an argon2id PHC string into its parameters, salt and key
+func decodeArgon2idHash(hash string) (*argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, err
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("incompatible argon2 version: %d", version)
+	}
+
+	params := &argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return params, salt, key, nil
+}
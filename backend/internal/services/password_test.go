@@ -0,0 +1,120 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHashers(t *testing.T) {
+	for _, algorithm := range []string{HashAlgorithmBcrypt, HashAlgorithmArgon2id} {
+		t.Run(algorithm, func(t *testing.T) {
+			hasher, err := NewPasswordHasher(algorithm, 10)
+			require.NoError(t, err)
+
+			hash, err := hasher.Hash("s3cret")
+			require.NoError(t, err)
+
+			assert.True(t, hasher.Owns(hash))
+			assert.True(t, hasher.Verify(hash, "s3cret"))
+			assert.False(t, hasher.Verify(hash, "wrong"))
+			assert.False(t, hasher.NeedsRehash(hash))
+		})
+	}
+
+	t.Run("unknown algorithm", func(t *testing.T) {
+		_, err := NewPasswordHasher("md5", 10)
+		assert.Error(t, err)
+	})
+
+	t.Run("argon2id hash carries identifier prefix", func(t *testing.T) {
+		hasher, _ := NewPasswordHasher(HashAlgorithmArgon2id, 10)
+		hash, err := hasher.Hash("s3cret")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$"))
+	})
+}
+
+func TestAuthServiceCrossAlgorithmVerification(t *testing.T) {
+	cfg := setupTestDB(t)
+
+	cfg.Security.HashAlgorithm = HashAlgorithmBcrypt
+	bcryptAuth := NewAuthService(cfg)
+	bcryptHash, err := bcryptAuth.HashPassword("s3cret")
+	require.NoError(t, err)
+
+	cfg.Security.HashAlgorithm = HashAlgorithmArgon2id
+	argonAuth := NewAuthService(cfg)
+	argonHash, err := argonAuth.HashPassword("s3cret")
+	require.NoError(t, err)
+
+	// Both services verify hashes from either algorithm
+	assert.True(t, argonAuth.VerifyPassword(bcryptHash, "s3cret"))
+	assert.True(t, bcryptAuth.VerifyPassword(argonHash, "s3cret"))
+	assert.False(t, argonAuth.VerifyPassword(bcryptHash, "wrong"))
+	assert.False(t, argonAuth.VerifyPassword("plaintext", "plaintext"))
+
+	assert.True(t, argonAuth.NeedsRehash(bcryptHash))
+	assert.False(t, argonAuth.NeedsRehash(argonHash))
+}
+
+func TestAuthenticateMigratesHashOnLogin(t *testing.T) {
+	cfg := setupTestDB(t)
+
+	// User created while bcrypt was configured
+	cfg.Security.HashAlgorithm = HashAlgorithmBcrypt
+	user, err := NewAuthService(cfg).CreateUser("migrate", "s3cret", "user")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(user.PasswordHash, "$2"))
+
+	// Admin switches to argon2id; the next login rehashes the stored password
+	cfg.Security.HashAlgorithm = HashAlgorithmArgon2id
+	argonAuth := NewAuthService(cfg)
+
+	_, err = argonAuth.Authenticate("migrate", "s3cret")
+	require.NoError(t, err)
+
+	var stored models.User
+	require.NoError(t, models.DB.First(&stored, user.ID).Error)
+	assert.True(t, strings.HasPrefix(stored.PasswordHash, "$argon2id$"))
+
+	// The migrated hash keeps working
+	_, err = argonAuth.Authenticate("migrate", "s3cret")
+	assert.NoError(t, err)
+	_, err = argonAuth.Authenticate("migrate", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthenticateRehashesOnBcryptCostChange(t *testing.T) {
+	cfg := setupTestDB(t)
+	cfg.Security.BcryptCost = 10
+	user, err := NewAuthService(cfg).CreateUser("costly", "s3cret", "user")
+	require.NoError(t, err)
+
+	cost, err := bcrypt.Cost([]byte(user.PasswordHash))
+	require.NoError(t, err)
+	require.Equal(t, 10, cost)
+
+	// Admin raises the cost; the next login rehashes with it
+	cfg.Security.BcryptCost = 11
+	auth := NewAuthService(cfg)
+	assert.True(t, auth.NeedsRehash(user.PasswordHash))
+
+	_, err = auth.Authenticate("costly", "s3cret")
+	require.NoError(t, err)
+
+	var stored models.User
+	require.NoError(t, models.DB.First(&stored, user.ID).Error)
+	cost, err = bcrypt.Cost([]byte(stored.PasswordHash))
+	require.NoError(t, err)
+	assert.Equal(t, 11, cost)
+	assert.False(t, auth.NeedsRehash(stored.PasswordHash))
+
+	_, err = auth.Authenticate("costly", "s3cret")
+	assert.NoError(t, err)
+}
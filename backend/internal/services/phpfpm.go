@@ -1,15 +1,43 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 )
 
+var (
+	ErrInvalidPHPVersion = errors.New("invalid PHP version: expected major.minor, e.g. 8.2")
+	ErrInvalidPoolName   = errors.New("invalid pool name: use letters, digits, dots, hyphens and underscores only")
+)
+
+var (
+	phpVersionPattern = regexp.MustCompile(`^\d+\.\d+$`)
+	poolNamePattern   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+)
+
+// phpRoot holds a directory per installed PHP version
+const phpRoot = "/etc/php"
+
 type PHPFPMService struct {
 	poolsPath string
+	root      string
+	cacheTTL  time.Duration
+	now       func() time.Time
+
+	// The last version and pool scans, dropped when a pool changes
+	mu         sync.Mutex
+	versions   []string
+	versionsAt time.Time
+	pools      []PHPPool
+	poolsAt    time.Time
 }
 
 type PHPPool struct {
@@ -20,50 +48,74 @@ type PHPPool struct {
 	Config     string `json:"config"`
 }
 
-func NewPHPFPMService(poolsPath string) *PHPFPMService {
+// NewPHPFPMService returns the service for the pools under /etc/php. Version
+// and pool scans are reused for cacheTTL, 0 scans every time.
+func NewPHPFPMService(poolsPath string, cacheTTL time.Duration) *PHPFPMService {
 	return &PHPFPMService{
 		poolsPath: poolsPath,
+		root:      phpRoot,
+		cacheTTL:  cacheTTL,
+		now:       time.Now,
 	}
 }
 
+// fresh reports whether a scan made at scannedAt may still be used
+func (s *PHPFPMService) fresh(scannedAt time.Time) bool {
+	return s.cacheTTL > 0 && !scannedAt.IsZero() && s.now().Sub(scannedAt) < s.cacheTTL
+}
+
+// invalidate drops the cached scans after a pool was created, changed or deleted
+func (s *PHPFPMService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.versions, s.versionsAt = nil, time.Time{}
+	s.pools, s.poolsAt = nil, time.Time{}
+}
+
 // GetPHPVersions returns list of installed PHP versions
 func (s *PHPFPMService) GetPHPVersions() ([]string, error) {
-	var versions []string
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Check common PHP FPM paths
-	phpPaths := []string{
-		"/etc/php/7.4/fpm/pool.d",
-		"/etc/php/8.0/fpm/pool.d",
-		"/etc/php/8.1/fpm/pool.d",
-		"/etc/php/8.2/fpm/pool.d",
-		"/etc/php/8.3/fpm/pool.d",
-	}
-
-	for _, path := range phpPaths {
-		if _, err := os.Stat(path); err == nil {
-			// Extract version from path
-			parts := strings.Split(path, "/")
-			if len(parts) >= 3 {
-				version := parts[2]
-				versions = append(versions, version)
-			}
-		}
+	if !s.fresh(s.versionsAt) {
+		s.versions, s.versionsAt = s.scanPHPVersions(), s.now()
 	}
+	return append([]string(nil), s.versions...), nil
+}
 
-	return versions, nil
+// scanPHPVersions checks the common PHP-FPM paths
+func (s *PHPFPMService) scanPHPVersions() []string {
+	var versions []string
+	for _, version := range []string{"7.4", "8.0", "8.1", "8.2", "8.3"} {
+		if _, err := os.Stat(filepath.Join(s.root, version, "fpm", "pool.d")); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
 }
 
 // GetPools returns all PHP-FPM pools
 func (s *PHPFPMService) GetPools() ([]PHPPool, error) {
-	var pools []PHPPool
-
 	versions, err := s.GetPHPVersions()
 	if err != nil {
 		return nil, err
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.fresh(s.poolsAt) {
+		s.pools, s.poolsAt = s.scanPools(versions), s.now()
+	}
+	return append([]PHPPool(nil), s.pools...), nil
+}
+
+// scanPools reads every pool config of the given versions
+func (s *PHPFPMService) scanPools(versions []string) []PHPPool {
+	var pools []PHPPool
 	for _, version := range versions {
-		poolsPath := fmt.Sprintf("/etc/php/%s/fpm/pool.d", version)
+		poolsPath := filepath.Join(s.root, version, "fpm", "pool.d")
 		files, err := os.ReadDir(poolsPath)
 		if err != nil {
 			continue
@@ -97,14 +149,45 @@ func (s *PHPFPMService) GetPools() ([]PHPPool, error) {
 		}
 	}
 
-	return pools, nil
+	return pools
+}
+
+// ValidatePHPVersion checks that phpVersion is a major.minor version
+func ValidatePHPVersion(phpVersion string) error {
+	if !phpVersionPattern.MatchString(phpVersion) {
+		return fmt.Errorf("%w: %q", ErrInvalidPHPVersion, phpVersion)
+	}
+	return nil
+}
+
+// ValidatePoolName checks that poolName is safe to use as a file name
+func ValidatePoolName(poolName string) error {
+	if !poolNamePattern.MatchString(poolName) || strings.Contains(poolName, "..") {
+		return fmt.Errorf("%w: %q", ErrInvalidPoolName, poolName)
+	}
+	return nil
+}
+
+// poolPath validates the version and pool name before building the pool file path,
+// so neither can point outside the pool directory
+func (s *PHPFPMService) poolPath(phpVersion, poolName string) (string, error) {
+	if err := ValidatePHPVersion(phpVersion); err != nil {
+		return "", err
+	}
+	if err := ValidatePoolName(poolName); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, phpVersion, "fpm", "pool.d", poolName+".conf"), nil
 }
 
 // GetPool returns a specific pool
 func (s *PHPFPMService) GetPool(phpVersion, poolName string) (*PHPPool, error) {
-	poolPath := fmt.Sprintf("/etc/php/%s/fpm/pool.d/%s.conf", phpVersion, poolName)
+	poolPath, err := s.poolPath(phpVersion, poolName)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := os.Stat(poolPath)
+	_, err = os.Stat(poolPath)
 	if err != nil {
 		return nil, fmt.Errorf("pool not found")
 	}
@@ -126,7 +209,10 @@ func (s *PHPFPMService) GetPool(phpVersion, poolName string) (*PHPPool, error) {
 
 // GetPoolConfig reads pool configuration file
 func (s *PHPFPMService) GetPoolConfig(phpVersion, poolName string) (string, error) {
-	poolPath := fmt.Sprintf("/etc/php/%s/fpm/pool.d/%s.conf", phpVersion, poolName)
+	poolPath, err := s.poolPath(phpVersion, poolName)
+	if err != nil {
+		return "", err
+	}
 	data, err := os.ReadFile(poolPath)
 	if err != nil {
 		return "", err
@@ -136,7 +222,10 @@ func (s *PHPFPMService) GetPoolConfig(phpVersion, poolName string) (string, erro
 
 // CreatePool creates a new PHP-FPM pool
 func (s *PHPFPMService) CreatePool(phpVersion, poolName, config string) error {
-	poolPath := fmt.Sprintf("/etc/php/%s/fpm/pool.d/%s.conf", phpVersion, poolName)
+	poolPath, err := s.poolPath(phpVersion, poolName)
+	if err != nil {
+		return err
+	}
 
 	// Check if pool already exists
 	if _, err := os.Stat(poolPath); err == nil {
@@ -147,30 +236,82 @@ func (s *PHPFPMService) CreatePool(phpVersion, poolName, config string) error {
 	if err := os.WriteFile(poolPath, []byte(config), 0644); err != nil {
 		return fmt.Errorf("failed to write pool config: %w", err)
 	}
+	s.invalidate()
+	markReloadPending(PHPFPMServiceName(phpVersion))
 
 	return nil
 }
 
 // UpdatePool updates an existing pool configuration
 func (s *PHPFPMService) UpdatePool(phpVersion, poolName, config string) error {
-	poolPath := fmt.Sprintf("/etc/php/%s/fpm/pool.d/%s.conf", phpVersion, poolName)
+	poolPath, err := s.poolPath(phpVersion, poolName)
+	if err != nil {
+		return err
+	}
 
 	// Check if pool exists
 	if _, err := os.Stat(poolPath); err != nil {
 		return fmt.Errorf("pool not found")
 	}
 
+	previous, err := os.ReadFile(poolPath)
+	if err != nil {
+		return fmt.Errorf("failed to read pool config: %w", err)
+	}
+	if string(previous) != config {
+		if err := s.poolVersions(phpVersion, poolName).save(previous); err != nil {
+			return err
+		}
+	}
+
 	// Write configuration file
 	if err := os.WriteFile(poolPath, []byte(config), 0644); err != nil {
 		return fmt.Errorf("failed to write pool config: %w", err)
 	}
+	s.invalidate()
+	if string(previous) != config {
+		markReloadPending(PHPFPMServiceName(phpVersion))
+	}
 
 	return nil
 }
 
+// poolVersions holds the previous configs of a pool, outside pool.d so
+// php-fpm never loads them. The caller has validated version and name.
+func (s *PHPFPMService) poolVersions(phpVersion, poolName string) configVersions {
+	return configVersions{fs: osFileSystem{}, dir: filepath.Join(s.root, phpVersion, "fpm", configVersionsDir, poolName)}
+}
+
+// GetPoolVersions returns the saved previous configs of a pool, newest first
+func (s *PHPFPMService) GetPoolVersions(phpVersion, poolName string) ([]ConfigVersion, error) {
+	if _, err := s.GetPool(phpVersion, poolName); err != nil {
+		return nil, err
+	}
+	return s.poolVersions(phpVersion, poolName).list()
+}
+
+// RestorePoolVersion puts a saved config of a pool back and returns it. The
+// config it replaces is saved as a version in turn.
+func (s *PHPFPMService) RestorePoolVersion(phpVersion, poolName, version string) (string, error) {
+	if _, err := s.GetPool(phpVersion, poolName); err != nil {
+		return "", err
+	}
+	config, err := s.poolVersions(phpVersion, poolName).read(version)
+	if err != nil {
+		return "", err
+	}
+	if err := s.UpdatePool(phpVersion, poolName, string(config)); err != nil {
+		return "", err
+	}
+	return string(config), nil
+}
+
 // DeletePool deletes a pool configuration
 func (s *PHPFPMService) DeletePool(phpVersion, poolName string) error {
-	poolPath := fmt.Sprintf("/etc/php/%s/fpm/pool.d/%s.conf", phpVersion, poolName)
+	poolPath, err := s.poolPath(phpVersion, poolName)
+	if err != nil {
+		return err
+	}
 
 	// Check if pool exists
 	if _, err := os.Stat(poolPath); err != nil {
@@ -180,52 +321,82 @@ func (s *PHPFPMService) DeletePool(phpVersion, poolName string) error {
 	if err := os.Remove(poolPath); err != nil {
 		return fmt.Errorf("failed to delete pool: %w", err)
 	}
+	s.invalidate()
+	markReloadPending(PHPFPMServiceName(phpVersion))
 
 	return nil
 }
 
 // ReloadPHPFPM reloads PHP-FPM service for a specific version
-func (s *PHPFPMService) ReloadPHPFPM(phpVersion string) error {
-	serviceName := fmt.Sprintf("php%s-fpm", phpVersion)
-	cmd := exec.Command("systemctl", "reload", serviceName)
-	return cmd.Run()
+func (s *PHPFPMService) ReloadPHPFPM(ctx context.Context, phpVersion string) error {
+	if err := ValidatePHPVersion(phpVersion); err != nil {
+		return err
+	}
+	return systemctlPHPFPM(ctx, "reload", phpVersion)
+}
+
+// RestartPHPFPM restarts the PHP-FPM service of a version, which also
+// recovers one that is stopped
+func (s *PHPFPMService) RestartPHPFPM(ctx context.Context, phpVersion string) error {
+	if err := ValidatePHPVersion(phpVersion); err != nil {
+		return err
+	}
+	return systemctlPHPFPM(ctx, "restart", phpVersion)
+}
+
+// PHPFPMServiceName returns the systemd unit of a PHP-FPM version
+func PHPFPMServiceName(phpVersion string) string {
+	return fmt.Sprintf("php%s-fpm", phpVersion)
+}
+
+// systemctlPHPFPM runs a systemctl action on the PHP-FPM service of a version
+func systemctlPHPFPM(ctx context.Context, action, phpVersion string) error {
+	serviceName := PHPFPMServiceName(phpVersion)
+	output, err := exec.CommandContext(ctx, "systemctl", action, serviceName).CombinedOutput()
+	if err != nil {
+		return serviceCommandError(action, serviceName, output, err)
+	}
+	clearReloadPending(serviceName)
+	return nil
+}
+
+// ReloadState returns whether the PHP-FPM service of a version has pool
+// changes it has not loaded yet
+func (s *PHPFPMService) ReloadState(phpVersion string) (ReloadState, error) {
+	if err := ValidatePHPVersion(phpVersion); err != nil {
+		return ReloadState{}, err
+	}
+	return ReloadPending(PHPFPMServiceName(phpVersion))
 }
 
 // TestPHPFPMConfig tests PHP-FPM configuration
-func (s *PHPFPMService) TestPHPFPMConfig(phpVersion string) error {
+func (s *PHPFPMService) TestPHPFPMConfig(ctx context.Context, phpVersion string) error {
+	if err := ValidatePHPVersion(phpVersion); err != nil {
+		return err
+	}
 	fpmBin := fmt.Sprintf("/usr/sbin/php-fpm%s", phpVersion)
-	cmd := exec.Command(fpmBin, "-t")
-	return cmd.Run()
+	output, err := exec.CommandContext(ctx, fpmBin, "-t").CombinedOutput()
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("PHP-FPM config test failed: %s", message)
+		}
+		return fmt.Errorf("PHP-FPM config test failed: %w", err)
+	}
+	return nil
 }
 
 // isPoolActive checks if a pool is active (simple check)
 func (s *PHPFPMService) isPoolActive(phpVersion, poolName string) bool {
-	poolPath := fmt.Sprintf("/etc/php/%s/fpm/pool.d/%s.conf", phpVersion, poolName)
-	_, err := os.Stat(poolPath)
+	poolPath, err := s.poolPath(phpVersion, poolName)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(poolPath)
 	return err == nil
 }
 
-// GeneratePoolConfig generates a default pool configuration
-func (s *PHPFPMService) GeneratePoolConfig(poolName, user, group string) string {
-	config := fmt.Sprintf(`[%s]
-user = %s
-group = %s
-listen = /run/php/php-fpm-%s.sock
-listen.owner = www-data
-listen.group = www-data
-listen.mode = 0660
-
-pm = dynamic
-pm.max_children = 50
-pm.start_servers = 5
-pm.min_spare_servers = 5
-pm.max_spare_servers = 35
-pm.max_requests = 500
-
-php_admin_value[memory_limit] = 128M
-php_admin_value[max_execution_time] = 30
-php_admin_value[disable_functions] = exec,passthru,shell_exec,system,proc_open,popen
-
-`, poolName, user, group, poolName)
-	return config
+// GeneratePoolConfig generates a default pool configuration from the phpfpm
+// config template in use
+func (s *PHPFPMService) GeneratePoolConfig(poolName, user, group string) (string, error) {
+	return renderConfigTemplate(ConfigTemplatePHPFPM, PHPFPMPoolTemplateData{Name: poolName, User: user, Group: group})
 }
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPHPFPMServiceCachesScans(t *testing.T) {
+	root := t.TempDir()
+	poolDir := filepath.Join(root, "8.2", "fpm", "pool.d")
+	require.NoError(t, os.MkdirAll(poolDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(poolDir, "shop.conf"), []byte("[shop]"), 0644))
+
+	now := time.Now()
+	service := NewPHPFPMService("", time.Minute)
+	service.root = root
+	service.now = func() time.Time { return now }
+
+	pools, err := service.GetPools()
+	require.NoError(t, err)
+	require.Len(t, pools, 1)
+	assert.Equal(t, "[shop]", pools[0].Config)
+
+	// Changes made behind the panel's back wait for the TTL
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "8.3", "fpm", "pool.d"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(poolDir, "blog.conf"), []byte("[blog]"), 0644))
+	pools, err = service.GetPools()
+	require.NoError(t, err)
+	assert.Len(t, pools, 1)
+	versions, err := service.GetPHPVersions()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"8.2"}, versions)
+
+	now = now.Add(time.Minute)
+	pools, err = service.GetPools()
+	require.NoError(t, err)
+	assert.Len(t, pools, 2)
+	versions, err = service.GetPHPVersions()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"8.2", "8.3"}, versions)
+
+	// Changes made through the service show up at once
+	require.NoError(t, service.CreatePool("8.2", "wiki", "[wiki]"))
+	pools, err = service.GetPools()
+	require.NoError(t, err)
+	assert.Len(t, pools, 3)
+
+	require.NoError(t, service.UpdatePool("8.2", "wiki", "[wiki]\npm = static"))
+	pools, err = service.GetPools()
+	require.NoError(t, err)
+	assert.Equal(t, "[wiki]\npm = static", pools[2].Config)
+
+	require.NoError(t, service.DeletePool("8.2", "shop"))
+	pools, err = service.GetPools()
+	require.NoError(t, err)
+	assert.Len(t, pools, 2)
+
+	// Without a TTL every call scans
+	service.cacheTTL = 0
+	require.NoError(t, os.Remove(filepath.Join(poolDir, "blog.conf")))
+	pools, err = service.GetPools()
+	require.NoError(t, err)
+	assert.Len(t, pools, 1)
+}
+
+func TestPHPFPMServiceRejectsTraversalParameters(t *testing.T) {
+	service := NewPHPFPMService("/etc/php", 0)
+
+	versions := []string{"", "8", "8.", ".2", "8.2.1", "../../../../etc/cron.d", "8.2/../../..", "8.2\x00", "v8.2", " 8.2"}
+	for _, version := range versions {
+		t.Run("version "+version, func(t *testing.T) {
+			_, err := service.GetPool(version, "site")
+			assert.ErrorIs(t, err, ErrInvalidPHPVersion)
+			_, err = service.GetPoolConfig(version, "site")
+			assert.ErrorIs(t, err, ErrInvalidPHPVersion)
+			assert.ErrorIs(t, service.CreatePool(version, "site", "[site]"), ErrInvalidPHPVersion)
+			assert.ErrorIs(t, service.UpdatePool(version, "site", "[site]"), ErrInvalidPHPVersion)
+			assert.ErrorIs(t, service.DeletePool(version, "site"), ErrInvalidPHPVersion)
+			assert.ErrorIs(t, service.ReloadPHPFPM(context.Background(), version), ErrInvalidPHPVersion)
+			assert.ErrorIs(t, service.TestPHPFPMConfig(context.Background(), version), ErrInvalidPHPVersion)
+		})
+	}
+
+	names := []string{"", ".", "..", "../evil", "../../../../etc/cron.d/evil", "a/b", `a\b`, "a..b", ".hidden", "-flag", "pool\x00", "my pool", string(make([]byte, 65))}
+	for _, name := range names {
+		t.Run("pool "+name, func(t *testing.T) {
+			_, err := service.GetPool("8.2", name)
+			assert.ErrorIs(t, err, ErrInvalidPoolName)
+			_, err = service.GetPoolConfig("8.2", name)
+			assert.ErrorIs(t, err, ErrInvalidPoolName)
+			assert.ErrorIs(t, service.CreatePool("8.2", name, "[evil]"), ErrInvalidPoolName)
+			assert.ErrorIs(t, service.UpdatePool("8.2", name, "[evil]"), ErrInvalidPoolName)
+			assert.ErrorIs(t, service.DeletePool("8.2", name), ErrInvalidPoolName)
+		})
+	}
+
+	for _, version := range []string{"7.4", "8.2", "10.0"} {
+		assert.NoError(t, ValidatePHPVersion(version), version)
+	}
+	for _, name := range []string{"www", "client1", "example.com", "shop_prod-2"} {
+		assert.NoError(t, ValidatePoolName(name), name)
+	}
+}
+
+func TestPHPFPMServiceMarksPoolChangesForReload(t *testing.T) {
+	setupTestDB(t)
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "8.2", "fpm", "pool.d"), 0755))
+	service := NewPHPFPMService("", 0)
+	service.root = root
+
+	state, err := service.ReloadState("8.2")
+	require.NoError(t, err)
+	assert.False(t, state.ReloadPending)
+
+	require.NoError(t, service.CreatePool("8.2", "shop", "[shop]"))
+	state, err = service.ReloadState("8.2")
+	require.NoError(t, err)
+	assert.True(t, state.ReloadPending)
+	state, err = service.ReloadState("8.3")
+	require.NoError(t, err)
+	assert.False(t, state.ReloadPending, "other versions are not affected")
+
+	clearReloadPending(PHPFPMServiceName("8.2"))
+	require.NoError(t, service.UpdatePool("8.2", "shop", "[shop]"))
+	state, err = service.ReloadState("8.2")
+	require.NoError(t, err)
+	assert.False(t, state.ReloadPending, "an unchanged config needs no reload")
+
+	_, err = service.ReloadState("../8.2")
+	assert.ErrorIs(t, err, ErrInvalidPHPVersion)
+}
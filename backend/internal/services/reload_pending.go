@@ -0,0 +1,62 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"r-panel/internal/models"
+)
+
+// settingReloadPendingPrefix prefixes the settings holding when a service's
+// config first changed since its last reload, e.g. reload_pending:nginx
+const settingReloadPendingPrefix = "reload_pending:"
+
+// ReloadState tells whether a service still runs an older config than the one
+// on disk
+type ReloadState struct {
+	ReloadPending bool       `json:"reload_pending"`
+	ChangedSince  *time.Time `json:"changed_since,omitempty"` // first change the service has not loaded
+}
+
+// markReloadPending records that the config of service changed. The flag is
+// kept in the settings table so it survives a panel restart, and only the
+// first change is recorded until the service is reloaded.
+func markReloadPending(service string) {
+	if models.DB == nil {
+		return
+	}
+	setting := models.Setting{Key: settingReloadPendingPrefix + service, Value: time.Now().UTC().Format(time.RFC3339)}
+	if err := models.DB.Where(models.Setting{Key: setting.Key}).FirstOrCreate(&setting).Error; err != nil {
+		fmt.Printf("Warning: failed to mark %s for reload: %v\n", service, err)
+	}
+}
+
+// clearReloadPending records that service loaded its current config
+func clearReloadPending(service string) {
+	if models.DB == nil {
+		return
+	}
+	if err := models.DB.Delete(&models.Setting{}, "`key` = ?", settingReloadPendingPrefix+service).Error; err != nil {
+		fmt.Printf("Warning: failed to clear pending reload of %s: %v\n", service, err)
+	}
+}
+
+// ReloadPending returns whether service has config changes it has not loaded
+func ReloadPending(service string) (ReloadState, error) {
+	if models.DB == nil {
+		return ReloadState{}, nil
+	}
+	var setting models.Setting
+	if err := models.DB.Where("`key` = ?", settingReloadPendingPrefix+service).Limit(1).Find(&setting).Error; err != nil {
+		return ReloadState{}, fmt.Errorf("failed to read pending reload of %s: %w", service, err)
+	}
+	if setting.Key == "" {
+		return ReloadState{}, nil
+	}
+
+	state := ReloadState{ReloadPending: true}
+	if since, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+		state.ChangedSince = &since
+	}
+	return state, nil
+}
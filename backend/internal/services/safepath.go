@@ -0,0 +1,53 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// The panel runs as root but writes into directories its clients own. A client
+// can swap any part of such a path for a symlink between a check and the use,
+// so these helpers walk client paths through directory descriptors opened with
+// O_NOFOLLOW and act relative to them instead of by name.
+
+const openDirFlags = unix.O_RDONLY | unix.O_DIRECTORY | unix.O_NOFOLLOW | unix.O_CLOEXEC
+
+// openDirAt opens the directory name inside dirfd, unix.AT_FDCWD for a path,
+// refusing a symlink in its place
+func openDirAt(dirfd int, name string) (int, error) {
+	for {
+		fd, err := unix.Openat(dirfd, name, openDirFlags, 0)
+		if err == unix.EINTR {
+			continue
+		}
+		return fd, err
+	}
+}
+
+// mkdirOpenAt creates the directory name inside dirfd unless it exists and
+// opens it without following a symlink. created reports whether it was made.
+func mkdirOpenAt(dirfd int, name string, mode uint32) (fd int, created bool, err error) {
+	err = unix.Mkdirat(dirfd, name, mode)
+	if err != nil && !errors.Is(err, unix.EEXIST) {
+		return -1, false, err
+	}
+	created = err == nil
+	fd, err = openDirAt(dirfd, name)
+	return fd, created, err
+}
+
+// isSymlinkError reports whether opening with O_NOFOLLOW hit a symlink or a
+// file where a directory was expected
+func isSymlinkError(err error) bool {
+	return errors.Is(err, unix.ELOOP) || errors.Is(err, unix.ENOTDIR)
+}
+
+// tempName returns a name for a temporary file next to name
+func tempName(name string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "." + name + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// MonitoredServices are the systemd services shown on the dashboard and watched for outages
+var MonitoredServices = []string{"nginx", "php8.1-fpm", "php8.2-fpm", "mysql", "mariadb"}
+
+// ServiceDownEvent is the webhook data sent when a watched service stops
+type ServiceDownEvent struct {
+	Service string `json:"service"`
+	Status  string `json:"status"`
+}
+
+// ServiceWatcher polls systemd services and fires service.down when one that was
+// running stops. Services that were never seen running are not reported, so
+// alternatives that aren't installed (mysql vs mariadb) stay quiet.
+type ServiceWatcher struct {
+	system   *SystemService
+	dispatch func(event WebhookEvent, data interface{})
+	services []string
+	active   map[string]bool
+}
+
+func NewServiceWatcher(system *SystemService, webhooks *WebhookService, services []string) *ServiceWatcher {
+	return &ServiceWatcher{
+		system:   system,
+		dispatch: webhooks.Dispatch,
+		services: services,
+		active:   map[string]bool{},
+	}
+}
+
+// Run checks immediately and then every interval until ctx is cancelled
+func (w *ServiceWatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.Check(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check polls every watched service once
+func (w *ServiceWatcher) Check(ctx context.Context) {
+	statuses, _ := w.system.GetServicesStatus(ctx, w.services)
+	for _, status := range statuses {
+		if w.active[status.Name] && !status.Active {
+			w.dispatch(WebhookServiceDown, ServiceDownEvent{Service: status.Name, Status: status.Status})
+		}
+		w.active[status.Name] = status.Active
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSetupCompleted   = errors.New("setup has already been completed")
+	ErrInvalidSetupData = errors.New("invalid setup data")
+)
+
+// MinPasswordLength is the shortest password accepted when a password is chosen
+const MinPasswordLength = 8
+
+// Settings stored by the setup wizard
+const (
+	SettingPanelName  = "panel_name"
+	SettingAdminEmail = "admin_email"
+	SettingTimezone   = "timezone"
+)
+
+// setupMu serializes setup so two concurrent requests cannot both create a first admin
+var setupMu sync.Mutex
+
+// SetupData holds the first admin account and the basic panel settings
+type SetupData struct {
+	Username   string
+	Password   string
+	PanelName  string
+	AdminEmail string
+	Timezone   string
+}
+
+// SetupStatus reports whether the first-run setup still has to be completed
+type SetupStatus struct {
+	SetupRequired bool   `json:"setup_required"`
+	PanelName     string `json:"panel_name,omitempty"`
+}
+
+type SetupService struct {
+	authService *AuthService
+}
+
+func NewSetupService(cfg *config.Config) *SetupService {
+	return NewSetupServiceWithAuth(NewAuthService(cfg))
+}
+
+func NewSetupServiceWithAuth(authService *AuthService) *SetupService {
+	return &SetupService{authService: authService}
+}
+
+// Status reports whether setup is required, which is the case until the first user exists
+func (s *SetupService) Status() (*SetupStatus, error) {
+	var count int64
+	if err := models.DB.Model(&models.User{}).Count(&count).Error; err != nil {
+		return nil, err
+	}
+
+	status := &SetupStatus{SetupRequired: count == 0}
+	var setting models.Setting
+	if err := models.DB.Where("`key` = ?", SettingPanelName).Limit(1).Find(&setting).Error; err != nil {
+		return nil, err
+	}
+	status.PanelName = setting.Value
+	return status, nil
+}
+
+// Complete creates the first admin and stores the basic settings. It fails with
+// ErrSetupCompleted once any user exists.
+func (s *SetupService) Complete(data *SetupData) (*models.User, error) {
+	data.Username = NormalizeUsername(data.Username)
+	data.PanelName = strings.TrimSpace(data.PanelName)
+	data.AdminEmail = strings.TrimSpace(data.AdminEmail)
+	data.Timezone = strings.TrimSpace(data.Timezone)
+	if err := validateSetupData(data); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.authService.HashPassword(data.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	setupMu.Lock()
+	defer setupMu.Unlock()
+
+	user := &models.User{
+		Username:     data.Username,
+		PasswordHash: hashedPassword,
+		Role:         "admin",
+	}
+	settings := map[string]string{
+		SettingPanelName:  data.PanelName,
+		SettingAdminEmail: data.AdminEmail,
+		SettingTimezone:   data.Timezone,
+	}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrSetupCompleted
+		}
+
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create admin: %w", err)
+		}
+		for key, value := range settings {
+			if value == "" {
+				continue
+			}
+			if err := tx.Save(&models.Setting{Key: key, Value: value}).Error; err != nil {
+				return fmt.Errorf("failed to save %s: %w", key, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.PasswordHash = ""
+	return user, nil
+}
+
+func validateSetupData(data *SetupData) error {
+	if data.Username == "" {
+		return fmt.Errorf("%w: username is required", ErrInvalidSetupData)
+	}
+	if len(data.Password) < MinPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrInvalidSetupData, MinPasswordLength)
+	}
+	if data.AdminEmail != "" {
+		if _, err := mail.ParseAddress(data.AdminEmail); err != nil {
+			return fmt.Errorf("%w: invalid admin email %q", ErrInvalidSetupData, data.AdminEmail)
+		}
+	}
+	if data.Timezone != "" {
+		if _, err := time.LoadLocation(data.Timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSetupData, data.Timezone)
+		}
+	}
+	return nil
+}
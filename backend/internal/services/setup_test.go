@@ -0,0 +1,96 @@
+package services
+
+import (
+	"sync"
+	"testing"
+
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupServiceCreatesFirstAdminOnce(t *testing.T) {
+	cfg := setupTestDB(t)
+	service := NewSetupService(cfg)
+
+	status, err := service.Status()
+	require.NoError(t, err)
+	assert.True(t, status.SetupRequired)
+
+	_, err = service.Complete(&SetupData{Username: "owner", Password: "short"})
+	assert.ErrorIs(t, err, ErrInvalidSetupData)
+	_, err = service.Complete(&SetupData{Username: "owner", Password: "long enough", Timezone: "Mars/Olympus"})
+	assert.ErrorIs(t, err, ErrInvalidSetupData)
+
+	user, err := service.Complete(&SetupData{
+		Username:   " owner ",
+		Password:   "long enough",
+		PanelName:  "Acme Hosting",
+		AdminEmail: "ops@example.com",
+		Timezone:   "Asia/Jakarta",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "owner", user.Username)
+	assert.Equal(t, "admin", user.Role)
+	assert.Empty(t, user.PasswordHash)
+
+	authenticated, err := NewAuthService(cfg).Authenticate("owner", "long enough")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, authenticated.ID)
+
+	status, err = service.Status()
+	require.NoError(t, err)
+	assert.Equal(t, &SetupStatus{SetupRequired: false, PanelName: "Acme Hosting"}, status)
+
+	var timezone models.Setting
+	require.NoError(t, models.DB.First(&timezone, "`key` = ?", SettingTimezone).Error)
+	assert.Equal(t, "Asia/Jakarta", timezone.Value)
+
+	_, err = service.Complete(&SetupData{Username: "intruder", Password: "long enough"})
+	assert.ErrorIs(t, err, ErrSetupCompleted)
+}
+
+func TestSetupServiceConcurrentCallsCreateOneAdmin(t *testing.T) {
+	cfg := setupTestDB(t)
+	service := NewSetupService(cfg)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = service.Complete(&SetupData{Username: "admin", Password: "long enough"})
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(t, err, ErrSetupCompleted)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+}
+
+func TestCreateDefaultUserRequiresConfiguredPassword(t *testing.T) {
+	cfg := setupTestDB(t)
+	cfg.DefaultUser.Username = "admin"
+	service := NewAuthService(cfg)
+
+	require.NoError(t, service.CreateDefaultUser())
+	status, err := NewSetupService(cfg).Status()
+	require.NoError(t, err)
+	assert.True(t, status.SetupRequired, "no user is created without a configured password")
+
+	cfg.DefaultUser.Password = "changeme"
+	cfg.DefaultUser.Role = "admin"
+	require.NoError(t, service.CreateDefaultUser())
+	status, err = NewSetupService(cfg).Status()
+	require.NoError(t, err)
+	assert.False(t, status.SetupRequired)
+}
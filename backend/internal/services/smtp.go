@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"r-panel/internal/config"
+)
+
+var ErrSMTPNotConfigured = errors.New("SMTP is not configured")
+
+type SMTPService struct {
+	cfg config.SMTPConfig
+}
+
+func NewSMTPService(cfg config.SMTPConfig) *SMTPService {
+	return &SMTPService{cfg: cfg}
+}
+
+// IsConfigured reports whether an SMTP host and sender address are set
+func (s *SMTPService) IsConfigured() bool {
+	return s.cfg.Host != "" && s.cfg.From != ""
+}
+
+// SendEmail sends a plain-text email to a single recipient
+func (s *SMTPService) SendEmail(to, subject, body string) error {
+	if !s.IsConfigured() {
+		return ErrSMTPNotConfigured
+	}
+
+	from, err := mail.ParseAddress(s.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	port := s.cfg.Port
+	if port == 0 {
+		port = 25
+	}
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	message := buildEmailMessage(from.String(), recipient.String(), subject, body)
+	if err := smtp.SendMail(addr, auth, from.Address, []string{recipient.Address}, message); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// buildEmailMessage assembles the headers and body of a plain-text message
+func buildEmailMessage(from, to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"r-panel/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSMTPServer is a minimal SMTP server that records delivered messages
+type mockSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []mockSMTPMessage
+	done     chan struct{}
+}
+
+type mockSMTPMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+func newMockSMTPServer(t *testing.T) *mockSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &mockSMTPServer{listener: listener, done: make(chan struct{})}
+	go server.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		<-server.done
+	})
+
+	return server
+}
+
+func (s *mockSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *mockSMTPServer) delivered() []mockSMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mockSMTPMessage(nil), s.messages...)
+}
+
+func (s *mockSMTPServer) serve() {
+	defer close(s.done)
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.handle(conn)
+	}
+}
+
+func (s *mockSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var msg mockSMTPMessage
+	reply("220 mock ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 mock")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			msg = mockSMTPMessage{From: strings.Trim(strings.TrimSpace(line)[10:], "<>")}
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			msg.To = append(msg.To, strings.Trim(strings.TrimSpace(line)[8:], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			msg.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 OK: queued")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPServiceSendEmail(t *testing.T) {
+	server := newMockSMTPServer(t)
+
+	service := NewSMTPService(config.SMTPConfig{
+		Host: "127.0.0.1",
+		Port: server.port(),
+		From: "R-Panel <panel@example.com>",
+	})
+
+	err := service.SendEmail("admin@example.com", "R-Panel test email", "Hello from the panel")
+	require.NoError(t, err)
+
+	messages := server.delivered()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "panel@example.com", messages[0].From)
+	assert.Equal(t, []string{"admin@example.com"}, messages[0].To)
+	assert.Contains(t, messages[0].Data, "Subject: R-Panel test email")
+	assert.Contains(t, messages[0].Data, "Hello from the panel")
+}
+
+func TestSMTPServiceErrors(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		err := NewSMTPService(config.SMTPConfig{}).SendEmail("admin@example.com", "subject", "body")
+		assert.ErrorIs(t, err, ErrSMTPNotConfigured)
+	})
+
+	t.Run("invalid recipient", func(t *testing.T) {
+		service := NewSMTPService(config.SMTPConfig{Host: "127.0.0.1", From: "panel@example.com"})
+		assert.Error(t, service.SendEmail("not-an-address", "subject", "body"))
+	})
+
+	t.Run("server unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+
+		service := NewSMTPService(config.SMTPConfig{Host: "127.0.0.1", Port: port, From: "panel@example.com"})
+		err = service.SendEmail("admin@example.com", "subject", "body")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), strconv.Itoa(port))
+	})
+}
@@ -2,8 +2,11 @@ package services
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -11,15 +14,21 @@ import (
 )
 
 type SystemStats struct {
-	CPU    CPUStats    `json:"cpu"`
-	Memory MemoryStats `json:"memory"`
-	Disk   []DiskStats `json:"disk"`
-	Uptime string      `json:"uptime"`
+	CPU    CPUStats      `json:"cpu"`
+	Memory MemoryStats   `json:"memory"`
+	Disk   []DiskStats   `json:"disk"`
+	DiskIO []DiskIOStats `json:"disk_io"`
+	Uptime string        `json:"uptime"`
+	Time   TimeStatus    `json:"time"`
+
+	// Unavailable lists metrics that could not be collected on this platform, keyed by metric name
+	Unavailable map[string]string `json:"unavailable,omitempty"`
 }
 
 type CPUStats struct {
-	UsagePercent float64 `json:"usage_percent"`
-	Cores        int     `json:"cores"`
+	UsagePercent float64   `json:"usage_percent"`
+	PerCore      []float64 `json:"per_core"` // usage percent of cpu0, cpu1, ...
+	Cores        int       `json:"cores"`
 }
 
 type MemoryStats struct {
@@ -38,6 +47,32 @@ type DiskStats struct {
 	Avail      string `json:"avail"`
 	UsePercent string `json:"use_percent"`
 	MountedOn  string `json:"mounted_on"`
+
+	// Inode usage from df -i, zero when the file system does not report it
+	InodesUsed       uint64 `json:"inodes_used"`
+	InodesFree       uint64 `json:"inodes_free"`
+	InodesUsePercent string `json:"inodes_use_percent"`
+}
+
+// TimeStatus describes the server clock. Synchronization is unknown (nil) when
+// timedatectl is not available.
+type TimeStatus struct {
+	Timezone     string    `json:"timezone"`
+	ServerTime   time.Time `json:"server_time"`
+	NTPEnabled   *bool     `json:"ntp_enabled"`
+	Synchronized *bool     `json:"synchronized"`
+
+	// Unavailable says why the sync status could not be read
+	Unavailable string `json:"unavailable,omitempty"`
+}
+
+// DiskIOStats is the throughput of a block device over a short sample
+type DiskIOStats struct {
+	Device           string  `json:"device"`
+	ReadBytesPerSec  float64 `json:"read_bytes_per_sec"`
+	WriteBytesPerSec float64 `json:"write_bytes_per_sec"`
+	ReadIOPS         float64 `json:"read_iops"`
+	WriteIOPS        float64 `json:"write_iops"`
 }
 
 type ServiceStatus struct {
@@ -54,97 +89,181 @@ type ProcessInfo struct {
 	Command string  `json:"command"`
 }
 
-type SystemService struct{}
+// ErrUnsupportedPlatform is returned by collectors whose data source does not exist on this system
+var ErrUnsupportedPlatform = errors.New("unsupported on this platform")
+
+type SystemService struct {
+	fs     FileSystem
+	runner CommandRunner
+}
 
 func NewSystemService() *SystemService {
-	return &SystemService{}
+	return NewSystemServiceWithDeps(osFileSystem{}, osCommandRunner{})
 }
 
-// GetStats returns current system statistics
-func (s *SystemService) GetStats() (*SystemStats, error) {
-	cpu, err := s.getCPUStats()
+// NewSystemServiceWithDeps creates a system service that reads /proc through fsys and runs commands through runner
+func NewSystemServiceWithDeps(fsys FileSystem, runner CommandRunner) *SystemService {
+	return &SystemService{fs: fsys, runner: runner}
+}
+
+// readProc reads a /proc file, reporting a missing file as ErrUnsupportedPlatform
+func (s *SystemService) readProc(name string) ([]byte, error) {
+	data, err := s.fs.ReadFile(name)
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%s: %w", name, ErrUnsupportedPlatform)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetStats returns current system statistics. Metrics that are not supported on
+// this platform are reported in Unavailable instead of failing the whole call.
+func (s *SystemService) GetStats(ctx context.Context) (*SystemStats, error) {
+	stats := &SystemStats{
+		Disk:   []DiskStats{},
+		DiskIO: []DiskIOStats{},
+		Uptime: "unknown",
+	}
+
+	markUnavailable := func(metric string, err error) {
+		if stats.Unavailable == nil {
+			stats.Unavailable = map[string]string{}
+		}
+		stats.Unavailable[metric] = err.Error()
+	}
+
+	cpu, err := s.getCPUStats()
+	switch {
+	case errors.Is(err, ErrUnsupportedPlatform):
+		markUnavailable("cpu", err)
+	case err != nil:
 		return nil, fmt.Errorf("failed to get CPU stats: %w", err)
+	default:
+		stats.CPU = *cpu
 	}
 
 	memory, err := s.getMemoryStats()
-	if err != nil {
+	switch {
+	case errors.Is(err, ErrUnsupportedPlatform):
+		markUnavailable("memory", err)
+	case err != nil:
 		return nil, fmt.Errorf("failed to get memory stats: %w", err)
+	default:
+		stats.Memory = *memory
 	}
 
-	disk, err := s.getDiskStats()
-	if err != nil {
+	disk, err := s.getDiskStats(ctx)
+	switch {
+	case errors.Is(err, ErrUnsupportedPlatform):
+		markUnavailable("disk", err)
+	case err != nil:
 		return nil, fmt.Errorf("failed to get disk stats: %w", err)
+	default:
+		stats.Disk = disk
+	}
+
+	diskIO, err := s.GetDiskIOStats(false)
+	switch {
+	case errors.Is(err, ErrUnsupportedPlatform):
+		markUnavailable("disk_io", err)
+	case err != nil:
+		return nil, fmt.Errorf("failed to get disk I/O stats: %w", err)
+	default:
+		stats.DiskIO = diskIO
 	}
 
 	uptime, err := s.getUptime()
 	if err != nil {
-		uptime = "unknown"
+		markUnavailable("uptime", err)
+	} else {
+		stats.Uptime = uptime
 	}
 
-	return &SystemStats{
-		CPU:    *cpu,
-		Memory: *memory,
-		Disk:   disk,
-		Uptime: uptime,
-	}, nil
+	stats.Time = s.GetTimeStatus(ctx)
+	if stats.Time.Unavailable != "" {
+		markUnavailable("time_sync", errors.New(stats.Time.Unavailable))
+	}
+
+	return stats, nil
 }
 
-// getCPUStats reads CPU usage from /proc/stat
+// cpuTimes are the user, nice, system and idle times of a cpu line in /proc/stat
+type cpuTimes struct {
+	user, nice, system, idle int64
+}
+
+// usageSince returns the percentage of time t spent busy since the earlier sample prev
+func (t cpuTimes) usageSince(prev cpuTimes) float64 {
+	totalDelta := (t.user + t.nice + t.system + t.idle) - (prev.user + prev.nice + prev.system + prev.idle)
+	idleDelta := t.idle - prev.idle
+	if totalDelta <= 0 {
+		return 0
+	}
+	return float64(totalDelta-idleDelta) / float64(totalDelta) * 100
+}
+
+// readCPUTimes returns the aggregate times from /proc/stat and those of each
+// core, in the order of the cpu0, cpu1, ... lines
+func (s *SystemService) readCPUTimes() (total cpuTimes, cores []cpuTimes, err error) {
+	data, err := s.readProc("/proc/stat")
+	if err != nil {
+		return cpuTimes{}, nil, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		name, _, _ := strings.Cut(line, " ")
+		if !strings.HasPrefix(name, "cpu") {
+			continue
+		}
+		var times cpuTimes
+		fmt.Sscanf(strings.TrimSpace(strings.TrimPrefix(line, name)), "%d %d %d %d", &times.user, &times.nice, &times.system, &times.idle)
+		if name == "cpu" {
+			total = times
+		} else {
+			cores = append(cores, times)
+		}
+	}
+	return total, cores, nil
+}
+
+// getCPUStats reads CPU usage, overall and per core, from /proc/stat
 func (s *SystemService) getCPUStats() (*CPUStats, error) {
 	// First reading
-	file1, err := os.Open("/proc/stat")
+	total1, cores1, err := s.readCPUTimes()
 	if err != nil {
 		return nil, err
 	}
-	defer file1.Close()
-
-	var user1, nice1, system1, idle1 int64
-	scanner := bufio.NewScanner(file1)
-	if scanner.Scan() {
-		line := scanner.Text()
-		fmt.Sscanf(line, "cpu %d %d %d %d", &user1, &nice1, &system1, &idle1)
-	}
 
 	// Wait a bit
 	time.Sleep(100 * time.Millisecond)
 
 	// Second reading
-	file2, err := os.Open("/proc/stat")
+	total2, cores2, err := s.readCPUTimes()
 	if err != nil {
 		return nil, err
 	}
-	defer file2.Close()
-
-	var user2, nice2, system2, idle2 int64
-	scanner2 := bufio.NewScanner(file2)
-	if scanner2.Scan() {
-		line := scanner2.Text()
-		fmt.Sscanf(line, "cpu %d %d %d %d", &user2, &nice2, &system2, &idle2)
-	}
-
-	total1 := user1 + nice1 + system1 + idle1
-	total2 := user2 + nice2 + system2 + idle2
-	totalDelta := total2 - total1
-	idleDelta := idle2 - idle1
 
-	var usagePercent float64
-	if totalDelta > 0 {
-		usagePercent = float64(totalDelta-idleDelta) / float64(totalDelta) * 100
+	// A core going offline in between shortens the list, compare what both have
+	perCore := make([]float64, min(len(cores1), len(cores2)))
+	for i := range perCore {
+		perCore[i] = cores2[i].usageSince(cores1[i])
 	}
 
 	// Get CPU cores
 	cores, _ := s.getCPUCores()
 
 	return &CPUStats{
-		UsagePercent: usagePercent,
+		UsagePercent: total2.usageSince(total1),
+		PerCore:      perCore,
 		Cores:        cores,
 	}, nil
 }
 
 // getCPUCores returns number of CPU cores
 func (s *SystemService) getCPUCores() (int, error) {
-	data, err := os.ReadFile("/proc/cpuinfo")
+	data, err := s.readProc("/proc/cpuinfo")
 	if err != nil {
 		return 0, err
 	}
@@ -156,14 +275,13 @@ func (s *SystemService) getCPUCores() (int, error) {
 
 // getMemoryStats reads memory info from /proc/meminfo
 func (s *SystemService) getMemoryStats() (*MemoryStats, error) {
-	file, err := os.Open("/proc/meminfo")
+	data, err := s.readProc("/proc/meminfo")
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
 	stats := &MemoryStats{}
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -202,10 +320,12 @@ func (s *SystemService) getMemoryStats() (*MemoryStats, error) {
 }
 
 // getDiskStats runs df command to get disk usage
-func (s *SystemService) getDiskStats() ([]DiskStats, error) {
-	cmd := exec.Command("df", "-h")
-	output, err := cmd.Output()
+func (s *SystemService) getDiskStats(ctx context.Context) ([]DiskStats, error) {
+	output, err := s.runner.Output(ctx, "df", "-h")
 	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("df: %w", ErrUnsupportedPlatform)
+		}
 		return nil, err
 	}
 
@@ -232,12 +352,150 @@ func (s *SystemService) getDiskStats() ([]DiskStats, error) {
 		})
 	}
 
+	// Inodes run out on mail and cache servers while bytes are still free.
+	// Without df -i the byte usage is still worth returning.
+	if inodes, err := s.getInodeStats(ctx); err == nil {
+		for i := range disks {
+			if usage, ok := inodes[disks[i].MountedOn]; ok {
+				disks[i].InodesUsed = usage.InodesUsed
+				disks[i].InodesFree = usage.InodesFree
+				disks[i].InodesUsePercent = usage.InodesUsePercent
+			}
+		}
+	}
+
 	return disks, nil
 }
 
+// getInodeStats runs df -i and returns the inode usage by mount point
+func (s *SystemService) getInodeStats(ctx context.Context) (map[string]DiskStats, error) {
+	output, err := s.runner.Output(ctx, "df", "-i")
+	if err != nil {
+		return nil, err
+	}
+
+	inodes := map[string]DiskStats{}
+	for i, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 6 {
+			continue // Skip header
+		}
+		// Some file systems, like vfat, show "-" for counts they do not keep
+		used, _ := strconv.ParseUint(fields[2], 10, 64)
+		free, _ := strconv.ParseUint(fields[3], 10, 64)
+		inodes[fields[5]] = DiskStats{
+			InodesUsed:       used,
+			InodesFree:       free,
+			InodesUsePercent: fields[4],
+		}
+	}
+	return inodes, nil
+}
+
+// diskCounters are the cumulative counters of a device line in /proc/diskstats
+type diskCounters struct {
+	reads, readSectors, writes, writeSectors uint64
+}
+
+// diskSectorSize is the unit /proc/diskstats counts sectors in, whatever the device uses
+const diskSectorSize = 512
+
+// virtualDiskPrefixes name devices that are not backed by a disk
+var virtualDiskPrefixes = []string{"loop", "ram", "zram"}
+
+func isVirtualDisk(device string) bool {
+	for _, prefix := range virtualDiskPrefixes {
+		if strings.HasPrefix(device, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readDiskCounters parses /proc/diskstats, returning the devices in file order
+func (s *SystemService) readDiskCounters() ([]string, map[string]diskCounters, error) {
+	data, err := s.readProc("/proc/diskstats")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var devices []string
+	counters := map[string]diskCounters{}
+	for _, line := range strings.Split(string(data), "\n") {
+		// major minor name reads merged sectors ms writes merged sectors ...
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		values := make([]uint64, 7)
+		valid := true
+		for i := range values {
+			value, err := strconv.ParseUint(fields[3+i], 10, 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			values[i] = value
+		}
+		if !valid {
+			continue
+		}
+		devices = append(devices, fields[2])
+		counters[fields[2]] = diskCounters{reads: values[0], readSectors: values[2], writes: values[4], writeSectors: values[6]}
+	}
+	return devices, counters, nil
+}
+
+// GetDiskIOStats samples /proc/diskstats twice and returns the read and write
+// rate of every device. Loop, ram and zram devices are left out unless
+// includeVirtual is set.
+func (s *SystemService) GetDiskIOStats(includeVirtual bool) ([]DiskIOStats, error) {
+	_, before, err := s.readDiskCounters()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	time.Sleep(100 * time.Millisecond)
+
+	devices, after, err := s.readDiskCounters()
+	if err != nil {
+		return nil, err
+	}
+	return diskIORates(devices, before, after, time.Since(start).Seconds(), includeVirtual), nil
+}
+
+// diskIORates turns two samples of the counters taken seconds apart into rates
+func diskIORates(devices []string, before, after map[string]diskCounters, seconds float64, includeVirtual bool) []DiskIOStats {
+	// Counters only go backwards when a device was replaced in between
+	rate := func(from, to uint64) float64 {
+		if to < from {
+			return 0
+		}
+		return float64(to-from) / seconds
+	}
+
+	stats := []DiskIOStats{}
+	for _, device := range devices {
+		prev, ok := before[device]
+		if !ok || (!includeVirtual && isVirtualDisk(device)) {
+			continue
+		}
+		cur := after[device]
+		stats = append(stats, DiskIOStats{
+			Device:           device,
+			ReadBytesPerSec:  rate(prev.readSectors, cur.readSectors) * diskSectorSize,
+			WriteBytesPerSec: rate(prev.writeSectors, cur.writeSectors) * diskSectorSize,
+			ReadIOPS:         rate(prev.reads, cur.reads),
+			WriteIOPS:        rate(prev.writes, cur.writes),
+		})
+	}
+	return stats
+}
+
 // getUptime reads system uptime
 func (s *SystemService) getUptime() (string, error) {
-	data, err := os.ReadFile("/proc/uptime")
+	data, err := s.readProc("/proc/uptime")
 	if err != nil {
 		return "", err
 	}
@@ -260,10 +518,71 @@ func (s *SystemService) getUptime() (string, error) {
 	return fmt.Sprintf("%dd %dh %dm", days, hours, minutes), nil
 }
 
+// GetTimeStatus returns the timezone, the current server time and whether the
+// clock is synchronized over NTP according to timedatectl. Without timedatectl
+// the timezone comes from /etc/timezone or the local zone name.
+func (s *SystemService) GetTimeStatus(ctx context.Context) TimeStatus {
+	status := TimeStatus{ServerTime: time.Now()}
+
+	output, err := s.runner.Output(ctx, "timedatectl", "show")
+	if err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "Timezone":
+				status.Timezone = value
+			case "NTP":
+				status.NTPEnabled = parseTimedatectlBool(value)
+			case "NTPSynchronized":
+				status.Synchronized = parseTimedatectlBool(value)
+			}
+		}
+	} else if errors.Is(err, exec.ErrNotFound) {
+		status.Unavailable = fmt.Sprintf("timedatectl: %s", ErrUnsupportedPlatform)
+	} else {
+		// Usually systemd is not running, as in most containers
+		status.Unavailable = fmt.Sprintf("timedatectl: %s", err)
+	}
+
+	if status.Timezone == "" {
+		status.Timezone = s.localTimezone()
+	}
+	return status
+}
+
+// localTimezone names the system timezone when timedatectl cannot
+func (s *SystemService) localTimezone() string {
+	if data, err := s.fs.ReadFile("/etc/timezone"); err == nil {
+		if name := strings.TrimSpace(string(data)); name != "" {
+			return name
+		}
+	}
+	name, _ := time.Now().Zone()
+	return name
+}
+
+func parseTimedatectlBool(value string) *bool {
+	switch value {
+	case "yes":
+		v := true
+		return &v
+	case "no":
+		v := false
+		return &v
+	}
+	return nil
+}
+
 // GetServiceStatus checks status of a systemd service
-func (s *SystemService) GetServiceStatus(serviceName string) (*ServiceStatus, error) {
-	cmd := exec.Command("systemctl", "is-active", serviceName)
-	output, err := cmd.Output()
+func (s *SystemService) GetServiceStatus(ctx context.Context, serviceName string) (*ServiceStatus, error) {
+	output, err := s.runner.Output(ctx, "systemctl", "is-active", serviceName)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// Killed by the deadline, the service may well be running
+		return nil, ctxErr
+	}
 	if err != nil {
 		return &ServiceStatus{
 			Name:   serviceName,
@@ -282,13 +601,25 @@ func (s *SystemService) GetServiceStatus(serviceName string) (*ServiceStatus, er
 	}, nil
 }
 
+// serviceCommandError describes a failed systemctl action with what systemctl
+// printed, which usually says why
+func serviceCommandError(action, service string, output []byte, err error) error {
+	if message := strings.TrimSpace(string(output)); message != "" {
+		return fmt.Errorf("systemctl %s %s failed: %s", action, service, message)
+	}
+	return fmt.Errorf("systemctl %s %s failed: %w", action, service, err)
+}
+
 // GetServicesStatus checks status of multiple services
-func (s *SystemService) GetServicesStatus(serviceNames []string) ([]ServiceStatus, error) {
+func (s *SystemService) GetServicesStatus(ctx context.Context, serviceNames []string) ([]ServiceStatus, error) {
 	var services []ServiceStatus
 
 	for _, name := range serviceNames {
-		status, err := s.GetServiceStatus(name)
+		status, err := s.GetServiceStatus(ctx, name)
 		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
 			continue
 		}
 		services = append(services, *status)
@@ -298,9 +629,8 @@ func (s *SystemService) GetServicesStatus(serviceNames []string) ([]ServiceStatu
 }
 
 // GetTopProcesses returns top processes by CPU and Memory
-func (s *SystemService) GetTopProcesses(limit int) ([]ProcessInfo, error) {
-	cmd := exec.Command("ps", "aux", "--sort=-%cpu", "--no-headers")
-	output, err := cmd.Output()
+func (s *SystemService) GetTopProcesses(ctx context.Context, limit int) ([]ProcessInfo, error) {
+	output, err := s.runner.Output(ctx, "ps", "aux", "--sort=-%cpu", "--no-headers")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeProc returns a file system holding the given /proc files and a runner answering df
+func newFakeProc(files map[string]string) (*fakeFileSystem, *fakeCommandRunner) {
+	fsys := newFakeFileSystem("/proc")
+	for name, data := range files {
+		fsys.WriteFile(name, []byte(data), 0444)
+	}
+
+	runner := newFakeCommandRunner()
+	runner.on("df -h", "Filesystem Size Used Avail Use% Mounted on\n/dev/sda1 20G 5G 15G 25% /\n", nil)
+	return fsys, runner
+}
+
+func TestSystemServiceGetStatsPartial(t *testing.T) {
+	fsys, runner := newFakeProc(map[string]string{
+		"/proc/stat":    "cpu  100 0 50 850 0 0 0 0 0 0\ncpu0 100 0 50 850 0 0 0 0 0 0\n",
+		"/proc/cpuinfo": "processor\t: 0\nmodel name\t: test\n\nprocessor\t: 1\nmodel name\t: test\n",
+		"/proc/uptime":  "93784.12 180000.00\n",
+		// /proc/meminfo is missing
+	})
+
+	stats, err := NewSystemServiceWithDeps(fsys, runner).GetStats(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, stats.CPU.Cores)
+	assert.Equal(t, "1d 2h 3m", stats.Uptime)
+
+	require.Contains(t, stats.Unavailable, "memory")
+	assert.Contains(t, stats.Unavailable["memory"], ErrUnsupportedPlatform.Error())
+	assert.NotContains(t, stats.Unavailable, "cpu")
+	assert.NotContains(t, stats.Unavailable, "uptime")
+	assert.Zero(t, stats.Memory.Total)
+
+	require.Len(t, stats.Disk, 1)
+	assert.Equal(t, "/", stats.Disk[0].MountedOn)
+}
+
+func TestSystemServiceDiskStatsInodes(t *testing.T) {
+	fsys, runner := newFakeProc(nil)
+	runner.on("df -h", "Filesystem Size Used Avail Use% Mounted on\n/dev/sda1 20G 5G 15G 25% /\n/dev/sdb1 100G 10G 90G 10% /var/mail\n/dev/sdc1 1G 1M 1G 1% /boot/efi\n", nil)
+	runner.on("df -i", "Filesystem Inodes IUsed IFree IUse% Mounted on\n/dev/sda1 1310720 131072 1179648 10% /\n/dev/sdb1 6553600 6553500 100 100% /var/mail\n/dev/sdc1 0 0 0 - /boot/efi\n", nil)
+
+	disks, err := NewSystemServiceWithDeps(fsys, runner).getDiskStats(context.Background())
+	require.NoError(t, err)
+	require.Len(t, disks, 3)
+
+	assert.Equal(t, uint64(131072), disks[0].InodesUsed)
+	assert.Equal(t, uint64(1179648), disks[0].InodesFree)
+	assert.Equal(t, "10%", disks[0].InodesUsePercent)
+	assert.Equal(t, "10%", disks[1].UsePercent)
+	assert.Equal(t, "100%", disks[1].InodesUsePercent)
+	assert.Equal(t, uint64(100), disks[1].InodesFree)
+	assert.Equal(t, "-", disks[2].InodesUsePercent)
+	assert.Zero(t, disks[2].InodesUsed)
+}
+
+func TestSystemServiceGetStatsWithoutProc(t *testing.T) {
+	fsys, runner := newFakeProc(nil)
+	stats, err := NewSystemServiceWithDeps(fsys, runner).GetStats(context.Background())
+	require.NoError(t, err)
+
+	for _, metric := range []string{"cpu", "memory", "uptime"} {
+		assert.Contains(t, stats.Unavailable, metric)
+	}
+	assert.Equal(t, "unknown", stats.Uptime)
+}
+
+func TestSystemServiceGetServiceStatus(t *testing.T) {
+	runner := newFakeCommandRunner()
+	runner.on("systemctl is-active nginx", "active\n", nil)
+	runner.on("systemctl is-active mysql", "failed\n", nil)
+
+	service := NewSystemServiceWithDeps(newFakeFileSystem(), runner)
+	statuses, err := service.GetServicesStatus(context.Background(), []string{"nginx", "mysql", "missing"})
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+
+	assert.True(t, statuses[0].Active)
+	assert.Equal(t, "failed", statuses[1].Status)
+	assert.False(t, statuses[1].Active)
+	assert.Equal(t, "inactive", statuses[2].Status)
+}
+
+func TestSystemServiceGetTimeStatus(t *testing.T) {
+	runner := newFakeCommandRunner()
+	runner.on("timedatectl show", "Timezone=Asia/Jakarta\nLocalRTC=no\nCanNTP=yes\nNTP=yes\nNTPSynchronized=no\nTimeUSec=Fri 2026-10-16 10:00:00 WIB\n", nil)
+
+	status := NewSystemServiceWithDeps(newFakeFileSystem(), runner).GetTimeStatus(context.Background())
+	assert.Equal(t, "Asia/Jakarta", status.Timezone)
+	assert.False(t, status.ServerTime.IsZero())
+	require.NotNil(t, status.NTPEnabled)
+	assert.True(t, *status.NTPEnabled)
+	require.NotNil(t, status.Synchronized)
+	assert.False(t, *status.Synchronized)
+	assert.Empty(t, status.Unavailable)
+}
+
+func TestSystemServiceGetTimeStatusWithoutTimedatectl(t *testing.T) {
+	fsys, runner := newFakeProc(nil)
+	fsys.mkdir("/etc")
+	fsys.WriteFile("/etc/timezone", []byte("Europe/Berlin\n"), 0644)
+	runner.on("timedatectl show", "", exec.ErrNotFound)
+
+	stats, err := NewSystemServiceWithDeps(fsys, runner).GetStats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", stats.Time.Timezone)
+	assert.Nil(t, stats.Time.Synchronized)
+	assert.Nil(t, stats.Time.NTPEnabled)
+	assert.Contains(t, stats.Time.Unavailable, ErrUnsupportedPlatform.Error())
+	assert.Contains(t, stats.Unavailable, "time_sync")
+}
+
+func TestSystemServiceReadCPUTimesPerCore(t *testing.T) {
+	fsys, runner := newFakeProc(map[string]string{
+		"/proc/stat": "cpu  300 0 100 1600 0 0 0 0 0 0\ncpu0 250 0 50 700 0 0 0 0 0 0\ncpu1 50 0 50 900 0 0 0 0 0 0\nintr 12345\nctxt 678\n",
+	})
+	service := NewSystemServiceWithDeps(fsys, runner)
+
+	total, cores, err := service.readCPUTimes()
+	require.NoError(t, err)
+	assert.Equal(t, cpuTimes{user: 300, system: 100, idle: 1600}, total)
+	require.Len(t, cores, 2)
+	assert.Equal(t, cpuTimes{user: 50, system: 50, idle: 900}, cores[1])
+
+	// cpu0 saturated, cpu1 idle over the interval
+	assert.InDelta(t, 100, cpuTimes{user: 350, system: 50, idle: 700}.usageSince(cores[0]), 0.001)
+	assert.InDelta(t, 0, cpuTimes{user: 50, system: 50, idle: 1000}.usageSince(cores[1]), 0.001)
+	assert.Zero(t, cores[1].usageSince(cores[1]))
+
+	stats, err := service.GetStats(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, stats.CPU.PerCore, 2)
+}
+
+func TestSystemServiceDiskIOStats(t *testing.T) {
+	fsys, runner := newFakeProc(map[string]string{
+		"/proc/diskstats": "   7       0 loop0 50 0 400 10 0 0 0 0 0 10 10\n" +
+			"   8       0 sda 1000 20 80000 500 2000 30 160000 900 0 1200 1400 0 0 0 0\n" +
+			"   8       1 sda1 900 20 72000 450 1900 30 150000 850 0 1100 1300 0 0 0 0\n",
+	})
+	service := NewSystemServiceWithDeps(fsys, runner)
+
+	devices, before, err := service.readDiskCounters()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"loop0", "sda", "sda1"}, devices)
+	assert.Equal(t, diskCounters{reads: 1000, readSectors: 80000, writes: 2000, writeSectors: 160000}, before["sda"])
+
+	after := map[string]diskCounters{
+		"loop0": before["loop0"],
+		"sda":   {reads: 1100, readSectors: 82000, writes: 2400, writeSectors: 168000},
+		"sda1":  {reads: 1000, readSectors: 70000, writes: 1900, writeSectors: 150000}, // replaced, counters reset
+	}
+	stats := diskIORates(devices, before, after, 2, false)
+	require.Len(t, stats, 2)
+	assert.Equal(t, DiskIOStats{Device: "sda", ReadBytesPerSec: 1000 * 512, WriteBytesPerSec: 4000 * 512, ReadIOPS: 50, WriteIOPS: 200}, stats[0])
+	assert.Equal(t, DiskIOStats{Device: "sda1", ReadIOPS: 50}, stats[1])
+
+	assert.Len(t, diskIORates(devices, before, after, 2, true), 3)
+
+	all, err := service.GetStats(context.Background())
+	require.NoError(t, err)
+	require.Len(t, all.DiskIO, 2)
+	assert.Zero(t, all.DiskIO[0].ReadIOPS)
+	assert.NotContains(t, all.Unavailable, "disk_io")
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"io"
+	"time"
+)
+
+// throttleInterval is how much data, in time, is read between pauses
+const throttleInterval = 100 * time.Millisecond
+
+// throttledReadSeeker limits how fast an io.ReadSeeker is read
+type throttledReadSeeker struct {
+	rs    io.ReadSeeker
+	rate  int64 // bytes per second
+	start time.Time
+	read  int64 // since start
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewThrottledReadSeeker returns rs read at no more than bytesPerSecond, or rs
+// itself when bytesPerSecond is not positive. Seeking keeps working, so it can
+// be handed to http.ServeContent for range requests.
+func NewThrottledReadSeeker(rs io.ReadSeeker, bytesPerSecond int64) io.ReadSeeker {
+	if bytesPerSecond <= 0 {
+		return rs
+	}
+	return &throttledReadSeeker{rs: rs, rate: bytesPerSecond, now: time.Now, sleep: time.Sleep}
+}
+
+func (t *throttledReadSeeker) Read(p []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = t.now()
+	}
+	// Read in small chunks so the pauses stay short and the rate even
+	chunk := max(t.rate*int64(throttleInterval)/int64(time.Second), 1)
+	if int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+
+	n, err := t.rs.Read(p)
+	t.read += int64(n)
+	due := time.Duration(t.read * int64(time.Second) / t.rate)
+	if wait := due - t.now().Sub(t.start); wait > 0 {
+		t.sleep(wait)
+	}
+	return n, err
+}
+
+// Seek starts the rate over, ServeContent seeks before sending a range
+func (t *throttledReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	t.start = time.Time{}
+	t.read = 0
+	return t.rs.Seek(offset, whence)
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottledReadSeeker(t *testing.T) {
+	data := strings.Repeat("x", 10<<10)
+	plain := bytes.NewReader([]byte(data))
+	assert.Same(t, plain, NewThrottledReadSeeker(plain, 0))
+
+	// 10 KiB at 4 KiB/s takes about 2.5 seconds of pauses
+	rs := NewThrottledReadSeeker(bytes.NewReader([]byte(data)), 4<<10).(*throttledReadSeeker)
+	clock := time.Now()
+	var slept time.Duration
+	rs.now = func() time.Time { return clock }
+	rs.sleep = func(d time.Duration) {
+		slept += d
+		clock = clock.Add(d)
+	}
+
+	out, err := io.ReadAll(rs)
+	require.NoError(t, err)
+	assert.Equal(t, data, string(out))
+	assert.InDelta(t, 2500*time.Millisecond, slept, float64(200*time.Millisecond))
+
+	// Seeking works for range requests and starts the rate over
+	_, err = rs.Seek(-5, io.SeekEnd)
+	require.NoError(t, err)
+	slept = 0
+	out, err = io.ReadAll(rs)
+	require.NoError(t, err)
+	assert.Equal(t, "xxxxx", string(out))
+	assert.Less(t, slept, 10*time.Millisecond)
+}
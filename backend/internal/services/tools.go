@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"r-panel/internal/config"
+)
+
+var (
+	ErrInvalidDNSType = errors.New("invalid record type: use A, AAAA, MX, TXT or CNAME")
+	ErrInvalidIP      = errors.New("invalid IP address")
+	ErrDNSLookup      = errors.New("DNS lookup failed")
+	ErrInvalidPort    = errors.New("invalid port: use 1 to 65535")
+	ErrHostNotAllowed = errors.New("host is not in tools.portcheck_allowed_hosts")
+)
+
+// dnsLookupTimeout bounds each lookup so a dead resolver does not hang the request
+const dnsLookupTimeout = 5 * time.Second
+
+// Port check timeouts: the default, and the most a caller may ask for
+const (
+	DefaultPortCheckTimeout = 3 * time.Second
+	MaxPortCheckTimeout     = 10 * time.Second
+)
+
+// Port check outcomes
+const (
+	PortOpen    = "open"
+	PortClosed  = "closed"
+	PortTimeout = "timeout"
+)
+
+// DNSResolver is the part of net.Resolver the tools use
+type DNSResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// DNSRecord is one answer of a lookup. Priority is only set for MX records.
+type DNSRecord struct {
+	Type     string  `json:"type"`
+	Value    string  `json:"value"`
+	Priority *uint16 `json:"priority,omitempty"`
+}
+
+// DNSLookupResult holds the answers for a name, empty when it does not exist
+type DNSLookupResult struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Records []DNSRecord `json:"records"`
+}
+
+// PortCheckResult is the outcome of a TCP connection attempt
+type PortCheckResult struct {
+	Host      string  `json:"host"`
+	Port      int     `json:"port"`
+	Address   string  `json:"address"` // the resolved address that was dialed
+	Status    string  `json:"status"`  // open, closed or timeout
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ToolsService runs diagnostics such as DNS lookups for admins debugging sites and mail
+type ToolsService struct {
+	resolver DNSResolver
+	dial     func(network, address string, timeout time.Duration) (net.Conn, error)
+
+	// Port check allowlist, both empty allows any host
+	allowedHosts    []string
+	allowedNetworks []*net.IPNet
+}
+
+// NewToolsService creates a tools service with the port check allowlist of
+// cfg. Config.Load has already validated it.
+func NewToolsService(cfg config.ToolsConfig) *ToolsService {
+	service := NewToolsServiceWithResolver(net.DefaultResolver)
+	service.allowedHosts, service.allowedNetworks, _ = cfg.PortCheckAllowlist()
+	return service
+}
+
+// NewToolsServiceWithResolver creates a tools service that resolves names through resolver
+func NewToolsServiceWithResolver(resolver DNSResolver) *ToolsService {
+	return &ToolsService{resolver: resolver, dial: net.DialTimeout}
+}
+
+// LookupDNS returns the records of type recordType for name
+func (s *ToolsService) LookupDNS(name, recordType string) (*DNSLookupResult, error) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".")
+	if err := ValidateDomain(name); err != nil {
+		return nil, err
+	}
+	recordType = strings.ToUpper(strings.TrimSpace(recordType))
+	if recordType == "" {
+		recordType = "A"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+
+	result := &DNSLookupResult{Name: name, Type: recordType, Records: []DNSRecord{}}
+	var err error
+	switch recordType {
+	case "A", "AAAA":
+		var addrs []net.IPAddr
+		if addrs, err = s.resolver.LookupIPAddr(ctx, name); err == nil {
+			for _, addr := range addrs {
+				if (addr.IP.To4() != nil) == (recordType == "A") {
+					result.Records = append(result.Records, DNSRecord{Type: recordType, Value: addr.IP.String()})
+				}
+			}
+		}
+	case "MX":
+		var mxs []*net.MX
+		if mxs, err = s.resolver.LookupMX(ctx, name); err == nil {
+			for _, mx := range mxs {
+				priority := mx.Pref
+				result.Records = append(result.Records, DNSRecord{Type: "MX", Value: strings.TrimSuffix(mx.Host, "."), Priority: &priority})
+			}
+		}
+	case "TXT":
+		var txts []string
+		if txts, err = s.resolver.LookupTXT(ctx, name); err == nil {
+			for _, txt := range txts {
+				result.Records = append(result.Records, DNSRecord{Type: "TXT", Value: txt})
+			}
+		}
+	case "CNAME":
+		var cname string
+		// A name without a CNAME resolves to itself
+		if cname, err = s.resolver.LookupCNAME(ctx, name); err == nil && strings.TrimSuffix(cname, ".") != name {
+			result.Records = append(result.Records, DNSRecord{Type: "CNAME", Value: strings.TrimSuffix(cname, ".")})
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDNSType, recordType)
+	}
+
+	if err != nil && !isDNSNotFound(err) {
+		return nil, fmt.Errorf("%w: %v", ErrDNSLookup, err)
+	}
+	return result, nil
+}
+
+// LookupPTR returns the names an IP address resolves back to
+func (s *ToolsService) LookupPTR(ip string) (*DNSLookupResult, error) {
+	addr := net.ParseIP(strings.TrimSpace(ip))
+	if addr == nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidIP, ip)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+
+	result := &DNSLookupResult{Name: addr.String(), Type: "PTR", Records: []DNSRecord{}}
+	names, err := s.resolver.LookupAddr(ctx, addr.String())
+	if err != nil && !isDNSNotFound(err) {
+		return nil, fmt.Errorf("%w: %v", ErrDNSLookup, err)
+	}
+	for _, name := range names {
+		result.Records = append(result.Records, DNSRecord{Type: "PTR", Value: strings.TrimSuffix(name, ".")})
+	}
+	return result, nil
+}
+
+// CheckPort dials host:port over TCP and reports whether something accepted the
+// connection. timeout is capped at MaxPortCheckTimeout, zero uses
+// DefaultPortCheckTimeout. The host is resolved first and the resolved address
+// dialed, so the allowlist check cannot be sidestepped by DNS changing in between.
+func (s *ToolsService) CheckPort(host string, port int, timeout time.Duration) (*PortCheckResult, error) {
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+	if port < 1 || port > 65535 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidPort, port)
+	}
+	if timeout <= 0 {
+		timeout = DefaultPortCheckTimeout
+	}
+	timeout = min(timeout, MaxPortCheckTimeout)
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		if err := ValidateDomain(host); err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+		defer cancel()
+		addrs, err := s.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDNSLookup, err)
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("%w: %s has no addresses", ErrDNSLookup, host)
+		}
+		ip = addrs[0].IP
+	}
+	if !s.portCheckAllowed(host, ip) {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotAllowed, host)
+	}
+
+	result := &PortCheckResult{Host: host, Port: port, Address: net.JoinHostPort(ip.String(), fmt.Sprint(port))}
+	start := time.Now()
+	conn, err := s.dial("tcp", result.Address, timeout)
+	result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+
+	var netErr net.Error
+	switch {
+	case err == nil:
+		conn.Close()
+		result.Status = PortOpen
+	case errors.As(err, &netErr) && netErr.Timeout():
+		result.Status = PortTimeout
+		result.Error = err.Error()
+	default:
+		result.Status = PortClosed
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+// portCheckAllowed reports whether the allowlist names host or covers ip
+func (s *ToolsService) portCheckAllowed(host string, ip net.IP) bool {
+	if len(s.allowedHosts) == 0 && len(s.allowedNetworks) == 0 {
+		return true
+	}
+	for _, allowed := range s.allowedHosts {
+		if host == allowed {
+			return true
+		}
+	}
+	for _, network := range s.allowedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDNSNotFound reports whether err only says there is no such record, which
+// is an answer rather than a failure
+func isDNSNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
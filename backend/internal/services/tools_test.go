@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"r-panel/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver answers from fixed tables; unknown names are not found
+type fakeResolver struct {
+	ips    map[string][]net.IPAddr
+	mxs    map[string][]*net.MX
+	txts   map[string][]string
+	cnames map[string]string
+	ptrs   map[string][]string
+	err    error
+}
+
+func notFound(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if ips, ok := r.ips[host]; ok {
+		return ips, nil
+	}
+	return nil, notFound(host)
+}
+
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if mxs, ok := r.mxs[name]; ok {
+		return mxs, nil
+	}
+	return nil, notFound(name)
+}
+
+func (r *fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if txts, ok := r.txts[name]; ok {
+		return txts, nil
+	}
+	return nil, notFound(name)
+}
+
+func (r *fakeResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	if cname, ok := r.cnames[host]; ok {
+		return cname, nil
+	}
+	return host + ".", nil
+}
+
+func (r *fakeResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	if names, ok := r.ptrs[addr]; ok {
+		return names, nil
+	}
+	return nil, notFound(addr)
+}
+
+func TestToolsServiceLookupDNS(t *testing.T) {
+	service := NewToolsServiceWithResolver(&fakeResolver{
+		ips: map[string][]net.IPAddr{
+			"example.com": {{IP: net.ParseIP("192.0.2.10")}, {IP: net.ParseIP("2001:db8::10")}},
+		},
+		mxs: map[string][]*net.MX{
+			"example.com": {{Host: "mx1.example.com.", Pref: 10}, {Host: "mx2.example.com.", Pref: 20}},
+		},
+		txts:   map[string][]string{"_dmarc.example.com": {"v=DMARC1; p=none"}},
+		cnames: map[string]string{"www.example.com": "example.com."},
+		ptrs:   map[string][]string{"192.0.2.10": {"mail.example.com."}},
+	})
+
+	result, err := service.LookupDNS("example.com.", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", result.Name)
+	assert.Equal(t, []DNSRecord{{Type: "A", Value: "192.0.2.10"}}, result.Records)
+
+	result, err = service.LookupDNS("example.com", "AAAA")
+	require.NoError(t, err)
+	assert.Equal(t, []DNSRecord{{Type: "AAAA", Value: "2001:db8::10"}}, result.Records)
+
+	result, err = service.LookupDNS("example.com", "MX")
+	require.NoError(t, err)
+	require.Len(t, result.Records, 2)
+	assert.Equal(t, "mx1.example.com", result.Records[0].Value)
+	assert.Equal(t, uint16(20), *result.Records[1].Priority)
+
+	result, err = service.LookupDNS("_dmarc.example.com", "TXT")
+	require.NoError(t, err)
+	assert.Equal(t, "v=DMARC1; p=none", result.Records[0].Value)
+
+	result, err = service.LookupDNS("www.example.com", "CNAME")
+	require.NoError(t, err)
+	assert.Equal(t, []DNSRecord{{Type: "CNAME", Value: "example.com"}}, result.Records)
+	result, err = service.LookupDNS("example.com", "CNAME")
+	require.NoError(t, err)
+	assert.Empty(t, result.Records)
+
+	// A missing name is an empty answer, not an error
+	result, err = service.LookupDNS("missing.example.com", "MX")
+	require.NoError(t, err)
+	assert.Empty(t, result.Records)
+
+	_, err = service.LookupDNS("example.com", "SRV")
+	assert.ErrorIs(t, err, ErrInvalidDNSType)
+	_, err = service.LookupDNS("bad name", "A")
+	assert.ErrorIs(t, err, ErrInvalidDomain)
+
+	result, err = service.LookupPTR("192.0.2.10")
+	require.NoError(t, err)
+	assert.Equal(t, []DNSRecord{{Type: "PTR", Value: "mail.example.com"}}, result.Records)
+	result, err = service.LookupPTR("192.0.2.99")
+	require.NoError(t, err)
+	assert.Empty(t, result.Records)
+	_, err = service.LookupPTR("not-an-ip")
+	assert.ErrorIs(t, err, ErrInvalidIP)
+}
+
+func TestToolsServiceLookupDNSFailure(t *testing.T) {
+	service := NewToolsServiceWithResolver(&fakeResolver{err: errors.New("i/o timeout")})
+	_, err := service.LookupDNS("example.com", "A")
+	assert.ErrorIs(t, err, ErrDNSLookup)
+}
+
+// timeoutError is the net.Error a dial that ran out of time fails with
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestToolsServiceCheckPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	service := NewToolsServiceWithResolver(&fakeResolver{
+		ips: map[string][]net.IPAddr{"panel.example.com": {{IP: net.ParseIP("127.0.0.1")}}},
+	})
+
+	result, err := service.CheckPort("panel.example.com", port, 0)
+	require.NoError(t, err)
+	assert.Equal(t, PortOpen, result.Status)
+	assert.Equal(t, listener.Addr().String(), result.Address)
+	assert.Empty(t, result.Error)
+
+	var dialTimeout time.Duration
+	service.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		dialTimeout = timeout
+		return nil, &net.OpError{Op: "dial", Net: network, Err: timeoutError{}}
+	}
+	result, err = service.CheckPort("192.0.2.1", 443, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, PortTimeout, result.Status)
+	assert.Equal(t, MaxPortCheckTimeout, dialTimeout, "the timeout is capped")
+
+	service.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+	}
+	result, err = service.CheckPort("192.0.2.1", 443, 0)
+	require.NoError(t, err)
+	assert.Equal(t, PortClosed, result.Status)
+	assert.Contains(t, result.Error, "connection refused")
+
+	_, err = service.CheckPort("192.0.2.1", 70000, 0)
+	assert.ErrorIs(t, err, ErrInvalidPort)
+	_, err = service.CheckPort("missing.example.com", 80, 0)
+	assert.ErrorIs(t, err, ErrDNSLookup)
+}
+
+func TestToolsServiceCheckPortAllowlist(t *testing.T) {
+	service := NewToolsServiceWithResolver(&fakeResolver{
+		ips: map[string][]net.IPAddr{
+			"panel.example.com":  {{IP: net.ParseIP("203.0.113.5")}},
+			"inside.example.com": {{IP: net.ParseIP("10.1.2.3")}},
+			"other.example.com":  {{IP: net.ParseIP("198.51.100.7")}},
+		},
+	})
+	service.allowedHosts, service.allowedNetworks, _ = config.ToolsConfig{
+		PortCheckAllowedHosts: []string{"panel.example.com", "10.0.0.0/8"},
+	}.PortCheckAllowlist()
+	service.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	for _, host := range []string{"panel.example.com", "inside.example.com", "10.9.9.9"} {
+		_, err := service.CheckPort(host, 80, 0)
+		assert.NoError(t, err, host)
+	}
+	for _, host := range []string{"other.example.com", "127.0.0.1", "169.254.169.254"} {
+		_, err := service.CheckPort(host, 80, 0)
+		assert.ErrorIs(t, err, ErrHostNotAllowed, host)
+	}
+}
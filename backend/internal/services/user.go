@@ -8,6 +8,8 @@ import (
 	"gorm.io/gorm"
 )
 
+var ErrInvalidRole = errors.New("invalid role: use admin, user or readonly")
+
 type UserService struct {
 	authService *AuthService
 }
@@ -49,6 +51,10 @@ func (s *UserService) GetUser(id uint) (*models.User, error) {
 
 // CreateUser creates a new user
 func (s *UserService) CreateUser(username, password, role string) (*models.User, error) {
+	if !models.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
 	user, err := s.authService.CreateUser(username, password, role)
 	if err != nil {
 		return nil, err
@@ -60,6 +66,10 @@ func (s *UserService) CreateUser(username, password, role string) (*models.User,
 
 // UpdateUser updates user information (except password)
 func (s *UserService) UpdateUser(id uint, username, role string) (*models.User, error) {
+	if role != "" && !models.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
 	var user models.User
 	if err := models.DB.First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -68,16 +78,18 @@ func (s *UserService) UpdateUser(id uint, username, role string) (*models.User,
 		return nil, err
 	}
 
-	// Check if username is taken by another user
-	if username != user.Username {
+	// Check if username is taken by another user, an empty one is left as is
+	if username = NormalizeUsername(username); username != "" {
 		var existingUser models.User
-		if err := models.DB.Where("username = ? AND id != ?", username, id).First(&existingUser).Error; err == nil {
+		if err := models.DB.Where("LOWER(username) = ? AND id != ?", username, id).First(&existingUser).Error; err == nil {
 			return nil, ErrUserExists
 		}
+		user.Username = username
 	}
 
-	user.Username = username
-	user.Role = role
+	if role != "" {
+		user.Role = role
+	}
 
 	if err := models.DB.Save(&user).Error; err != nil {
 		return nil, err
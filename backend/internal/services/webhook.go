@@ -0,0 +1,367 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrInvalidWebhookURL   = errors.New("invalid webhook URL: use an absolute http or https URL")
+	ErrInvalidWebhookEvent = errors.New("invalid webhook event")
+)
+
+// WebhookEvent is a panel event webhooks can subscribe to
+type WebhookEvent string
+
+const (
+	WebhookClientCreated   WebhookEvent = "client.created"
+	WebhookClientDeleted   WebhookEvent = "client.deleted"
+	WebhookBackupCompleted WebhookEvent = "backup.completed"
+	WebhookServiceDown     WebhookEvent = "service.down"
+	WebhookCertExpiring    WebhookEvent = "cert.expiring"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to
+var WebhookEvents = []WebhookEvent{WebhookClientCreated, WebhookClientDeleted, WebhookBackupCompleted, WebhookServiceDown, WebhookCertExpiring}
+
+// Headers sent with every delivery. The signature is "sha256=" followed by the
+// hex HMAC-SHA256 of the request body keyed with the webhook secret.
+const (
+	WebhookSignatureHeader = "X-RPanel-Signature"
+	WebhookEventHeader     = "X-RPanel-Event"
+	WebhookDeliveryHeader  = "X-RPanel-Delivery"
+)
+
+const (
+	webhookTimeout           = 10 * time.Second
+	defaultWebhookRetryDelay = time.Second
+	maxWebhookResponseBody   = 1024
+)
+
+// WebhookPayload is the JSON body POSTed to subscribed webhooks
+type WebhookPayload struct {
+	ID        string       `json:"id"`
+	Event     WebhookEvent `json:"event"`
+	Timestamp time.Time    `json:"timestamp"`
+	Data      interface{}  `json:"data"`
+}
+
+// ClientEvent is the webhook data sent for client.created and client.deleted
+type ClientEvent struct {
+	ClientID    uint   `json:"client_id"`
+	CustomerNo  string `json:"customer_no"`
+	Username    string `json:"username,omitempty"`
+	CompanyName string `json:"company_name,omitempty"`
+	Email       string `json:"email,omitempty"`
+	Purged      bool   `json:"purged,omitempty"` // client.deleted only: removed for good instead of moved to the trash
+}
+
+// NewClientEvent returns the webhook data describing client
+func NewClientEvent(client *models.Client) ClientEvent {
+	return ClientEvent{
+		ClientID:    client.ID,
+		CustomerNo:  client.CustomerNo,
+		Username:    client.User.Username,
+		CompanyName: client.CompanyName,
+		Email:       client.Email,
+	}
+}
+
+// BackupEvent is the webhook data sent for backup.completed
+type BackupEvent struct {
+	Type   string `json:"type"` // file, database or client
+	Source string `json:"source"`
+	Path   string `json:"path"`
+}
+
+// WebhookData holds the fields of a webhook that can be created or updated
+type WebhookData struct {
+	Name   string
+	URL    string
+	Secret string // generated when empty on create, kept when empty on update
+	Events []string
+	Active *bool
+}
+
+type WebhookService struct {
+	client      *http.Client
+	maxAttempts int
+	retryDelay  time.Duration
+	pending     sync.WaitGroup
+}
+
+func NewWebhookService(cfg *config.Config) *WebhookService {
+	maxAttempts := cfg.Webhooks.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = config.DefaultWebhookMaxAttempts
+	}
+	return &WebhookService{
+		client:      &http.Client{Timeout: webhookTimeout},
+		maxAttempts: maxAttempts,
+		retryDelay:  defaultWebhookRetryDelay,
+	}
+}
+
+// GetWebhooks returns all webhooks
+func (s *WebhookService) GetWebhooks() ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := models.DB.Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns a webhook by ID
+func (s *WebhookService) GetWebhook(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := models.DB.First(&webhook, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// CreateWebhook creates a webhook, generating a signing secret unless one is given
+func (s *WebhookService) CreateWebhook(data *WebhookData) (*models.Webhook, error) {
+	if err := validateWebhook(data.URL, data.Events); err != nil {
+		return nil, err
+	}
+
+	secret := data.Secret
+	if secret == "" {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+		secret = hex.EncodeToString(raw)
+	}
+
+	webhook := &models.Webhook{
+		Name:   data.Name,
+		URL:    data.URL,
+		Secret: secret,
+		Events: models.StringArray(data.Events),
+		Active: data.Active == nil || *data.Active,
+	}
+	if err := models.DB.Create(webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook replaces the settings of a webhook
+func (s *WebhookService) UpdateWebhook(id uint, data *WebhookData) (*models.Webhook, error) {
+	webhook, err := s.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhook(data.URL, data.Events); err != nil {
+		return nil, err
+	}
+
+	webhook.Name = data.Name
+	webhook.URL = data.URL
+	webhook.Events = models.StringArray(data.Events)
+	if data.Secret != "" {
+		webhook.Secret = data.Secret
+	}
+	if data.Active != nil {
+		webhook.Active = *data.Active
+	}
+
+	if err := models.DB.Save(webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook and its dead letters
+func (s *WebhookService) DeleteWebhook(id uint) error {
+	if _, err := s.GetWebhook(id); err != nil {
+		return err
+	}
+	return models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDeadLetter{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Webhook{}, id).Error
+	})
+}
+
+// GetDeadLetters returns the failed deliveries of a webhook, newest first
+func (s *WebhookService) GetDeadLetters(webhookID uint) ([]models.WebhookDeadLetter, error) {
+	if _, err := s.GetWebhook(webhookID); err != nil {
+		return nil, err
+	}
+	var letters []models.WebhookDeadLetter
+	if err := models.DB.Where("webhook_id = ?", webhookID).Order("id DESC").Find(&letters).Error; err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+// Dispatch delivers event to every active webhook subscribed to it. Deliveries
+// run in the background; failures are retried with backoff and recorded as dead
+// letters once every attempt failed.
+func (s *WebhookService) Dispatch(event WebhookEvent, data interface{}) {
+	if models.DB == nil {
+		return
+	}
+
+	var webhooks []models.Webhook
+	if err := models.DB.Where("active = ?", true).Find(&webhooks).Error; err != nil {
+		log.Printf("Webhooks: failed to load webhooks for %s: %v", event, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !subscribedTo(webhook, event) {
+			continue
+		}
+
+		payload := WebhookPayload{
+			ID:        newDeliveryID(),
+			Event:     event,
+			Timestamp: time.Now().UTC(),
+			Data:      data,
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			log.Printf("Webhooks: failed to encode %s payload: %v", event, err)
+			return
+		}
+
+		s.pending.Add(1)
+		go func(webhook models.Webhook) {
+			defer s.pending.Done()
+			s.deliver(webhook, payload, body)
+		}(webhook)
+	}
+}
+
+// Wait blocks until every delivery started by Dispatch has finished
+func (s *WebhookService) Wait() {
+	s.pending.Wait()
+}
+
+// deliver POSTs body to webhook until it succeeds or the attempts run out
+func (s *WebhookService) deliver(webhook models.Webhook, payload WebhookPayload, body []byte) {
+	delay := s.retryDelay
+	var err error
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		if err = s.post(webhook, payload, body); err == nil {
+			return
+		}
+		if attempt < s.maxAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	log.Printf("Webhooks: giving up on %s delivery %s to webhook %d after %d attempts: %v",
+		payload.Event, payload.ID, webhook.ID, s.maxAttempts, err)
+	letter := &models.WebhookDeadLetter{
+		WebhookID:  webhook.ID,
+		DeliveryID: payload.ID,
+		Event:      string(payload.Event),
+		Payload:    string(body),
+		Attempts:   s.maxAttempts,
+		LastError:  err.Error(),
+	}
+	if err := models.DB.Create(letter).Error; err != nil {
+		log.Printf("Webhooks: failed to record dead letter for delivery %s: %v", payload.ID, err)
+	}
+}
+
+func (s *WebhookService) post(webhook models.Webhook, payload WebhookPayload, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "R-Panel-Webhook/1.0")
+	req.Header.Set(WebhookEventHeader, string(payload.Event))
+	req.Header.Set(WebhookDeliveryHeader, payload.ID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(snippet))
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the signature header value for body
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func validateWebhook(rawURL string, events []string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("%w: subscribe to at least one event", ErrInvalidWebhookEvent)
+	}
+	for _, event := range events {
+		if !knownWebhookEvent(event) {
+			return fmt.Errorf("%w: %q", ErrInvalidWebhookEvent, event)
+		}
+	}
+	return nil
+}
+
+func knownWebhookEvent(event string) bool {
+	for _, known := range WebhookEvents {
+		if string(known) == event {
+			return true
+		}
+	}
+	return false
+}
+
+func subscribedTo(webhook models.Webhook, event WebhookEvent) bool {
+	for _, subscribed := range webhook.Events {
+		if subscribed == string(event) {
+			return true
+		}
+	}
+	return false
+}
+
+func newDeliveryID() string {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(raw)
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"r-panel/internal/config"
+	"r-panel/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// newWebhookReceiver records deliveries and answers with the next queued status, 200 once the queue is empty
+func newWebhookReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []receivedWebhook) {
+	t.Helper()
+	var mu sync.Mutex
+	var received []receivedWebhook
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, receivedWebhook{header: r.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []receivedWebhook {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedWebhook(nil), received...)
+	}
+}
+
+func newTestWebhookService(cfg *config.Config) *WebhookService {
+	service := NewWebhookService(cfg)
+	service.retryDelay = time.Millisecond
+	return service
+}
+
+func TestWebhookServiceCRUDValidation(t *testing.T) {
+	cfg := setupTestDB(t)
+	service := newTestWebhookService(cfg)
+
+	_, err := service.CreateWebhook(&WebhookData{URL: "ftp://example.com/hook", Events: []string{"client.created"}})
+	assert.ErrorIs(t, err, ErrInvalidWebhookURL)
+	_, err = service.CreateWebhook(&WebhookData{URL: "/relative", Events: []string{"client.created"}})
+	assert.ErrorIs(t, err, ErrInvalidWebhookURL)
+	_, err = service.CreateWebhook(&WebhookData{URL: "https://example.com/hook", Events: []string{"client.exploded"}})
+	assert.ErrorIs(t, err, ErrInvalidWebhookEvent)
+	_, err = service.CreateWebhook(&WebhookData{URL: "https://example.com/hook"})
+	assert.ErrorIs(t, err, ErrInvalidWebhookEvent)
+
+	inactive := false
+	webhook, err := service.CreateWebhook(&WebhookData{
+		Name:   "Slack",
+		URL:    "https://example.com/hook",
+		Events: []string{"client.created"},
+		Active: &inactive,
+	})
+	require.NoError(t, err)
+	assert.Len(t, webhook.Secret, 64, "secret is generated")
+
+	stored, err := service.GetWebhook(webhook.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Active)
+	assert.Equal(t, models.StringArray{"client.created"}, stored.Events)
+
+	updated, err := service.UpdateWebhook(webhook.ID, &WebhookData{
+		URL:    "https://example.com/other",
+		Events: []string{"backup.completed", "service.down"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, webhook.Secret, updated.Secret, "empty secret keeps the current one")
+	assert.False(t, updated.Active, "active is unchanged when omitted")
+
+	require.NoError(t, service.DeleteWebhook(webhook.ID))
+	_, err = service.GetWebhook(webhook.ID)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+	assert.ErrorIs(t, service.DeleteWebhook(webhook.ID), ErrWebhookNotFound)
+}
+
+func TestWebhookServiceDispatchSignsPayload(t *testing.T) {
+	cfg := setupTestDB(t)
+	service := newTestWebhookService(cfg)
+	server, received := newWebhookReceiver(t)
+
+	subscribed, err := service.CreateWebhook(&WebhookData{
+		URL:    server.URL,
+		Secret: "s3cret",
+		Events: []string{"client.created", "client.deleted"},
+	})
+	require.NoError(t, err)
+	_, err = service.CreateWebhook(&WebhookData{URL: server.URL + "/other", Events: []string{"backup.completed"}})
+	require.NoError(t, err)
+	inactive := false
+	_, err = service.CreateWebhook(&WebhookData{URL: server.URL + "/off", Events: []string{"client.created"}, Active: &inactive})
+	require.NoError(t, err)
+
+	service.Dispatch(WebhookClientCreated, ClientEvent{ClientID: 7, CustomerNo: "C0007"})
+	service.Wait()
+
+	deliveries := received()
+	require.Len(t, deliveries, 1, "only the active subscribed webhook is called")
+	delivery := deliveries[0]
+
+	assert.Equal(t, "application/json", delivery.header.Get("Content-Type"))
+	assert.Equal(t, "client.created", delivery.header.Get(WebhookEventHeader))
+	assert.Equal(t, SignWebhookPayload("s3cret", delivery.body), delivery.header.Get(WebhookSignatureHeader))
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, delivery.header.Get(WebhookSignatureHeader))
+
+	var payload struct {
+		ID    string      `json:"id"`
+		Event string      `json:"event"`
+		Data  ClientEvent `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(delivery.body, &payload))
+	assert.Equal(t, delivery.header.Get(WebhookDeliveryHeader), payload.ID)
+	assert.Equal(t, "client.created", payload.Event)
+	assert.Equal(t, ClientEvent{ClientID: 7, CustomerNo: "C0007"}, payload.Data)
+
+	letters, err := service.GetDeadLetters(subscribed.ID)
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+}
+
+func TestWebhookServiceRetriesAndDeadLetters(t *testing.T) {
+	cfg := setupTestDB(t)
+	cfg.Webhooks.MaxAttempts = 3
+	service := newTestWebhookService(cfg)
+
+	t.Run("succeeds after failures", func(t *testing.T) {
+		server, received := newWebhookReceiver(t, 500, 502)
+		webhook, err := service.CreateWebhook(&WebhookData{URL: server.URL, Events: []string{"backup.completed"}})
+		require.NoError(t, err)
+
+		service.Dispatch(WebhookBackupCompleted, BackupEvent{Type: "file", Source: "/home/site", Path: "/backups/site.tar.gz"})
+		service.Wait()
+
+		deliveries := received()
+		require.Len(t, deliveries, 3)
+		assert.Equal(t, deliveries[0].header.Get(WebhookDeliveryHeader), deliveries[2].header.Get(WebhookDeliveryHeader),
+			"retries reuse the delivery ID")
+
+		letters, err := service.GetDeadLetters(webhook.ID)
+		require.NoError(t, err)
+		assert.Empty(t, letters)
+		require.NoError(t, service.DeleteWebhook(webhook.ID))
+	})
+
+	t.Run("dead letter after every attempt failed", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		webhook, err := service.CreateWebhook(&WebhookData{URL: server.URL, Events: []string{"service.down"}})
+		require.NoError(t, err)
+
+		service.Dispatch(WebhookServiceDown, ServiceDownEvent{Service: "nginx", Status: "failed"})
+		service.Wait()
+		assert.Equal(t, int32(3), calls.Load())
+
+		letters, err := service.GetDeadLetters(webhook.ID)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.Equal(t, "service.down", letters[0].Event)
+		assert.Equal(t, 3, letters[0].Attempts)
+		assert.Contains(t, letters[0].LastError, "503")
+		assert.Contains(t, letters[0].LastError, "maintenance")
+		assert.Contains(t, letters[0].Payload, `"service":"nginx"`)
+
+		// Dead letters go with their webhook
+		require.NoError(t, service.DeleteWebhook(webhook.ID))
+		var count int64
+		require.NoError(t, models.DB.Model(&models.WebhookDeadLetter{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
+
+func TestServiceWatcherReportsServicesThatStop(t *testing.T) {
+	runner := newFakeCommandRunner()
+	runner.on("systemctl is-active nginx", "active\n", nil)
+	runner.on("systemctl is-active mariadb", "", errors.New("exit status 4"))
+
+	var events []ServiceDownEvent
+	watcher := &ServiceWatcher{
+		system:   NewSystemServiceWithDeps(newFakeFileSystem(), runner),
+		services: []string{"nginx", "mariadb"},
+		active:   map[string]bool{},
+		dispatch: func(event WebhookEvent, data interface{}) {
+			require.Equal(t, WebhookServiceDown, event)
+			events = append(events, data.(ServiceDownEvent))
+		},
+	}
+
+	watcher.Check(context.Background())
+	assert.Empty(t, events, "services never seen running are not reported")
+
+	runner.on("systemctl is-active nginx", "failed\n", errors.New("exit status 3"))
+	watcher.Check(context.Background())
+	watcher.Check(context.Background())
+	assert.Equal(t, []ServiceDownEvent{{Service: "nginx", Status: "inactive"}}, events, "reported once per outage")
+
+	runner.on("systemctl is-active nginx", "active\n", nil)
+	watcher.Check(context.Background())
+	runner.on("systemctl is-active nginx", "failed\n", errors.New("exit status 3"))
+	watcher.Check(context.Background())
+	assert.Len(t, events, 2)
+}
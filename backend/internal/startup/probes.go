@@ -0,0 +1,54 @@
+package startup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"r-panel/internal/models"
+	"r-panel/internal/services"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// DatabaseProbe checks that the panel database answers a ping
+func DatabaseProbe() Probe {
+	return func(ctx context.Context) error {
+		if models.DB == nil {
+			return errors.New("database not initialized")
+		}
+		sqlDB, err := models.DB.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// MySQLProbe checks that the MySQL server of dsn answers a ping, over a
+// connection of its own that is closed again
+func MySQLProbe(dsn string) Probe {
+	return func(ctx context.Context) error {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return db.PingContext(ctx)
+	}
+}
+
+// NginxProbe checks that systemd reports nginx as running
+func NginxProbe(system *services.SystemService) Probe {
+	return func(ctx context.Context) error {
+		status, err := system.GetServiceStatus(ctx, "nginx")
+		if err != nil {
+			return err
+		}
+		if !status.Active {
+			return fmt.Errorf("nginx is %s", status.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,174 @@
+// Package startup starts background workers in dependency order. Each worker
+// names the dependencies it needs; it is started as soon as all of them have
+// been probed healthy, and failed dependencies are retried in the background.
+package startup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryInterval    = 2 * time.Second
+	defaultMaxRetryInterval = time.Minute
+	probeTimeout            = 5 * time.Second
+)
+
+// Probe returns nil once a dependency is ready to use
+type Probe func(ctx context.Context) error
+
+// Worker is a background task started once its dependencies are healthy.
+// Start must not block; long-running workers start their own goroutine.
+type Worker struct {
+	Name     string
+	Requires []string
+	Start    func(ctx context.Context)
+}
+
+type dependency struct {
+	name  string
+	probe Probe
+	ready chan struct{}
+}
+
+// Orchestrator probes dependencies and starts workers once they are healthy
+type Orchestrator struct {
+	// RetryInterval is the first delay between probes of an unhealthy
+	// dependency; it doubles up to MaxRetryInterval
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+	// Logf receives the startup sequence, log.Printf by default
+	Logf func(format string, args ...interface{})
+
+	deps    []*dependency
+	byName  map[string]*dependency
+	workers []Worker
+}
+
+func New() *Orchestrator {
+	return &Orchestrator{
+		RetryInterval:    defaultRetryInterval,
+		MaxRetryInterval: defaultMaxRetryInterval,
+		Logf:             log.Printf,
+		byName:           map[string]*dependency{},
+	}
+}
+
+// AddDependency registers a named dependency checked by probe
+func (o *Orchestrator) AddDependency(name string, probe Probe) {
+	dep := &dependency{name: name, probe: probe, ready: make(chan struct{})}
+	o.deps = append(o.deps, dep)
+	o.byName[name] = dep
+}
+
+// AddWorker registers a worker; workers with the same dependencies start in registration order
+func (o *Orchestrator) AddWorker(worker Worker) {
+	o.workers = append(o.workers, worker)
+}
+
+// Start probes every dependency once, starts the workers whose dependencies are
+// healthy and leaves the others waiting while their dependencies are retried in
+// the background until ctx is cancelled. It fails only if a worker requires an
+// unknown dependency, before anything is started.
+func (o *Orchestrator) Start(ctx context.Context) error {
+	for _, worker := range o.workers {
+		for _, name := range worker.Requires {
+			if _, ok := o.byName[name]; !ok {
+				return fmt.Errorf("worker %s requires unknown dependency %s", worker.Name, name)
+			}
+		}
+	}
+
+	for _, dep := range o.deps {
+		if err := o.probe(ctx, dep); err != nil {
+			o.Logf("Startup: %s unavailable: %v, retrying in background", dep.name, err)
+			go o.retry(ctx, dep)
+			continue
+		}
+		o.Logf("Startup: %s healthy", dep.name)
+		close(dep.ready)
+	}
+
+	// Workers that can start now do so in order; the rest wait for their dependencies
+	var mu sync.Mutex
+	for _, worker := range o.workers {
+		waiting := o.waitingFor(worker)
+		if len(waiting) == 0 {
+			o.startWorker(ctx, &mu, worker)
+			continue
+		}
+
+		o.Logf("Startup: %s waiting for %s", worker.Name, strings.Join(waiting, ", "))
+		go func(worker Worker) {
+			for _, name := range worker.Requires {
+				select {
+				case <-o.byName[name].ready:
+				case <-ctx.Done():
+					return
+				}
+			}
+			o.startWorker(ctx, &mu, worker)
+		}(worker)
+	}
+
+	return nil
+}
+
+// waitingFor returns the dependencies of worker that are not healthy yet
+func (o *Orchestrator) waitingFor(worker Worker) []string {
+	var waiting []string
+	for _, name := range worker.Requires {
+		select {
+		case <-o.byName[name].ready:
+		default:
+			waiting = append(waiting, name)
+		}
+	}
+	return waiting
+}
+
+// startWorker starts one worker at a time so the logged sequence matches the real one
+func (o *Orchestrator) startWorker(ctx context.Context, mu *sync.Mutex, worker Worker) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+	o.Logf("Startup: starting %s", worker.Name)
+	worker.Start(ctx)
+}
+
+// retry probes dep with backoff until it is healthy or ctx is cancelled
+func (o *Orchestrator) retry(ctx context.Context, dep *dependency) {
+	interval := o.RetryInterval
+	for attempt := 2; ; attempt++ {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+
+		if err := o.probe(ctx, dep); err != nil {
+			interval *= 2
+			if interval > o.MaxRetryInterval {
+				interval = o.MaxRetryInterval
+			}
+			continue
+		}
+
+		o.Logf("Startup: %s healthy after %d attempts", dep.name, attempt)
+		close(dep.ready)
+		return
+	}
+}
+
+func (o *Orchestrator) probe(ctx context.Context, dep *dependency) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	return dep.probe(ctx)
+}
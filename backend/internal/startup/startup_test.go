@@ -0,0 +1,148 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"r-panel/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects the order in which workers were started
+type recorder struct {
+	mu      sync.Mutex
+	started []string
+}
+
+func (r *recorder) worker(name string, requires ...string) Worker {
+	return Worker{
+		Name:     name,
+		Requires: requires,
+		Start: func(ctx context.Context) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.started = append(r.started, name)
+		},
+	}
+}
+
+func (r *recorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.started...)
+}
+
+func newTestOrchestrator(logs *[]string) *Orchestrator {
+	o := New()
+	o.RetryInterval = 5 * time.Millisecond
+	o.MaxRetryInterval = 20 * time.Millisecond
+	var mu sync.Mutex
+	o.Logf = func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		*logs = append(*logs, fmt.Sprintf(format, args...))
+	}
+	return o
+}
+
+func TestOrchestratorStartsWorkersOnceDependenciesAreHealthy(t *testing.T) {
+	var logs []string
+	o := newTestOrchestrator(&logs)
+
+	// mysql comes up a little after the panel
+	healthyAt := time.Now().Add(50 * time.Millisecond)
+	var probes atomic.Int32
+	o.AddDependency("database", func(ctx context.Context) error { return nil })
+	o.AddDependency("mysql", func(ctx context.Context) error {
+		probes.Add(1)
+		if time.Now().Before(healthyAt) {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	rec := &recorder{}
+	o.AddWorker(rec.worker("backup scheduler", "database", "mysql"))
+	o.AddWorker(rec.worker("session cleanup", "database"))
+	o.AddWorker(rec.worker("metrics sampler"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, o.Start(ctx))
+
+	// Workers with healthy dependencies start synchronously, in registration order
+	assert.Equal(t, []string{"session cleanup", "metrics sampler"}, rec.snapshot())
+
+	require.Eventually(t, func() bool { return len(rec.snapshot()) == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "backup scheduler", rec.snapshot()[2])
+	assert.False(t, time.Now().Before(healthyAt), "worker started before its dependency was healthy")
+	assert.Greater(t, probes.Load(), int32(1), "unhealthy dependency was not retried")
+
+	assert.Contains(t, logs, "Startup: mysql unavailable: connection refused, retrying in background")
+	assert.Contains(t, logs, "Startup: backup scheduler waiting for mysql")
+}
+
+func TestOrchestratorCancelStopsWaitingWorkers(t *testing.T) {
+	var logs []string
+	o := newTestOrchestrator(&logs)
+	o.AddDependency("mysql", func(ctx context.Context) error { return errors.New("down") })
+
+	rec := &recorder{}
+	o.AddWorker(rec.worker("backup scheduler", "mysql"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, o.Start(ctx))
+	cancel()
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, rec.snapshot())
+}
+
+func TestOrchestratorRejectsUnknownDependency(t *testing.T) {
+	var logs []string
+	o := newTestOrchestrator(&logs)
+
+	rec := &recorder{}
+	o.AddWorker(rec.worker("metrics sampler"))
+	o.AddWorker(rec.worker("backup scheduler", "missing"))
+
+	err := o.Start(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing")
+	assert.Empty(t, rec.snapshot(), "nothing may start when the graph is invalid")
+}
+
+// systemctl answers is-active with output, failing when err is set
+type systemctl struct {
+	output string
+	err    error
+}
+
+func (s systemctl) Run(ctx context.Context, name string, args ...string) error { return s.err }
+func (s systemctl) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return []byte(s.output), s.err
+}
+func (s systemctl) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return []byte(s.output), s.err
+}
+
+func TestNginxProbe(t *testing.T) {
+	probe := func(runner systemctl) error {
+		return NginxProbe(services.NewSystemServiceWithDeps(nil, runner))(context.Background())
+	}
+	assert.NoError(t, probe(systemctl{output: "active\n"}))
+	assert.EqualError(t, probe(systemctl{output: "activating\n"}), "nginx is activating")
+	assert.EqualError(t, probe(systemctl{err: errors.New("exit status 3")}), "nginx is inactive")
+}
+
+func TestMySQLProbe(t *testing.T) {
+	// Nothing listens on port 1
+	assert.Error(t, MySQLProbe("panel@tcp(127.0.0.1:1)/")(context.Background()))
+}
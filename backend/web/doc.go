@@ -0,0 +1,4 @@
+// Package web holds the built frontend in dist. Building with the
+// embed_frontend tag after the frontend build puts it inside the binary, so it
+// can serve the UI without a dist directory next to it.
+package web
@@ -0,0 +1,10 @@
+//go:build !embed_frontend
+
+package web
+
+import "io/fs"
+
+// Embedded returns the frontend built into the binary, this binary has none
+func Embedded() (fs.FS, bool) {
+	return nil, false
+}
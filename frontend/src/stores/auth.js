@@ -32,6 +32,20 @@ export const useAuthStore = defineStore('auth', () => {
     }
   }
 
+  async function changeInitialPassword(username, currentPassword, newPassword) {
+    try {
+      const response = await api.post('/auth/change-password', {
+        username,
+        current_password: currentPassword,
+        new_password: newPassword,
+      })
+      setAuth(response.data.user, response.data.token)
+      return response.data
+    } catch (error) {
+      throw error.response?.data || error
+    }
+  }
+
   async function logout() {
     try {
       await api.post('/auth/logout')
@@ -69,6 +83,7 @@ export const useAuthStore = defineStore('auth', () => {
     token,
     isAuthenticated,
     login,
+    changeInitialPassword,
     logout,
     getMe,
     clearAuth,
@@ -2671,7 +2671,7 @@ display_info() {
         echo "  ⚠  IMPORTANT: Change this password on first login!"
     else
         echo "  ⚠  Admin password may need manual configuration"
-        echo "     Default: username=admin, password=changeme"
+        echo "     Without a default_user password, create the first admin in the setup wizard"
     fi
     echo ""
     if [ -f /tmp/r-panel-mysql-password.txt ] || [ -f /tmp/r-panel-admin-password.txt ]; then